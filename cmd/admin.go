@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cnfs/common"
+	"cnfs/config"
+	db "cnfs/db/sqlc"
+	"cnfs/handler"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// the shortest password the API accepts
+const minPasswordLength = 8
+
+// openStore opens the database of the configuration for the commands that work on its content.
+func openStore(cfg *config.Config) (db.Store, error) {
+	if handler.IsMemoryDatabase(cfg) {
+		return nil, errMemoryDatabase
+	}
+	return handler.OpenStore(cfg)
+}
+
+func createAdminCommand(cfg *config.Config, args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := flags.String("username", "", "the username of the account")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("create-admin needs a -username")
+	}
+
+	fmt.Fprint(out, "password: ")
+	password, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	fmt.Fprintln(out)
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	userID, err := createAdmin(context.Background(), store, *username, strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "created moderator %s (%s)\n", *username, userID)
+	return nil
+}
+
+// createAdmin creates a moderator account with its default identity.
+func createAdmin(ctx context.Context, store db.Store, username, password string) (uuid.UUID, error) {
+	if len(password) < minPasswordLength {
+		return uuid.Nil, fmt.Errorf("the password must have at least %d characters", minPasswordLength)
+	}
+
+	hashedPassword, err := common.HashPassword(password)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID := uuid.New()
+	err = store.ExecTx(ctx, func(q db.Querier) error {
+		if _, err := q.CreateUser(ctx, db.CreateUserParams{
+			ID:        userID,
+			Username:  username,
+			Password:  hashedPassword,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+				return fmt.Errorf("the username %s is taken", username)
+			}
+			return err
+		}
+
+		if _, err := q.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+			ID:           uuid.New(),
+			UserID:       userID,
+			IdentityHash: uuid.New(),
+			Name:         "default",
+			IsDefault:    true,
+		}); err != nil {
+			return err
+		}
+
+		_, err := q.SetUserModerator(ctx, db.SetUserModeratorParams{IsModerator: true, ID: userID})
+		return err
+	})
+
+	return userID, err
+}
+
+func pruneSessionsCommand(cfg *config.Config, out io.Writer) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	n, err := store.DeleteExpiredSessions(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "deleted %d expired sessions\n", n)
+	return nil
+}
@@ -1,17 +1,77 @@
+// Command cnfs runs the API server and the tasks that look after its database.
+//
+//	cnfs [serve]                      run the API server
+//	cnfs migrate up [N]               apply the pending migrations, or the next N of them
+//	cnfs migrate down [N]             revert the last N migrations, 1 by default
+//	cnfs migrate status               list the migrations and whether they are applied
+//	cnfs migrate force VERSION        record VERSION as applied and clean, after a migration failed halfway
+//	cnfs create-admin -username NAME  create a moderator account, the password is read from stdin
+//	cnfs prune-sessions               delete the expired sessions
+//	cnfs seed [-users N] [-posts N]   fill the database with sample users and content
+//
+// The configuration is read from app.env and the environment, as for serve.
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"cnfs/config"
 	"cnfs/handler"
 
 	"github.com/labstack/gommon/log"
 )
 
+const usage = `usage: cnfs <command> [arguments]
+
+commands:
+  serve                          run the API server, the default
+  migrate up [N]                 apply the pending migrations, or the next N of them
+  migrate down [N]               revert the last N migrations, 1 by default
+  migrate status                 list the migrations and whether they are applied
+  migrate force VERSION          record VERSION as applied and clean, after a migration failed halfway
+  create-admin -username NAME    create a moderator account, the password is read from stdin
+  prune-sessions                 delete the expired sessions
+  seed [-users N] [-posts N]     fill the database with sample users and content
+`
+
+// errMemoryDatabase is returned by the commands that have nothing to work on when the data is kept in memory.
+var errMemoryDatabase = errors.New("DATABASE_URL keeps the data in memory, it only lives as long as serve")
+
 func main() {
 	cfg, err := config.LoadConfig(".", "app")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	handler.Launch(&cfg)
+	if err := run(&cfg, os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "serve":
+		handler.Launch(cfg)
+		return nil
+	case "migrate":
+		return migrateCommand(cfg, args)
+	case "create-admin":
+		return createAdminCommand(cfg, args, os.Stdin, os.Stdout)
+	case "prune-sessions":
+		return pruneSessionsCommand(cfg, os.Stdout)
+	case "seed":
+		return seedCommand(cfg, args, os.Stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	return fmt.Errorf("unknown command %q\n\n%s", command, usage)
 }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"cnfs/config"
+	"cnfs/db/memory"
+	db "cnfs/db/sqlc"
+	"cnfs/migrations"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	err := run(&config.Config{}, []string{"launch"})
+	require.ErrorContains(t, err, `unknown command "launch"`)
+
+	err = run(&config.Config{DatabaseUrl: "memory://"}, []string{"migrate", "up"})
+	require.ErrorIs(t, err, errMemoryDatabase)
+	err = run(&config.Config{DatabaseUrl: "memory://"}, []string{"prune-sessions"})
+	require.ErrorIs(t, err, errMemoryDatabase)
+}
+
+func TestOptionalCount(t *testing.T) {
+	n, err := optionalCount(nil, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	n, err = optionalCount([]string{"3"}, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	_, err = optionalCount([]string{"0"}, 1)
+	require.Error(t, err)
+	_, err = optionalCount([]string{"1", "2"}, 1)
+	require.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	list, err := embeddedMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	require.Equal(t, migration{version: 1, name: "init"}, list[0])
+	for i, m := range list {
+		require.Equal(t, uint(i+1), m.version)
+	}
+
+	// every up has its down, as migrate reads them
+	source, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	for _, m := range list {
+		_, _, err := source.ReadDown(m.version)
+		require.NoError(t, err, "no down migration for version %d", m.version)
+	}
+}
+
+func TestCreateAdmin(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	_, err := createAdmin(ctx, store, "admin", "short")
+	require.Error(t, err)
+
+	userID, err := createAdmin(ctx, store, "admin", "a long password")
+	require.NoError(t, err)
+
+	user, err := store.GetUserById(ctx, userID)
+	require.NoError(t, err)
+	require.True(t, user.IsModerator)
+	_, err = store.GetDefaultUserIdentity(ctx, userID)
+	require.NoError(t, err)
+
+	// usernames are unique for moderators too
+	_, err = createAdmin(ctx, store, "admin", "a long password")
+	require.ErrorContains(t, err, "taken")
+}
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	var out bytes.Buffer
+	require.NoError(t, seed(ctx, store, 3, 2, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+
+	user, err := store.GetUserByUsername(ctx, lines[0])
+	require.NoError(t, err)
+	posts, err := store.ListPublicPostsByUserId(ctx, db.ListPublicPostsByUserIdParams{UserID: user.ID})
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+
+	feed, err := store.ListFeedPosts(ctx, db.ListFeedPostsParams{UserID: user.ID})
+	require.NoError(t, err)
+	require.Len(t, feed, 2)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cnfs/common"
+	"cnfs/config"
+	"cnfs/handler"
+	"cnfs/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+func migrateCommand(cfg *config.Config, args []string) error {
+	if handler.IsMemoryDatabase(cfg) {
+		return errMemoryDatabase
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("migrate needs up, down, status or force\n\n%s", usage)
+	}
+
+	conn, err := sql.Open("postgres", cfg.DatabaseUrl)
+	if err != nil {
+		return err
+	}
+	m, err := common.NewMigrate(conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer m.Close()
+
+	action, args := args[0], args[1:]
+	switch action {
+	case "up":
+		n, err := optionalCount(args, 0)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			err = m.Up()
+		} else {
+			err = m.Steps(n)
+		}
+		return reportMigration(m, err, os.Stdout)
+	case "down":
+		n, err := optionalCount(args, 1)
+		if err != nil {
+			return err
+		}
+		return reportMigration(m, m.Steps(-n), os.Stdout)
+	case "status":
+		return migrationStatus(m, os.Stdout)
+	case "force":
+		if len(args) != 1 {
+			return errors.New("migrate force needs the version to record")
+		}
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		return reportMigration(m, m.Force(version), os.Stdout)
+	}
+
+	return fmt.Errorf("unknown migrate command %q\n\n%s", action, usage)
+}
+
+// optionalCount reads the optional number of migrations to apply or revert.
+func optionalCount(args []string, fallback int) (int, error) {
+	switch len(args) {
+	case 0:
+		return fallback, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+		return n, nil
+	}
+	return 0, errors.New("too many arguments")
+}
+
+// reportMigration prints the version the database is at after a migration, which ended with err.
+func reportMigration(m *migrate.Migrate, err error, out io.Writer) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Fprintln(out, "no change")
+	} else if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Fprintln(out, "no migration applied")
+	case err != nil:
+		return err
+	case dirty:
+		fmt.Fprintf(out, "at version %d, dirty\n", version)
+	default:
+		fmt.Fprintf(out, "at version %d\n", version)
+	}
+	return nil
+}
+
+// migration is a migration embedded in the binary.
+type migration struct {
+	version uint
+	name    string
+}
+
+// embeddedMigrations lists the migrations of the binary, oldest first.
+func embeddedMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var list []migration
+	for _, file := range files {
+		version, name, ok := strings.Cut(strings.TrimSuffix(file, ".up.sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+		v, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+		list = append(list, migration{version: uint(v), name: name})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].version < list[j].version })
+	return list, nil
+}
+
+// migrationStatus lists the migrations of the binary and whether the database has them.
+func migrationStatus(m *migrate.Migrate, out io.Writer) error {
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+
+	list, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range list {
+		status := "pending"
+		switch {
+		case err == nil && mig.version == current && dirty:
+			status = "dirty"
+		case err == nil && mig.version <= current:
+			status = "applied"
+		}
+		fmt.Fprintf(out, "%06d  %-8s %s\n", mig.version, status, mig.name)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"cnfs/common"
+	"cnfs/config"
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// the password of every seeded account
+const seedPassword = "password"
+
+var (
+	seedPosts = []struct {
+		content string
+		tags    []string
+	}{
+		{"I still sleep with the light on. #confession", []string{"confession"}},
+		{"I pretend to know wine, I pick the bottle with the nicest label. #food", []string{"food"}},
+		{"Took credit for a coworker's idea once, still feel bad about it. #work", []string{"work"}},
+		{"I have never finished a book I started in the last five years.", nil},
+		{"Sometimes I cancel plans just to stay home with my cat. #cats", []string{"cats"}},
+		{"I sing in the car at full volume and wave at nobody. #music", []string{"music"}},
+	}
+	seedComments = []string{
+		"Same here, honestly.",
+		"This made my day.",
+		"You are not alone.",
+		"I would never have guessed.",
+		"Respect for admitting it.",
+	}
+)
+
+func seedCommand(cfg *config.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := flags.Int("users", 5, "the number of accounts to create")
+	posts := flags.Int("posts", 3, "the number of posts of each account")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *users < 1 || *posts < 0 {
+		return fmt.Errorf("seed needs at least one user and no negative number of posts")
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	return seed(context.Background(), store, *users, *posts, out)
+}
+
+// seed creates accounts with posts, comments on them and follows between them, printing the usernames.
+// Every account has the password seedPassword.
+func seed(ctx context.Context, store db.Store, users, posts int, out io.Writer) error {
+	hashedPassword, err := common.HashPassword(seedPassword)
+	if err != nil {
+		return err
+	}
+
+	identities := make([]db.UserIdentity, 0, users)
+	for i := 0; i < users; i++ {
+		username := "seed_" + common.RandomString(8)
+		result, err := store.CreateUserTx(ctx, db.CreateUserTxParams{
+			CreateUserParams: db.CreateUserParams{
+				ID:        uuid.New(),
+				Username:  username,
+				Password:  hashedPassword,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+			Identity: db.CreateUserIdentityParams{
+				ID:           uuid.New(),
+				IdentityHash: uuid.New(),
+				Name:         "default",
+				IsDefault:    true,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("cannot create user: %w", err)
+		}
+
+		// public so follows of the user show their posts
+		identity, err := store.UpdateUserIdentity(ctx, db.UpdateUserIdentityParams{
+			IsPublic: sql.NullBool{Bool: true, Valid: true},
+			ID:       result.Identity.ID,
+			UserID:   result.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot update identity: %w", err)
+		}
+
+		identities = append(identities, identity)
+		fmt.Fprintln(out, username)
+	}
+
+	for i, author := range identities {
+		for j := 0; j < posts; j++ {
+			if err := seedPost(ctx, store, author, identities); err != nil {
+				return err
+			}
+		}
+
+		// each account follows the next one
+		if users > 1 {
+			next := identities[(i+1)%users]
+			if err := store.FollowUser(ctx, db.FollowUserParams{
+				ID:             uuid.New(),
+				FollowerID:     author.UserID.UUID,
+				FolloweeUserID: next.UserID.UUID,
+			}); err != nil {
+				return fmt.Errorf("cannot follow user: %w", err)
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "seeded %d users with %d posts each, the password is %q\n", users, posts, seedPassword)
+	return nil
+}
+
+// seedPost creates a post of the author, with its revision and tags as the API creates them, and comments
+// of the other accounts on it.
+func seedPost(ctx context.Context, store db.Store, author db.UserIdentity, identities []db.UserIdentity) error {
+	sample := seedPosts[rand.Intn(len(seedPosts))]
+
+	post, err := store.CreatePost(ctx, db.CreatePostParams{
+		ID:              uuid.New(),
+		Content:         sample.content,
+		UserIdentityID:  author.ID,
+		Status:          "published",
+		ContentWarnings: []string{},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create post: %w", err)
+	}
+
+	if err := store.CreatePostRevision(ctx, db.CreatePostRevisionParams{
+		PostID:         post.ID,
+		UserIdentityID: author.ID,
+		Content:        post.Content,
+	}); err != nil {
+		return fmt.Errorf("cannot create post revision: %w", err)
+	}
+
+	if err := store.SetPostTags(ctx, db.SetPostTagsParams{PostID: post.ID, Tags: append([]string{}, sample.tags...)}); err != nil {
+		return fmt.Errorf("cannot tag post: %w", err)
+	}
+
+	for _, commenter := range identities {
+		if commenter.ID == author.ID || rand.Intn(2) == 0 {
+			continue
+		}
+
+		id, content := uuid.New(), seedComments[rand.Intn(len(seedComments))]
+		if _, err := store.CreateComment(ctx, db.CreateCommentParams{
+			ID:             id,
+			Content:        content,
+			UserIdentityID: commenter.ID,
+			PostID:         post.ID,
+			ParentID:       id,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}); err != nil {
+			return fmt.Errorf("cannot create comment: %w", err)
+		}
+		if err := store.CreateCommentRevision(ctx, db.CreateCommentRevisionParams{
+			CommentID:      id,
+			UserIdentityID: commenter.ID,
+			Content:        content,
+		}); err != nil {
+			return fmt.Errorf("cannot create comment revision: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -2,42 +2,68 @@ package common
 
 import (
 	"database/sql"
-
+	"errors"
+	"fmt"
 	"log"
 
+	"cnfs/migrations"
+
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/lib/pq"
 )
 
-func SetupDb(dbUrl string) *sql.DB {
+// SetupDb connects to the database, applying the migrations it is missing first when migrateUp is set.
+func SetupDb(dbUrl string, migrateUp bool) (*sql.DB, error) {
 	db, err := sql.Open("postgres", dbUrl)
 	if err != nil {
-		log.Fatal(err.Error())
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot reach the database: %w", err)
 	}
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatal(err.Error())
+	if !migrateUp {
+		return db, nil
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations/",
-		"postgres", driver)
+	log.Println("-- Migration started --")
+	if err := MigrateUp(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Println("-- Migration done --")
+
+	return db, nil
+}
+
+// NewMigrate returns a migrate of the migrations embedded in the binary against the database. Closing it
+// closes the database too.
+func NewMigrate(db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
 
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		log.Fatalf("error while migrating: %v", err.Error())
+		return nil, err
 	}
 
-	log.Println("-- Migration started --")
+	return migrate.NewWithInstance("iofs", source, "postgres", driver)
+}
 
-	migrateErr := m.Up()
-	if migrateErr != nil {
-		log.Println("Migration err: ", migrateErr.Error())
+// MigrateUp applies the migrations the database is missing, it is not an error when there are none.
+func MigrateUp(db *sql.DB) error {
+	m, err := NewMigrate(db)
+	if err != nil {
+		return fmt.Errorf("cannot read the migrations: %w", err)
 	}
 
-	log.Println("-- Migration done --")
-
-	return db
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("cannot migrate: %w", err)
+	}
+	return nil
 }
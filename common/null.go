@@ -0,0 +1,19 @@
+package common
+
+import "database/sql"
+
+// NullString converts an optional string to a sql.NullString, nil becomes NULL.
+func NullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// NullBool converts an optional bool to a sql.NullBool, nil becomes NULL.
+func NullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net/url"
 	"time"
 
 	"github.com/spf13/viper"
@@ -17,6 +19,89 @@ type Config struct {
 	RefreshTokenSecretKey string        `mapstructure:"REFRESH_TOKEN_SECRET"`
 	RefreshTokenDuration  time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
 	ClientUrl             string        `mapstructure:"CLIENT_URL"`
+
+	// whether serve applies the missing migrations before it starts, off when they are run with `migrate up`
+	MigrateOnStartup bool `mapstructure:"MIGRATE_ON_STARTUP"`
+	// how long serve waits for the requests in flight and the background jobs once it is asked to stop
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
+	// the database connection pool. 0 open connections is no limit, 0 idle connections keeps none and a lifetime
+	// or idle time of 0 keeps connections open for good
+	DbMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DbMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DbConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DbConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+	// how often the pool statistics are logged, 0 turns them off
+	DbStatsInterval time.Duration `mapstructure:"DB_STATS_INTERVAL"`
+
+	// how often the scheduled identity rotation job runs
+	IdentityRotationInterval time.Duration `mapstructure:"IDENTITY_ROTATION_INTERVAL"`
+	// how often the hot, top and controversial post scores are recomputed
+	PostScoreRefreshInterval time.Duration `mapstructure:"POST_SCORE_REFRESH_INTERVAL"`
+	// how often deleted content past its retention period is purged
+	TrashPurgeInterval time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`
+	// how long deleted posts, comments and messages can be restored before they are purged
+	TrashRetention time.Duration `mapstructure:"TRASH_RETENTION"`
+	// how long a deleted account waits before it is purged, logging in before then cancels the deletion
+	AccountDeletionGracePeriod time.Duration `mapstructure:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	// how often queued data exports are built
+	DataExportInterval time.Duration `mapstructure:"DATA_EXPORT_INTERVAL"`
+	// how long a built data export can be downloaded before it is removed
+	DataExportTTL time.Duration `mapstructure:"DATA_EXPORT_TTL"`
+	// how often scheduled posts whose publish time has passed are published
+	ScheduledPostInterval time.Duration `mapstructure:"SCHEDULED_POST_INTERVAL"`
+
+	// live events, REALTIME_BROKER is local for a single instance or postgres to reach every instance
+	RealtimeBroker string `mapstructure:"REALTIME_BROKER"`
+	// how often an open event stream gets a heartbeat to keep it from idling out
+	RealtimeHeartbeatInterval time.Duration `mapstructure:"REALTIME_HEARTBEAT_INTERVAL"`
+
+	// how often due webhook deliveries are sent
+	WebhookDeliveryInterval time.Duration `mapstructure:"WEBHOOK_DELIVERY_INTERVAL"`
+	// how long an endpoint has to answer a delivery
+	WebhookTimeout time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+	// how many times a delivery is tried before it is given up
+	WebhookMaxAttempts int32 `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	// lets endpoints resolve to loopback and private addresses, only for development
+	WebhookAllowPrivateAddresses bool `mapstructure:"WEBHOOK_ALLOW_PRIVATE_ADDRESSES"`
+
+	// web push, left off unless both VAPID keys are set. They are base64url encoded P-256 keys as
+	// `npx web-push generate-vapid-keys` prints them
+	VapidPublicKey  string `mapstructure:"VAPID_PUBLIC_KEY"`
+	VapidPrivateKey string `mapstructure:"VAPID_PRIVATE_KEY"`
+	// a mailto: or https: url push services can reach us at, CLIENT_URL when empty
+	VapidSubject string `mapstructure:"VAPID_SUBJECT"`
+	// how long push services keep a notification for a browser that is offline
+	WebPushTTL time.Duration `mapstructure:"WEB_PUSH_TTL"`
+	// how long a push service has to accept a notification
+	WebPushTimeout time.Duration `mapstructure:"WEB_PUSH_TIMEOUT"`
+
+	// email, MAILER_BACKEND is file to write messages to MAIL_FILE_DIR or smtp to send them through the relay
+	MailerBackend string `mapstructure:"MAILER_BACKEND"`
+	MailFrom      string `mapstructure:"MAIL_FROM"`
+	MailFileDir   string `mapstructure:"MAIL_FILE_DIR"`
+	SmtpHost      string `mapstructure:"SMTP_HOST"`
+	SmtpPort      string `mapstructure:"SMTP_PORT"`
+	SmtpUsername  string `mapstructure:"SMTP_USERNAME"`
+	SmtpPassword  string `mapstructure:"SMTP_PASSWORD"`
+	// how often due email digests are sent
+	DigestInterval time.Duration `mapstructure:"DIGEST_INTERVAL"`
+	// the public address of this server, unsubscribe links in emails point at it
+	ApiUrl string `mapstructure:"API_URL"`
+
+	// media uploads, STORAGE_BACKEND is either local or s3
+	StorageBackend    string        `mapstructure:"STORAGE_BACKEND"`
+	StorageLocalDir   string        `mapstructure:"STORAGE_LOCAL_DIR"`
+	StoragePublicUrl  string        `mapstructure:"STORAGE_PUBLIC_URL"`
+	StorageSigningKey string        `mapstructure:"STORAGE_SIGNING_KEY"`
+	SignedUrlDuration time.Duration `mapstructure:"SIGNED_URL_DURATION"`
+	MaxUploadSize     int64         `mapstructure:"MAX_UPLOAD_SIZE"`
+	S3Endpoint        string        `mapstructure:"S3_ENDPOINT"`
+	S3Region          string        `mapstructure:"S3_REGION"`
+	S3Bucket          string        `mapstructure:"S3_BUCKET"`
+	S3AccessKey       string        `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey       string        `mapstructure:"S3_SECRET_KEY"`
+	S3PathStyle       bool          `mapstructure:"S3_PATH_STYLE"`
 }
 
 func LoadConfig(path, name string) (Config, error) {
@@ -26,6 +111,39 @@ func LoadConfig(path, name string) (Config, error) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("MIGRATE_ON_STARTUP", true)
+	viper.SetDefault("SHUTDOWN_TIMEOUT", 15*time.Second)
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 25)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 10)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", 30*time.Minute)
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	viper.SetDefault("DB_STATS_INTERVAL", 5*time.Minute)
+	viper.SetDefault("IDENTITY_ROTATION_INTERVAL", time.Hour)
+	viper.SetDefault("POST_SCORE_REFRESH_INTERVAL", 5*time.Minute)
+	viper.SetDefault("TRASH_PURGE_INTERVAL", time.Hour)
+	viper.SetDefault("TRASH_RETENTION", 30*24*time.Hour)
+	viper.SetDefault("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	viper.SetDefault("DATA_EXPORT_INTERVAL", time.Minute)
+	viper.SetDefault("DATA_EXPORT_TTL", 7*24*time.Hour)
+	viper.SetDefault("SCHEDULED_POST_INTERVAL", 30*time.Second)
+	viper.SetDefault("REALTIME_BROKER", "postgres")
+	viper.SetDefault("REALTIME_HEARTBEAT_INTERVAL", 25*time.Second)
+	viper.SetDefault("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second)
+	viper.SetDefault("WEBHOOK_TIMEOUT", 10*time.Second)
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE_ADDRESSES", false)
+	viper.SetDefault("WEB_PUSH_TTL", 24*time.Hour)
+	viper.SetDefault("WEB_PUSH_TIMEOUT", 10*time.Second)
+	viper.SetDefault("MAILER_BACKEND", "file")
+	viper.SetDefault("MAIL_FILE_DIR", "outbox")
+	viper.SetDefault("SMTP_PORT", "587")
+	viper.SetDefault("DIGEST_INTERVAL", 15*time.Minute)
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "uploads")
+	viper.SetDefault("STORAGE_PUBLIC_URL", "/api/v1/media")
+	viper.SetDefault("SIGNED_URL_DURATION", 15*time.Minute)
+	viper.SetDefault("MAX_UPLOAD_SIZE", 5<<20)
+
 	if err := viper.ReadInConfig(); err != nil {
 		return Config{}, err
 	}
@@ -39,3 +157,28 @@ func LoadConfig(path, name string) (Config, error) {
 
 	return config, nil
 }
+
+// String prints the config with its keys, secrets and the database password masked so it can be logged
+func (c Config) String() string {
+	type plain Config
+	masked := plain(c)
+	for _, secret := range []*string{
+		&masked.PasetoSymmetricKey,
+		&masked.AccessTokenSecretKey,
+		&masked.RefreshTokenSecretKey,
+		&masked.VapidPrivateKey,
+		&masked.SmtpPassword,
+		&masked.StorageSigningKey,
+		&masked.S3SecretKey,
+	} {
+		if *secret != "" {
+			*secret = "xxxxx"
+		}
+	}
+	if u, err := url.Parse(c.DatabaseUrl); err == nil {
+		masked.DatabaseUrl = u.Redacted()
+	} else {
+		masked.DatabaseUrl = "xxxxx"
+	}
+	return fmt.Sprint(masked)
+}
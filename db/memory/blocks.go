@@ -0,0 +1,191 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) BlockUser(ctx context.Context, arg db.BlockUserParams) (db.Block, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.block(db.Block{
+		ID:            arg.ID,
+		UserID:        arg.UserID,
+		BlockedUserID: uuid.NullUUID{UUID: arg.BlockedUserID, Valid: true},
+		Kind:          arg.Kind,
+		CreatedAt:     now(),
+	})
+}
+
+func (q *Queries) BlockIdentity(ctx context.Context, arg db.BlockIdentityParams) (db.Block, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.block(db.Block{
+		ID:                arg.ID,
+		UserID:            arg.UserID,
+		BlockedIdentityID: uuid.NullUUID{UUID: arg.BlockedIdentityID, Valid: true},
+		Kind:              arg.Kind,
+		CreatedAt:         now(),
+	})
+}
+
+// block inserts the block, or changes the kind of the one already targeting the same user or identity.
+func (t *tables) block(b db.Block) (db.Block, error) {
+	if _, ok := t.users[b.UserID]; !ok {
+		return db.Block{}, foreignKeyViolation("blocks", "blocks_user_id_fkey")
+	}
+	if _, ok := t.users[b.BlockedUserID.UUID]; b.BlockedUserID.Valid && !ok {
+		return db.Block{}, foreignKeyViolation("blocks", "blocks_blocked_user_id_fkey")
+	}
+	if _, ok := t.identities[b.BlockedIdentityID.UUID]; b.BlockedIdentityID.Valid && !ok {
+		return db.Block{}, foreignKeyViolation("blocks", "blocks_blocked_identity_id_fkey")
+	}
+
+	for _, other := range t.blocks {
+		if other.UserID == b.UserID && other.BlockedUserID == b.BlockedUserID &&
+			other.BlockedIdentityID == b.BlockedIdentityID {
+			other.Kind = b.Kind
+			t.blocks[other.ID] = other
+			return other, nil
+		}
+	}
+	if _, ok := t.blocks[b.ID]; ok {
+		return db.Block{}, uniqueViolation("blocks_pkey")
+	}
+
+	t.blocks[b.ID] = b
+	return b, nil
+}
+
+func (q *Queries) ListBlocks(ctx context.Context, arg db.ListBlocksParams) ([]db.Block, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	blocks := values(q.t.blocks,
+		func(b db.Block) bool { return b.UserID == arg.UserID && b.Kind == arg.Kind },
+		func(a, b db.Block) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(blocks, arg.Offset), nil
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg db.DeleteBlockParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	b, ok := q.t.blocks[arg.ID]
+	if !ok || b.UserID != arg.UserID {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	delete(q.t.blocks, b.ID)
+	return b.ID, nil
+}
+
+func (q *Queries) IsBlocked(ctx context.Context, arg db.IsBlockedParams) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, b := range q.t.blocks {
+		if b.UserID != arg.UserID || b.Kind != "block" {
+			continue
+		}
+		if b.BlockedUserID.Valid && b.BlockedUserID.UUID == arg.ActorID {
+			return true, nil
+		}
+		if b.BlockedIdentityID.Valid && q.t.identityOwnedBy(b.BlockedIdentityID.UUID, arg.ActorID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (q *Queries) HasBlocks(ctx context.Context, userID uuid.UUID) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, b := range q.t.blocks {
+		if b.UserID == userID && b.Kind == "block" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (q *Queries) CreateMutedKeyword(ctx context.Context, arg db.CreateMutedKeywordParams) (db.MutedKeyword, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.mutedKeywords[arg.ID]; ok {
+		return db.MutedKeyword{}, uniqueViolation("muted_keywords_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.MutedKeyword{}, foreignKeyViolation("muted_keywords", "muted_keywords_user_id_fkey")
+	}
+	for _, mk := range q.t.mutedKeywords {
+		if mk.UserID == arg.UserID && strings.ToLower(mk.Keyword) == strings.ToLower(arg.Keyword) {
+			return db.MutedKeyword{}, uniqueViolation("muted_keywords_user_id_keyword_key")
+		}
+	}
+
+	mk := db.MutedKeyword{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Keyword:   arg.Keyword,
+		CreatedAt: now(),
+	}
+	q.t.mutedKeywords[mk.ID] = mk
+	return mk, nil
+}
+
+func (q *Queries) ListMutedKeywords(ctx context.Context, userID uuid.UUID) ([]db.MutedKeyword, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.mutedKeywords,
+		func(mk db.MutedKeyword) bool { return mk.UserID == userID },
+		func(a, b db.MutedKeyword) bool {
+			if a.Keyword != b.Keyword {
+				return a.Keyword < b.Keyword
+			}
+			return lessID(a.ID, b.ID)
+		},
+	), nil
+}
+
+func (q *Queries) CountMutedKeywords(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, mk := range q.t.mutedKeywords {
+		if mk.UserID == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queries) DeleteMutedKeyword(ctx context.Context, arg db.DeleteMutedKeywordParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	mk, ok := q.t.mutedKeywords[arg.ID]
+	if !ok || mk.UserID != arg.UserID {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	delete(q.t.mutedKeywords, mk.ID)
+	return mk.ID, nil
+}
@@ -0,0 +1,218 @@
+package memory
+
+import "github.com/google/uuid"
+
+// The deletes below take the rows referencing the deleted one with them, as the ON DELETE CASCADE foreign
+// keys of the migrations do.
+
+func (t *tables) deleteUser(id uuid.UUID) {
+	delete(t.users, id)
+
+	for _, ui := range t.identities {
+		if ui.UserID.Valid && ui.UserID.UUID == id {
+			t.deleteIdentity(ui.ID)
+		}
+	}
+	for _, m := range t.messages {
+		if m.ReceiverID == id {
+			t.deleteMessage(m.ID)
+		}
+	}
+	for k, s := range t.sessions {
+		if s.UserID == id {
+			delete(t.sessions, k)
+		}
+	}
+	for k, m := range t.media {
+		if m.UserID.Valid && m.UserID.UUID == id {
+			delete(t.media, k)
+		}
+	}
+	for k, f := range t.follows {
+		if f.FollowerID == id || f.FolloweeUserID.Valid && f.FolloweeUserID.UUID == id {
+			delete(t.follows, k)
+		}
+	}
+	for k, b := range t.blocks {
+		if b.UserID == id || b.BlockedUserID.Valid && b.BlockedUserID.UUID == id {
+			delete(t.blocks, k)
+		}
+	}
+	for k, mk := range t.mutedKeywords {
+		if mk.UserID == id {
+			delete(t.mutedKeywords, k)
+		}
+	}
+	for k, r := range t.reactions {
+		if r.UserID == id {
+			delete(t.reactions, k)
+		}
+	}
+	for k, e := range t.dataExports {
+		if e.UserID == id {
+			delete(t.dataExports, k)
+		}
+	}
+	for k := range t.contentPreferences {
+		if k.id == id {
+			delete(t.contentPreferences, k)
+		}
+	}
+	for k, n := range t.notifications {
+		if n.UserID == id {
+			delete(t.notifications, k)
+		}
+	}
+	for k := range t.notificationPreferences {
+		if k.id == id {
+			delete(t.notificationPreferences, k)
+		}
+	}
+	for _, w := range t.webhooks {
+		if w.UserID == id {
+			t.deleteWebhook(w.ID)
+		}
+	}
+	for k, ps := range t.pushSubscriptions {
+		if ps.UserID == id {
+			delete(t.pushSubscriptions, k)
+		}
+	}
+	delete(t.emailDigests, id)
+}
+
+func (t *tables) deleteIdentity(id uuid.UUID) {
+	delete(t.identities, id)
+
+	for _, p := range t.posts {
+		if p.UserIdentityID == id {
+			t.deletePost(p.ID)
+		}
+	}
+	for _, c := range t.comments {
+		if c.UserIdentityID == id {
+			t.deleteComment(c.ID)
+		}
+	}
+	for k, f := range t.follows {
+		if f.FolloweeIdentityID.Valid && f.FolloweeIdentityID.UUID == id {
+			delete(t.follows, k)
+		}
+	}
+	for k, b := range t.blocks {
+		if b.BlockedIdentityID.Valid && b.BlockedIdentityID.UUID == id {
+			delete(t.blocks, k)
+		}
+	}
+	for k, r := range t.postRevisions {
+		if r.UserIdentityID == id {
+			delete(t.postRevisions, k)
+		}
+	}
+	for k, r := range t.commentRevisions {
+		if r.UserIdentityID == id {
+			delete(t.commentRevisions, k)
+		}
+	}
+	for k := range t.pollBallots {
+		if k.b == id {
+			t.deletePollBallot(k)
+		}
+	}
+}
+
+func (t *tables) deleteMessage(id uuid.UUID) {
+	delete(t.messages, id)
+
+	for k, m := range t.media {
+		if m.MessageID.Valid && m.MessageID.UUID == id {
+			delete(t.media, k)
+		}
+	}
+}
+
+func (t *tables) deletePost(id uuid.UUID) {
+	delete(t.posts, id)
+
+	for _, c := range t.comments {
+		if c.PostID == id {
+			t.deleteComment(c.ID)
+		}
+	}
+	for k, m := range t.media {
+		if m.PostID.Valid && m.PostID.UUID == id {
+			delete(t.media, k)
+		}
+	}
+	for k := range t.postTags {
+		if k.id == id {
+			delete(t.postTags, k)
+		}
+	}
+	for k := range t.reactions {
+		if k.a == id {
+			delete(t.reactions, k)
+		}
+	}
+	delete(t.scores, id)
+	for k := range t.postRevisions {
+		if k.id == id {
+			delete(t.postRevisions, k)
+		}
+	}
+	t.deletePoll(id)
+}
+
+// deleteComment deletes the comment and its replies.
+func (t *tables) deleteComment(id uuid.UUID) {
+	if _, ok := t.comments[id]; !ok {
+		return
+	}
+	delete(t.comments, id)
+
+	for _, c := range t.comments {
+		if c.ParentID == id {
+			t.deleteComment(c.ID)
+		}
+	}
+	for k := range t.commentRevisions {
+		if k.id == id {
+			delete(t.commentRevisions, k)
+		}
+	}
+}
+
+func (t *tables) deletePoll(postID uuid.UUID) {
+	delete(t.polls, postID)
+
+	for k, o := range t.pollOptions {
+		if o.PostID == postID {
+			delete(t.pollOptions, k)
+		}
+	}
+	for k := range t.pollBallots {
+		if k.a == postID {
+			t.deletePollBallot(k)
+		}
+	}
+}
+
+func (t *tables) deletePollBallot(k pairKey) {
+	delete(t.pollBallots, k)
+
+	for v := range t.pollVotes {
+		if v.postID == k.a && v.identityID == k.b {
+			delete(t.pollVotes, v)
+		}
+	}
+}
+
+func (t *tables) deleteWebhook(id uuid.UUID) {
+	delete(t.webhooks, id)
+
+	for k, d := range t.webhookDeliveries {
+		if d.WebhookID == id {
+			delete(t.webhookDeliveries, k)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func oldestCommentFirst(a, b db.Comment) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return lessID(a.ID, b.ID)
+}
+
+func (q *Queries) ListAllComments(ctx context.Context, arg db.ListAllCommentsParams) ([]db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.comments,
+		func(c db.Comment) bool {
+			return c.PostID == arg.PostID && !q.t.commentTrashed(c.ID) &&
+				!q.t.hiddenFrom(arg.ViewerID, c.UserIdentityID, c.Content)
+		},
+		oldestCommentFirst,
+	), nil
+}
+
+func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.t.comments[id]
+	if !ok || q.t.commentTrashed(id) || q.t.authorDeleted(c.UserIdentityID) {
+		return db.Comment{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (q *Queries) GetCommentIncludingDeleted(ctx context.Context, id uuid.UUID) (db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.t.comments[id]
+	if !ok {
+		return db.Comment{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (q *Queries) CreateComment(ctx context.Context, arg db.CreateCommentParams) (db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.comments[arg.ID]; ok {
+		return db.Comment{}, uniqueViolation("comments_pkey")
+	}
+	if _, ok := q.t.identities[arg.UserIdentityID]; !ok {
+		return db.Comment{}, foreignKeyViolation("comments", "comments_user_identity_id_fkey")
+	}
+	if _, ok := q.t.posts[arg.PostID]; !ok {
+		return db.Comment{}, foreignKeyViolation("comments", "comments_post_id_fkey")
+	}
+	// top level comments are their own parent
+	if _, ok := q.t.comments[arg.ParentID]; !ok && arg.ParentID != arg.ID {
+		return db.Comment{}, foreignKeyViolation("comments", "comments_parent_id_fkey")
+	}
+
+	c := db.Comment{
+		ID:             arg.ID,
+		Content:        arg.Content,
+		UserIdentityID: arg.UserIdentityID,
+		PostID:         arg.PostID,
+		ParentID:       arg.ParentID,
+		CreatedAt:      timestamp(arg.CreatedAt),
+		UpdatedAt:      timestamp(arg.UpdatedAt),
+		Revision:       1,
+	}
+	q.t.comments[c.ID] = c
+	return c, nil
+}
+
+func (q *Queries) UpdateComment(ctx context.Context, arg db.UpdateCommentParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.t.comments[arg.ID]
+	if !ok || c.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	c.Content = arg.Content
+	c.UpdatedAt = timestamp(arg.UpdatedAt)
+	c.Revision++
+	c.Edited = true
+
+	key := revisionKey{c.ID, c.Revision}
+	if _, ok := q.t.commentRevisions[key]; ok {
+		return uuid.Nil, uniqueViolation("comment_revisions_pkey")
+	}
+	if _, ok := q.t.identities[arg.EditorIdentityID]; !ok {
+		return uuid.Nil, foreignKeyViolation("comment_revisions", "comment_revisions_user_identity_id_fkey")
+	}
+
+	q.t.commentRevisions[key] = db.CommentRevision{
+		CommentID:      c.ID,
+		Revision:       c.Revision,
+		UserIdentityID: arg.EditorIdentityID,
+		Content:        c.Content,
+		CreatedAt:      now(),
+	}
+	q.t.comments[c.ID] = c
+	return c.ID, nil
+}
+
+func (q *Queries) DeleteComment(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.t.comments[id]
+	if !ok || c.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	c.DeletedAt = ptr(now())
+	q.t.comments[c.ID] = c
+	return c.ID, nil
+}
+
+func (q *Queries) RestoreComment(ctx context.Context, arg db.RestoreCommentParams) (db.RestoreCommentRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c, ok := q.t.comments[arg.ID]
+	if !ok || c.DeletedAt == nil || !q.t.identityOwnedBy(c.UserIdentityID, arg.UserID) {
+		return db.RestoreCommentRow{}, sql.ErrNoRows
+	}
+
+	c.DeletedAt = nil
+	q.t.comments[c.ID] = c
+	q.t.markScoreDirty(c.PostID)
+	return db.RestoreCommentRow{
+		ID:             c.ID,
+		Content:        c.Content,
+		UserIdentityID: c.UserIdentityID,
+		PostID:         c.PostID,
+		ParentID:       c.ParentID,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		Revision:       c.Revision,
+		Edited:         c.Edited,
+		DeletedAt:      c.DeletedAt,
+	}, nil
+}
+
+func (q *Queries) ListTrashedComments(ctx context.Context, arg db.ListTrashedCommentsParams) ([]db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	comments := values(q.t.comments,
+		func(c db.Comment) bool {
+			return c.DeletedAt != nil && q.t.identityOwnedBy(c.UserIdentityID, arg.UserID)
+		},
+		func(a, b db.Comment) bool {
+			if !a.DeletedAt.Equal(*b.DeletedAt) {
+				return a.DeletedAt.After(*b.DeletedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(comments, arg.PageOffset), nil
+}
+
+func (q *Queries) PurgeComments(ctx context.Context, retentionSeconds float64) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	before := now().Add(-seconds(retentionSeconds))
+	purged := []uuid.UUID{}
+	for _, c := range q.t.comments {
+		if c.DeletedAt != nil && c.DeletedAt.Before(before) {
+			purged = append(purged, c.ID)
+		}
+	}
+
+	// the replies the delete cascades to are not counted, the trashed ones among them are as they match too
+	for _, id := range purged {
+		q.t.deleteComment(id)
+	}
+	return int64(len(purged)), nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) ListContentPreferences(ctx context.Context, userID uuid.UUID) ([]db.ContentPreference, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.contentPreferences,
+		func(cp db.ContentPreference) bool { return cp.UserID == userID },
+		func(a, b db.ContentPreference) bool { return a.Category < b.Category },
+	), nil
+}
+
+func (q *Queries) SetContentPreferences(ctx context.Context, arg db.SetContentPreferencesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.users[arg.UserID]; !ok && len(arg.Categories) > 0 {
+		return foreignKeyViolation("content_preferences", "content_preferences_user_id_fkey")
+	}
+
+	kept := map[string]bool{}
+	for _, category := range arg.Categories {
+		kept[category] = true
+	}
+	for k := range q.t.contentPreferences {
+		if k.id == arg.UserID && !kept[k.name] {
+			delete(q.t.contentPreferences, k)
+		}
+	}
+
+	for i := 0; i < len(arg.Categories) && i < len(arg.Actions); i++ {
+		q.t.contentPreferences[nameKey{arg.UserID, arg.Categories[i]}] = db.ContentPreference{
+			UserID:   arg.UserID,
+			Category: arg.Categories[i],
+			Action:   arg.Actions[i],
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) GetEmailDigest(ctx context.Context, userID uuid.UUID) (db.EmailDigest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.t.emailDigests[userID]
+	if !ok {
+		return db.EmailDigest{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+func (q *Queries) SetEmailDigest(ctx context.Context, arg db.SetEmailDigestParams) (db.EmailDigest, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if d, ok := q.t.emailDigests[arg.UserID]; ok {
+		// the token stays, the links of the digests already sent keep working
+		from := now()
+		if d.LastSentAt != nil {
+			from = *d.LastSentAt
+		}
+		d.Email = arg.Email
+		d.Frequency = arg.Frequency
+		d.NextSendAt = from.Add(digestPeriod(arg.Frequency))
+		d.UpdatedAt = now()
+		q.t.emailDigests[d.UserID] = d
+		return d, nil
+	}
+
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.EmailDigest{}, foreignKeyViolation("email_digests", "email_digests_user_id_fkey")
+	}
+	for _, d := range q.t.emailDigests {
+		if d.UnsubscribeToken == arg.UnsubscribeToken {
+			return db.EmailDigest{}, uniqueViolation("email_digests_unsubscribe_token_key")
+		}
+	}
+
+	d := db.EmailDigest{
+		UserID:           arg.UserID,
+		Email:            arg.Email,
+		Frequency:        arg.Frequency,
+		UnsubscribeToken: arg.UnsubscribeToken,
+		NextSendAt:       now().Add(digestPeriod(arg.Frequency)),
+		CreatedAt:        now(),
+		UpdatedAt:        now(),
+	}
+	q.t.emailDigests[d.UserID] = d
+	return d, nil
+}
+
+func (q *Queries) DeleteEmailDigest(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.emailDigests[userID]; !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	delete(q.t.emailDigests, userID)
+	return userID, nil
+}
+
+func (q *Queries) UnsubscribeEmailDigest(ctx context.Context, unsubscribeToken string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, d := range q.t.emailDigests {
+		if d.UnsubscribeToken == unsubscribeToken {
+			delete(q.t.emailDigests, d.UserID)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (q *Queries) ClaimDueEmailDigests(ctx context.Context, arg db.ClaimDueEmailDigestsParams) ([]db.ClaimDueEmailDigestsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := values(q.t.emailDigests,
+		func(d db.EmailDigest) bool {
+			u, ok := q.t.users[d.UserID]
+			return !d.NextSendAt.After(now()) && ok && u.DeletedAt == nil
+		},
+		func(a, b db.EmailDigest) bool {
+			if !a.NextSendAt.Equal(b.NextSendAt) {
+				return a.NextSendAt.Before(b.NextSendAt)
+			}
+			return lessID(a.UserID, b.UserID)
+		},
+	)
+
+	rows := []db.ClaimDueEmailDigestsRow{}
+	for _, d := range limit(0, due, int(arg.BatchSize)) {
+		d.NextSendAt = now().Add(seconds(arg.LeaseSeconds))
+		q.t.emailDigests[d.UserID] = d
+
+		rows = append(rows, db.ClaimDueEmailDigestsRow{
+			UserID:           d.UserID,
+			Email:            d.Email,
+			Frequency:        d.Frequency,
+			UnsubscribeToken: d.UnsubscribeToken,
+			LastSentAt:       d.LastSentAt,
+			NextSendAt:       d.NextSendAt,
+			CreatedAt:        d.CreatedAt,
+			UpdatedAt:        d.UpdatedAt,
+			Username:         q.t.users[d.UserID].Username,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) MarkEmailDigestSent(ctx context.Context, userID uuid.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.t.emailDigests[userID]
+	if !ok {
+		return nil
+	}
+
+	d.LastSentAt = ptr(now())
+	d.NextSendAt = d.LastSentAt.Add(digestPeriod(d.Frequency))
+	q.t.emailDigests[d.UserID] = d
+	return nil
+}
+
+func (q *Queries) CountDigestMessages(ctx context.Context, arg db.CountDigestMessagesParams) (db.CountDigestMessagesRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	since := timestamp(arg.Since)
+	var row db.CountDigestMessagesRow
+	for _, m := range q.t.messages {
+		if m.ReceiverID != arg.UserID || m.Seen || m.DeletedAt != nil {
+			continue
+		}
+		row.Unread++
+		if !m.CreatedAt.Before(since) {
+			row.New++
+		}
+	}
+	return row, nil
+}
+
+func (q *Queries) ListDigestTopComments(ctx context.Context, arg db.ListDigestTopCommentsParams) ([]db.ListDigestTopCommentsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	since := timestamp(arg.Since)
+	replies := map[uuid.UUID]int64{}
+	for _, c := range q.t.comments {
+		if c.ParentID != c.ID && c.DeletedAt == nil {
+			replies[c.ParentID]++
+		}
+	}
+
+	comments := values(q.t.comments,
+		func(c db.Comment) bool {
+			p, ok := q.t.posts[c.PostID]
+			commenter := q.t.identities[c.UserIdentityID].UserID
+			return ok && q.t.identityOwnedBy(p.UserIdentityID, arg.UserID) &&
+				!(commenter.Valid && commenter.UUID == arg.UserID) &&
+				!c.CreatedAt.Before(since) &&
+				!q.t.commentTrashed(c.ID) &&
+				!q.t.hiddenFrom(arg.UserID, c.UserIdentityID, c.Content)
+		},
+		func(a, b db.Comment) bool {
+			if replies[a.ID] != replies[b.ID] {
+				return replies[a.ID] > replies[b.ID]
+			}
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+
+	rows := []db.ListDigestTopCommentsRow{}
+	for _, c := range limit(0, comments, int(arg.Top)) {
+		rows = append(rows, db.ListDigestTopCommentsRow{
+			ID:          c.ID,
+			Content:     c.Content,
+			PostID:      c.PostID,
+			PostContent: q.t.posts[c.PostID].Content,
+			Replies:     replies[c.ID],
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) CountDigestFollowers(ctx context.Context, arg db.CountDigestFollowersParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	since := timestamp(arg.Since)
+	var n int64
+	for _, f := range q.t.follows {
+		if f.CreatedAt.Before(since) {
+			continue
+		}
+		if f.FolloweeUserID.Valid && f.FolloweeUserID.UUID == arg.UserID ||
+			f.FolloweeIdentityID.Valid && q.t.identityOwnedBy(f.FolloweeIdentityID.UUID, arg.UserID) {
+			n++
+		}
+	}
+	return n, nil
+}
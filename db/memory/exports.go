@@ -0,0 +1,207 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreateDataExport(ctx context.Context, arg db.CreateDataExportParams) (db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.dataExports[arg.ID]; ok {
+		return db.DataExport{}, uniqueViolation("data_exports_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.DataExport{}, foreignKeyViolation("data_exports", "data_exports_user_id_fkey")
+	}
+	for _, e := range q.t.dataExports {
+		if e.UserID == arg.UserID && (e.Status == "pending" || e.Status == "running") {
+			return db.DataExport{}, uniqueViolation("data_exports_user_id_in_progress_key")
+		}
+	}
+
+	e := db.DataExport{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Format:    arg.Format,
+		Status:    "pending",
+		CreatedAt: now(),
+	}
+	q.t.dataExports[e.ID] = e
+	return e, nil
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, arg db.GetDataExportParams) (db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.t.dataExports[arg.ID]
+	if !ok || e.UserID != arg.UserID {
+		return db.DataExport{}, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (q *Queries) ListDataExports(ctx context.Context, userID uuid.UUID) ([]db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	exports := values(q.t.dataExports,
+		func(e db.DataExport) bool { return e.UserID == userID },
+		func(a, b db.DataExport) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(exports, 0), nil
+}
+
+func (q *Queries) ClaimDataExport(ctx context.Context, staleSeconds float64) (db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stale := now().Add(-seconds(staleSeconds))
+	claimable := values(q.t.dataExports,
+		func(e db.DataExport) bool {
+			return e.Status == "pending" || e.Status == "running" && e.StartedAt != nil && e.StartedAt.Before(stale)
+		},
+		func(a, b db.DataExport) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	if len(claimable) == 0 {
+		return db.DataExport{}, sql.ErrNoRows
+	}
+
+	e := claimable[0]
+	e.Status = "running"
+	e.StartedAt = ptr(now())
+	q.t.dataExports[e.ID] = e
+	return e, nil
+}
+
+func (q *Queries) CompleteDataExport(ctx context.Context, arg db.CompleteDataExportParams) (db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.t.dataExports[arg.ID]
+	if !ok {
+		return db.DataExport{}, sql.ErrNoRows
+	}
+
+	e.Status = "done"
+	e.StorageKey = arg.StorageKey
+	e.Size = arg.Size
+	e.CompletedAt = ptr(now())
+	e.ExpiresAt = ptr(e.CompletedAt.Add(seconds(arg.TtlSeconds)))
+	q.t.dataExports[e.ID] = e
+	return e, nil
+}
+
+func (q *Queries) FailDataExport(ctx context.Context, arg db.FailDataExportParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.t.dataExports[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	e.Status = "failed"
+	e.Error = arg.Error
+	e.CompletedAt = ptr(now())
+	q.t.dataExports[e.ID] = e
+	return nil
+}
+
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context) ([]db.DataExport, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	expired := values(q.t.dataExports,
+		func(e db.DataExport) bool { return e.ExpiresAt != nil && e.ExpiresAt.Before(now()) },
+		func(a, b db.DataExport) bool { return lessID(a.ID, b.ID) },
+	)
+	for _, e := range expired {
+		delete(q.t.dataExports, e.ID)
+	}
+	return expired, nil
+}
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]db.ListUserSessionsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	sessions := values(q.t.sessions,
+		func(s db.Session) bool { return s.UserID == userID },
+		func(a, b db.Session) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+
+	rows := []db.ListUserSessionsRow{}
+	for _, s := range sessions {
+		rows = append(rows, db.ListUserSessionsRow{
+			ID:        s.ID,
+			UserAgent: s.UserAgent,
+			ClientIp:  s.ClientIp,
+			IsBlocked: s.IsBlocked,
+			CreatedAt: s.CreatedAt,
+			ExpiresAt: s.ExpiresAt,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) ExportUserMessages(ctx context.Context, receiverID uuid.UUID) ([]db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.messages,
+		func(m db.Message) bool { return m.ReceiverID == receiverID },
+		func(a, b db.Message) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	), nil
+}
+
+func (q *Queries) ExportUserPosts(ctx context.Context, userID uuid.UUID) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.posts,
+		func(p db.Post) bool { return q.t.identityOwnedBy(p.UserIdentityID, userID) },
+		oldestPostFirst,
+	), nil
+}
+
+func (q *Queries) ExportUserComments(ctx context.Context, userID uuid.UUID) ([]db.Comment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.comments,
+		func(c db.Comment) bool { return q.t.identityOwnedBy(c.UserIdentityID, userID) },
+		func(a, b db.Comment) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	), nil
+}
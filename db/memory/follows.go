@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) FollowUser(ctx context.Context, arg db.FollowUserParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.follow(db.Follow{
+		ID:             arg.ID,
+		FollowerID:     arg.FollowerID,
+		FolloweeUserID: uuid.NullUUID{UUID: arg.FolloweeUserID, Valid: true},
+		CreatedAt:      now(),
+	})
+}
+
+func (q *Queries) FollowIdentity(ctx context.Context, arg db.FollowIdentityParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.follow(db.Follow{
+		ID:                 arg.ID,
+		FollowerID:         arg.FollowerID,
+		FolloweeIdentityID: uuid.NullUUID{UUID: arg.FolloweeIdentityID, Valid: true},
+		CreatedAt:          now(),
+	})
+}
+
+// follow inserts the follow unless it is already there, ON CONFLICT DO NOTHING.
+func (t *tables) follow(f db.Follow) error {
+	if _, ok := t.users[f.FollowerID]; !ok {
+		return foreignKeyViolation("follows", "follows_follower_id_fkey")
+	}
+	if _, ok := t.users[f.FolloweeUserID.UUID]; f.FolloweeUserID.Valid && !ok {
+		return foreignKeyViolation("follows", "follows_followee_user_id_fkey")
+	}
+	if _, ok := t.identities[f.FolloweeIdentityID.UUID]; f.FolloweeIdentityID.Valid && !ok {
+		return foreignKeyViolation("follows", "follows_followee_identity_id_fkey")
+	}
+
+	if _, ok := t.follows[f.ID]; ok {
+		return nil
+	}
+	for _, other := range t.follows {
+		if other.FollowerID == f.FollowerID && other.FolloweeUserID == f.FolloweeUserID &&
+			other.FolloweeIdentityID == f.FolloweeIdentityID {
+			return nil
+		}
+	}
+
+	t.follows[f.ID] = f
+	return nil
+}
+
+func (q *Queries) UnfollowUser(ctx context.Context, arg db.UnfollowUserParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.unfollow(arg.FollowerID, uuid.NullUUID{UUID: arg.FolloweeUserID, Valid: true}, uuid.NullUUID{})
+}
+
+func (q *Queries) UnfollowIdentity(ctx context.Context, arg db.UnfollowIdentityParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.unfollow(arg.FollowerID, uuid.NullUUID{}, uuid.NullUUID{UUID: arg.FolloweeIdentityID, Valid: true})
+}
+
+func (t *tables) unfollow(follower uuid.UUID, user, identity uuid.NullUUID) (uuid.UUID, error) {
+	for id, f := range t.follows {
+		if f.FollowerID == follower && f.FolloweeUserID == user && f.FolloweeIdentityID == identity {
+			delete(t.follows, id)
+			return id, nil
+		}
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+// latestFollowFirst orders follows by follows.created_at DESC and then the username of the user on the other side.
+func (t *tables) latestFollowFirst(other func(db.Follow) uuid.UUID) func(a, b db.Follow) bool {
+	return func(a, b db.Follow) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return t.users[other(a)].Username < t.users[other(b)].Username
+	}
+}
+
+func (q *Queries) ListFollowers(ctx context.Context, arg db.ListFollowersParams) ([]db.ListFollowersRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	follower := func(f db.Follow) uuid.UUID { return f.FollowerID }
+	follows := values(q.t.follows,
+		func(f db.Follow) bool {
+			u, ok := q.t.users[f.FollowerID]
+			return f.FolloweeUserID.Valid && f.FolloweeUserID.UUID == arg.UserID && ok && u.DeletedAt == nil
+		},
+		q.t.latestFollowFirst(follower),
+	)
+
+	rows := []db.ListFollowersRow{}
+	for _, f := range page(follows, arg.PageOffset) {
+		u := q.t.users[f.FollowerID]
+		rows = append(rows, db.ListFollowersRow{
+			ID:          u.ID,
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			AvatarUrl:   u.AvatarUrl,
+			FollowedAt:  f.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) ListFollowedUsers(ctx context.Context, arg db.ListFollowedUsersParams) ([]db.ListFollowedUsersRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	followee := func(f db.Follow) uuid.UUID { return f.FolloweeUserID.UUID }
+	follows := values(q.t.follows,
+		func(f db.Follow) bool {
+			u, ok := q.t.users[f.FolloweeUserID.UUID]
+			return f.FollowerID == arg.UserID && f.FolloweeUserID.Valid && ok && u.DeletedAt == nil
+		},
+		q.t.latestFollowFirst(followee),
+	)
+
+	rows := []db.ListFollowedUsersRow{}
+	for _, f := range page(follows, arg.PageOffset) {
+		u := q.t.users[f.FolloweeUserID.UUID]
+		rows = append(rows, db.ListFollowedUsersRow{
+			ID:          u.ID,
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			AvatarUrl:   u.AvatarUrl,
+			FollowedAt:  f.CreatedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) ListFollowedIdentities(ctx context.Context, arg db.ListFollowedIdentitiesParams) ([]db.ListFollowedIdentitiesRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	follows := values(q.t.follows,
+		func(f db.Follow) bool { return f.FollowerID == arg.UserID && f.FolloweeIdentityID.Valid },
+		func(a, b db.Follow) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.FolloweeIdentityID.UUID, b.FolloweeIdentityID.UUID)
+		},
+	)
+
+	rows := []db.ListFollowedIdentitiesRow{}
+	for _, f := range page(follows, arg.PageOffset) {
+		rows = append(rows, db.ListFollowedIdentitiesRow{
+			IdentityID: f.FolloweeIdentityID.UUID,
+			FollowedAt: f.CreatedAt,
+		})
+	}
+	return rows, nil
+}
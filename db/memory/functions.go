@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The functions the migrations define for the queries.
+
+// authorDeleted is author_deleted, whether the user behind an identity deleted their account.
+func (t *tables) authorDeleted(identity uuid.UUID) bool {
+	ui, ok := t.identities[identity]
+	if !ok || !ui.UserID.Valid {
+		return false
+	}
+	u, ok := t.users[ui.UserID.UUID]
+	return ok && u.DeletedAt != nil
+}
+
+// hiddenFrom is hidden_from, whether content written under an identity is hidden from the viewer. Blocks
+// and mutes of a user only cover their public identities.
+func (t *tables) hiddenFrom(viewer, identity uuid.UUID, content string) bool {
+	if t.authorDeleted(identity) {
+		return true
+	}
+
+	ui := t.identities[identity]
+	for _, b := range t.blocks {
+		if b.UserID != viewer {
+			continue
+		}
+		if b.BlockedIdentityID.Valid && b.BlockedIdentityID.UUID == identity {
+			return true
+		}
+		if b.BlockedUserID.Valid && ui.IsPublic && ui.UserID.Valid && b.BlockedUserID.UUID == ui.UserID.UUID {
+			return true
+		}
+	}
+
+	content = strings.ToLower(content)
+	for _, mk := range t.mutedKeywords {
+		if mk.UserID == viewer && strings.Contains(content, strings.ToLower(mk.Keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hiddenByPreference is hidden_by_preference, whether the viewer chose to hide one of the categories of a post.
+func (t *tables) hiddenByPreference(viewer uuid.UUID, warnings []string, nsfw bool) bool {
+	for _, cp := range t.contentPreferences {
+		if cp.UserID != viewer || cp.Action != "hide" {
+			continue
+		}
+		if nsfw && cp.Category == "nsfw" {
+			return true
+		}
+		for _, w := range warnings {
+			if cp.Category == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commentTrashed is comment_trashed, whether a comment is in the trash itself, through one of the comments
+// it replies to or through its post.
+func (t *tables) commentTrashed(id uuid.UUID) bool {
+	for seen := map[uuid.UUID]bool{}; !seen[id]; {
+		seen[id] = true
+
+		c, ok := t.comments[id]
+		if !ok {
+			return false
+		}
+		if c.DeletedAt != nil {
+			return true
+		}
+		if p, ok := t.posts[c.PostID]; ok && p.DeletedAt != nil {
+			return true
+		}
+		// top level comments are their own parent
+		id = c.ParentID
+	}
+	return false
+}
+
+// digestPeriod is digest_period, the time between two digests of the frequency.
+func digestPeriod(frequency string) time.Duration {
+	if frequency == "daily" {
+		return 24 * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// identityOwnedBy reports whether the identity belongs to the user.
+func (t *tables) identityOwnedBy(identity, user uuid.UUID) bool {
+	ui, ok := t.identities[identity]
+	return ok && ui.UserID.Valid && ui.UserID.UUID == user
+}
+
+// hasDefaultIdentity reports whether the user has a default identity other than except, as
+// user_identities_default_idx allows one.
+func (t *tables) hasDefaultIdentity(user, except uuid.UUID) bool {
+	for _, ui := range t.identities {
+		if ui.IsDefault && ui.ID != except && ui.UserID.Valid && ui.UserID.UUID == user {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreateMedia(ctx context.Context, arg db.CreateMediaParams) (db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.media[arg.ID]; ok {
+		return db.Media{}, uniqueViolation("media_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID.UUID]; arg.UserID.Valid && !ok {
+		return db.Media{}, foreignKeyViolation("media", "media_user_id_fkey")
+	}
+	if _, ok := q.t.posts[arg.PostID.UUID]; arg.PostID.Valid && !ok {
+		return db.Media{}, foreignKeyViolation("media", "media_post_id_fkey")
+	}
+	if _, ok := q.t.messages[arg.MessageID.UUID]; arg.MessageID.Valid && !ok {
+		return db.Media{}, foreignKeyViolation("media", "media_message_id_fkey")
+	}
+
+	m := db.Media{
+		ID:           arg.ID,
+		Kind:         arg.Kind,
+		UserID:       arg.UserID,
+		PostID:       arg.PostID,
+		MessageID:    arg.MessageID,
+		StorageKey:   arg.StorageKey,
+		ThumbnailKey: arg.ThumbnailKey,
+		ContentType:  arg.ContentType,
+		Size:         arg.Size,
+		Width:        arg.Width,
+		Height:       arg.Height,
+		IsPrivate:    arg.IsPrivate,
+		CreatedAt:    now(),
+	}
+	q.t.media[m.ID] = m
+	return m, nil
+}
+
+func (q *Queries) GetMediaById(ctx context.Context, id uuid.UUID) (db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.media[id]
+	if !ok {
+		return db.Media{}, sql.ErrNoRows
+	}
+	return m, nil
+}
+
+func (q *Queries) ListMediaByPostId(ctx context.Context, postID uuid.NullUUID) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.media,
+		func(m db.Media) bool { return m.PostID.Valid && postID.Valid && m.PostID.UUID == postID.UUID },
+		oldestMediaFirst,
+	), nil
+}
+
+func (q *Queries) ListMediaByMessageId(ctx context.Context, messageID uuid.NullUUID) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.media,
+		func(m db.Media) bool {
+			return m.MessageID.Valid && messageID.Valid && m.MessageID.UUID == messageID.UUID
+		},
+		oldestMediaFirst,
+	), nil
+}
+
+func oldestMediaFirst(a, b db.Media) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return lessID(a.ID, b.ID)
+}
+
+func (q *Queries) CountMediaByPostId(ctx context.Context, postID uuid.NullUUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, m := range q.t.media {
+		if m.PostID.Valid && postID.Valid && m.PostID.UUID == postID.UUID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queries) DeleteMedia(ctx context.Context, id uuid.UUID) (db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.media[id]
+	if !ok {
+		return db.Media{}, sql.ErrNoRows
+	}
+
+	delete(q.t.media, id)
+	return m, nil
+}
+
+func (q *Queries) DeleteOldAvatars(ctx context.Context, arg db.DeleteOldAvatarsParams) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	old := values(q.t.media,
+		func(m db.Media) bool {
+			return m.Kind == "avatar" && m.UserID.Valid && m.UserID.UUID == arg.UserID && m.ID != arg.KeepID
+		},
+		oldestMediaFirst,
+	)
+	for _, m := range old {
+		delete(q.t.media, m.ID)
+	}
+	return old, nil
+}
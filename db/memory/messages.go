@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) GetMessageById(ctx context.Context, id uuid.UUID) (db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.messages[id]
+	if !ok || m.DeletedAt != nil {
+		return db.Message{}, sql.ErrNoRows
+	}
+	return m, nil
+}
+
+func (q *Queries) ListMessage(ctx context.Context, arg db.ListMessageParams) ([]db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := values(q.t.messages,
+		func(m db.Message) bool { return m.ReceiverID == arg.ReceiverID && m.DeletedAt == nil },
+		func(a, b db.Message) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(messages, arg.Offset), nil
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg db.CreateMessageParams) (db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.messages[arg.ID]; ok {
+		return db.Message{}, uniqueViolation("messages_pkey")
+	}
+	if _, ok := q.t.users[arg.ReceiverID]; !ok {
+		return db.Message{}, foreignKeyViolation("messages", "messages_receiver_id_fkey")
+	}
+
+	m := db.Message{
+		ID:         arg.ID,
+		ReceiverID: arg.ReceiverID,
+		Content:    arg.Content,
+		CreatedAt:  timestamp(arg.CreatedAt),
+		UpdatedAt:  timestamp(arg.UpdatedAt),
+	}
+	q.t.messages[m.ID] = m
+	return m, nil
+}
+
+func (q *Queries) UpdateMessageStatus(ctx context.Context, arg db.UpdateMessageStatusParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.messages[arg.ID]
+	if !ok || m.ReceiverID != arg.ReceiverID || m.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	m.Seen = true
+	m.UpdatedAt = timestamp(arg.UpdatedAt)
+	q.t.messages[m.ID] = m
+	return m.ID, nil
+}
+
+func (q *Queries) DeleteOneMessage(ctx context.Context, arg db.DeleteOneMessageParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.messages[arg.ID]
+	if !ok || m.ReceiverID != arg.ReceiverID || m.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	m.DeletedAt = ptr(now())
+	q.t.messages[m.ID] = m
+	return m.ID, nil
+}
+
+func (q *Queries) RestoreMessage(ctx context.Context, arg db.RestoreMessageParams) (db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	m, ok := q.t.messages[arg.ID]
+	if !ok || m.ReceiverID != arg.ReceiverID || m.DeletedAt == nil {
+		return db.Message{}, sql.ErrNoRows
+	}
+
+	m.DeletedAt = nil
+	q.t.messages[m.ID] = m
+	return m, nil
+}
+
+func (q *Queries) ListTrashedMessages(ctx context.Context, arg db.ListTrashedMessagesParams) ([]db.Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := values(q.t.messages,
+		func(m db.Message) bool { return m.ReceiverID == arg.ReceiverID && m.DeletedAt != nil },
+		func(a, b db.Message) bool {
+			if !a.DeletedAt.Equal(*b.DeletedAt) {
+				return a.DeletedAt.After(*b.DeletedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(messages, arg.Offset), nil
+}
+
+func (q *Queries) PurgeMessages(ctx context.Context, retentionSeconds float64) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	before := now().Add(-seconds(retentionSeconds))
+	purged := map[uuid.UUID]bool{}
+	for _, m := range q.t.messages {
+		if m.DeletedAt != nil && m.DeletedAt.Before(before) {
+			purged[m.ID] = true
+		}
+	}
+
+	media := values(q.t.media,
+		func(m db.Media) bool { return m.MessageID.Valid && purged[m.MessageID.UUID] },
+		oldestMediaFirst,
+	)
+	for id := range purged {
+		q.t.deleteMessage(id)
+	}
+	return media, nil
+}
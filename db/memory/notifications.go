@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreateNotification(ctx context.Context, arg db.CreateNotificationParams) (db.CreateNotificationRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if p, ok := q.t.notificationPreferences[nameKey{arg.UserID, arg.Type}]; ok && !p.Enabled {
+		return db.CreateNotificationRow{}, sql.ErrNoRows
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.CreateNotificationRow{}, foreignKeyViolation("notifications", "notifications_user_id_fkey")
+	}
+
+	for _, n := range q.t.notifications {
+		if n.UserID != arg.UserID || n.Type != arg.Type || n.SubjectID != arg.SubjectID || n.ReadAt != nil {
+			continue
+		}
+
+		counted := false
+		for _, actor := range n.Actors {
+			counted = counted || actor == arg.ActorID
+		}
+		if !counted {
+			n.Actors = append(append([]uuid.UUID{}, n.Actors...), arg.ActorID)
+		}
+		n.UpdatedAt = now()
+		q.t.notifications[n.ID] = n
+		return db.CreateNotificationRow(notificationRow(n)), nil
+	}
+
+	if _, ok := q.t.notifications[arg.ID]; ok {
+		return db.CreateNotificationRow{}, uniqueViolation("notifications_pkey")
+	}
+	n := db.Notification{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Type:      arg.Type,
+		SubjectID: arg.SubjectID,
+		Actors:    []uuid.UUID{arg.ActorID},
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	q.t.notifications[n.ID] = n
+	return db.CreateNotificationRow(notificationRow(n)), nil
+}
+
+// notificationRow is a notification as the queries return it, with its actors counted.
+func notificationRow(n db.Notification) db.ListNotificationsRow {
+	return db.ListNotificationsRow{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		Type:      n.Type,
+		SubjectID: n.SubjectID,
+		Count:     int64(len(n.Actors)),
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+	}
+}
+
+func (q *Queries) ListNotifications(ctx context.Context, arg db.ListNotificationsParams) ([]db.ListNotificationsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	notifications := values(q.t.notifications,
+		func(n db.Notification) bool { return n.UserID == arg.UserID && (!arg.UnreadOnly || n.ReadAt == nil) },
+		func(a, b db.Notification) bool {
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+
+	rows := []db.ListNotificationsRow{}
+	for _, n := range page(notifications, arg.PageOffset) {
+		rows = append(rows, notificationRow(n))
+	}
+	return rows, nil
+}
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, notification := range q.t.notifications {
+		if notification.UserID == userID && notification.ReadAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg db.MarkNotificationReadParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n, ok := q.t.notifications[arg.ID]
+	if !ok || n.UserID != arg.UserID {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	if n.ReadAt == nil {
+		n.ReadAt = ptr(now())
+		q.t.notifications[n.ID] = n
+	}
+	return n.ID, nil
+}
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var marked int64
+	for _, n := range q.t.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = ptr(now())
+			q.t.notifications[n.ID] = n
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (q *Queries) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]db.NotificationPreference, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.notificationPreferences,
+		func(p db.NotificationPreference) bool { return p.UserID == userID },
+		func(a, b db.NotificationPreference) bool { return a.Type < b.Type },
+	), nil
+}
+
+func (q *Queries) SetNotificationPreferences(ctx context.Context, arg db.SetNotificationPreferencesParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.users[arg.UserID]; !ok && len(arg.Types) > 0 {
+		return foreignKeyViolation("notification_preferences", "notification_preferences_user_id_fkey")
+	}
+
+	for i := 0; i < len(arg.Types) && i < len(arg.Enabled); i++ {
+		q.t.notificationPreferences[nameKey{arg.UserID, arg.Types[i]}] = db.NotificationPreference{
+			UserID:  arg.UserID,
+			Type:    arg.Types[i],
+			Enabled: arg.Enabled[i],
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreatePoll(ctx context.Context, arg db.CreatePollParams) (db.Poll, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.polls[arg.PostID]; ok {
+		return db.Poll{}, uniqueViolation("polls_pkey")
+	}
+	if _, ok := q.t.posts[arg.PostID]; !ok {
+		return db.Poll{}, foreignKeyViolation("polls", "polls_post_id_fkey")
+	}
+
+	p := db.Poll{
+		PostID:    arg.PostID,
+		Multiple:  arg.Multiple,
+		CreatedAt: now(),
+	}
+	if arg.ClosesAt != nil {
+		p.ClosesAt = ptr(timestamp(*arg.ClosesAt))
+	}
+	q.t.polls[p.PostID] = p
+	return p, nil
+}
+
+func (q *Queries) CreatePollOptions(ctx context.Context, arg db.CreatePollOptionsParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.polls[arg.PostID]; !ok {
+		return foreignKeyViolation("poll_options", "poll_options_post_id_fkey")
+	}
+
+	options := []db.PollOption{}
+	for i := 0; i < len(arg.Ids) && i < len(arg.Texts); i++ {
+		if _, ok := q.t.pollOptions[arg.Ids[i]]; ok {
+			return uniqueViolation("poll_options_pkey")
+		}
+		options = append(options, db.PollOption{
+			ID:       arg.Ids[i],
+			PostID:   arg.PostID,
+			Position: int32(i + 1),
+			Text:     arg.Texts[i],
+		})
+	}
+	for _, o := range q.t.pollOptions {
+		if o.PostID == arg.PostID && int(o.Position) <= len(options) {
+			return uniqueViolation("poll_options_post_id_position_key")
+		}
+	}
+
+	for _, o := range options {
+		q.t.pollOptions[o.ID] = o
+	}
+	return nil
+}
+
+// pollClosed reports whether the poll no longer takes ballots.
+func pollClosed(p db.Poll) bool {
+	return p.ClosesAt != nil && !p.ClosesAt.After(now())
+}
+
+func (q *Queries) ListPolls(ctx context.Context, arg db.ListPollsParams) ([]db.ListPollsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	postIDs := map[uuid.UUID]bool{}
+	for _, id := range arg.PostIds {
+		postIDs[id] = true
+	}
+
+	polls := values(q.t.polls,
+		func(p db.Poll) bool { return postIDs[p.PostID] },
+		func(a, b db.Poll) bool { return lessID(a.PostID, b.PostID) },
+	)
+
+	rows := []db.ListPollsRow{}
+	for _, p := range polls {
+		row := db.ListPollsRow{
+			PostID:   p.PostID,
+			Multiple: p.Multiple,
+			ClosesAt: p.ClosesAt,
+			Closed:   pollClosed(p),
+		}
+		for k := range q.t.pollBallots {
+			if k.a != p.PostID {
+				continue
+			}
+			row.Voters++
+			if q.t.identityOwnedBy(k.b, arg.ViewerID) {
+				row.Voted = true
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (q *Queries) ListPollOptions(ctx context.Context, arg db.ListPollOptionsParams) ([]db.ListPollOptionsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	postIDs := map[uuid.UUID]bool{}
+	for _, id := range arg.PostIds {
+		postIDs[id] = true
+	}
+
+	options := values(q.t.pollOptions,
+		func(o db.PollOption) bool { return postIDs[o.PostID] },
+		func(a, b db.PollOption) bool {
+			if a.PostID != b.PostID {
+				return lessID(a.PostID, b.PostID)
+			}
+			return a.Position < b.Position
+		},
+	)
+
+	rows := []db.ListPollOptionsRow{}
+	for _, o := range options {
+		row := db.ListPollOptionsRow{
+			ID:       o.ID,
+			PostID:   o.PostID,
+			Position: o.Position,
+			Text:     o.Text,
+		}
+		for v := range q.t.pollVotes {
+			if v.optionID != o.ID {
+				continue
+			}
+			row.Votes++
+			if q.t.identityOwnedBy(v.identityID, arg.ViewerID) {
+				row.Voted = true
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (q *Queries) CastPollBallot(ctx context.Context, arg db.CastPollBallotParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.polls[arg.PostID]
+	if !ok || pollClosed(p) {
+		return 0, nil
+	}
+
+	// one ballot per user, whichever of their identities cast it
+	for key := range q.t.pollBallots {
+		if key.a == arg.PostID && key.b != arg.UserIdentityID && q.t.identityOwnedBy(key.b, arg.UserID) {
+			return 0, nil
+		}
+	}
+
+	ballot := pairKey{arg.PostID, arg.UserIdentityID}
+	if _, ok := q.t.pollBallots[ballot]; ok {
+		return 0, uniqueViolation("poll_ballots_pkey")
+	}
+	if _, ok := q.t.identities[arg.UserIdentityID]; !ok {
+		return 0, foreignKeyViolation("poll_ballots", "poll_ballots_user_identity_id_fkey")
+	}
+
+	votes := map[voteKey]bool{}
+	for _, optionID := range arg.OptionIds {
+		vote := voteKey{arg.PostID, arg.UserIdentityID, optionID}
+		if votes[vote] {
+			return 0, uniqueViolation("poll_votes_pkey")
+		}
+		if o, ok := q.t.pollOptions[optionID]; !ok || o.PostID != arg.PostID {
+			return 0, foreignKeyViolation("poll_votes", "poll_votes_post_id_option_id_fkey")
+		}
+		votes[vote] = true
+	}
+
+	q.t.pollBallots[ballot] = db.PollBallot{
+		PostID:         arg.PostID,
+		UserIdentityID: arg.UserIdentityID,
+		CreatedAt:      now(),
+	}
+	for vote := range votes {
+		q.t.pollVotes[vote] = db.PollVote{
+			PostID:         vote.postID,
+			UserIdentityID: vote.identityID,
+			OptionID:       vote.optionID,
+		}
+	}
+	return int64(len(votes)), nil
+}
@@ -0,0 +1,458 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// visiblePost reports whether the post is listed to the viewer, published and neither hidden from them
+// nor of a category they hide.
+func (t *tables) visiblePost(viewer uuid.UUID, p db.Post) bool {
+	return p.DeletedAt == nil && p.Status == "published" &&
+		!t.hiddenFrom(viewer, p.UserIdentityID, p.Content) &&
+		!t.hiddenByPreference(viewer, p.ContentWarnings, p.Nsfw)
+}
+
+func latestPostFirst(a, b db.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return lessID(b.ID, a.ID)
+}
+
+func oldestPostFirst(a, b db.Post) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return lessID(a.ID, b.ID)
+}
+
+// byScore orders posts by score, highest first, then latest first.
+func byScore(score func(db.Post) float64) func(a, b db.Post) bool {
+	return func(a, b db.Post) bool {
+		if sa, sb := score(a), score(b); sa != sb {
+			return sa > sb
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return lessID(a.ID, b.ID)
+	}
+}
+
+// createdInLast reports whether the post was created in the last days, days 0 means all time.
+func createdInLast(days int32, p db.Post) bool {
+	return days == 0 || !p.CreatedAt.Before(now().AddDate(0, 0, -int(days)))
+}
+
+func (q *Queries) ListAllPosts(ctx context.Context, arg db.ListAllPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return q.t.visiblePost(arg.ViewerID, p) },
+		latestPostFirst,
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) ListPublicPostsByUserId(ctx context.Context, arg db.ListPublicPostsByUserIdParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool {
+			return q.t.identityOwnedBy(p.UserIdentityID, arg.UserID) && q.t.identities[p.UserIdentityID].IsPublic &&
+				p.DeletedAt == nil && p.Status == "published" && !q.t.authorDeleted(p.UserIdentityID) &&
+				!q.t.hiddenByPreference(arg.ViewerID, p.ContentWarnings, p.Nsfw)
+		},
+		latestPostFirst,
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[id]
+	if !ok || p.DeletedAt != nil || p.Status != "published" || q.t.authorDeleted(p.UserIdentityID) {
+		return db.Post{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *Queries) GetPostByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[id]
+	if !ok {
+		return db.Post{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *Queries) CreatePost(ctx context.Context, arg db.CreatePostParams) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.posts[arg.ID]; ok {
+		return db.Post{}, uniqueViolation("posts_pkey")
+	}
+	if _, ok := q.t.identities[arg.UserIdentityID]; !ok {
+		return db.Post{}, foreignKeyViolation("posts", "posts_user_identity_id_fkey")
+	}
+
+	p := db.Post{
+		ID:              arg.ID,
+		Content:         arg.Content,
+		UserIdentityID:  arg.UserIdentityID,
+		CreatedAt:       now(),
+		UpdatedAt:       now(),
+		Revision:        1,
+		Status:          arg.Status,
+		ContentWarnings: append([]string{}, arg.ContentWarnings...),
+		Nsfw:            arg.Nsfw,
+	}
+	if arg.PublishAt != nil {
+		p.PublishAt = ptr(timestamp(*arg.PublishAt))
+	}
+	q.t.posts[p.ID] = p
+	return p, nil
+}
+
+func (q *Queries) UpdatePost(ctx context.Context, arg db.UpdatePostParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[arg.ID]
+	if !ok || p.DeletedAt != nil || p.Status != "published" {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	p.Content = arg.Content
+	p.Revision++
+	p.Edited = true
+	p.UpdatedAt = now()
+	if err := q.t.addPostRevision(p, arg.EditorIdentityID); err != nil {
+		return uuid.Nil, err
+	}
+	q.t.posts[p.ID] = p
+	return p.ID, nil
+}
+
+// addPostRevision records the content of the post as its current revision, written by the identity.
+func (t *tables) addPostRevision(p db.Post, identity uuid.UUID) error {
+	key := revisionKey{p.ID, p.Revision}
+	if _, ok := t.postRevisions[key]; ok {
+		return uniqueViolation("post_revisions_pkey")
+	}
+	if _, ok := t.identities[identity]; !ok {
+		return foreignKeyViolation("post_revisions", "post_revisions_user_identity_id_fkey")
+	}
+
+	t.postRevisions[key] = db.PostRevision{
+		PostID:         p.ID,
+		Revision:       p.Revision,
+		UserIdentityID: identity,
+		Content:        p.Content,
+		CreatedAt:      now(),
+	}
+	return nil
+}
+
+func (q *Queries) DeletePost(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[id]
+	if !ok || p.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	p.DeletedAt = ptr(now())
+	q.t.posts[p.ID] = p
+	return p.ID, nil
+}
+
+func (q *Queries) RestorePost(ctx context.Context, arg db.RestorePostParams) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[arg.ID]
+	if !ok || p.DeletedAt == nil || !q.t.identityOwnedBy(p.UserIdentityID, arg.UserID) {
+		return db.Post{}, sql.ErrNoRows
+	}
+
+	p.DeletedAt = nil
+	q.t.posts[p.ID] = p
+	return p, nil
+}
+
+func (q *Queries) ListTrashedPosts(ctx context.Context, arg db.ListTrashedPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return p.DeletedAt != nil && q.t.identityOwnedBy(p.UserIdentityID, arg.UserID) },
+		func(a, b db.Post) bool {
+			if !a.DeletedAt.Equal(*b.DeletedAt) {
+				return a.DeletedAt.After(*b.DeletedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) PurgePosts(ctx context.Context, retentionSeconds float64) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	before := now().Add(-seconds(retentionSeconds))
+	purged := map[uuid.UUID]bool{}
+	for _, p := range q.t.posts {
+		if p.DeletedAt != nil && p.DeletedAt.Before(before) {
+			purged[p.ID] = true
+		}
+	}
+
+	media := values(q.t.media,
+		func(m db.Media) bool { return m.PostID.Valid && purged[m.PostID.UUID] },
+		oldestMediaFirst,
+	)
+	for id := range purged {
+		q.t.deletePost(id)
+	}
+	return media, nil
+}
+
+// inFeed reports whether the post is by an identity the user follows, or by a user they follow under
+// one of their public identities.
+func (t *tables) inFeed(userID uuid.UUID, p db.Post) bool {
+	ui := t.identities[p.UserIdentityID]
+	for _, f := range t.follows {
+		if f.FollowerID != userID {
+			continue
+		}
+		if f.FolloweeIdentityID.Valid && f.FolloweeIdentityID.UUID == p.UserIdentityID {
+			return true
+		}
+		if f.FolloweeUserID.Valid && ui.IsPublic && ui.UserID.Valid && f.FolloweeUserID.UUID == ui.UserID.UUID {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *Queries) ListFeedPosts(ctx context.Context, arg db.ListFeedPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return q.t.inFeed(arg.UserID, p) && q.t.visiblePost(arg.UserID, p) },
+		latestPostFirst,
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) HasFeedPosts(ctx context.Context, userID uuid.UUID) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, p := range q.t.posts {
+		if q.t.inFeed(userID, p) && q.t.visiblePost(userID, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (q *Queries) ListTrendingPosts(ctx context.Context, arg db.ListTrendingPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	weekAgo := now().AddDate(0, 0, -7)
+	comments := map[uuid.UUID]int{}
+	for _, c := range q.t.comments {
+		if !c.CreatedAt.Before(weekAgo) && c.DeletedAt == nil {
+			comments[c.PostID]++
+		}
+	}
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return q.t.visiblePost(arg.ViewerID, p) },
+		byScore(func(p db.Post) float64 { return float64(comments[p.ID]) }),
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) ListHotPosts(ctx context.Context, arg db.ListHotPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return q.t.visiblePost(arg.ViewerID, p) },
+		byScore(func(p db.Post) float64 { return q.t.scores[p.ID].Hot }),
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) ListTopPosts(ctx context.Context, arg db.ListTopPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return createdInLast(arg.Days, p) && q.t.visiblePost(arg.ViewerID, p) },
+		byScore(func(p db.Post) float64 {
+			s := q.t.scores[p.ID]
+			return float64(s.Likes - s.Dislikes)
+		}),
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) ListControversialPosts(ctx context.Context, arg db.ListControversialPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool { return createdInLast(arg.Days, p) && q.t.visiblePost(arg.ViewerID, p) },
+		byScore(func(p db.Post) float64 { return q.t.scores[p.ID].Controversy }),
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) ListDraftPosts(ctx context.Context, arg db.ListDraftPostsParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool {
+			return q.t.identityOwnedBy(p.UserIdentityID, arg.UserID) && p.Status != "published" && p.DeletedAt == nil
+		},
+		func(a, b db.Post) bool {
+			switch {
+			case a.PublishAt != nil && b.PublishAt != nil && !a.PublishAt.Equal(*b.PublishAt):
+				return a.PublishAt.Before(*b.PublishAt)
+			case (a.PublishAt == nil) != (b.PublishAt == nil):
+				return a.PublishAt != nil
+			case !a.UpdatedAt.Equal(b.UpdatedAt):
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+func (q *Queries) GetDraftPost(ctx context.Context, id uuid.UUID) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[id]
+	if !ok || p.Status == "published" || p.DeletedAt != nil {
+		return db.Post{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (q *Queries) UpdateDraftPost(ctx context.Context, arg db.UpdateDraftPostParams) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[arg.ID]
+	if !ok || p.Status == "published" || p.DeletedAt != nil {
+		return db.Post{}, sql.ErrNoRows
+	}
+
+	p.Content = arg.Content
+	p.Status = arg.Status
+	p.PublishAt = nil
+	if arg.PublishAt != nil {
+		p.PublishAt = ptr(timestamp(*arg.PublishAt))
+	}
+	p.UpdatedAt = now()
+	q.t.posts[p.ID] = p
+	return p, nil
+}
+
+func (q *Queries) PublishDraftPost(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[id]
+	if !ok || p.Status == "published" || p.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	if err := q.t.publish(p); err != nil {
+		return uuid.Nil, err
+	}
+	return p.ID, nil
+}
+
+// publish publishes the draft dated from now, its revision history starts there.
+func (t *tables) publish(p db.Post) error {
+	p.Status = "published"
+	p.PublishAt = nil
+	p.CreatedAt = now()
+	p.UpdatedAt = now()
+	if err := t.addPostRevision(p, p.UserIdentityID); err != nil {
+		return err
+	}
+	t.posts[p.ID] = p
+	return nil
+}
+
+func (q *Queries) PublishDuePosts(ctx context.Context, batchSize int32) ([]uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := values(q.t.posts,
+		func(p db.Post) bool {
+			return p.Status == "scheduled" && p.PublishAt != nil && !p.PublishAt.After(now()) && p.DeletedAt == nil
+		},
+		func(a, b db.Post) bool {
+			if !a.PublishAt.Equal(*b.PublishAt) {
+				return a.PublishAt.Before(*b.PublishAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+
+	// the statement publishes all of them or none
+	saved := q.t.clone()
+	ids := []uuid.UUID{}
+	for _, p := range limit(0, due, int(batchSize)) {
+		if err := q.t.publish(p); err != nil {
+			*q.t = *saved
+			return nil, err
+		}
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+func (q *Queries) SetPostContentFlags(ctx context.Context, arg db.SetPostContentFlagsParams) (db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	p, ok := q.t.posts[arg.ID]
+	if !ok || p.DeletedAt != nil || !arg.Moderated && p.FlagsModeratedAt != nil {
+		return db.Post{}, sql.ErrNoRows
+	}
+
+	p.ContentWarnings = append([]string{}, arg.ContentWarnings...)
+	p.Nsfw = arg.Nsfw
+	if arg.Moderated {
+		p.FlagsModeratedAt = ptr(now())
+	}
+	q.t.posts[p.ID] = p
+	return p, nil
+}
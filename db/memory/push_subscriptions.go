@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreatePushSubscription(ctx context.Context, arg db.CreatePushSubscriptionParams) (db.PushSubscription, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.PushSubscription{}, foreignKeyViolation("push_subscriptions", "push_subscriptions_user_id_fkey")
+	}
+
+	// the browser subscribing again takes its endpoint over
+	for _, ps := range q.t.pushSubscriptions {
+		if ps.Endpoint == arg.Endpoint {
+			ps.UserID = arg.UserID
+			ps.P256dh = arg.P256dh
+			ps.Auth = arg.Auth
+			ps.UpdatedAt = now()
+			q.t.pushSubscriptions[ps.ID] = ps
+			return ps, nil
+		}
+	}
+	if _, ok := q.t.pushSubscriptions[arg.ID]; ok {
+		return db.PushSubscription{}, uniqueViolation("push_subscriptions_pkey")
+	}
+
+	ps := db.PushSubscription{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Endpoint:  arg.Endpoint,
+		P256dh:    arg.P256dh,
+		Auth:      arg.Auth,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	q.t.pushSubscriptions[ps.ID] = ps
+	return ps, nil
+}
+
+func (q *Queries) ListPushSubscriptions(ctx context.Context, userID uuid.UUID) ([]db.PushSubscription, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.pushSubscriptions,
+		func(ps db.PushSubscription) bool { return ps.UserID == userID },
+		func(a, b db.PushSubscription) bool { return lessID(a.ID, b.ID) },
+	), nil
+}
+
+func (q *Queries) DeletePushSubscription(ctx context.Context, arg db.DeletePushSubscriptionParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, ps := range q.t.pushSubscriptions {
+		if ps.Endpoint == arg.Endpoint && ps.UserID == arg.UserID {
+			delete(q.t.pushSubscriptions, ps.ID)
+			return ps.ID, nil
+		}
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+func (q *Queries) DeleteGonePushSubscription(ctx context.Context, id uuid.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.t.pushSubscriptions, id)
+	return nil
+}
+
+func (q *Queries) DeleteUserPushSubscriptions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, ps := range q.t.pushSubscriptions {
+		if ps.UserID == userID {
+			delete(q.t.pushSubscriptions, ps.ID)
+			n++
+		}
+	}
+	return n, nil
+}
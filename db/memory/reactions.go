@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) SetPostReaction(ctx context.Context, arg db.SetPostReactionParams) (db.PostReaction, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.posts[arg.PostID]; !ok {
+		return db.PostReaction{}, foreignKeyViolation("post_reactions", "post_reactions_post_id_fkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.PostReaction{}, foreignKeyViolation("post_reactions", "post_reactions_user_id_fkey")
+	}
+
+	key := pairKey{arg.PostID, arg.UserID}
+	r, ok := q.t.reactions[key]
+	if !ok {
+		r = db.PostReaction{PostID: arg.PostID, UserID: arg.UserID, CreatedAt: now()}
+	}
+	r.Reaction = arg.Reaction
+	r.UpdatedAt = now()
+	q.t.reactions[key] = r
+	return r, nil
+}
+
+func (q *Queries) DeletePostReaction(ctx context.Context, arg db.DeletePostReactionParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := pairKey{arg.PostID, arg.UserID}
+	if _, ok := q.t.reactions[key]; !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	delete(q.t.reactions, key)
+	q.t.markScoreDirty(arg.PostID)
+	return arg.PostID, nil
+}
+
+// markScoreDirty has the score of the post refreshed next time, for the changes that leave no timestamp.
+func (t *tables) markScoreDirty(postID uuid.UUID) {
+	if score, ok := t.scores[postID]; ok {
+		score.Dirty = true
+		t.scores[postID] = score
+	}
+}
+
+func (q *Queries) GetPostReaction(ctx context.Context, arg db.GetPostReactionParams) (db.PostReaction, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	r, ok := q.t.reactions[pairKey{arg.PostID, arg.UserID}]
+	if !ok {
+		return db.PostReaction{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (q *Queries) CountPostReactions(ctx context.Context, postID uuid.UUID) (db.CountPostReactionsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.countReactions(postID), nil
+}
+
+func (t *tables) countReactions(postID uuid.UUID) db.CountPostReactionsRow {
+	var row db.CountPostReactionsRow
+	for _, r := range t.reactions {
+		if r.PostID != postID {
+			continue
+		}
+		switch r.Reaction {
+		case db.SatisfactionLIKE:
+			row.Likes++
+		case db.SatisfactionDISLIKE:
+			row.Dislikes++
+		}
+	}
+	return row
+}
+
+func (q *Queries) RefreshPostScores(ctx context.Context, arg db.RefreshPostScoresParams) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	refreshed := now()
+	window := refreshed.Add(-time.Duration(arg.WindowSeconds * float64(time.Second)))
+
+	// the posts marked dirty, reacted to or commented on since they were scored, see reactions.sql
+	changed := map[uuid.UUID]bool{}
+	comments := map[uuid.UUID]int64{}
+	for _, c := range q.t.comments {
+		if c.DeletedAt == nil {
+			comments[c.PostID]++
+		}
+		if score, ok := q.t.scores[c.PostID]; ok && (!c.CreatedAt.Before(score.RefreshedAt) ||
+			c.DeletedAt != nil && !c.DeletedAt.Before(score.RefreshedAt)) {
+			changed[c.PostID] = true
+		}
+	}
+	for _, r := range q.t.reactions {
+		if score, ok := q.t.scores[r.PostID]; ok && !r.UpdatedAt.Before(score.RefreshedAt) {
+			changed[r.PostID] = true
+		}
+	}
+	for _, score := range q.t.scores {
+		if score.Dirty {
+			changed[score.PostID] = true
+		}
+	}
+
+	var rows int64
+	for _, p := range q.t.posts {
+		if _, scored := q.t.scores[p.ID]; scored && !p.CreatedAt.After(window) && !changed[p.ID] {
+			continue
+		}
+
+		counts := q.t.countReactions(p.ID)
+		likes, dislikes := float64(counts.Likes), float64(counts.Dislikes)
+
+		// the same formulas as the query
+		hours := refreshed.Sub(p.CreatedAt).Hours()
+		hot := (likes - dislikes + float64(comments[p.ID])) / math.Pow(hours+2, arg.Gravity)
+		controversy := 0.0
+		if likes != 0 && dislikes != 0 {
+			controversy = math.Pow(likes+dislikes, math.Min(likes, dislikes)/math.Max(likes, dislikes))
+		}
+
+		q.t.scores[p.ID] = db.PostScore{
+			PostID:      p.ID,
+			Likes:       int32(counts.Likes),
+			Dislikes:    int32(counts.Dislikes),
+			Comments:    int32(comments[p.ID]),
+			Hot:         hot,
+			Controversy: controversy,
+			RefreshedAt: refreshed,
+		}
+		rows++
+	}
+	return rows, nil
+}
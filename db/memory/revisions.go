@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreatePostRevision(ctx context.Context, arg db.CreatePostRevisionParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.posts[arg.PostID]; !ok {
+		return foreignKeyViolation("post_revisions", "post_revisions_post_id_fkey")
+	}
+	return q.t.addPostRevision(db.Post{ID: arg.PostID, Revision: 1, Content: arg.Content}, arg.UserIdentityID)
+}
+
+func (q *Queries) ListPostRevisions(ctx context.Context, postID uuid.UUID) ([]db.PostRevision, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.postRevisions,
+		func(r db.PostRevision) bool { return r.PostID == postID },
+		func(a, b db.PostRevision) bool { return a.Revision > b.Revision },
+	), nil
+}
+
+func (q *Queries) GetPostRevision(ctx context.Context, arg db.GetPostRevisionParams) (db.PostRevision, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	r, ok := q.t.postRevisions[revisionKey{arg.PostID, arg.Revision}]
+	if !ok {
+		return db.PostRevision{}, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+func (q *Queries) CreateCommentRevision(ctx context.Context, arg db.CreateCommentRevisionParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := revisionKey{arg.CommentID, 1}
+	if _, ok := q.t.commentRevisions[key]; ok {
+		return uniqueViolation("comment_revisions_pkey")
+	}
+	if _, ok := q.t.comments[arg.CommentID]; !ok {
+		return foreignKeyViolation("comment_revisions", "comment_revisions_comment_id_fkey")
+	}
+	if _, ok := q.t.identities[arg.UserIdentityID]; !ok {
+		return foreignKeyViolation("comment_revisions", "comment_revisions_user_identity_id_fkey")
+	}
+
+	q.t.commentRevisions[key] = db.CommentRevision{
+		CommentID:      arg.CommentID,
+		Revision:       1,
+		UserIdentityID: arg.UserIdentityID,
+		Content:        arg.Content,
+		CreatedAt:      now(),
+	}
+	return nil
+}
+
+func (q *Queries) ListCommentRevisions(ctx context.Context, commentID uuid.UUID) ([]db.CommentRevision, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.commentRevisions,
+		func(r db.CommentRevision) bool { return r.CommentID == commentID },
+		func(a, b db.CommentRevision) bool { return a.Revision > b.Revision },
+	), nil
+}
+
+func (q *Queries) GetCommentRevision(ctx context.Context, arg db.GetCommentRevisionParams) (db.CommentRevision, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	r, ok := q.t.commentRevisions[revisionKey{arg.CommentID, arg.Revision}]
+	if !ok {
+		return db.CommentRevision{}, sql.ErrNoRows
+	}
+	return r, nil
+}
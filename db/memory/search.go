@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+// The search here understands the part of the to_tsquery syntax the search handler writes: terms joined by
+// & or |, phrases of words joined by <->, negated terms in !( ) and prefixes ending in :*. Words are matched
+// without the stemming and stop words of the english configuration, and the rank only counts the matches,
+// so results come in roughly but not exactly the order postgres gives them.
+
+// lexeme is a word of a query, matching the whole word or any word it starts.
+type lexeme struct {
+	word   string
+	prefix bool
+}
+
+func (l lexeme) matches(word string) bool {
+	if l.prefix {
+		return strings.HasPrefix(word, l.word)
+	}
+	return word == l.word
+}
+
+// term is a phrase of a query, a single word when it has one lexeme.
+type term struct {
+	phrase  []lexeme
+	negated bool
+}
+
+// at reports whether the phrase starts at the i-th word.
+func (t term) at(words []string, i int) bool {
+	if i+len(t.phrase) > len(words) {
+		return false
+	}
+	for k, l := range t.phrase {
+		if !l.matches(words[i+k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// tsquery is a query in disjunctive form, matching the documents one of its groups of terms all match.
+type tsquery [][]term
+
+func parseTsquery(query string) tsquery {
+	var q tsquery
+	for _, group := range strings.Split(query, " | ") {
+		var terms []term
+		for _, s := range strings.Split(group, " & ") {
+			t := term{}
+			if strings.HasPrefix(s, "!(") && strings.HasSuffix(s, ")") {
+				t.negated = true
+				s = s[2 : len(s)-1]
+			}
+			for _, w := range strings.Split(s, " <-> ") {
+				l := lexeme{word: strings.ToLower(strings.TrimSpace(w))}
+				if strings.HasSuffix(l.word, ":*") {
+					l.word, l.prefix = strings.TrimSuffix(l.word, ":*"), true
+				}
+				t.phrase = append(t.phrase, l)
+			}
+			terms = append(terms, t)
+		}
+		q = append(q, terms)
+	}
+	return q
+}
+
+// words splits text into its lower case words, as the search vectors do.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// search matches the text against the query. It returns the rank of the text and which of its words the
+// matched terms cover, and ok is false when the text does not match.
+func (q tsquery) search(text string) (rank float32, marked []bool, ok bool) {
+	ws := words(text)
+	marked = make([]bool, len(ws))
+
+	for _, group := range q {
+		groupMatched := true
+		for _, t := range group {
+			found := false
+			for i := range ws {
+				if t.at(ws, i) {
+					found = true
+					break
+				}
+			}
+			if found == t.negated {
+				groupMatched = false
+				break
+			}
+		}
+		if !groupMatched {
+			continue
+		}
+		ok = true
+
+		for _, t := range group {
+			if t.negated {
+				continue
+			}
+			for i := range ws {
+				if t.at(ws, i) {
+					rank += 0.1
+					for k := range t.phrase {
+						marked[i+k] = true
+					}
+				}
+			}
+		}
+	}
+	return rank, marked, ok
+}
+
+// headline wraps the marked words of the text in <mark>, as ts_headline does.
+func headline(text string, marked []bool) string {
+	var b strings.Builder
+	word, inWord := 0, false
+	for _, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && !inWord {
+			inWord = true
+			if word < len(marked) && marked[word] {
+				b.WriteString("<mark>")
+			}
+		} else if !isWordRune && inWord {
+			inWord = false
+			if word < len(marked) && marked[word] {
+				b.WriteString("</mark>")
+			}
+			word++
+		}
+		b.WriteRune(r)
+	}
+	if inWord && word < len(marked) && marked[word] {
+		b.WriteString("</mark>")
+	}
+	return b.String()
+}
+
+// beforeCursor is (rank, id) < (cursor_rank, cursor_id) of the keyset pagination, ranks compared as real.
+func beforeCursor(rank float32, id uuid.UUID, cursorRank float64, cursorID uuid.UUID) bool {
+	if rank != float32(cursorRank) {
+		return rank < float32(cursorRank)
+	}
+	return lessID(id, cursorID)
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg db.SearchPostsParams) ([]db.SearchPostsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	query := parseTsquery(arg.Query)
+	results := map[uuid.UUID]db.SearchPostsRow{}
+	for _, p := range q.t.posts {
+		switch {
+		case arg.IdentityID.Valid && p.UserIdentityID != arg.IdentityID.UUID,
+			arg.CreatedFrom.Valid && p.CreatedAt.Before(date(arg.CreatedFrom.Time)),
+			arg.CreatedTo.Valid && !p.CreatedAt.Before(date(arg.CreatedTo.Time).AddDate(0, 0, 1)),
+			!q.t.visiblePost(arg.ViewerID, p):
+			continue
+		}
+
+		rank, marked, ok := query.search(p.Content)
+		if !ok || arg.CursorID.Valid && !beforeCursor(rank, p.ID, arg.CursorRank.Float64, arg.CursorID.UUID) {
+			continue
+		}
+		results[p.ID] = db.SearchPostsRow{
+			ID:               p.ID,
+			Content:          p.Content,
+			UserIdentityID:   p.UserIdentityID,
+			CreatedAt:        p.CreatedAt,
+			UpdatedAt:        p.UpdatedAt,
+			Revision:         p.Revision,
+			Edited:           p.Edited,
+			DeletedAt:        p.DeletedAt,
+			Status:           p.Status,
+			PublishAt:        p.PublishAt,
+			ContentWarnings:  p.ContentWarnings,
+			Nsfw:             p.Nsfw,
+			FlagsModeratedAt: p.FlagsModeratedAt,
+			Rank:             rank,
+			Snippet:          headline(p.Content, marked),
+		}
+	}
+
+	rows := values(results,
+		func(db.SearchPostsRow) bool { return true },
+		func(a, b db.SearchPostsRow) bool { return beforeCursor(b.Rank, b.ID, float64(a.Rank), a.ID) },
+	)
+	return limit(0, rows, pageSize), nil
+}
+
+func (q *Queries) SearchComments(ctx context.Context, arg db.SearchCommentsParams) ([]db.SearchCommentsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	query := parseTsquery(arg.Query)
+	results := map[uuid.UUID]db.SearchCommentsRow{}
+	for _, c := range q.t.comments {
+		switch {
+		case arg.IdentityID.Valid && c.UserIdentityID != arg.IdentityID.UUID,
+			arg.CreatedFrom.Valid && c.CreatedAt.Before(date(arg.CreatedFrom.Time)),
+			arg.CreatedTo.Valid && !c.CreatedAt.Before(date(arg.CreatedTo.Time).AddDate(0, 0, 1)),
+			q.t.commentTrashed(c.ID),
+			q.t.hiddenFrom(arg.ViewerID, c.UserIdentityID, c.Content):
+			continue
+		}
+
+		rank, marked, ok := query.search(c.Content)
+		if !ok || arg.CursorID.Valid && !beforeCursor(rank, c.ID, arg.CursorRank.Float64, arg.CursorID.UUID) {
+			continue
+		}
+		results[c.ID] = db.SearchCommentsRow{
+			ID:             c.ID,
+			Content:        c.Content,
+			UserIdentityID: c.UserIdentityID,
+			PostID:         c.PostID,
+			ParentID:       c.ParentID,
+			CreatedAt:      c.CreatedAt,
+			UpdatedAt:      c.UpdatedAt,
+			Rank:           rank,
+			Snippet:        headline(c.Content, marked),
+		}
+	}
+
+	rows := values(results,
+		func(db.SearchCommentsRow) bool { return true },
+		func(a, b db.SearchCommentsRow) bool { return beforeCursor(b.Rank, b.ID, float64(a.Rank), a.ID) },
+	)
+	return limit(0, rows, pageSize), nil
+}
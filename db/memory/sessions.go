@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) GetSessionById(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s, ok := q.t.sessions[id]
+	if !ok {
+		return db.Session{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.sessions[arg.ID]; ok {
+		return db.Session{}, uniqueViolation("sessions_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.Session{}, foreignKeyViolation("sessions", "sessions_user_id_fkey")
+	}
+
+	s := db.Session{
+		ID:           arg.ID,
+		UserID:       arg.UserID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    arg.IsBlocked,
+		CreatedAt:    now(),
+		ExpiresAt:    timestamp(arg.ExpiresAt),
+	}
+	q.t.sessions[s.ID] = s
+	return s, nil
+}
+
+func (q *Queries) BlockSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s, ok := q.t.sessions[id]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	s.IsBlocked = true
+	q.t.sessions[id] = s
+	return id, nil
+}
+
+func (q *Queries) DeleteSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.sessions[id]; !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	delete(q.t.sessions, id)
+	return id, nil
+}
+
+func (q *Queries) DeleteSessionByUserId(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for id, s := range q.t.sessions {
+		if s.UserID == userID {
+			delete(q.t.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for id, s := range q.t.sessions {
+		if s.ExpiresAt.Before(now()) {
+			delete(q.t.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,267 @@
+// Package memory is a db.Store keeping every table in maps, for running the API locally without a database
+// server and for tests. Its queries do what the postgres ones do, down to the constraints they violate, the
+// rows they cascade to and the errors they return, which the tests in cnfs/db/storetest hold both stores to.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// the number of rows on a page of the paginated queries
+const pageSize = 20
+
+type (
+	// pairKey is the primary key of the tables keyed by two ids
+	pairKey struct{ a, b uuid.UUID }
+	// nameKey is the primary key of the tables keyed by an id and a name
+	nameKey struct {
+		id   uuid.UUID
+		name string
+	}
+	// revisionKey is the primary key of the revision tables
+	revisionKey struct {
+		id       uuid.UUID
+		revision int32
+	}
+	// voteKey is the primary key of poll_votes
+	voteKey struct{ postID, identityID, optionID uuid.UUID }
+)
+
+// tables holds the rows of every table. Rows are stored by value and slices in them are never changed in
+// place, so copying the maps is enough to snapshot the database.
+type tables struct {
+	users                   map[uuid.UUID]db.User
+	sessions                map[uuid.UUID]db.Session
+	identities              map[uuid.UUID]db.UserIdentity
+	messages                map[uuid.UUID]db.Message
+	posts                   map[uuid.UUID]db.Post
+	comments                map[uuid.UUID]db.Comment
+	media                   map[uuid.UUID]db.Media
+	follows                 map[uuid.UUID]db.Follow
+	blocks                  map[uuid.UUID]db.Block
+	mutedKeywords           map[uuid.UUID]db.MutedKeyword
+	tags                    map[string]db.Tag
+	postTags                map[nameKey]db.PostTag
+	reactions               map[pairKey]db.PostReaction
+	scores                  map[uuid.UUID]db.PostScore
+	postRevisions           map[revisionKey]db.PostRevision
+	commentRevisions        map[revisionKey]db.CommentRevision
+	dataExports             map[uuid.UUID]db.DataExport
+	polls                   map[uuid.UUID]db.Poll
+	pollOptions             map[uuid.UUID]db.PollOption
+	pollBallots             map[pairKey]db.PollBallot
+	pollVotes               map[voteKey]db.PollVote
+	contentPreferences      map[nameKey]db.ContentPreference
+	notifications           map[uuid.UUID]db.Notification
+	notificationPreferences map[nameKey]db.NotificationPreference
+	webhooks                map[uuid.UUID]db.Webhook
+	webhookDeliveries       map[uuid.UUID]db.WebhookDelivery
+	pushSubscriptions       map[uuid.UUID]db.PushSubscription
+	emailDigests            map[uuid.UUID]db.EmailDigest
+}
+
+func newTables() *tables {
+	return &tables{
+		users:                   map[uuid.UUID]db.User{},
+		sessions:                map[uuid.UUID]db.Session{},
+		identities:              map[uuid.UUID]db.UserIdentity{},
+		messages:                map[uuid.UUID]db.Message{},
+		posts:                   map[uuid.UUID]db.Post{},
+		comments:                map[uuid.UUID]db.Comment{},
+		media:                   map[uuid.UUID]db.Media{},
+		follows:                 map[uuid.UUID]db.Follow{},
+		blocks:                  map[uuid.UUID]db.Block{},
+		mutedKeywords:           map[uuid.UUID]db.MutedKeyword{},
+		tags:                    map[string]db.Tag{},
+		postTags:                map[nameKey]db.PostTag{},
+		reactions:               map[pairKey]db.PostReaction{},
+		scores:                  map[uuid.UUID]db.PostScore{},
+		postRevisions:           map[revisionKey]db.PostRevision{},
+		commentRevisions:        map[revisionKey]db.CommentRevision{},
+		dataExports:             map[uuid.UUID]db.DataExport{},
+		polls:                   map[uuid.UUID]db.Poll{},
+		pollOptions:             map[uuid.UUID]db.PollOption{},
+		pollBallots:             map[pairKey]db.PollBallot{},
+		pollVotes:               map[voteKey]db.PollVote{},
+		contentPreferences:      map[nameKey]db.ContentPreference{},
+		notifications:           map[uuid.UUID]db.Notification{},
+		notificationPreferences: map[nameKey]db.NotificationPreference{},
+		webhooks:                map[uuid.UUID]db.Webhook{},
+		webhookDeliveries:       map[uuid.UUID]db.WebhookDelivery{},
+		pushSubscriptions:       map[uuid.UUID]db.PushSubscription{},
+		emailDigests:            map[uuid.UUID]db.EmailDigest{},
+	}
+}
+
+func (t *tables) clone() *tables {
+	return &tables{
+		users:                   clone(t.users),
+		sessions:                clone(t.sessions),
+		identities:              clone(t.identities),
+		messages:                clone(t.messages),
+		posts:                   clone(t.posts),
+		comments:                clone(t.comments),
+		media:                   clone(t.media),
+		follows:                 clone(t.follows),
+		blocks:                  clone(t.blocks),
+		mutedKeywords:           clone(t.mutedKeywords),
+		tags:                    clone(t.tags),
+		postTags:                clone(t.postTags),
+		reactions:               clone(t.reactions),
+		scores:                  clone(t.scores),
+		postRevisions:           clone(t.postRevisions),
+		commentRevisions:        clone(t.commentRevisions),
+		dataExports:             clone(t.dataExports),
+		polls:                   clone(t.polls),
+		pollOptions:             clone(t.pollOptions),
+		pollBallots:             clone(t.pollBallots),
+		pollVotes:               clone(t.pollVotes),
+		contentPreferences:      clone(t.contentPreferences),
+		notifications:           clone(t.notifications),
+		notificationPreferences: clone(t.notificationPreferences),
+		webhooks:                clone(t.webhooks),
+		webhookDeliveries:       clone(t.webhookDeliveries),
+		pushSubscriptions:       clone(t.pushSubscriptions),
+		emailDigests:            clone(t.emailDigests),
+	}
+}
+
+func clone[K comparable, V any](m map[K]V) map[K]V {
+	c := make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Queries runs the queries of db.Querier against the tables, each one atomically.
+type Queries struct {
+	mu sync.Locker
+	t  *tables
+}
+
+type store struct {
+	mu sync.Mutex
+	*Queries
+}
+
+// NewStore returns an empty store.
+func NewStore() db.Store {
+	s := &store{}
+	s.Queries = &Queries{mu: &s.mu, t: newTables()}
+	return db.Transactions{TxRunner: s}
+}
+
+// ExecTx runs fn with the store locked, so transactions never fail to serialize and nothing else sees their
+// changes before they are done. The tables are put back as they were when fn fails.
+func (s *store) ExecTx(ctx context.Context, fn func(q db.Querier) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	saved := s.t.clone()
+	if err := fn(&Queries{mu: noLock{}, t: s.t}); err != nil {
+		*s.t = *saved
+		return err
+	}
+	return nil
+}
+
+// noLock is the lock of the queries of a transaction, which already holds the lock of the store.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
+
+// now is the time now() stores, to the microsecond as postgres keeps it.
+func now() time.Time {
+	return timestamp(time.Now())
+}
+
+// timestamp is t as storing it in a timestamp or timestamptz column leaves it.
+func timestamp(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// date drops the time of day from t, as casting it to date does.
+func date(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// seconds is the interval of float8 seconds the queries add to and subtract from times.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func uniqueViolation(constraint string) error {
+	return &pq.Error{
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// lessID orders uuids as postgres does, byte by byte.
+func lessID(a, b uuid.UUID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// page returns the rows of LIMIT 20 OFFSET offset.
+func page[T any](rows []T, offset int32) []T {
+	return limit(offset, rows, pageSize)
+}
+
+// limit returns at most n rows after skipping offset of them.
+func limit[T any](offset int32, rows []T, n int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if int(offset) >= len(rows) {
+		return []T{}
+	}
+	rows = rows[offset:]
+	if len(rows) > n {
+		rows = rows[:n]
+	}
+	return rows
+}
+
+// values returns the rows of a table that keep accepts, in the order of less. less breaks the ties of the
+// ORDER BY of the query on the primary key, maps have no order of their own and pages must not overlap.
+func values[K comparable, V any](m map[K]V, keep func(V) bool, less func(a, b V) bool) []V {
+	rows := []V{}
+	for _, v := range m {
+		if keep(v) {
+			rows = append(rows, v)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return less(rows[i], rows[j]) })
+	return rows
+}
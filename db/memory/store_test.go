@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	db "cnfs/db/sqlc"
+	"cnfs/db/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) db.Store { return NewStore() })
+}
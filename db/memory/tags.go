@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	db "cnfs/db/sqlc"
+)
+
+func (q *Queries) SetPostTags(ctx context.Context, arg db.SetPostTagsParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.posts[arg.PostID]; !ok && len(arg.Tags) > 0 {
+		return foreignKeyViolation("post_tags", "post_tags_post_id_fkey")
+	}
+
+	keep := map[string]bool{}
+	for _, tag := range arg.Tags {
+		keep[tag] = true
+		if _, ok := q.t.tags[tag]; !ok {
+			q.t.tags[tag] = db.Tag{Name: tag, CreatedAt: now()}
+		}
+	}
+
+	for k := range q.t.postTags {
+		if k.id == arg.PostID && !keep[k.name] {
+			delete(q.t.postTags, k)
+		}
+	}
+	for tag := range keep {
+		key := nameKey{arg.PostID, tag}
+		if _, ok := q.t.postTags[key]; !ok {
+			q.t.postTags[key] = db.PostTag{PostID: arg.PostID, Tag: tag, CreatedAt: now()}
+		}
+	}
+	return nil
+}
+
+func (q *Queries) ListPostsByTag(ctx context.Context, arg db.ListPostsByTagParams) ([]db.Post, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	posts := values(q.t.posts,
+		func(p db.Post) bool {
+			_, tagged := q.t.postTags[nameKey{p.ID, arg.Tag}]
+			return tagged && q.t.visiblePost(arg.ViewerID, p)
+		},
+		latestPostFirst,
+	)
+	return page(posts, arg.PageOffset), nil
+}
+
+// countTags counts the published posts of the tags of the post_tags rows keep accepts, the most used tags
+// first.
+func (t *tables) countTags(keep func(db.PostTag) bool) []tagCount {
+	counts := map[string]tagCount{}
+	for _, pt := range t.postTags {
+		p := t.posts[pt.PostID]
+		if keep(pt) && p.DeletedAt == nil && p.Status == "published" {
+			counts[pt.Tag] = tagCount{Name: pt.Tag, PostCount: counts[pt.Tag].PostCount + 1}
+		}
+	}
+
+	return values(counts,
+		func(tagCount) bool { return true },
+		func(a, b tagCount) bool {
+			if a.PostCount != b.PostCount {
+				return a.PostCount > b.PostCount
+			}
+			return a.Name < b.Name
+		},
+	)
+}
+
+// tagCount is the row both tag counting queries return.
+type tagCount struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+func (q *Queries) SearchTags(ctx context.Context, prefix string) ([]db.SearchTagsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// the handler escapes the prefix for LIKE
+	prefix = strings.NewReplacer(`\\`, `\`, `\%`, `%`, `\_`, `_`).Replace(prefix)
+	rows := q.t.countTags(func(pt db.PostTag) bool { return strings.HasPrefix(pt.Tag, prefix) })
+
+	tags := []db.SearchTagsRow{}
+	for _, row := range limit(0, rows, 10) {
+		tags = append(tags, db.SearchTagsRow(row))
+	}
+	return tags, nil
+}
+
+func (q *Queries) ListTrendingTags(ctx context.Context, days int32) ([]db.ListTrendingTagsRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	since := now().AddDate(0, 0, -int(days))
+	rows := q.t.countTags(func(pt db.PostTag) bool { return !pt.CreatedAt.Before(since) })
+
+	tags := []db.ListTrendingTagsRow{}
+	for _, row := range limit(0, rows, pageSize) {
+		tags = append(tags, db.ListTrendingTagsRow(row))
+	}
+	return tags, nil
+}
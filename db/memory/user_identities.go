@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.identities[arg.ID]; ok {
+		return db.UserIdentity{}, uniqueViolation("user_identities_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.UserIdentity{}, foreignKeyViolation("user_identities", "user_identities_user_id_fkey")
+	}
+	if arg.IsDefault && q.t.hasDefaultIdentity(arg.UserID, arg.ID) {
+		return db.UserIdentity{}, uniqueViolation("user_identities_default_idx")
+	}
+
+	ui := db.UserIdentity{
+		ID:                   arg.ID,
+		UserID:               uuid.NullUUID{UUID: arg.UserID, Valid: true},
+		IdentityHash:         arg.IdentityHash,
+		Name:                 arg.Name,
+		IsDefault:            arg.IsDefault,
+		CreatedAt:            now(),
+		RotatedAt:            now(),
+		RotationIntervalDays: arg.RotationIntervalDays,
+		RotationDetach:       arg.RotationDetach,
+	}
+	q.t.identities[ui.ID] = ui
+	return ui, nil
+}
+
+func (q *Queries) GetUserIdentityById(ctx context.Context, id uuid.UUID) (db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ui, ok := q.t.identities[id]
+	if !ok {
+		return db.UserIdentity{}, sql.ErrNoRows
+	}
+	return ui, nil
+}
+
+func (q *Queries) GetDefaultUserIdentity(ctx context.Context, userID uuid.UUID) (db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, ui := range q.t.identities {
+		if ui.IsDefault && q.t.identityOwnedBy(ui.ID, userID) {
+			return ui, nil
+		}
+	}
+	return db.UserIdentity{}, sql.ErrNoRows
+}
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.identities,
+		func(ui db.UserIdentity) bool { return q.t.identityOwnedBy(ui.ID, userID) },
+		func(a, b db.UserIdentity) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	), nil
+}
+
+func (q *Queries) CountActiveUserIdentities(ctx context.Context, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var n int64
+	for _, ui := range q.t.identities {
+		if !ui.Retired && q.t.identityOwnedBy(ui.ID, userID) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (q *Queries) UpdateUserIdentity(ctx context.Context, arg db.UpdateUserIdentityParams) (db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.t.identityOwnedBy(arg.ID, arg.UserID) {
+		return db.UserIdentity{}, sql.ErrNoRows
+	}
+
+	ui := q.t.identities[arg.ID]
+	if arg.Name.Valid {
+		ui.Name = arg.Name.String
+	}
+	if arg.IsPublic.Valid {
+		ui.IsPublic = arg.IsPublic.Bool
+	}
+	q.t.identities[ui.ID] = ui
+	return ui, nil
+}
+
+func (q *Queries) ClearDefaultUserIdentity(ctx context.Context, arg db.ClearDefaultUserIdentityParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, ui := range q.t.identities {
+		if ui.IsDefault && ui.ID != arg.ID && q.t.identityOwnedBy(ui.ID, arg.UserID) {
+			ui.IsDefault = false
+			q.t.identities[ui.ID] = ui
+		}
+	}
+	return nil
+}
+
+func (q *Queries) SetDefaultUserIdentity(ctx context.Context, arg db.SetDefaultUserIdentityParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.t.identityOwnedBy(arg.ID, arg.UserID) {
+		return nil
+	}
+	if q.t.hasDefaultIdentity(arg.UserID, arg.ID) {
+		return uniqueViolation("user_identities_default_idx")
+	}
+
+	ui := q.t.identities[arg.ID]
+	ui.IsDefault = true
+	q.t.identities[ui.ID] = ui
+	return nil
+}
+
+func (q *Queries) RetireUserIdentity(ctx context.Context, arg db.RetireUserIdentityParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ui, ok := q.t.identities[arg.ID]
+	if !ok || !q.t.identityOwnedBy(ui.ID, arg.UserID) || ui.IsDefault {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	ui.Retired = true
+	q.t.identities[ui.ID] = ui
+	return ui.ID, nil
+}
+
+func (q *Queries) ReplaceUserIdentity(ctx context.Context, arg db.ReplaceUserIdentityParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ui, ok := q.t.identities[arg.ID]
+	if !ok || ui.Retired {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	ui.Retired = true
+	ui.IsDefault = false
+	ui.RotationIntervalDays = sql.NullInt32{}
+	if arg.Detach {
+		ui.UserID = uuid.NullUUID{}
+	}
+	q.t.identities[ui.ID] = ui
+	return ui.ID, nil
+}
+
+func (q *Queries) UpdateUserIdentityRotation(ctx context.Context, arg db.UpdateUserIdentityRotationParams) (db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ui, ok := q.t.identities[arg.ID]
+	if !ok || !q.t.identityOwnedBy(ui.ID, arg.UserID) || ui.Retired {
+		return db.UserIdentity{}, sql.ErrNoRows
+	}
+
+	ui.RotationIntervalDays = arg.RotationIntervalDays
+	ui.RotationDetach = arg.RotationDetach
+	q.t.identities[ui.ID] = ui
+	return ui, nil
+}
+
+func (q *Queries) ListUserIdentitiesDueForRotation(ctx context.Context, n int32) ([]db.UserIdentity, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := values(q.t.identities,
+		func(ui db.UserIdentity) bool {
+			return !ui.Retired && ui.UserID.Valid && ui.RotationIntervalDays.Valid &&
+				!ui.RotatedAt.AddDate(0, 0, int(ui.RotationIntervalDays.Int32)).After(now())
+		},
+		func(a, b db.UserIdentity) bool {
+			if !a.RotatedAt.Equal(b.RotatedAt) {
+				return a.RotatedAt.Before(b.RotatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return limit(0, due, int(n)), nil
+}
@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[id]
+	if !ok || u.DeletedAt != nil {
+		return db.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.userByUsername(username)
+	if !ok || u.DeletedAt != nil {
+		return db.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (q *Queries) GetDeletedUserByUsername(ctx context.Context, username string) (db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.userByUsername(username)
+	if !ok || u.DeletedAt == nil || !inGracePeriod(u) {
+		return db.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (t *tables) userByUsername(username string) (db.User, bool) {
+	for _, u := range t.users {
+		if u.Username == username {
+			return u, true
+		}
+	}
+	return db.User{}, false
+}
+
+func (q *Queries) ListUsers(ctx context.Context, offset int32) ([]db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	users := values(q.t.users,
+		func(u db.User) bool { return u.DeletedAt == nil },
+		func(a, b db.User) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(users, offset), nil
+}
+
+func (q *Queries) CreateUser(ctx context.Context, arg db.CreateUserParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.users[arg.ID]; ok {
+		return uuid.Nil, uniqueViolation("users_pkey")
+	}
+	if _, ok := q.t.userByUsername(arg.Username); ok {
+		return uuid.Nil, uniqueViolation("users_username_key")
+	}
+
+	q.t.users[arg.ID] = db.User{
+		ID:        arg.ID,
+		Username:  arg.Username,
+		Password:  arg.Password,
+		CreatedAt: timestamp(arg.CreatedAt),
+		UpdatedAt: timestamp(arg.UpdatedAt),
+		Links:     []string{},
+	}
+	return arg.ID, nil
+}
+
+func (q *Queries) UpdateUsername(ctx context.Context, arg db.UpdateUsernameParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[arg.ID]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	if other, ok := q.t.userByUsername(arg.Username); ok && other.ID != u.ID {
+		return uuid.Nil, uniqueViolation("users_username_key")
+	}
+
+	u.Username = arg.Username
+	u.UpdatedAt = timestamp(arg.UpdatedAt)
+	q.t.users[u.ID] = u
+	return u.ID, nil
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[arg.ID]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	u.Password = arg.Password
+	u.UpdatedAt = timestamp(arg.UpdatedAt)
+	q.t.users[u.ID] = u
+	return u.ID, nil
+}
+
+func (q *Queries) SetUserModerator(ctx context.Context, arg db.SetUserModeratorParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[arg.ID]
+	if !ok || u.DeletedAt != nil {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	u.IsModerator = arg.IsModerator
+	u.UpdatedAt = now()
+	q.t.users[u.ID] = u
+	return u.ID, nil
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg db.UpdateUserProfileParams) (db.User, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[arg.ID]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+
+	if arg.DisplayName.Valid {
+		u.DisplayName = arg.DisplayName.String
+	}
+	if arg.Bio.Valid {
+		u.Bio = arg.Bio.String
+	}
+	if arg.AvatarUrl.Valid {
+		u.AvatarUrl = arg.AvatarUrl.String
+	}
+	if arg.ConfessionPrompt.Valid {
+		u.ConfessionPrompt = arg.ConfessionPrompt.String
+	}
+	// a nil slice is NULL, an empty one clears the links
+	if arg.Links != nil {
+		u.Links = append([]string{}, arg.Links...)
+	}
+	u.UpdatedAt = timestamp(arg.UpdatedAt)
+	q.t.users[u.ID] = u
+	return u, nil
+}
+
+func (q *Queries) DeleteOneUser(ctx context.Context, arg db.DeleteOneUserParams) (*time.Time, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[arg.ID]
+	if !ok || u.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+
+	u.DeletedAt = ptr(now())
+	u.DeleteAfter = ptr(u.DeletedAt.Add(seconds(arg.GraceSeconds)))
+	q.t.users[u.ID] = u
+	return u.DeleteAfter, nil
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u, ok := q.t.users[id]
+	if !ok || u.DeletedAt == nil || !inGracePeriod(u) {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	u.DeletedAt = nil
+	u.DeleteAfter = nil
+	q.t.users[u.ID] = u
+	return u.ID, nil
+}
+
+// inGracePeriod tells whether the deletion of the account can still be cancelled.
+func inGracePeriod(u db.User) bool {
+	return u.DeleteAfter != nil && u.DeleteAfter.After(now())
+}
+
+func (q *Queries) PurgeUsers(ctx context.Context) ([]db.Media, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	purged := map[uuid.UUID]bool{}
+	for _, u := range q.t.users {
+		if u.DeleteAfter != nil && u.DeleteAfter.Before(now()) {
+			purged[u.ID] = true
+		}
+	}
+
+	// the media is read before the delete cascades to it
+	media := values(q.t.media,
+		func(m db.Media) bool {
+			if m.UserID.Valid && purged[m.UserID.UUID] {
+				return true
+			}
+			if m.MessageID.Valid && purged[q.t.messages[m.MessageID.UUID].ReceiverID] {
+				return true
+			}
+			if m.PostID.Valid {
+				ui := q.t.identities[q.t.posts[m.PostID.UUID].UserIdentityID]
+				return ui.UserID.Valid && purged[ui.UserID.UUID]
+			}
+			return false
+		},
+		func(a, b db.Media) bool { return lessID(a.ID, b.ID) },
+	)
+
+	for id := range purged {
+		q.t.deleteUser(id)
+	}
+	return media, nil
+}
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+)
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.t.webhooks[arg.ID]; ok {
+		return db.Webhook{}, uniqueViolation("webhooks_pkey")
+	}
+	if _, ok := q.t.users[arg.UserID]; !ok {
+		return db.Webhook{}, foreignKeyViolation("webhooks", "webhooks_user_id_fkey")
+	}
+
+	w := db.Webhook{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		Events:    append([]string{}, arg.Events...),
+		Active:    true,
+		CreatedAt: now(),
+		UpdatedAt: now(),
+	}
+	q.t.webhooks[w.ID] = w
+	return w, nil
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]db.Webhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.webhooks,
+		func(w db.Webhook) bool { return w.UserID == userID },
+		oldestWebhookFirst,
+	), nil
+}
+
+func oldestWebhookFirst(a, b db.Webhook) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return lessID(a.ID, b.ID)
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, arg db.GetWebhookParams) (db.Webhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w, ok := q.t.webhooks[arg.ID]
+	if !ok || w.UserID != arg.UserID {
+		return db.Webhook{}, sql.ErrNoRows
+	}
+	return w, nil
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg db.UpdateWebhookParams) (db.Webhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w, ok := q.t.webhooks[arg.ID]
+	if !ok || w.UserID != arg.UserID {
+		return db.Webhook{}, sql.ErrNoRows
+	}
+
+	if arg.Url.Valid {
+		w.Url = arg.Url.String
+	}
+	// a nil slice is NULL
+	if arg.Events != nil {
+		w.Events = append([]string{}, arg.Events...)
+	}
+	if arg.Active.Valid {
+		w.Active = arg.Active.Bool
+	}
+	w.UpdatedAt = now()
+	q.t.webhooks[w.ID] = w
+	return w, nil
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg db.DeleteWebhookParams) (uuid.UUID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	w, ok := q.t.webhooks[arg.ID]
+	if !ok || w.UserID != arg.UserID {
+		return uuid.Nil, sql.ErrNoRows
+	}
+
+	q.t.deleteWebhook(w.ID)
+	return w.ID, nil
+}
+
+func (q *Queries) ListWebhooksForEvent(ctx context.Context, arg db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return values(q.t.webhooks,
+		func(w db.Webhook) bool {
+			if w.UserID != arg.UserID || !w.Active {
+				return false
+			}
+			for _, event := range w.Events {
+				if event == arg.Event {
+					return true
+				}
+			}
+			return false
+		},
+		oldestWebhookFirst,
+	), nil
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.t.createWebhookDelivery(arg)
+}
+
+func (t *tables) createWebhookDelivery(arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	if _, ok := t.webhookDeliveries[arg.ID]; ok {
+		return db.WebhookDelivery{}, uniqueViolation("webhook_deliveries_pkey")
+	}
+	if _, ok := t.webhooks[arg.WebhookID]; !ok {
+		return db.WebhookDelivery{}, foreignKeyViolation("webhook_deliveries", "webhook_deliveries_webhook_id_fkey")
+	}
+
+	d := db.WebhookDelivery{
+		ID:            arg.ID,
+		WebhookID:     arg.WebhookID,
+		Event:         arg.Event,
+		Payload:       append([]byte{}, arg.Payload...),
+		Status:        "pending",
+		NextAttemptAt: now(),
+		CreatedAt:     now(),
+	}
+	t.webhookDeliveries[d.ID] = d
+	return d, nil
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if w, ok := q.t.webhooks[arg.WebhookID]; !ok || w.UserID != arg.UserID {
+		return []db.WebhookDelivery{}, nil
+	}
+
+	deliveries := values(q.t.webhookDeliveries,
+		func(d db.WebhookDelivery) bool { return d.WebhookID == arg.WebhookID },
+		func(a, b db.WebhookDelivery) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+	return page(deliveries, arg.PageOffset), nil
+}
+
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, arg db.RedeliverWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.t.webhookDeliveries[arg.ID]
+	if !ok || d.WebhookID != arg.WebhookID {
+		return db.WebhookDelivery{}, sql.ErrNoRows
+	}
+	if w, ok := q.t.webhooks[d.WebhookID]; !ok || w.UserID != arg.UserID {
+		return db.WebhookDelivery{}, sql.ErrNoRows
+	}
+
+	return q.t.createWebhookDelivery(db.CreateWebhookDeliveryParams{
+		ID:        arg.NewID,
+		WebhookID: d.WebhookID,
+		Event:     d.Event,
+		Payload:   d.Payload,
+	})
+}
+
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg db.ClaimWebhookDeliveriesParams) ([]db.ClaimWebhookDeliveriesRow, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	due := values(q.t.webhookDeliveries,
+		func(d db.WebhookDelivery) bool {
+			return d.Status == "pending" && !d.NextAttemptAt.After(now()) && q.t.webhooks[d.WebhookID].Active
+		},
+		func(a, b db.WebhookDelivery) bool {
+			if !a.NextAttemptAt.Equal(b.NextAttemptAt) {
+				return a.NextAttemptAt.Before(b.NextAttemptAt)
+			}
+			return lessID(a.ID, b.ID)
+		},
+	)
+
+	rows := []db.ClaimWebhookDeliveriesRow{}
+	for _, d := range limit(0, due, int(arg.BatchSize)) {
+		d.NextAttemptAt = now().Add(seconds(arg.LeaseSeconds))
+		q.t.webhookDeliveries[d.ID] = d
+
+		w := q.t.webhooks[d.WebhookID]
+		rows = append(rows, db.ClaimWebhookDeliveriesRow{
+			ID:             d.ID,
+			WebhookID:      d.WebhookID,
+			Event:          d.Event,
+			Payload:        d.Payload,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			ResponseStatus: d.ResponseStatus,
+			Error:          d.Error,
+			CreatedAt:      d.CreatedAt,
+			DeliveredAt:    d.DeliveredAt,
+			Url:            w.Url,
+			Secret:         w.Secret,
+		})
+	}
+	return rows, nil
+}
+
+func (q *Queries) CompleteWebhookDelivery(ctx context.Context, arg db.CompleteWebhookDeliveryParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.t.webhookDeliveries[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	d.Status = "succeeded"
+	d.Attempts++
+	d.ResponseStatus = arg.ResponseStatus
+	d.Error = ""
+	d.DeliveredAt = ptr(now())
+	q.t.webhookDeliveries[d.ID] = d
+	return nil
+}
+
+func (q *Queries) FailWebhookDelivery(ctx context.Context, arg db.FailWebhookDeliveryParams) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.t.webhookDeliveries[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	d.Attempts++
+	d.ResponseStatus = arg.ResponseStatus
+	d.Error = arg.Error
+	if d.Attempts >= arg.MaxAttempts {
+		d.Status = "failed"
+	} else {
+		d.Status = "pending"
+	}
+	d.NextAttemptAt = now().Add(seconds(arg.RetrySeconds))
+	q.t.webhookDeliveries[d.ID] = d
+	return nil
+}
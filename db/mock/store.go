@@ -8,6 +8,7 @@ import (
 	db "cnfs/db/sqlc"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,6 +37,21 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// BlockIdentity mocks base method.
+func (m *MockStore) BlockIdentity(arg0 context.Context, arg1 db.BlockIdentityParams) (db.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockIdentity indicates an expected call of BlockIdentity.
+func (mr *MockStoreMockRecorder) BlockIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockIdentity", reflect.TypeOf((*MockStore)(nil).BlockIdentity), arg0, arg1)
+}
+
 // BlockSession mocks base method.
 func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -45,370 +61,2393 @@ func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) (uuid.UUI
 	return ret0, ret1
 }
 
-// BlockSession indicates an expected call of BlockSession.
-func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockUser mocks base method.
+func (m *MockStore) BlockUser(arg0 context.Context, arg1 db.BlockUserParams) (db.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUser", arg0, arg1)
+	ret0, _ := ret[0].(db.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUser indicates an expected call of BlockUser.
+func (mr *MockStoreMockRecorder) BlockUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUser", reflect.TypeOf((*MockStore)(nil).BlockUser), arg0, arg1)
+}
+
+// CastPollBallot mocks base method.
+func (m *MockStore) CastPollBallot(arg0 context.Context, arg1 db.CastPollBallotParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CastPollBallot", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CastPollBallot indicates an expected call of CastPollBallot.
+func (mr *MockStoreMockRecorder) CastPollBallot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CastPollBallot", reflect.TypeOf((*MockStore)(nil).CastPollBallot), arg0, arg1)
+}
+
+// CastPollBallotTx mocks base method.
+func (m *MockStore) CastPollBallotTx(arg0 context.Context, arg1 db.CastPollBallotParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CastPollBallotTx", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CastPollBallotTx indicates an expected call of CastPollBallotTx.
+func (mr *MockStoreMockRecorder) CastPollBallotTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CastPollBallotTx", reflect.TypeOf((*MockStore)(nil).CastPollBallotTx), arg0, arg1)
+}
+
+// ChangePasswordTx mocks base method.
+func (m *MockStore) ChangePasswordTx(arg0 context.Context, arg1 db.ChangePasswordTxParams) (db.ChangePasswordTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePasswordTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ChangePasswordTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePasswordTx indicates an expected call of ChangePasswordTx.
+func (mr *MockStoreMockRecorder) ChangePasswordTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePasswordTx", reflect.TypeOf((*MockStore)(nil).ChangePasswordTx), arg0, arg1)
+}
+
+// ClaimDataExport mocks base method.
+func (m *MockStore) ClaimDataExport(arg0 context.Context, arg1 float64) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDataExport indicates an expected call of ClaimDataExport.
+func (mr *MockStoreMockRecorder) ClaimDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDataExport", reflect.TypeOf((*MockStore)(nil).ClaimDataExport), arg0, arg1)
+}
+
+// ClaimDueEmailDigests mocks base method.
+func (m *MockStore) ClaimDueEmailDigests(arg0 context.Context, arg1 db.ClaimDueEmailDigestsParams) ([]db.ClaimDueEmailDigestsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueEmailDigests", arg0, arg1)
+	ret0, _ := ret[0].([]db.ClaimDueEmailDigestsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueEmailDigests indicates an expected call of ClaimDueEmailDigests.
+func (mr *MockStoreMockRecorder) ClaimDueEmailDigests(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueEmailDigests", reflect.TypeOf((*MockStore)(nil).ClaimDueEmailDigests), arg0, arg1)
+}
+
+// ClaimWebhookDeliveries mocks base method.
+func (m *MockStore) ClaimWebhookDeliveries(arg0 context.Context, arg1 db.ClaimWebhookDeliveriesParams) ([]db.ClaimWebhookDeliveriesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.ClaimWebhookDeliveriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDeliveries indicates an expected call of ClaimWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ClaimWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ClaimWebhookDeliveries), arg0, arg1)
+}
+
+// ClearDefaultUserIdentity mocks base method.
+func (m *MockStore) ClearDefaultUserIdentity(arg0 context.Context, arg1 db.ClearDefaultUserIdentityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearDefaultUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearDefaultUserIdentity indicates an expected call of ClearDefaultUserIdentity.
+func (mr *MockStoreMockRecorder) ClearDefaultUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearDefaultUserIdentity", reflect.TypeOf((*MockStore)(nil).ClearDefaultUserIdentity), arg0, arg1)
+}
+
+// CompleteDataExport mocks base method.
+func (m *MockStore) CompleteDataExport(arg0 context.Context, arg1 db.CompleteDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteDataExport indicates an expected call of CompleteDataExport.
+func (mr *MockStoreMockRecorder) CompleteDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteDataExport", reflect.TypeOf((*MockStore)(nil).CompleteDataExport), arg0, arg1)
+}
+
+// CompleteWebhookDelivery mocks base method.
+func (m *MockStore) CompleteWebhookDelivery(arg0 context.Context, arg1 db.CompleteWebhookDeliveryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteWebhookDelivery indicates an expected call of CompleteWebhookDelivery.
+func (mr *MockStoreMockRecorder) CompleteWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CompleteWebhookDelivery), arg0, arg1)
+}
+
+// CountActiveUserIdentities mocks base method.
+func (m *MockStore) CountActiveUserIdentities(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountActiveUserIdentities", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountActiveUserIdentities indicates an expected call of CountActiveUserIdentities.
+func (mr *MockStoreMockRecorder) CountActiveUserIdentities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountActiveUserIdentities", reflect.TypeOf((*MockStore)(nil).CountActiveUserIdentities), arg0, arg1)
+}
+
+// CountDigestFollowers mocks base method.
+func (m *MockStore) CountDigestFollowers(arg0 context.Context, arg1 db.CountDigestFollowersParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDigestFollowers", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDigestFollowers indicates an expected call of CountDigestFollowers.
+func (mr *MockStoreMockRecorder) CountDigestFollowers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDigestFollowers", reflect.TypeOf((*MockStore)(nil).CountDigestFollowers), arg0, arg1)
+}
+
+// CountDigestMessages mocks base method.
+func (m *MockStore) CountDigestMessages(arg0 context.Context, arg1 db.CountDigestMessagesParams) (db.CountDigestMessagesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDigestMessages", arg0, arg1)
+	ret0, _ := ret[0].(db.CountDigestMessagesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDigestMessages indicates an expected call of CountDigestMessages.
+func (mr *MockStoreMockRecorder) CountDigestMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDigestMessages", reflect.TypeOf((*MockStore)(nil).CountDigestMessages), arg0, arg1)
+}
+
+// CountMediaByPostId mocks base method.
+func (m *MockStore) CountMediaByPostId(arg0 context.Context, arg1 uuid.NullUUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountMediaByPostId", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountMediaByPostId indicates an expected call of CountMediaByPostId.
+func (mr *MockStoreMockRecorder) CountMediaByPostId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountMediaByPostId", reflect.TypeOf((*MockStore)(nil).CountMediaByPostId), arg0, arg1)
+}
+
+// CountMutedKeywords mocks base method.
+func (m *MockStore) CountMutedKeywords(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountMutedKeywords", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountMutedKeywords indicates an expected call of CountMutedKeywords.
+func (mr *MockStoreMockRecorder) CountMutedKeywords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountMutedKeywords", reflect.TypeOf((*MockStore)(nil).CountMutedKeywords), arg0, arg1)
+}
+
+// CountPostReactions mocks base method.
+func (m *MockStore) CountPostReactions(arg0 context.Context, arg1 uuid.UUID) (db.CountPostReactionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPostReactions", arg0, arg1)
+	ret0, _ := ret[0].(db.CountPostReactionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPostReactions indicates an expected call of CountPostReactions.
+func (mr *MockStoreMockRecorder) CountPostReactions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPostReactions", reflect.TypeOf((*MockStore)(nil).CountPostReactions), arg0, arg1)
+}
+
+// CountUnreadNotifications mocks base method.
+func (m *MockStore) CountUnreadNotifications(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadNotifications", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadNotifications indicates an expected call of CountUnreadNotifications.
+func (mr *MockStoreMockRecorder) CountUnreadNotifications(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadNotifications", reflect.TypeOf((*MockStore)(nil).CountUnreadNotifications), arg0, arg1)
+}
+
+// CreateComment mocks base method.
+func (m *MockStore) CreateComment(arg0 context.Context, arg1 db.CreateCommentParams) (db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", arg0, arg1)
+	ret0, _ := ret[0].(db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockStoreMockRecorder) CreateComment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockStore)(nil).CreateComment), arg0, arg1)
+}
+
+// CreateCommentRevision mocks base method.
+func (m *MockStore) CreateCommentRevision(arg0 context.Context, arg1 db.CreateCommentRevisionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommentRevision", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCommentRevision indicates an expected call of CreateCommentRevision.
+func (mr *MockStoreMockRecorder) CreateCommentRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommentRevision", reflect.TypeOf((*MockStore)(nil).CreateCommentRevision), arg0, arg1)
+}
+
+// CreateCommentTx mocks base method.
+func (m *MockStore) CreateCommentTx(arg0 context.Context, arg1 db.CreateCommentParams) (db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommentTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCommentTx indicates an expected call of CreateCommentTx.
+func (mr *MockStoreMockRecorder) CreateCommentTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommentTx", reflect.TypeOf((*MockStore)(nil).CreateCommentTx), arg0, arg1)
+}
+
+// CreateDataExport mocks base method.
+func (m *MockStore) CreateDataExport(arg0 context.Context, arg1 db.CreateDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDataExport indicates an expected call of CreateDataExport.
+func (mr *MockStoreMockRecorder) CreateDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDataExport", reflect.TypeOf((*MockStore)(nil).CreateDataExport), arg0, arg1)
+}
+
+// CreateMedia mocks base method.
+func (m *MockStore) CreateMedia(arg0 context.Context, arg1 db.CreateMediaParams) (db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMedia", arg0, arg1)
+	ret0, _ := ret[0].(db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMedia indicates an expected call of CreateMedia.
+func (mr *MockStoreMockRecorder) CreateMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMedia", reflect.TypeOf((*MockStore)(nil).CreateMedia), arg0, arg1)
+}
+
+// CreateMessage mocks base method.
+func (m *MockStore) CreateMessage(arg0 context.Context, arg1 db.CreateMessageParams) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessage indicates an expected call of CreateMessage.
+func (mr *MockStoreMockRecorder) CreateMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockStore)(nil).CreateMessage), arg0, arg1)
+}
+
+// CreateMessageTx mocks base method.
+func (m *MockStore) CreateMessageTx(arg0 context.Context, arg1 db.CreateMessageTxParams) (db.CreateMessageTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessageTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateMessageTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessageTx indicates an expected call of CreateMessageTx.
+func (mr *MockStoreMockRecorder) CreateMessageTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessageTx", reflect.TypeOf((*MockStore)(nil).CreateMessageTx), arg0, arg1)
+}
+
+// CreateMutedKeyword mocks base method.
+func (m *MockStore) CreateMutedKeyword(arg0 context.Context, arg1 db.CreateMutedKeywordParams) (db.MutedKeyword, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMutedKeyword", arg0, arg1)
+	ret0, _ := ret[0].(db.MutedKeyword)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMutedKeyword indicates an expected call of CreateMutedKeyword.
+func (mr *MockStoreMockRecorder) CreateMutedKeyword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMutedKeyword", reflect.TypeOf((*MockStore)(nil).CreateMutedKeyword), arg0, arg1)
+}
+
+// CreateNotification mocks base method.
+func (m *MockStore) CreateNotification(arg0 context.Context, arg1 db.CreateNotificationParams) (db.CreateNotificationRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNotification", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateNotificationRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNotification indicates an expected call of CreateNotification.
+func (mr *MockStoreMockRecorder) CreateNotification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNotification", reflect.TypeOf((*MockStore)(nil).CreateNotification), arg0, arg1)
+}
+
+// CreatePoll mocks base method.
+func (m *MockStore) CreatePoll(arg0 context.Context, arg1 db.CreatePollParams) (db.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePoll", arg0, arg1)
+	ret0, _ := ret[0].(db.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePoll indicates an expected call of CreatePoll.
+func (mr *MockStoreMockRecorder) CreatePoll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePoll", reflect.TypeOf((*MockStore)(nil).CreatePoll), arg0, arg1)
+}
+
+// CreatePollOptions mocks base method.
+func (m *MockStore) CreatePollOptions(arg0 context.Context, arg1 db.CreatePollOptionsParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePollOptions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePollOptions indicates an expected call of CreatePollOptions.
+func (mr *MockStoreMockRecorder) CreatePollOptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePollOptions", reflect.TypeOf((*MockStore)(nil).CreatePollOptions), arg0, arg1)
+}
+
+// CreatePost mocks base method.
+func (m *MockStore) CreatePost(arg0 context.Context, arg1 db.CreatePostParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePost", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePost indicates an expected call of CreatePost.
+func (mr *MockStoreMockRecorder) CreatePost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockStore)(nil).CreatePost), arg0, arg1)
+}
+
+// CreatePostRevision mocks base method.
+func (m *MockStore) CreatePostRevision(arg0 context.Context, arg1 db.CreatePostRevisionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostRevision", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePostRevision indicates an expected call of CreatePostRevision.
+func (mr *MockStoreMockRecorder) CreatePostRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostRevision", reflect.TypeOf((*MockStore)(nil).CreatePostRevision), arg0, arg1)
+}
+
+// CreatePostTx mocks base method.
+func (m *MockStore) CreatePostTx(arg0 context.Context, arg1 db.CreatePostTxParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePostTx indicates an expected call of CreatePostTx.
+func (mr *MockStoreMockRecorder) CreatePostTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostTx", reflect.TypeOf((*MockStore)(nil).CreatePostTx), arg0, arg1)
+}
+
+// CreatePushSubscription mocks base method.
+func (m *MockStore) CreatePushSubscription(arg0 context.Context, arg1 db.CreatePushSubscriptionParams) (db.PushSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePushSubscription", arg0, arg1)
+	ret0, _ := ret[0].(db.PushSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePushSubscription indicates an expected call of CreatePushSubscription.
+func (mr *MockStoreMockRecorder) CreatePushSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePushSubscription", reflect.TypeOf((*MockStore)(nil).CreatePushSubscription), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUserIdentity mocks base method.
+func (m *MockStore) CreateUserIdentity(arg0 context.Context, arg1 db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserIdentity indicates an expected call of CreateUserIdentity.
+func (mr *MockStoreMockRecorder) CreateUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserIdentity", reflect.TypeOf((*MockStore)(nil).CreateUserIdentity), arg0, arg1)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockStore) CreateWebhook(arg0 context.Context, arg1 db.CreateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockStoreMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockStore)(nil).CreateWebhook), arg0, arg1)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockStore) CreateWebhookDelivery(arg0 context.Context, arg1 db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockStoreMockRecorder) CreateWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockStore)(nil).CreateWebhookDelivery), arg0, arg1)
+}
+
+// DeleteAccountTx mocks base method.
+func (m *MockStore) DeleteAccountTx(arg0 context.Context, arg1 db.DeleteOneUserParams) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAccountTx indicates an expected call of DeleteAccountTx.
+func (mr *MockStoreMockRecorder) DeleteAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountTx", reflect.TypeOf((*MockStore)(nil).DeleteAccountTx), arg0, arg1)
+}
+
+// DeleteBlock mocks base method.
+func (m *MockStore) DeleteBlock(arg0 context.Context, arg1 db.DeleteBlockParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBlock", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBlock indicates an expected call of DeleteBlock.
+func (mr *MockStoreMockRecorder) DeleteBlock(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBlock", reflect.TypeOf((*MockStore)(nil).DeleteBlock), arg0, arg1)
+}
+
+// DeleteComment mocks base method.
+func (m *MockStore) DeleteComment(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockStoreMockRecorder) DeleteComment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockStore)(nil).DeleteComment), arg0, arg1)
+}
+
+// DeleteEmailDigest mocks base method.
+func (m *MockStore) DeleteEmailDigest(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailDigest", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEmailDigest indicates an expected call of DeleteEmailDigest.
+func (mr *MockStoreMockRecorder) DeleteEmailDigest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailDigest", reflect.TypeOf((*MockStore)(nil).DeleteEmailDigest), arg0, arg1)
+}
+
+// DeleteExpiredDataExports mocks base method.
+func (m *MockStore) DeleteExpiredDataExports(arg0 context.Context) ([]db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredDataExports", arg0)
+	ret0, _ := ret[0].([]db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredDataExports indicates an expected call of DeleteExpiredDataExports.
+func (mr *MockStoreMockRecorder) DeleteExpiredDataExports(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredDataExports", reflect.TypeOf((*MockStore)(nil).DeleteExpiredDataExports), arg0)
+}
+
+// DeleteExpiredSessions mocks base method.
+func (m *MockStore) DeleteExpiredSessions(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSessions", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSessions indicates an expected call of DeleteExpiredSessions.
+func (mr *MockStoreMockRecorder) DeleteExpiredSessions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSessions", reflect.TypeOf((*MockStore)(nil).DeleteExpiredSessions), arg0)
+}
+
+// DeleteGonePushSubscription mocks base method.
+func (m *MockStore) DeleteGonePushSubscription(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGonePushSubscription", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGonePushSubscription indicates an expected call of DeleteGonePushSubscription.
+func (mr *MockStoreMockRecorder) DeleteGonePushSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGonePushSubscription", reflect.TypeOf((*MockStore)(nil).DeleteGonePushSubscription), arg0, arg1)
+}
+
+// DeleteMedia mocks base method.
+func (m *MockStore) DeleteMedia(arg0 context.Context, arg1 uuid.UUID) (db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMedia", arg0, arg1)
+	ret0, _ := ret[0].(db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMedia indicates an expected call of DeleteMedia.
+func (mr *MockStoreMockRecorder) DeleteMedia(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMedia", reflect.TypeOf((*MockStore)(nil).DeleteMedia), arg0, arg1)
+}
+
+// DeleteMutedKeyword mocks base method.
+func (m *MockStore) DeleteMutedKeyword(arg0 context.Context, arg1 db.DeleteMutedKeywordParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMutedKeyword", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMutedKeyword indicates an expected call of DeleteMutedKeyword.
+func (mr *MockStoreMockRecorder) DeleteMutedKeyword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMutedKeyword", reflect.TypeOf((*MockStore)(nil).DeleteMutedKeyword), arg0, arg1)
+}
+
+// DeleteOldAvatars mocks base method.
+func (m *MockStore) DeleteOldAvatars(arg0 context.Context, arg1 db.DeleteOldAvatarsParams) ([]db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldAvatars", arg0, arg1)
+	ret0, _ := ret[0].([]db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOldAvatars indicates an expected call of DeleteOldAvatars.
+func (mr *MockStoreMockRecorder) DeleteOldAvatars(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldAvatars", reflect.TypeOf((*MockStore)(nil).DeleteOldAvatars), arg0, arg1)
+}
+
+// DeleteOneMessage mocks base method.
+func (m *MockStore) DeleteOneMessage(arg0 context.Context, arg1 db.DeleteOneMessageParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOneMessage", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOneMessage indicates an expected call of DeleteOneMessage.
+func (mr *MockStoreMockRecorder) DeleteOneMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOneMessage", reflect.TypeOf((*MockStore)(nil).DeleteOneMessage), arg0, arg1)
+}
+
+// DeleteOneUser mocks base method.
+func (m *MockStore) DeleteOneUser(arg0 context.Context, arg1 db.DeleteOneUserParams) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOneUser", arg0, arg1)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOneUser indicates an expected call of DeleteOneUser.
+func (mr *MockStoreMockRecorder) DeleteOneUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOneUser", reflect.TypeOf((*MockStore)(nil).DeleteOneUser), arg0, arg1)
+}
+
+// DeletePost mocks base method.
+func (m *MockStore) DeletePost(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePost", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePost indicates an expected call of DeletePost.
+func (mr *MockStoreMockRecorder) DeletePost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockStore)(nil).DeletePost), arg0, arg1)
+}
+
+// DeletePostReaction mocks base method.
+func (m *MockStore) DeletePostReaction(arg0 context.Context, arg1 db.DeletePostReactionParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePostReaction", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePostReaction indicates an expected call of DeletePostReaction.
+func (mr *MockStoreMockRecorder) DeletePostReaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostReaction", reflect.TypeOf((*MockStore)(nil).DeletePostReaction), arg0, arg1)
+}
+
+// DeletePushSubscription mocks base method.
+func (m *MockStore) DeletePushSubscription(arg0 context.Context, arg1 db.DeletePushSubscriptionParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePushSubscription", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePushSubscription indicates an expected call of DeletePushSubscription.
+func (mr *MockStoreMockRecorder) DeletePushSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePushSubscription", reflect.TypeOf((*MockStore)(nil).DeletePushSubscription), arg0, arg1)
+}
+
+// DeleteSession mocks base method.
+func (m *MockStore) DeleteSession(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStoreMockRecorder) DeleteSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStore)(nil).DeleteSession), arg0, arg1)
+}
+
+// DeleteSessionByUserId mocks base method.
+func (m *MockStore) DeleteSessionByUserId(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionByUserId", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSessionByUserId indicates an expected call of DeleteSessionByUserId.
+func (mr *MockStoreMockRecorder) DeleteSessionByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionByUserId", reflect.TypeOf((*MockStore)(nil).DeleteSessionByUserId), arg0, arg1)
+}
+
+// DeleteUserPushSubscriptions mocks base method.
+func (m *MockStore) DeleteUserPushSubscriptions(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserPushSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserPushSubscriptions indicates an expected call of DeleteUserPushSubscriptions.
+func (mr *MockStoreMockRecorder) DeleteUserPushSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserPushSubscriptions", reflect.TypeOf((*MockStore)(nil).DeleteUserPushSubscriptions), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockStore) DeleteWebhook(arg0 context.Context, arg1 db.DeleteWebhookParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockStoreMockRecorder) DeleteWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockStore)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// ExecTx mocks base method.
+func (m *MockStore) ExecTx(arg0 context.Context, arg1 func(db.Querier) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecTx indicates an expected call of ExecTx.
+func (mr *MockStoreMockRecorder) ExecTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecTx", reflect.TypeOf((*MockStore)(nil).ExecTx), arg0, arg1)
+}
+
+// ExportUserComments mocks base method.
+func (m *MockStore) ExportUserComments(arg0 context.Context, arg1 uuid.UUID) ([]db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserComments", arg0, arg1)
+	ret0, _ := ret[0].([]db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserComments indicates an expected call of ExportUserComments.
+func (mr *MockStoreMockRecorder) ExportUserComments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserComments", reflect.TypeOf((*MockStore)(nil).ExportUserComments), arg0, arg1)
+}
+
+// ExportUserMessages mocks base method.
+func (m *MockStore) ExportUserMessages(arg0 context.Context, arg1 uuid.UUID) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserMessages", arg0, arg1)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserMessages indicates an expected call of ExportUserMessages.
+func (mr *MockStoreMockRecorder) ExportUserMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserMessages", reflect.TypeOf((*MockStore)(nil).ExportUserMessages), arg0, arg1)
+}
+
+// ExportUserPosts mocks base method.
+func (m *MockStore) ExportUserPosts(arg0 context.Context, arg1 uuid.UUID) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUserPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportUserPosts indicates an expected call of ExportUserPosts.
+func (mr *MockStoreMockRecorder) ExportUserPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUserPosts", reflect.TypeOf((*MockStore)(nil).ExportUserPosts), arg0, arg1)
+}
+
+// FailDataExport mocks base method.
+func (m *MockStore) FailDataExport(arg0 context.Context, arg1 db.FailDataExportParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailDataExport", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailDataExport indicates an expected call of FailDataExport.
+func (mr *MockStoreMockRecorder) FailDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailDataExport", reflect.TypeOf((*MockStore)(nil).FailDataExport), arg0, arg1)
+}
+
+// FailWebhookDelivery mocks base method.
+func (m *MockStore) FailWebhookDelivery(arg0 context.Context, arg1 db.FailWebhookDeliveryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailWebhookDelivery indicates an expected call of FailWebhookDelivery.
+func (mr *MockStoreMockRecorder) FailWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailWebhookDelivery", reflect.TypeOf((*MockStore)(nil).FailWebhookDelivery), arg0, arg1)
+}
+
+// FollowIdentity mocks base method.
+func (m *MockStore) FollowIdentity(arg0 context.Context, arg1 db.FollowIdentityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FollowIdentity indicates an expected call of FollowIdentity.
+func (mr *MockStoreMockRecorder) FollowIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowIdentity", reflect.TypeOf((*MockStore)(nil).FollowIdentity), arg0, arg1)
+}
+
+// FollowUser mocks base method.
+func (m *MockStore) FollowUser(arg0 context.Context, arg1 db.FollowUserParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FollowUser indicates an expected call of FollowUser.
+func (mr *MockStoreMockRecorder) FollowUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowUser", reflect.TypeOf((*MockStore)(nil).FollowUser), arg0, arg1)
+}
+
+// GetComment mocks base method.
+func (m *MockStore) GetComment(arg0 context.Context, arg1 uuid.UUID) (db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComment", arg0, arg1)
+	ret0, _ := ret[0].(db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComment indicates an expected call of GetComment.
+func (mr *MockStoreMockRecorder) GetComment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockStore)(nil).GetComment), arg0, arg1)
+}
+
+// GetCommentIncludingDeleted mocks base method.
+func (m *MockStore) GetCommentIncludingDeleted(arg0 context.Context, arg1 uuid.UUID) (db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentIncludingDeleted", arg0, arg1)
+	ret0, _ := ret[0].(db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentIncludingDeleted indicates an expected call of GetCommentIncludingDeleted.
+func (mr *MockStoreMockRecorder) GetCommentIncludingDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentIncludingDeleted", reflect.TypeOf((*MockStore)(nil).GetCommentIncludingDeleted), arg0, arg1)
+}
+
+// GetCommentRevision mocks base method.
+func (m *MockStore) GetCommentRevision(arg0 context.Context, arg1 db.GetCommentRevisionParams) (db.CommentRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentRevision", arg0, arg1)
+	ret0, _ := ret[0].(db.CommentRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentRevision indicates an expected call of GetCommentRevision.
+func (mr *MockStoreMockRecorder) GetCommentRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentRevision", reflect.TypeOf((*MockStore)(nil).GetCommentRevision), arg0, arg1)
+}
+
+// GetDataExport mocks base method.
+func (m *MockStore) GetDataExport(arg0 context.Context, arg1 db.GetDataExportParams) (db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataExport", arg0, arg1)
+	ret0, _ := ret[0].(db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDataExport indicates an expected call of GetDataExport.
+func (mr *MockStoreMockRecorder) GetDataExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataExport", reflect.TypeOf((*MockStore)(nil).GetDataExport), arg0, arg1)
+}
+
+// GetDefaultUserIdentity mocks base method.
+func (m *MockStore) GetDefaultUserIdentity(arg0 context.Context, arg1 uuid.UUID) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDefaultUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDefaultUserIdentity indicates an expected call of GetDefaultUserIdentity.
+func (mr *MockStoreMockRecorder) GetDefaultUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDefaultUserIdentity", reflect.TypeOf((*MockStore)(nil).GetDefaultUserIdentity), arg0, arg1)
+}
+
+// GetDeletedUserByUsername mocks base method.
+func (m *MockStore) GetDeletedUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserByUsername indicates an expected call of GetDeletedUserByUsername.
+func (mr *MockStoreMockRecorder) GetDeletedUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserByUsername", reflect.TypeOf((*MockStore)(nil).GetDeletedUserByUsername), arg0, arg1)
+}
+
+// GetDraftPost mocks base method.
+func (m *MockStore) GetDraftPost(arg0 context.Context, arg1 uuid.UUID) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDraftPost", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDraftPost indicates an expected call of GetDraftPost.
+func (mr *MockStoreMockRecorder) GetDraftPost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDraftPost", reflect.TypeOf((*MockStore)(nil).GetDraftPost), arg0, arg1)
+}
+
+// GetEmailDigest mocks base method.
+func (m *MockStore) GetEmailDigest(arg0 context.Context, arg1 uuid.UUID) (db.EmailDigest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailDigest", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailDigest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailDigest indicates an expected call of GetEmailDigest.
+func (mr *MockStoreMockRecorder) GetEmailDigest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailDigest", reflect.TypeOf((*MockStore)(nil).GetEmailDigest), arg0, arg1)
+}
+
+// GetMediaById mocks base method.
+func (m *MockStore) GetMediaById(arg0 context.Context, arg1 uuid.UUID) (db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaById", arg0, arg1)
+	ret0, _ := ret[0].(db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaById indicates an expected call of GetMediaById.
+func (mr *MockStoreMockRecorder) GetMediaById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaById", reflect.TypeOf((*MockStore)(nil).GetMediaById), arg0, arg1)
+}
+
+// GetMessageById mocks base method.
+func (m *MockStore) GetMessageById(arg0 context.Context, arg1 uuid.UUID) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageById", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageById indicates an expected call of GetMessageById.
+func (mr *MockStoreMockRecorder) GetMessageById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageById", reflect.TypeOf((*MockStore)(nil).GetMessageById), arg0, arg1)
+}
+
+// GetPostById mocks base method.
+func (m *MockStore) GetPostById(arg0 context.Context, arg1 uuid.UUID) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostById", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostById indicates an expected call of GetPostById.
+func (mr *MockStoreMockRecorder) GetPostById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostById", reflect.TypeOf((*MockStore)(nil).GetPostById), arg0, arg1)
+}
+
+// GetPostByIdIncludingDeleted mocks base method.
+func (m *MockStore) GetPostByIdIncludingDeleted(arg0 context.Context, arg1 uuid.UUID) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostByIdIncludingDeleted", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostByIdIncludingDeleted indicates an expected call of GetPostByIdIncludingDeleted.
+func (mr *MockStoreMockRecorder) GetPostByIdIncludingDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostByIdIncludingDeleted", reflect.TypeOf((*MockStore)(nil).GetPostByIdIncludingDeleted), arg0, arg1)
+}
+
+// GetPostReaction mocks base method.
+func (m *MockStore) GetPostReaction(arg0 context.Context, arg1 db.GetPostReactionParams) (db.PostReaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostReaction", arg0, arg1)
+	ret0, _ := ret[0].(db.PostReaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostReaction indicates an expected call of GetPostReaction.
+func (mr *MockStoreMockRecorder) GetPostReaction(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostReaction", reflect.TypeOf((*MockStore)(nil).GetPostReaction), arg0, arg1)
+}
+
+// GetPostRevision mocks base method.
+func (m *MockStore) GetPostRevision(arg0 context.Context, arg1 db.GetPostRevisionParams) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevision", arg0, arg1)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevision indicates an expected call of GetPostRevision.
+func (mr *MockStoreMockRecorder) GetPostRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevision", reflect.TypeOf((*MockStore)(nil).GetPostRevision), arg0, arg1)
+}
+
+// GetSessionById mocks base method.
+func (m *MockStore) GetSessionById(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionById", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionById indicates an expected call of GetSessionById.
+func (mr *MockStoreMockRecorder) GetSessionById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionById", reflect.TypeOf((*MockStore)(nil).GetSessionById), arg0, arg1)
+}
+
+// GetUserById mocks base method.
+func (m *MockStore) GetUserById(arg0 context.Context, arg1 uuid.UUID) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockStoreMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStore)(nil).GetUserById), arg0, arg1)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// GetUserIdentityById mocks base method.
+func (m *MockStore) GetUserIdentityById(arg0 context.Context, arg1 uuid.UUID) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentityById", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdentityById indicates an expected call of GetUserIdentityById.
+func (mr *MockStoreMockRecorder) GetUserIdentityById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentityById", reflect.TypeOf((*MockStore)(nil).GetUserIdentityById), arg0, arg1)
+}
+
+// GetWebhook mocks base method.
+func (m *MockStore) GetWebhook(arg0 context.Context, arg1 db.GetWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhook indicates an expected call of GetWebhook.
+func (mr *MockStoreMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhook", reflect.TypeOf((*MockStore)(nil).GetWebhook), arg0, arg1)
+}
+
+// HasBlocks mocks base method.
+func (m *MockStore) HasBlocks(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasBlocks", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasBlocks indicates an expected call of HasBlocks.
+func (mr *MockStoreMockRecorder) HasBlocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasBlocks", reflect.TypeOf((*MockStore)(nil).HasBlocks), arg0, arg1)
+}
+
+// HasFeedPosts mocks base method.
+func (m *MockStore) HasFeedPosts(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasFeedPosts", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasFeedPosts indicates an expected call of HasFeedPosts.
+func (mr *MockStoreMockRecorder) HasFeedPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasFeedPosts", reflect.TypeOf((*MockStore)(nil).HasFeedPosts), arg0, arg1)
+}
+
+// IsBlocked mocks base method.
+func (m *MockStore) IsBlocked(arg0 context.Context, arg1 db.IsBlockedParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockStoreMockRecorder) IsBlocked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockStore)(nil).IsBlocked), arg0, arg1)
+}
+
+// ListAllComments mocks base method.
+func (m *MockStore) ListAllComments(arg0 context.Context, arg1 db.ListAllCommentsParams) ([]db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllComments", arg0, arg1)
+	ret0, _ := ret[0].([]db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllComments indicates an expected call of ListAllComments.
+func (mr *MockStoreMockRecorder) ListAllComments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllComments", reflect.TypeOf((*MockStore)(nil).ListAllComments), arg0, arg1)
+}
+
+// ListAllPosts mocks base method.
+func (m *MockStore) ListAllPosts(arg0 context.Context, arg1 db.ListAllPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllPosts indicates an expected call of ListAllPosts.
+func (mr *MockStoreMockRecorder) ListAllPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllPosts", reflect.TypeOf((*MockStore)(nil).ListAllPosts), arg0, arg1)
+}
+
+// ListBlocks mocks base method.
+func (m *MockStore) ListBlocks(arg0 context.Context, arg1 db.ListBlocksParams) ([]db.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlocks", arg0, arg1)
+	ret0, _ := ret[0].([]db.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlocks indicates an expected call of ListBlocks.
+func (mr *MockStoreMockRecorder) ListBlocks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlocks", reflect.TypeOf((*MockStore)(nil).ListBlocks), arg0, arg1)
+}
+
+// ListCommentRevisions mocks base method.
+func (m *MockStore) ListCommentRevisions(arg0 context.Context, arg1 uuid.UUID) ([]db.CommentRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCommentRevisions", arg0, arg1)
+	ret0, _ := ret[0].([]db.CommentRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCommentRevisions indicates an expected call of ListCommentRevisions.
+func (mr *MockStoreMockRecorder) ListCommentRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCommentRevisions", reflect.TypeOf((*MockStore)(nil).ListCommentRevisions), arg0, arg1)
+}
+
+// ListContentPreferences mocks base method.
+func (m *MockStore) ListContentPreferences(arg0 context.Context, arg1 uuid.UUID) ([]db.ContentPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListContentPreferences", arg0, arg1)
+	ret0, _ := ret[0].([]db.ContentPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListContentPreferences indicates an expected call of ListContentPreferences.
+func (mr *MockStoreMockRecorder) ListContentPreferences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContentPreferences", reflect.TypeOf((*MockStore)(nil).ListContentPreferences), arg0, arg1)
+}
+
+// ListControversialPosts mocks base method.
+func (m *MockStore) ListControversialPosts(arg0 context.Context, arg1 db.ListControversialPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListControversialPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListControversialPosts indicates an expected call of ListControversialPosts.
+func (mr *MockStoreMockRecorder) ListControversialPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListControversialPosts", reflect.TypeOf((*MockStore)(nil).ListControversialPosts), arg0, arg1)
+}
+
+// ListDataExports mocks base method.
+func (m *MockStore) ListDataExports(arg0 context.Context, arg1 uuid.UUID) ([]db.DataExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDataExports", arg0, arg1)
+	ret0, _ := ret[0].([]db.DataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDataExports indicates an expected call of ListDataExports.
+func (mr *MockStoreMockRecorder) ListDataExports(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDataExports", reflect.TypeOf((*MockStore)(nil).ListDataExports), arg0, arg1)
+}
+
+// ListDigestTopComments mocks base method.
+func (m *MockStore) ListDigestTopComments(arg0 context.Context, arg1 db.ListDigestTopCommentsParams) ([]db.ListDigestTopCommentsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDigestTopComments", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListDigestTopCommentsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDigestTopComments indicates an expected call of ListDigestTopComments.
+func (mr *MockStoreMockRecorder) ListDigestTopComments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDigestTopComments", reflect.TypeOf((*MockStore)(nil).ListDigestTopComments), arg0, arg1)
+}
+
+// ListDraftPosts mocks base method.
+func (m *MockStore) ListDraftPosts(arg0 context.Context, arg1 db.ListDraftPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDraftPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDraftPosts indicates an expected call of ListDraftPosts.
+func (mr *MockStoreMockRecorder) ListDraftPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDraftPosts", reflect.TypeOf((*MockStore)(nil).ListDraftPosts), arg0, arg1)
+}
+
+// ListFeedPosts mocks base method.
+func (m *MockStore) ListFeedPosts(arg0 context.Context, arg1 db.ListFeedPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedPosts indicates an expected call of ListFeedPosts.
+func (mr *MockStoreMockRecorder) ListFeedPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedPosts", reflect.TypeOf((*MockStore)(nil).ListFeedPosts), arg0, arg1)
+}
+
+// ListFollowedIdentities mocks base method.
+func (m *MockStore) ListFollowedIdentities(arg0 context.Context, arg1 db.ListFollowedIdentitiesParams) ([]db.ListFollowedIdentitiesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowedIdentities", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFollowedIdentitiesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowedIdentities indicates an expected call of ListFollowedIdentities.
+func (mr *MockStoreMockRecorder) ListFollowedIdentities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowedIdentities", reflect.TypeOf((*MockStore)(nil).ListFollowedIdentities), arg0, arg1)
+}
+
+// ListFollowedUsers mocks base method.
+func (m *MockStore) ListFollowedUsers(arg0 context.Context, arg1 db.ListFollowedUsersParams) ([]db.ListFollowedUsersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowedUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFollowedUsersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowedUsers indicates an expected call of ListFollowedUsers.
+func (mr *MockStoreMockRecorder) ListFollowedUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowedUsers", reflect.TypeOf((*MockStore)(nil).ListFollowedUsers), arg0, arg1)
+}
+
+// ListFollowers mocks base method.
+func (m *MockStore) ListFollowers(arg0 context.Context, arg1 db.ListFollowersParams) ([]db.ListFollowersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListFollowersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowers indicates an expected call of ListFollowers.
+func (mr *MockStoreMockRecorder) ListFollowers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowers", reflect.TypeOf((*MockStore)(nil).ListFollowers), arg0, arg1)
+}
+
+// ListHotPosts mocks base method.
+func (m *MockStore) ListHotPosts(arg0 context.Context, arg1 db.ListHotPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHotPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHotPosts indicates an expected call of ListHotPosts.
+func (mr *MockStoreMockRecorder) ListHotPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHotPosts", reflect.TypeOf((*MockStore)(nil).ListHotPosts), arg0, arg1)
+}
+
+// ListMediaByMessageId mocks base method.
+func (m *MockStore) ListMediaByMessageId(arg0 context.Context, arg1 uuid.NullUUID) ([]db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaByMessageId", arg0, arg1)
+	ret0, _ := ret[0].([]db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaByMessageId indicates an expected call of ListMediaByMessageId.
+func (mr *MockStoreMockRecorder) ListMediaByMessageId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaByMessageId", reflect.TypeOf((*MockStore)(nil).ListMediaByMessageId), arg0, arg1)
+}
+
+// ListMediaByPostId mocks base method.
+func (m *MockStore) ListMediaByPostId(arg0 context.Context, arg1 uuid.NullUUID) ([]db.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaByPostId", arg0, arg1)
+	ret0, _ := ret[0].([]db.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaByPostId indicates an expected call of ListMediaByPostId.
+func (mr *MockStoreMockRecorder) ListMediaByPostId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaByPostId", reflect.TypeOf((*MockStore)(nil).ListMediaByPostId), arg0, arg1)
+}
+
+// ListMessage mocks base method.
+func (m *MockStore) ListMessage(arg0 context.Context, arg1 db.ListMessageParams) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessage", arg0, arg1)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessage indicates an expected call of ListMessage.
+func (mr *MockStoreMockRecorder) ListMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessage", reflect.TypeOf((*MockStore)(nil).ListMessage), arg0, arg1)
+}
+
+// ListMutedKeywords mocks base method.
+func (m *MockStore) ListMutedKeywords(arg0 context.Context, arg1 uuid.UUID) ([]db.MutedKeyword, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMutedKeywords", arg0, arg1)
+	ret0, _ := ret[0].([]db.MutedKeyword)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMutedKeywords indicates an expected call of ListMutedKeywords.
+func (mr *MockStoreMockRecorder) ListMutedKeywords(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMutedKeywords", reflect.TypeOf((*MockStore)(nil).ListMutedKeywords), arg0, arg1)
+}
+
+// ListNotificationPreferences mocks base method.
+func (m *MockStore) ListNotificationPreferences(arg0 context.Context, arg1 uuid.UUID) ([]db.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationPreferences", arg0, arg1)
+	ret0, _ := ret[0].([]db.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotificationPreferences indicates an expected call of ListNotificationPreferences.
+func (mr *MockStoreMockRecorder) ListNotificationPreferences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationPreferences", reflect.TypeOf((*MockStore)(nil).ListNotificationPreferences), arg0, arg1)
+}
+
+// ListNotifications mocks base method.
+func (m *MockStore) ListNotifications(arg0 context.Context, arg1 db.ListNotificationsParams) ([]db.ListNotificationsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListNotificationsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockStoreMockRecorder) ListNotifications(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockStore)(nil).ListNotifications), arg0, arg1)
+}
+
+// ListPollOptions mocks base method.
+func (m *MockStore) ListPollOptions(arg0 context.Context, arg1 db.ListPollOptionsParams) ([]db.ListPollOptionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPollOptions", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListPollOptionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPollOptions indicates an expected call of ListPollOptions.
+func (mr *MockStoreMockRecorder) ListPollOptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPollOptions", reflect.TypeOf((*MockStore)(nil).ListPollOptions), arg0, arg1)
+}
+
+// ListPolls mocks base method.
+func (m *MockStore) ListPolls(arg0 context.Context, arg1 db.ListPollsParams) ([]db.ListPollsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPolls", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListPollsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPolls indicates an expected call of ListPolls.
+func (mr *MockStoreMockRecorder) ListPolls(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPolls", reflect.TypeOf((*MockStore)(nil).ListPolls), arg0, arg1)
+}
+
+// ListPostRevisions mocks base method.
+func (m *MockStore) ListPostRevisions(arg0 context.Context, arg1 uuid.UUID) ([]db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostRevisions", arg0, arg1)
+	ret0, _ := ret[0].([]db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostRevisions indicates an expected call of ListPostRevisions.
+func (mr *MockStoreMockRecorder) ListPostRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostRevisions", reflect.TypeOf((*MockStore)(nil).ListPostRevisions), arg0, arg1)
+}
+
+// ListPostsByTag mocks base method.
+func (m *MockStore) ListPostsByTag(arg0 context.Context, arg1 db.ListPostsByTagParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostsByTag", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostsByTag indicates an expected call of ListPostsByTag.
+func (mr *MockStoreMockRecorder) ListPostsByTag(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsByTag", reflect.TypeOf((*MockStore)(nil).ListPostsByTag), arg0, arg1)
+}
+
+// ListPublicPostsByUserId mocks base method.
+func (m *MockStore) ListPublicPostsByUserId(arg0 context.Context, arg1 db.ListPublicPostsByUserIdParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPublicPostsByUserId", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPublicPostsByUserId indicates an expected call of ListPublicPostsByUserId.
+func (mr *MockStoreMockRecorder) ListPublicPostsByUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublicPostsByUserId", reflect.TypeOf((*MockStore)(nil).ListPublicPostsByUserId), arg0, arg1)
+}
+
+// ListPushSubscriptions mocks base method.
+func (m *MockStore) ListPushSubscriptions(arg0 context.Context, arg1 uuid.UUID) ([]db.PushSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPushSubscriptions", arg0, arg1)
+	ret0, _ := ret[0].([]db.PushSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPushSubscriptions indicates an expected call of ListPushSubscriptions.
+func (mr *MockStoreMockRecorder) ListPushSubscriptions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPushSubscriptions", reflect.TypeOf((*MockStore)(nil).ListPushSubscriptions), arg0, arg1)
+}
+
+// ListTopPosts mocks base method.
+func (m *MockStore) ListTopPosts(arg0 context.Context, arg1 db.ListTopPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTopPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTopPosts indicates an expected call of ListTopPosts.
+func (mr *MockStoreMockRecorder) ListTopPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTopPosts", reflect.TypeOf((*MockStore)(nil).ListTopPosts), arg0, arg1)
+}
+
+// ListTrashedComments mocks base method.
+func (m *MockStore) ListTrashedComments(arg0 context.Context, arg1 db.ListTrashedCommentsParams) ([]db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrashedComments", arg0, arg1)
+	ret0, _ := ret[0].([]db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrashedComments indicates an expected call of ListTrashedComments.
+func (mr *MockStoreMockRecorder) ListTrashedComments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrashedComments", reflect.TypeOf((*MockStore)(nil).ListTrashedComments), arg0, arg1)
+}
+
+// ListTrashedMessages mocks base method.
+func (m *MockStore) ListTrashedMessages(arg0 context.Context, arg1 db.ListTrashedMessagesParams) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrashedMessages", arg0, arg1)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrashedMessages indicates an expected call of ListTrashedMessages.
+func (mr *MockStoreMockRecorder) ListTrashedMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrashedMessages", reflect.TypeOf((*MockStore)(nil).ListTrashedMessages), arg0, arg1)
+}
+
+// ListTrashedPosts mocks base method.
+func (m *MockStore) ListTrashedPosts(arg0 context.Context, arg1 db.ListTrashedPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrashedPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrashedPosts indicates an expected call of ListTrashedPosts.
+func (mr *MockStoreMockRecorder) ListTrashedPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrashedPosts", reflect.TypeOf((*MockStore)(nil).ListTrashedPosts), arg0, arg1)
+}
+
+// ListTrendingPosts mocks base method.
+func (m *MockStore) ListTrendingPosts(arg0 context.Context, arg1 db.ListTrendingPostsParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrendingPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrendingPosts indicates an expected call of ListTrendingPosts.
+func (mr *MockStoreMockRecorder) ListTrendingPosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrendingPosts", reflect.TypeOf((*MockStore)(nil).ListTrendingPosts), arg0, arg1)
+}
+
+// ListTrendingTags mocks base method.
+func (m *MockStore) ListTrendingTags(arg0 context.Context, arg1 int32) ([]db.ListTrendingTagsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrendingTags", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListTrendingTagsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrendingTags indicates an expected call of ListTrendingTags.
+func (mr *MockStoreMockRecorder) ListTrendingTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrendingTags", reflect.TypeOf((*MockStore)(nil).ListTrendingTags), arg0, arg1)
+}
+
+// ListUserIdentities mocks base method.
+func (m *MockStore) ListUserIdentities(arg0 context.Context, arg1 uuid.UUID) ([]db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIdentities", arg0, arg1)
+	ret0, _ := ret[0].([]db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIdentities indicates an expected call of ListUserIdentities.
+func (mr *MockStoreMockRecorder) ListUserIdentities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIdentities", reflect.TypeOf((*MockStore)(nil).ListUserIdentities), arg0, arg1)
+}
+
+// ListUserIdentitiesDueForRotation mocks base method.
+func (m *MockStore) ListUserIdentitiesDueForRotation(arg0 context.Context, arg1 int32) ([]db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIdentitiesDueForRotation", arg0, arg1)
+	ret0, _ := ret[0].([]db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIdentitiesDueForRotation indicates an expected call of ListUserIdentitiesDueForRotation.
+func (mr *MockStoreMockRecorder) ListUserIdentitiesDueForRotation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIdentitiesDueForRotation", reflect.TypeOf((*MockStore)(nil).ListUserIdentitiesDueForRotation), arg0, arg1)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockStore) ListUserSessions(arg0 context.Context, arg1 uuid.UUID) ([]db.ListUserSessionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListUserSessionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockStoreMockRecorder) ListUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockStore)(nil).ListUserSessions), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockStore) ListUsers(arg0 context.Context, arg1 int32) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockStore) ListWebhookDeliveries(arg0 context.Context, arg1 db.ListWebhookDeliveriesParams) ([]db.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]db.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockStoreMockRecorder) ListWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockStore)(nil).ListWebhookDeliveries), arg0, arg1)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockStore) ListWebhooks(arg0 context.Context, arg1 uuid.UUID) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0, arg1)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockStoreMockRecorder) ListWebhooks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockStore)(nil).ListWebhooks), arg0, arg1)
+}
+
+// ListWebhooksForEvent mocks base method.
+func (m *MockStore) ListWebhooksForEvent(arg0 context.Context, arg1 db.ListWebhooksForEventParams) ([]db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooksForEvent", arg0, arg1)
+	ret0, _ := ret[0].([]db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhooksForEvent indicates an expected call of ListWebhooksForEvent.
+func (mr *MockStoreMockRecorder) ListWebhooksForEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooksForEvent", reflect.TypeOf((*MockStore)(nil).ListWebhooksForEvent), arg0, arg1)
+}
+
+// MarkAllNotificationsRead mocks base method.
+func (m *MockStore) MarkAllNotificationsRead(arg0 context.Context, arg1 uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllNotificationsRead", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkAllNotificationsRead indicates an expected call of MarkAllNotificationsRead.
+func (mr *MockStoreMockRecorder) MarkAllNotificationsRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllNotificationsRead", reflect.TypeOf((*MockStore)(nil).MarkAllNotificationsRead), arg0, arg1)
+}
+
+// MarkEmailDigestSent mocks base method.
+func (m *MockStore) MarkEmailDigestSent(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailDigestSent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailDigestSent indicates an expected call of MarkEmailDigestSent.
+func (mr *MockStoreMockRecorder) MarkEmailDigestSent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailDigestSent", reflect.TypeOf((*MockStore)(nil).MarkEmailDigestSent), arg0, arg1)
+}
+
+// MarkNotificationRead mocks base method.
+func (m *MockStore) MarkNotificationRead(arg0 context.Context, arg1 db.MarkNotificationReadParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationRead", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkNotificationRead indicates an expected call of MarkNotificationRead.
+func (mr *MockStoreMockRecorder) MarkNotificationRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationRead", reflect.TypeOf((*MockStore)(nil).MarkNotificationRead), arg0, arg1)
+}
+
+// PublishDraftPost mocks base method.
+func (m *MockStore) PublishDraftPost(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDraftPost", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDraftPost indicates an expected call of PublishDraftPost.
+func (mr *MockStoreMockRecorder) PublishDraftPost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDraftPost", reflect.TypeOf((*MockStore)(nil).PublishDraftPost), arg0, arg1)
+}
+
+// PublishDuePosts mocks base method.
+func (m *MockStore) PublishDuePosts(arg0 context.Context, arg1 int32) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDuePosts", arg0, arg1)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDuePosts indicates an expected call of PublishDuePosts.
+func (mr *MockStoreMockRecorder) PublishDuePosts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDuePosts", reflect.TypeOf((*MockStore)(nil).PublishDuePosts), arg0, arg1)
+}
+
+// PurgeComments mocks base method.
+func (m *MockStore) PurgeComments(arg0 context.Context, arg1 float64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeComments", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeComments indicates an expected call of PurgeComments.
+func (mr *MockStoreMockRecorder) PurgeComments(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeComments", reflect.TypeOf((*MockStore)(nil).PurgeComments), arg0, arg1)
 }
 
-// CreateComment mocks base method.
-func (m *MockStore) CreateComment(arg0 context.Context, arg1 db.CreateCommentParams) (db.Comment, error) {
+// PurgeMessages mocks base method.
+func (m *MockStore) PurgeMessages(arg0 context.Context, arg1 float64) ([]db.Media, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateComment", arg0, arg1)
-	ret0, _ := ret[0].(db.Comment)
+	ret := m.ctrl.Call(m, "PurgeMessages", arg0, arg1)
+	ret0, _ := ret[0].([]db.Media)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateComment indicates an expected call of CreateComment.
-func (mr *MockStoreMockRecorder) CreateComment(arg0, arg1 interface{}) *gomock.Call {
+// PurgeMessages indicates an expected call of PurgeMessages.
+func (mr *MockStoreMockRecorder) PurgeMessages(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockStore)(nil).CreateComment), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeMessages", reflect.TypeOf((*MockStore)(nil).PurgeMessages), arg0, arg1)
 }
 
-// CreateMessage mocks base method.
-func (m *MockStore) CreateMessage(arg0 context.Context, arg1 db.CreateMessageParams) (db.Message, error) {
+// PurgePosts mocks base method.
+func (m *MockStore) PurgePosts(arg0 context.Context, arg1 float64) ([]db.Media, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateMessage", arg0, arg1)
-	ret0, _ := ret[0].(db.Message)
+	ret := m.ctrl.Call(m, "PurgePosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Media)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateMessage indicates an expected call of CreateMessage.
-func (mr *MockStoreMockRecorder) CreateMessage(arg0, arg1 interface{}) *gomock.Call {
+// PurgePosts indicates an expected call of PurgePosts.
+func (mr *MockStoreMockRecorder) PurgePosts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockStore)(nil).CreateMessage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgePosts", reflect.TypeOf((*MockStore)(nil).PurgePosts), arg0, arg1)
 }
 
-// CreatePost mocks base method.
-func (m *MockStore) CreatePost(arg0 context.Context, arg1 db.CreatePostParams) (db.Post, error) {
+// PurgeUsers mocks base method.
+func (m *MockStore) PurgeUsers(arg0 context.Context) ([]db.Media, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreatePost", arg0, arg1)
-	ret0, _ := ret[0].(db.Post)
+	ret := m.ctrl.Call(m, "PurgeUsers", arg0)
+	ret0, _ := ret[0].([]db.Media)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreatePost indicates an expected call of CreatePost.
-func (mr *MockStoreMockRecorder) CreatePost(arg0, arg1 interface{}) *gomock.Call {
+// PurgeUsers indicates an expected call of PurgeUsers.
+func (mr *MockStoreMockRecorder) PurgeUsers(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockStore)(nil).CreatePost), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUsers", reflect.TypeOf((*MockStore)(nil).PurgeUsers), arg0)
 }
 
-// CreateSession mocks base method.
-func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockStore) RedeliverWebhookDelivery(arg0 context.Context, arg1 db.RedeliverWebhookDeliveryParams) (db.WebhookDelivery, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(db.WebhookDelivery)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateSession indicates an expected call of CreateSession.
-func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockStoreMockRecorder) RedeliverWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockStore)(nil).RedeliverWebhookDelivery), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (uuid.UUID, error) {
+// RefreshPostScores mocks base method.
+func (m *MockStore) RefreshPostScores(arg0 context.Context, arg1 db.RefreshPostScoresParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "RefreshPostScores", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// RefreshPostScores indicates an expected call of RefreshPostScores.
+func (mr *MockStoreMockRecorder) RefreshPostScores(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshPostScores", reflect.TypeOf((*MockStore)(nil).RefreshPostScores), arg0, arg1)
 }
 
-// CreateUserIdentity mocks base method.
-func (m *MockStore) CreateUserIdentity(arg0 context.Context, arg1 db.CreateUserIdentityParams) (db.UserIdentity, error) {
+// ReplaceUserIdentity mocks base method.
+func (m *MockStore) ReplaceUserIdentity(arg0 context.Context, arg1 db.ReplaceUserIdentityParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserIdentity", arg0, arg1)
-	ret0, _ := ret[0].(db.UserIdentity)
+	ret := m.ctrl.Call(m, "ReplaceUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUserIdentity indicates an expected call of CreateUserIdentity.
-func (mr *MockStoreMockRecorder) CreateUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+// ReplaceUserIdentity indicates an expected call of ReplaceUserIdentity.
+func (mr *MockStoreMockRecorder) ReplaceUserIdentity(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserIdentity", reflect.TypeOf((*MockStore)(nil).CreateUserIdentity), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUserIdentity", reflect.TypeOf((*MockStore)(nil).ReplaceUserIdentity), arg0, arg1)
 }
 
-// DeleteComment mocks base method.
-func (m *MockStore) DeleteComment(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+// RestoreComment mocks base method.
+func (m *MockStore) RestoreComment(arg0 context.Context, arg1 db.RestoreCommentParams) (db.RestoreCommentRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteComment", arg0, arg1)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "RestoreComment", arg0, arg1)
+	ret0, _ := ret[0].(db.RestoreCommentRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteComment indicates an expected call of DeleteComment.
-func (mr *MockStoreMockRecorder) DeleteComment(arg0, arg1 interface{}) *gomock.Call {
+// RestoreComment indicates an expected call of RestoreComment.
+func (mr *MockStoreMockRecorder) RestoreComment(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockStore)(nil).DeleteComment), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreComment", reflect.TypeOf((*MockStore)(nil).RestoreComment), arg0, arg1)
 }
 
-// DeleteOneMessage mocks base method.
-func (m *MockStore) DeleteOneMessage(arg0 context.Context, arg1 db.DeleteOneMessageParams) (uuid.UUID, error) {
+// RestoreMessage mocks base method.
+func (m *MockStore) RestoreMessage(arg0 context.Context, arg1 db.RestoreMessageParams) (db.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOneMessage", arg0, arg1)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "RestoreMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteOneMessage indicates an expected call of DeleteOneMessage.
-func (mr *MockStoreMockRecorder) DeleteOneMessage(arg0, arg1 interface{}) *gomock.Call {
+// RestoreMessage indicates an expected call of RestoreMessage.
+func (mr *MockStoreMockRecorder) RestoreMessage(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOneMessage", reflect.TypeOf((*MockStore)(nil).DeleteOneMessage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreMessage", reflect.TypeOf((*MockStore)(nil).RestoreMessage), arg0, arg1)
 }
 
-// DeleteOneUser mocks base method.
-func (m *MockStore) DeleteOneUser(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+// RestorePost mocks base method.
+func (m *MockStore) RestorePost(arg0 context.Context, arg1 db.RestorePostParams) (db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOneUser", arg0, arg1)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "RestorePost", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteOneUser indicates an expected call of DeleteOneUser.
-func (mr *MockStoreMockRecorder) DeleteOneUser(arg0, arg1 interface{}) *gomock.Call {
+// RestorePost indicates an expected call of RestorePost.
+func (mr *MockStoreMockRecorder) RestorePost(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOneUser", reflect.TypeOf((*MockStore)(nil).DeleteOneUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePost", reflect.TypeOf((*MockStore)(nil).RestorePost), arg0, arg1)
 }
 
-// DeletePost mocks base method.
-func (m *MockStore) DeletePost(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+// RestoreUser mocks base method.
+func (m *MockStore) RestoreUser(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePost", arg0, arg1)
+	ret := m.ctrl.Call(m, "RestoreUser", arg0, arg1)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeletePost indicates an expected call of DeletePost.
-func (mr *MockStoreMockRecorder) DeletePost(arg0, arg1 interface{}) *gomock.Call {
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockStoreMockRecorder) RestoreUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockStore)(nil).DeletePost), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockStore)(nil).RestoreUser), arg0, arg1)
 }
 
-// DeleteSession mocks base method.
-func (m *MockStore) DeleteSession(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+// RetireUserIdentity mocks base method.
+func (m *MockStore) RetireUserIdentity(arg0 context.Context, arg1 db.RetireUserIdentityParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSession", arg0, arg1)
+	ret := m.ctrl.Call(m, "RetireUserIdentity", arg0, arg1)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteSession indicates an expected call of DeleteSession.
-func (mr *MockStoreMockRecorder) DeleteSession(arg0, arg1 interface{}) *gomock.Call {
+// RetireUserIdentity indicates an expected call of RetireUserIdentity.
+func (mr *MockStoreMockRecorder) RetireUserIdentity(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStore)(nil).DeleteSession), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireUserIdentity", reflect.TypeOf((*MockStore)(nil).RetireUserIdentity), arg0, arg1)
 }
 
-// DeleteSessionByUserId mocks base method.
-func (m *MockStore) DeleteSessionByUserId(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+// RotateDueUserIdentitiesTx mocks base method.
+func (m *MockStore) RotateDueUserIdentitiesTx(arg0 context.Context, arg1 int32) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSessionByUserId", arg0, arg1)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "RotateDueUserIdentitiesTx", arg0, arg1)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteSessionByUserId indicates an expected call of DeleteSessionByUserId.
-func (mr *MockStoreMockRecorder) DeleteSessionByUserId(arg0, arg1 interface{}) *gomock.Call {
+// RotateDueUserIdentitiesTx indicates an expected call of RotateDueUserIdentitiesTx.
+func (mr *MockStoreMockRecorder) RotateDueUserIdentitiesTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionByUserId", reflect.TypeOf((*MockStore)(nil).DeleteSessionByUserId), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateDueUserIdentitiesTx", reflect.TypeOf((*MockStore)(nil).RotateDueUserIdentitiesTx), arg0, arg1)
 }
 
-// GetComment mocks base method.
-func (m *MockStore) GetComment(arg0 context.Context, arg1 uuid.UUID) (db.Comment, error) {
+// RotateUserIdentityTx mocks base method.
+func (m *MockStore) RotateUserIdentityTx(arg0 context.Context, arg1 db.RotateUserIdentityTxParams) (db.UserIdentity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetComment", arg0, arg1)
-	ret0, _ := ret[0].(db.Comment)
+	ret := m.ctrl.Call(m, "RotateUserIdentityTx", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetComment indicates an expected call of GetComment.
-func (mr *MockStoreMockRecorder) GetComment(arg0, arg1 interface{}) *gomock.Call {
+// RotateUserIdentityTx indicates an expected call of RotateUserIdentityTx.
+func (mr *MockStoreMockRecorder) RotateUserIdentityTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockStore)(nil).GetComment), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateUserIdentityTx", reflect.TypeOf((*MockStore)(nil).RotateUserIdentityTx), arg0, arg1)
 }
 
-// GetMessageById mocks base method.
-func (m *MockStore) GetMessageById(arg0 context.Context, arg1 uuid.UUID) (db.Message, error) {
+// SearchComments mocks base method.
+func (m *MockStore) SearchComments(arg0 context.Context, arg1 db.SearchCommentsParams) ([]db.SearchCommentsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessageById", arg0, arg1)
-	ret0, _ := ret[0].(db.Message)
+	ret := m.ctrl.Call(m, "SearchComments", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchCommentsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetMessageById indicates an expected call of GetMessageById.
-func (mr *MockStoreMockRecorder) GetMessageById(arg0, arg1 interface{}) *gomock.Call {
+// SearchComments indicates an expected call of SearchComments.
+func (mr *MockStoreMockRecorder) SearchComments(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageById", reflect.TypeOf((*MockStore)(nil).GetMessageById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchComments", reflect.TypeOf((*MockStore)(nil).SearchComments), arg0, arg1)
 }
 
-// GetPostById mocks base method.
-func (m *MockStore) GetPostById(arg0 context.Context, arg1 uuid.UUID) (db.Post, error) {
+// SearchPosts mocks base method.
+func (m *MockStore) SearchPosts(arg0 context.Context, arg1 db.SearchPostsParams) ([]db.SearchPostsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostById", arg0, arg1)
-	ret0, _ := ret[0].(db.Post)
+	ret := m.ctrl.Call(m, "SearchPosts", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchPostsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostById indicates an expected call of GetPostById.
-func (mr *MockStoreMockRecorder) GetPostById(arg0, arg1 interface{}) *gomock.Call {
+// SearchPosts indicates an expected call of SearchPosts.
+func (mr *MockStoreMockRecorder) SearchPosts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostById", reflect.TypeOf((*MockStore)(nil).GetPostById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPosts", reflect.TypeOf((*MockStore)(nil).SearchPosts), arg0, arg1)
 }
 
-// GetSessionById mocks base method.
-func (m *MockStore) GetSessionById(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+// SearchTags mocks base method.
+func (m *MockStore) SearchTags(arg0 context.Context, arg1 string) ([]db.SearchTagsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSessionById", arg0, arg1)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "SearchTags", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchTagsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSessionById indicates an expected call of GetSessionById.
-func (mr *MockStoreMockRecorder) GetSessionById(arg0, arg1 interface{}) *gomock.Call {
+// SearchTags indicates an expected call of SearchTags.
+func (mr *MockStoreMockRecorder) SearchTags(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionById", reflect.TypeOf((*MockStore)(nil).GetSessionById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTags", reflect.TypeOf((*MockStore)(nil).SearchTags), arg0, arg1)
 }
 
-// GetUserById mocks base method.
-func (m *MockStore) GetUserById(arg0 context.Context, arg1 uuid.UUID) (db.User, error) {
+// SetContentPreferences mocks base method.
+func (m *MockStore) SetContentPreferences(arg0 context.Context, arg1 db.SetContentPreferencesParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SetContentPreferences", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUserById indicates an expected call of GetUserById.
-func (mr *MockStoreMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+// SetContentPreferences indicates an expected call of SetContentPreferences.
+func (mr *MockStoreMockRecorder) SetContentPreferences(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStore)(nil).GetUserById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetContentPreferences", reflect.TypeOf((*MockStore)(nil).SetContentPreferences), arg0, arg1)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+// SetDefaultUserIdentity mocks base method.
+func (m *MockStore) SetDefaultUserIdentity(arg0 context.Context, arg1 db.SetDefaultUserIdentityParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "SetDefaultUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDefaultUserIdentity indicates an expected call of SetDefaultUserIdentity.
+func (mr *MockStoreMockRecorder) SetDefaultUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultUserIdentity", reflect.TypeOf((*MockStore)(nil).SetDefaultUserIdentity), arg0, arg1)
+}
+
+// SetDefaultUserIdentityTx mocks base method.
+func (m *MockStore) SetDefaultUserIdentityTx(arg0 context.Context, arg1 db.SetDefaultUserIdentityParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDefaultUserIdentityTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDefaultUserIdentityTx indicates an expected call of SetDefaultUserIdentityTx.
+func (mr *MockStoreMockRecorder) SetDefaultUserIdentityTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDefaultUserIdentityTx", reflect.TypeOf((*MockStore)(nil).SetDefaultUserIdentityTx), arg0, arg1)
+}
+
+// SetEmailDigest mocks base method.
+func (m *MockStore) SetEmailDigest(arg0 context.Context, arg1 db.SetEmailDigestParams) (db.EmailDigest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailDigest", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailDigest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+// SetEmailDigest indicates an expected call of SetEmailDigest.
+func (mr *MockStoreMockRecorder) SetEmailDigest(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailDigest", reflect.TypeOf((*MockStore)(nil).SetEmailDigest), arg0, arg1)
 }
 
-// GetUserIdentityById mocks base method.
-func (m *MockStore) GetUserIdentityById(arg0 context.Context, arg1 uuid.UUID) (db.UserIdentity, error) {
+// SetNotificationPreferences mocks base method.
+func (m *MockStore) SetNotificationPreferences(arg0 context.Context, arg1 db.SetNotificationPreferencesParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIdentityById", arg0, arg1)
-	ret0, _ := ret[0].(db.UserIdentity)
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockStoreMockRecorder) SetNotificationPreferences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockStore)(nil).SetNotificationPreferences), arg0, arg1)
+}
+
+// SetPostContentFlags mocks base method.
+func (m *MockStore) SetPostContentFlags(arg0 context.Context, arg1 db.SetPostContentFlagsParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPostContentFlags", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIdentityById indicates an expected call of GetUserIdentityById.
-func (mr *MockStoreMockRecorder) GetUserIdentityById(arg0, arg1 interface{}) *gomock.Call {
+// SetPostContentFlags indicates an expected call of SetPostContentFlags.
+func (mr *MockStoreMockRecorder) SetPostContentFlags(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentityById", reflect.TypeOf((*MockStore)(nil).GetUserIdentityById), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPostContentFlags", reflect.TypeOf((*MockStore)(nil).SetPostContentFlags), arg0, arg1)
 }
 
-// GetUserIdentityByUserId mocks base method.
-func (m *MockStore) GetUserIdentityByUserId(arg0 context.Context, arg1 uuid.UUID) (db.UserIdentity, error) {
+// SetPostReaction mocks base method.
+func (m *MockStore) SetPostReaction(arg0 context.Context, arg1 db.SetPostReactionParams) (db.PostReaction, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIdentityByUserId", arg0, arg1)
-	ret0, _ := ret[0].(db.UserIdentity)
+	ret := m.ctrl.Call(m, "SetPostReaction", arg0, arg1)
+	ret0, _ := ret[0].(db.PostReaction)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIdentityByUserId indicates an expected call of GetUserIdentityByUserId.
-func (mr *MockStoreMockRecorder) GetUserIdentityByUserId(arg0, arg1 interface{}) *gomock.Call {
+// SetPostReaction indicates an expected call of SetPostReaction.
+func (mr *MockStoreMockRecorder) SetPostReaction(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentityByUserId", reflect.TypeOf((*MockStore)(nil).GetUserIdentityByUserId), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPostReaction", reflect.TypeOf((*MockStore)(nil).SetPostReaction), arg0, arg1)
 }
 
-// ListAllComments mocks base method.
-func (m *MockStore) ListAllComments(arg0 context.Context, arg1 uuid.UUID) ([]db.Comment, error) {
+// SetPostTags mocks base method.
+func (m *MockStore) SetPostTags(arg0 context.Context, arg1 db.SetPostTagsParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAllComments", arg0, arg1)
-	ret0, _ := ret[0].([]db.Comment)
+	ret := m.ctrl.Call(m, "SetPostTags", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPostTags indicates an expected call of SetPostTags.
+func (mr *MockStoreMockRecorder) SetPostTags(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPostTags", reflect.TypeOf((*MockStore)(nil).SetPostTags), arg0, arg1)
+}
+
+// SetUserModerator mocks base method.
+func (m *MockStore) SetUserModerator(arg0 context.Context, arg1 db.SetUserModeratorParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserModerator", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAllComments indicates an expected call of ListAllComments.
-func (mr *MockStoreMockRecorder) ListAllComments(arg0, arg1 interface{}) *gomock.Call {
+// SetUserModerator indicates an expected call of SetUserModerator.
+func (mr *MockStoreMockRecorder) SetUserModerator(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllComments", reflect.TypeOf((*MockStore)(nil).ListAllComments), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserModerator", reflect.TypeOf((*MockStore)(nil).SetUserModerator), arg0, arg1)
 }
 
-// ListAllPosts mocks base method.
-func (m *MockStore) ListAllPosts(arg0 context.Context, arg1 int32) ([]db.Post, error) {
+// UnfollowIdentity mocks base method.
+func (m *MockStore) UnfollowIdentity(arg0 context.Context, arg1 db.UnfollowIdentityParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAllPosts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Post)
+	ret := m.ctrl.Call(m, "UnfollowIdentity", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAllPosts indicates an expected call of ListAllPosts.
-func (mr *MockStoreMockRecorder) ListAllPosts(arg0, arg1 interface{}) *gomock.Call {
+// UnfollowIdentity indicates an expected call of UnfollowIdentity.
+func (mr *MockStoreMockRecorder) UnfollowIdentity(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllPosts", reflect.TypeOf((*MockStore)(nil).ListAllPosts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowIdentity", reflect.TypeOf((*MockStore)(nil).UnfollowIdentity), arg0, arg1)
 }
 
-// ListMessage mocks base method.
-func (m *MockStore) ListMessage(arg0 context.Context, arg1 db.ListMessageParams) ([]db.Message, error) {
+// UnfollowUser mocks base method.
+func (m *MockStore) UnfollowUser(arg0 context.Context, arg1 db.UnfollowUserParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMessage", arg0, arg1)
-	ret0, _ := ret[0].([]db.Message)
+	ret := m.ctrl.Call(m, "UnfollowUser", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListMessage indicates an expected call of ListMessage.
-func (mr *MockStoreMockRecorder) ListMessage(arg0, arg1 interface{}) *gomock.Call {
+// UnfollowUser indicates an expected call of UnfollowUser.
+func (mr *MockStoreMockRecorder) UnfollowUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessage", reflect.TypeOf((*MockStore)(nil).ListMessage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfollowUser", reflect.TypeOf((*MockStore)(nil).UnfollowUser), arg0, arg1)
 }
 
-// ListUsers mocks base method.
-func (m *MockStore) ListUsers(arg0 context.Context, arg1 int32) ([]db.User, error) {
+// UnsubscribeEmailDigest mocks base method.
+func (m *MockStore) UnsubscribeEmailDigest(arg0 context.Context, arg1 string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1)
-	ret0, _ := ret[0].([]db.User)
+	ret := m.ctrl.Call(m, "UnsubscribeEmailDigest", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListUsers indicates an expected call of ListUsers.
-func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call {
+// UnsubscribeEmailDigest indicates an expected call of UnsubscribeEmailDigest.
+func (mr *MockStoreMockRecorder) UnsubscribeEmailDigest(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsubscribeEmailDigest", reflect.TypeOf((*MockStore)(nil).UnsubscribeEmailDigest), arg0, arg1)
 }
 
 // UpdateComment mocks base method.
@@ -426,6 +2465,36 @@ func (mr *MockStoreMockRecorder) UpdateComment(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockStore)(nil).UpdateComment), arg0, arg1)
 }
 
+// UpdateDraftPost mocks base method.
+func (m *MockStore) UpdateDraftPost(arg0 context.Context, arg1 db.UpdateDraftPostParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDraftPost", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDraftPost indicates an expected call of UpdateDraftPost.
+func (mr *MockStoreMockRecorder) UpdateDraftPost(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDraftPost", reflect.TypeOf((*MockStore)(nil).UpdateDraftPost), arg0, arg1)
+}
+
+// UpdateDraftPostTx mocks base method.
+func (m *MockStore) UpdateDraftPostTx(arg0 context.Context, arg1 db.UpdateDraftPostTxParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDraftPostTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDraftPostTx indicates an expected call of UpdateDraftPostTx.
+func (mr *MockStoreMockRecorder) UpdateDraftPostTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDraftPostTx", reflect.TypeOf((*MockStore)(nil).UpdateDraftPostTx), arg0, arg1)
+}
+
 // UpdateMessageStatus mocks base method.
 func (m *MockStore) UpdateMessageStatus(arg0 context.Context, arg1 db.UpdateMessageStatusParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -456,6 +2525,51 @@ func (mr *MockStoreMockRecorder) UpdatePost(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockStore)(nil).UpdatePost), arg0, arg1)
 }
 
+// UpdatePostTx mocks base method.
+func (m *MockStore) UpdatePostTx(arg0 context.Context, arg1 db.UpdatePostTxParams) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostTx", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostTx indicates an expected call of UpdatePostTx.
+func (mr *MockStoreMockRecorder) UpdatePostTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostTx", reflect.TypeOf((*MockStore)(nil).UpdatePostTx), arg0, arg1)
+}
+
+// UpdateUserIdentity mocks base method.
+func (m *MockStore) UpdateUserIdentity(arg0 context.Context, arg1 db.UpdateUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserIdentity", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserIdentity indicates an expected call of UpdateUserIdentity.
+func (mr *MockStoreMockRecorder) UpdateUserIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserIdentity", reflect.TypeOf((*MockStore)(nil).UpdateUserIdentity), arg0, arg1)
+}
+
+// UpdateUserIdentityRotation mocks base method.
+func (m *MockStore) UpdateUserIdentityRotation(arg0 context.Context, arg1 db.UpdateUserIdentityRotationParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserIdentityRotation", arg0, arg1)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserIdentityRotation indicates an expected call of UpdateUserIdentityRotation.
+func (mr *MockStoreMockRecorder) UpdateUserIdentityRotation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserIdentityRotation", reflect.TypeOf((*MockStore)(nil).UpdateUserIdentityRotation), arg0, arg1)
+}
+
 // UpdateUserPassword mocks base method.
 func (m *MockStore) UpdateUserPassword(arg0 context.Context, arg1 db.UpdateUserPasswordParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -471,6 +2585,21 @@ func (mr *MockStoreMockRecorder) UpdateUserPassword(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserPassword), arg0, arg1)
 }
 
+// UpdateUserProfile mocks base method.
+func (m *MockStore) UpdateUserProfile(arg0 context.Context, arg1 db.UpdateUserProfileParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserProfile", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserProfile indicates an expected call of UpdateUserProfile.
+func (mr *MockStoreMockRecorder) UpdateUserProfile(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockStore)(nil).UpdateUserProfile), arg0, arg1)
+}
+
 // UpdateUsername mocks base method.
 func (m *MockStore) UpdateUsername(arg0 context.Context, arg1 db.UpdateUsernameParams) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -485,3 +2614,18 @@ func (mr *MockStoreMockRecorder) UpdateUsername(arg0, arg1 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsername", reflect.TypeOf((*MockStore)(nil).UpdateUsername), arg0, arg1)
 }
+
+// UpdateWebhook mocks base method.
+func (m *MockStore) UpdateWebhook(arg0 context.Context, arg1 db.UpdateWebhookParams) (db.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(db.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockStoreMockRecorder) UpdateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockStore)(nil).UpdateWebhook), arg0, arg1)
+}
@@ -0,0 +1,70 @@
+-- name: BlockUser :one
+INSERT INTO "blocks" (
+    id, user_id, blocked_user_id, kind
+) VALUES (
+    sqlc.arg(id), sqlc.arg(user_id), sqlc.arg(blocked_user_id)::uuid, sqlc.arg(kind)
+) ON CONFLICT ("user_id", "blocked_user_id") WHERE "blocked_user_id" IS NOT NULL
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING *;
+
+-- name: BlockIdentity :one
+INSERT INTO "blocks" (
+    id, user_id, blocked_identity_id, kind
+) VALUES (
+    sqlc.arg(id), sqlc.arg(user_id), sqlc.arg(blocked_identity_id)::uuid, sqlc.arg(kind)
+) ON CONFLICT ("user_id", "blocked_identity_id") WHERE "blocked_identity_id" IS NOT NULL
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING *;
+
+-- name: ListBlocks :many
+SELECT *
+FROM "blocks"
+WHERE user_id = $1 AND kind = $2
+ORDER BY created_at DESC, id
+LIMIT 20
+OFFSET $3;
+
+-- name: DeleteBlock :one
+DELETE FROM "blocks"
+WHERE id = $1 AND user_id = $2
+RETURNING id;
+
+-- name: IsBlocked :one
+-- a block of any identity counts for all identities of the same user
+SELECT EXISTS (
+    SELECT 1
+    FROM "blocks"
+    LEFT JOIN "user_identities" ON user_identities.id = blocks.blocked_identity_id
+    WHERE blocks.user_id = sqlc.arg(user_id)::uuid
+      AND blocks.kind = 'block'
+      AND (blocks.blocked_user_id = sqlc.arg(actor_id)::uuid OR user_identities.user_id = sqlc.arg(actor_id)::uuid)
+);
+
+-- name: HasBlocks :one
+-- whether the user blocks anybody, they can't be told apart from anonymous senders
+SELECT EXISTS (
+    SELECT 1 FROM "blocks" WHERE user_id = $1 AND kind = 'block'
+);
+
+-- name: CreateMutedKeyword :one
+INSERT INTO "muted_keywords" (
+    id, user_id, keyword
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: ListMutedKeywords :many
+SELECT *
+FROM "muted_keywords"
+WHERE user_id = $1
+ORDER BY keyword;
+
+-- name: CountMutedKeywords :one
+SELECT count(*)
+FROM "muted_keywords"
+WHERE user_id = $1;
+
+-- name: DeleteMutedKeyword :one
+DELETE FROM "muted_keywords"
+WHERE id = $1 AND user_id = $2
+RETURNING id;
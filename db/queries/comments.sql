@@ -1,7 +1,13 @@
 -- name: ListAllComments :many
-SELECT * FROM "comments" WHERE post_id = $1;
+SELECT * FROM "comments"
+WHERE post_id = sqlc.arg(post_id) AND NOT comment_trashed(id)
+    AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, user_identity_id, content);
 
 -- name: GetComment :one
+SELECT * FROM "comments" WHERE id = $1 AND NOT comment_trashed(id) AND NOT author_deleted(user_identity_id);
+
+-- name: GetCommentIncludingDeleted :one
+-- the comment whatever became of it, for the revisions its author and moderators can read
 SELECT * FROM "comments" WHERE id = $1;
 
 -- name: CreateComment :one
@@ -18,7 +24,44 @@ INSERT INTO "comments"(
 ) RETURNING *;
 
 -- name: UpdateComment :one
-UPDATE "comments" SET content = $1, updated_at = $2 WHERE id = $3 RETURNING id;
+-- the row lock of the update serializes concurrent edits, so revision numbers never collide
+WITH comment AS (
+    UPDATE "comments"
+    SET content = sqlc.arg(content), updated_at = sqlc.arg(updated_at), revision = revision + 1, edited = true
+    WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+    RETURNING id, revision, content
+)
+INSERT INTO comment_revisions (comment_id, revision, user_identity_id, content)
+SELECT comment.id, comment.revision, sqlc.arg(editor_identity_id)::uuid, comment.content FROM comment
+RETURNING comment_id;
 
 -- name: DeleteComment :one
-DELETE FROM "comments" WHERE id = $1 RETURNING id;
\ No newline at end of file
+-- moves the comment to the trash, its replies are hidden along with it
+UPDATE "comments" SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id;
+
+-- name: RestoreComment :one
+-- marks the score of the post dirty, the comment counts again but its deleted_at is gone
+WITH comment AS (
+    UPDATE "comments" SET deleted_at = NULL
+    WHERE comments.id = sqlc.arg(id) AND comments.deleted_at IS NOT NULL
+        AND comments.user_identity_id IN (SELECT user_identities.id FROM user_identities WHERE user_identities.user_id = sqlc.arg(user_id)::uuid)
+    RETURNING *
+), score AS (
+    UPDATE post_scores SET dirty = true WHERE post_scores.post_id IN (SELECT comment.post_id FROM comment)
+)
+SELECT comment.id, comment.content, comment.user_identity_id, comment.post_id, comment.parent_id,
+    comment.created_at, comment.updated_at, comment.revision, comment.edited, comment.deleted_at
+FROM comment;
+
+-- name: ListTrashedComments :many
+SELECT comments.*
+FROM "comments"
+JOIN user_identities ON user_identities.id = comments.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid AND comments.deleted_at IS NOT NULL
+ORDER BY comments.deleted_at DESC, comments.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: PurgeComments :execrows
+-- removes the comments trashed longer ago than the retention for good, their replies go with them
+DELETE FROM "comments" WHERE deleted_at < now() - sqlc.arg(retention_seconds)::float8 * interval '1 second';
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: ListContentPreferences :many
+SELECT * FROM content_preferences WHERE user_id = $1 ORDER BY category;
+
+-- name: SetContentPreferences :exec
+-- replaces the preferences of the user, the categories and actions pair up by position
+WITH removed AS (
+    DELETE FROM content_preferences
+    WHERE content_preferences.user_id = sqlc.arg(user_id)::uuid
+        AND NOT (content_preferences.category = ANY(sqlc.arg(categories)::varchar[]))
+)
+INSERT INTO content_preferences (user_id, category, action)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(categories)::varchar[]), unnest(sqlc.arg(actions)::varchar[])
+ON CONFLICT (user_id, category) DO UPDATE SET action = EXCLUDED.action;
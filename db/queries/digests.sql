@@ -0,0 +1,79 @@
+-- name: GetEmailDigest :one
+SELECT * FROM "email_digests" WHERE user_id = $1 LIMIT 1;
+
+-- name: SetEmailDigest :one
+-- opts in or changes the settings, the next digest is one period after the last one or from now on the first
+INSERT INTO "email_digests" (
+    user_id, email, frequency, unsubscribe_token, next_send_at
+) VALUES (
+    sqlc.arg(user_id), sqlc.arg(email), sqlc.arg(frequency), sqlc.arg(unsubscribe_token),
+    now() + digest_period(sqlc.arg(frequency))
+)
+ON CONFLICT (user_id) DO UPDATE
+SET email = excluded.email,
+    frequency = excluded.frequency,
+    next_send_at = coalesce(email_digests.last_sent_at, now()) + digest_period(excluded.frequency),
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteEmailDigest :one
+DELETE FROM "email_digests" WHERE user_id = $1 RETURNING user_id;
+
+-- name: UnsubscribeEmailDigest :execrows
+DELETE FROM "email_digests" WHERE unsubscribe_token = $1;
+
+-- name: ClaimDueEmailDigests :many
+-- takes the due digests of accounts that are not being deleted, moving them past the lease so a server that dies
+-- while sending leaves them to be retried. SKIP LOCKED lets several servers share the work.
+UPDATE "email_digests"
+SET next_send_at = now() + sqlc.arg(lease_seconds)::float8 * interval '1 second'
+FROM "users"
+WHERE users.id = email_digests.user_id AND email_digests.user_id IN (
+    SELECT due.user_id
+    FROM "email_digests" AS due
+    JOIN "users" AS due_users ON due_users.id = due.user_id
+    WHERE due.next_send_at <= now() AND due_users.deleted_at IS NULL
+    ORDER BY due.next_send_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE OF due SKIP LOCKED
+)
+RETURNING email_digests.*, users.username;
+
+-- name: MarkEmailDigestSent :exec
+UPDATE "email_digests"
+SET last_sent_at = now(), next_send_at = now() + digest_period(frequency)
+WHERE user_id = $1;
+
+-- name: CountDigestMessages :one
+SELECT count(*) AS unread, count(*) FILTER (WHERE created_at >= sqlc.arg(since)::timestamptz) AS new
+FROM "messages"
+WHERE receiver_id = sqlc.arg(user_id) AND NOT seen AND deleted_at IS NULL;
+
+-- name: ListDigestTopComments :many
+-- the comments others left on the posts of the user since the last digest, the most replied to first
+SELECT comments.id, comments.content, comments.post_id, posts.content AS post_content, count(replies.id) AS replies
+FROM "comments"
+JOIN "posts" ON posts.id = comments.post_id
+JOIN "user_identities" AS authors ON authors.id = posts.user_identity_id
+JOIN "user_identities" AS commenters ON commenters.id = comments.user_identity_id
+LEFT JOIN "comments" AS replies
+    ON replies.parent_id = comments.id AND replies.id <> comments.id AND replies.deleted_at IS NULL
+WHERE authors.user_id = sqlc.arg(user_id)::uuid
+    AND commenters.user_id IS DISTINCT FROM sqlc.arg(user_id)::uuid
+    AND comments.created_at >= sqlc.arg(since)::timestamptz
+    AND NOT comment_trashed(comments.id)
+    AND NOT hidden_from(sqlc.arg(user_id)::uuid, comments.user_identity_id, comments.content)
+GROUP BY comments.id, posts.id
+ORDER BY replies DESC, comments.created_at DESC, comments.id
+LIMIT sqlc.arg(top);
+
+-- name: CountDigestFollowers :one
+-- new follows of the user or any of their identities
+SELECT count(*)
+FROM "follows"
+WHERE follows.created_at >= sqlc.arg(since)::timestamptz AND (
+    follows.followee_user_id = sqlc.arg(user_id)::uuid
+    OR follows.followee_identity_id IN (
+        SELECT id FROM "user_identities" WHERE user_identities.user_id = sqlc.arg(user_id)::uuid
+    )
+);
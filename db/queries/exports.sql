@@ -0,0 +1,81 @@
+-- name: CreateDataExport :one
+INSERT INTO "data_exports" (
+    id, user_id, format
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetDataExport :one
+SELECT *
+FROM "data_exports"
+WHERE id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: ListDataExports :many
+SELECT *
+FROM "data_exports"
+WHERE user_id = $1
+ORDER BY created_at DESC, id
+LIMIT 20;
+
+-- name: ClaimDataExport :one
+-- takes the oldest pending export, or one whose worker died while building it.
+-- SKIP LOCKED lets several servers work through the queue without building an export twice.
+UPDATE "data_exports"
+SET status = 'running', started_at = now()
+WHERE id = (
+    SELECT data_exports.id
+    FROM "data_exports"
+    WHERE data_exports.status = 'pending'
+        OR (data_exports.status = 'running' AND data_exports.started_at < now() - sqlc.arg(stale_seconds)::float8 * interval '1 second')
+    ORDER BY data_exports.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteDataExport :one
+UPDATE "data_exports"
+SET status = 'done', storage_key = sqlc.arg(storage_key), size = sqlc.arg(size), completed_at = now(),
+    expires_at = now() + sqlc.arg(ttl_seconds)::float8 * interval '1 second'
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: FailDataExport :exec
+UPDATE "data_exports"
+SET status = 'failed', error = sqlc.arg(error), completed_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteExpiredDataExports :many
+-- the archives are removed from storage by the caller
+DELETE FROM "data_exports"
+WHERE expires_at < now()
+RETURNING *;
+
+-- name: ListUserSessions :many
+-- leaves the refresh tokens out, they are secrets even to their owner
+SELECT id, user_agent, client_ip, is_blocked, created_at, expires_at
+FROM "sessions"
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ExportUserMessages :many
+SELECT *
+FROM "messages"
+WHERE receiver_id = $1
+ORDER BY created_at, id;
+
+-- name: ExportUserPosts :many
+-- every post under the identities still linked to the user, anonymous and deleted ones included
+SELECT posts.*
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid
+ORDER BY posts.created_at, posts.id;
+
+-- name: ExportUserComments :many
+SELECT comments.*
+FROM "comments"
+JOIN user_identities ON user_identities.id = comments.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid
+ORDER BY comments.created_at, comments.id;
@@ -0,0 +1,49 @@
+-- name: FollowUser :exec
+INSERT INTO "follows" (
+    id, follower_id, followee_user_id
+) VALUES (
+    sqlc.arg(id), sqlc.arg(follower_id), sqlc.arg(followee_user_id)::uuid
+) ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :one
+DELETE FROM "follows"
+WHERE follower_id = sqlc.arg(follower_id) AND followee_user_id = sqlc.arg(followee_user_id)::uuid
+RETURNING id;
+
+-- name: FollowIdentity :exec
+INSERT INTO "follows" (
+    id, follower_id, followee_identity_id
+) VALUES (
+    sqlc.arg(id), sqlc.arg(follower_id), sqlc.arg(followee_identity_id)::uuid
+) ON CONFLICT DO NOTHING;
+
+-- name: UnfollowIdentity :one
+DELETE FROM "follows"
+WHERE follower_id = sqlc.arg(follower_id) AND followee_identity_id = sqlc.arg(followee_identity_id)::uuid
+RETURNING id;
+
+-- name: ListFollowers :many
+SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at
+FROM "follows"
+JOIN "users" ON users.id = follows.follower_id
+WHERE follows.followee_user_id = sqlc.arg(user_id)::uuid AND users.deleted_at IS NULL
+ORDER BY follows.created_at DESC, users.username
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListFollowedUsers :many
+SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at
+FROM "follows"
+JOIN "users" ON users.id = follows.followee_user_id
+WHERE follows.follower_id = sqlc.arg(user_id)::uuid AND users.deleted_at IS NULL
+ORDER BY follows.created_at DESC, users.username
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListFollowedIdentities :many
+SELECT follows.followee_identity_id::uuid AS identity_id, follows.created_at AS followed_at
+FROM "follows"
+WHERE follows.follower_id = sqlc.arg(user_id)::uuid AND follows.followee_identity_id IS NOT NULL
+ORDER BY follows.created_at DESC, follows.followee_identity_id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
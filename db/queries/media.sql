@@ -0,0 +1,39 @@
+-- name: CreateMedia :one
+INSERT INTO "media" (
+    id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: GetMediaById :one
+SELECT *
+FROM "media"
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListMediaByPostId :many
+SELECT *
+FROM "media"
+WHERE post_id = $1
+ORDER BY created_at, id;
+
+-- name: ListMediaByMessageId :many
+SELECT *
+FROM "media"
+WHERE message_id = $1
+ORDER BY created_at, id;
+
+-- name: CountMediaByPostId :one
+SELECT count(*)
+FROM "media"
+WHERE post_id = $1;
+
+-- name: DeleteMedia :one
+DELETE FROM "media"
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteOldAvatars :many
+DELETE FROM "media"
+WHERE kind = 'avatar' AND user_id = sqlc.arg(user_id)::uuid AND id <> sqlc.arg(keep_id)
+RETURNING *;
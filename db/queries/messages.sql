@@ -1,13 +1,13 @@
 -- name: GetMessageById :one
 SELECT *
 FROM "messages"
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: ListMessage :many
 SELECT *
 FROM "messages"
-WHERE receiver_id = $1
+WHERE receiver_id = $1 AND deleted_at IS NULL
 LIMIT 20
 OFFSET $2;
 
@@ -21,10 +21,33 @@ INSERT INTO "messages" (
 -- name: UpdateMessageStatus :one
 UPDATE "messages"
 SET seen = TRUE, updated_at = $1
-WHERE id = $2 AND receiver_id = $3
+WHERE id = $2 AND receiver_id = $3 AND deleted_at IS NULL
 RETURNING id;
 
 -- name: DeleteOneMessage :one
-DELETE FROM "messages"
-WHERE id = $1 AND receiver_id = $2
-RETURNING id;
\ No newline at end of file
+-- moves the message to the trash
+UPDATE "messages"
+SET deleted_at = now()
+WHERE id = $1 AND receiver_id = $2 AND deleted_at IS NULL
+RETURNING id;
+
+-- name: RestoreMessage :one
+UPDATE "messages"
+SET deleted_at = NULL
+WHERE id = $1 AND receiver_id = $2 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: ListTrashedMessages :many
+SELECT *
+FROM "messages"
+WHERE receiver_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT 20
+OFFSET $2;
+
+-- name: PurgeMessages :many
+-- removes the messages trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+WITH purged AS (
+    DELETE FROM "messages" WHERE messages.deleted_at < now() - sqlc.arg(retention_seconds)::float8 * interval '1 second' RETURNING messages.id
+)
+SELECT media.* FROM media WHERE media.message_id IN (SELECT purged.id FROM purged);
\ No newline at end of file
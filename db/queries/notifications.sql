@@ -0,0 +1,48 @@
+-- name: CreateNotification :one
+-- folds the event into the unread notification of the same type and subject, counting each actor once.
+-- nothing is written when the user turned the type off.
+INSERT INTO notifications (id, user_id, type, subject_id, actors)
+SELECT sqlc.arg(id), sqlc.arg(user_id), sqlc.arg(type), sqlc.arg(subject_id), ARRAY[sqlc.arg(actor_id)::uuid]
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = sqlc.arg(user_id)
+        AND notification_preferences.type = sqlc.arg(type)
+        AND NOT notification_preferences.enabled
+)
+ON CONFLICT (user_id, type, subject_id) WHERE read_at IS NULL DO UPDATE SET
+    actors = CASE
+        WHEN sqlc.arg(actor_id)::uuid = ANY(notifications.actors) THEN notifications.actors
+        ELSE array_append(notifications.actors, sqlc.arg(actor_id)::uuid)
+    END,
+    updated_at = now()
+RETURNING id, user_id, type, subject_id, cardinality(actors)::bigint AS count, read_at, created_at, updated_at;
+
+-- name: ListNotifications :many
+-- the notifications of the user, latest activity first, with the number of actors folded into each
+SELECT id, user_id, type, subject_id, cardinality(actors)::bigint AS count, read_at, created_at, updated_at
+FROM notifications
+WHERE user_id = sqlc.arg(user_id) AND (NOT sqlc.arg(unread_only)::bool OR read_at IS NULL)
+ORDER BY updated_at DESC, id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :one
+-- marking a read notification again keeps the time it was first read
+UPDATE notifications SET read_at = coalesce(read_at, now())
+WHERE id = $1 AND user_id = $2
+RETURNING id;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences WHERE user_id = $1 ORDER BY type;
+
+-- name: SetNotificationPreferences :exec
+-- the types and enabled flags pair up by position, types left out keep their setting
+INSERT INTO notification_preferences (user_id, type, enabled)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(types)::varchar[]), unnest(sqlc.arg(enabled)::bool[])
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled;
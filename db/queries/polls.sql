@@ -0,0 +1,58 @@
+-- name: CreatePoll :one
+INSERT INTO polls (post_id, multiple, closes_at)
+VALUES (sqlc.arg(post_id), sqlc.arg(multiple), sqlc.narg(closes_at))
+RETURNING *;
+
+-- name: CreatePollOptions :exec
+-- the options are numbered in the order given
+INSERT INTO poll_options (id, post_id, position, text)
+SELECT ids.id, sqlc.arg(post_id)::uuid, ids.position, texts.text
+FROM unnest(sqlc.arg(ids)::uuid[]) WITH ORDINALITY AS ids (id, position)
+JOIN unnest(sqlc.arg(texts)::varchar[]) WITH ORDINALITY AS texts (text, position) ON texts.position = ids.position;
+
+-- name: ListPolls :many
+-- the polls of the posts, voted tells whether the viewer voted under any of their identities
+SELECT
+    polls.post_id, polls.multiple, polls.closes_at,
+    (polls.closes_at IS NOT NULL AND polls.closes_at <= now())::bool AS closed,
+    (SELECT count(*) FROM poll_ballots WHERE poll_ballots.post_id = polls.post_id) AS voters,
+    EXISTS (
+        SELECT 1 FROM poll_ballots
+        JOIN user_identities ON user_identities.id = poll_ballots.user_identity_id
+        WHERE poll_ballots.post_id = polls.post_id AND user_identities.user_id = sqlc.arg(viewer_id)::uuid
+    ) AS voted
+FROM polls
+WHERE polls.post_id = ANY(sqlc.arg(post_ids)::uuid[]);
+
+-- name: ListPollOptions :many
+-- the options of the polls with their vote counts, voted tells whether the viewer chose the option
+SELECT
+    poll_options.id, poll_options.post_id, poll_options.position, poll_options.text,
+    count(poll_votes.option_id) AS votes,
+    coalesce(bool_or(user_identities.user_id = sqlc.arg(viewer_id)::uuid), false)::bool AS voted
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+LEFT JOIN user_identities ON user_identities.id = poll_votes.user_identity_id
+WHERE poll_options.post_id = ANY(sqlc.arg(post_ids)::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.post_id, poll_options.position;
+
+-- name: CastPollBallot :execrows
+-- nothing is cast once the poll is closed or when another identity of the user voted in it already, a second
+-- ballot of the identity violates the primary key
+WITH ballot AS (
+    INSERT INTO poll_ballots (post_id, user_identity_id)
+    SELECT polls.post_id, sqlc.arg(user_identity_id)::uuid
+    FROM polls
+    WHERE polls.post_id = sqlc.arg(post_id)::uuid AND (polls.closes_at IS NULL OR polls.closes_at > now())
+        AND NOT EXISTS (
+            SELECT 1 FROM poll_ballots
+            JOIN user_identities ON user_identities.id = poll_ballots.user_identity_id
+            WHERE poll_ballots.post_id = polls.post_id AND user_identities.user_id = sqlc.arg(user_id)::uuid
+                AND poll_ballots.user_identity_id <> sqlc.arg(user_identity_id)::uuid
+        )
+    RETURNING poll_ballots.post_id, poll_ballots.user_identity_id
+)
+INSERT INTO poll_votes (post_id, user_identity_id, option_id)
+SELECT ballot.post_id, ballot.user_identity_id, unnest(sqlc.arg(option_ids)::uuid[])
+FROM ballot;
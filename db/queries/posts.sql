@@ -1,14 +1,207 @@
 -- name: ListAllPosts :many
-SELECT * FROM posts ORDER BY created_at DESC LIMIT 20 OFFSET $1;
+SELECT * FROM posts
+WHERE deleted_at IS NULL AND status = 'published' AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, user_identity_id, content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, content_warnings, nsfw)
+ORDER BY created_at DESC
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListPublicPostsByUserId :many
+SELECT posts.*
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid AND user_identities.is_public = true
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT author_deleted(posts.user_identity_id)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
 
 -- name: GetPostById :one
+SELECT * FROM posts WHERE id = $1 AND deleted_at IS NULL AND status = 'published' AND NOT author_deleted(user_identity_id) LIMIT 1;
+
+-- name: GetPostByIdIncludingDeleted :one
+-- the post whatever became of it, for the revisions its author and moderators can read
 SELECT * FROM posts WHERE id = $1 LIMIT 1;
 
 -- name: CreatePost :one
-INSERT INTO posts (id, content, user_identity_id) VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO posts (id, content, user_identity_id, status, publish_at, content_warnings, nsfw)
+VALUES (
+    sqlc.arg(id), sqlc.arg(content), sqlc.arg(user_identity_id), sqlc.arg(status), sqlc.narg(publish_at),
+    sqlc.arg(content_warnings)::varchar[], sqlc.arg(nsfw)
+)
+RETURNING *;
 
 -- name: UpdatePost :one
-UPDATE posts SET content = $1 WHERE id = $2 RETURNING id;
+-- the row lock of the update serializes concurrent edits, so revision numbers never collide
+WITH post AS (
+    UPDATE posts SET content = sqlc.arg(content), revision = revision + 1, edited = true, updated_at = now()
+    WHERE id = sqlc.arg(id) AND deleted_at IS NULL AND status = 'published'
+    RETURNING id, revision, content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, sqlc.arg(editor_identity_id)::uuid, post.content FROM post
+RETURNING post_id;
 
 -- name: DeletePost :one
-DELETE FROM posts WHERE id = $1 RETURNING id;
\ No newline at end of file
+-- moves the post to the trash, its comments are hidden along with it
+UPDATE posts SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id;
+
+-- name: RestorePost :one
+UPDATE posts SET deleted_at = NULL
+WHERE posts.id = sqlc.arg(id) AND posts.deleted_at IS NOT NULL
+    AND posts.user_identity_id IN (SELECT user_identities.id FROM user_identities WHERE user_identities.user_id = sqlc.arg(user_id)::uuid)
+RETURNING *;
+
+-- name: ListTrashedPosts :many
+SELECT posts.*
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid AND posts.deleted_at IS NOT NULL
+ORDER BY posts.deleted_at DESC, posts.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: PurgePosts :many
+-- removes the posts trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+WITH purged AS (
+    DELETE FROM posts WHERE posts.deleted_at < now() - sqlc.arg(retention_seconds)::float8 * interval '1 second' RETURNING posts.id
+)
+SELECT media.* FROM media WHERE media.post_id IN (SELECT purged.id FROM purged);
+
+-- name: ListFeedPosts :many
+-- posts of followed identities, and of followed users under their public identities only
+SELECT posts.*
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE (
+    posts.user_identity_id IN (
+        SELECT followee_identity_id FROM follows WHERE follower_id = sqlc.arg(user_id)::uuid
+    ) OR (
+        user_identities.is_public AND user_identities.user_id IN (
+            SELECT followee_user_id FROM follows WHERE follower_id = sqlc.arg(user_id)::uuid
+        )
+    )
+) AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(user_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(user_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: HasFeedPosts :one
+SELECT EXISTS (
+    SELECT 1
+    FROM posts
+    JOIN user_identities ON user_identities.id = posts.user_identity_id
+    WHERE (
+        posts.user_identity_id IN (
+            SELECT followee_identity_id FROM follows WHERE follower_id = sqlc.arg(user_id)::uuid
+        ) OR (
+            user_identities.is_public AND user_identities.user_id IN (
+                SELECT followee_user_id FROM follows WHERE follower_id = sqlc.arg(user_id)::uuid
+            )
+        )
+    ) AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(user_id)::uuid, posts.user_identity_id, posts.content)
+        AND NOT hidden_by_preference(sqlc.arg(user_id)::uuid, posts.content_warnings, posts.nsfw)
+);
+
+-- name: ListTrendingPosts :many
+-- ranked by the number of comments in the last week
+SELECT posts.*
+FROM posts
+LEFT JOIN comments ON comments.post_id = posts.id AND comments.created_at >= now() - interval '7 days'
+    AND comments.deleted_at IS NULL
+WHERE posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+GROUP BY posts.id
+ORDER BY count(comments.id) DESC, posts.created_at DESC
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListHotPosts :many
+-- posts the score refresher hasn't seen yet sort as if they had no reactions
+SELECT posts.*
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.hot, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListTopPosts :many
+-- the posts with the most net likes created in the last days, days 0 means all time
+SELECT posts.*
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE (sqlc.arg(days)::int = 0 OR posts.created_at >= now() - sqlc.arg(days)::int * interval '1 day')
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.likes - post_scores.dislikes, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListControversialPosts :many
+SELECT posts.*
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE (sqlc.arg(days)::int = 0 OR posts.created_at >= now() - sqlc.arg(days)::int * interval '1 day')
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.controversy, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: ListDraftPosts :many
+-- the drafts and scheduled posts of the user, next to be published first
+SELECT posts.*
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = sqlc.arg(user_id)::uuid AND posts.status <> 'published' AND posts.deleted_at IS NULL
+ORDER BY posts.publish_at NULLS LAST, posts.updated_at DESC, posts.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: GetDraftPost :one
+SELECT * FROM posts WHERE id = $1 AND status <> 'published' AND deleted_at IS NULL LIMIT 1;
+
+-- name: UpdateDraftPost :one
+UPDATE posts SET content = sqlc.arg(content), status = sqlc.arg(status), publish_at = sqlc.narg(publish_at), updated_at = now()
+WHERE id = sqlc.arg(id) AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDraftPost :one
+-- the post is dated from its publication, and its revision history starts there
+WITH post AS (
+    UPDATE posts SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+    WHERE posts.id = sqlc.arg(id) AND posts.status <> 'published' AND posts.deleted_at IS NULL
+    RETURNING posts.id, posts.revision, posts.user_identity_id, posts.content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, post.user_identity_id, post.content FROM post
+RETURNING post_id;
+
+-- name: PublishDuePosts :many
+-- the row locks are skipped rather than waited on, so concurrent schedulers each publish a post once
+WITH due AS (
+    SELECT posts.id FROM posts
+    WHERE posts.status = 'scheduled' AND posts.publish_at <= now() AND posts.deleted_at IS NULL
+    ORDER BY posts.publish_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+), post AS (
+    UPDATE posts SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+    WHERE posts.id IN (SELECT due.id FROM due)
+    RETURNING posts.id, posts.revision, posts.user_identity_id, posts.content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, post.user_identity_id, post.content FROM post
+RETURNING post_id;
+
+-- name: SetPostContentFlags :one
+-- flags set by a moderator can only be changed by a moderator
+UPDATE posts
+SET content_warnings = sqlc.arg(content_warnings)::varchar[], nsfw = sqlc.arg(nsfw),
+    flags_moderated_at = CASE WHEN sqlc.arg(moderated)::bool THEN now() ELSE flags_moderated_at END
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL AND (sqlc.arg(moderated)::bool OR flags_moderated_at IS NULL)
+RETURNING *;
@@ -0,0 +1,23 @@
+-- name: CreatePushSubscription :one
+-- a browser subscribing again, possibly logged in as someone else, takes its endpoint over
+INSERT INTO "push_subscriptions" (
+    id, user_id, endpoint, p256dh, auth
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = excluded.user_id, p256dh = excluded.p256dh, auth = excluded.auth, updated_at = now()
+RETURNING *;
+
+-- name: ListPushSubscriptions :many
+SELECT * FROM "push_subscriptions" WHERE user_id = $1;
+
+-- name: DeletePushSubscription :one
+DELETE FROM "push_subscriptions" WHERE endpoint = $1 AND user_id = $2 RETURNING id;
+
+-- name: DeleteGonePushSubscription :exec
+-- the push service told us the subscription no longer exists
+DELETE FROM "push_subscriptions" WHERE id = $1;
+
+-- name: DeleteUserPushSubscriptions :execrows
+DELETE FROM "push_subscriptions" WHERE user_id = $1;
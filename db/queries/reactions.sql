@@ -0,0 +1,78 @@
+-- name: SetPostReaction :one
+INSERT INTO post_reactions (post_id, user_id, reaction)
+VALUES ($1, $2, $3)
+ON CONFLICT (post_id, user_id) DO UPDATE SET reaction = EXCLUDED.reaction, updated_at = now()
+RETURNING *;
+
+-- name: DeletePostReaction :one
+-- marks the score of the post dirty, a removed reaction leaves nothing for the refresher to compare
+WITH reaction AS (
+    DELETE FROM post_reactions WHERE post_reactions.post_id = $1 AND post_reactions.user_id = $2 RETURNING post_reactions.post_id
+), score AS (
+    UPDATE post_scores SET dirty = true WHERE post_scores.post_id IN (SELECT reaction.post_id FROM reaction)
+)
+SELECT reaction.post_id FROM reaction;
+
+-- name: GetPostReaction :one
+SELECT * FROM post_reactions WHERE post_id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: CountPostReactions :one
+SELECT
+    count(*) FILTER (WHERE reaction = 'LIKE') AS likes,
+    count(*) FILTER (WHERE reaction = 'DISLIKE') AS dislikes
+FROM post_reactions
+WHERE post_id = $1;
+
+-- name: RefreshPostScores :execrows
+-- hot decays the net reactions plus comments with the age of the post, like hacker news does.
+-- controversy is high when there are many reactions split evenly between likes and dislikes.
+-- only the posts younger than the window, those never scored, those marked dirty and those reacted to or
+-- commented on since they were are refreshed, the scores of the others barely move. Deleted comments don't count.
+INSERT INTO post_scores (post_id, likes, dislikes, comments, hot, controversy, refreshed_at)
+SELECT
+    posts.id,
+    reactions.likes,
+    reactions.dislikes,
+    comment_counts.comments,
+    (reactions.likes - reactions.dislikes + comment_counts.comments)
+        / power(extract(epoch FROM now() - posts.created_at) / 3600 + 2, sqlc.arg(gravity)::float),
+    CASE WHEN reactions.likes = 0 OR reactions.dislikes = 0 THEN 0
+    ELSE power(
+        reactions.likes + reactions.dislikes,
+        least(reactions.likes, reactions.dislikes)::float / greatest(reactions.likes, reactions.dislikes)
+    ) END,
+    now()
+FROM posts
+LEFT JOIN post_scores AS scores ON scores.post_id = posts.id
+CROSS JOIN LATERAL (
+    SELECT
+        count(*) FILTER (WHERE reaction = 'LIKE') AS likes,
+        count(*) FILTER (WHERE reaction = 'DISLIKE') AS dislikes
+    FROM post_reactions
+    WHERE post_reactions.post_id = posts.id
+) AS reactions
+CROSS JOIN LATERAL (
+    SELECT count(*) AS comments
+    FROM comments
+    WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL
+) AS comment_counts
+WHERE posts.created_at > now() - sqlc.arg(window_seconds)::float8 * interval '1 second'
+    OR scores.post_id IS NULL
+    OR scores.dirty
+    OR EXISTS (
+        SELECT 1 FROM post_reactions
+        WHERE post_reactions.post_id = posts.id AND post_reactions.updated_at >= scores.refreshed_at
+    )
+    OR EXISTS (
+        SELECT 1 FROM comments
+        WHERE comments.post_id = posts.id
+            AND (comments.created_at >= scores.refreshed_at OR comments.deleted_at >= scores.refreshed_at)
+    )
+ON CONFLICT (post_id) DO UPDATE SET
+    likes = EXCLUDED.likes,
+    dislikes = EXCLUDED.dislikes,
+    comments = EXCLUDED.comments,
+    hot = EXCLUDED.hot,
+    controversy = EXCLUDED.controversy,
+    refreshed_at = EXCLUDED.refreshed_at,
+    dirty = false;
@@ -0,0 +1,21 @@
+-- name: CreatePostRevision :exec
+-- records the content of a new post as its first revision
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+VALUES ($1, 1, $2, $3);
+
+-- name: ListPostRevisions :many
+SELECT * FROM post_revisions WHERE post_id = $1 ORDER BY revision DESC;
+
+-- name: GetPostRevision :one
+SELECT * FROM post_revisions WHERE post_id = $1 AND revision = $2 LIMIT 1;
+
+-- name: CreateCommentRevision :exec
+-- records the content of a new comment as its first revision
+INSERT INTO comment_revisions (comment_id, revision, user_identity_id, content)
+VALUES ($1, 1, $2, $3);
+
+-- name: ListCommentRevisions :many
+SELECT * FROM comment_revisions WHERE comment_id = $1 ORDER BY revision DESC;
+
+-- name: GetCommentRevision :one
+SELECT * FROM comment_revisions WHERE comment_id = $1 AND revision = $2 LIMIT 1;
@@ -0,0 +1,52 @@
+-- name: SearchPosts :many
+-- keyset paginated on (rank, id), the headline is only built for the returned page
+SELECT
+    results.id, results.content, results.user_identity_id, results.created_at, results.updated_at,
+    results.revision, results.edited, results.deleted_at, results.status, results.publish_at,
+    results.content_warnings, results.nsfw, results.flags_moderated_at, results.rank,
+    ts_headline('english', results.content, to_tsquery('english', sqlc.arg(query)),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT
+        posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at,
+        posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at,
+        posts.content_warnings, posts.nsfw, posts.flags_moderated_at,
+        ts_rank_cd(posts.search_vector, to_tsquery('english', sqlc.arg(query))) AS rank
+    FROM posts
+    WHERE posts.search_vector @@ to_tsquery('english', sqlc.arg(query))
+        AND (sqlc.narg(identity_id)::uuid IS NULL OR posts.user_identity_id = sqlc.narg(identity_id)::uuid)
+        AND (sqlc.narg(created_from)::date IS NULL OR posts.created_at >= sqlc.narg(created_from)::date)
+        AND (sqlc.narg(created_to)::date IS NULL OR posts.created_at < sqlc.narg(created_to)::date + 1)
+        AND posts.deleted_at IS NULL AND posts.status = 'published'
+        AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+        AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+) AS results
+WHERE sqlc.narg(cursor_id)::uuid IS NULL
+    OR (results.rank, results.id) < (sqlc.narg(cursor_rank)::real, sqlc.narg(cursor_id)::uuid)
+ORDER BY results.rank DESC, results.id DESC
+LIMIT 20;
+
+-- name: SearchComments :many
+-- keyset paginated on (rank, id), the headline is only built for the returned page
+SELECT
+    results.id, results.content, results.user_identity_id, results.post_id, results.parent_id,
+    results.created_at, results.updated_at, results.rank,
+    ts_headline('english', results.content, to_tsquery('english', sqlc.arg(query)),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT
+        comments.id, comments.content, comments.user_identity_id, comments.post_id, comments.parent_id,
+        comments.created_at, comments.updated_at,
+        ts_rank_cd(comments.search_vector, to_tsquery('english', sqlc.arg(query))) AS rank
+    FROM comments
+    WHERE comments.search_vector @@ to_tsquery('english', sqlc.arg(query))
+        AND (sqlc.narg(identity_id)::uuid IS NULL OR comments.user_identity_id = sqlc.narg(identity_id)::uuid)
+        AND (sqlc.narg(created_from)::date IS NULL OR comments.created_at >= sqlc.narg(created_from)::date)
+        AND (sqlc.narg(created_to)::date IS NULL OR comments.created_at < sqlc.narg(created_to)::date + 1)
+        AND NOT comment_trashed(comments.id)
+        AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, comments.user_identity_id, comments.content)
+) AS results
+WHERE sqlc.narg(cursor_id)::uuid IS NULL
+    OR (results.rank, results.id) < (sqlc.narg(cursor_rank)::real, sqlc.narg(cursor_id)::uuid)
+ORDER BY results.rank DESC, results.id DESC
+LIMIT 20;
@@ -29,7 +29,10 @@ DELETE FROM "sessions"
 WHERE id = $1
 RETURNING id;
 
--- name: DeleteSessionByUserId :one
+-- name: DeleteSessionByUserId :execrows
 DELETE FROM "sessions"
-WHERE user_id = $1
-RETURNING id;
\ No newline at end of file
+WHERE user_id = $1;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM "sessions"
+WHERE expires_at < now();
\ No newline at end of file
@@ -0,0 +1,45 @@
+-- name: SetPostTags :exec
+-- replaces the tags of a post in one statement, so the tag index never sees a half updated post
+WITH new_tags AS (
+    INSERT INTO tags (name)
+    SELECT unnest(sqlc.arg(tags)::varchar[])
+    ON CONFLICT (name) DO NOTHING
+), removed AS (
+    DELETE FROM post_tags
+    WHERE post_tags.post_id = sqlc.arg(post_id)::uuid AND NOT (post_tags.tag = ANY(sqlc.arg(tags)::varchar[]))
+)
+INSERT INTO post_tags (post_id, tag)
+SELECT sqlc.arg(post_id)::uuid, unnest(sqlc.arg(tags)::varchar[])
+ON CONFLICT (post_id, tag) DO NOTHING;
+
+-- name: ListPostsByTag :many
+SELECT posts.*
+FROM posts
+JOIN post_tags ON post_tags.post_id = posts.id
+WHERE post_tags.tag = sqlc.arg(tag)
+    AND posts.deleted_at IS NULL AND posts.status = 'published'
+    AND NOT hidden_from(sqlc.arg(viewer_id)::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference(sqlc.arg(viewer_id)::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: SearchTags :many
+-- the prefix has to be escaped for LIKE
+SELECT tags.name, count(post_tags.post_id) AS post_count
+FROM tags
+JOIN post_tags ON post_tags.tag = tags.name
+JOIN posts ON posts.id = post_tags.post_id
+WHERE tags.name LIKE sqlc.arg(prefix)::varchar || '%' AND posts.deleted_at IS NULL AND posts.status = 'published'
+GROUP BY tags.name
+ORDER BY post_count DESC, tags.name
+LIMIT 10;
+
+-- name: ListTrendingTags :many
+SELECT post_tags.tag AS name, count(*) AS post_count
+FROM post_tags
+JOIN posts ON posts.id = post_tags.post_id
+WHERE post_tags.created_at >= now() - sqlc.arg(days)::int * interval '1 day' AND posts.deleted_at IS NULL AND posts.status = 'published'
+GROUP BY post_tags.tag
+ORDER BY post_count DESC, post_tags.tag
+LIMIT 20;
@@ -2,13 +2,82 @@
 INSERT INTO "user_identities"(
 	id,
 	user_id,
-	identity_hash
+	identity_hash,
+	name,
+	is_default,
+	rotation_interval_days,
+	rotation_detach
 ) VALUES (
-	$1, $2, $3
+	sqlc.arg(id),
+	sqlc.arg(user_id)::uuid,
+	sqlc.arg(identity_hash),
+	sqlc.arg(name),
+	sqlc.arg(is_default),
+	sqlc.arg(rotation_interval_days),
+	sqlc.arg(rotation_detach)
 ) RETURNING *;
 
 -- name: GetUserIdentityById :one
 SELECT * FROM "user_identities" WHERE id = $1 LIMIT 1;
 
--- name: GetUserIdentityByUserId :one
-SELECT * FROM "user_identities" WHERE user_id = $1 LIMIT 1;
\ No newline at end of file
+-- name: GetDefaultUserIdentity :one
+SELECT * FROM "user_identities" WHERE user_id = sqlc.arg(user_id)::uuid AND is_default = true LIMIT 1;
+
+-- name: ListUserIdentities :many
+SELECT * FROM "user_identities" WHERE user_id = sqlc.arg(user_id)::uuid ORDER BY created_at ASC;
+
+-- name: CountActiveUserIdentities :one
+SELECT COUNT(*) FROM "user_identities" WHERE user_id = sqlc.arg(user_id)::uuid AND retired = false;
+
+-- name: UpdateUserIdentity :one
+UPDATE "user_identities"
+SET name = COALESCE(sqlc.narg(name), name),
+	is_public = COALESCE(sqlc.narg(is_public), is_public)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)::uuid
+RETURNING *;
+
+-- name: ClearDefaultUserIdentity :exec
+-- unsets the default of the user other than the identity, before SetDefaultUserIdentity. The unique index on the
+-- default of a user is checked row by row, so both cannot be done in one statement.
+UPDATE "user_identities"
+SET is_default = false
+WHERE user_id = sqlc.arg(user_id)::uuid AND is_default = true AND id <> sqlc.arg(id);
+
+-- name: SetDefaultUserIdentity :exec
+UPDATE "user_identities"
+SET is_default = true
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)::uuid;
+
+-- name: RetireUserIdentity :one
+UPDATE "user_identities"
+SET retired = true
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)::uuid AND is_default = false
+RETURNING id;
+
+-- name: ReplaceUserIdentity :one
+-- retires the identity ahead of its replacement, an identity already replaced is not found
+UPDATE "user_identities"
+SET retired = true,
+	is_default = false,
+	rotation_interval_days = NULL,
+	user_id = CASE WHEN sqlc.arg(detach)::boolean THEN NULL ELSE user_id END
+WHERE id = sqlc.arg(id) AND retired = false
+RETURNING id;
+
+-- name: UpdateUserIdentityRotation :one
+UPDATE "user_identities"
+SET rotation_interval_days = sqlc.arg(rotation_interval_days), rotation_detach = sqlc.arg(rotation_detach)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)::uuid AND retired = false
+RETURNING *;
+
+-- name: ListUserIdentitiesDueForRotation :many
+-- locks the due identities until the transaction it runs in ends. SKIP LOCKED lets several servers share the
+-- rotations without rotating an identity twice.
+SELECT * FROM "user_identities"
+WHERE retired = false
+	AND user_id IS NOT NULL
+	AND rotation_interval_days IS NOT NULL
+	AND rotated_at + rotation_interval_days * interval '1 day' <= now()
+ORDER BY rotated_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
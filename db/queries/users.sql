@@ -1,18 +1,32 @@
 -- name: GetUserById :one
 SELECT *
 FROM "users"
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: GetUserByUsername :one
 SELECT *
 FROM "users"
-WHERE username = $1
+WHERE username = $1 AND deleted_at IS NULL
 LIMIT 1;
 
+-- name: GetDeletedUserByUsername :one
+-- the account waiting for its deletion, until its grace period is over
+SELECT *
+FROM "users"
+WHERE username = $1 AND deleted_at IS NOT NULL AND delete_after > now()
+LIMIT 1;
+
+-- name: SetUserModerator :one
+UPDATE "users"
+SET is_moderator = sqlc.arg(is_moderator), updated_at = now()
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+RETURNING id;
+
 -- name: ListUsers :many
 SELECT *
 FROM "users"
+WHERE deleted_at IS NULL
 LIMIT 20
 OFFSET $1;
 
@@ -35,7 +49,47 @@ SET password = $1, updated_at = $2
 WHERE id = $3
 RETURNING id;
 
+-- name: UpdateUserProfile :one
+UPDATE "users"
+SET display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    avatar_url = COALESCE(sqlc.narg(avatar_url), avatar_url),
+    confession_prompt = COALESCE(sqlc.narg(confession_prompt), confession_prompt),
+    links = COALESCE(sqlc.narg(links), links),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: DeleteOneUser :one
-DELETE FROM "users"
-WHERE id = $1
-RETURNING id;
\ No newline at end of file
+-- schedules the account for deletion after the grace period, its content is hidden until the user logs in again
+-- or the account is purged
+UPDATE "users"
+SET deleted_at = now(), delete_after = now() + sqlc.arg(grace_seconds)::float8 * interval '1 second'
+WHERE users.id = sqlc.arg(id) AND users.deleted_at IS NULL
+RETURNING users.delete_after;
+
+-- name: RestoreUser :one
+-- cancels the scheduled deletion of the account, unless its grace period is over
+UPDATE "users"
+SET deleted_at = NULL, delete_after = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL AND delete_after > now()
+RETURNING id;
+
+-- name: PurgeUsers :many
+-- removes the accounts whose grace period is over for good, everything they own goes with them.
+-- Returns their media so the stored files can be removed too.
+WITH purged AS (
+    DELETE FROM "users" WHERE users.delete_after < now() RETURNING users.id
+)
+SELECT media.*
+FROM media
+WHERE media.user_id IN (SELECT purged.id FROM purged)
+    OR media.message_id IN (
+        SELECT messages.id FROM messages WHERE messages.receiver_id IN (SELECT purged.id FROM purged)
+    )
+    OR media.post_id IN (
+        SELECT posts.id
+        FROM posts
+        JOIN user_identities ON user_identities.id = posts.user_identity_id
+        WHERE user_identities.user_id IN (SELECT purged.id FROM purged)
+    );
\ No newline at end of file
@@ -0,0 +1,90 @@
+-- name: CreateWebhook :one
+INSERT INTO "webhooks" (
+    id, user_id, url, secret, events
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM "webhooks" WHERE user_id = $1 ORDER BY created_at, id;
+
+-- name: GetWebhook :one
+SELECT * FROM "webhooks" WHERE id = $1 AND user_id = $2 LIMIT 1;
+
+-- name: UpdateWebhook :one
+-- fields left null keep their value
+UPDATE "webhooks"
+SET url = coalesce(sqlc.narg(url), url),
+    events = coalesce(sqlc.narg(events)::varchar[], events),
+    active = coalesce(sqlc.narg(active), active),
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: DeleteWebhook :one
+DELETE FROM "webhooks" WHERE id = $1 AND user_id = $2 RETURNING id;
+
+-- name: ListWebhooksForEvent :many
+-- the active webhooks of the user subscribed to the event
+SELECT * FROM "webhooks"
+WHERE user_id = sqlc.arg(user_id) AND active AND sqlc.arg(event)::varchar = ANY(events);
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO "webhook_deliveries" (
+    id, webhook_id, event, payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListWebhookDeliveries :many
+SELECT webhook_deliveries.*
+FROM "webhook_deliveries"
+JOIN "webhooks" ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhooks.id = sqlc.arg(webhook_id) AND webhooks.user_id = sqlc.arg(user_id)
+ORDER BY webhook_deliveries.created_at DESC, webhook_deliveries.id
+LIMIT 20
+OFFSET sqlc.arg(page_offset);
+
+-- name: RedeliverWebhookDelivery :one
+-- queues a copy of the delivery, the original stays in the log as it was
+INSERT INTO "webhook_deliveries" (id, webhook_id, event, payload)
+SELECT sqlc.arg(new_id), webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload
+FROM "webhook_deliveries"
+JOIN "webhooks" ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.id = sqlc.arg(id)
+    AND webhooks.id = sqlc.arg(webhook_id)
+    AND webhooks.user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: ClaimWebhookDeliveries :many
+-- takes the due deliveries of active webhooks and moves their next attempt past the lease, so a server that
+-- dies while delivering leaves them to be retried. SKIP LOCKED lets several servers share the queue.
+UPDATE "webhook_deliveries"
+SET next_attempt_at = now() + sqlc.arg(lease_seconds)::float8 * interval '1 second'
+FROM "webhooks"
+WHERE webhooks.id = webhook_deliveries.webhook_id AND webhook_deliveries.id IN (
+    SELECT pending.id
+    FROM "webhook_deliveries" AS pending
+    JOIN "webhooks" AS pending_webhooks ON pending_webhooks.id = pending.webhook_id
+    WHERE pending.status = 'pending' AND pending.next_attempt_at <= now() AND pending_webhooks.active
+    ORDER BY pending.next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE OF pending SKIP LOCKED
+)
+RETURNING webhook_deliveries.*, webhooks.url, webhooks.secret;
+
+-- name: CompleteWebhookDelivery :exec
+UPDATE "webhook_deliveries"
+SET status = 'succeeded', attempts = attempts + 1, response_status = sqlc.arg(response_status), error = '',
+    delivered_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: FailWebhookDelivery :exec
+-- records a failed attempt, the delivery is given up once it has had max_attempts
+UPDATE "webhook_deliveries"
+SET attempts = attempts + 1,
+    response_status = sqlc.narg(response_status),
+    error = sqlc.arg(error),
+    status = CASE WHEN attempts + 1 >= sqlc.arg(max_attempts)::int THEN 'failed' ELSE 'pending' END,
+    next_attempt_at = now() + sqlc.arg(retry_seconds)::float8 * interval '1 second'
+WHERE id = sqlc.arg(id);
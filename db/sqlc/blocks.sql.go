@@ -0,0 +1,277 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: blocks.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const blockIdentity = `-- name: BlockIdentity :one
+INSERT INTO "blocks" (
+    id, user_id, blocked_identity_id, kind
+) VALUES (
+    $1, $2, $3::uuid, $4
+) ON CONFLICT ("user_id", "blocked_identity_id") WHERE "blocked_identity_id" IS NOT NULL
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING id, user_id, blocked_user_id, blocked_identity_id, kind, created_at
+`
+
+type BlockIdentityParams struct {
+	ID                uuid.UUID `json:"id"`
+	UserID            uuid.UUID `json:"user_id"`
+	BlockedIdentityID uuid.UUID `json:"blocked_identity_id"`
+	Kind              string    `json:"kind"`
+}
+
+func (q *Queries) BlockIdentity(ctx context.Context, arg BlockIdentityParams) (Block, error) {
+	row := q.queryRow(ctx, q.blockIdentityStmt, blockIdentity,
+		arg.ID,
+		arg.UserID,
+		arg.BlockedIdentityID,
+		arg.Kind,
+	)
+	var i Block
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BlockedUserID,
+		&i.BlockedIdentityID,
+		&i.Kind,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const blockUser = `-- name: BlockUser :one
+INSERT INTO "blocks" (
+    id, user_id, blocked_user_id, kind
+) VALUES (
+    $1, $2, $3::uuid, $4
+) ON CONFLICT ("user_id", "blocked_user_id") WHERE "blocked_user_id" IS NOT NULL
+DO UPDATE SET kind = EXCLUDED.kind
+RETURNING id, user_id, blocked_user_id, blocked_identity_id, kind, created_at
+`
+
+type BlockUserParams struct {
+	ID            uuid.UUID `json:"id"`
+	UserID        uuid.UUID `json:"user_id"`
+	BlockedUserID uuid.UUID `json:"blocked_user_id"`
+	Kind          string    `json:"kind"`
+}
+
+func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) (Block, error) {
+	row := q.queryRow(ctx, q.blockUserStmt, blockUser,
+		arg.ID,
+		arg.UserID,
+		arg.BlockedUserID,
+		arg.Kind,
+	)
+	var i Block
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BlockedUserID,
+		&i.BlockedIdentityID,
+		&i.Kind,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const countMutedKeywords = `-- name: CountMutedKeywords :one
+SELECT count(*)
+FROM "muted_keywords"
+WHERE user_id = $1
+`
+
+func (q *Queries) CountMutedKeywords(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.countMutedKeywordsStmt, countMutedKeywords, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createMutedKeyword = `-- name: CreateMutedKeyword :one
+INSERT INTO "muted_keywords" (
+    id, user_id, keyword
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, keyword, created_at
+`
+
+type CreateMutedKeywordParams struct {
+	ID      uuid.UUID `json:"id"`
+	UserID  uuid.UUID `json:"user_id"`
+	Keyword string    `json:"keyword"`
+}
+
+func (q *Queries) CreateMutedKeyword(ctx context.Context, arg CreateMutedKeywordParams) (MutedKeyword, error) {
+	row := q.queryRow(ctx, q.createMutedKeywordStmt, createMutedKeyword, arg.ID, arg.UserID, arg.Keyword)
+	var i MutedKeyword
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Keyword,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBlock = `-- name: DeleteBlock :one
+DELETE FROM "blocks"
+WHERE id = $1 AND user_id = $2
+RETURNING id
+`
+
+type DeleteBlockParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg DeleteBlockParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.deleteBlockStmt, deleteBlock, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteMutedKeyword = `-- name: DeleteMutedKeyword :one
+DELETE FROM "muted_keywords"
+WHERE id = $1 AND user_id = $2
+RETURNING id
+`
+
+type DeleteMutedKeywordParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteMutedKeyword(ctx context.Context, arg DeleteMutedKeywordParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.deleteMutedKeywordStmt, deleteMutedKeyword, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const hasBlocks = `-- name: HasBlocks :one
+SELECT EXISTS (
+    SELECT 1 FROM "blocks" WHERE user_id = $1 AND kind = 'block'
+)
+`
+
+// whether the user blocks anybody, they can't be told apart from anonymous senders
+func (q *Queries) HasBlocks(ctx context.Context, userID uuid.UUID) (bool, error) {
+	row := q.queryRow(ctx, q.hasBlocksStmt, hasBlocks, userID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isBlocked = `-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM "blocks"
+    LEFT JOIN "user_identities" ON user_identities.id = blocks.blocked_identity_id
+    WHERE blocks.user_id = $1::uuid
+      AND blocks.kind = 'block'
+      AND (blocks.blocked_user_id = $2::uuid OR user_identities.user_id = $2::uuid)
+)
+`
+
+type IsBlockedParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	ActorID uuid.UUID `json:"actor_id"`
+}
+
+// a block of any identity counts for all identities of the same user
+func (q *Queries) IsBlocked(ctx context.Context, arg IsBlockedParams) (bool, error) {
+	row := q.queryRow(ctx, q.isBlockedStmt, isBlocked, arg.UserID, arg.ActorID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listBlocks = `-- name: ListBlocks :many
+SELECT id, user_id, blocked_user_id, blocked_identity_id, kind, created_at
+FROM "blocks"
+WHERE user_id = $1 AND kind = $2
+ORDER BY created_at DESC, id
+LIMIT 20
+OFFSET $3
+`
+
+type ListBlocksParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Kind   string    `json:"kind"`
+	Offset int32     `json:"offset"`
+}
+
+func (q *Queries) ListBlocks(ctx context.Context, arg ListBlocksParams) ([]Block, error) {
+	rows, err := q.query(ctx, q.listBlocksStmt, listBlocks, arg.UserID, arg.Kind, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Block
+	for rows.Next() {
+		var i Block
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BlockedUserID,
+			&i.BlockedIdentityID,
+			&i.Kind,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMutedKeywords = `-- name: ListMutedKeywords :many
+SELECT id, user_id, keyword, created_at
+FROM "muted_keywords"
+WHERE user_id = $1
+ORDER BY keyword
+`
+
+func (q *Queries) ListMutedKeywords(ctx context.Context, userID uuid.UUID) ([]MutedKeyword, error) {
+	rows, err := q.query(ctx, q.listMutedKeywordsStmt, listMutedKeywords, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MutedKeyword
+	for rows.Next() {
+		var i MutedKeyword
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Keyword,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
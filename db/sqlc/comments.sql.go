@@ -23,7 +23,7 @@ INSERT INTO "comments"(
 	updated_at
 ) VALUES (
 	$1, $2, $3, $4, $5, $6, $7
-) RETURNING id, content, user_identity_id, post_id, parent_id, created_at, updated_at
+) RETURNING id, content, user_identity_id, post_id, parent_id, created_at, updated_at, search_vector, revision, edited, deleted_at
 `
 
 type CreateCommentParams struct {
@@ -55,14 +55,19 @@ func (q *Queries) CreateComment(ctx context.Context, arg CreateCommentParams) (C
 		&i.ParentID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteComment = `-- name: DeleteComment :one
-DELETE FROM "comments" WHERE id = $1 RETURNING id
+UPDATE "comments" SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id
 `
 
+// moves the comment to the trash, its replies are hidden along with it
 func (q *Queries) DeleteComment(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
 	row := q.queryRow(ctx, q.deleteCommentStmt, deleteComment, id)
 	err := row.Scan(&id)
@@ -70,7 +75,7 @@ func (q *Queries) DeleteComment(ctx context.Context, id uuid.UUID) (uuid.UUID, e
 }
 
 const getComment = `-- name: GetComment :one
-SELECT id, content, user_identity_id, post_id, parent_id, created_at, updated_at FROM "comments" WHERE id = $1
+SELECT id, content, user_identity_id, post_id, parent_id, created_at, updated_at, search_vector, revision, edited, deleted_at FROM "comments" WHERE id = $1 AND NOT comment_trashed(id) AND NOT author_deleted(user_identity_id)
 `
 
 func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (Comment, error) {
@@ -84,16 +89,101 @@ func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (Comment, error)
 		&i.ParentID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getCommentIncludingDeleted = `-- name: GetCommentIncludingDeleted :one
+SELECT id, content, user_identity_id, post_id, parent_id, created_at, updated_at, search_vector, revision, edited, deleted_at FROM "comments" WHERE id = $1
+`
+
+// the comment whatever became of it, for the revisions its author and moderators can read
+func (q *Queries) GetCommentIncludingDeleted(ctx context.Context, id uuid.UUID) (Comment, error) {
+	row := q.queryRow(ctx, q.getCommentIncludingDeletedStmt, getCommentIncludingDeleted, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.PostID,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const listAllComments = `-- name: ListAllComments :many
-SELECT id, content, user_identity_id, post_id, parent_id, created_at, updated_at FROM "comments" WHERE post_id = $1
+SELECT id, content, user_identity_id, post_id, parent_id, created_at, updated_at, search_vector, revision, edited, deleted_at FROM "comments"
+WHERE post_id = $1 AND NOT comment_trashed(id)
+    AND NOT hidden_from($2::uuid, user_identity_id, content)
+`
+
+type ListAllCommentsParams struct {
+	PostID   uuid.UUID `json:"post_id"`
+	ViewerID uuid.UUID `json:"viewer_id"`
+}
+
+func (q *Queries) ListAllComments(ctx context.Context, arg ListAllCommentsParams) ([]Comment, error) {
+	rows, err := q.query(ctx, q.listAllCommentsStmt, listAllComments, arg.PostID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Comment
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.PostID,
+			&i.ParentID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedComments = `-- name: ListTrashedComments :many
+SELECT comments.id, comments.content, comments.user_identity_id, comments.post_id, comments.parent_id, comments.created_at, comments.updated_at, comments.search_vector, comments.revision, comments.edited, comments.deleted_at
+FROM "comments"
+JOIN user_identities ON user_identities.id = comments.user_identity_id
+WHERE user_identities.user_id = $1::uuid AND comments.deleted_at IS NOT NULL
+ORDER BY comments.deleted_at DESC, comments.id
+LIMIT 20
+OFFSET $2
 `
 
-func (q *Queries) ListAllComments(ctx context.Context, postID uuid.UUID) ([]Comment, error) {
-	rows, err := q.query(ctx, q.listAllCommentsStmt, listAllComments, postID)
+type ListTrashedCommentsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListTrashedComments(ctx context.Context, arg ListTrashedCommentsParams) ([]Comment, error) {
+	rows, err := q.query(ctx, q.listTrashedCommentsStmt, listTrashedComments, arg.UserID, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +199,10 @@ func (q *Queries) ListAllComments(ctx context.Context, postID uuid.UUID) ([]Comm
 			&i.ParentID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -123,19 +217,98 @@ func (q *Queries) ListAllComments(ctx context.Context, postID uuid.UUID) ([]Comm
 	return items, nil
 }
 
+const purgeComments = `-- name: PurgeComments :execrows
+DELETE FROM "comments" WHERE deleted_at < now() - $1::float8 * interval '1 second'
+`
+
+// removes the comments trashed longer ago than the retention for good, their replies go with them
+func (q *Queries) PurgeComments(ctx context.Context, retentionSeconds float64) (int64, error) {
+	result, err := q.exec(ctx, q.purgeCommentsStmt, purgeComments, retentionSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreComment = `-- name: RestoreComment :one
+WITH comment AS (
+    UPDATE "comments" SET deleted_at = NULL
+    WHERE comments.id = $1 AND comments.deleted_at IS NOT NULL
+        AND comments.user_identity_id IN (SELECT user_identities.id FROM user_identities WHERE user_identities.user_id = $2::uuid)
+    RETURNING id, content, user_identity_id, post_id, parent_id, created_at, updated_at, search_vector, revision, edited, deleted_at
+), score AS (
+    UPDATE post_scores SET dirty = true WHERE post_scores.post_id IN (SELECT comment.post_id FROM comment)
+)
+SELECT comment.id, comment.content, comment.user_identity_id, comment.post_id, comment.parent_id,
+    comment.created_at, comment.updated_at, comment.revision, comment.edited, comment.deleted_at
+FROM comment
+`
+
+type RestoreCommentParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type RestoreCommentRow struct {
+	ID             uuid.UUID  `json:"id"`
+	Content        string     `json:"content"`
+	UserIdentityID uuid.UUID  `json:"user_identity_id"`
+	PostID         uuid.UUID  `json:"post_id"`
+	ParentID       uuid.UUID  `json:"parent_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Revision       int32      `json:"revision"`
+	Edited         bool       `json:"edited"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+}
+
+// marks the score of the post dirty, the comment counts again but its deleted_at is gone
+func (q *Queries) RestoreComment(ctx context.Context, arg RestoreCommentParams) (RestoreCommentRow, error) {
+	row := q.queryRow(ctx, q.restoreCommentStmt, restoreComment, arg.ID, arg.UserID)
+	var i RestoreCommentRow
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.PostID,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateComment = `-- name: UpdateComment :one
-UPDATE "comments" SET content = $1, updated_at = $2 WHERE id = $3 RETURNING id
+WITH comment AS (
+    UPDATE "comments"
+    SET content = $2, updated_at = $3, revision = revision + 1, edited = true
+    WHERE id = $4 AND deleted_at IS NULL
+    RETURNING id, revision, content
+)
+INSERT INTO comment_revisions (comment_id, revision, user_identity_id, content)
+SELECT comment.id, comment.revision, $1::uuid, comment.content FROM comment
+RETURNING comment_id
 `
 
 type UpdateCommentParams struct {
-	Content   string    `json:"content"`
-	UpdatedAt time.Time `json:"updated_at"`
-	ID        uuid.UUID `json:"id"`
+	EditorIdentityID uuid.UUID `json:"editor_identity_id"`
+	Content          string    `json:"content"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	ID               uuid.UUID `json:"id"`
 }
 
+// the row lock of the update serializes concurrent edits, so revision numbers never collide
 func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (uuid.UUID, error) {
-	row := q.queryRow(ctx, q.updateCommentStmt, updateComment, arg.Content, arg.UpdatedAt, arg.ID)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+	row := q.queryRow(ctx, q.updateCommentStmt, updateComment,
+		arg.EditorIdentityID,
+		arg.Content,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var comment_id uuid.UUID
+	err := row.Scan(&comment_id)
+	return comment_id, err
 }
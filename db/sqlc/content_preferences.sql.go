@@ -0,0 +1,63 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: content_preferences.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const listContentPreferences = `-- name: ListContentPreferences :many
+SELECT user_id, category, action FROM content_preferences WHERE user_id = $1 ORDER BY category
+`
+
+func (q *Queries) ListContentPreferences(ctx context.Context, userID uuid.UUID) ([]ContentPreference, error) {
+	rows, err := q.query(ctx, q.listContentPreferencesStmt, listContentPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ContentPreference
+	for rows.Next() {
+		var i ContentPreference
+		if err := rows.Scan(&i.UserID, &i.Category, &i.Action); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setContentPreferences = `-- name: SetContentPreferences :exec
+WITH removed AS (
+    DELETE FROM content_preferences
+    WHERE content_preferences.user_id = $1::uuid
+        AND NOT (content_preferences.category = ANY($2::varchar[]))
+)
+INSERT INTO content_preferences (user_id, category, action)
+SELECT $1::uuid, unnest($2::varchar[]), unnest($3::varchar[])
+ON CONFLICT (user_id, category) DO UPDATE SET action = EXCLUDED.action
+`
+
+type SetContentPreferencesParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Categories []string  `json:"categories"`
+	Actions    []string  `json:"actions"`
+}
+
+// replaces the preferences of the user, the categories and actions pair up by position
+func (q *Queries) SetContentPreferences(ctx context.Context, arg SetContentPreferencesParams) error {
+	_, err := q.exec(ctx, q.setContentPreferencesStmt, setContentPreferences, arg.UserID, pq.Array(arg.Categories), pq.Array(arg.Actions))
+	return err
+}
@@ -24,18 +24,93 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.blockIdentityStmt, err = db.PrepareContext(ctx, blockIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockIdentity: %w", err)
+	}
 	if q.blockSessionStmt, err = db.PrepareContext(ctx, blockSession); err != nil {
 		return nil, fmt.Errorf("error preparing query BlockSession: %w", err)
 	}
+	if q.blockUserStmt, err = db.PrepareContext(ctx, blockUser); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockUser: %w", err)
+	}
+	if q.castPollBallotStmt, err = db.PrepareContext(ctx, castPollBallot); err != nil {
+		return nil, fmt.Errorf("error preparing query CastPollBallot: %w", err)
+	}
+	if q.claimDataExportStmt, err = db.PrepareContext(ctx, claimDataExport); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDataExport: %w", err)
+	}
+	if q.claimDueEmailDigestsStmt, err = db.PrepareContext(ctx, claimDueEmailDigests); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDueEmailDigests: %w", err)
+	}
+	if q.claimWebhookDeliveriesStmt, err = db.PrepareContext(ctx, claimWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimWebhookDeliveries: %w", err)
+	}
+	if q.clearDefaultUserIdentityStmt, err = db.PrepareContext(ctx, clearDefaultUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearDefaultUserIdentity: %w", err)
+	}
+	if q.completeDataExportStmt, err = db.PrepareContext(ctx, completeDataExport); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteDataExport: %w", err)
+	}
+	if q.completeWebhookDeliveryStmt, err = db.PrepareContext(ctx, completeWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteWebhookDelivery: %w", err)
+	}
+	if q.countActiveUserIdentitiesStmt, err = db.PrepareContext(ctx, countActiveUserIdentities); err != nil {
+		return nil, fmt.Errorf("error preparing query CountActiveUserIdentities: %w", err)
+	}
+	if q.countDigestFollowersStmt, err = db.PrepareContext(ctx, countDigestFollowers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountDigestFollowers: %w", err)
+	}
+	if q.countDigestMessagesStmt, err = db.PrepareContext(ctx, countDigestMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query CountDigestMessages: %w", err)
+	}
+	if q.countMediaByPostIdStmt, err = db.PrepareContext(ctx, countMediaByPostId); err != nil {
+		return nil, fmt.Errorf("error preparing query CountMediaByPostId: %w", err)
+	}
+	if q.countMutedKeywordsStmt, err = db.PrepareContext(ctx, countMutedKeywords); err != nil {
+		return nil, fmt.Errorf("error preparing query CountMutedKeywords: %w", err)
+	}
+	if q.countPostReactionsStmt, err = db.PrepareContext(ctx, countPostReactions); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPostReactions: %w", err)
+	}
+	if q.countUnreadNotificationsStmt, err = db.PrepareContext(ctx, countUnreadNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUnreadNotifications: %w", err)
+	}
 	if q.createCommentStmt, err = db.PrepareContext(ctx, createComment); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateComment: %w", err)
 	}
+	if q.createCommentRevisionStmt, err = db.PrepareContext(ctx, createCommentRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCommentRevision: %w", err)
+	}
+	if q.createDataExportStmt, err = db.PrepareContext(ctx, createDataExport); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDataExport: %w", err)
+	}
+	if q.createMediaStmt, err = db.PrepareContext(ctx, createMedia); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMedia: %w", err)
+	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createMutedKeywordStmt, err = db.PrepareContext(ctx, createMutedKeyword); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMutedKeyword: %w", err)
+	}
+	if q.createNotificationStmt, err = db.PrepareContext(ctx, createNotification); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateNotification: %w", err)
+	}
+	if q.createPollStmt, err = db.PrepareContext(ctx, createPoll); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePoll: %w", err)
+	}
+	if q.createPollOptionsStmt, err = db.PrepareContext(ctx, createPollOptions); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePollOptions: %w", err)
+	}
 	if q.createPostStmt, err = db.PrepareContext(ctx, createPost); err != nil {
 		return nil, fmt.Errorf("error preparing query CreatePost: %w", err)
 	}
+	if q.createPostRevisionStmt, err = db.PrepareContext(ctx, createPostRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePostRevision: %w", err)
+	}
+	if q.createPushSubscriptionStmt, err = db.PrepareContext(ctx, createPushSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePushSubscription: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
@@ -45,9 +120,39 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createUserIdentityStmt, err = db.PrepareContext(ctx, createUserIdentity); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUserIdentity: %w", err)
 	}
+	if q.createWebhookStmt, err = db.PrepareContext(ctx, createWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhook: %w", err)
+	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.deleteBlockStmt, err = db.PrepareContext(ctx, deleteBlock); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBlock: %w", err)
+	}
 	if q.deleteCommentStmt, err = db.PrepareContext(ctx, deleteComment); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteComment: %w", err)
 	}
+	if q.deleteEmailDigestStmt, err = db.PrepareContext(ctx, deleteEmailDigest); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteEmailDigest: %w", err)
+	}
+	if q.deleteExpiredDataExportsStmt, err = db.PrepareContext(ctx, deleteExpiredDataExports); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredDataExports: %w", err)
+	}
+	if q.deleteExpiredSessionsStmt, err = db.PrepareContext(ctx, deleteExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredSessions: %w", err)
+	}
+	if q.deleteGonePushSubscriptionStmt, err = db.PrepareContext(ctx, deleteGonePushSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGonePushSubscription: %w", err)
+	}
+	if q.deleteMediaStmt, err = db.PrepareContext(ctx, deleteMedia); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMedia: %w", err)
+	}
+	if q.deleteMutedKeywordStmt, err = db.PrepareContext(ctx, deleteMutedKeyword); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMutedKeyword: %w", err)
+	}
+	if q.deleteOldAvatarsStmt, err = db.PrepareContext(ctx, deleteOldAvatars); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteOldAvatars: %w", err)
+	}
 	if q.deleteOneMessageStmt, err = db.PrepareContext(ctx, deleteOneMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteOneMessage: %w", err)
 	}
@@ -57,21 +162,87 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deletePostStmt, err = db.PrepareContext(ctx, deletePost); err != nil {
 		return nil, fmt.Errorf("error preparing query DeletePost: %w", err)
 	}
+	if q.deletePostReactionStmt, err = db.PrepareContext(ctx, deletePostReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePostReaction: %w", err)
+	}
+	if q.deletePushSubscriptionStmt, err = db.PrepareContext(ctx, deletePushSubscription); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePushSubscription: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
 	if q.deleteSessionByUserIdStmt, err = db.PrepareContext(ctx, deleteSessionByUserId); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionByUserId: %w", err)
 	}
+	if q.deleteUserPushSubscriptionsStmt, err = db.PrepareContext(ctx, deleteUserPushSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserPushSubscriptions: %w", err)
+	}
+	if q.deleteWebhookStmt, err = db.PrepareContext(ctx, deleteWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhook: %w", err)
+	}
+	if q.exportUserCommentsStmt, err = db.PrepareContext(ctx, exportUserComments); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserComments: %w", err)
+	}
+	if q.exportUserMessagesStmt, err = db.PrepareContext(ctx, exportUserMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserMessages: %w", err)
+	}
+	if q.exportUserPostsStmt, err = db.PrepareContext(ctx, exportUserPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserPosts: %w", err)
+	}
+	if q.failDataExportStmt, err = db.PrepareContext(ctx, failDataExport); err != nil {
+		return nil, fmt.Errorf("error preparing query FailDataExport: %w", err)
+	}
+	if q.failWebhookDeliveryStmt, err = db.PrepareContext(ctx, failWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query FailWebhookDelivery: %w", err)
+	}
+	if q.followIdentityStmt, err = db.PrepareContext(ctx, followIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query FollowIdentity: %w", err)
+	}
+	if q.followUserStmt, err = db.PrepareContext(ctx, followUser); err != nil {
+		return nil, fmt.Errorf("error preparing query FollowUser: %w", err)
+	}
 	if q.getCommentStmt, err = db.PrepareContext(ctx, getComment); err != nil {
 		return nil, fmt.Errorf("error preparing query GetComment: %w", err)
 	}
+	if q.getCommentIncludingDeletedStmt, err = db.PrepareContext(ctx, getCommentIncludingDeleted); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCommentIncludingDeleted: %w", err)
+	}
+	if q.getCommentRevisionStmt, err = db.PrepareContext(ctx, getCommentRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCommentRevision: %w", err)
+	}
+	if q.getDataExportStmt, err = db.PrepareContext(ctx, getDataExport); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDataExport: %w", err)
+	}
+	if q.getDefaultUserIdentityStmt, err = db.PrepareContext(ctx, getDefaultUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDefaultUserIdentity: %w", err)
+	}
+	if q.getDeletedUserByUsernameStmt, err = db.PrepareContext(ctx, getDeletedUserByUsername); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDeletedUserByUsername: %w", err)
+	}
+	if q.getDraftPostStmt, err = db.PrepareContext(ctx, getDraftPost); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDraftPost: %w", err)
+	}
+	if q.getEmailDigestStmt, err = db.PrepareContext(ctx, getEmailDigest); err != nil {
+		return nil, fmt.Errorf("error preparing query GetEmailDigest: %w", err)
+	}
+	if q.getMediaByIdStmt, err = db.PrepareContext(ctx, getMediaById); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMediaById: %w", err)
+	}
 	if q.getMessageByIdStmt, err = db.PrepareContext(ctx, getMessageById); err != nil {
 		return nil, fmt.Errorf("error preparing query GetMessageById: %w", err)
 	}
 	if q.getPostByIdStmt, err = db.PrepareContext(ctx, getPostById); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPostById: %w", err)
 	}
+	if q.getPostByIdIncludingDeletedStmt, err = db.PrepareContext(ctx, getPostByIdIncludingDeleted); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPostByIdIncludingDeleted: %w", err)
+	}
+	if q.getPostReactionStmt, err = db.PrepareContext(ctx, getPostReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPostReaction: %w", err)
+	}
+	if q.getPostRevisionStmt, err = db.PrepareContext(ctx, getPostRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPostRevision: %w", err)
+	}
 	if q.getSessionByIdStmt, err = db.PrepareContext(ctx, getSessionById); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionById: %w", err)
 	}
@@ -84,8 +255,17 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getUserIdentityByIdStmt, err = db.PrepareContext(ctx, getUserIdentityById); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserIdentityById: %w", err)
 	}
-	if q.getUserIdentityByUserIdStmt, err = db.PrepareContext(ctx, getUserIdentityByUserId); err != nil {
-		return nil, fmt.Errorf("error preparing query GetUserIdentityByUserId: %w", err)
+	if q.getWebhookStmt, err = db.PrepareContext(ctx, getWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhook: %w", err)
+	}
+	if q.hasBlocksStmt, err = db.PrepareContext(ctx, hasBlocks); err != nil {
+		return nil, fmt.Errorf("error preparing query HasBlocks: %w", err)
+	}
+	if q.hasFeedPostsStmt, err = db.PrepareContext(ctx, hasFeedPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query HasFeedPosts: %w", err)
+	}
+	if q.isBlockedStmt, err = db.PrepareContext(ctx, isBlocked); err != nil {
+		return nil, fmt.Errorf("error preparing query IsBlocked: %w", err)
 	}
 	if q.listAllCommentsStmt, err = db.PrepareContext(ctx, listAllComments); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAllComments: %w", err)
@@ -93,52 +273,390 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listAllPostsStmt, err = db.PrepareContext(ctx, listAllPosts); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAllPosts: %w", err)
 	}
+	if q.listBlocksStmt, err = db.PrepareContext(ctx, listBlocks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBlocks: %w", err)
+	}
+	if q.listCommentRevisionsStmt, err = db.PrepareContext(ctx, listCommentRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListCommentRevisions: %w", err)
+	}
+	if q.listContentPreferencesStmt, err = db.PrepareContext(ctx, listContentPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query ListContentPreferences: %w", err)
+	}
+	if q.listControversialPostsStmt, err = db.PrepareContext(ctx, listControversialPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListControversialPosts: %w", err)
+	}
+	if q.listDataExportsStmt, err = db.PrepareContext(ctx, listDataExports); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDataExports: %w", err)
+	}
+	if q.listDigestTopCommentsStmt, err = db.PrepareContext(ctx, listDigestTopComments); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDigestTopComments: %w", err)
+	}
+	if q.listDraftPostsStmt, err = db.PrepareContext(ctx, listDraftPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDraftPosts: %w", err)
+	}
+	if q.listFeedPostsStmt, err = db.PrepareContext(ctx, listFeedPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeedPosts: %w", err)
+	}
+	if q.listFollowedIdentitiesStmt, err = db.PrepareContext(ctx, listFollowedIdentities); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFollowedIdentities: %w", err)
+	}
+	if q.listFollowedUsersStmt, err = db.PrepareContext(ctx, listFollowedUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFollowedUsers: %w", err)
+	}
+	if q.listFollowersStmt, err = db.PrepareContext(ctx, listFollowers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFollowers: %w", err)
+	}
+	if q.listHotPostsStmt, err = db.PrepareContext(ctx, listHotPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHotPosts: %w", err)
+	}
+	if q.listMediaByMessageIdStmt, err = db.PrepareContext(ctx, listMediaByMessageId); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMediaByMessageId: %w", err)
+	}
+	if q.listMediaByPostIdStmt, err = db.PrepareContext(ctx, listMediaByPostId); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMediaByPostId: %w", err)
+	}
 	if q.listMessageStmt, err = db.PrepareContext(ctx, listMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query ListMessage: %w", err)
 	}
+	if q.listMutedKeywordsStmt, err = db.PrepareContext(ctx, listMutedKeywords); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMutedKeywords: %w", err)
+	}
+	if q.listNotificationPreferencesStmt, err = db.PrepareContext(ctx, listNotificationPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNotificationPreferences: %w", err)
+	}
+	if q.listNotificationsStmt, err = db.PrepareContext(ctx, listNotifications); err != nil {
+		return nil, fmt.Errorf("error preparing query ListNotifications: %w", err)
+	}
+	if q.listPollOptionsStmt, err = db.PrepareContext(ctx, listPollOptions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPollOptions: %w", err)
+	}
+	if q.listPollsStmt, err = db.PrepareContext(ctx, listPolls); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPolls: %w", err)
+	}
+	if q.listPostRevisionsStmt, err = db.PrepareContext(ctx, listPostRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPostRevisions: %w", err)
+	}
+	if q.listPostsByTagStmt, err = db.PrepareContext(ctx, listPostsByTag); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPostsByTag: %w", err)
+	}
+	if q.listPublicPostsByUserIdStmt, err = db.PrepareContext(ctx, listPublicPostsByUserId); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPublicPostsByUserId: %w", err)
+	}
+	if q.listPushSubscriptionsStmt, err = db.PrepareContext(ctx, listPushSubscriptions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPushSubscriptions: %w", err)
+	}
+	if q.listTopPostsStmt, err = db.PrepareContext(ctx, listTopPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTopPosts: %w", err)
+	}
+	if q.listTrashedCommentsStmt, err = db.PrepareContext(ctx, listTrashedComments); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTrashedComments: %w", err)
+	}
+	if q.listTrashedMessagesStmt, err = db.PrepareContext(ctx, listTrashedMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTrashedMessages: %w", err)
+	}
+	if q.listTrashedPostsStmt, err = db.PrepareContext(ctx, listTrashedPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTrashedPosts: %w", err)
+	}
+	if q.listTrendingPostsStmt, err = db.PrepareContext(ctx, listTrendingPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTrendingPosts: %w", err)
+	}
+	if q.listTrendingTagsStmt, err = db.PrepareContext(ctx, listTrendingTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTrendingTags: %w", err)
+	}
+	if q.listUserIdentitiesStmt, err = db.PrepareContext(ctx, listUserIdentities); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserIdentities: %w", err)
+	}
+	if q.listUserIdentitiesDueForRotationStmt, err = db.PrepareContext(ctx, listUserIdentitiesDueForRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserIdentitiesDueForRotation: %w", err)
+	}
+	if q.listUserSessionsStmt, err = db.PrepareContext(ctx, listUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserSessions: %w", err)
+	}
 	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhooksStmt, err = db.PrepareContext(ctx, listWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooks: %w", err)
+	}
+	if q.listWebhooksForEventStmt, err = db.PrepareContext(ctx, listWebhooksForEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooksForEvent: %w", err)
+	}
+	if q.markAllNotificationsReadStmt, err = db.PrepareContext(ctx, markAllNotificationsRead); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkAllNotificationsRead: %w", err)
+	}
+	if q.markEmailDigestSentStmt, err = db.PrepareContext(ctx, markEmailDigestSent); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailDigestSent: %w", err)
+	}
+	if q.markNotificationReadStmt, err = db.PrepareContext(ctx, markNotificationRead); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkNotificationRead: %w", err)
+	}
+	if q.publishDraftPostStmt, err = db.PrepareContext(ctx, publishDraftPost); err != nil {
+		return nil, fmt.Errorf("error preparing query PublishDraftPost: %w", err)
+	}
+	if q.publishDuePostsStmt, err = db.PrepareContext(ctx, publishDuePosts); err != nil {
+		return nil, fmt.Errorf("error preparing query PublishDuePosts: %w", err)
+	}
+	if q.purgeCommentsStmt, err = db.PrepareContext(ctx, purgeComments); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeComments: %w", err)
+	}
+	if q.purgeMessagesStmt, err = db.PrepareContext(ctx, purgeMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeMessages: %w", err)
+	}
+	if q.purgePostsStmt, err = db.PrepareContext(ctx, purgePosts); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgePosts: %w", err)
+	}
+	if q.purgeUsersStmt, err = db.PrepareContext(ctx, purgeUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeUsers: %w", err)
+	}
+	if q.redeliverWebhookDeliveryStmt, err = db.PrepareContext(ctx, redeliverWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query RedeliverWebhookDelivery: %w", err)
+	}
+	if q.refreshPostScoresStmt, err = db.PrepareContext(ctx, refreshPostScores); err != nil {
+		return nil, fmt.Errorf("error preparing query RefreshPostScores: %w", err)
+	}
+	if q.replaceUserIdentityStmt, err = db.PrepareContext(ctx, replaceUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query ReplaceUserIdentity: %w", err)
+	}
+	if q.restoreCommentStmt, err = db.PrepareContext(ctx, restoreComment); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreComment: %w", err)
+	}
+	if q.restoreMessageStmt, err = db.PrepareContext(ctx, restoreMessage); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreMessage: %w", err)
+	}
+	if q.restorePostStmt, err = db.PrepareContext(ctx, restorePost); err != nil {
+		return nil, fmt.Errorf("error preparing query RestorePost: %w", err)
+	}
+	if q.restoreUserStmt, err = db.PrepareContext(ctx, restoreUser); err != nil {
+		return nil, fmt.Errorf("error preparing query RestoreUser: %w", err)
+	}
+	if q.retireUserIdentityStmt, err = db.PrepareContext(ctx, retireUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query RetireUserIdentity: %w", err)
+	}
+	if q.searchCommentsStmt, err = db.PrepareContext(ctx, searchComments); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchComments: %w", err)
+	}
+	if q.searchPostsStmt, err = db.PrepareContext(ctx, searchPosts); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchPosts: %w", err)
+	}
+	if q.searchTagsStmt, err = db.PrepareContext(ctx, searchTags); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchTags: %w", err)
+	}
+	if q.setContentPreferencesStmt, err = db.PrepareContext(ctx, setContentPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query SetContentPreferences: %w", err)
+	}
+	if q.setDefaultUserIdentityStmt, err = db.PrepareContext(ctx, setDefaultUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query SetDefaultUserIdentity: %w", err)
+	}
+	if q.setEmailDigestStmt, err = db.PrepareContext(ctx, setEmailDigest); err != nil {
+		return nil, fmt.Errorf("error preparing query SetEmailDigest: %w", err)
+	}
+	if q.setNotificationPreferencesStmt, err = db.PrepareContext(ctx, setNotificationPreferences); err != nil {
+		return nil, fmt.Errorf("error preparing query SetNotificationPreferences: %w", err)
+	}
+	if q.setPostContentFlagsStmt, err = db.PrepareContext(ctx, setPostContentFlags); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPostContentFlags: %w", err)
+	}
+	if q.setPostReactionStmt, err = db.PrepareContext(ctx, setPostReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPostReaction: %w", err)
+	}
+	if q.setPostTagsStmt, err = db.PrepareContext(ctx, setPostTags); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPostTags: %w", err)
+	}
+	if q.setUserModeratorStmt, err = db.PrepareContext(ctx, setUserModerator); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserModerator: %w", err)
+	}
+	if q.unfollowIdentityStmt, err = db.PrepareContext(ctx, unfollowIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query UnfollowIdentity: %w", err)
+	}
+	if q.unfollowUserStmt, err = db.PrepareContext(ctx, unfollowUser); err != nil {
+		return nil, fmt.Errorf("error preparing query UnfollowUser: %w", err)
+	}
+	if q.unsubscribeEmailDigestStmt, err = db.PrepareContext(ctx, unsubscribeEmailDigest); err != nil {
+		return nil, fmt.Errorf("error preparing query UnsubscribeEmailDigest: %w", err)
+	}
 	if q.updateCommentStmt, err = db.PrepareContext(ctx, updateComment); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateComment: %w", err)
 	}
+	if q.updateDraftPostStmt, err = db.PrepareContext(ctx, updateDraftPost); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateDraftPost: %w", err)
+	}
 	if q.updateMessageStatusStmt, err = db.PrepareContext(ctx, updateMessageStatus); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessageStatus: %w", err)
 	}
 	if q.updatePostStmt, err = db.PrepareContext(ctx, updatePost); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdatePost: %w", err)
 	}
+	if q.updateUserIdentityStmt, err = db.PrepareContext(ctx, updateUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserIdentity: %w", err)
+	}
+	if q.updateUserIdentityRotationStmt, err = db.PrepareContext(ctx, updateUserIdentityRotation); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserIdentityRotation: %w", err)
+	}
 	if q.updateUserPasswordStmt, err = db.PrepareContext(ctx, updateUserPassword); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUserPassword: %w", err)
 	}
+	if q.updateUserProfileStmt, err = db.PrepareContext(ctx, updateUserProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserProfile: %w", err)
+	}
 	if q.updateUsernameStmt, err = db.PrepareContext(ctx, updateUsername); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateUsername: %w", err)
 	}
+	if q.updateWebhookStmt, err = db.PrepareContext(ctx, updateWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhook: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.blockIdentityStmt != nil {
+		if cerr := q.blockIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockIdentityStmt: %w", cerr)
+		}
+	}
 	if q.blockSessionStmt != nil {
 		if cerr := q.blockSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing blockSessionStmt: %w", cerr)
 		}
 	}
+	if q.blockUserStmt != nil {
+		if cerr := q.blockUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockUserStmt: %w", cerr)
+		}
+	}
+	if q.castPollBallotStmt != nil {
+		if cerr := q.castPollBallotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing castPollBallotStmt: %w", cerr)
+		}
+	}
+	if q.claimDataExportStmt != nil {
+		if cerr := q.claimDataExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDataExportStmt: %w", cerr)
+		}
+	}
+	if q.claimDueEmailDigestsStmt != nil {
+		if cerr := q.claimDueEmailDigestsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDueEmailDigestsStmt: %w", cerr)
+		}
+	}
+	if q.claimWebhookDeliveriesStmt != nil {
+		if cerr := q.claimWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.clearDefaultUserIdentityStmt != nil {
+		if cerr := q.clearDefaultUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearDefaultUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.completeDataExportStmt != nil {
+		if cerr := q.completeDataExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeDataExportStmt: %w", cerr)
+		}
+	}
+	if q.completeWebhookDeliveryStmt != nil {
+		if cerr := q.completeWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.countActiveUserIdentitiesStmt != nil {
+		if cerr := q.countActiveUserIdentitiesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countActiveUserIdentitiesStmt: %w", cerr)
+		}
+	}
+	if q.countDigestFollowersStmt != nil {
+		if cerr := q.countDigestFollowersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countDigestFollowersStmt: %w", cerr)
+		}
+	}
+	if q.countDigestMessagesStmt != nil {
+		if cerr := q.countDigestMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countDigestMessagesStmt: %w", cerr)
+		}
+	}
+	if q.countMediaByPostIdStmt != nil {
+		if cerr := q.countMediaByPostIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countMediaByPostIdStmt: %w", cerr)
+		}
+	}
+	if q.countMutedKeywordsStmt != nil {
+		if cerr := q.countMutedKeywordsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countMutedKeywordsStmt: %w", cerr)
+		}
+	}
+	if q.countPostReactionsStmt != nil {
+		if cerr := q.countPostReactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPostReactionsStmt: %w", cerr)
+		}
+	}
+	if q.countUnreadNotificationsStmt != nil {
+		if cerr := q.countUnreadNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUnreadNotificationsStmt: %w", cerr)
+		}
+	}
 	if q.createCommentStmt != nil {
 		if cerr := q.createCommentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createCommentStmt: %w", cerr)
 		}
 	}
+	if q.createCommentRevisionStmt != nil {
+		if cerr := q.createCommentRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCommentRevisionStmt: %w", cerr)
+		}
+	}
+	if q.createDataExportStmt != nil {
+		if cerr := q.createDataExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDataExportStmt: %w", cerr)
+		}
+	}
+	if q.createMediaStmt != nil {
+		if cerr := q.createMediaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMediaStmt: %w", cerr)
+		}
+	}
 	if q.createMessageStmt != nil {
 		if cerr := q.createMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createMutedKeywordStmt != nil {
+		if cerr := q.createMutedKeywordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMutedKeywordStmt: %w", cerr)
+		}
+	}
+	if q.createNotificationStmt != nil {
+		if cerr := q.createNotificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createNotificationStmt: %w", cerr)
+		}
+	}
+	if q.createPollStmt != nil {
+		if cerr := q.createPollStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPollStmt: %w", cerr)
+		}
+	}
+	if q.createPollOptionsStmt != nil {
+		if cerr := q.createPollOptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPollOptionsStmt: %w", cerr)
+		}
+	}
 	if q.createPostStmt != nil {
 		if cerr := q.createPostStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createPostStmt: %w", cerr)
 		}
 	}
+	if q.createPostRevisionStmt != nil {
+		if cerr := q.createPostRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPostRevisionStmt: %w", cerr)
+		}
+	}
+	if q.createPushSubscriptionStmt != nil {
+		if cerr := q.createPushSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPushSubscriptionStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
@@ -154,11 +672,61 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createUserIdentityStmt: %w", cerr)
 		}
 	}
+	if q.createWebhookStmt != nil {
+		if cerr := q.createWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.deleteBlockStmt != nil {
+		if cerr := q.deleteBlockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBlockStmt: %w", cerr)
+		}
+	}
 	if q.deleteCommentStmt != nil {
 		if cerr := q.deleteCommentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteCommentStmt: %w", cerr)
 		}
 	}
+	if q.deleteEmailDigestStmt != nil {
+		if cerr := q.deleteEmailDigestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteEmailDigestStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredDataExportsStmt != nil {
+		if cerr := q.deleteExpiredDataExportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredDataExportsStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredSessionsStmt != nil {
+		if cerr := q.deleteExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteGonePushSubscriptionStmt != nil {
+		if cerr := q.deleteGonePushSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGonePushSubscriptionStmt: %w", cerr)
+		}
+	}
+	if q.deleteMediaStmt != nil {
+		if cerr := q.deleteMediaStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMediaStmt: %w", cerr)
+		}
+	}
+	if q.deleteMutedKeywordStmt != nil {
+		if cerr := q.deleteMutedKeywordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMutedKeywordStmt: %w", cerr)
+		}
+	}
+	if q.deleteOldAvatarsStmt != nil {
+		if cerr := q.deleteOldAvatarsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteOldAvatarsStmt: %w", cerr)
+		}
+	}
 	if q.deleteOneMessageStmt != nil {
 		if cerr := q.deleteOneMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteOneMessageStmt: %w", cerr)
@@ -174,6 +742,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deletePostStmt: %w", cerr)
 		}
 	}
+	if q.deletePostReactionStmt != nil {
+		if cerr := q.deletePostReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePostReactionStmt: %w", cerr)
+		}
+	}
+	if q.deletePushSubscriptionStmt != nil {
+		if cerr := q.deletePushSubscriptionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePushSubscriptionStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
@@ -184,11 +762,96 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionByUserIdStmt: %w", cerr)
 		}
 	}
+	if q.deleteUserPushSubscriptionsStmt != nil {
+		if cerr := q.deleteUserPushSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserPushSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookStmt != nil {
+		if cerr := q.deleteWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookStmt: %w", cerr)
+		}
+	}
+	if q.exportUserCommentsStmt != nil {
+		if cerr := q.exportUserCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserCommentsStmt: %w", cerr)
+		}
+	}
+	if q.exportUserMessagesStmt != nil {
+		if cerr := q.exportUserMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserMessagesStmt: %w", cerr)
+		}
+	}
+	if q.exportUserPostsStmt != nil {
+		if cerr := q.exportUserPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserPostsStmt: %w", cerr)
+		}
+	}
+	if q.failDataExportStmt != nil {
+		if cerr := q.failDataExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failDataExportStmt: %w", cerr)
+		}
+	}
+	if q.failWebhookDeliveryStmt != nil {
+		if cerr := q.failWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing failWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.followIdentityStmt != nil {
+		if cerr := q.followIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing followIdentityStmt: %w", cerr)
+		}
+	}
+	if q.followUserStmt != nil {
+		if cerr := q.followUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing followUserStmt: %w", cerr)
+		}
+	}
 	if q.getCommentStmt != nil {
 		if cerr := q.getCommentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getCommentStmt: %w", cerr)
 		}
 	}
+	if q.getCommentIncludingDeletedStmt != nil {
+		if cerr := q.getCommentIncludingDeletedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCommentIncludingDeletedStmt: %w", cerr)
+		}
+	}
+	if q.getCommentRevisionStmt != nil {
+		if cerr := q.getCommentRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCommentRevisionStmt: %w", cerr)
+		}
+	}
+	if q.getDataExportStmt != nil {
+		if cerr := q.getDataExportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDataExportStmt: %w", cerr)
+		}
+	}
+	if q.getDefaultUserIdentityStmt != nil {
+		if cerr := q.getDefaultUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDefaultUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.getDeletedUserByUsernameStmt != nil {
+		if cerr := q.getDeletedUserByUsernameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDeletedUserByUsernameStmt: %w", cerr)
+		}
+	}
+	if q.getDraftPostStmt != nil {
+		if cerr := q.getDraftPostStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDraftPostStmt: %w", cerr)
+		}
+	}
+	if q.getEmailDigestStmt != nil {
+		if cerr := q.getEmailDigestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getEmailDigestStmt: %w", cerr)
+		}
+	}
+	if q.getMediaByIdStmt != nil {
+		if cerr := q.getMediaByIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMediaByIdStmt: %w", cerr)
+		}
+	}
 	if q.getMessageByIdStmt != nil {
 		if cerr := q.getMessageByIdStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getMessageByIdStmt: %w", cerr)
@@ -199,6 +862,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getPostByIdStmt: %w", cerr)
 		}
 	}
+	if q.getPostByIdIncludingDeletedStmt != nil {
+		if cerr := q.getPostByIdIncludingDeletedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPostByIdIncludingDeletedStmt: %w", cerr)
+		}
+	}
+	if q.getPostReactionStmt != nil {
+		if cerr := q.getPostReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPostReactionStmt: %w", cerr)
+		}
+	}
+	if q.getPostRevisionStmt != nil {
+		if cerr := q.getPostRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPostRevisionStmt: %w", cerr)
+		}
+	}
 	if q.getSessionByIdStmt != nil {
 		if cerr := q.getSessionByIdStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionByIdStmt: %w", cerr)
@@ -219,9 +897,24 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserIdentityByIdStmt: %w", cerr)
 		}
 	}
-	if q.getUserIdentityByUserIdStmt != nil {
-		if cerr := q.getUserIdentityByUserIdStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getUserIdentityByUserIdStmt: %w", cerr)
+	if q.getWebhookStmt != nil {
+		if cerr := q.getWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookStmt: %w", cerr)
+		}
+	}
+	if q.hasBlocksStmt != nil {
+		if cerr := q.hasBlocksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hasBlocksStmt: %w", cerr)
+		}
+	}
+	if q.hasFeedPostsStmt != nil {
+		if cerr := q.hasFeedPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing hasFeedPostsStmt: %w", cerr)
+		}
+	}
+	if q.isBlockedStmt != nil {
+		if cerr := q.isBlockedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing isBlockedStmt: %w", cerr)
 		}
 	}
 	if q.listAllCommentsStmt != nil {
@@ -234,21 +927,356 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listAllPostsStmt: %w", cerr)
 		}
 	}
+	if q.listBlocksStmt != nil {
+		if cerr := q.listBlocksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBlocksStmt: %w", cerr)
+		}
+	}
+	if q.listCommentRevisionsStmt != nil {
+		if cerr := q.listCommentRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listCommentRevisionsStmt: %w", cerr)
+		}
+	}
+	if q.listContentPreferencesStmt != nil {
+		if cerr := q.listContentPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listContentPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.listControversialPostsStmt != nil {
+		if cerr := q.listControversialPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listControversialPostsStmt: %w", cerr)
+		}
+	}
+	if q.listDataExportsStmt != nil {
+		if cerr := q.listDataExportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDataExportsStmt: %w", cerr)
+		}
+	}
+	if q.listDigestTopCommentsStmt != nil {
+		if cerr := q.listDigestTopCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDigestTopCommentsStmt: %w", cerr)
+		}
+	}
+	if q.listDraftPostsStmt != nil {
+		if cerr := q.listDraftPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDraftPostsStmt: %w", cerr)
+		}
+	}
+	if q.listFeedPostsStmt != nil {
+		if cerr := q.listFeedPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeedPostsStmt: %w", cerr)
+		}
+	}
+	if q.listFollowedIdentitiesStmt != nil {
+		if cerr := q.listFollowedIdentitiesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFollowedIdentitiesStmt: %w", cerr)
+		}
+	}
+	if q.listFollowedUsersStmt != nil {
+		if cerr := q.listFollowedUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFollowedUsersStmt: %w", cerr)
+		}
+	}
+	if q.listFollowersStmt != nil {
+		if cerr := q.listFollowersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFollowersStmt: %w", cerr)
+		}
+	}
+	if q.listHotPostsStmt != nil {
+		if cerr := q.listHotPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHotPostsStmt: %w", cerr)
+		}
+	}
+	if q.listMediaByMessageIdStmt != nil {
+		if cerr := q.listMediaByMessageIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMediaByMessageIdStmt: %w", cerr)
+		}
+	}
+	if q.listMediaByPostIdStmt != nil {
+		if cerr := q.listMediaByPostIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMediaByPostIdStmt: %w", cerr)
+		}
+	}
 	if q.listMessageStmt != nil {
 		if cerr := q.listMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listMessageStmt: %w", cerr)
 		}
 	}
+	if q.listMutedKeywordsStmt != nil {
+		if cerr := q.listMutedKeywordsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMutedKeywordsStmt: %w", cerr)
+		}
+	}
+	if q.listNotificationPreferencesStmt != nil {
+		if cerr := q.listNotificationPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNotificationPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.listNotificationsStmt != nil {
+		if cerr := q.listNotificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listNotificationsStmt: %w", cerr)
+		}
+	}
+	if q.listPollOptionsStmt != nil {
+		if cerr := q.listPollOptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPollOptionsStmt: %w", cerr)
+		}
+	}
+	if q.listPollsStmt != nil {
+		if cerr := q.listPollsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPollsStmt: %w", cerr)
+		}
+	}
+	if q.listPostRevisionsStmt != nil {
+		if cerr := q.listPostRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPostRevisionsStmt: %w", cerr)
+		}
+	}
+	if q.listPostsByTagStmt != nil {
+		if cerr := q.listPostsByTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPostsByTagStmt: %w", cerr)
+		}
+	}
+	if q.listPublicPostsByUserIdStmt != nil {
+		if cerr := q.listPublicPostsByUserIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPublicPostsByUserIdStmt: %w", cerr)
+		}
+	}
+	if q.listPushSubscriptionsStmt != nil {
+		if cerr := q.listPushSubscriptionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPushSubscriptionsStmt: %w", cerr)
+		}
+	}
+	if q.listTopPostsStmt != nil {
+		if cerr := q.listTopPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTopPostsStmt: %w", cerr)
+		}
+	}
+	if q.listTrashedCommentsStmt != nil {
+		if cerr := q.listTrashedCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTrashedCommentsStmt: %w", cerr)
+		}
+	}
+	if q.listTrashedMessagesStmt != nil {
+		if cerr := q.listTrashedMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTrashedMessagesStmt: %w", cerr)
+		}
+	}
+	if q.listTrashedPostsStmt != nil {
+		if cerr := q.listTrashedPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTrashedPostsStmt: %w", cerr)
+		}
+	}
+	if q.listTrendingPostsStmt != nil {
+		if cerr := q.listTrendingPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTrendingPostsStmt: %w", cerr)
+		}
+	}
+	if q.listTrendingTagsStmt != nil {
+		if cerr := q.listTrendingTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTrendingTagsStmt: %w", cerr)
+		}
+	}
+	if q.listUserIdentitiesStmt != nil {
+		if cerr := q.listUserIdentitiesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserIdentitiesStmt: %w", cerr)
+		}
+	}
+	if q.listUserIdentitiesDueForRotationStmt != nil {
+		if cerr := q.listUserIdentitiesDueForRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserIdentitiesDueForRotationStmt: %w", cerr)
+		}
+	}
+	if q.listUserSessionsStmt != nil {
+		if cerr := q.listUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserSessionsStmt: %w", cerr)
+		}
+	}
 	if q.listUsersStmt != nil {
 		if cerr := q.listUsersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
 		}
 	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksStmt != nil {
+		if cerr := q.listWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksForEventStmt != nil {
+		if cerr := q.listWebhooksForEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksForEventStmt: %w", cerr)
+		}
+	}
+	if q.markAllNotificationsReadStmt != nil {
+		if cerr := q.markAllNotificationsReadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markAllNotificationsReadStmt: %w", cerr)
+		}
+	}
+	if q.markEmailDigestSentStmt != nil {
+		if cerr := q.markEmailDigestSentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailDigestSentStmt: %w", cerr)
+		}
+	}
+	if q.markNotificationReadStmt != nil {
+		if cerr := q.markNotificationReadStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markNotificationReadStmt: %w", cerr)
+		}
+	}
+	if q.publishDraftPostStmt != nil {
+		if cerr := q.publishDraftPostStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing publishDraftPostStmt: %w", cerr)
+		}
+	}
+	if q.publishDuePostsStmt != nil {
+		if cerr := q.publishDuePostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing publishDuePostsStmt: %w", cerr)
+		}
+	}
+	if q.purgeCommentsStmt != nil {
+		if cerr := q.purgeCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeCommentsStmt: %w", cerr)
+		}
+	}
+	if q.purgeMessagesStmt != nil {
+		if cerr := q.purgeMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeMessagesStmt: %w", cerr)
+		}
+	}
+	if q.purgePostsStmt != nil {
+		if cerr := q.purgePostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgePostsStmt: %w", cerr)
+		}
+	}
+	if q.purgeUsersStmt != nil {
+		if cerr := q.purgeUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeUsersStmt: %w", cerr)
+		}
+	}
+	if q.redeliverWebhookDeliveryStmt != nil {
+		if cerr := q.redeliverWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing redeliverWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.refreshPostScoresStmt != nil {
+		if cerr := q.refreshPostScoresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing refreshPostScoresStmt: %w", cerr)
+		}
+	}
+	if q.replaceUserIdentityStmt != nil {
+		if cerr := q.replaceUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing replaceUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.restoreCommentStmt != nil {
+		if cerr := q.restoreCommentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreCommentStmt: %w", cerr)
+		}
+	}
+	if q.restoreMessageStmt != nil {
+		if cerr := q.restoreMessageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreMessageStmt: %w", cerr)
+		}
+	}
+	if q.restorePostStmt != nil {
+		if cerr := q.restorePostStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restorePostStmt: %w", cerr)
+		}
+	}
+	if q.restoreUserStmt != nil {
+		if cerr := q.restoreUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing restoreUserStmt: %w", cerr)
+		}
+	}
+	if q.retireUserIdentityStmt != nil {
+		if cerr := q.retireUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing retireUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.searchCommentsStmt != nil {
+		if cerr := q.searchCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchCommentsStmt: %w", cerr)
+		}
+	}
+	if q.searchPostsStmt != nil {
+		if cerr := q.searchPostsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchPostsStmt: %w", cerr)
+		}
+	}
+	if q.searchTagsStmt != nil {
+		if cerr := q.searchTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchTagsStmt: %w", cerr)
+		}
+	}
+	if q.setContentPreferencesStmt != nil {
+		if cerr := q.setContentPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setContentPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.setDefaultUserIdentityStmt != nil {
+		if cerr := q.setDefaultUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setDefaultUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.setEmailDigestStmt != nil {
+		if cerr := q.setEmailDigestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setEmailDigestStmt: %w", cerr)
+		}
+	}
+	if q.setNotificationPreferencesStmt != nil {
+		if cerr := q.setNotificationPreferencesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setNotificationPreferencesStmt: %w", cerr)
+		}
+	}
+	if q.setPostContentFlagsStmt != nil {
+		if cerr := q.setPostContentFlagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPostContentFlagsStmt: %w", cerr)
+		}
+	}
+	if q.setPostReactionStmt != nil {
+		if cerr := q.setPostReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPostReactionStmt: %w", cerr)
+		}
+	}
+	if q.setPostTagsStmt != nil {
+		if cerr := q.setPostTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPostTagsStmt: %w", cerr)
+		}
+	}
+	if q.setUserModeratorStmt != nil {
+		if cerr := q.setUserModeratorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserModeratorStmt: %w", cerr)
+		}
+	}
+	if q.unfollowIdentityStmt != nil {
+		if cerr := q.unfollowIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unfollowIdentityStmt: %w", cerr)
+		}
+	}
+	if q.unfollowUserStmt != nil {
+		if cerr := q.unfollowUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unfollowUserStmt: %w", cerr)
+		}
+	}
+	if q.unsubscribeEmailDigestStmt != nil {
+		if cerr := q.unsubscribeEmailDigestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unsubscribeEmailDigestStmt: %w", cerr)
+		}
+	}
 	if q.updateCommentStmt != nil {
 		if cerr := q.updateCommentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateCommentStmt: %w", cerr)
 		}
 	}
+	if q.updateDraftPostStmt != nil {
+		if cerr := q.updateDraftPostStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateDraftPostStmt: %w", cerr)
+		}
+	}
 	if q.updateMessageStatusStmt != nil {
 		if cerr := q.updateMessageStatusStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateMessageStatusStmt: %w", cerr)
@@ -259,16 +1287,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updatePostStmt: %w", cerr)
 		}
 	}
+	if q.updateUserIdentityStmt != nil {
+		if cerr := q.updateUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.updateUserIdentityRotationStmt != nil {
+		if cerr := q.updateUserIdentityRotationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserIdentityRotationStmt: %w", cerr)
+		}
+	}
 	if q.updateUserPasswordStmt != nil {
 		if cerr := q.updateUserPasswordStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateUserPasswordStmt: %w", cerr)
 		}
 	}
+	if q.updateUserProfileStmt != nil {
+		if cerr := q.updateUserProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserProfileStmt: %w", cerr)
+		}
+	}
 	if q.updateUsernameStmt != nil {
 		if cerr := q.updateUsernameStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateUsernameStmt: %w", cerr)
 		}
 	}
+	if q.updateWebhookStmt != nil {
+		if cerr := q.updateWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -306,73 +1354,335 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                          DBTX
-	tx                          *sql.Tx
-	blockSessionStmt            *sql.Stmt
-	createCommentStmt           *sql.Stmt
-	createMessageStmt           *sql.Stmt
-	createPostStmt              *sql.Stmt
-	createSessionStmt           *sql.Stmt
-	createUserStmt              *sql.Stmt
-	createUserIdentityStmt      *sql.Stmt
-	deleteCommentStmt           *sql.Stmt
-	deleteOneMessageStmt        *sql.Stmt
-	deleteOneUserStmt           *sql.Stmt
-	deletePostStmt              *sql.Stmt
-	deleteSessionStmt           *sql.Stmt
-	deleteSessionByUserIdStmt   *sql.Stmt
-	getCommentStmt              *sql.Stmt
-	getMessageByIdStmt          *sql.Stmt
-	getPostByIdStmt             *sql.Stmt
-	getSessionByIdStmt          *sql.Stmt
-	getUserByIdStmt             *sql.Stmt
-	getUserByUsernameStmt       *sql.Stmt
-	getUserIdentityByIdStmt     *sql.Stmt
-	getUserIdentityByUserIdStmt *sql.Stmt
-	listAllCommentsStmt         *sql.Stmt
-	listAllPostsStmt            *sql.Stmt
-	listMessageStmt             *sql.Stmt
-	listUsersStmt               *sql.Stmt
-	updateCommentStmt           *sql.Stmt
-	updateMessageStatusStmt     *sql.Stmt
-	updatePostStmt              *sql.Stmt
-	updateUserPasswordStmt      *sql.Stmt
-	updateUsernameStmt          *sql.Stmt
+	db                                   DBTX
+	tx                                   *sql.Tx
+	blockIdentityStmt                    *sql.Stmt
+	blockSessionStmt                     *sql.Stmt
+	blockUserStmt                        *sql.Stmt
+	castPollBallotStmt                   *sql.Stmt
+	claimDataExportStmt                  *sql.Stmt
+	claimDueEmailDigestsStmt             *sql.Stmt
+	claimWebhookDeliveriesStmt           *sql.Stmt
+	clearDefaultUserIdentityStmt         *sql.Stmt
+	completeDataExportStmt               *sql.Stmt
+	completeWebhookDeliveryStmt          *sql.Stmt
+	countActiveUserIdentitiesStmt        *sql.Stmt
+	countDigestFollowersStmt             *sql.Stmt
+	countDigestMessagesStmt              *sql.Stmt
+	countMediaByPostIdStmt               *sql.Stmt
+	countMutedKeywordsStmt               *sql.Stmt
+	countPostReactionsStmt               *sql.Stmt
+	countUnreadNotificationsStmt         *sql.Stmt
+	createCommentStmt                    *sql.Stmt
+	createCommentRevisionStmt            *sql.Stmt
+	createDataExportStmt                 *sql.Stmt
+	createMediaStmt                      *sql.Stmt
+	createMessageStmt                    *sql.Stmt
+	createMutedKeywordStmt               *sql.Stmt
+	createNotificationStmt               *sql.Stmt
+	createPollStmt                       *sql.Stmt
+	createPollOptionsStmt                *sql.Stmt
+	createPostStmt                       *sql.Stmt
+	createPostRevisionStmt               *sql.Stmt
+	createPushSubscriptionStmt           *sql.Stmt
+	createSessionStmt                    *sql.Stmt
+	createUserStmt                       *sql.Stmt
+	createUserIdentityStmt               *sql.Stmt
+	createWebhookStmt                    *sql.Stmt
+	createWebhookDeliveryStmt            *sql.Stmt
+	deleteBlockStmt                      *sql.Stmt
+	deleteCommentStmt                    *sql.Stmt
+	deleteEmailDigestStmt                *sql.Stmt
+	deleteExpiredDataExportsStmt         *sql.Stmt
+	deleteExpiredSessionsStmt            *sql.Stmt
+	deleteGonePushSubscriptionStmt       *sql.Stmt
+	deleteMediaStmt                      *sql.Stmt
+	deleteMutedKeywordStmt               *sql.Stmt
+	deleteOldAvatarsStmt                 *sql.Stmt
+	deleteOneMessageStmt                 *sql.Stmt
+	deleteOneUserStmt                    *sql.Stmt
+	deletePostStmt                       *sql.Stmt
+	deletePostReactionStmt               *sql.Stmt
+	deletePushSubscriptionStmt           *sql.Stmt
+	deleteSessionStmt                    *sql.Stmt
+	deleteSessionByUserIdStmt            *sql.Stmt
+	deleteUserPushSubscriptionsStmt      *sql.Stmt
+	deleteWebhookStmt                    *sql.Stmt
+	exportUserCommentsStmt               *sql.Stmt
+	exportUserMessagesStmt               *sql.Stmt
+	exportUserPostsStmt                  *sql.Stmt
+	failDataExportStmt                   *sql.Stmt
+	failWebhookDeliveryStmt              *sql.Stmt
+	followIdentityStmt                   *sql.Stmt
+	followUserStmt                       *sql.Stmt
+	getCommentStmt                       *sql.Stmt
+	getCommentIncludingDeletedStmt       *sql.Stmt
+	getCommentRevisionStmt               *sql.Stmt
+	getDataExportStmt                    *sql.Stmt
+	getDefaultUserIdentityStmt           *sql.Stmt
+	getDeletedUserByUsernameStmt         *sql.Stmt
+	getDraftPostStmt                     *sql.Stmt
+	getEmailDigestStmt                   *sql.Stmt
+	getMediaByIdStmt                     *sql.Stmt
+	getMessageByIdStmt                   *sql.Stmt
+	getPostByIdStmt                      *sql.Stmt
+	getPostByIdIncludingDeletedStmt      *sql.Stmt
+	getPostReactionStmt                  *sql.Stmt
+	getPostRevisionStmt                  *sql.Stmt
+	getSessionByIdStmt                   *sql.Stmt
+	getUserByIdStmt                      *sql.Stmt
+	getUserByUsernameStmt                *sql.Stmt
+	getUserIdentityByIdStmt              *sql.Stmt
+	getWebhookStmt                       *sql.Stmt
+	hasBlocksStmt                        *sql.Stmt
+	hasFeedPostsStmt                     *sql.Stmt
+	isBlockedStmt                        *sql.Stmt
+	listAllCommentsStmt                  *sql.Stmt
+	listAllPostsStmt                     *sql.Stmt
+	listBlocksStmt                       *sql.Stmt
+	listCommentRevisionsStmt             *sql.Stmt
+	listContentPreferencesStmt           *sql.Stmt
+	listControversialPostsStmt           *sql.Stmt
+	listDataExportsStmt                  *sql.Stmt
+	listDigestTopCommentsStmt            *sql.Stmt
+	listDraftPostsStmt                   *sql.Stmt
+	listFeedPostsStmt                    *sql.Stmt
+	listFollowedIdentitiesStmt           *sql.Stmt
+	listFollowedUsersStmt                *sql.Stmt
+	listFollowersStmt                    *sql.Stmt
+	listHotPostsStmt                     *sql.Stmt
+	listMediaByMessageIdStmt             *sql.Stmt
+	listMediaByPostIdStmt                *sql.Stmt
+	listMessageStmt                      *sql.Stmt
+	listMutedKeywordsStmt                *sql.Stmt
+	listNotificationPreferencesStmt      *sql.Stmt
+	listNotificationsStmt                *sql.Stmt
+	listPollOptionsStmt                  *sql.Stmt
+	listPollsStmt                        *sql.Stmt
+	listPostRevisionsStmt                *sql.Stmt
+	listPostsByTagStmt                   *sql.Stmt
+	listPublicPostsByUserIdStmt          *sql.Stmt
+	listPushSubscriptionsStmt            *sql.Stmt
+	listTopPostsStmt                     *sql.Stmt
+	listTrashedCommentsStmt              *sql.Stmt
+	listTrashedMessagesStmt              *sql.Stmt
+	listTrashedPostsStmt                 *sql.Stmt
+	listTrendingPostsStmt                *sql.Stmt
+	listTrendingTagsStmt                 *sql.Stmt
+	listUserIdentitiesStmt               *sql.Stmt
+	listUserIdentitiesDueForRotationStmt *sql.Stmt
+	listUserSessionsStmt                 *sql.Stmt
+	listUsersStmt                        *sql.Stmt
+	listWebhookDeliveriesStmt            *sql.Stmt
+	listWebhooksStmt                     *sql.Stmt
+	listWebhooksForEventStmt             *sql.Stmt
+	markAllNotificationsReadStmt         *sql.Stmt
+	markEmailDigestSentStmt              *sql.Stmt
+	markNotificationReadStmt             *sql.Stmt
+	publishDraftPostStmt                 *sql.Stmt
+	publishDuePostsStmt                  *sql.Stmt
+	purgeCommentsStmt                    *sql.Stmt
+	purgeMessagesStmt                    *sql.Stmt
+	purgePostsStmt                       *sql.Stmt
+	purgeUsersStmt                       *sql.Stmt
+	redeliverWebhookDeliveryStmt         *sql.Stmt
+	refreshPostScoresStmt                *sql.Stmt
+	replaceUserIdentityStmt              *sql.Stmt
+	restoreCommentStmt                   *sql.Stmt
+	restoreMessageStmt                   *sql.Stmt
+	restorePostStmt                      *sql.Stmt
+	restoreUserStmt                      *sql.Stmt
+	retireUserIdentityStmt               *sql.Stmt
+	searchCommentsStmt                   *sql.Stmt
+	searchPostsStmt                      *sql.Stmt
+	searchTagsStmt                       *sql.Stmt
+	setContentPreferencesStmt            *sql.Stmt
+	setDefaultUserIdentityStmt           *sql.Stmt
+	setEmailDigestStmt                   *sql.Stmt
+	setNotificationPreferencesStmt       *sql.Stmt
+	setPostContentFlagsStmt              *sql.Stmt
+	setPostReactionStmt                  *sql.Stmt
+	setPostTagsStmt                      *sql.Stmt
+	setUserModeratorStmt                 *sql.Stmt
+	unfollowIdentityStmt                 *sql.Stmt
+	unfollowUserStmt                     *sql.Stmt
+	unsubscribeEmailDigestStmt           *sql.Stmt
+	updateCommentStmt                    *sql.Stmt
+	updateDraftPostStmt                  *sql.Stmt
+	updateMessageStatusStmt              *sql.Stmt
+	updatePostStmt                       *sql.Stmt
+	updateUserIdentityStmt               *sql.Stmt
+	updateUserIdentityRotationStmt       *sql.Stmt
+	updateUserPasswordStmt               *sql.Stmt
+	updateUserProfileStmt                *sql.Stmt
+	updateUsernameStmt                   *sql.Stmt
+	updateWebhookStmt                    *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                          tx,
-		tx:                          tx,
-		blockSessionStmt:            q.blockSessionStmt,
-		createCommentStmt:           q.createCommentStmt,
-		createMessageStmt:           q.createMessageStmt,
-		createPostStmt:              q.createPostStmt,
-		createSessionStmt:           q.createSessionStmt,
-		createUserStmt:              q.createUserStmt,
-		createUserIdentityStmt:      q.createUserIdentityStmt,
-		deleteCommentStmt:           q.deleteCommentStmt,
-		deleteOneMessageStmt:        q.deleteOneMessageStmt,
-		deleteOneUserStmt:           q.deleteOneUserStmt,
-		deletePostStmt:              q.deletePostStmt,
-		deleteSessionStmt:           q.deleteSessionStmt,
-		deleteSessionByUserIdStmt:   q.deleteSessionByUserIdStmt,
-		getCommentStmt:              q.getCommentStmt,
-		getMessageByIdStmt:          q.getMessageByIdStmt,
-		getPostByIdStmt:             q.getPostByIdStmt,
-		getSessionByIdStmt:          q.getSessionByIdStmt,
-		getUserByIdStmt:             q.getUserByIdStmt,
-		getUserByUsernameStmt:       q.getUserByUsernameStmt,
-		getUserIdentityByIdStmt:     q.getUserIdentityByIdStmt,
-		getUserIdentityByUserIdStmt: q.getUserIdentityByUserIdStmt,
-		listAllCommentsStmt:         q.listAllCommentsStmt,
-		listAllPostsStmt:            q.listAllPostsStmt,
-		listMessageStmt:             q.listMessageStmt,
-		listUsersStmt:               q.listUsersStmt,
-		updateCommentStmt:           q.updateCommentStmt,
-		updateMessageStatusStmt:     q.updateMessageStatusStmt,
-		updatePostStmt:              q.updatePostStmt,
-		updateUserPasswordStmt:      q.updateUserPasswordStmt,
-		updateUsernameStmt:          q.updateUsernameStmt,
+		db:                                   tx,
+		tx:                                   tx,
+		blockIdentityStmt:                    q.blockIdentityStmt,
+		blockSessionStmt:                     q.blockSessionStmt,
+		blockUserStmt:                        q.blockUserStmt,
+		castPollBallotStmt:                   q.castPollBallotStmt,
+		claimDataExportStmt:                  q.claimDataExportStmt,
+		claimDueEmailDigestsStmt:             q.claimDueEmailDigestsStmt,
+		claimWebhookDeliveriesStmt:           q.claimWebhookDeliveriesStmt,
+		clearDefaultUserIdentityStmt:         q.clearDefaultUserIdentityStmt,
+		completeDataExportStmt:               q.completeDataExportStmt,
+		completeWebhookDeliveryStmt:          q.completeWebhookDeliveryStmt,
+		countActiveUserIdentitiesStmt:        q.countActiveUserIdentitiesStmt,
+		countDigestFollowersStmt:             q.countDigestFollowersStmt,
+		countDigestMessagesStmt:              q.countDigestMessagesStmt,
+		countMediaByPostIdStmt:               q.countMediaByPostIdStmt,
+		countMutedKeywordsStmt:               q.countMutedKeywordsStmt,
+		countPostReactionsStmt:               q.countPostReactionsStmt,
+		countUnreadNotificationsStmt:         q.countUnreadNotificationsStmt,
+		createCommentStmt:                    q.createCommentStmt,
+		createCommentRevisionStmt:            q.createCommentRevisionStmt,
+		createDataExportStmt:                 q.createDataExportStmt,
+		createMediaStmt:                      q.createMediaStmt,
+		createMessageStmt:                    q.createMessageStmt,
+		createMutedKeywordStmt:               q.createMutedKeywordStmt,
+		createNotificationStmt:               q.createNotificationStmt,
+		createPollStmt:                       q.createPollStmt,
+		createPollOptionsStmt:                q.createPollOptionsStmt,
+		createPostStmt:                       q.createPostStmt,
+		createPostRevisionStmt:               q.createPostRevisionStmt,
+		createPushSubscriptionStmt:           q.createPushSubscriptionStmt,
+		createSessionStmt:                    q.createSessionStmt,
+		createUserStmt:                       q.createUserStmt,
+		createUserIdentityStmt:               q.createUserIdentityStmt,
+		createWebhookStmt:                    q.createWebhookStmt,
+		createWebhookDeliveryStmt:            q.createWebhookDeliveryStmt,
+		deleteBlockStmt:                      q.deleteBlockStmt,
+		deleteCommentStmt:                    q.deleteCommentStmt,
+		deleteEmailDigestStmt:                q.deleteEmailDigestStmt,
+		deleteExpiredDataExportsStmt:         q.deleteExpiredDataExportsStmt,
+		deleteExpiredSessionsStmt:            q.deleteExpiredSessionsStmt,
+		deleteGonePushSubscriptionStmt:       q.deleteGonePushSubscriptionStmt,
+		deleteMediaStmt:                      q.deleteMediaStmt,
+		deleteMutedKeywordStmt:               q.deleteMutedKeywordStmt,
+		deleteOldAvatarsStmt:                 q.deleteOldAvatarsStmt,
+		deleteOneMessageStmt:                 q.deleteOneMessageStmt,
+		deleteOneUserStmt:                    q.deleteOneUserStmt,
+		deletePostStmt:                       q.deletePostStmt,
+		deletePostReactionStmt:               q.deletePostReactionStmt,
+		deletePushSubscriptionStmt:           q.deletePushSubscriptionStmt,
+		deleteSessionStmt:                    q.deleteSessionStmt,
+		deleteSessionByUserIdStmt:            q.deleteSessionByUserIdStmt,
+		deleteUserPushSubscriptionsStmt:      q.deleteUserPushSubscriptionsStmt,
+		deleteWebhookStmt:                    q.deleteWebhookStmt,
+		exportUserCommentsStmt:               q.exportUserCommentsStmt,
+		exportUserMessagesStmt:               q.exportUserMessagesStmt,
+		exportUserPostsStmt:                  q.exportUserPostsStmt,
+		failDataExportStmt:                   q.failDataExportStmt,
+		failWebhookDeliveryStmt:              q.failWebhookDeliveryStmt,
+		followIdentityStmt:                   q.followIdentityStmt,
+		followUserStmt:                       q.followUserStmt,
+		getCommentStmt:                       q.getCommentStmt,
+		getCommentIncludingDeletedStmt:       q.getCommentIncludingDeletedStmt,
+		getCommentRevisionStmt:               q.getCommentRevisionStmt,
+		getDataExportStmt:                    q.getDataExportStmt,
+		getDefaultUserIdentityStmt:           q.getDefaultUserIdentityStmt,
+		getDeletedUserByUsernameStmt:         q.getDeletedUserByUsernameStmt,
+		getDraftPostStmt:                     q.getDraftPostStmt,
+		getEmailDigestStmt:                   q.getEmailDigestStmt,
+		getMediaByIdStmt:                     q.getMediaByIdStmt,
+		getMessageByIdStmt:                   q.getMessageByIdStmt,
+		getPostByIdStmt:                      q.getPostByIdStmt,
+		getPostByIdIncludingDeletedStmt:      q.getPostByIdIncludingDeletedStmt,
+		getPostReactionStmt:                  q.getPostReactionStmt,
+		getPostRevisionStmt:                  q.getPostRevisionStmt,
+		getSessionByIdStmt:                   q.getSessionByIdStmt,
+		getUserByIdStmt:                      q.getUserByIdStmt,
+		getUserByUsernameStmt:                q.getUserByUsernameStmt,
+		getUserIdentityByIdStmt:              q.getUserIdentityByIdStmt,
+		getWebhookStmt:                       q.getWebhookStmt,
+		hasBlocksStmt:                        q.hasBlocksStmt,
+		hasFeedPostsStmt:                     q.hasFeedPostsStmt,
+		isBlockedStmt:                        q.isBlockedStmt,
+		listAllCommentsStmt:                  q.listAllCommentsStmt,
+		listAllPostsStmt:                     q.listAllPostsStmt,
+		listBlocksStmt:                       q.listBlocksStmt,
+		listCommentRevisionsStmt:             q.listCommentRevisionsStmt,
+		listContentPreferencesStmt:           q.listContentPreferencesStmt,
+		listControversialPostsStmt:           q.listControversialPostsStmt,
+		listDataExportsStmt:                  q.listDataExportsStmt,
+		listDigestTopCommentsStmt:            q.listDigestTopCommentsStmt,
+		listDraftPostsStmt:                   q.listDraftPostsStmt,
+		listFeedPostsStmt:                    q.listFeedPostsStmt,
+		listFollowedIdentitiesStmt:           q.listFollowedIdentitiesStmt,
+		listFollowedUsersStmt:                q.listFollowedUsersStmt,
+		listFollowersStmt:                    q.listFollowersStmt,
+		listHotPostsStmt:                     q.listHotPostsStmt,
+		listMediaByMessageIdStmt:             q.listMediaByMessageIdStmt,
+		listMediaByPostIdStmt:                q.listMediaByPostIdStmt,
+		listMessageStmt:                      q.listMessageStmt,
+		listMutedKeywordsStmt:                q.listMutedKeywordsStmt,
+		listNotificationPreferencesStmt:      q.listNotificationPreferencesStmt,
+		listNotificationsStmt:                q.listNotificationsStmt,
+		listPollOptionsStmt:                  q.listPollOptionsStmt,
+		listPollsStmt:                        q.listPollsStmt,
+		listPostRevisionsStmt:                q.listPostRevisionsStmt,
+		listPostsByTagStmt:                   q.listPostsByTagStmt,
+		listPublicPostsByUserIdStmt:          q.listPublicPostsByUserIdStmt,
+		listPushSubscriptionsStmt:            q.listPushSubscriptionsStmt,
+		listTopPostsStmt:                     q.listTopPostsStmt,
+		listTrashedCommentsStmt:              q.listTrashedCommentsStmt,
+		listTrashedMessagesStmt:              q.listTrashedMessagesStmt,
+		listTrashedPostsStmt:                 q.listTrashedPostsStmt,
+		listTrendingPostsStmt:                q.listTrendingPostsStmt,
+		listTrendingTagsStmt:                 q.listTrendingTagsStmt,
+		listUserIdentitiesStmt:               q.listUserIdentitiesStmt,
+		listUserIdentitiesDueForRotationStmt: q.listUserIdentitiesDueForRotationStmt,
+		listUserSessionsStmt:                 q.listUserSessionsStmt,
+		listUsersStmt:                        q.listUsersStmt,
+		listWebhookDeliveriesStmt:            q.listWebhookDeliveriesStmt,
+		listWebhooksStmt:                     q.listWebhooksStmt,
+		listWebhooksForEventStmt:             q.listWebhooksForEventStmt,
+		markAllNotificationsReadStmt:         q.markAllNotificationsReadStmt,
+		markEmailDigestSentStmt:              q.markEmailDigestSentStmt,
+		markNotificationReadStmt:             q.markNotificationReadStmt,
+		publishDraftPostStmt:                 q.publishDraftPostStmt,
+		publishDuePostsStmt:                  q.publishDuePostsStmt,
+		purgeCommentsStmt:                    q.purgeCommentsStmt,
+		purgeMessagesStmt:                    q.purgeMessagesStmt,
+		purgePostsStmt:                       q.purgePostsStmt,
+		purgeUsersStmt:                       q.purgeUsersStmt,
+		redeliverWebhookDeliveryStmt:         q.redeliverWebhookDeliveryStmt,
+		refreshPostScoresStmt:                q.refreshPostScoresStmt,
+		replaceUserIdentityStmt:              q.replaceUserIdentityStmt,
+		restoreCommentStmt:                   q.restoreCommentStmt,
+		restoreMessageStmt:                   q.restoreMessageStmt,
+		restorePostStmt:                      q.restorePostStmt,
+		restoreUserStmt:                      q.restoreUserStmt,
+		retireUserIdentityStmt:               q.retireUserIdentityStmt,
+		searchCommentsStmt:                   q.searchCommentsStmt,
+		searchPostsStmt:                      q.searchPostsStmt,
+		searchTagsStmt:                       q.searchTagsStmt,
+		setContentPreferencesStmt:            q.setContentPreferencesStmt,
+		setDefaultUserIdentityStmt:           q.setDefaultUserIdentityStmt,
+		setEmailDigestStmt:                   q.setEmailDigestStmt,
+		setNotificationPreferencesStmt:       q.setNotificationPreferencesStmt,
+		setPostContentFlagsStmt:              q.setPostContentFlagsStmt,
+		setPostReactionStmt:                  q.setPostReactionStmt,
+		setPostTagsStmt:                      q.setPostTagsStmt,
+		setUserModeratorStmt:                 q.setUserModeratorStmt,
+		unfollowIdentityStmt:                 q.unfollowIdentityStmt,
+		unfollowUserStmt:                     q.unfollowUserStmt,
+		unsubscribeEmailDigestStmt:           q.unsubscribeEmailDigestStmt,
+		updateCommentStmt:                    q.updateCommentStmt,
+		updateDraftPostStmt:                  q.updateDraftPostStmt,
+		updateMessageStatusStmt:              q.updateMessageStatusStmt,
+		updatePostStmt:                       q.updatePostStmt,
+		updateUserIdentityStmt:               q.updateUserIdentityStmt,
+		updateUserIdentityRotationStmt:       q.updateUserIdentityRotationStmt,
+		updateUserPasswordStmt:               q.updateUserPasswordStmt,
+		updateUserProfileStmt:                q.updateUserProfileStmt,
+		updateUsernameStmt:                   q.updateUsernameStmt,
+		updateWebhookStmt:                    q.updateWebhookStmt,
 	}
 }
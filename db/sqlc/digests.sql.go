@@ -0,0 +1,288 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: digests.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const claimDueEmailDigests = `-- name: ClaimDueEmailDigests :many
+UPDATE "email_digests"
+SET next_send_at = now() + $1::float8 * interval '1 second'
+FROM "users"
+WHERE users.id = email_digests.user_id AND email_digests.user_id IN (
+    SELECT due.user_id
+    FROM "email_digests" AS due
+    JOIN "users" AS due_users ON due_users.id = due.user_id
+    WHERE due.next_send_at <= now() AND due_users.deleted_at IS NULL
+    ORDER BY due.next_send_at
+    LIMIT $2
+    FOR UPDATE OF due SKIP LOCKED
+)
+RETURNING email_digests.user_id, email_digests.email, email_digests.frequency, email_digests.unsubscribe_token, email_digests.last_sent_at, email_digests.next_send_at, email_digests.created_at, email_digests.updated_at, users.username
+`
+
+type ClaimDueEmailDigestsParams struct {
+	LeaseSeconds float64 `json:"lease_seconds"`
+	BatchSize    int32   `json:"batch_size"`
+}
+
+type ClaimDueEmailDigestsRow struct {
+	UserID           uuid.UUID  `json:"user_id"`
+	Email            string     `json:"email"`
+	Frequency        string     `json:"frequency"`
+	UnsubscribeToken string     `json:"unsubscribe_token"`
+	LastSentAt       *time.Time `json:"last_sent_at"`
+	NextSendAt       time.Time  `json:"next_send_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Username         string     `json:"username"`
+}
+
+// takes the due digests of accounts that are not being deleted, moving them past the lease so a server that dies
+// while sending leaves them to be retried. SKIP LOCKED lets several servers share the work.
+func (q *Queries) ClaimDueEmailDigests(ctx context.Context, arg ClaimDueEmailDigestsParams) ([]ClaimDueEmailDigestsRow, error) {
+	rows, err := q.query(ctx, q.claimDueEmailDigestsStmt, claimDueEmailDigests, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueEmailDigestsRow
+	for rows.Next() {
+		var i ClaimDueEmailDigestsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Email,
+			&i.Frequency,
+			&i.UnsubscribeToken,
+			&i.LastSentAt,
+			&i.NextSendAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countDigestFollowers = `-- name: CountDigestFollowers :one
+SELECT count(*)
+FROM "follows"
+WHERE follows.created_at >= $1::timestamptz AND (
+    follows.followee_user_id = $2::uuid
+    OR follows.followee_identity_id IN (
+        SELECT id FROM "user_identities" WHERE user_identities.user_id = $2::uuid
+    )
+)
+`
+
+type CountDigestFollowersParams struct {
+	Since  time.Time `json:"since"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// new follows of the user or any of their identities
+func (q *Queries) CountDigestFollowers(ctx context.Context, arg CountDigestFollowersParams) (int64, error) {
+	row := q.queryRow(ctx, q.countDigestFollowersStmt, countDigestFollowers, arg.Since, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countDigestMessages = `-- name: CountDigestMessages :one
+SELECT count(*) AS unread, count(*) FILTER (WHERE created_at >= $1::timestamptz) AS new
+FROM "messages"
+WHERE receiver_id = $2 AND NOT seen AND deleted_at IS NULL
+`
+
+type CountDigestMessagesParams struct {
+	Since  time.Time `json:"since"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type CountDigestMessagesRow struct {
+	Unread int64 `json:"unread"`
+	New    int64 `json:"new"`
+}
+
+func (q *Queries) CountDigestMessages(ctx context.Context, arg CountDigestMessagesParams) (CountDigestMessagesRow, error) {
+	row := q.queryRow(ctx, q.countDigestMessagesStmt, countDigestMessages, arg.Since, arg.UserID)
+	var i CountDigestMessagesRow
+	err := row.Scan(&i.Unread, &i.New)
+	return i, err
+}
+
+const deleteEmailDigest = `-- name: DeleteEmailDigest :one
+DELETE FROM "email_digests" WHERE user_id = $1 RETURNING user_id
+`
+
+func (q *Queries) DeleteEmailDigest(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.deleteEmailDigestStmt, deleteEmailDigest, userID)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getEmailDigest = `-- name: GetEmailDigest :one
+SELECT user_id, email, frequency, unsubscribe_token, last_sent_at, next_send_at, created_at, updated_at FROM "email_digests" WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetEmailDigest(ctx context.Context, userID uuid.UUID) (EmailDigest, error) {
+	row := q.queryRow(ctx, q.getEmailDigestStmt, getEmailDigest, userID)
+	var i EmailDigest
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.Frequency,
+		&i.UnsubscribeToken,
+		&i.LastSentAt,
+		&i.NextSendAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDigestTopComments = `-- name: ListDigestTopComments :many
+SELECT comments.id, comments.content, comments.post_id, posts.content AS post_content, count(replies.id) AS replies
+FROM "comments"
+JOIN "posts" ON posts.id = comments.post_id
+JOIN "user_identities" AS authors ON authors.id = posts.user_identity_id
+JOIN "user_identities" AS commenters ON commenters.id = comments.user_identity_id
+LEFT JOIN "comments" AS replies
+    ON replies.parent_id = comments.id AND replies.id <> comments.id AND replies.deleted_at IS NULL
+WHERE authors.user_id = $1::uuid
+    AND commenters.user_id IS DISTINCT FROM $1::uuid
+    AND comments.created_at >= $2::timestamptz
+    AND NOT comment_trashed(comments.id)
+    AND NOT hidden_from($1::uuid, comments.user_identity_id, comments.content)
+GROUP BY comments.id, posts.id
+ORDER BY replies DESC, comments.created_at DESC, comments.id
+LIMIT $3
+`
+
+type ListDigestTopCommentsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Since  time.Time `json:"since"`
+	Top    int32     `json:"top"`
+}
+
+type ListDigestTopCommentsRow struct {
+	ID          uuid.UUID `json:"id"`
+	Content     string    `json:"content"`
+	PostID      uuid.UUID `json:"post_id"`
+	PostContent string    `json:"post_content"`
+	Replies     int64     `json:"replies"`
+}
+
+// the comments others left on the posts of the user since the last digest, the most replied to first
+func (q *Queries) ListDigestTopComments(ctx context.Context, arg ListDigestTopCommentsParams) ([]ListDigestTopCommentsRow, error) {
+	rows, err := q.query(ctx, q.listDigestTopCommentsStmt, listDigestTopComments, arg.UserID, arg.Since, arg.Top)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDigestTopCommentsRow
+	for rows.Next() {
+		var i ListDigestTopCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.PostID,
+			&i.PostContent,
+			&i.Replies,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEmailDigestSent = `-- name: MarkEmailDigestSent :exec
+UPDATE "email_digests"
+SET last_sent_at = now(), next_send_at = now() + digest_period(frequency)
+WHERE user_id = $1
+`
+
+func (q *Queries) MarkEmailDigestSent(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.exec(ctx, q.markEmailDigestSentStmt, markEmailDigestSent, userID)
+	return err
+}
+
+const setEmailDigest = `-- name: SetEmailDigest :one
+INSERT INTO "email_digests" (
+    user_id, email, frequency, unsubscribe_token, next_send_at
+) VALUES (
+    $1, $2, $3, $4,
+    now() + digest_period($3)
+)
+ON CONFLICT (user_id) DO UPDATE
+SET email = excluded.email,
+    frequency = excluded.frequency,
+    next_send_at = coalesce(email_digests.last_sent_at, now()) + digest_period(excluded.frequency),
+    updated_at = now()
+RETURNING user_id, email, frequency, unsubscribe_token, last_sent_at, next_send_at, created_at, updated_at
+`
+
+type SetEmailDigestParams struct {
+	UserID           uuid.UUID `json:"user_id"`
+	Email            string    `json:"email"`
+	Frequency        string    `json:"frequency"`
+	UnsubscribeToken string    `json:"unsubscribe_token"`
+}
+
+// opts in or changes the settings, the next digest is one period after the last one or from now on the first
+func (q *Queries) SetEmailDigest(ctx context.Context, arg SetEmailDigestParams) (EmailDigest, error) {
+	row := q.queryRow(ctx, q.setEmailDigestStmt, setEmailDigest,
+		arg.UserID,
+		arg.Email,
+		arg.Frequency,
+		arg.UnsubscribeToken,
+	)
+	var i EmailDigest
+	err := row.Scan(
+		&i.UserID,
+		&i.Email,
+		&i.Frequency,
+		&i.UnsubscribeToken,
+		&i.LastSentAt,
+		&i.NextSendAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const unsubscribeEmailDigest = `-- name: UnsubscribeEmailDigest :execrows
+DELETE FROM "email_digests" WHERE unsubscribe_token = $1
+`
+
+func (q *Queries) UnsubscribeEmailDigest(ctx context.Context, unsubscribeToken string) (int64, error) {
+	result, err := q.exec(ctx, q.unsubscribeEmailDigestStmt, unsubscribeEmailDigest, unsubscribeToken)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
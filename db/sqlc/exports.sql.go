@@ -0,0 +1,429 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: exports.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimDataExport = `-- name: ClaimDataExport :one
+UPDATE "data_exports"
+SET status = 'running', started_at = now()
+WHERE id = (
+    SELECT data_exports.id
+    FROM "data_exports"
+    WHERE data_exports.status = 'pending'
+        OR (data_exports.status = 'running' AND data_exports.started_at < now() - $1::float8 * interval '1 second')
+    ORDER BY data_exports.created_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+`
+
+// takes the oldest pending export, or one whose worker died while building it.
+// SKIP LOCKED lets several servers work through the queue without building an export twice.
+func (q *Queries) ClaimDataExport(ctx context.Context, staleSeconds float64) (DataExport, error) {
+	row := q.queryRow(ctx, q.claimDataExportStmt, claimDataExport, staleSeconds)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Format,
+		&i.Status,
+		&i.StorageKey,
+		&i.Size,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const completeDataExport = `-- name: CompleteDataExport :one
+UPDATE "data_exports"
+SET status = 'done', storage_key = $1, size = $2, completed_at = now(),
+    expires_at = now() + $3::float8 * interval '1 second'
+WHERE id = $4
+RETURNING id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+`
+
+type CompleteDataExportParams struct {
+	StorageKey string    `json:"storage_key"`
+	Size       int64     `json:"size"`
+	TtlSeconds float64   `json:"ttl_seconds"`
+	ID         uuid.UUID `json:"id"`
+}
+
+func (q *Queries) CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) (DataExport, error) {
+	row := q.queryRow(ctx, q.completeDataExportStmt, completeDataExport,
+		arg.StorageKey,
+		arg.Size,
+		arg.TtlSeconds,
+		arg.ID,
+	)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Format,
+		&i.Status,
+		&i.StorageKey,
+		&i.Size,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createDataExport = `-- name: CreateDataExport :one
+INSERT INTO "data_exports" (
+    id, user_id, format
+) VALUES (
+    $1, $2, $3
+) RETURNING id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+`
+
+type CreateDataExportParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Format string    `json:"format"`
+}
+
+func (q *Queries) CreateDataExport(ctx context.Context, arg CreateDataExportParams) (DataExport, error) {
+	row := q.queryRow(ctx, q.createDataExportStmt, createDataExport, arg.ID, arg.UserID, arg.Format)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Format,
+		&i.Status,
+		&i.StorageKey,
+		&i.Size,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const deleteExpiredDataExports = `-- name: DeleteExpiredDataExports :many
+DELETE FROM "data_exports"
+WHERE expires_at < now()
+RETURNING id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+`
+
+// the archives are removed from storage by the caller
+func (q *Queries) DeleteExpiredDataExports(ctx context.Context) ([]DataExport, error) {
+	rows, err := q.query(ctx, q.deleteExpiredDataExportsStmt, deleteExpiredDataExports)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DataExport
+	for rows.Next() {
+		var i DataExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Format,
+			&i.Status,
+			&i.StorageKey,
+			&i.Size,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserComments = `-- name: ExportUserComments :many
+SELECT comments.id, comments.content, comments.user_identity_id, comments.post_id, comments.parent_id, comments.created_at, comments.updated_at, comments.search_vector, comments.revision, comments.edited, comments.deleted_at
+FROM "comments"
+JOIN user_identities ON user_identities.id = comments.user_identity_id
+WHERE user_identities.user_id = $1::uuid
+ORDER BY comments.created_at, comments.id
+`
+
+func (q *Queries) ExportUserComments(ctx context.Context, userID uuid.UUID) ([]Comment, error) {
+	rows, err := q.query(ctx, q.exportUserCommentsStmt, exportUserComments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Comment
+	for rows.Next() {
+		var i Comment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.PostID,
+			&i.ParentID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserMessages = `-- name: ExportUserMessages :many
+SELECT id, receiver_id, content, seen, created_at, updated_at, deleted_at
+FROM "messages"
+WHERE receiver_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ExportUserMessages(ctx context.Context, receiverID uuid.UUID) ([]Message, error) {
+	rows, err := q.query(ctx, q.exportUserMessagesStmt, exportUserMessages, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReceiverID,
+			&i.Content,
+			&i.Seen,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserPosts = `-- name: ExportUserPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = $1::uuid
+ORDER BY posts.created_at, posts.id
+`
+
+// every post under the identities still linked to the user, anonymous and deleted ones included
+func (q *Queries) ExportUserPosts(ctx context.Context, userID uuid.UUID) ([]Post, error) {
+	rows, err := q.query(ctx, q.exportUserPostsStmt, exportUserPosts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const failDataExport = `-- name: FailDataExport :exec
+UPDATE "data_exports"
+SET status = 'failed', error = $1, completed_at = now()
+WHERE id = $2
+`
+
+type FailDataExportParams struct {
+	Error string    `json:"error"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func (q *Queries) FailDataExport(ctx context.Context, arg FailDataExportParams) error {
+	_, err := q.exec(ctx, q.failDataExportStmt, failDataExport, arg.Error, arg.ID)
+	return err
+}
+
+const getDataExport = `-- name: GetDataExport :one
+SELECT id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+FROM "data_exports"
+WHERE id = $1 AND user_id = $2
+LIMIT 1
+`
+
+type GetDataExportParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetDataExport(ctx context.Context, arg GetDataExportParams) (DataExport, error) {
+	row := q.queryRow(ctx, q.getDataExportStmt, getDataExport, arg.ID, arg.UserID)
+	var i DataExport
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Format,
+		&i.Status,
+		&i.StorageKey,
+		&i.Size,
+		&i.Error,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const listDataExports = `-- name: ListDataExports :many
+SELECT id, user_id, format, status, storage_key, size, error, created_at, started_at, completed_at, expires_at
+FROM "data_exports"
+WHERE user_id = $1
+ORDER BY created_at DESC, id
+LIMIT 20
+`
+
+func (q *Queries) ListDataExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error) {
+	rows, err := q.query(ctx, q.listDataExportsStmt, listDataExports, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DataExport
+	for rows.Next() {
+		var i DataExport
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Format,
+			&i.Status,
+			&i.StorageKey,
+			&i.Size,
+			&i.Error,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, user_agent, client_ip, is_blocked, created_at, expires_at
+FROM "sessions"
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+type ListUserSessionsRow struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// leaves the refresh tokens out, they are secrets even to their owner
+func (q *Queries) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]ListUserSessionsRow, error) {
+	rows, err := q.query(ctx, q.listUserSessionsStmt, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,233 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: follows.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const followIdentity = `-- name: FollowIdentity :exec
+INSERT INTO "follows" (
+    id, follower_id, followee_identity_id
+) VALUES (
+    $1, $2, $3::uuid
+) ON CONFLICT DO NOTHING
+`
+
+type FollowIdentityParams struct {
+	ID                 uuid.UUID `json:"id"`
+	FollowerID         uuid.UUID `json:"follower_id"`
+	FolloweeIdentityID uuid.UUID `json:"followee_identity_id"`
+}
+
+func (q *Queries) FollowIdentity(ctx context.Context, arg FollowIdentityParams) error {
+	_, err := q.exec(ctx, q.followIdentityStmt, followIdentity, arg.ID, arg.FollowerID, arg.FolloweeIdentityID)
+	return err
+}
+
+const followUser = `-- name: FollowUser :exec
+INSERT INTO "follows" (
+    id, follower_id, followee_user_id
+) VALUES (
+    $1, $2, $3::uuid
+) ON CONFLICT DO NOTHING
+`
+
+type FollowUserParams struct {
+	ID             uuid.UUID `json:"id"`
+	FollowerID     uuid.UUID `json:"follower_id"`
+	FolloweeUserID uuid.UUID `json:"followee_user_id"`
+}
+
+func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) error {
+	_, err := q.exec(ctx, q.followUserStmt, followUser, arg.ID, arg.FollowerID, arg.FolloweeUserID)
+	return err
+}
+
+const listFollowedIdentities = `-- name: ListFollowedIdentities :many
+SELECT follows.followee_identity_id::uuid AS identity_id, follows.created_at AS followed_at
+FROM "follows"
+WHERE follows.follower_id = $1::uuid AND follows.followee_identity_id IS NOT NULL
+ORDER BY follows.created_at DESC, follows.followee_identity_id
+LIMIT 20
+OFFSET $2
+`
+
+type ListFollowedIdentitiesParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+type ListFollowedIdentitiesRow struct {
+	IdentityID uuid.UUID `json:"identity_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (q *Queries) ListFollowedIdentities(ctx context.Context, arg ListFollowedIdentitiesParams) ([]ListFollowedIdentitiesRow, error) {
+	rows, err := q.query(ctx, q.listFollowedIdentitiesStmt, listFollowedIdentities, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowedIdentitiesRow
+	for rows.Next() {
+		var i ListFollowedIdentitiesRow
+		if err := rows.Scan(&i.IdentityID, &i.FollowedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowedUsers = `-- name: ListFollowedUsers :many
+SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at
+FROM "follows"
+JOIN "users" ON users.id = follows.followee_user_id
+WHERE follows.follower_id = $1::uuid AND users.deleted_at IS NULL
+ORDER BY follows.created_at DESC, users.username
+LIMIT 20
+OFFSET $2
+`
+
+type ListFollowedUsersParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+type ListFollowedUsersRow struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarUrl   string    `json:"avatar_url"`
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+func (q *Queries) ListFollowedUsers(ctx context.Context, arg ListFollowedUsersParams) ([]ListFollowedUsersRow, error) {
+	rows, err := q.query(ctx, q.listFollowedUsersStmt, listFollowedUsers, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowedUsersRow
+	for rows.Next() {
+		var i ListFollowedUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowers = `-- name: ListFollowers :many
+SELECT users.id, users.username, users.display_name, users.avatar_url, follows.created_at AS followed_at
+FROM "follows"
+JOIN "users" ON users.id = follows.follower_id
+WHERE follows.followee_user_id = $1::uuid AND users.deleted_at IS NULL
+ORDER BY follows.created_at DESC, users.username
+LIMIT 20
+OFFSET $2
+`
+
+type ListFollowersParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+type ListFollowersRow struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarUrl   string    `json:"avatar_url"`
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+func (q *Queries) ListFollowers(ctx context.Context, arg ListFollowersParams) ([]ListFollowersRow, error) {
+	rows, err := q.query(ctx, q.listFollowersStmt, listFollowers, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFollowersRow
+	for rows.Next() {
+		var i ListFollowersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unfollowIdentity = `-- name: UnfollowIdentity :one
+DELETE FROM "follows"
+WHERE follower_id = $1 AND followee_identity_id = $2::uuid
+RETURNING id
+`
+
+type UnfollowIdentityParams struct {
+	FollowerID         uuid.UUID `json:"follower_id"`
+	FolloweeIdentityID uuid.UUID `json:"followee_identity_id"`
+}
+
+func (q *Queries) UnfollowIdentity(ctx context.Context, arg UnfollowIdentityParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.unfollowIdentityStmt, unfollowIdentity, arg.FollowerID, arg.FolloweeIdentityID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const unfollowUser = `-- name: UnfollowUser :one
+DELETE FROM "follows"
+WHERE follower_id = $1 AND followee_user_id = $2::uuid
+RETURNING id
+`
+
+type UnfollowUserParams struct {
+	FollowerID     uuid.UUID `json:"follower_id"`
+	FolloweeUserID uuid.UUID `json:"followee_user_id"`
+}
+
+func (q *Queries) UnfollowUser(ctx context.Context, arg UnfollowUserParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.unfollowUserStmt, unfollowUser, arg.FollowerID, arg.FolloweeUserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
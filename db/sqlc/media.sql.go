@@ -0,0 +1,273 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: media.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const countMediaByPostId = `-- name: CountMediaByPostId :one
+SELECT count(*)
+FROM "media"
+WHERE post_id = $1
+`
+
+func (q *Queries) CountMediaByPostId(ctx context.Context, postID uuid.NullUUID) (int64, error) {
+	row := q.queryRow(ctx, q.countMediaByPostIdStmt, countMediaByPostId, postID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createMedia = `-- name: CreateMedia :one
+INSERT INTO "media" (
+    id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+`
+
+type CreateMediaParams struct {
+	ID           uuid.UUID     `json:"id"`
+	Kind         string        `json:"kind"`
+	UserID       uuid.NullUUID `json:"user_id"`
+	PostID       uuid.NullUUID `json:"post_id"`
+	MessageID    uuid.NullUUID `json:"message_id"`
+	StorageKey   string        `json:"storage_key"`
+	ThumbnailKey string        `json:"thumbnail_key"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	Width        int32         `json:"width"`
+	Height       int32         `json:"height"`
+	IsPrivate    bool          `json:"is_private"`
+}
+
+func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Media, error) {
+	row := q.queryRow(ctx, q.createMediaStmt, createMedia,
+		arg.ID,
+		arg.Kind,
+		arg.UserID,
+		arg.PostID,
+		arg.MessageID,
+		arg.StorageKey,
+		arg.ThumbnailKey,
+		arg.ContentType,
+		arg.Size,
+		arg.Width,
+		arg.Height,
+		arg.IsPrivate,
+	)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.UserID,
+		&i.PostID,
+		&i.MessageID,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Size,
+		&i.Width,
+		&i.Height,
+		&i.IsPrivate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMedia = `-- name: DeleteMedia :one
+DELETE FROM "media"
+WHERE id = $1
+RETURNING id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+`
+
+func (q *Queries) DeleteMedia(ctx context.Context, id uuid.UUID) (Media, error) {
+	row := q.queryRow(ctx, q.deleteMediaStmt, deleteMedia, id)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.UserID,
+		&i.PostID,
+		&i.MessageID,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Size,
+		&i.Width,
+		&i.Height,
+		&i.IsPrivate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteOldAvatars = `-- name: DeleteOldAvatars :many
+DELETE FROM "media"
+WHERE kind = 'avatar' AND user_id = $1::uuid AND id <> $2
+RETURNING id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+`
+
+type DeleteOldAvatarsParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	KeepID uuid.UUID `json:"keep_id"`
+}
+
+func (q *Queries) DeleteOldAvatars(ctx context.Context, arg DeleteOldAvatarsParams) ([]Media, error) {
+	rows, err := q.query(ctx, q.deleteOldAvatarsStmt, deleteOldAvatars, arg.UserID, arg.KeepID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMediaById = `-- name: GetMediaById :one
+SELECT id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+FROM "media"
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetMediaById(ctx context.Context, id uuid.UUID) (Media, error) {
+	row := q.queryRow(ctx, q.getMediaByIdStmt, getMediaById, id)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.UserID,
+		&i.PostID,
+		&i.MessageID,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.ContentType,
+		&i.Size,
+		&i.Width,
+		&i.Height,
+		&i.IsPrivate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listMediaByMessageId = `-- name: ListMediaByMessageId :many
+SELECT id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+FROM "media"
+WHERE message_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListMediaByMessageId(ctx context.Context, messageID uuid.NullUUID) ([]Media, error) {
+	rows, err := q.query(ctx, q.listMediaByMessageIdStmt, listMediaByMessageId, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaByPostId = `-- name: ListMediaByPostId :many
+SELECT id, kind, user_id, post_id, message_id, storage_key, thumbnail_key, content_type, size, width, height, is_private, created_at
+FROM "media"
+WHERE post_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListMediaByPostId(ctx context.Context, postID uuid.NullUUID) ([]Media, error) {
+	rows, err := q.query(ctx, q.listMediaByPostIdStmt, listMediaByPostId, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -17,7 +17,7 @@ INSERT INTO "messages" (
     id, receiver_id, content, created_at, updated_at
 ) VALUES (
     $1, $2, $3, $4, $5
-) RETURNING id, receiver_id, content, seen, created_at, updated_at
+) RETURNING id, receiver_id, content, seen, created_at, updated_at, deleted_at
 `
 
 type CreateMessageParams struct {
@@ -44,13 +44,15 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.Seen,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteOneMessage = `-- name: DeleteOneMessage :one
-DELETE FROM "messages"
-WHERE id = $1 AND receiver_id = $2
+UPDATE "messages"
+SET deleted_at = now()
+WHERE id = $1 AND receiver_id = $2 AND deleted_at IS NULL
 RETURNING id
 `
 
@@ -59,6 +61,7 @@ type DeleteOneMessageParams struct {
 	ReceiverID uuid.UUID `json:"receiver_id"`
 }
 
+// moves the message to the trash
 func (q *Queries) DeleteOneMessage(ctx context.Context, arg DeleteOneMessageParams) (uuid.UUID, error) {
 	row := q.queryRow(ctx, q.deleteOneMessageStmt, deleteOneMessage, arg.ID, arg.ReceiverID)
 	var id uuid.UUID
@@ -67,9 +70,9 @@ func (q *Queries) DeleteOneMessage(ctx context.Context, arg DeleteOneMessagePara
 }
 
 const getMessageById = `-- name: GetMessageById :one
-SELECT id, receiver_id, content, seen, created_at, updated_at
+SELECT id, receiver_id, content, seen, created_at, updated_at, deleted_at
 FROM "messages"
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -83,14 +86,15 @@ func (q *Queries) GetMessageById(ctx context.Context, id uuid.UUID) (Message, er
 		&i.Seen,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const listMessage = `-- name: ListMessage :many
-SELECT id, receiver_id, content, seen, created_at, updated_at
+SELECT id, receiver_id, content, seen, created_at, updated_at, deleted_at
 FROM "messages"
-WHERE receiver_id = $1
+WHERE receiver_id = $1 AND deleted_at IS NULL
 LIMIT 20
 OFFSET $2
 `
@@ -116,6 +120,97 @@ func (q *Queries) ListMessage(ctx context.Context, arg ListMessageParams) ([]Mes
 			&i.Seen,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedMessages = `-- name: ListTrashedMessages :many
+SELECT id, receiver_id, content, seen, created_at, updated_at, deleted_at
+FROM "messages"
+WHERE receiver_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT 20
+OFFSET $2
+`
+
+type ListTrashedMessagesParams struct {
+	ReceiverID uuid.UUID `json:"receiver_id"`
+	Offset     int32     `json:"offset"`
+}
+
+func (q *Queries) ListTrashedMessages(ctx context.Context, arg ListTrashedMessagesParams) ([]Message, error) {
+	rows, err := q.query(ctx, q.listTrashedMessagesStmt, listTrashedMessages, arg.ReceiverID, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReceiverID,
+			&i.Content,
+			&i.Seen,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeMessages = `-- name: PurgeMessages :many
+WITH purged AS (
+    DELETE FROM "messages" WHERE messages.deleted_at < now() - $1::float8 * interval '1 second' RETURNING messages.id
+)
+SELECT media.id, media.kind, media.user_id, media.post_id, media.message_id, media.storage_key, media.thumbnail_key, media.content_type, media.size, media.width, media.height, media.is_private, media.created_at FROM media WHERE media.message_id IN (SELECT purged.id FROM purged)
+`
+
+// removes the messages trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+func (q *Queries) PurgeMessages(ctx context.Context, retentionSeconds float64) ([]Media, error) {
+	rows, err := q.query(ctx, q.purgeMessagesStmt, purgeMessages, retentionSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -130,10 +225,37 @@ func (q *Queries) ListMessage(ctx context.Context, arg ListMessageParams) ([]Mes
 	return items, nil
 }
 
+const restoreMessage = `-- name: RestoreMessage :one
+UPDATE "messages"
+SET deleted_at = NULL
+WHERE id = $1 AND receiver_id = $2 AND deleted_at IS NOT NULL
+RETURNING id, receiver_id, content, seen, created_at, updated_at, deleted_at
+`
+
+type RestoreMessageParams struct {
+	ID         uuid.UUID `json:"id"`
+	ReceiverID uuid.UUID `json:"receiver_id"`
+}
+
+func (q *Queries) RestoreMessage(ctx context.Context, arg RestoreMessageParams) (Message, error) {
+	row := q.queryRow(ctx, q.restoreMessageStmt, restoreMessage, arg.ID, arg.ReceiverID)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ReceiverID,
+		&i.Content,
+		&i.Seen,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateMessageStatus = `-- name: UpdateMessageStatus :one
 UPDATE "messages"
 SET seen = TRUE, updated_at = $1
-WHERE id = $2 AND receiver_id = $3
+WHERE id = $2 AND receiver_id = $3 AND deleted_at IS NULL
 RETURNING id
 `
 
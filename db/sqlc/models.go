@@ -5,7 +5,9 @@
 package db
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -54,31 +56,210 @@ func (ns NullSatisfaction) Value() (driver.Value, error) {
 	return ns.Satisfaction, nil
 }
 
+type Block struct {
+	ID                uuid.UUID     `json:"id"`
+	UserID            uuid.UUID     `json:"user_id"`
+	BlockedUserID     uuid.NullUUID `json:"blocked_user_id"`
+	BlockedIdentityID uuid.NullUUID `json:"blocked_identity_id"`
+	Kind              string        `json:"kind"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
 type Comment struct {
-	ID             uuid.UUID `json:"id"`
-	Content        string    `json:"content"`
+	ID             uuid.UUID   `json:"id"`
+	Content        string      `json:"content"`
+	UserIdentityID uuid.UUID   `json:"user_identity_id"`
+	PostID         uuid.UUID   `json:"post_id"`
+	ParentID       uuid.UUID   `json:"parent_id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	SearchVector   interface{} `json:"-"`
+	Revision       int32       `json:"revision"`
+	Edited         bool        `json:"edited"`
+	DeletedAt      *time.Time  `json:"deleted_at"`
+}
+
+type CommentRevision struct {
+	CommentID      uuid.UUID `json:"comment_id"`
+	Revision       int32     `json:"revision"`
 	UserIdentityID uuid.UUID `json:"user_identity_id"`
-	PostID         uuid.UUID `json:"post_id"`
-	ParentID       uuid.UUID `json:"parent_id"`
+	Content        string    `json:"content"`
 	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type ContentPreference struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Category string    `json:"category"`
+	Action   string    `json:"action"`
+}
+
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Format      string     `json:"format"`
+	Status      string     `json:"status"`
+	StorageKey  string     `json:"storage_key"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type EmailDigest struct {
+	UserID           uuid.UUID  `json:"user_id"`
+	Email            string     `json:"email"`
+	Frequency        string     `json:"frequency"`
+	UnsubscribeToken string     `json:"-"`
+	LastSentAt       *time.Time `json:"last_sent_at"`
+	NextSendAt       time.Time  `json:"next_send_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type Follow struct {
+	ID                 uuid.UUID     `json:"id"`
+	FollowerID         uuid.UUID     `json:"follower_id"`
+	FolloweeUserID     uuid.NullUUID `json:"followee_user_id"`
+	FolloweeIdentityID uuid.NullUUID `json:"followee_identity_id"`
+	CreatedAt          time.Time     `json:"created_at"`
+}
+
+type Media struct {
+	ID           uuid.UUID     `json:"id"`
+	Kind         string        `json:"kind"`
+	UserID       uuid.NullUUID `json:"user_id"`
+	PostID       uuid.NullUUID `json:"post_id"`
+	MessageID    uuid.NullUUID `json:"message_id"`
+	StorageKey   string        `json:"storage_key"`
+	ThumbnailKey string        `json:"thumbnail_key"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	Width        int32         `json:"width"`
+	Height       int32         `json:"height"`
+	IsPrivate    bool          `json:"is_private"`
+	CreatedAt    time.Time     `json:"created_at"`
 }
 
 type Message struct {
-	ID         uuid.UUID `json:"id"`
-	ReceiverID uuid.UUID `json:"receiver_id"`
-	Content    string    `json:"content"`
-	Seen       bool      `json:"seen"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         uuid.UUID  `json:"id"`
+	ReceiverID uuid.UUID  `json:"receiver_id"`
+	Content    string     `json:"content"`
+	Seen       bool       `json:"seen"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at"`
+}
+
+type MutedKeyword struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Keyword   string    `json:"keyword"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID        uuid.UUID   `json:"id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Type      string      `json:"type"`
+	SubjectID uuid.UUID   `json:"subject_id"`
+	Actors    []uuid.UUID `json:"-"`
+	ReadAt    *time.Time  `json:"read_at"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type NotificationPreference struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Type    string    `json:"type"`
+	Enabled bool      `json:"enabled"`
+}
+
+type Poll struct {
+	PostID    uuid.UUID  `json:"post_id"`
+	Multiple  bool       `json:"multiple"`
+	ClosesAt  *time.Time `json:"closes_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type PollBallot struct {
+	PostID         uuid.UUID `json:"post_id"`
+	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PollOption struct {
+	ID       uuid.UUID `json:"id"`
+	PostID   uuid.UUID `json:"post_id"`
+	Position int32     `json:"position"`
+	Text     string    `json:"text"`
+}
+
+type PollVote struct {
+	PostID         uuid.UUID `json:"post_id"`
+	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	OptionID       uuid.UUID `json:"option_id"`
 }
 
 type Post struct {
-	ID             uuid.UUID `json:"id"`
-	Content        string    `json:"content"`
+	ID               uuid.UUID   `json:"id"`
+	Content          string      `json:"content"`
+	UserIdentityID   uuid.UUID   `json:"user_identity_id"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+	SearchVector     interface{} `json:"-"`
+	Revision         int32       `json:"revision"`
+	Edited           bool        `json:"edited"`
+	DeletedAt        *time.Time  `json:"deleted_at"`
+	Status           string      `json:"status"`
+	PublishAt        *time.Time  `json:"publish_at"`
+	ContentWarnings  []string    `json:"content_warnings"`
+	Nsfw             bool        `json:"nsfw"`
+	FlagsModeratedAt *time.Time  `json:"flags_moderated_at"`
+}
+
+type PostReaction struct {
+	PostID    uuid.UUID    `json:"post_id"`
+	UserID    uuid.UUID    `json:"user_id"`
+	Reaction  Satisfaction `json:"reaction"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type PostRevision struct {
+	PostID         uuid.UUID `json:"post_id"`
+	Revision       int32     `json:"revision"`
 	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	Content        string    `json:"content"`
 	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type PostScore struct {
+	PostID      uuid.UUID `json:"post_id"`
+	Likes       int32     `json:"likes"`
+	Dislikes    int32     `json:"dislikes"`
+	Comments    int32     `json:"comments"`
+	Hot         float64   `json:"hot"`
+	Controversy float64   `json:"controversy"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	Dirty       bool      `json:"dirty"`
+}
+
+type PostTag struct {
+	PostID    uuid.UUID `json:"post_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PushSubscription struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"`
+	Auth      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Session struct {
@@ -93,16 +274,62 @@ type Session struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
+type Tag struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type User struct {
+	ID               uuid.UUID  `json:"id"`
+	Username         string     `json:"username"`
+	Password         string     `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DisplayName      string     `json:"display_name"`
+	Bio              string     `json:"bio"`
+	AvatarUrl        string     `json:"avatar_url"`
+	ConfessionPrompt string     `json:"confession_prompt"`
+	Links            []string   `json:"links"`
+	IsModerator      bool       `json:"is_moderator"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	DeleteAfter      *time.Time `json:"delete_after"`
+}
+
+type UserIdentity struct {
+	ID                   uuid.UUID     `json:"id"`
+	UserID               uuid.NullUUID `json:"user_id"`
+	IdentityHash         uuid.UUID     `json:"identity_hash"`
+	Name                 string        `json:"name"`
+	IsDefault            bool          `json:"is_default"`
+	Retired              bool          `json:"retired"`
+	CreatedAt            time.Time     `json:"created_at"`
+	RotatedAt            time.Time     `json:"rotated_at"`
+	RotationIntervalDays sql.NullInt32 `json:"rotation_interval_days"`
+	RotationDetach       bool          `json:"rotation_detach"`
+	IsPublic             bool          `json:"is_public"`
+}
+
+type Webhook struct {
 	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"`
+	UserID    uuid.UUID `json:"user_id"`
+	Url       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-type UserIdentity struct {
-	ID           uuid.UUID `json:"id"`
-	UserID       uuid.UUID `json:"user_id"`
-	IdentityHash uuid.UUID `json:"identity_hash"`
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	Error          string          `json:"error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
 }
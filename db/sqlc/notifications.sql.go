@@ -0,0 +1,221 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: notifications.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.countUnreadNotificationsStmt, countUnreadNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createNotification = `-- name: CreateNotification :one
+INSERT INTO notifications (id, user_id, type, subject_id, actors)
+SELECT $1, $2, $3, $4, ARRAY[$5::uuid]
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = $2
+        AND notification_preferences.type = $3
+        AND NOT notification_preferences.enabled
+)
+ON CONFLICT (user_id, type, subject_id) WHERE read_at IS NULL DO UPDATE SET
+    actors = CASE
+        WHEN $5::uuid = ANY(notifications.actors) THEN notifications.actors
+        ELSE array_append(notifications.actors, $5::uuid)
+    END,
+    updated_at = now()
+RETURNING id, user_id, type, subject_id, cardinality(actors)::bigint AS count, read_at, created_at, updated_at
+`
+
+type CreateNotificationParams struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Type      string    `json:"type"`
+	SubjectID uuid.UUID `json:"subject_id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+}
+
+type CreateNotificationRow struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Type      string     `json:"type"`
+	SubjectID uuid.UUID  `json:"subject_id"`
+	Count     int64      `json:"count"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// folds the event into the unread notification of the same type and subject, counting each actor once.
+// nothing is written when the user turned the type off.
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (CreateNotificationRow, error) {
+	row := q.queryRow(ctx, q.createNotificationStmt, createNotification,
+		arg.ID,
+		arg.UserID,
+		arg.Type,
+		arg.SubjectID,
+		arg.ActorID,
+	)
+	var i CreateNotificationRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Type,
+		&i.SubjectID,
+		&i.Count,
+		&i.ReadAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listNotificationPreferences = `-- name: ListNotificationPreferences :many
+SELECT user_id, type, enabled FROM notification_preferences WHERE user_id = $1 ORDER BY type
+`
+
+func (q *Queries) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error) {
+	rows, err := q.query(ctx, q.listNotificationPreferencesStmt, listNotificationPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationPreference
+	for rows.Next() {
+		var i NotificationPreference
+		if err := rows.Scan(&i.UserID, &i.Type, &i.Enabled); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifications = `-- name: ListNotifications :many
+SELECT id, user_id, type, subject_id, cardinality(actors)::bigint AS count, read_at, created_at, updated_at
+FROM notifications
+WHERE user_id = $1 AND (NOT $2::bool OR read_at IS NULL)
+ORDER BY updated_at DESC, id
+LIMIT 20
+OFFSET $3
+`
+
+type ListNotificationsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	UnreadOnly bool      `json:"unread_only"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+type ListNotificationsRow struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Type      string     `json:"type"`
+	SubjectID uuid.UUID  `json:"subject_id"`
+	Count     int64      `json:"count"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// the notifications of the user, latest activity first, with the number of actors folded into each
+func (q *Queries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error) {
+	rows, err := q.query(ctx, q.listNotificationsStmt, listNotifications, arg.UserID, arg.UnreadOnly, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotificationsRow
+	for rows.Next() {
+		var i ListNotificationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.SubjectID,
+			&i.Count,
+			&i.ReadAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.markAllNotificationsReadStmt, markAllNotificationsRead, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :one
+UPDATE notifications SET read_at = coalesce(read_at, now())
+WHERE id = $1 AND user_id = $2
+RETURNING id
+`
+
+type MarkNotificationReadParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// marking a read notification again keeps the time it was first read
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.markNotificationReadStmt, markNotificationRead, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setNotificationPreferences = `-- name: SetNotificationPreferences :exec
+INSERT INTO notification_preferences (user_id, type, enabled)
+SELECT $1::uuid, unnest($2::varchar[]), unnest($3::bool[])
+ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled
+`
+
+type SetNotificationPreferencesParams struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Types   []string  `json:"types"`
+	Enabled []bool    `json:"enabled"`
+}
+
+// the types and enabled flags pair up by position, types left out keep their setting
+func (q *Queries) SetNotificationPreferences(ctx context.Context, arg SetNotificationPreferencesParams) error {
+	_, err := q.exec(ctx, q.setNotificationPreferencesStmt, setNotificationPreferences, arg.UserID, pq.Array(arg.Types), pq.Array(arg.Enabled))
+	return err
+}
@@ -0,0 +1,215 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: polls.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+const castPollBallot = `-- name: CastPollBallot :execrows
+WITH ballot AS (
+    INSERT INTO poll_ballots (post_id, user_identity_id)
+    SELECT polls.post_id, $2::uuid
+    FROM polls
+    WHERE polls.post_id = $3::uuid AND (polls.closes_at IS NULL OR polls.closes_at > now())
+        AND NOT EXISTS (
+            SELECT 1 FROM poll_ballots
+            JOIN user_identities ON user_identities.id = poll_ballots.user_identity_id
+            WHERE poll_ballots.post_id = polls.post_id AND user_identities.user_id = $4::uuid
+                AND poll_ballots.user_identity_id <> $2::uuid
+        )
+    RETURNING poll_ballots.post_id, poll_ballots.user_identity_id
+)
+INSERT INTO poll_votes (post_id, user_identity_id, option_id)
+SELECT ballot.post_id, ballot.user_identity_id, unnest($1::uuid[])
+FROM ballot
+`
+
+type CastPollBallotParams struct {
+	OptionIds      []uuid.UUID `json:"option_ids"`
+	UserIdentityID uuid.UUID   `json:"user_identity_id"`
+	PostID         uuid.UUID   `json:"post_id"`
+	UserID         uuid.UUID   `json:"user_id"`
+}
+
+// nothing is cast once the poll is closed or when another identity of the user voted in it already, a second
+// ballot of the identity violates the primary key
+func (q *Queries) CastPollBallot(ctx context.Context, arg CastPollBallotParams) (int64, error) {
+	result, err := q.exec(ctx, q.castPollBallotStmt, castPollBallot,
+		pq.Array(arg.OptionIds),
+		arg.UserIdentityID,
+		arg.PostID,
+		arg.UserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createPoll = `-- name: CreatePoll :one
+INSERT INTO polls (post_id, multiple, closes_at)
+VALUES ($1, $2, $3)
+RETURNING post_id, multiple, closes_at, created_at
+`
+
+type CreatePollParams struct {
+	PostID   uuid.UUID  `json:"post_id"`
+	Multiple bool       `json:"multiple"`
+	ClosesAt *time.Time `json:"closes_at"`
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error) {
+	row := q.queryRow(ctx, q.createPollStmt, createPoll, arg.PostID, arg.Multiple, arg.ClosesAt)
+	var i Poll
+	err := row.Scan(
+		&i.PostID,
+		&i.Multiple,
+		&i.ClosesAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPollOptions = `-- name: CreatePollOptions :exec
+INSERT INTO poll_options (id, post_id, position, text)
+SELECT ids.id, $1::uuid, ids.position, texts.text
+FROM unnest($2::uuid[]) WITH ORDINALITY AS ids (id, position)
+JOIN unnest($3::varchar[]) WITH ORDINALITY AS texts (text, position) ON texts.position = ids.position
+`
+
+type CreatePollOptionsParams struct {
+	PostID uuid.UUID   `json:"post_id"`
+	Ids    []uuid.UUID `json:"ids"`
+	Texts  []string    `json:"texts"`
+}
+
+// the options are numbered in the order given
+func (q *Queries) CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error {
+	_, err := q.exec(ctx, q.createPollOptionsStmt, createPollOptions, arg.PostID, pq.Array(arg.Ids), pq.Array(arg.Texts))
+	return err
+}
+
+const listPollOptions = `-- name: ListPollOptions :many
+SELECT
+    poll_options.id, poll_options.post_id, poll_options.position, poll_options.text,
+    count(poll_votes.option_id) AS votes,
+    coalesce(bool_or(user_identities.user_id = $1::uuid), false)::bool AS voted
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+LEFT JOIN user_identities ON user_identities.id = poll_votes.user_identity_id
+WHERE poll_options.post_id = ANY($2::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.post_id, poll_options.position
+`
+
+type ListPollOptionsParams struct {
+	ViewerID uuid.UUID   `json:"viewer_id"`
+	PostIds  []uuid.UUID `json:"post_ids"`
+}
+
+type ListPollOptionsRow struct {
+	ID       uuid.UUID `json:"id"`
+	PostID   uuid.UUID `json:"post_id"`
+	Position int32     `json:"position"`
+	Text     string    `json:"text"`
+	Votes    int64     `json:"votes"`
+	Voted    bool      `json:"voted"`
+}
+
+// the options of the polls with their vote counts, voted tells whether the viewer chose the option
+func (q *Queries) ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error) {
+	rows, err := q.query(ctx, q.listPollOptionsStmt, listPollOptions, arg.ViewerID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollOptionsRow
+	for rows.Next() {
+		var i ListPollOptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.Position,
+			&i.Text,
+			&i.Votes,
+			&i.Voted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPolls = `-- name: ListPolls :many
+SELECT
+    polls.post_id, polls.multiple, polls.closes_at,
+    (polls.closes_at IS NOT NULL AND polls.closes_at <= now())::bool AS closed,
+    (SELECT count(*) FROM poll_ballots WHERE poll_ballots.post_id = polls.post_id) AS voters,
+    EXISTS (
+        SELECT 1 FROM poll_ballots
+        JOIN user_identities ON user_identities.id = poll_ballots.user_identity_id
+        WHERE poll_ballots.post_id = polls.post_id AND user_identities.user_id = $1::uuid
+    ) AS voted
+FROM polls
+WHERE polls.post_id = ANY($2::uuid[])
+`
+
+type ListPollsParams struct {
+	ViewerID uuid.UUID   `json:"viewer_id"`
+	PostIds  []uuid.UUID `json:"post_ids"`
+}
+
+type ListPollsRow struct {
+	PostID   uuid.UUID  `json:"post_id"`
+	Multiple bool       `json:"multiple"`
+	ClosesAt *time.Time `json:"closes_at"`
+	Closed   bool       `json:"closed"`
+	Voters   int64      `json:"voters"`
+	Voted    bool       `json:"voted"`
+}
+
+// the polls of the posts, voted tells whether the viewer voted under any of their identities
+func (q *Queries) ListPolls(ctx context.Context, arg ListPollsParams) ([]ListPollsRow, error) {
+	rows, err := q.query(ctx, q.listPollsStmt, listPolls, arg.ViewerID, pq.Array(arg.PostIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollsRow
+	for rows.Next() {
+		var i ListPollsRow
+		if err := rows.Scan(
+			&i.PostID,
+			&i.Multiple,
+			&i.ClosesAt,
+			&i.Closed,
+			&i.Voters,
+			&i.Voted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
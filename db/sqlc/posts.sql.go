@@ -9,20 +9,39 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
 )
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, content, user_identity_id) VALUES ($1, $2, $3) RETURNING id, content, user_identity_id, created_at, updated_at
+INSERT INTO posts (id, content, user_identity_id, status, publish_at, content_warnings, nsfw)
+VALUES (
+    $1, $2, $3, $4, $5,
+    $6::varchar[], $7
+)
+RETURNING id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at
 `
 
 type CreatePostParams struct {
-	ID             uuid.UUID `json:"id"`
-	Content        string    `json:"content"`
-	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	ID              uuid.UUID  `json:"id"`
+	Content         string     `json:"content"`
+	UserIdentityID  uuid.UUID  `json:"user_identity_id"`
+	Status          string     `json:"status"`
+	PublishAt       *time.Time `json:"publish_at"`
+	ContentWarnings []string   `json:"content_warnings"`
+	Nsfw            bool       `json:"nsfw"`
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.queryRow(ctx, q.createPostStmt, createPost, arg.ID, arg.Content, arg.UserIdentityID)
+	row := q.queryRow(ctx, q.createPostStmt, createPost,
+		arg.ID,
+		arg.Content,
+		arg.UserIdentityID,
+		arg.Status,
+		arg.PublishAt,
+		pq.Array(arg.ContentWarnings),
+		arg.Nsfw,
+	)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -30,22 +49,58 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.UserIdentityID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
 	)
 	return i, err
 }
 
 const deletePost = `-- name: DeletePost :one
-DELETE FROM posts WHERE id = $1 RETURNING id
+UPDATE posts SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING id
 `
 
+// moves the post to the trash, its comments are hidden along with it
 func (q *Queries) DeletePost(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
 	row := q.queryRow(ctx, q.deletePostStmt, deletePost, id)
 	err := row.Scan(&id)
 	return id, err
 }
 
+const getDraftPost = `-- name: GetDraftPost :one
+SELECT id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at FROM posts WHERE id = $1 AND status <> 'published' AND deleted_at IS NULL LIMIT 1
+`
+
+func (q *Queries) GetDraftPost(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.queryRow(ctx, q.getDraftPostStmt, getDraftPost, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
+	)
+	return i, err
+}
+
 const getPostById = `-- name: GetPostById :one
-SELECT id, content, user_identity_id, created_at, updated_at FROM posts WHERE id = $1 LIMIT 1
+SELECT id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at FROM posts WHERE id = $1 AND deleted_at IS NULL AND status = 'published' AND NOT author_deleted(user_identity_id) LIMIT 1
 `
 
 func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (Post, error) {
@@ -57,16 +112,371 @@ func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (Post, error) {
 		&i.UserIdentityID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
+	)
+	return i, err
+}
+
+const getPostByIdIncludingDeleted = `-- name: GetPostByIdIncludingDeleted :one
+SELECT id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at FROM posts WHERE id = $1 LIMIT 1
+`
+
+// the post whatever became of it, for the revisions its author and moderators can read
+func (q *Queries) GetPostByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.queryRow(ctx, q.getPostByIdIncludingDeletedStmt, getPostByIdIncludingDeleted, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
 	)
 	return i, err
 }
 
+const hasFeedPosts = `-- name: HasFeedPosts :one
+SELECT EXISTS (
+    SELECT 1
+    FROM posts
+    JOIN user_identities ON user_identities.id = posts.user_identity_id
+    WHERE (
+        posts.user_identity_id IN (
+            SELECT followee_identity_id FROM follows WHERE follower_id = $1::uuid
+        ) OR (
+            user_identities.is_public AND user_identities.user_id IN (
+                SELECT followee_user_id FROM follows WHERE follower_id = $1::uuid
+            )
+        )
+    ) AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($1::uuid, posts.user_identity_id, posts.content)
+        AND NOT hidden_by_preference($1::uuid, posts.content_warnings, posts.nsfw)
+)
+`
+
+func (q *Queries) HasFeedPosts(ctx context.Context, userID uuid.UUID) (bool, error) {
+	row := q.queryRow(ctx, q.hasFeedPostsStmt, hasFeedPosts, userID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listAllPosts = `-- name: ListAllPosts :many
-SELECT id, content, user_identity_id, created_at, updated_at FROM posts ORDER BY created_at DESC LIMIT 20 OFFSET $1
+SELECT id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at FROM posts
+WHERE deleted_at IS NULL AND status = 'published' AND NOT hidden_from($1::uuid, user_identity_id, content)
+    AND NOT hidden_by_preference($1::uuid, content_warnings, nsfw)
+ORDER BY created_at DESC
+LIMIT 20
+OFFSET $2
+`
+
+type ListAllPostsParams struct {
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListAllPosts(ctx context.Context, arg ListAllPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listAllPostsStmt, listAllPosts, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listControversialPosts = `-- name: ListControversialPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE ($1::int = 0 OR posts.created_at >= now() - $1::int * interval '1 day')
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($2::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($2::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.controversy, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET $3
+`
+
+type ListControversialPostsParams struct {
+	Days       int32     `json:"days"`
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListControversialPosts(ctx context.Context, arg ListControversialPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listControversialPostsStmt, listControversialPosts, arg.Days, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDraftPosts = `-- name: ListDraftPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = $1::uuid AND posts.status <> 'published' AND posts.deleted_at IS NULL
+ORDER BY posts.publish_at NULLS LAST, posts.updated_at DESC, posts.id
+LIMIT 20
+OFFSET $2
+`
+
+type ListDraftPostsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+// the drafts and scheduled posts of the user, next to be published first
+func (q *Queries) ListDraftPosts(ctx context.Context, arg ListDraftPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listDraftPostsStmt, listDraftPosts, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedPosts = `-- name: ListFeedPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE (
+    posts.user_identity_id IN (
+        SELECT followee_identity_id FROM follows WHERE follower_id = $1::uuid
+    ) OR (
+        user_identities.is_public AND user_identities.user_id IN (
+            SELECT followee_user_id FROM follows WHERE follower_id = $1::uuid
+        )
+    )
+) AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($1::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($1::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC
+LIMIT 20
+OFFSET $2
+`
+
+type ListFeedPostsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+// posts of followed identities, and of followed users under their public identities only
+func (q *Queries) ListFeedPosts(ctx context.Context, arg ListFeedPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listFeedPostsStmt, listFeedPosts, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHotPosts = `-- name: ListHotPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($1::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($1::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.hot, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET $2
+`
+
+type ListHotPostsParams struct {
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+// posts the score refresher hasn't seen yet sort as if they had no reactions
+func (q *Queries) ListHotPosts(ctx context.Context, arg ListHotPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listHotPostsStmt, listHotPosts, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublicPostsByUserId = `-- name: ListPublicPostsByUserId :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = $1::uuid AND user_identities.is_public = true
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT author_deleted(posts.user_identity_id)
+    AND NOT hidden_by_preference($2::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC
+LIMIT 20
+OFFSET $3
 `
 
-func (q *Queries) ListAllPosts(ctx context.Context, offset int32) ([]Post, error) {
-	rows, err := q.query(ctx, q.listAllPostsStmt, listAllPosts, offset)
+type ListPublicPostsByUserIdParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListPublicPostsByUserId(ctx context.Context, arg ListPublicPostsByUserIdParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listPublicPostsByUserIdStmt, listPublicPostsByUserId, arg.UserID, arg.ViewerID, arg.PageOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +490,15 @@ func (q *Queries) ListAllPosts(ctx context.Context, offset int32) ([]Post, error
 			&i.UserIdentityID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -94,18 +513,416 @@ func (q *Queries) ListAllPosts(ctx context.Context, offset int32) ([]Post, error
 	return items, nil
 }
 
+const listTopPosts = `-- name: ListTopPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+LEFT JOIN post_scores ON post_scores.post_id = posts.id
+WHERE ($1::int = 0 OR posts.created_at >= now() - $1::int * interval '1 day')
+    AND posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($2::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($2::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY coalesce(post_scores.likes - post_scores.dislikes, 0) DESC, posts.created_at DESC, posts.id
+LIMIT 20
+OFFSET $3
+`
+
+type ListTopPostsParams struct {
+	Days       int32     `json:"days"`
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+// the posts with the most net likes created in the last days, days 0 means all time
+func (q *Queries) ListTopPosts(ctx context.Context, arg ListTopPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listTopPostsStmt, listTopPosts, arg.Days, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedPosts = `-- name: ListTrashedPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN user_identities ON user_identities.id = posts.user_identity_id
+WHERE user_identities.user_id = $1::uuid AND posts.deleted_at IS NOT NULL
+ORDER BY posts.deleted_at DESC, posts.id
+LIMIT 20
+OFFSET $2
+`
+
+type ListTrashedPostsParams struct {
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListTrashedPosts(ctx context.Context, arg ListTrashedPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listTrashedPostsStmt, listTrashedPosts, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrendingPosts = `-- name: ListTrendingPosts :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+LEFT JOIN comments ON comments.post_id = posts.id AND comments.created_at >= now() - interval '7 days'
+    AND comments.deleted_at IS NULL
+WHERE posts.deleted_at IS NULL AND posts.status = 'published' AND NOT hidden_from($1::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($1::uuid, posts.content_warnings, posts.nsfw)
+GROUP BY posts.id
+ORDER BY count(comments.id) DESC, posts.created_at DESC
+LIMIT 20
+OFFSET $2
+`
+
+type ListTrendingPostsParams struct {
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+// ranked by the number of comments in the last week
+func (q *Queries) ListTrendingPosts(ctx context.Context, arg ListTrendingPostsParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listTrendingPostsStmt, listTrendingPosts, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDraftPost = `-- name: PublishDraftPost :one
+WITH post AS (
+    UPDATE posts SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+    WHERE posts.id = $1 AND posts.status <> 'published' AND posts.deleted_at IS NULL
+    RETURNING posts.id, posts.revision, posts.user_identity_id, posts.content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, post.user_identity_id, post.content FROM post
+RETURNING post_id
+`
+
+// the post is dated from its publication, and its revision history starts there
+func (q *Queries) PublishDraftPost(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.publishDraftPostStmt, publishDraftPost, id)
+	var post_id uuid.UUID
+	err := row.Scan(&post_id)
+	return post_id, err
+}
+
+const publishDuePosts = `-- name: PublishDuePosts :many
+WITH due AS (
+    SELECT posts.id FROM posts
+    WHERE posts.status = 'scheduled' AND posts.publish_at <= now() AND posts.deleted_at IS NULL
+    ORDER BY posts.publish_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+), post AS (
+    UPDATE posts SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+    WHERE posts.id IN (SELECT due.id FROM due)
+    RETURNING posts.id, posts.revision, posts.user_identity_id, posts.content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, post.user_identity_id, post.content FROM post
+RETURNING post_id
+`
+
+// the row locks are skipped rather than waited on, so concurrent schedulers each publish a post once
+func (q *Queries) PublishDuePosts(ctx context.Context, batchSize int32) ([]uuid.UUID, error) {
+	rows, err := q.query(ctx, q.publishDuePostsStmt, publishDuePosts, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var post_id uuid.UUID
+		if err := rows.Scan(&post_id); err != nil {
+			return nil, err
+		}
+		items = append(items, post_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgePosts = `-- name: PurgePosts :many
+WITH purged AS (
+    DELETE FROM posts WHERE posts.deleted_at < now() - $1::float8 * interval '1 second' RETURNING posts.id
+)
+SELECT media.id, media.kind, media.user_id, media.post_id, media.message_id, media.storage_key, media.thumbnail_key, media.content_type, media.size, media.width, media.height, media.is_private, media.created_at FROM media WHERE media.post_id IN (SELECT purged.id FROM purged)
+`
+
+// removes the posts trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+func (q *Queries) PurgePosts(ctx context.Context, retentionSeconds float64) ([]Media, error) {
+	rows, err := q.query(ctx, q.purgePostsStmt, purgePosts, retentionSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restorePost = `-- name: RestorePost :one
+UPDATE posts SET deleted_at = NULL
+WHERE posts.id = $1 AND posts.deleted_at IS NOT NULL
+    AND posts.user_identity_id IN (SELECT user_identities.id FROM user_identities WHERE user_identities.user_id = $2::uuid)
+RETURNING id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at
+`
+
+type RestorePostParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RestorePost(ctx context.Context, arg RestorePostParams) (Post, error) {
+	row := q.queryRow(ctx, q.restorePostStmt, restorePost, arg.ID, arg.UserID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
+	)
+	return i, err
+}
+
+const setPostContentFlags = `-- name: SetPostContentFlags :one
+UPDATE posts
+SET content_warnings = $1::varchar[], nsfw = $2,
+    flags_moderated_at = CASE WHEN $3::bool THEN now() ELSE flags_moderated_at END
+WHERE id = $4 AND deleted_at IS NULL AND ($3::bool OR flags_moderated_at IS NULL)
+RETURNING id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at
+`
+
+type SetPostContentFlagsParams struct {
+	ContentWarnings []string  `json:"content_warnings"`
+	Nsfw            bool      `json:"nsfw"`
+	Moderated       bool      `json:"moderated"`
+	ID              uuid.UUID `json:"id"`
+}
+
+// flags set by a moderator can only be changed by a moderator
+func (q *Queries) SetPostContentFlags(ctx context.Context, arg SetPostContentFlagsParams) (Post, error) {
+	row := q.queryRow(ctx, q.setPostContentFlagsStmt, setPostContentFlags,
+		pq.Array(arg.ContentWarnings),
+		arg.Nsfw,
+		arg.Moderated,
+		arg.ID,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
+	)
+	return i, err
+}
+
+const updateDraftPost = `-- name: UpdateDraftPost :one
+UPDATE posts SET content = $1, status = $2, publish_at = $3, updated_at = now()
+WHERE id = $4 AND status <> 'published' AND deleted_at IS NULL
+RETURNING id, content, user_identity_id, created_at, updated_at, search_vector, revision, edited, deleted_at, status, publish_at, content_warnings, nsfw, flags_moderated_at
+`
+
+type UpdateDraftPostParams struct {
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+	ID        uuid.UUID  `json:"id"`
+}
+
+func (q *Queries) UpdateDraftPost(ctx context.Context, arg UpdateDraftPostParams) (Post, error) {
+	row := q.queryRow(ctx, q.updateDraftPostStmt, updateDraftPost,
+		arg.Content,
+		arg.Status,
+		arg.PublishAt,
+		arg.ID,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Content,
+		&i.UserIdentityID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SearchVector,
+		&i.Revision,
+		&i.Edited,
+		&i.DeletedAt,
+		&i.Status,
+		&i.PublishAt,
+		pq.Array(&i.ContentWarnings),
+		&i.Nsfw,
+		&i.FlagsModeratedAt,
+	)
+	return i, err
+}
+
 const updatePost = `-- name: UpdatePost :one
-UPDATE posts SET content = $1 WHERE id = $2 RETURNING id
+WITH post AS (
+    UPDATE posts SET content = $2, revision = revision + 1, edited = true, updated_at = now()
+    WHERE id = $3 AND deleted_at IS NULL AND status = 'published'
+    RETURNING id, revision, content
+)
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+SELECT post.id, post.revision, $1::uuid, post.content FROM post
+RETURNING post_id
 `
 
 type UpdatePostParams struct {
-	Content string    `json:"content"`
-	ID      uuid.UUID `json:"id"`
+	EditorIdentityID uuid.UUID `json:"editor_identity_id"`
+	Content          string    `json:"content"`
+	ID               uuid.UUID `json:"id"`
 }
 
+// the row lock of the update serializes concurrent edits, so revision numbers never collide
 func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (uuid.UUID, error) {
-	row := q.queryRow(ctx, q.updatePostStmt, updatePost, arg.Content, arg.ID)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+	row := q.queryRow(ctx, q.updatePostStmt, updatePost, arg.EditorIdentityID, arg.Content, arg.ID)
+	var post_id uuid.UUID
+	err := row.Scan(&post_id)
+	return post_id, err
 }
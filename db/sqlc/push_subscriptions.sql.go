@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: push_subscriptions.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createPushSubscription = `-- name: CreatePushSubscription :one
+INSERT INTO "push_subscriptions" (
+    id, user_id, endpoint, p256dh, auth
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (endpoint) DO UPDATE
+SET user_id = excluded.user_id, p256dh = excluded.p256dh, auth = excluded.auth, updated_at = now()
+RETURNING id, user_id, endpoint, p256dh, auth, created_at, updated_at
+`
+
+type CreatePushSubscriptionParams struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Endpoint string    `json:"endpoint"`
+	P256dh   string    `json:"p256dh"`
+	Auth     string    `json:"auth"`
+}
+
+// a browser subscribing again, possibly logged in as someone else, takes its endpoint over
+func (q *Queries) CreatePushSubscription(ctx context.Context, arg CreatePushSubscriptionParams) (PushSubscription, error) {
+	row := q.queryRow(ctx, q.createPushSubscriptionStmt, createPushSubscription,
+		arg.ID,
+		arg.UserID,
+		arg.Endpoint,
+		arg.P256dh,
+		arg.Auth,
+	)
+	var i PushSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Endpoint,
+		&i.P256dh,
+		&i.Auth,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteGonePushSubscription = `-- name: DeleteGonePushSubscription :exec
+DELETE FROM "push_subscriptions" WHERE id = $1
+`
+
+// the push service told us the subscription no longer exists
+func (q *Queries) DeleteGonePushSubscription(ctx context.Context, id uuid.UUID) error {
+	_, err := q.exec(ctx, q.deleteGonePushSubscriptionStmt, deleteGonePushSubscription, id)
+	return err
+}
+
+const deletePushSubscription = `-- name: DeletePushSubscription :one
+DELETE FROM "push_subscriptions" WHERE endpoint = $1 AND user_id = $2 RETURNING id
+`
+
+type DeletePushSubscriptionParams struct {
+	Endpoint string    `json:"endpoint"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.deletePushSubscriptionStmt, deletePushSubscription, arg.Endpoint, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteUserPushSubscriptions = `-- name: DeleteUserPushSubscriptions :execrows
+DELETE FROM "push_subscriptions" WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserPushSubscriptions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserPushSubscriptionsStmt, deleteUserPushSubscriptions, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listPushSubscriptions = `-- name: ListPushSubscriptions :many
+SELECT id, user_id, endpoint, p256dh, auth, created_at, updated_at FROM "push_subscriptions" WHERE user_id = $1
+`
+
+func (q *Queries) ListPushSubscriptions(ctx context.Context, userID uuid.UUID) ([]PushSubscription, error) {
+	rows, err := q.query(ctx, q.listPushSubscriptionsStmt, listPushSubscriptions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PushSubscription
+	for rows.Next() {
+		var i PushSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Endpoint,
+			&i.P256dh,
+			&i.Auth,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
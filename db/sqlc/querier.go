@@ -8,39 +8,243 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"time"
 )
 
 type Querier interface {
+	BlockIdentity(ctx context.Context, arg BlockIdentityParams) (Block, error)
 	BlockSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	BlockUser(ctx context.Context, arg BlockUserParams) (Block, error)
+	// nothing is cast once the poll is closed or when another identity of the user voted in it already, a second
+	// ballot of the identity violates the primary key
+	CastPollBallot(ctx context.Context, arg CastPollBallotParams) (int64, error)
+	// takes the oldest pending export, or one whose worker died while building it.
+	// SKIP LOCKED lets several servers work through the queue without building an export twice.
+	ClaimDataExport(ctx context.Context, staleSeconds float64) (DataExport, error)
+	// takes the due digests of accounts that are not being deleted, moving them past the lease so a server that dies
+	// while sending leaves them to be retried. SKIP LOCKED lets several servers share the work.
+	ClaimDueEmailDigests(ctx context.Context, arg ClaimDueEmailDigestsParams) ([]ClaimDueEmailDigestsRow, error)
+	// takes the due deliveries of active webhooks and moves their next attempt past the lease, so a server that
+	// dies while delivering leaves them to be retried. SKIP LOCKED lets several servers share the queue.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	// unsets the default of the user other than the identity, before SetDefaultUserIdentity. The unique index on the
+	// default of a user is checked row by row, so both cannot be done in one statement.
+	ClearDefaultUserIdentity(ctx context.Context, arg ClearDefaultUserIdentityParams) error
+	CompleteDataExport(ctx context.Context, arg CompleteDataExportParams) (DataExport, error)
+	CompleteWebhookDelivery(ctx context.Context, arg CompleteWebhookDeliveryParams) error
+	CountActiveUserIdentities(ctx context.Context, userID uuid.UUID) (int64, error)
+	// new follows of the user or any of their identities
+	CountDigestFollowers(ctx context.Context, arg CountDigestFollowersParams) (int64, error)
+	CountDigestMessages(ctx context.Context, arg CountDigestMessagesParams) (CountDigestMessagesRow, error)
+	CountMediaByPostId(ctx context.Context, postID uuid.NullUUID) (int64, error)
+	CountMutedKeywords(ctx context.Context, userID uuid.UUID) (int64, error)
+	CountPostReactions(ctx context.Context, postID uuid.UUID) (CountPostReactionsRow, error)
+	CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error)
 	CreateComment(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	// records the content of a new comment as its first revision
+	CreateCommentRevision(ctx context.Context, arg CreateCommentRevisionParams) error
+	CreateDataExport(ctx context.Context, arg CreateDataExportParams) (DataExport, error)
+	CreateMedia(ctx context.Context, arg CreateMediaParams) (Media, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateMutedKeyword(ctx context.Context, arg CreateMutedKeywordParams) (MutedKeyword, error)
+	// folds the event into the unread notification of the same type and subject, counting each actor once.
+	// nothing is written when the user turned the type off.
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (CreateNotificationRow, error)
+	CreatePoll(ctx context.Context, arg CreatePollParams) (Poll, error)
+	// the options are numbered in the order given
+	CreatePollOptions(ctx context.Context, arg CreatePollOptionsParams) error
 	CreatePost(ctx context.Context, arg CreatePostParams) (Post, error)
+	// records the content of a new post as its first revision
+	CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) error
+	// a browser subscribing again, possibly logged in as someone else, takes its endpoint over
+	CreatePushSubscription(ctx context.Context, arg CreatePushSubscriptionParams) (PushSubscription, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UUID, error)
 	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	DeleteBlock(ctx context.Context, arg DeleteBlockParams) (uuid.UUID, error)
+	// moves the comment to the trash, its replies are hidden along with it
 	DeleteComment(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	DeleteEmailDigest(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	// the archives are removed from storage by the caller
+	DeleteExpiredDataExports(ctx context.Context) ([]DataExport, error)
+	DeleteExpiredSessions(ctx context.Context) (int64, error)
+	// the push service told us the subscription no longer exists
+	DeleteGonePushSubscription(ctx context.Context, id uuid.UUID) error
+	DeleteMedia(ctx context.Context, id uuid.UUID) (Media, error)
+	DeleteMutedKeyword(ctx context.Context, arg DeleteMutedKeywordParams) (uuid.UUID, error)
+	DeleteOldAvatars(ctx context.Context, arg DeleteOldAvatarsParams) ([]Media, error)
+	// moves the message to the trash
 	DeleteOneMessage(ctx context.Context, arg DeleteOneMessageParams) (uuid.UUID, error)
-	DeleteOneUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	// schedules the account for deletion after the grace period, its content is hidden until the user logs in again
+	// or the account is purged
+	DeleteOneUser(ctx context.Context, arg DeleteOneUserParams) (*time.Time, error)
+	// moves the post to the trash, its comments are hidden along with it
 	DeletePost(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	// marks the score of the post dirty, a removed reaction leaves nothing for the refresher to compare
+	DeletePostReaction(ctx context.Context, arg DeletePostReactionParams) (uuid.UUID, error)
+	DeletePushSubscription(ctx context.Context, arg DeletePushSubscriptionParams) (uuid.UUID, error)
 	DeleteSession(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
-	DeleteSessionByUserId(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	DeleteSessionByUserId(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteUserPushSubscriptions(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (uuid.UUID, error)
+	ExportUserComments(ctx context.Context, userID uuid.UUID) ([]Comment, error)
+	ExportUserMessages(ctx context.Context, receiverID uuid.UUID) ([]Message, error)
+	// every post under the identities still linked to the user, anonymous and deleted ones included
+	ExportUserPosts(ctx context.Context, userID uuid.UUID) ([]Post, error)
+	FailDataExport(ctx context.Context, arg FailDataExportParams) error
+	// records a failed attempt, the delivery is given up once it has had max_attempts
+	FailWebhookDelivery(ctx context.Context, arg FailWebhookDeliveryParams) error
+	FollowIdentity(ctx context.Context, arg FollowIdentityParams) error
+	FollowUser(ctx context.Context, arg FollowUserParams) error
 	GetComment(ctx context.Context, id uuid.UUID) (Comment, error)
+	// the comment whatever became of it, for the revisions its author and moderators can read
+	GetCommentIncludingDeleted(ctx context.Context, id uuid.UUID) (Comment, error)
+	GetCommentRevision(ctx context.Context, arg GetCommentRevisionParams) (CommentRevision, error)
+	GetDataExport(ctx context.Context, arg GetDataExportParams) (DataExport, error)
+	GetDefaultUserIdentity(ctx context.Context, userID uuid.UUID) (UserIdentity, error)
+	// the account waiting for its deletion, until its grace period is over
+	GetDeletedUserByUsername(ctx context.Context, username string) (User, error)
+	GetDraftPost(ctx context.Context, id uuid.UUID) (Post, error)
+	GetEmailDigest(ctx context.Context, userID uuid.UUID) (EmailDigest, error)
+	GetMediaById(ctx context.Context, id uuid.UUID) (Media, error)
 	GetMessageById(ctx context.Context, id uuid.UUID) (Message, error)
 	GetPostById(ctx context.Context, id uuid.UUID) (Post, error)
+	// the post whatever became of it, for the revisions its author and moderators can read
+	GetPostByIdIncludingDeleted(ctx context.Context, id uuid.UUID) (Post, error)
+	GetPostReaction(ctx context.Context, arg GetPostReactionParams) (PostReaction, error)
+	GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (PostRevision, error)
 	GetSessionById(ctx context.Context, id uuid.UUID) (Session, error)
 	GetUserById(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
 	GetUserIdentityById(ctx context.Context, id uuid.UUID) (UserIdentity, error)
-	GetUserIdentityByUserId(ctx context.Context, userID uuid.UUID) (UserIdentity, error)
-	ListAllComments(ctx context.Context, postID uuid.UUID) ([]Comment, error)
-	ListAllPosts(ctx context.Context, offset int32) ([]Post, error)
+	GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error)
+	// whether the user blocks anybody, they can't be told apart from anonymous senders
+	HasBlocks(ctx context.Context, userID uuid.UUID) (bool, error)
+	HasFeedPosts(ctx context.Context, userID uuid.UUID) (bool, error)
+	// a block of any identity counts for all identities of the same user
+	IsBlocked(ctx context.Context, arg IsBlockedParams) (bool, error)
+	ListAllComments(ctx context.Context, arg ListAllCommentsParams) ([]Comment, error)
+	ListAllPosts(ctx context.Context, arg ListAllPostsParams) ([]Post, error)
+	ListBlocks(ctx context.Context, arg ListBlocksParams) ([]Block, error)
+	ListCommentRevisions(ctx context.Context, commentID uuid.UUID) ([]CommentRevision, error)
+	ListContentPreferences(ctx context.Context, userID uuid.UUID) ([]ContentPreference, error)
+	ListControversialPosts(ctx context.Context, arg ListControversialPostsParams) ([]Post, error)
+	ListDataExports(ctx context.Context, userID uuid.UUID) ([]DataExport, error)
+	// the comments others left on the posts of the user since the last digest, the most replied to first
+	ListDigestTopComments(ctx context.Context, arg ListDigestTopCommentsParams) ([]ListDigestTopCommentsRow, error)
+	// the drafts and scheduled posts of the user, next to be published first
+	ListDraftPosts(ctx context.Context, arg ListDraftPostsParams) ([]Post, error)
+	// posts of followed identities, and of followed users under their public identities only
+	ListFeedPosts(ctx context.Context, arg ListFeedPostsParams) ([]Post, error)
+	ListFollowedIdentities(ctx context.Context, arg ListFollowedIdentitiesParams) ([]ListFollowedIdentitiesRow, error)
+	ListFollowedUsers(ctx context.Context, arg ListFollowedUsersParams) ([]ListFollowedUsersRow, error)
+	ListFollowers(ctx context.Context, arg ListFollowersParams) ([]ListFollowersRow, error)
+	// posts the score refresher hasn't seen yet sort as if they had no reactions
+	ListHotPosts(ctx context.Context, arg ListHotPostsParams) ([]Post, error)
+	ListMediaByMessageId(ctx context.Context, messageID uuid.NullUUID) ([]Media, error)
+	ListMediaByPostId(ctx context.Context, postID uuid.NullUUID) ([]Media, error)
 	ListMessage(ctx context.Context, arg ListMessageParams) ([]Message, error)
+	ListMutedKeywords(ctx context.Context, userID uuid.UUID) ([]MutedKeyword, error)
+	ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error)
+	// the notifications of the user, latest activity first, with the number of actors folded into each
+	ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]ListNotificationsRow, error)
+	// the options of the polls with their vote counts, voted tells whether the viewer chose the option
+	ListPollOptions(ctx context.Context, arg ListPollOptionsParams) ([]ListPollOptionsRow, error)
+	// the polls of the posts, voted tells whether the viewer voted under any of their identities
+	ListPolls(ctx context.Context, arg ListPollsParams) ([]ListPollsRow, error)
+	ListPostRevisions(ctx context.Context, postID uuid.UUID) ([]PostRevision, error)
+	ListPostsByTag(ctx context.Context, arg ListPostsByTagParams) ([]Post, error)
+	ListPublicPostsByUserId(ctx context.Context, arg ListPublicPostsByUserIdParams) ([]Post, error)
+	ListPushSubscriptions(ctx context.Context, userID uuid.UUID) ([]PushSubscription, error)
+	// the posts with the most net likes created in the last days, days 0 means all time
+	ListTopPosts(ctx context.Context, arg ListTopPostsParams) ([]Post, error)
+	ListTrashedComments(ctx context.Context, arg ListTrashedCommentsParams) ([]Comment, error)
+	ListTrashedMessages(ctx context.Context, arg ListTrashedMessagesParams) ([]Message, error)
+	ListTrashedPosts(ctx context.Context, arg ListTrashedPostsParams) ([]Post, error)
+	// ranked by the number of comments in the last week
+	ListTrendingPosts(ctx context.Context, arg ListTrendingPostsParams) ([]Post, error)
+	ListTrendingTags(ctx context.Context, days int32) ([]ListTrendingTagsRow, error)
+	ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error)
+	// locks the due identities until the transaction it runs in ends. SKIP LOCKED lets several servers share the
+	// rotations without rotating an identity twice.
+	ListUserIdentitiesDueForRotation(ctx context.Context, limit int32) ([]UserIdentity, error)
+	// leaves the refresh tokens out, they are secrets even to their owner
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]ListUserSessionsRow, error)
 	ListUsers(ctx context.Context, offset int32) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]Webhook, error)
+	// the active webhooks of the user subscribed to the event
+	ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error)
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkEmailDigestSent(ctx context.Context, userID uuid.UUID) error
+	// marking a read notification again keeps the time it was first read
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (uuid.UUID, error)
+	// the post is dated from its publication, and its revision history starts there
+	PublishDraftPost(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	// the row locks are skipped rather than waited on, so concurrent schedulers each publish a post once
+	PublishDuePosts(ctx context.Context, batchSize int32) ([]uuid.UUID, error)
+	// removes the comments trashed longer ago than the retention for good, their replies go with them
+	PurgeComments(ctx context.Context, retentionSeconds float64) (int64, error)
+	// removes the messages trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+	PurgeMessages(ctx context.Context, retentionSeconds float64) ([]Media, error)
+	// removes the posts trashed longer ago than the retention for good, returns their media so the stored files can be removed too
+	PurgePosts(ctx context.Context, retentionSeconds float64) ([]Media, error)
+	// removes the accounts whose grace period is over for good, everything they own goes with them.
+	// Returns their media so the stored files can be removed too.
+	PurgeUsers(ctx context.Context) ([]Media, error)
+	// queues a copy of the delivery, the original stays in the log as it was
+	RedeliverWebhookDelivery(ctx context.Context, arg RedeliverWebhookDeliveryParams) (WebhookDelivery, error)
+	// hot decays the net reactions plus comments with the age of the post, like hacker news does.
+	// controversy is high when there are many reactions split evenly between likes and dislikes.
+	// only the posts younger than the window, those never scored, those marked dirty and those reacted to or
+	// commented on since they were are refreshed, the scores of the others barely move. Deleted comments don't count.
+	RefreshPostScores(ctx context.Context, arg RefreshPostScoresParams) (int64, error)
+	// retires the identity ahead of its replacement, an identity already replaced is not found
+	ReplaceUserIdentity(ctx context.Context, arg ReplaceUserIdentityParams) (uuid.UUID, error)
+	// marks the score of the post dirty, the comment counts again but its deleted_at is gone
+	RestoreComment(ctx context.Context, arg RestoreCommentParams) (RestoreCommentRow, error)
+	RestoreMessage(ctx context.Context, arg RestoreMessageParams) (Message, error)
+	RestorePost(ctx context.Context, arg RestorePostParams) (Post, error)
+	// cancels the scheduled deletion of the account, unless its grace period is over
+	RestoreUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	RetireUserIdentity(ctx context.Context, arg RetireUserIdentityParams) (uuid.UUID, error)
+	// keyset paginated on (rank, id), the headline is only built for the returned page
+	SearchComments(ctx context.Context, arg SearchCommentsParams) ([]SearchCommentsRow, error)
+	// keyset paginated on (rank, id), the headline is only built for the returned page
+	SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error)
+	// the prefix has to be escaped for LIKE
+	SearchTags(ctx context.Context, prefix string) ([]SearchTagsRow, error)
+	// replaces the preferences of the user, the categories and actions pair up by position
+	SetContentPreferences(ctx context.Context, arg SetContentPreferencesParams) error
+	SetDefaultUserIdentity(ctx context.Context, arg SetDefaultUserIdentityParams) error
+	// opts in or changes the settings, the next digest is one period after the last one or from now on the first
+	SetEmailDigest(ctx context.Context, arg SetEmailDigestParams) (EmailDigest, error)
+	// the types and enabled flags pair up by position, types left out keep their setting
+	SetNotificationPreferences(ctx context.Context, arg SetNotificationPreferencesParams) error
+	// flags set by a moderator can only be changed by a moderator
+	SetPostContentFlags(ctx context.Context, arg SetPostContentFlagsParams) (Post, error)
+	SetPostReaction(ctx context.Context, arg SetPostReactionParams) (PostReaction, error)
+	// replaces the tags of a post in one statement, so the tag index never sees a half updated post
+	SetPostTags(ctx context.Context, arg SetPostTagsParams) error
+	SetUserModerator(ctx context.Context, arg SetUserModeratorParams) (uuid.UUID, error)
+	UnfollowIdentity(ctx context.Context, arg UnfollowIdentityParams) (uuid.UUID, error)
+	UnfollowUser(ctx context.Context, arg UnfollowUserParams) (uuid.UUID, error)
+	UnsubscribeEmailDigest(ctx context.Context, unsubscribeToken string) (int64, error)
+	// the row lock of the update serializes concurrent edits, so revision numbers never collide
 	UpdateComment(ctx context.Context, arg UpdateCommentParams) (uuid.UUID, error)
+	UpdateDraftPost(ctx context.Context, arg UpdateDraftPostParams) (Post, error)
 	UpdateMessageStatus(ctx context.Context, arg UpdateMessageStatusParams) (uuid.UUID, error)
+	// the row lock of the update serializes concurrent edits, so revision numbers never collide
 	UpdatePost(ctx context.Context, arg UpdatePostParams) (uuid.UUID, error)
+	UpdateUserIdentity(ctx context.Context, arg UpdateUserIdentityParams) (UserIdentity, error)
+	UpdateUserIdentityRotation(ctx context.Context, arg UpdateUserIdentityRotationParams) (UserIdentity, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (uuid.UUID, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
 	UpdateUsername(ctx context.Context, arg UpdateUsernameParams) (uuid.UUID, error)
+	// fields left null keep their value
+	UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,156 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: revisions.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createCommentRevision = `-- name: CreateCommentRevision :exec
+INSERT INTO comment_revisions (comment_id, revision, user_identity_id, content)
+VALUES ($1, 1, $2, $3)
+`
+
+type CreateCommentRevisionParams struct {
+	CommentID      uuid.UUID `json:"comment_id"`
+	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	Content        string    `json:"content"`
+}
+
+// records the content of a new comment as its first revision
+func (q *Queries) CreateCommentRevision(ctx context.Context, arg CreateCommentRevisionParams) error {
+	_, err := q.exec(ctx, q.createCommentRevisionStmt, createCommentRevision, arg.CommentID, arg.UserIdentityID, arg.Content)
+	return err
+}
+
+const createPostRevision = `-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (post_id, revision, user_identity_id, content)
+VALUES ($1, 1, $2, $3)
+`
+
+type CreatePostRevisionParams struct {
+	PostID         uuid.UUID `json:"post_id"`
+	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	Content        string    `json:"content"`
+}
+
+// records the content of a new post as its first revision
+func (q *Queries) CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) error {
+	_, err := q.exec(ctx, q.createPostRevisionStmt, createPostRevision, arg.PostID, arg.UserIdentityID, arg.Content)
+	return err
+}
+
+const getCommentRevision = `-- name: GetCommentRevision :one
+SELECT comment_id, revision, user_identity_id, content, created_at FROM comment_revisions WHERE comment_id = $1 AND revision = $2 LIMIT 1
+`
+
+type GetCommentRevisionParams struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	Revision  int32     `json:"revision"`
+}
+
+func (q *Queries) GetCommentRevision(ctx context.Context, arg GetCommentRevisionParams) (CommentRevision, error) {
+	row := q.queryRow(ctx, q.getCommentRevisionStmt, getCommentRevision, arg.CommentID, arg.Revision)
+	var i CommentRevision
+	err := row.Scan(
+		&i.CommentID,
+		&i.Revision,
+		&i.UserIdentityID,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPostRevision = `-- name: GetPostRevision :one
+SELECT post_id, revision, user_identity_id, content, created_at FROM post_revisions WHERE post_id = $1 AND revision = $2 LIMIT 1
+`
+
+type GetPostRevisionParams struct {
+	PostID   uuid.UUID `json:"post_id"`
+	Revision int32     `json:"revision"`
+}
+
+func (q *Queries) GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (PostRevision, error) {
+	row := q.queryRow(ctx, q.getPostRevisionStmt, getPostRevision, arg.PostID, arg.Revision)
+	var i PostRevision
+	err := row.Scan(
+		&i.PostID,
+		&i.Revision,
+		&i.UserIdentityID,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listCommentRevisions = `-- name: ListCommentRevisions :many
+SELECT comment_id, revision, user_identity_id, content, created_at FROM comment_revisions WHERE comment_id = $1 ORDER BY revision DESC
+`
+
+func (q *Queries) ListCommentRevisions(ctx context.Context, commentID uuid.UUID) ([]CommentRevision, error) {
+	rows, err := q.query(ctx, q.listCommentRevisionsStmt, listCommentRevisions, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentRevision
+	for rows.Next() {
+		var i CommentRevision
+		if err := rows.Scan(
+			&i.CommentID,
+			&i.Revision,
+			&i.UserIdentityID,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostRevisions = `-- name: ListPostRevisions :many
+SELECT post_id, revision, user_identity_id, content, created_at FROM post_revisions WHERE post_id = $1 ORDER BY revision DESC
+`
+
+func (q *Queries) ListPostRevisions(ctx context.Context, postID uuid.UUID) ([]PostRevision, error) {
+	rows, err := q.query(ctx, q.listPostRevisionsStmt, listPostRevisions, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.PostID,
+			&i.Revision,
+			&i.UserIdentityID,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
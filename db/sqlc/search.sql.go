@@ -0,0 +1,208 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: search.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const searchComments = `-- name: SearchComments :many
+SELECT
+    results.id, results.content, results.user_identity_id, results.post_id, results.parent_id,
+    results.created_at, results.updated_at, results.rank,
+    ts_headline('english', results.content, to_tsquery('english', $1),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT
+        comments.id, comments.content, comments.user_identity_id, comments.post_id, comments.parent_id,
+        comments.created_at, comments.updated_at,
+        ts_rank_cd(comments.search_vector, to_tsquery('english', $1)) AS rank
+    FROM comments
+    WHERE comments.search_vector @@ to_tsquery('english', $1)
+        AND ($2::uuid IS NULL OR comments.user_identity_id = $2::uuid)
+        AND ($3::date IS NULL OR comments.created_at >= $3::date)
+        AND ($4::date IS NULL OR comments.created_at < $4::date + 1)
+        AND NOT comment_trashed(comments.id)
+        AND NOT hidden_from($5::uuid, comments.user_identity_id, comments.content)
+) AS results
+WHERE $6::uuid IS NULL
+    OR (results.rank, results.id) < ($7::real, $6::uuid)
+ORDER BY results.rank DESC, results.id DESC
+LIMIT 20
+`
+
+type SearchCommentsParams struct {
+	Query       string          `json:"query"`
+	IdentityID  uuid.NullUUID   `json:"identity_id"`
+	CreatedFrom sql.NullTime    `json:"created_from"`
+	CreatedTo   sql.NullTime    `json:"created_to"`
+	ViewerID    uuid.UUID       `json:"viewer_id"`
+	CursorID    uuid.NullUUID   `json:"cursor_id"`
+	CursorRank  sql.NullFloat64 `json:"cursor_rank"`
+}
+
+type SearchCommentsRow struct {
+	ID             uuid.UUID `json:"id"`
+	Content        string    `json:"content"`
+	UserIdentityID uuid.UUID `json:"user_identity_id"`
+	PostID         uuid.UUID `json:"post_id"`
+	ParentID       uuid.UUID `json:"parent_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Rank           float32   `json:"rank"`
+	Snippet        string    `json:"snippet"`
+}
+
+// keyset paginated on (rank, id), the headline is only built for the returned page
+func (q *Queries) SearchComments(ctx context.Context, arg SearchCommentsParams) ([]SearchCommentsRow, error) {
+	rows, err := q.query(ctx, q.searchCommentsStmt, searchComments,
+		arg.Query,
+		arg.IdentityID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.ViewerID,
+		arg.CursorID,
+		arg.CursorRank,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCommentsRow
+	for rows.Next() {
+		var i SearchCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.PostID,
+			&i.ParentID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT
+    results.id, results.content, results.user_identity_id, results.created_at, results.updated_at,
+    results.revision, results.edited, results.deleted_at, results.status, results.publish_at,
+    results.content_warnings, results.nsfw, results.flags_moderated_at, results.rank,
+    ts_headline('english', results.content, to_tsquery('english', $1),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT
+        posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at,
+        posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at,
+        posts.content_warnings, posts.nsfw, posts.flags_moderated_at,
+        ts_rank_cd(posts.search_vector, to_tsquery('english', $1)) AS rank
+    FROM posts
+    WHERE posts.search_vector @@ to_tsquery('english', $1)
+        AND ($2::uuid IS NULL OR posts.user_identity_id = $2::uuid)
+        AND ($3::date IS NULL OR posts.created_at >= $3::date)
+        AND ($4::date IS NULL OR posts.created_at < $4::date + 1)
+        AND posts.deleted_at IS NULL AND posts.status = 'published'
+        AND NOT hidden_from($5::uuid, posts.user_identity_id, posts.content)
+        AND NOT hidden_by_preference($5::uuid, posts.content_warnings, posts.nsfw)
+) AS results
+WHERE $6::uuid IS NULL
+    OR (results.rank, results.id) < ($7::real, $6::uuid)
+ORDER BY results.rank DESC, results.id DESC
+LIMIT 20
+`
+
+type SearchPostsParams struct {
+	Query       string          `json:"query"`
+	IdentityID  uuid.NullUUID   `json:"identity_id"`
+	CreatedFrom sql.NullTime    `json:"created_from"`
+	CreatedTo   sql.NullTime    `json:"created_to"`
+	ViewerID    uuid.UUID       `json:"viewer_id"`
+	CursorID    uuid.NullUUID   `json:"cursor_id"`
+	CursorRank  sql.NullFloat64 `json:"cursor_rank"`
+}
+
+type SearchPostsRow struct {
+	ID               uuid.UUID  `json:"id"`
+	Content          string     `json:"content"`
+	UserIdentityID   uuid.UUID  `json:"user_identity_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	Revision         int32      `json:"revision"`
+	Edited           bool       `json:"edited"`
+	DeletedAt        *time.Time `json:"deleted_at"`
+	Status           string     `json:"status"`
+	PublishAt        *time.Time `json:"publish_at"`
+	ContentWarnings  []string   `json:"content_warnings"`
+	Nsfw             bool       `json:"nsfw"`
+	FlagsModeratedAt *time.Time `json:"flags_moderated_at"`
+	Rank             float32    `json:"rank"`
+	Snippet          string     `json:"snippet"`
+}
+
+// keyset paginated on (rank, id), the headline is only built for the returned page
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.query(ctx, q.searchPostsStmt, searchPosts,
+		arg.Query,
+		arg.IdentityID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.ViewerID,
+		arg.CursorID,
+		arg.CursorRank,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
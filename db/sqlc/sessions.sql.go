@@ -77,6 +77,19 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 	return i, err
 }
 
+const deleteExpiredSessions = `-- name: DeleteExpiredSessions :execrows
+DELETE FROM "sessions"
+WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := q.exec(ctx, q.deleteExpiredSessionsStmt, deleteExpiredSessions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteSession = `-- name: DeleteSession :one
 DELETE FROM "sessions"
 WHERE id = $1
@@ -89,17 +102,17 @@ func (q *Queries) DeleteSession(ctx context.Context, id uuid.UUID) (uuid.UUID, e
 	return id, err
 }
 
-const deleteSessionByUserId = `-- name: DeleteSessionByUserId :one
+const deleteSessionByUserId = `-- name: DeleteSessionByUserId :execrows
 DELETE FROM "sessions"
 WHERE user_id = $1
-RETURNING id
 `
 
-func (q *Queries) DeleteSessionByUserId(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
-	row := q.queryRow(ctx, q.deleteSessionByUserIdStmt, deleteSessionByUserId, userID)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) DeleteSessionByUserId(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.deleteSessionByUserIdStmt, deleteSessionByUserId, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getSessionById = `-- name: GetSessionById :one
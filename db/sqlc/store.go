@@ -2,11 +2,71 @@ package db
 
 import (
 	"cnfs/config"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// how many times a transaction is run before a serialization failure is given up on
+	maxTxAttempts = 5
+	// the wait before the second attempt, doubled for every one after it
+	txRetryDelay = 10 * time.Millisecond
 )
 
 type Store interface {
 	Querier
+	// ExecTx runs fn in a serializable transaction, committed when fn returns nil and rolled back otherwise.
+	// fn is run again when the transaction fails to serialize with a concurrent one, so it must not have
+	// side effects outside the transaction.
+	ExecTx(ctx context.Context, fn func(q Querier) error) error
+	// CreateUserTx creates a user along with their default identity.
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	// ChangePasswordTx changes the login credentials of a user, the username, the password or both, and
+	// deletes the session they were changed from.
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error)
+	// DeleteAccountTx schedules the deletion of an account, revokes its sessions and unsubscribes its browsers.
+	DeleteAccountTx(ctx context.Context, arg DeleteOneUserParams) (time.Time, error)
+	// CreatePostTx creates a post along with its first revision when it is published, its tags and its poll.
+	CreatePostTx(ctx context.Context, arg CreatePostTxParams) (Post, error)
+	// UpdatePostTx edits a published post, recording the revision, and replaces its tags.
+	UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (uuid.UUID, error)
+	// UpdateDraftPostTx edits a draft or scheduled post and replaces its tags.
+	UpdateDraftPostTx(ctx context.Context, arg UpdateDraftPostTxParams) (Post, error)
+	// CreateCommentTx creates a comment along with its first revision.
+	CreateCommentTx(ctx context.Context, arg CreateCommentParams) (Comment, error)
+	// CreateMessageTx creates a message along with the media row of its attached image.
+	CreateMessageTx(ctx context.Context, arg CreateMessageTxParams) (CreateMessageTxResult, error)
+	// CastPollBallotTx casts a ballot serializably, so two identities of a user voting at once can't both get
+	// past the check that the user didn't vote yet.
+	CastPollBallotTx(ctx context.Context, arg CastPollBallotParams) (int64, error)
+	// SetDefaultUserIdentityTx makes the identity the default of the user in place of their current one.
+	SetDefaultUserIdentityTx(ctx context.Context, arg SetDefaultUserIdentityParams) error
+	// RotateUserIdentityTx retires the identity and creates the fresh one replacing it. It fails with
+	// sql.ErrNoRows when the identity was retired already.
+	RotateUserIdentityTx(ctx context.Context, arg RotateUserIdentityTxParams) (UserIdentity, error)
+	// RotateDueUserIdentitiesTx rotates up to limit identities whose rotation schedule has elapsed, returning
+	// how many it rotated.
+	RotateDueUserIdentitiesTx(ctx context.Context, limit int32) (int, error)
+}
+
+// TxRunner is the part of a Store that differs between databases, the queries and a way to run them in a
+// transaction.
+type TxRunner interface {
+	Querier
+	ExecTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+// Transactions makes a Store of a TxRunner, the transactions of a Store are written once on top of ExecTx
+// and shared by every database.
+type Transactions struct {
+	TxRunner
 }
 
 type PostgresqlStore struct {
@@ -16,9 +76,393 @@ type PostgresqlStore struct {
 }
 
 func NewStore(db *sql.DB, cfg *config.Config) Store {
-	return &PostgresqlStore{
+	return Transactions{&PostgresqlStore{
 		db:      db,
 		cfg:     cfg,
 		Queries: New(db),
+	}}
+}
+
+func (s *PostgresqlStore) ExecTx(ctx context.Context, fn func(q Querier) error) error {
+	delay := txRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := s.execTx(ctx, fn)
+		if err == nil || attempt == maxTxAttempts || !retryable(err) {
+			return err
+		}
+
+		// jittered so the transactions that collided do not collide again
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay/2 + time.Duration(rand.Int63n(int64(delay)))):
+		}
+		delay *= 2
+	}
+}
+
+func (s *PostgresqlStore) execTx(ctx context.Context, fn func(q Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w, and cannot roll back: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// retryable reports whether the transaction failed only because it ran concurrently with another one, so
+// running it again can succeed.
+func retryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Name() {
+	case "serialization_failure", "deadlock_detected":
+		return true
+	}
+	return false
+}
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// the default identity of the user, its UserID is filled in
+	Identity CreateUserIdentityParams
+}
+
+type CreateUserTxResult struct {
+	UserID   uuid.UUID
+	Identity UserIdentity
+}
+
+func (s Transactions) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		result.UserID, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		identity := arg.Identity
+		identity.UserID = result.UserID
+		result.Identity, err = q.CreateUserIdentity(ctx, identity)
+		return err
+	})
+
+	return result, err
+}
+
+type ChangePasswordTxParams struct {
+	UserID uuid.UUID
+	// the session the change is made from, it is deleted so the user logs in again with the new credentials
+	SessionID uuid.UUID
+	// the new username, unchanged when not valid
+	Username sql.NullString
+	// the new password hash, unchanged when not valid
+	Password  sql.NullString
+	UpdatedAt time.Time
+}
+
+type ChangePasswordTxResult struct {
+	// the id of the deleted session
+	SessionID uuid.UUID
+}
+
+func (s Transactions) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error) {
+	var result ChangePasswordTxResult
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		if arg.Username.Valid {
+			if _, err := q.UpdateUsername(ctx, UpdateUsernameParams{
+				Username:  arg.Username.String,
+				UpdatedAt: arg.UpdatedAt,
+				ID:        arg.UserID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if arg.Password.Valid {
+			if _, err := q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+				Password:  arg.Password.String,
+				UpdatedAt: arg.UpdatedAt,
+				ID:        arg.UserID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		result.SessionID, err = q.DeleteSession(ctx, arg.SessionID)
+		return err
+	})
+
+	return result, err
+}
+
+func (s Transactions) DeleteAccountTx(ctx context.Context, arg DeleteOneUserParams) (time.Time, error) {
+	var deleteAfter time.Time
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		after, err := q.DeleteOneUser(ctx, arg)
+		if err != nil {
+			return err
+		}
+		// set by the update, the column is only null for accounts not being deleted
+		deleteAfter = *after
+
+		if _, err := q.DeleteSessionByUserId(ctx, arg.ID); err != nil {
+			return err
+		}
+
+		// logging in again cancels the deletion, the browsers subscribe again then
+		_, err = q.DeleteUserPushSubscriptions(ctx, arg.ID)
+		return err
+	})
+
+	return deleteAfter, err
+}
+
+func (s Transactions) SetDefaultUserIdentityTx(ctx context.Context, arg SetDefaultUserIdentityParams) error {
+	return s.ExecTx(ctx, func(q Querier) error {
+		if err := q.ClearDefaultUserIdentity(ctx, ClearDefaultUserIdentityParams{UserID: arg.UserID, ID: arg.ID}); err != nil {
+			return err
+		}
+		return q.SetDefaultUserIdentity(ctx, arg)
+	})
+}
+
+type RotateUserIdentityTxParams struct {
+	// the identity as it was read, the fresh one takes its name, default and rotation schedule
+	Identity UserIdentity
+	// whether the retired identity is detached from the user
+	Detach bool
+}
+
+func (s Transactions) RotateUserIdentityTx(ctx context.Context, arg RotateUserIdentityTxParams) (UserIdentity, error) {
+	var fresh UserIdentity
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		fresh, err = rotateUserIdentity(ctx, q, arg.Identity, arg.Detach)
+		return err
+	})
+
+	return fresh, err
+}
+
+func (s Transactions) RotateDueUserIdentitiesTx(ctx context.Context, limit int32) (int, error) {
+	var rotated int
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		identities, err := q.ListUserIdentitiesDueForRotation(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		rotated = 0
+		for _, identity := range identities {
+			if _, err := rotateUserIdentity(ctx, q, identity, identity.RotationDetach); err != nil {
+				return err
+			}
+			rotated++
+		}
+		return nil
+	})
+
+	return rotated, err
+}
+
+// rotateUserIdentity retires the identity before creating its replacement, so the user never has two defaults.
+func rotateUserIdentity(ctx context.Context, q Querier, identity UserIdentity, detach bool) (UserIdentity, error) {
+	if _, err := q.ReplaceUserIdentity(ctx, ReplaceUserIdentityParams{ID: identity.ID, Detach: detach}); err != nil {
+		return UserIdentity{}, err
 	}
+
+	return q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		ID:                   uuid.New(),
+		UserID:               identity.UserID.UUID,
+		IdentityHash:         uuid.New(),
+		Name:                 identity.Name,
+		IsDefault:            identity.IsDefault,
+		RotationIntervalDays: identity.RotationIntervalDays,
+		RotationDetach:       identity.RotationDetach,
+	})
+}
+
+type CreatePostTxParams struct {
+	CreatePostParams
+	// the tags of the post, from the #hashtags of its content
+	Tags []string
+	// the poll attached to the post, nil for none. Its PostID is filled in
+	Poll *CreatePollParams
+	// the options of the poll, in order
+	PollOptions []string
+}
+
+func (s Transactions) CreatePostTx(ctx context.Context, arg CreatePostTxParams) (Post, error) {
+	var post Post
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		post, err = q.CreatePost(ctx, arg.CreatePostParams)
+		if err != nil {
+			return err
+		}
+
+		// the revision history of drafts starts when they are published
+		if post.Status == "published" {
+			if err := q.CreatePostRevision(ctx, CreatePostRevisionParams{
+				PostID:         post.ID,
+				UserIdentityID: post.UserIdentityID,
+				Content:        post.Content,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := q.SetPostTags(ctx, SetPostTagsParams{PostID: post.ID, Tags: arg.Tags}); err != nil {
+			return err
+		}
+
+		if arg.Poll == nil {
+			return nil
+		}
+
+		poll := *arg.Poll
+		poll.PostID = post.ID
+		if _, err := q.CreatePoll(ctx, poll); err != nil {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(arg.PollOptions))
+		for range arg.PollOptions {
+			ids = append(ids, uuid.New())
+		}
+		return q.CreatePollOptions(ctx, CreatePollOptionsParams{PostID: post.ID, Ids: ids, Texts: arg.PollOptions})
+	})
+
+	return post, err
+}
+
+type UpdatePostTxParams struct {
+	UpdatePostParams
+	// the tags of the post, from the #hashtags of its new content
+	Tags []string
+}
+
+func (s Transactions) UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (uuid.UUID, error) {
+	var postID uuid.UUID
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		postID, err = q.UpdatePost(ctx, arg.UpdatePostParams)
+		if err != nil {
+			return err
+		}
+		return q.SetPostTags(ctx, SetPostTagsParams{PostID: postID, Tags: arg.Tags})
+	})
+
+	return postID, err
+}
+
+type UpdateDraftPostTxParams struct {
+	UpdateDraftPostParams
+	// the tags of the post, from the #hashtags of its new content
+	Tags []string
+}
+
+func (s Transactions) UpdateDraftPostTx(ctx context.Context, arg UpdateDraftPostTxParams) (Post, error) {
+	var post Post
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		post, err = q.UpdateDraftPost(ctx, arg.UpdateDraftPostParams)
+		if err != nil {
+			return err
+		}
+		return q.SetPostTags(ctx, SetPostTagsParams{PostID: post.ID, Tags: arg.Tags})
+	})
+
+	return post, err
+}
+
+func (s Transactions) CreateCommentTx(ctx context.Context, arg CreateCommentParams) (Comment, error) {
+	var comment Comment
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		comment, err = q.CreateComment(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return q.CreateCommentRevision(ctx, CreateCommentRevisionParams{
+			CommentID:      comment.ID,
+			UserIdentityID: comment.UserIdentityID,
+			Content:        comment.Content,
+		})
+	})
+
+	return comment, err
+}
+
+type CreateMessageTxParams struct {
+	CreateMessageParams
+	// the image attached to the message, nil for none. Its MessageID is filled in
+	Image *CreateMediaParams
+}
+
+type CreateMessageTxResult struct {
+	Message Message
+	// the attached image, empty for none
+	Attachments []Media
+}
+
+func (s Transactions) CreateMessageTx(ctx context.Context, arg CreateMessageTxParams) (CreateMessageTxResult, error) {
+	var result CreateMessageTxResult
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		result.Message, err = q.CreateMessage(ctx, arg.CreateMessageParams)
+		if err != nil {
+			return err
+		}
+
+		result.Attachments = nil
+		if arg.Image == nil {
+			return nil
+		}
+
+		image := *arg.Image
+		image.MessageID = uuid.NullUUID{UUID: result.Message.ID, Valid: true}
+		attachment, err := q.CreateMedia(ctx, image)
+		if err != nil {
+			return err
+		}
+		result.Attachments = []Media{attachment}
+		return nil
+	})
+
+	return result, err
+}
+
+func (s Transactions) CastPollBallotTx(ctx context.Context, arg CastPollBallotParams) (int64, error) {
+	var cast int64
+
+	err := s.ExecTx(ctx, func(q Querier) error {
+		var err error
+		cast, err = q.CastPollBallot(ctx, arg)
+		return err
+	})
+
+	return cast, err
 }
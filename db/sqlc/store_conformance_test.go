@@ -0,0 +1,32 @@
+package db_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"cnfs/config"
+	db "cnfs/db/sqlc"
+	"cnfs/db/storetest"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStore runs the store tests against the migrated database of TEST_DATABASE_URL. The tests leave their
+// rows behind, so it should be a database for tests only.
+func TestStore(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	storetest.Run(t, func(t *testing.T) db.Store {
+		conn, err := sql.Open("postgres", url)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		require.NoError(t, conn.Ping())
+		return db.NewStore(conn, &config.Config{})
+	})
+}
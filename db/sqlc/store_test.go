@@ -0,0 +1,20 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryable(t *testing.T) {
+	require.True(t, retryable(&pq.Error{Code: "40001"}))
+	require.True(t, retryable(&pq.Error{Code: "40P01"}))
+	require.True(t, retryable(fmt.Errorf("cannot create user: %w", &pq.Error{Code: "40001"})))
+
+	require.False(t, retryable(&pq.Error{Code: "23505"}))
+	require.False(t, retryable(sql.ErrNoRows))
+	require.False(t, retryable(nil))
+}
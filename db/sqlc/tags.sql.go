@@ -0,0 +1,173 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: tags.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const listPostsByTag = `-- name: ListPostsByTag :many
+SELECT posts.id, posts.content, posts.user_identity_id, posts.created_at, posts.updated_at, posts.search_vector, posts.revision, posts.edited, posts.deleted_at, posts.status, posts.publish_at, posts.content_warnings, posts.nsfw, posts.flags_moderated_at
+FROM posts
+JOIN post_tags ON post_tags.post_id = posts.id
+WHERE post_tags.tag = $1
+    AND posts.deleted_at IS NULL AND posts.status = 'published'
+    AND NOT hidden_from($2::uuid, posts.user_identity_id, posts.content)
+    AND NOT hidden_by_preference($2::uuid, posts.content_warnings, posts.nsfw)
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT 20
+OFFSET $3
+`
+
+type ListPostsByTagParams struct {
+	Tag        string    `json:"tag"`
+	ViewerID   uuid.UUID `json:"viewer_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListPostsByTag(ctx context.Context, arg ListPostsByTagParams) ([]Post, error) {
+	rows, err := q.query(ctx, q.listPostsByTagStmt, listPostsByTag, arg.Tag, arg.ViewerID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.UserIdentityID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.SearchVector,
+			&i.Revision,
+			&i.Edited,
+			&i.DeletedAt,
+			&i.Status,
+			&i.PublishAt,
+			pq.Array(&i.ContentWarnings),
+			&i.Nsfw,
+			&i.FlagsModeratedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrendingTags = `-- name: ListTrendingTags :many
+SELECT post_tags.tag AS name, count(*) AS post_count
+FROM post_tags
+JOIN posts ON posts.id = post_tags.post_id
+WHERE post_tags.created_at >= now() - $1::int * interval '1 day' AND posts.deleted_at IS NULL AND posts.status = 'published'
+GROUP BY post_tags.tag
+ORDER BY post_count DESC, post_tags.tag
+LIMIT 20
+`
+
+type ListTrendingTagsRow struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+func (q *Queries) ListTrendingTags(ctx context.Context, days int32) ([]ListTrendingTagsRow, error) {
+	rows, err := q.query(ctx, q.listTrendingTagsStmt, listTrendingTags, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTrendingTagsRow
+	for rows.Next() {
+		var i ListTrendingTagsRow
+		if err := rows.Scan(&i.Name, &i.PostCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTags = `-- name: SearchTags :many
+SELECT tags.name, count(post_tags.post_id) AS post_count
+FROM tags
+JOIN post_tags ON post_tags.tag = tags.name
+JOIN posts ON posts.id = post_tags.post_id
+WHERE tags.name LIKE $1::varchar || '%' AND posts.deleted_at IS NULL AND posts.status = 'published'
+GROUP BY tags.name
+ORDER BY post_count DESC, tags.name
+LIMIT 10
+`
+
+type SearchTagsRow struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// the prefix has to be escaped for LIKE
+func (q *Queries) SearchTags(ctx context.Context, prefix string) ([]SearchTagsRow, error) {
+	rows, err := q.query(ctx, q.searchTagsStmt, searchTags, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTagsRow
+	for rows.Next() {
+		var i SearchTagsRow
+		if err := rows.Scan(&i.Name, &i.PostCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPostTags = `-- name: SetPostTags :exec
+WITH new_tags AS (
+    INSERT INTO tags (name)
+    SELECT unnest($2::varchar[])
+    ON CONFLICT (name) DO NOTHING
+), removed AS (
+    DELETE FROM post_tags
+    WHERE post_tags.post_id = $1::uuid AND NOT (post_tags.tag = ANY($2::varchar[]))
+)
+INSERT INTO post_tags (post_id, tag)
+SELECT $1::uuid, unnest($2::varchar[])
+ON CONFLICT (post_id, tag) DO NOTHING
+`
+
+type SetPostTagsParams struct {
+	PostID uuid.UUID `json:"post_id"`
+	Tags   []string  `json:"tags"`
+}
+
+// replaces the tags of a post in one statement, so the tag index never sees a half updated post
+func (q *Queries) SetPostTags(ctx context.Context, arg SetPostTagsParams) error {
+	_, err := q.exec(ctx, q.setPostTagsStmt, setPostTags, arg.PostID, pq.Array(arg.Tags))
+	return err
+}
@@ -7,51 +7,361 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const clearDefaultUserIdentity = `-- name: ClearDefaultUserIdentity :exec
+UPDATE "user_identities"
+SET is_default = false
+WHERE user_id = $1::uuid AND is_default = true AND id <> $2
+`
+
+type ClearDefaultUserIdentityParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// unsets the default of the user other than the identity, before SetDefaultUserIdentity. The unique index on the
+// default of a user is checked row by row, so both cannot be done in one statement.
+func (q *Queries) ClearDefaultUserIdentity(ctx context.Context, arg ClearDefaultUserIdentityParams) error {
+	_, err := q.exec(ctx, q.clearDefaultUserIdentityStmt, clearDefaultUserIdentity, arg.UserID, arg.ID)
+	return err
+}
+
+const countActiveUserIdentities = `-- name: CountActiveUserIdentities :one
+SELECT COUNT(*) FROM "user_identities" WHERE user_id = $1::uuid AND retired = false
+`
+
+func (q *Queries) CountActiveUserIdentities(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.queryRow(ctx, q.countActiveUserIdentitiesStmt, countActiveUserIdentities, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUserIdentity = `-- name: CreateUserIdentity :one
 INSERT INTO "user_identities"(
 	id,
 	user_id,
-	identity_hash
+	identity_hash,
+	name,
+	is_default,
+	rotation_interval_days,
+	rotation_detach
 ) VALUES (
-	$1, $2, $3
-) RETURNING id, user_id, identity_hash
+	$1,
+	$2::uuid,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+) RETURNING id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public
 `
 
 type CreateUserIdentityParams struct {
-	ID           uuid.UUID `json:"id"`
-	UserID       uuid.UUID `json:"user_id"`
-	IdentityHash uuid.UUID `json:"identity_hash"`
+	ID                   uuid.UUID     `json:"id"`
+	UserID               uuid.UUID     `json:"user_id"`
+	IdentityHash         uuid.UUID     `json:"identity_hash"`
+	Name                 string        `json:"name"`
+	IsDefault            bool          `json:"is_default"`
+	RotationIntervalDays sql.NullInt32 `json:"rotation_interval_days"`
+	RotationDetach       bool          `json:"rotation_detach"`
 }
 
 func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
-	row := q.queryRow(ctx, q.createUserIdentityStmt, createUserIdentity, arg.ID, arg.UserID, arg.IdentityHash)
+	row := q.queryRow(ctx, q.createUserIdentityStmt, createUserIdentity,
+		arg.ID,
+		arg.UserID,
+		arg.IdentityHash,
+		arg.Name,
+		arg.IsDefault,
+		arg.RotationIntervalDays,
+		arg.RotationDetach,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IdentityHash,
+		&i.Name,
+		&i.IsDefault,
+		&i.Retired,
+		&i.CreatedAt,
+		&i.RotatedAt,
+		&i.RotationIntervalDays,
+		&i.RotationDetach,
+		&i.IsPublic,
+	)
+	return i, err
+}
+
+const getDefaultUserIdentity = `-- name: GetDefaultUserIdentity :one
+SELECT id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public FROM "user_identities" WHERE user_id = $1::uuid AND is_default = true LIMIT 1
+`
+
+func (q *Queries) GetDefaultUserIdentity(ctx context.Context, userID uuid.UUID) (UserIdentity, error) {
+	row := q.queryRow(ctx, q.getDefaultUserIdentityStmt, getDefaultUserIdentity, userID)
 	var i UserIdentity
-	err := row.Scan(&i.ID, &i.UserID, &i.IdentityHash)
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IdentityHash,
+		&i.Name,
+		&i.IsDefault,
+		&i.Retired,
+		&i.CreatedAt,
+		&i.RotatedAt,
+		&i.RotationIntervalDays,
+		&i.RotationDetach,
+		&i.IsPublic,
+	)
 	return i, err
 }
 
 const getUserIdentityById = `-- name: GetUserIdentityById :one
-SELECT id, user_id, identity_hash FROM "user_identities" WHERE id = $1 LIMIT 1
+SELECT id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public FROM "user_identities" WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetUserIdentityById(ctx context.Context, id uuid.UUID) (UserIdentity, error) {
 	row := q.queryRow(ctx, q.getUserIdentityByIdStmt, getUserIdentityById, id)
 	var i UserIdentity
-	err := row.Scan(&i.ID, &i.UserID, &i.IdentityHash)
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IdentityHash,
+		&i.Name,
+		&i.IsDefault,
+		&i.Retired,
+		&i.CreatedAt,
+		&i.RotatedAt,
+		&i.RotationIntervalDays,
+		&i.RotationDetach,
+		&i.IsPublic,
+	)
+	return i, err
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public FROM "user_identities" WHERE user_id = $1::uuid ORDER BY created_at ASC
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error) {
+	rows, err := q.query(ctx, q.listUserIdentitiesStmt, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IdentityHash,
+			&i.Name,
+			&i.IsDefault,
+			&i.Retired,
+			&i.CreatedAt,
+			&i.RotatedAt,
+			&i.RotationIntervalDays,
+			&i.RotationDetach,
+			&i.IsPublic,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserIdentitiesDueForRotation = `-- name: ListUserIdentitiesDueForRotation :many
+SELECT id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public FROM "user_identities"
+WHERE retired = false
+	AND user_id IS NOT NULL
+	AND rotation_interval_days IS NOT NULL
+	AND rotated_at + rotation_interval_days * interval '1 day' <= now()
+ORDER BY rotated_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+// locks the due identities until the transaction it runs in ends. SKIP LOCKED lets several servers share the
+// rotations without rotating an identity twice.
+func (q *Queries) ListUserIdentitiesDueForRotation(ctx context.Context, limit int32) ([]UserIdentity, error) {
+	rows, err := q.query(ctx, q.listUserIdentitiesDueForRotationStmt, listUserIdentitiesDueForRotation, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserIdentity
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IdentityHash,
+			&i.Name,
+			&i.IsDefault,
+			&i.Retired,
+			&i.CreatedAt,
+			&i.RotatedAt,
+			&i.RotationIntervalDays,
+			&i.RotationDetach,
+			&i.IsPublic,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const replaceUserIdentity = `-- name: ReplaceUserIdentity :one
+UPDATE "user_identities"
+SET retired = true,
+	is_default = false,
+	rotation_interval_days = NULL,
+	user_id = CASE WHEN $1::boolean THEN NULL ELSE user_id END
+WHERE id = $2 AND retired = false
+RETURNING id
+`
+
+type ReplaceUserIdentityParams struct {
+	Detach bool      `json:"detach"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// retires the identity ahead of its replacement, an identity already replaced is not found
+func (q *Queries) ReplaceUserIdentity(ctx context.Context, arg ReplaceUserIdentityParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.replaceUserIdentityStmt, replaceUserIdentity, arg.Detach, arg.ID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const retireUserIdentity = `-- name: RetireUserIdentity :one
+UPDATE "user_identities"
+SET retired = true
+WHERE id = $1 AND user_id = $2::uuid AND is_default = false
+RETURNING id
+`
+
+type RetireUserIdentityParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) RetireUserIdentity(ctx context.Context, arg RetireUserIdentityParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.retireUserIdentityStmt, retireUserIdentity, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setDefaultUserIdentity = `-- name: SetDefaultUserIdentity :exec
+UPDATE "user_identities"
+SET is_default = true
+WHERE id = $1 AND user_id = $2::uuid
+`
+
+type SetDefaultUserIdentityParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) SetDefaultUserIdentity(ctx context.Context, arg SetDefaultUserIdentityParams) error {
+	_, err := q.exec(ctx, q.setDefaultUserIdentityStmt, setDefaultUserIdentity, arg.ID, arg.UserID)
+	return err
+}
+
+const updateUserIdentity = `-- name: UpdateUserIdentity :one
+UPDATE "user_identities"
+SET name = COALESCE($1, name),
+	is_public = COALESCE($2, is_public)
+WHERE id = $3 AND user_id = $4::uuid
+RETURNING id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public
+`
+
+type UpdateUserIdentityParams struct {
+	Name     sql.NullString `json:"name"`
+	IsPublic sql.NullBool   `json:"is_public"`
+	ID       uuid.UUID      `json:"id"`
+	UserID   uuid.UUID      `json:"user_id"`
+}
+
+func (q *Queries) UpdateUserIdentity(ctx context.Context, arg UpdateUserIdentityParams) (UserIdentity, error) {
+	row := q.queryRow(ctx, q.updateUserIdentityStmt, updateUserIdentity,
+		arg.Name,
+		arg.IsPublic,
+		arg.ID,
+		arg.UserID,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IdentityHash,
+		&i.Name,
+		&i.IsDefault,
+		&i.Retired,
+		&i.CreatedAt,
+		&i.RotatedAt,
+		&i.RotationIntervalDays,
+		&i.RotationDetach,
+		&i.IsPublic,
+	)
 	return i, err
 }
 
-const getUserIdentityByUserId = `-- name: GetUserIdentityByUserId :one
-SELECT id, user_id, identity_hash FROM "user_identities" WHERE user_id = $1 LIMIT 1
+const updateUserIdentityRotation = `-- name: UpdateUserIdentityRotation :one
+UPDATE "user_identities"
+SET rotation_interval_days = $1, rotation_detach = $2
+WHERE id = $3 AND user_id = $4::uuid AND retired = false
+RETURNING id, user_id, identity_hash, name, is_default, retired, created_at, rotated_at, rotation_interval_days, rotation_detach, is_public
 `
 
-func (q *Queries) GetUserIdentityByUserId(ctx context.Context, userID uuid.UUID) (UserIdentity, error) {
-	row := q.queryRow(ctx, q.getUserIdentityByUserIdStmt, getUserIdentityByUserId, userID)
+type UpdateUserIdentityRotationParams struct {
+	RotationIntervalDays sql.NullInt32 `json:"rotation_interval_days"`
+	RotationDetach       bool          `json:"rotation_detach"`
+	ID                   uuid.UUID     `json:"id"`
+	UserID               uuid.UUID     `json:"user_id"`
+}
+
+func (q *Queries) UpdateUserIdentityRotation(ctx context.Context, arg UpdateUserIdentityRotationParams) (UserIdentity, error) {
+	row := q.queryRow(ctx, q.updateUserIdentityRotationStmt, updateUserIdentityRotation,
+		arg.RotationIntervalDays,
+		arg.RotationDetach,
+		arg.ID,
+		arg.UserID,
+	)
 	var i UserIdentity
-	err := row.Scan(&i.ID, &i.UserID, &i.IdentityHash)
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IdentityHash,
+		&i.Name,
+		&i.IsDefault,
+		&i.Retired,
+		&i.CreatedAt,
+		&i.RotatedAt,
+		&i.RotationIntervalDays,
+		&i.RotationDetach,
+		&i.IsPublic,
+	)
 	return i, err
 }
@@ -7,9 +7,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -42,21 +44,59 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UU
 }
 
 const deleteOneUser = `-- name: DeleteOneUser :one
-DELETE FROM "users"
-WHERE id = $1
-RETURNING id
+UPDATE "users"
+SET deleted_at = now(), delete_after = now() + $1::float8 * interval '1 second'
+WHERE users.id = $2 AND users.deleted_at IS NULL
+RETURNING users.delete_after
 `
 
-func (q *Queries) DeleteOneUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
-	row := q.queryRow(ctx, q.deleteOneUserStmt, deleteOneUser, id)
-	err := row.Scan(&id)
-	return id, err
+type DeleteOneUserParams struct {
+	GraceSeconds float64   `json:"grace_seconds"`
+	ID           uuid.UUID `json:"id"`
+}
+
+// schedules the account for deletion after the grace period, its content is hidden until the user logs in again
+// or the account is purged
+func (q *Queries) DeleteOneUser(ctx context.Context, arg DeleteOneUserParams) (*time.Time, error) {
+	row := q.queryRow(ctx, q.deleteOneUserStmt, deleteOneUser, arg.GraceSeconds, arg.ID)
+	var delete_after *time.Time
+	err := row.Scan(&delete_after)
+	return delete_after, err
+}
+
+const getDeletedUserByUsername = `-- name: GetDeletedUserByUsername :one
+SELECT id, username, password, created_at, updated_at, display_name, bio, avatar_url, confession_prompt, links, is_moderator, deleted_at, delete_after
+FROM "users"
+WHERE username = $1 AND deleted_at IS NOT NULL AND delete_after > now()
+LIMIT 1
+`
+
+// the account waiting for its deletion, until its grace period is over
+func (q *Queries) GetDeletedUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.queryRow(ctx, q.getDeletedUserByUsernameStmt, getDeletedUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.ConfessionPrompt,
+		pq.Array(&i.Links),
+		&i.IsModerator,
+		&i.DeletedAt,
+		&i.DeleteAfter,
+	)
+	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, username, password, created_at, updated_at
+SELECT id, username, password, created_at, updated_at, display_name, bio, avatar_url, confession_prompt, links, is_moderator, deleted_at, delete_after
 FROM "users"
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -69,14 +109,22 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.ConfessionPrompt,
+		pq.Array(&i.Links),
+		&i.IsModerator,
+		&i.DeletedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, created_at, updated_at
+SELECT id, username, password, created_at, updated_at, display_name, bio, avatar_url, confession_prompt, links, is_moderator, deleted_at, delete_after
 FROM "users"
-WHERE username = $1
+WHERE username = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -89,13 +137,22 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Password,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.ConfessionPrompt,
+		pq.Array(&i.Links),
+		&i.IsModerator,
+		&i.DeletedAt,
+		&i.DeleteAfter,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, password, created_at, updated_at
+SELECT id, username, password, created_at, updated_at, display_name, bio, avatar_url, confession_prompt, links, is_moderator, deleted_at, delete_after
 FROM "users"
+WHERE deleted_at IS NULL
 LIMIT 20
 OFFSET $1
 `
@@ -115,6 +172,71 @@ func (q *Queries) ListUsers(ctx context.Context, offset int32) ([]User, error) {
 			&i.Password,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.ConfessionPrompt,
+			pq.Array(&i.Links),
+			&i.IsModerator,
+			&i.DeletedAt,
+			&i.DeleteAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeUsers = `-- name: PurgeUsers :many
+WITH purged AS (
+    DELETE FROM "users" WHERE users.delete_after < now() RETURNING users.id
+)
+SELECT media.id, media.kind, media.user_id, media.post_id, media.message_id, media.storage_key, media.thumbnail_key, media.content_type, media.size, media.width, media.height, media.is_private, media.created_at
+FROM media
+WHERE media.user_id IN (SELECT purged.id FROM purged)
+    OR media.message_id IN (
+        SELECT messages.id FROM messages WHERE messages.receiver_id IN (SELECT purged.id FROM purged)
+    )
+    OR media.post_id IN (
+        SELECT posts.id
+        FROM posts
+        JOIN user_identities ON user_identities.id = posts.user_identity_id
+        WHERE user_identities.user_id IN (SELECT purged.id FROM purged)
+    )
+`
+
+// removes the accounts whose grace period is over for good, everything they own goes with them.
+// Returns their media so the stored files can be removed too.
+func (q *Queries) PurgeUsers(ctx context.Context) ([]Media, error) {
+	rows, err := q.query(ctx, q.purgeUsersStmt, purgeUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.PostID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.ContentType,
+			&i.Size,
+			&i.Width,
+			&i.Height,
+			&i.IsPrivate,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,6 +251,39 @@ func (q *Queries) ListUsers(ctx context.Context, offset int32) ([]User, error) {
 	return items, nil
 }
 
+const restoreUser = `-- name: RestoreUser :one
+UPDATE "users"
+SET deleted_at = NULL, delete_after = NULL
+WHERE id = $1 AND deleted_at IS NOT NULL AND delete_after > now()
+RETURNING id
+`
+
+// cancels the scheduled deletion of the account, unless its grace period is over
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.restoreUserStmt, restoreUser, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setUserModerator = `-- name: SetUserModerator :one
+UPDATE "users"
+SET is_moderator = $1, updated_at = now()
+WHERE id = $2 AND deleted_at IS NULL
+RETURNING id
+`
+
+type SetUserModeratorParams struct {
+	IsModerator bool      `json:"is_moderator"`
+	ID          uuid.UUID `json:"id"`
+}
+
+func (q *Queries) SetUserModerator(ctx context.Context, arg SetUserModeratorParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.setUserModeratorStmt, setUserModerator, arg.IsModerator, arg.ID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE "users"
 SET password = $1, updated_at = $2
@@ -149,6 +304,57 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	return id, err
 }
 
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE "users"
+SET display_name = COALESCE($1, display_name),
+    bio = COALESCE($2, bio),
+    avatar_url = COALESCE($3, avatar_url),
+    confession_prompt = COALESCE($4, confession_prompt),
+    links = COALESCE($5, links),
+    updated_at = $6
+WHERE id = $7
+RETURNING id, username, password, created_at, updated_at, display_name, bio, avatar_url, confession_prompt, links, is_moderator, deleted_at, delete_after
+`
+
+type UpdateUserProfileParams struct {
+	DisplayName      sql.NullString `json:"display_name"`
+	Bio              sql.NullString `json:"bio"`
+	AvatarUrl        sql.NullString `json:"avatar_url"`
+	ConfessionPrompt sql.NullString `json:"confession_prompt"`
+	Links            []string       `json:"links"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	ID               uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserProfileStmt, updateUserProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.ConfessionPrompt,
+		pq.Array(arg.Links),
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.ConfessionPrompt,
+		pq.Array(&i.Links),
+		&i.IsModerator,
+		&i.DeletedAt,
+		&i.DeleteAfter,
+	)
+	return i, err
+}
+
 const updateUsername = `-- name: UpdateUsername :one
 UPDATE "users"
 SET username = $1, updated_at = $2
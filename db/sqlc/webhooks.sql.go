@@ -0,0 +1,471 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.16.0
+// source: webhooks.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE "webhook_deliveries"
+SET next_attempt_at = now() + $1::float8 * interval '1 second'
+FROM "webhooks"
+WHERE webhooks.id = webhook_deliveries.webhook_id AND webhook_deliveries.id IN (
+    SELECT pending.id
+    FROM "webhook_deliveries" AS pending
+    JOIN "webhooks" AS pending_webhooks ON pending_webhooks.id = pending.webhook_id
+    WHERE pending.status = 'pending' AND pending.next_attempt_at <= now() AND pending_webhooks.active
+    ORDER BY pending.next_attempt_at
+    LIMIT $2
+    FOR UPDATE OF pending SKIP LOCKED
+)
+RETURNING webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.error, webhook_deliveries.created_at, webhook_deliveries.delivered_at, webhooks.url, webhooks.secret
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseSeconds float64 `json:"lease_seconds"`
+	BatchSize    int32   `json:"batch_size"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	Error          string          `json:"error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Url            string          `json:"url"`
+	Secret         string          `json:"secret"`
+}
+
+// takes the due deliveries of active webhooks and moves their next attempt past the lease, so a server that
+// dies while delivering leaves them to be retried. SKIP LOCKED lets several servers share the queue.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.query(ctx, q.claimWebhookDeliveriesStmt, claimWebhookDeliveries, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.Error,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeWebhookDelivery = `-- name: CompleteWebhookDelivery :exec
+UPDATE "webhook_deliveries"
+SET status = 'succeeded', attempts = attempts + 1, response_status = $1, error = '',
+    delivered_at = now()
+WHERE id = $2
+`
+
+type CompleteWebhookDeliveryParams struct {
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	ID             uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) CompleteWebhookDelivery(ctx context.Context, arg CompleteWebhookDeliveryParams) error {
+	_, err := q.exec(ctx, q.completeWebhookDeliveryStmt, completeWebhookDelivery, arg.ResponseStatus, arg.ID)
+	return err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO "webhooks" (
+    id, user_id, url, secret, events
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, user_id, url, secret, events, active, created_at, updated_at
+`
+
+type CreateWebhookParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Url    string    `json:"url"`
+	Secret string    `json:"secret"`
+	Events []string  `json:"events"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.createWebhookStmt, createWebhook,
+		arg.ID,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		pq.Array(arg.Events),
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO "webhook_deliveries" (
+    id, webhook_id, event, payload
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, error, created_at, delivered_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	ID        uuid.UUID       `json:"id"`
+	WebhookID uuid.UUID       `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.queryRow(ctx, q.createWebhookDeliveryStmt, createWebhookDelivery,
+		arg.ID,
+		arg.WebhookID,
+		arg.Event,
+		arg.Payload,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.Error,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :one
+DELETE FROM "webhooks" WHERE id = $1 AND user_id = $2 RETURNING id
+`
+
+type DeleteWebhookParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (uuid.UUID, error) {
+	row := q.queryRow(ctx, q.deleteWebhookStmt, deleteWebhook, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const failWebhookDelivery = `-- name: FailWebhookDelivery :exec
+UPDATE "webhook_deliveries"
+SET attempts = attempts + 1,
+    response_status = $1,
+    error = $2,
+    status = CASE WHEN attempts + 1 >= $3::int THEN 'failed' ELSE 'pending' END,
+    next_attempt_at = now() + $4::float8 * interval '1 second'
+WHERE id = $5
+`
+
+type FailWebhookDeliveryParams struct {
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	Error          string        `json:"error"`
+	MaxAttempts    int32         `json:"max_attempts"`
+	RetrySeconds   float64       `json:"retry_seconds"`
+	ID             uuid.UUID     `json:"id"`
+}
+
+// records a failed attempt, the delivery is given up once it has had max_attempts
+func (q *Queries) FailWebhookDelivery(ctx context.Context, arg FailWebhookDeliveryParams) error {
+	_, err := q.exec(ctx, q.failWebhookDeliveryStmt, failWebhookDelivery,
+		arg.ResponseStatus,
+		arg.Error,
+		arg.MaxAttempts,
+		arg.RetrySeconds,
+		arg.ID,
+	)
+	return err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, user_id, url, secret, events, active, created_at, updated_at FROM "webhooks" WHERE id = $1 AND user_id = $2 LIMIT 1
+`
+
+type GetWebhookParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.getWebhookStmt, getWebhook, arg.ID, arg.UserID)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload, webhook_deliveries.status, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at, webhook_deliveries.response_status, webhook_deliveries.error, webhook_deliveries.created_at, webhook_deliveries.delivered_at
+FROM "webhook_deliveries"
+JOIN "webhooks" ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhooks.id = $1 AND webhooks.user_id = $2
+ORDER BY webhook_deliveries.created_at DESC, webhook_deliveries.id
+LIMIT 20
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID  uuid.UUID `json:"webhook_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	PageOffset int32     `json:"page_offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.query(ctx, q.listWebhookDeliveriesStmt, listWebhookDeliveries, arg.WebhookID, arg.UserID, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.ResponseStatus,
+			&i.Error,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, user_id, url, secret, events, active, created_at, updated_at FROM "webhooks" WHERE user_id = $1 ORDER BY created_at, id
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.listWebhooksStmt, listWebhooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			pq.Array(&i.Events),
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooksForEvent = `-- name: ListWebhooksForEvent :many
+SELECT id, user_id, url, secret, events, active, created_at, updated_at FROM "webhooks"
+WHERE user_id = $1 AND active AND $2::varchar = ANY(events)
+`
+
+type ListWebhooksForEventParams struct {
+	UserID uuid.UUID `json:"user_id"`
+	Event  string    `json:"event"`
+}
+
+// the active webhooks of the user subscribed to the event
+func (q *Queries) ListWebhooksForEvent(ctx context.Context, arg ListWebhooksForEventParams) ([]Webhook, error) {
+	rows, err := q.query(ctx, q.listWebhooksForEventStmt, listWebhooksForEvent, arg.UserID, arg.Event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			&i.Secret,
+			pq.Array(&i.Events),
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+INSERT INTO "webhook_deliveries" (id, webhook_id, event, payload)
+SELECT $1, webhook_deliveries.webhook_id, webhook_deliveries.event, webhook_deliveries.payload
+FROM "webhook_deliveries"
+JOIN "webhooks" ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.id = $2
+    AND webhooks.id = $3
+    AND webhooks.user_id = $4
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, response_status, error, created_at, delivered_at
+`
+
+type RedeliverWebhookDeliveryParams struct {
+	NewID     uuid.UUID `json:"new_id"`
+	ID        uuid.UUID `json:"id"`
+	WebhookID uuid.UUID `json:"webhook_id"`
+	UserID    uuid.UUID `json:"user_id"`
+}
+
+// queues a copy of the delivery, the original stays in the log as it was
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, arg RedeliverWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.queryRow(ctx, q.redeliverWebhookDeliveryStmt, redeliverWebhookDelivery,
+		arg.NewID,
+		arg.ID,
+		arg.WebhookID,
+		arg.UserID,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.ResponseStatus,
+		&i.Error,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE "webhooks"
+SET url = coalesce($1, url),
+    events = coalesce($2::varchar[], events),
+    active = coalesce($3, active),
+    updated_at = now()
+WHERE id = $4 AND user_id = $5
+RETURNING id, user_id, url, secret, events, active, created_at, updated_at
+`
+
+type UpdateWebhookParams struct {
+	Url    sql.NullString `json:"url"`
+	Events []string       `json:"events"`
+	Active sql.NullBool   `json:"active"`
+	ID     uuid.UUID      `json:"id"`
+	UserID uuid.UUID      `json:"user_id"`
+}
+
+// fields left null keep their value
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.updateWebhookStmt, updateWebhook,
+		arg.Url,
+		pq.Array(arg.Events),
+		arg.Active,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,1742 @@
+// Package storetest holds the tests every db.Store has to pass, so the stores behave the same to the
+// handlers. The tests share the store they are given and may find rows of other tests or of earlier runs in
+// it, so each one makes its own users and only looks at what they can see.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	db "cnfs/db/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the tests against the store newStore returns.
+func Run(t *testing.T, newStore func(t *testing.T) db.Store) {
+	store := newStore(t)
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, store db.Store)
+	}{
+		{"Users", testUsers},
+		{"Sessions", testSessions},
+		{"Timestamps", testTimestamps},
+		{"ExecTx", testExecTx},
+		{"ChangePasswordTx", testChangePasswordTx},
+		{"DeleteAccountTx", testDeleteAccountTx},
+		{"Identities", testIdentities},
+		{"Comments", testComments},
+		{"Visibility", testVisibility},
+		{"Feed", testFeed},
+		{"Cascade", testCascade},
+		{"PostTx", testPostTx},
+		{"Polls", testPolls},
+		{"PostScores", testPostScores},
+		{"Notifications", testNotifications},
+		{"Webhooks", testWebhooks},
+		{"Digests", testDigests},
+		{"Search", testSearch},
+		{"Blocks", testBlocks},
+		{"Tags", testTags},
+		{"Reactions", testReactions},
+		{"Drafts", testDrafts},
+		{"Revisions", testRevisions},
+		{"Media", testMedia},
+		{"Trash", testTrash},
+		{"Exports", testExports},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, store)
+		})
+	}
+}
+
+func testUsers(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	got, err := store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, user.UserID, got.ID)
+	require.Empty(t, got.Links)
+
+	byName, err := store.GetUserByUsername(ctx, got.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.UserID, byName.ID)
+
+	_, err = store.CreateUser(ctx, db.CreateUserParams{
+		ID:        uuid.New(),
+		Username:  got.Username,
+		Password:  "secret",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	requireViolation(t, err, "unique_violation")
+
+	_, err = store.SetUserModerator(ctx, db.SetUserModeratorParams{IsModerator: true, ID: user.UserID})
+	require.NoError(t, err)
+	got, err = store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+	require.True(t, got.IsModerator)
+
+	_, err = store.GetUserById(ctx, uuid.New())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testSessions(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	_, err := store.CreateSession(ctx, sessionParams(uuid.New()))
+	requireViolation(t, err, "foreign_key_violation")
+
+	session, err := store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+	require.False(t, session.IsBlocked)
+
+	_, err = store.BlockSession(ctx, session.ID)
+	require.NoError(t, err)
+	got, err := store.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, got.IsBlocked)
+
+	_, err = store.DeleteSession(ctx, session.ID)
+	require.NoError(t, err)
+	_, err = store.GetSessionById(ctx, session.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	params := sessionParams(user.UserID)
+	params.ExpiresAt = time.Now().Add(-time.Minute)
+	expired, err := store.CreateSession(ctx, params)
+	require.NoError(t, err)
+	live, err := store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+
+	pruned, err := store.DeleteExpiredSessions(ctx)
+	require.NoError(t, err)
+	require.Positive(t, pruned)
+	_, err = store.GetSessionById(ctx, expired.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetSessionById(ctx, live.ID)
+	require.NoError(t, err)
+}
+
+func testTimestamps(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	// the times are kept to the microsecond, not only the day
+	params := sessionParams(user.UserID)
+	params.ExpiresAt = time.Now().Add(90 * time.Minute)
+	session, err := store.CreateSession(ctx, params)
+	require.NoError(t, err)
+	require.WithinDuration(t, params.ExpiresAt, session.ExpiresAt, time.Microsecond)
+	require.WithinDuration(t, time.Now(), session.CreatedAt, time.Minute)
+
+	public, err := store.UpdateUserIdentity(ctx, db.UpdateUserIdentityParams{
+		IsPublic: sql.NullBool{Bool: true, Valid: true},
+		ID:       user.Identity.ID,
+		UserID:   user.UserID,
+	})
+	require.NoError(t, err)
+
+	// so posts of the same day come newest first
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		ids = append([]uuid.UUID{createPost(t, store, public.ID, "in order").ID}, ids...)
+		time.Sleep(time.Millisecond)
+	}
+	posts, err := store.ListPublicPostsByUserId(ctx, db.ListPublicPostsByUserIdParams{UserID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, ids, postIDs(posts))
+
+	// the columns written by now() agree with the times of the client, whatever the time zone of either
+	publishAt := time.Now().In(time.FixedZone("", -5*60*60)).Add(time.Hour)
+	draft, err := store.CreatePost(ctx, db.CreatePostParams{
+		ID:              uuid.New(),
+		Content:         "later",
+		UserIdentityID:  public.ID,
+		Status:          "scheduled",
+		PublishAt:       &publishAt,
+		ContentWarnings: []string{},
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, publishAt, *draft.PublishAt, time.Microsecond)
+
+	deleteAfter, err := store.DeleteAccountTx(ctx, db.DeleteOneUserParams{ID: user.UserID, GraceSeconds: 3600})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), deleteAfter, time.Minute)
+}
+
+func testExecTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	params := userParams()
+	failure := errors.New("failure")
+
+	err := store.ExecTx(ctx, func(q db.Querier) error {
+		if _, err := q.CreateUser(ctx, params); err != nil {
+			return err
+		}
+		// the transaction sees its own changes
+		if _, err := q.GetUserById(ctx, params.ID); err != nil {
+			return err
+		}
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	_, err = store.GetUserById(ctx, params.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// a failing query rolls back what the transaction did before it
+	user := createUser(t, store)
+	other, err := store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+
+	_, err = store.CreateUserTx(ctx, db.CreateUserTxParams{
+		CreateUserParams: params,
+		Identity:         db.CreateUserIdentityParams{ID: user.Identity.ID, IdentityHash: uuid.New(), Name: "taken"},
+	})
+	requireViolation(t, err, "unique_violation")
+	_, err = store.GetUserById(ctx, params.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.CreateUserTx(ctx, db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{ID: uuid.New(), Username: other.Username, Password: "secret"},
+	})
+	requireViolation(t, err, "unique_violation")
+}
+
+func testChangePasswordTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	session, err := store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+	other, err := store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+
+	username := randomName()
+	result, err := store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		UserID:    user.UserID,
+		SessionID: session.ID,
+		Username:  sql.NullString{String: username, Valid: true},
+		Password:  sql.NullString{String: "changed", Valid: true},
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, session.ID, result.SessionID)
+
+	got, err := store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, username, got.Username)
+	require.Equal(t, "changed", got.Password)
+
+	_, err = store.GetSessionById(ctx, session.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetSessionById(ctx, other.ID)
+	require.NoError(t, err)
+
+	// a taken username leaves the password as it was
+	taken := createUser(t, store)
+	takenUser, err := store.GetUserById(ctx, taken.UserID)
+	require.NoError(t, err)
+
+	_, err = store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		UserID:    user.UserID,
+		SessionID: other.ID,
+		Username:  sql.NullString{String: takenUser.Username, Valid: true},
+		Password:  sql.NullString{String: "lost", Valid: true},
+		UpdatedAt: time.Now(),
+	})
+	requireViolation(t, err, "unique_violation")
+
+	got, err = store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, "changed", got.Password)
+	_, err = store.GetSessionById(ctx, other.ID)
+	require.NoError(t, err)
+}
+
+func testDeleteAccountTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	got, err := store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+
+	_, err = store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+	_, err = store.CreatePushSubscription(ctx, db.CreatePushSubscriptionParams{
+		ID:       uuid.New(),
+		UserID:   user.UserID,
+		Endpoint: "https://push.example.com/" + uuid.NewString(),
+		P256dh:   "key",
+		Auth:     "auth",
+	})
+	require.NoError(t, err)
+
+	deleteAfter, err := store.DeleteAccountTx(ctx, db.DeleteOneUserParams{ID: user.UserID, GraceSeconds: 3600})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), deleteAfter, time.Minute)
+
+	_, err = store.GetUserById(ctx, user.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	sessions, err := store.ListUserSessions(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+	subscriptions, err := store.ListPushSubscriptions(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Empty(t, subscriptions)
+
+	// a deleted account is only deleted once
+	_, err = store.DeleteAccountTx(ctx, db.DeleteOneUserParams{ID: user.UserID, GraceSeconds: 3600})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// logging in during the grace period cancels the deletion
+	deleted, err := store.GetDeletedUserByUsername(ctx, got.Username)
+	require.NoError(t, err)
+	_, err = store.RestoreUser(ctx, deleted.ID)
+	require.NoError(t, err)
+	_, err = store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+
+	// after it the deletion is final, even before the account is purged
+	_, err = store.DeleteAccountTx(ctx, db.DeleteOneUserParams{ID: user.UserID})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = store.GetDeletedUserByUsername(ctx, got.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.RestoreUser(ctx, user.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testIdentities(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	other := createIdentity(t, store, user.UserID)
+
+	// a user has one default identity
+	_, err := store.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		ID:           uuid.New(),
+		UserID:       user.UserID,
+		IdentityHash: uuid.New(),
+		Name:         randomName(),
+		IsDefault:    true,
+	})
+	requireViolation(t, err, "unique_violation")
+
+	require.NoError(t, store.SetDefaultUserIdentityTx(ctx, db.SetDefaultUserIdentityParams{ID: other.ID, UserID: user.UserID}))
+	def, err := store.GetDefaultUserIdentity(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, other.ID, def.ID)
+
+	// the fresh identity takes over as the default, the replaced one cannot be rotated again
+	def.RotationIntervalDays = sql.NullInt32{Int32: 30, Valid: true}
+	fresh, err := store.RotateUserIdentityTx(ctx, db.RotateUserIdentityTxParams{Identity: def})
+	require.NoError(t, err)
+	require.True(t, fresh.IsDefault)
+	require.NotEqual(t, def.IdentityHash, fresh.IdentityHash)
+	require.Equal(t, def.RotationIntervalDays, fresh.RotationIntervalDays)
+
+	def, err = store.GetDefaultUserIdentity(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, fresh.ID, def.ID)
+	replaced, err := store.GetUserIdentityById(ctx, other.ID)
+	require.NoError(t, err)
+	require.True(t, replaced.Retired)
+	require.False(t, replaced.IsDefault)
+
+	_, err = store.RotateUserIdentityTx(ctx, db.RotateUserIdentityTxParams{Identity: other})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// an identity whose schedule has elapsed is rotated once, detached when asked
+	_, err = store.UpdateUserIdentityRotation(ctx, db.UpdateUserIdentityRotationParams{
+		RotationIntervalDays: sql.NullInt32{Int32: 0, Valid: true},
+		RotationDetach:       true,
+		ID:                   fresh.ID,
+		UserID:               user.UserID,
+	})
+	require.NoError(t, err)
+
+	rotated, err := store.RotateDueUserIdentitiesTx(ctx, 1000)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rotated, 1)
+
+	replaced, err = store.GetUserIdentityById(ctx, fresh.ID)
+	require.NoError(t, err)
+	require.True(t, replaced.Retired)
+	require.False(t, replaced.UserID.Valid)
+
+	identities, err := store.ListUserIdentities(ctx, user.UserID)
+	require.NoError(t, err)
+	defaults := 0
+	for _, identity := range identities {
+		if identity.IsDefault {
+			defaults++
+		}
+	}
+	require.Equal(t, 1, defaults)
+}
+
+func testComments(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	author := createUser(t, store)
+	post := createPost(t, store, author.Identity.ID, "a post to comment on")
+
+	_, err := store.CreateComment(ctx, commentParams(author.Identity.ID, uuid.New(), uuid.Nil, "lost"))
+	requireViolation(t, err, "foreign_key_violation")
+
+	comment, err := store.CreateCommentTx(ctx, commentParams(author.Identity.ID, post.ID, uuid.Nil, "first"))
+	require.NoError(t, err)
+	reply := createComment(t, store, author.Identity.ID, post.ID, comment.ID, "reply")
+	require.Equal(t, comment.ID, comment.ParentID)
+	require.Equal(t, comment.ID, reply.ParentID)
+
+	revision, err := store.GetCommentRevision(ctx, db.GetCommentRevisionParams{CommentID: comment.ID, Revision: 1})
+	require.NoError(t, err)
+	require.Equal(t, "first", revision.Content)
+
+	_, err = store.UpdateComment(ctx, db.UpdateCommentParams{
+		EditorIdentityID: author.Identity.ID,
+		Content:          "edited",
+		UpdatedAt:        time.Now(),
+		ID:               comment.ID,
+	})
+	require.NoError(t, err)
+
+	got, err := store.GetComment(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, "edited", got.Content)
+	require.Equal(t, int32(2), got.Revision)
+	require.True(t, got.Edited)
+
+	revisions, err := store.ListCommentRevisions(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, int32(2), revisions[0].Revision)
+	require.Equal(t, "first", revisions[1].Content)
+
+	// trashing a comment hides its replies along with it
+	_, err = store.DeleteComment(ctx, comment.ID)
+	require.NoError(t, err)
+	_, err = store.GetComment(ctx, reply.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	trashed, err := store.ListTrashedComments(ctx, db.ListTrashedCommentsParams{UserID: author.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{comment.ID}, commentIDs(trashed))
+
+	_, err = store.RestoreComment(ctx, db.RestoreCommentParams{ID: comment.ID, UserID: uuid.New()})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.RestoreComment(ctx, db.RestoreCommentParams{ID: comment.ID, UserID: author.UserID})
+	require.NoError(t, err)
+	_, err = store.GetComment(ctx, reply.ID)
+	require.NoError(t, err)
+
+	// as does trashing the post
+	_, err = store.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	_, err = store.GetComment(ctx, comment.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testVisibility(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	viewer := createUser(t, store)
+	author := createUser(t, store)
+	blocked := createUser(t, store)
+	post := createPost(t, store, author.Identity.ID, "a post to comment on")
+
+	kept := createComment(t, store, author.Identity.ID, post.ID, uuid.Nil, "nothing to hide")
+	muted := createComment(t, store, author.Identity.ID, post.ID, uuid.Nil, "talking about Spoilers")
+	hidden := createComment(t, store, blocked.Identity.ID, post.ID, uuid.Nil, "from a blocked identity")
+
+	all, err := store.ListAllComments(ctx, db.ListAllCommentsParams{PostID: post.ID, ViewerID: viewer.UserID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{kept.ID, muted.ID, hidden.ID}, commentIDs(all))
+
+	_, err = store.BlockIdentity(ctx, db.BlockIdentityParams{
+		ID:                uuid.New(),
+		UserID:            viewer.UserID,
+		BlockedIdentityID: blocked.Identity.ID,
+		Kind:              "block",
+	})
+	require.NoError(t, err)
+	_, err = store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{ID: uuid.New(), UserID: viewer.UserID, Keyword: "spoilers"})
+	require.NoError(t, err)
+	_, err = store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{ID: uuid.New(), UserID: viewer.UserID, Keyword: "SPOILERS"})
+	requireViolation(t, err, "unique_violation")
+
+	visible, err := store.ListAllComments(ctx, db.ListAllCommentsParams{PostID: post.ID, ViewerID: viewer.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{kept.ID}, commentIDs(visible))
+
+	// hiding is up to the viewer, the others still see everything
+	all, err = store.ListAllComments(ctx, db.ListAllCommentsParams{PostID: post.ID, ViewerID: author.UserID})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	blockedByID, err := store.IsBlocked(ctx, db.IsBlockedParams{UserID: viewer.UserID, ActorID: blocked.UserID})
+	require.NoError(t, err)
+	require.True(t, blockedByID)
+	notBlocked, err := store.IsBlocked(ctx, db.IsBlockedParams{UserID: viewer.UserID, ActorID: author.UserID})
+	require.NoError(t, err)
+	require.False(t, notBlocked)
+}
+
+func testFeed(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	reader := createUser(t, store)
+	author := createUser(t, store)
+
+	private := createIdentity(t, store, author.UserID)
+	public, err := store.UpdateUserIdentity(ctx, db.UpdateUserIdentityParams{
+		IsPublic: sql.NullBool{Bool: true, Valid: true},
+		ID:       author.Identity.ID,
+		UserID:   author.UserID,
+	})
+	require.NoError(t, err)
+	require.True(t, public.IsPublic)
+
+	publicPost := createPost(t, store, public.ID, "signed")
+	privatePost := createPost(t, store, private.ID, "anonymous")
+	nsfwPost := createPost(t, store, public.ID, "not safe", func(p *db.CreatePostParams) { p.Nsfw = true })
+	draft := createPost(t, store, public.ID, "unfinished", func(p *db.CreatePostParams) { p.Status = "draft" })
+
+	feed := func() []uuid.UUID {
+		posts, err := store.ListFeedPosts(ctx, db.ListFeedPostsParams{UserID: reader.UserID})
+		require.NoError(t, err)
+		return postIDs(posts)
+	}
+	require.Empty(t, feed())
+
+	// following a user only follows their public identities
+	require.NoError(t, store.FollowUser(ctx, db.FollowUserParams{ID: uuid.New(), FollowerID: reader.UserID, FolloweeUserID: author.UserID}))
+	require.NoError(t, store.FollowUser(ctx, db.FollowUserParams{ID: uuid.New(), FollowerID: reader.UserID, FolloweeUserID: author.UserID}))
+	require.ElementsMatch(t, []uuid.UUID{publicPost.ID, nsfwPost.ID}, feed())
+
+	require.NoError(t, store.FollowIdentity(ctx, db.FollowIdentityParams{ID: uuid.New(), FollowerID: reader.UserID, FolloweeIdentityID: private.ID}))
+	require.ElementsMatch(t, []uuid.UUID{publicPost.ID, nsfwPost.ID, privatePost.ID}, feed())
+
+	require.NoError(t, store.SetContentPreferences(ctx, db.SetContentPreferencesParams{
+		UserID:     reader.UserID,
+		Categories: []string{"nsfw"},
+		Actions:    []string{"hide"},
+	}))
+	require.ElementsMatch(t, []uuid.UUID{publicPost.ID, privatePost.ID}, feed())
+
+	_, err = store.UnfollowUser(ctx, db.UnfollowUserParams{FollowerID: reader.UserID, FolloweeUserID: author.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{privatePost.ID}, feed())
+
+	posts, err := store.ListPublicPostsByUserId(ctx, db.ListPublicPostsByUserIdParams{UserID: author.UserID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{publicPost.ID, nsfwPost.ID}, postIDs(posts))
+	require.NotContains(t, postIDs(posts), draft.ID)
+
+	posts, err = store.ListPublicPostsByUserId(ctx, db.ListPublicPostsByUserIdParams{UserID: author.UserID, ViewerID: reader.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{publicPost.ID}, postIDs(posts))
+}
+
+func testCascade(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	other := createUser(t, store)
+
+	post := createPost(t, store, user.Identity.ID, "going away")
+	comment := createComment(t, store, other.Identity.ID, post.ID, uuid.Nil, "going with it")
+	kept := createPost(t, store, other.Identity.ID, "staying")
+	reply := createComment(t, store, user.Identity.ID, kept.ID, uuid.Nil, "going too")
+	require.NoError(t, store.FollowUser(ctx, db.FollowUserParams{ID: uuid.New(), FollowerID: other.UserID, FolloweeUserID: user.UserID}))
+	_, err := store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: kept.ID, UserID: user.UserID, Reaction: db.SatisfactionLIKE})
+	require.NoError(t, err)
+
+	deleted, err := store.GetUserById(ctx, user.UserID)
+	require.NoError(t, err)
+	_, err = store.DeleteOneUser(ctx, db.DeleteOneUserParams{ID: user.UserID})
+	require.NoError(t, err)
+
+	// a deleted account hides its content until it is purged
+	_, err = store.GetPostById(ctx, post.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetComment(ctx, reply.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = store.PurgeUsers(ctx)
+	require.NoError(t, err)
+
+	_, err = store.GetDeletedUserByUsername(ctx, deleted.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetUserIdentityById(ctx, user.Identity.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetComment(ctx, comment.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	reactions, err := store.CountPostReactions(ctx, kept.ID)
+	require.NoError(t, err)
+	require.Zero(t, reactions.Likes)
+	followed, err := store.ListFollowedUsers(ctx, db.ListFollowedUsersParams{UserID: other.UserID})
+	require.NoError(t, err)
+	require.Empty(t, followed)
+
+	_, err = store.GetPostById(ctx, kept.ID)
+	require.NoError(t, err)
+}
+
+func testPostTx(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	tag, other := randomWord(), randomWord()
+
+	post, err := store.CreatePostTx(ctx, db.CreatePostTxParams{
+		CreatePostParams: db.CreatePostParams{
+			ID:              uuid.New(),
+			Content:         "vote #" + tag,
+			UserIdentityID:  user.Identity.ID,
+			Status:          "published",
+			ContentWarnings: []string{},
+		},
+		Tags:        []string{tag},
+		Poll:        &db.CreatePollParams{Multiple: true},
+		PollOptions: []string{"yes", "no"},
+	})
+	require.NoError(t, err)
+
+	revisions, err := store.ListPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	tagged, err := store.ListPostsByTag(ctx, db.ListPostsByTagParams{Tag: tag, ViewerID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{post.ID}, postIDs(tagged))
+
+	polls, err := store.ListPolls(ctx, db.ListPollsParams{ViewerID: user.UserID, PostIds: []uuid.UUID{post.ID}})
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	require.True(t, polls[0].Multiple)
+	options, err := store.ListPollOptions(ctx, db.ListPollOptionsParams{ViewerID: user.UserID, PostIds: []uuid.UUID{post.ID}})
+	require.NoError(t, err)
+	require.Len(t, options, 2)
+
+	// an edit records a revision and moves the post to its new tags
+	_, err = store.UpdatePostTx(ctx, db.UpdatePostTxParams{
+		UpdatePostParams: db.UpdatePostParams{ID: post.ID, Content: "vote #" + other, EditorIdentityID: user.Identity.ID},
+		Tags:             []string{other},
+	})
+	require.NoError(t, err)
+
+	revisions, err = store.ListPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	tagged, err = store.ListPostsByTag(ctx, db.ListPostsByTagParams{Tag: tag, ViewerID: user.UserID})
+	require.NoError(t, err)
+	require.Empty(t, tagged)
+	tagged, err = store.ListPostsByTag(ctx, db.ListPostsByTagParams{Tag: other, ViewerID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{post.ID}, postIDs(tagged))
+
+	// a draft has no revision until it is published, and a published post is not edited as a draft
+	draft, err := store.CreatePostTx(ctx, db.CreatePostTxParams{
+		CreatePostParams: db.CreatePostParams{
+			ID:              uuid.New(),
+			Content:         "not yet",
+			UserIdentityID:  user.Identity.ID,
+			Status:          "draft",
+			ContentWarnings: []string{},
+		},
+	})
+	require.NoError(t, err)
+	revisions, err = store.ListPostRevisions(ctx, draft.ID)
+	require.NoError(t, err)
+	require.Empty(t, revisions)
+
+	_, err = store.UpdateDraftPostTx(ctx, db.UpdateDraftPostTxParams{
+		UpdateDraftPostParams: db.UpdateDraftPostParams{ID: draft.ID, Content: "soon #" + tag, Status: "draft"},
+		Tags:                  []string{tag},
+	})
+	require.NoError(t, err)
+	_, err = store.UpdateDraftPostTx(ctx, db.UpdateDraftPostTxParams{
+		UpdateDraftPostParams: db.UpdateDraftPostParams{ID: post.ID, Content: "taken back", Status: "draft"},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// and a draft is not edited as a published post
+	_, err = store.UpdatePostTx(ctx, db.UpdatePostTxParams{
+		UpdatePostParams: db.UpdatePostParams{ID: draft.ID, Content: "edited", EditorIdentityID: user.Identity.ID},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testPolls(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	author := createUser(t, store)
+	voter := createUser(t, store)
+	post := createPost(t, store, author.Identity.ID, "which one")
+
+	_, err := store.CreatePoll(ctx, db.CreatePollParams{PostID: post.ID})
+	require.NoError(t, err)
+	options := []uuid.UUID{uuid.New(), uuid.New()}
+	require.NoError(t, store.CreatePollOptions(ctx, db.CreatePollOptionsParams{
+		PostID: post.ID,
+		Ids:    options,
+		Texts:  []string{"this", "that"},
+	}))
+
+	// an option of another poll is no option
+	_, err = store.CastPollBallot(ctx, db.CastPollBallotParams{
+		OptionIds:      []uuid.UUID{uuid.New()},
+		UserIdentityID: voter.Identity.ID,
+		UserID:         voter.UserID,
+		PostID:         post.ID,
+	})
+	requireViolation(t, err, "foreign_key_violation")
+
+	votes, err := store.CastPollBallotTx(ctx, db.CastPollBallotParams{
+		OptionIds:      options[1:],
+		UserIdentityID: voter.Identity.ID,
+		UserID:         voter.UserID,
+		PostID:         post.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), votes)
+
+	_, err = store.CastPollBallot(ctx, db.CastPollBallotParams{
+		OptionIds:      options[:1],
+		UserIdentityID: voter.Identity.ID,
+		UserID:         voter.UserID,
+		PostID:         post.ID,
+	})
+	requireViolation(t, err, "unique_violation")
+
+	// nor does the voter get another vote under another identity
+	other := createIdentity(t, store, voter.UserID)
+	votes, err = store.CastPollBallotTx(ctx, db.CastPollBallotParams{
+		OptionIds:      options[:1],
+		UserIdentityID: other.ID,
+		UserID:         voter.UserID,
+		PostID:         post.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, votes)
+
+	rows, err := store.ListPollOptions(ctx, db.ListPollOptionsParams{ViewerID: voter.UserID, PostIds: []uuid.UUID{post.ID}})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, db.ListPollOptionsRow{ID: options[0], PostID: post.ID, Position: 1, Text: "this"}, rows[0])
+	require.Equal(t, db.ListPollOptionsRow{ID: options[1], PostID: post.ID, Position: 2, Text: "that", Votes: 1, Voted: true}, rows[1])
+
+	polls, err := store.ListPolls(ctx, db.ListPollsParams{ViewerID: author.UserID, PostIds: []uuid.UUID{post.ID}})
+	require.NoError(t, err)
+	require.Len(t, polls, 1)
+	require.Equal(t, int64(1), polls[0].Voters)
+	require.False(t, polls[0].Voted)
+	require.False(t, polls[0].Closed)
+}
+
+func testPostScores(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	author := createUser(t, store)
+	reader := createUser(t, store)
+	post := createPost(t, store, author.Identity.ID, "scored")
+
+	// with no window only the posts never scored and those that changed since are refreshed. The changes are
+	// told apart by their timestamps, so they can't share one with a refresh
+	refresh := func() int64 {
+		time.Sleep(time.Millisecond)
+		rows, err := store.RefreshPostScores(ctx, db.RefreshPostScoresParams{Gravity: 1.8})
+		require.NoError(t, err)
+		return rows
+	}
+	require.NotZero(t, refresh())
+	require.Zero(t, refresh())
+
+	_, err := store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: post.ID, UserID: reader.UserID, Reaction: db.SatisfactionLIKE})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), refresh())
+	require.Zero(t, refresh())
+
+	comment := createComment(t, store, reader.Identity.ID, post.ID, uuid.Nil, "counted")
+	require.Equal(t, int64(1), refresh())
+
+	_, err = store.DeleteComment(ctx, comment.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), refresh())
+	require.Zero(t, refresh())
+
+	// a flipped reaction keeps its creation time, a removed reaction and a restored comment leave none
+	_, err = store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: post.ID, UserID: reader.UserID, Reaction: db.SatisfactionDISLIKE})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), refresh())
+	require.Zero(t, refresh())
+
+	_, err = store.DeletePostReaction(ctx, db.DeletePostReactionParams{PostID: post.ID, UserID: reader.UserID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), refresh())
+	require.Zero(t, refresh())
+
+	_, err = store.RestoreComment(ctx, db.RestoreCommentParams{ID: comment.ID, UserID: reader.UserID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), refresh())
+	require.Zero(t, refresh())
+
+	// within the window every post is refreshed
+	rows, err := store.RefreshPostScores(ctx, db.RefreshPostScoresParams{Gravity: 1.8, WindowSeconds: 3600})
+	require.NoError(t, err)
+	require.NotZero(t, rows)
+}
+
+func testNotifications(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	subject := uuid.New()
+
+	notify := func(kind string, actor uuid.UUID) (db.CreateNotificationRow, error) {
+		return store.CreateNotification(ctx, db.CreateNotificationParams{
+			ID:        uuid.New(),
+			UserID:    user.UserID,
+			Type:      kind,
+			SubjectID: subject,
+			ActorID:   actor,
+		})
+	}
+
+	first, err := notify("comment", uuid.New())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), first.Count)
+
+	// events on the same subject are folded into the unread notification, counting each actor once
+	actor := uuid.New()
+	folded, err := notify("comment", actor)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, folded.ID)
+	require.Equal(t, int64(2), folded.Count)
+	folded, err = notify("comment", actor)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), folded.Count)
+
+	unread, err := store.CountUnreadNotifications(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), unread)
+
+	read, err := store.MarkAllNotificationsRead(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), read)
+
+	next, err := notify("comment", actor)
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, next.ID)
+
+	require.NoError(t, store.SetNotificationPreferences(ctx, db.SetNotificationPreferencesParams{
+		UserID:  user.UserID,
+		Types:   []string{"reaction"},
+		Enabled: []bool{false},
+	}))
+	_, err = notify("reaction", actor)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	notifications, err := store.ListNotifications(ctx, db.ListNotificationsParams{UserID: user.UserID})
+	require.NoError(t, err)
+	require.Len(t, notifications, 2)
+	unreadOnly, err := store.ListNotifications(ctx, db.ListNotificationsParams{UserID: user.UserID, UnreadOnly: true})
+	require.NoError(t, err)
+	require.Len(t, unreadOnly, 1)
+	require.Equal(t, next.ID, unreadOnly[0].ID)
+}
+
+func testWebhooks(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+
+	webhook, err := store.CreateWebhook(ctx, db.CreateWebhookParams{
+		ID:     uuid.New(),
+		UserID: user.UserID,
+		Url:    "https://hooks.example.com/" + uuid.NewString(),
+		Secret: "secret",
+		Events: []string{"comment.created"},
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		ID:        uuid.New(),
+		WebhookID: uuid.New(),
+		Event:     "comment.created",
+		Payload:   json.RawMessage(`{}`),
+	})
+	requireViolation(t, err, "foreign_key_violation")
+
+	delivery, err := store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		ID:        uuid.New(),
+		WebhookID: webhook.ID,
+		Event:     "comment.created",
+		Payload:   json.RawMessage(`{"id":1}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "pending", delivery.Status)
+
+	fail := func() {
+		require.NoError(t, store.FailWebhookDelivery(ctx, db.FailWebhookDeliveryParams{
+			ResponseStatus: sql.NullInt32{Int32: 500, Valid: true},
+			Error:          "server error",
+			MaxAttempts:    2,
+			RetrySeconds:   60,
+			ID:             delivery.ID,
+		}))
+	}
+	list := func() db.WebhookDelivery {
+		deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: webhook.ID, UserID: user.UserID})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	fail()
+	got := list()
+	require.Equal(t, "pending", got.Status)
+	require.Equal(t, int32(1), got.Attempts)
+	require.Equal(t, int32(500), got.ResponseStatus.Int32)
+
+	fail()
+	require.Equal(t, "failed", list().Status)
+
+	// only the owner sees the deliveries
+	deliveries, err := store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: webhook.ID, UserID: uuid.New()})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+
+	_, err = store.DeleteWebhook(ctx, db.DeleteWebhookParams{ID: webhook.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	deliveries, err = store.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: webhook.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func testDigests(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	token := uuid.NewString()
+
+	digest, err := store.SetEmailDigest(ctx, db.SetEmailDigestParams{
+		UserID:           user.UserID,
+		Email:            "someone@example.com",
+		Frequency:        "daily",
+		UnsubscribeToken: token,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(24*time.Hour), digest.NextSendAt, time.Minute)
+
+	digest, err = store.SetEmailDigest(ctx, db.SetEmailDigestParams{
+		UserID:           user.UserID,
+		Email:            "someone@example.com",
+		Frequency:        "weekly",
+		UnsubscribeToken: uuid.NewString(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "weekly", digest.Frequency)
+	// the token a digest was sent with keeps working
+	require.Equal(t, token, digest.UnsubscribeToken)
+	require.WithinDuration(t, time.Now().Add(7*24*time.Hour), digest.NextSendAt, time.Minute)
+
+	unsubscribed, err := store.UnsubscribeEmailDigest(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), unsubscribed)
+
+	_, err = store.GetEmailDigest(ctx, user.UserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	unsubscribed, err = store.UnsubscribeEmailDigest(ctx, token)
+	require.NoError(t, err)
+	require.Zero(t, unsubscribed)
+}
+
+func testSearch(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	author := createUser(t, store)
+	viewer := createUser(t, store)
+	word, other := randomWord(), randomWord()
+
+	both := createPost(t, store, author.Identity.ID, "the "+word+" and the "+other)
+	only := createPost(t, store, author.Identity.ID, "only the "+word+" here")
+	createPost(t, store, author.Identity.ID, "neither of them")
+
+	search := func(query string) []db.SearchPostsRow {
+		rows, err := store.SearchPosts(ctx, db.SearchPostsParams{
+			Query:      query,
+			IdentityID: uuid.NullUUID{UUID: author.Identity.ID, Valid: true},
+			ViewerID:   viewer.UserID,
+		})
+		require.NoError(t, err)
+		return rows
+	}
+
+	rows := search(word)
+	require.Len(t, rows, 2)
+	require.ElementsMatch(t, []uuid.UUID{both.ID, only.ID}, []uuid.UUID{rows[0].ID, rows[1].ID})
+	for _, row := range rows {
+		require.Contains(t, row.Snippet, "<mark>"+word+"</mark>")
+		require.Positive(t, row.Rank)
+	}
+
+	rows = search(word + " & " + other)
+	require.Len(t, rows, 1)
+	require.Equal(t, both.ID, rows[0].ID)
+
+	rows = search(word + " & !(" + other + ")")
+	require.Len(t, rows, 1)
+	require.Equal(t, only.ID, rows[0].ID)
+
+	rows = search("only <-> the <-> " + word)
+	require.Len(t, rows, 1)
+	require.Equal(t, only.ID, rows[0].ID)
+
+	rows = search(word[:4] + ":*")
+	require.Len(t, rows, 2)
+
+	// the cursor of the first row leaves the second
+	first := search(word)[0]
+	rows, err := store.SearchPosts(ctx, db.SearchPostsParams{
+		Query:      word,
+		IdentityID: uuid.NullUUID{UUID: author.Identity.ID, Valid: true},
+		ViewerID:   viewer.UserID,
+		CursorID:   uuid.NullUUID{UUID: first.ID, Valid: true},
+		CursorRank: sql.NullFloat64{Float64: float64(first.Rank), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.NotEqual(t, first.ID, rows[0].ID)
+
+	_, err = store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{ID: uuid.New(), UserID: viewer.UserID, Keyword: other})
+	require.NoError(t, err)
+	rows = search(word)
+	require.Len(t, rows, 1)
+	require.Equal(t, only.ID, rows[0].ID)
+}
+
+func testBlocks(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	other := createUser(t, store)
+	muted := createUser(t, store)
+	post := createPost(t, store, user.Identity.ID, "a post to comment on")
+	comment := createComment(t, store, other.Identity.ID, post.ID, uuid.Nil, "under a private identity")
+
+	_, err := store.BlockUser(ctx, db.BlockUserParams{ID: uuid.New(), UserID: user.UserID, BlockedUserID: uuid.New(), Kind: "block"})
+	requireViolation(t, err, "foreign_key_violation")
+
+	// blocking the same user again changes the kind of the block already there
+	mute, err := store.BlockUser(ctx, db.BlockUserParams{ID: uuid.New(), UserID: user.UserID, BlockedUserID: other.UserID, Kind: "mute"})
+	require.NoError(t, err)
+	block, err := store.BlockUser(ctx, db.BlockUserParams{ID: uuid.New(), UserID: user.UserID, BlockedUserID: other.UserID, Kind: "block"})
+	require.NoError(t, err)
+	require.Equal(t, mute.ID, block.ID)
+	require.Equal(t, "block", block.Kind)
+
+	_, err = store.BlockIdentity(ctx, db.BlockIdentityParams{ID: uuid.New(), UserID: user.UserID, BlockedIdentityID: muted.Identity.ID, Kind: "mute"})
+	require.NoError(t, err)
+
+	blockIDs := func(kind string) []uuid.UUID {
+		blocks, err := store.ListBlocks(ctx, db.ListBlocksParams{UserID: user.UserID, Kind: kind})
+		require.NoError(t, err)
+		ids := []uuid.UUID{}
+		for _, b := range blocks {
+			ids = append(ids, b.ID)
+		}
+		return ids
+	}
+	require.Equal(t, []uuid.UUID{block.ID}, blockIDs("block"))
+	require.Len(t, blockIDs("mute"), 1)
+
+	// a mute is no block
+	blocked, err := store.IsBlocked(ctx, db.IsBlockedParams{UserID: user.UserID, ActorID: other.UserID})
+	require.NoError(t, err)
+	require.True(t, blocked)
+	blocked, err = store.IsBlocked(ctx, db.IsBlockedParams{UserID: user.UserID, ActorID: muted.UserID})
+	require.NoError(t, err)
+	require.False(t, blocked)
+	blocks, err := store.HasBlocks(ctx, user.UserID)
+	require.NoError(t, err)
+	require.True(t, blocks)
+
+	// blocking a user only hides what they write under their public identities
+	visibleComments := func() []uuid.UUID {
+		comments, err := store.ListAllComments(ctx, db.ListAllCommentsParams{PostID: post.ID, ViewerID: user.UserID})
+		require.NoError(t, err)
+		return commentIDs(comments)
+	}
+	require.Equal(t, []uuid.UUID{comment.ID}, visibleComments())
+	_, err = store.UpdateUserIdentity(ctx, db.UpdateUserIdentityParams{
+		IsPublic: sql.NullBool{Bool: true, Valid: true},
+		ID:       other.Identity.ID,
+		UserID:   other.UserID,
+	})
+	require.NoError(t, err)
+	require.Empty(t, visibleComments())
+
+	_, err = store.DeleteBlock(ctx, db.DeleteBlockParams{ID: block.ID, UserID: other.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.DeleteBlock(ctx, db.DeleteBlockParams{ID: block.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{comment.ID}, visibleComments())
+	blocks, err = store.HasBlocks(ctx, user.UserID)
+	require.NoError(t, err)
+	require.False(t, blocks)
+
+	beta, err := store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{ID: uuid.New(), UserID: user.UserID, Keyword: "beta"})
+	require.NoError(t, err)
+	_, err = store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{ID: uuid.New(), UserID: user.UserID, Keyword: "alpha"})
+	require.NoError(t, err)
+
+	keywords, err := store.ListMutedKeywords(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Len(t, keywords, 2)
+	require.Equal(t, "alpha", keywords[0].Keyword)
+	count, err := store.CountMutedKeywords(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	_, err = store.DeleteMutedKeyword(ctx, db.DeleteMutedKeywordParams{ID: beta.ID, UserID: other.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.DeleteMutedKeyword(ctx, db.DeleteMutedKeywordParams{ID: beta.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	count, err = store.CountMutedKeywords(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func testTags(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	tag := randomWord()
+	longer := tag + "z"
+
+	first := createPost(t, store, user.Identity.ID, "first")
+	second := createPost(t, store, user.Identity.ID, "second")
+	draft := createPost(t, store, user.Identity.ID, "unfinished", func(p *db.CreatePostParams) { p.Status = "draft" })
+
+	require.NoError(t, store.SetPostTags(ctx, db.SetPostTagsParams{PostID: first.ID, Tags: []string{tag, longer}}))
+	require.NoError(t, store.SetPostTags(ctx, db.SetPostTagsParams{PostID: second.ID, Tags: []string{tag}}))
+	require.NoError(t, store.SetPostTags(ctx, db.SetPostTagsParams{PostID: draft.ID, Tags: []string{tag}}))
+
+	err := store.SetPostTags(ctx, db.SetPostTagsParams{PostID: uuid.New(), Tags: []string{tag}})
+	requireViolation(t, err, "foreign_key_violation")
+
+	tagged := func(tag string) []uuid.UUID {
+		posts, err := store.ListPostsByTag(ctx, db.ListPostsByTagParams{Tag: tag, ViewerID: user.UserID})
+		require.NoError(t, err)
+		return postIDs(posts)
+	}
+	require.ElementsMatch(t, []uuid.UUID{first.ID, second.ID}, tagged(tag))
+	require.Equal(t, []uuid.UUID{first.ID}, tagged(longer))
+
+	// only published posts count, the most used tags first
+	tags, err := store.SearchTags(ctx, tag)
+	require.NoError(t, err)
+	require.Equal(t, []db.SearchTagsRow{{Name: tag, PostCount: 2}, {Name: longer, PostCount: 1}}, tags)
+
+	// setting the tags replaces them
+	require.NoError(t, store.SetPostTags(ctx, db.SetPostTagsParams{PostID: first.ID, Tags: []string{longer}}))
+	require.Equal(t, []uuid.UUID{second.ID}, tagged(tag))
+
+	_, err = store.DeletePost(ctx, second.ID)
+	require.NoError(t, err)
+	require.Empty(t, tagged(tag))
+	tags, err = store.SearchTags(ctx, tag)
+	require.NoError(t, err)
+	require.Equal(t, []db.SearchTagsRow{{Name: longer, PostCount: 1}}, tags)
+}
+
+func testReactions(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	author := createUser(t, store)
+	reader := createUser(t, store)
+	other := createUser(t, store)
+	post := createPost(t, store, author.Identity.ID, "react to this")
+
+	_, err := store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: uuid.New(), UserID: reader.UserID, Reaction: db.SatisfactionLIKE})
+	requireViolation(t, err, "foreign_key_violation")
+
+	// reacting again changes the reaction
+	_, err = store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: post.ID, UserID: reader.UserID, Reaction: db.SatisfactionLIKE})
+	require.NoError(t, err)
+	reaction, err := store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: post.ID, UserID: reader.UserID, Reaction: db.SatisfactionDISLIKE})
+	require.NoError(t, err)
+	require.Equal(t, db.SatisfactionDISLIKE, reaction.Reaction)
+	_, err = store.SetPostReaction(ctx, db.SetPostReactionParams{PostID: post.ID, UserID: other.UserID, Reaction: db.SatisfactionLIKE})
+	require.NoError(t, err)
+
+	got, err := store.GetPostReaction(ctx, db.GetPostReactionParams{PostID: post.ID, UserID: reader.UserID})
+	require.NoError(t, err)
+	require.Equal(t, db.SatisfactionDISLIKE, got.Reaction)
+
+	counts, err := store.CountPostReactions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.CountPostReactionsRow{Likes: 1, Dislikes: 1}, counts)
+
+	_, err = store.DeletePostReaction(ctx, db.DeletePostReactionParams{PostID: post.ID, UserID: reader.UserID})
+	require.NoError(t, err)
+	_, err = store.DeletePostReaction(ctx, db.DeletePostReactionParams{PostID: post.ID, UserID: reader.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetPostReaction(ctx, db.GetPostReactionParams{PostID: post.ID, UserID: reader.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	counts, err = store.CountPostReactions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.CountPostReactionsRow{Likes: 1}, counts)
+}
+
+func testDrafts(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	published := createPost(t, store, user.Identity.ID, "out already")
+
+	schedule := func(at time.Time) func(*db.CreatePostParams) {
+		return func(p *db.CreatePostParams) {
+			p.Status = "scheduled"
+			p.PublishAt = &at
+		}
+	}
+	draft := createPost(t, store, user.Identity.ID, "unfinished", func(p *db.CreatePostParams) { p.Status = "draft" })
+	later := createPost(t, store, user.Identity.ID, "tomorrow", schedule(time.Now().Add(24*time.Hour)))
+	due := createPost(t, store, user.Identity.ID, "a minute ago", schedule(time.Now().Add(-time.Minute)))
+
+	drafts := func() []uuid.UUID {
+		posts, err := store.ListDraftPosts(ctx, db.ListDraftPostsParams{UserID: user.UserID})
+		require.NoError(t, err)
+		return postIDs(posts)
+	}
+	// the next to be published first, the drafts last
+	require.Equal(t, []uuid.UUID{due.ID, later.ID, draft.ID}, drafts())
+
+	_, err := store.GetDraftPost(ctx, published.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetPostById(ctx, due.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	ids, err := store.PublishDuePosts(ctx, 1000)
+	require.NoError(t, err)
+	require.Contains(t, ids, due.ID)
+	require.NotContains(t, ids, later.ID)
+	require.NotContains(t, ids, draft.ID)
+
+	got, err := store.GetPostById(ctx, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, "published", got.Status)
+	require.Nil(t, got.PublishAt)
+	revisions, err := store.ListPostRevisions(ctx, due.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	_, err = store.PublishDraftPost(ctx, draft.ID)
+	require.NoError(t, err)
+	_, err = store.PublishDraftPost(ctx, draft.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	revisions, err = store.ListPostRevisions(ctx, draft.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, []uuid.UUID{later.ID}, drafts())
+
+	// a trashed draft is neither listed nor published
+	_, err = store.DeletePost(ctx, later.ID)
+	require.NoError(t, err)
+	require.Empty(t, drafts())
+	_, err = store.PublishDraftPost(ctx, later.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testRevisions(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	editor := createIdentity(t, store, user.UserID)
+	post := createPost(t, store, user.Identity.ID, "first")
+
+	require.NoError(t, store.CreatePostRevision(ctx, db.CreatePostRevisionParams{
+		PostID:         post.ID,
+		UserIdentityID: user.Identity.ID,
+		Content:        post.Content,
+	}))
+	for i, content := range []string{"second", "third"} {
+		identity := []uuid.UUID{user.Identity.ID, editor.ID}[i]
+		_, err := store.UpdatePost(ctx, db.UpdatePostParams{ID: post.ID, Content: content, EditorIdentityID: identity})
+		require.NoError(t, err)
+	}
+
+	// the latest revision first
+	revisions, err := store.ListPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	for i, content := range []string{"third", "second", "first"} {
+		require.Equal(t, int32(3-i), revisions[i].Revision)
+		require.Equal(t, content, revisions[i].Content)
+	}
+
+	revision, err := store.GetPostRevision(ctx, db.GetPostRevisionParams{PostID: post.ID, Revision: 3})
+	require.NoError(t, err)
+	require.Equal(t, editor.ID, revision.UserIdentityID)
+	_, err = store.GetPostRevision(ctx, db.GetPostRevisionParams{PostID: post.ID, Revision: 4})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// a trashed post keeps its revisions but takes no edits
+	_, err = store.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	_, err = store.UpdatePost(ctx, db.UpdatePostParams{ID: post.ID, Content: "fourth", EditorIdentityID: user.Identity.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	deleted, err := store.GetPostByIdIncludingDeleted(ctx, post.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+	require.Equal(t, int32(3), deleted.Revision)
+	revisions, err = store.ListPostRevisions(ctx, post.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+
+	other := createPost(t, store, user.Identity.ID, "commented")
+	comment := createComment(t, store, user.Identity.ID, other.ID, uuid.Nil, "said")
+	require.NoError(t, store.CreateCommentRevision(ctx, db.CreateCommentRevisionParams{
+		CommentID:      comment.ID,
+		UserIdentityID: user.Identity.ID,
+		Content:        comment.Content,
+	}))
+	commentRevision, err := store.GetCommentRevision(ctx, db.GetCommentRevisionParams{CommentID: comment.ID, Revision: 1})
+	require.NoError(t, err)
+	require.Equal(t, "said", commentRevision.Content)
+	_, err = store.GetCommentRevision(ctx, db.GetCommentRevisionParams{CommentID: comment.ID, Revision: 2})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = store.DeleteComment(ctx, comment.ID)
+	require.NoError(t, err)
+	deletedComment, err := store.GetCommentIncludingDeleted(ctx, comment.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deletedComment.DeletedAt)
+}
+
+func testMedia(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	post := createPost(t, store, user.Identity.ID, "with pictures")
+	message := createMessage(t, store, user.UserID)
+
+	_, err := store.CreateMedia(ctx, mediaParams("post", user.UserID, uuid.New(), uuid.Nil))
+	requireViolation(t, err, "foreign_key_violation")
+
+	first := createMedia(t, store, mediaParams("post", user.UserID, post.ID, uuid.Nil))
+	second := createMedia(t, store, mediaParams("post", user.UserID, post.ID, uuid.Nil))
+	attached := createMedia(t, store, mediaParams("message", uuid.Nil, uuid.Nil, message.ID))
+
+	media, err := store.ListMediaByPostId(ctx, uuid.NullUUID{UUID: post.ID, Valid: true})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{first.ID, second.ID}, mediaIDs(media))
+	count, err := store.CountMediaByPostId(ctx, uuid.NullUUID{UUID: post.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+	media, err = store.ListMediaByMessageId(ctx, uuid.NullUUID{UUID: message.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{attached.ID}, mediaIDs(media))
+
+	// a message is only stored along with its attachment
+	image := mediaParams("message", uuid.Nil, uuid.Nil, uuid.Nil)
+	sent, err := store.CreateMessageTx(ctx, db.CreateMessageTxParams{
+		CreateMessageParams: db.CreateMessageParams{ID: uuid.New(), ReceiverID: user.UserID, Content: "look"},
+		Image:               &image,
+	})
+	require.NoError(t, err)
+	require.Len(t, sent.Attachments, 1)
+	require.Equal(t, uuid.NullUUID{UUID: sent.Message.ID, Valid: true}, sent.Attachments[0].MessageID)
+
+	lost := mediaParams("message", uuid.Nil, uuid.New(), uuid.Nil)
+	unsent := db.CreateMessageParams{ID: uuid.New(), ReceiverID: user.UserID, Content: "lost"}
+	_, err = store.CreateMessageTx(ctx, db.CreateMessageTxParams{CreateMessageParams: unsent, Image: &lost})
+	requireViolation(t, err, "foreign_key_violation")
+	_, err = store.GetMessageById(ctx, unsent.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err := store.DeleteMedia(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first.StorageKey, deleted.StorageKey)
+	_, err = store.DeleteMedia(ctx, first.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetMediaById(ctx, first.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// a new avatar replaces the others of the user, and nothing else
+	old := createMedia(t, store, mediaParams("avatar", user.UserID, uuid.Nil, uuid.Nil))
+	older := createMedia(t, store, mediaParams("avatar", user.UserID, uuid.Nil, uuid.Nil))
+	avatar := createMedia(t, store, mediaParams("avatar", user.UserID, uuid.Nil, uuid.Nil))
+	replaced, err := store.DeleteOldAvatars(ctx, db.DeleteOldAvatarsParams{UserID: user.UserID, KeepID: avatar.ID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{old.ID, older.ID}, mediaIDs(replaced))
+	_, err = store.GetMediaById(ctx, avatar.ID)
+	require.NoError(t, err)
+	_, err = store.GetMediaById(ctx, second.ID)
+	require.NoError(t, err)
+}
+
+func testTrash(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	other := createUser(t, store)
+
+	post := createPost(t, store, user.Identity.ID, "thrown away")
+	picture := createMedia(t, store, mediaParams("post", user.UserID, post.ID, uuid.Nil))
+	_, err := store.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	_, err = store.DeletePost(ctx, post.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	trashed, err := store.ListTrashedPosts(ctx, db.ListTrashedPostsParams{UserID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{post.ID}, postIDs(trashed))
+
+	// only the author gets a post back
+	_, err = store.RestorePost(ctx, db.RestorePostParams{ID: post.ID, UserID: other.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	restored, err := store.RestorePost(ctx, db.RestorePostParams{ID: post.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	require.Nil(t, restored.DeletedAt)
+	_, err = store.GetPostById(ctx, post.ID)
+	require.NoError(t, err)
+	_, err = store.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+
+	message := createMessage(t, store, user.UserID)
+	attachment := createMedia(t, store, mediaParams("message", uuid.Nil, uuid.Nil, message.ID))
+	_, err = store.DeleteOneMessage(ctx, db.DeleteOneMessageParams{ID: message.ID, ReceiverID: other.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.DeleteOneMessage(ctx, db.DeleteOneMessageParams{ID: message.ID, ReceiverID: user.UserID})
+	require.NoError(t, err)
+	_, err = store.GetMessageById(ctx, message.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	messages, err := store.ListTrashedMessages(ctx, db.ListTrashedMessagesParams{ReceiverID: user.UserID})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, message.ID, messages[0].ID)
+
+	kept := createPost(t, store, other.Identity.ID, "staying")
+	comment := createComment(t, store, user.Identity.ID, kept.ID, uuid.Nil, "taken back")
+	_, err = store.DeleteComment(ctx, comment.ID)
+	require.NoError(t, err)
+
+	// nothing was trashed longer than an hour ago
+	media, err := store.PurgePosts(ctx, time.Hour.Seconds())
+	require.NoError(t, err)
+	require.NotContains(t, mediaIDs(media), picture.ID)
+	_, err = store.GetPostByIdIncludingDeleted(ctx, post.ID)
+	require.NoError(t, err)
+
+	// purging returns the media of what it removed, which goes with it
+	time.Sleep(10 * time.Millisecond)
+	media, err = store.PurgePosts(ctx, 0)
+	require.NoError(t, err)
+	require.Contains(t, mediaIDs(media), picture.ID)
+	_, err = store.GetPostByIdIncludingDeleted(ctx, post.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetMediaById(ctx, picture.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	media, err = store.PurgeMessages(ctx, 0)
+	require.NoError(t, err)
+	require.Contains(t, mediaIDs(media), attachment.ID)
+	messages, err = store.ListTrashedMessages(ctx, db.ListTrashedMessagesParams{ReceiverID: user.UserID})
+	require.NoError(t, err)
+	require.Empty(t, messages)
+
+	purged, err := store.PurgeComments(ctx, 0)
+	require.NoError(t, err)
+	require.NotZero(t, purged)
+	_, err = store.GetCommentIncludingDeleted(ctx, comment.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetPostById(ctx, kept.ID)
+	require.NoError(t, err)
+}
+
+func testExports(t *testing.T, store db.Store) {
+	ctx := context.Background()
+	user := createUser(t, store)
+	other := createUser(t, store)
+
+	create := func() db.DataExport {
+		export, err := store.CreateDataExport(ctx, db.CreateDataExportParams{ID: uuid.New(), UserID: user.UserID, Format: "zip"})
+		require.NoError(t, err)
+		require.Equal(t, "pending", export.Status)
+		return export
+	}
+	// the exports of earlier tests or runs may be ahead in the queue
+	claim := func(id uuid.UUID) db.DataExport {
+		for {
+			export, err := store.ClaimDataExport(ctx, time.Hour.Seconds())
+			require.NoError(t, err)
+			if export.ID == id {
+				return export
+			}
+		}
+	}
+
+	// a user waits for their export before asking for another one
+	first := create()
+	_, err := store.CreateDataExport(ctx, db.CreateDataExportParams{ID: uuid.New(), UserID: user.UserID, Format: "json"})
+	requireViolation(t, err, "unique_violation")
+	_, err = store.GetDataExport(ctx, db.GetDataExportParams{ID: first.ID, UserID: other.UserID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	running := claim(first.ID)
+	require.Equal(t, "running", running.Status)
+	require.NotNil(t, running.StartedAt)
+	_, err = store.CreateDataExport(ctx, db.CreateDataExportParams{ID: uuid.New(), UserID: user.UserID, Format: "json"})
+	requireViolation(t, err, "unique_violation")
+
+	done, err := store.CompleteDataExport(ctx, db.CompleteDataExportParams{ID: first.ID, StorageKey: "exports/first", Size: 12, TtlSeconds: time.Hour.Seconds()})
+	require.NoError(t, err)
+	require.Equal(t, "done", done.Status)
+	require.True(t, done.ExpiresAt.After(*done.CompletedAt))
+
+	second := create()
+	require.NoError(t, store.FailDataExport(ctx, db.FailDataExportParams{ID: second.ID, Error: "out of space"}))
+	failed, err := store.GetDataExport(ctx, db.GetDataExportParams{ID: second.ID, UserID: user.UserID})
+	require.NoError(t, err)
+	require.Equal(t, "failed", failed.Status)
+	require.Equal(t, "out of space", failed.Error)
+
+	// an export expiring right away is gone at the next clean up
+	third := create()
+	claim(third.ID)
+	_, err = store.CompleteDataExport(ctx, db.CompleteDataExportParams{ID: third.ID, StorageKey: "exports/third"})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	expired, err := store.DeleteExpiredDataExports(ctx)
+	require.NoError(t, err)
+	expiredIDs := []uuid.UUID{}
+	for _, e := range expired {
+		expiredIDs = append(expiredIDs, e.ID)
+	}
+	require.Contains(t, expiredIDs, third.ID)
+	require.NotContains(t, expiredIDs, first.ID)
+
+	exports, err := store.ListDataExports(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	require.Equal(t, second.ID, exports[0].ID)
+	require.Equal(t, first.ID, exports[1].ID)
+
+	// the archive holds everything of the user, anonymous, unpublished and trashed content included
+	anonymous := createIdentity(t, store, user.UserID)
+	post := createPost(t, store, user.Identity.ID, "published")
+	draft := createPost(t, store, anonymous.ID, "unfinished", func(p *db.CreatePostParams) { p.Status = "draft" })
+	_, err = store.DeletePost(ctx, post.ID)
+	require.NoError(t, err)
+	comment := createComment(t, store, anonymous.ID, post.ID, uuid.Nil, "on my own post")
+	message := createMessage(t, store, user.UserID)
+	session, err := store.CreateSession(ctx, sessionParams(user.UserID))
+	require.NoError(t, err)
+
+	posts, err := store.ExportUserPosts(ctx, user.UserID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []uuid.UUID{post.ID, draft.ID}, postIDs(posts))
+	comments, err := store.ExportUserComments(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{comment.ID}, commentIDs(comments))
+	messages, err := store.ExportUserMessages(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, message.ID, messages[0].ID)
+	sessions, err := store.ListUserSessions(ctx, user.UserID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session.ID, sessions[0].ID)
+}
+
+// requireViolation requires err to be the *pq.Error of a constraint violation, which the handlers look for.
+func requireViolation(t *testing.T, err error, code string) {
+	t.Helper()
+
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok, "want a *pq.Error, got %v", err)
+	require.Equal(t, code, pqErr.Code.Name())
+}
+
+func userParams() db.CreateUserParams {
+	return db.CreateUserParams{
+		ID:        uuid.New(),
+		Username:  randomName(),
+		Password:  "secret",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func createUser(t *testing.T, store db.Store) db.CreateUserTxResult {
+	t.Helper()
+
+	result, err := store.CreateUserTx(context.Background(), db.CreateUserTxParams{
+		CreateUserParams: userParams(),
+		Identity: db.CreateUserIdentityParams{
+			ID:           uuid.New(),
+			IdentityHash: uuid.New(),
+			Name:         randomName(),
+			IsDefault:    true,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.UserID, result.Identity.UserID.UUID)
+	return result
+}
+
+func createIdentity(t *testing.T, store db.Store, userID uuid.UUID) db.UserIdentity {
+	t.Helper()
+
+	identity, err := store.CreateUserIdentity(context.Background(), db.CreateUserIdentityParams{
+		ID:           uuid.New(),
+		UserID:       userID,
+		IdentityHash: uuid.New(),
+		Name:         randomName(),
+	})
+	require.NoError(t, err)
+	return identity
+}
+
+func sessionParams(userID uuid.UUID) db.CreateSessionParams {
+	return db.CreateSessionParams{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Username:     randomName(),
+		RefreshToken: uuid.NewString(),
+		UserAgent:    "storetest",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(24 * time.Hour),
+	}
+}
+
+func createPost(t *testing.T, store db.Store, identityID uuid.UUID, content string, opts ...func(*db.CreatePostParams)) db.Post {
+	t.Helper()
+
+	params := db.CreatePostParams{
+		ID:              uuid.New(),
+		Content:         content,
+		UserIdentityID:  identityID,
+		Status:          "published",
+		ContentWarnings: []string{},
+	}
+	for _, opt := range opts {
+		opt(&params)
+	}
+
+	post, err := store.CreatePost(context.Background(), params)
+	require.NoError(t, err)
+	return post
+}
+
+// commentParams is a comment on the post replying to parent, a top level comment when parent is uuid.Nil.
+func commentParams(identityID, postID, parent uuid.UUID, content string) db.CreateCommentParams {
+	id := uuid.New()
+	if parent == uuid.Nil {
+		parent = id
+	}
+	return db.CreateCommentParams{
+		ID:             id,
+		Content:        content,
+		UserIdentityID: identityID,
+		PostID:         postID,
+		ParentID:       parent,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+}
+
+func createComment(t *testing.T, store db.Store, identityID, postID, parent uuid.UUID, content string) db.Comment {
+	t.Helper()
+
+	comment, err := store.CreateComment(context.Background(), commentParams(identityID, postID, parent, content))
+	require.NoError(t, err)
+	return comment
+}
+
+func createMessage(t *testing.T, store db.Store, receiverID uuid.UUID) db.Message {
+	t.Helper()
+
+	message, err := store.CreateMessage(context.Background(), db.CreateMessageParams{
+		ID:         uuid.New(),
+		ReceiverID: receiverID,
+		Content:    "a confession",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+	return message
+}
+
+// mediaParams is media of the kind, the ids left uuid.Nil are null.
+func mediaParams(kind string, userID, postID, messageID uuid.UUID) db.CreateMediaParams {
+	nullable := func(id uuid.UUID) uuid.NullUUID { return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil} }
+	id := uuid.New()
+	return db.CreateMediaParams{
+		ID:           id,
+		Kind:         kind,
+		UserID:       nullable(userID),
+		PostID:       nullable(postID),
+		MessageID:    nullable(messageID),
+		StorageKey:   "media/" + id.String(),
+		ThumbnailKey: "media/" + id.String() + "-thumb",
+		ContentType:  "image/png",
+		Size:         1024,
+		Width:        32,
+		Height:       32,
+	}
+}
+
+func createMedia(t *testing.T, store db.Store, params db.CreateMediaParams) db.Media {
+	t.Helper()
+
+	media, err := store.CreateMedia(context.Background(), params)
+	require.NoError(t, err)
+	return media
+}
+
+func postIDs(posts []db.Post) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func commentIDs(comments []db.Comment) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func randomName() string {
+	return "st" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+}
+
+// randomWord is a word no other content has, for searching. It has no suffix the english stemmer would cut.
+func randomWord() string {
+	const consonants = "bcdfgjklmnpqrtvwxz"
+	b := make([]byte, 10)
+	for i := range b {
+		b[i] = consonants[rand.Intn(len(consonants))]
+	}
+	return "q" + string(b)
+}
+
+func mediaIDs(media []db.Media) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
@@ -0,0 +1,166 @@
+// Package diff computes word level differences between two texts.
+package diff
+
+import (
+	"regexp"
+)
+
+// Op is the kind of change of a Chunk
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Chunk is a run of text that is kept, inserted or deleted
+type Chunk struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// words and the whitespace between them are separate tokens, so whitespace changes show up too
+var tokenRegexp = regexp.MustCompile(`\s+|\S+`)
+
+// maxEdits bounds the work done for texts that have little in common, past it they are diffed as
+// one deletion of a followed by one insertion of b
+const maxEdits = 1000
+
+// Words returns the chunks that turn a into b, consecutive tokens with the same Op are merged.
+// Joining the Equal and Delete chunks gives back a, joining the Equal and Insert chunks gives b.
+func Words(a, b string) []Chunk {
+	ta, tb := tokenRegexp.FindAllString(a, -1), tokenRegexp.FindAllString(b, -1)
+
+	// the common prefix and suffix are kept as they are, only the middle needs diffing
+	prefix := 0
+	for prefix < len(ta) && prefix < len(tb) && ta[prefix] == tb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ta)-prefix && suffix < len(tb)-prefix && ta[len(ta)-1-suffix] == tb[len(tb)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, token := range ta[:prefix] {
+		edits = append(edits, edit{Equal, token})
+	}
+	edits = append(edits, myers(ta[prefix:len(ta)-suffix], tb[prefix:len(tb)-suffix])...)
+	for _, token := range ta[len(ta)-suffix:] {
+		edits = append(edits, edit{Equal, token})
+	}
+
+	return merge(edits)
+}
+
+type edit struct {
+	op    Op
+	token string
+}
+
+// myers finds the shortest edit script with the algorithm of
+// "An O(ND) Difference Algorithm and Its Variations", Eugene W. Myers 1986.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+
+	// v[k+offset] is the furthest x reached on diagonal k.
+	// trace[d] keeps the diagonals -d-1 to d+1 of v before step d, to walk the edits back.
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		if d > maxEdits {
+			return replace(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	// walk the trace back from the end to the start
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{Equal, a[x]})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				edits = append(edits, edit{Insert, b[y]})
+			} else {
+				x--
+				edits = append(edits, edit{Delete, a[x]})
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+func replace(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, token := range a {
+		edits = append(edits, edit{Delete, token})
+	}
+	for _, token := range b {
+		edits = append(edits, edit{Insert, token})
+	}
+	return edits
+}
+
+func merge(edits []edit) []Chunk {
+	chunks := []Chunk{}
+
+	for _, e := range edits {
+		if len(chunks) > 0 && chunks[len(chunks)-1].Op == e.op {
+			chunks[len(chunks)-1].Text += e.token
+			continue
+		}
+		chunks = append(chunks, Chunk{Op: e.op, Text: e.token})
+	}
+
+	return chunks
+}
@@ -0,0 +1,97 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWords(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b string
+		want []Chunk
+	}{
+		{
+			name: "same",
+			a:    "nothing changed",
+			b:    "nothing changed",
+			want: []Chunk{{Equal, "nothing changed"}},
+		},
+		{
+			name: "both empty",
+			want: []Chunk{},
+		},
+		{
+			name: "from empty",
+			b:    "new text",
+			want: []Chunk{{Insert, "new text"}},
+		},
+		{
+			name: "to empty",
+			a:    "old text",
+			want: []Chunk{{Delete, "old text"}},
+		},
+		{
+			name: "replace a word",
+			a:    "I saw my boss at the bar",
+			b:    "I saw my neighbour at the bar",
+			want: []Chunk{{Equal, "I saw my "}, {Delete, "boss"}, {Insert, "neighbour"}, {Equal, " at the bar"}},
+		},
+		{
+			name: "append",
+			a:    "hello",
+			b:    "hello world",
+			want: []Chunk{{Equal, "hello"}, {Insert, " world"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, Words(tc.a, tc.b))
+		})
+	}
+}
+
+// whatever the inputs, the chunks have to rebuild both texts
+func TestWordsRebuildsBothTexts(t *testing.T) {
+	words := []string{"a", "b", "c", "d", " ", "  ", "\n"}
+	random := func() string {
+		var sb strings.Builder
+		for i := rand.Intn(30); i > 0; i-- {
+			sb.WriteString(words[rand.Intn(len(words))])
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+
+		var gotA, gotB strings.Builder
+		for _, chunk := range Words(a, b) {
+			if chunk.Op != Insert {
+				gotA.WriteString(chunk.Text)
+			}
+			if chunk.Op != Delete {
+				gotB.WriteString(chunk.Text)
+			}
+		}
+
+		require.Equal(t, a, gotA.String())
+		require.Equal(t, b, gotB.String())
+	}
+}
+
+func TestWordsManyChanges(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 3*maxEdits; i++ {
+		a = append(a, "a")
+		b = append(b, "b")
+	}
+
+	require.Equal(t,
+		[]Chunk{{Delete, strings.Join(a, " ")}, {Insert, strings.Join(b, " ")}},
+		Words(strings.Join(a, " "), strings.Join(b, " ")))
+}
@@ -0,0 +1,123 @@
+// Package digest renders the email digests of what happened to a user since their last one, as HTML and as
+// the plain text alternative.
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+)
+
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var funcs = map[string]interface{}{
+	"plural":  plural,
+	"excerpt": excerpt,
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templates, "templates/digest.html"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(funcs).ParseFS(templates, "templates/digest.txt"))
+
+	unsubscribeTemplate = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/unsubscribe.html"))
+)
+
+// Comment is a comment others left on a post of the user.
+type Comment struct {
+	Content     string
+	PostContent string
+	// where the post opens in the app
+	Url     string
+	Replies int64
+}
+
+// Digest is what goes into one email.
+type Digest struct {
+	Username string
+	// Daily or Weekly
+	Frequency      string
+	UnreadMessages int64
+	// the unread messages that came in since the last digest
+	NewMessages  int64
+	NewFollowers int64
+	TopComments  []Comment
+	AppUrl       string
+	// the page that unsubscribes from digests without logging in
+	UnsubscribeUrl string
+}
+
+// Empty reports whether there is nothing worth an email.
+func (d Digest) Empty() bool {
+	return d.NewMessages == 0 && d.NewFollowers == 0 && len(d.TopComments) == 0
+}
+
+// Period names the time the digest covers.
+func (d Digest) Period() string {
+	if d.Frequency == Daily {
+		return "day"
+	}
+	return "week"
+}
+
+// Subject is the subject line of the email.
+func (d Digest) Subject() string {
+	if d.UnreadMessages > 0 {
+		return fmt.Sprintf("Your %s digest: %s", d.Frequency, plural(d.UnreadMessages, "unread confession", "unread confessions"))
+	}
+	return fmt.Sprintf("Your %s digest", d.Frequency)
+}
+
+// Render returns the HTML and plain text bodies of the email.
+func (d Digest) Render() (html, text string, err error) {
+	var h, t bytes.Buffer
+	if err := htmlTemplate.Execute(&h, d); err != nil {
+		return "", "", err
+	}
+	if err := textTemplate.Execute(&t, d); err != nil {
+		return "", "", err
+	}
+	return h.String(), t.String(), nil
+}
+
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// excerpt shortens s to at most max runes on a single line, cutting at a word where it can.
+func excerpt(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	cut := string([]rune(s)[:max-1])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// UnsubscribePage renders the page the unsubscribe link of an email opens. It asks to confirm with a form
+// posting to action, so mail scanners following links do not unsubscribe anyone, and tells once it is done.
+func UnsubscribePage(action, appUrl string, done bool) (string, error) {
+	var b bytes.Buffer
+	err := unsubscribeTemplate.Execute(&b, struct {
+		Action string
+		AppUrl string
+		Done   bool
+	}{action, appUrl, done})
+	return b.String(), err
+}
@@ -0,0 +1,78 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDigest() Digest {
+	return Digest{
+		Username:       "moth",
+		Frequency:      Weekly,
+		UnreadMessages: 3,
+		NewMessages:    1,
+		NewFollowers:   1,
+		TopComments: []Comment{
+			{Content: "so <b>true</b> & real", PostContent: "my confession", Url: "http://localhost:5173/posts/1", Replies: 2},
+			{Content: "same", PostContent: strings.Repeat("long post ", 20), Url: "http://localhost:5173/posts/2"},
+		},
+		AppUrl:         "http://localhost:5173",
+		UnsubscribeUrl: "http://localhost:8000/api/v1/digest/unsubscribe?token=abc&x=1",
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := testDigest()
+	html, text, err := d.Render()
+	require.NoError(t, err)
+
+	require.Contains(t, html, "Hi moth, here is your week on cnfs")
+	require.Contains(t, html, "3 unread confessions</a>, 1 of them new.")
+	require.Contains(t, html, "1 new follower.")
+	require.Contains(t, html, "2 replies")
+	// user content is escaped, urls are kept intact
+	require.Contains(t, html, "so &lt;b&gt;true&lt;/b&gt; &amp; real")
+	require.NotContains(t, html, "<b>true</b>")
+	require.Contains(t, html, `href="http://localhost:8000/api/v1/digest/unsubscribe?token=abc&amp;x=1"`)
+
+	require.Contains(t, text, "3 unread confessions, 1 of them new.")
+	require.Contains(t, text, `"so <b>true</b> & real"`)
+	require.Contains(t, text, "Unsubscribe: "+d.UnsubscribeUrl)
+	require.Contains(t, text, "long post long…\"\n")
+	require.NotContains(t, text, "<p")
+}
+
+func TestRenderNothingNew(t *testing.T) {
+	d := Digest{Username: "moth", Frequency: Daily, UnreadMessages: 2, AppUrl: "http://localhost:5173", UnsubscribeUrl: "u"}
+	require.True(t, d.Empty())
+	require.Equal(t, "Your daily digest: 2 unread confessions", d.Subject())
+
+	_, text, err := d.Render()
+	require.NoError(t, err)
+	require.Contains(t, text, "here is your day on cnfs")
+	require.NotContains(t, text, "of them new")
+	require.NotContains(t, text, "Top comments")
+
+	require.False(t, testDigest().Empty())
+	require.Equal(t, "Your daily digest", Digest{Frequency: Daily}.Subject())
+}
+
+func TestExcerpt(t *testing.T) {
+	require.Equal(t, "short", excerpt("short", 10))
+	require.Equal(t, "one line", excerpt("one\n  line", 10))
+	require.Equal(t, "a few…", excerpt("a few words here", 10))
+	require.Equal(t, "ääääääääää…", excerpt(strings.Repeat("ä", 20), 11))
+}
+
+func TestUnsubscribePage(t *testing.T) {
+	page, err := UnsubscribePage("/api/v1/digest/unsubscribe?token=a\"b", "http://localhost:5173", false)
+	require.NoError(t, err)
+	require.Contains(t, page, `<form method="post" action="/api/v1/digest/unsubscribe?token=a%22b">`)
+
+	page, err = UnsubscribePage("", "http://localhost:5173", true)
+	require.NoError(t, err)
+	require.Contains(t, page, "You are unsubscribed")
+	require.NotContains(t, page, "<form")
+}
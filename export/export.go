@@ -0,0 +1,90 @@
+// Package export writes the archive of everything stored about a user, as a single JSON document
+// or as a ZIP with one JSON file per kind of data.
+package export
+
+import (
+	"archive/zip"
+	db "cnfs/db/sqlc"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// the formats an archive can be written in
+const (
+	FormatZip  = "zip"
+	FormatJSON = "json"
+)
+
+// Archive is the data of a user at the time of the export.
+type Archive struct {
+	ExportedAt time.Time                `json:"exported_at"`
+	Profile    db.User                  `json:"profile"`
+	Sessions   []db.ListUserSessionsRow `json:"sessions"`
+	Messages   []db.Message             `json:"messages"`
+	Identities []db.UserIdentity        `json:"identities"`
+	Posts      []db.Post                `json:"posts"`
+	Comments   []db.Comment             `json:"comments"`
+}
+
+// ContentType returns the media type of an archive written in format.
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json"
+	}
+	return "application/zip"
+}
+
+// Write writes the archive to w in format.
+func (a Archive) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return a.writeJSON(w)
+	case FormatZip:
+		return a.writeZip(w)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func (a Archive) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+func (a Archive) writeZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", a.Profile},
+		{"sessions.json", a.Sessions},
+		{"messages.json", a.Messages},
+		{"identities.json", a.Identities},
+		{"posts.json", a.Posts},
+		{"comments.json", a.Comments},
+	}
+
+	for _, file := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: a.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	db "cnfs/db/sqlc"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomArchive() Archive {
+	userId := uuid.New()
+	identityId := uuid.New()
+
+	return Archive{
+		ExportedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Profile:    db.User{ID: userId, Username: "someone", Password: "hashed secret"},
+		Sessions:   []db.ListUserSessionsRow{{ID: uuid.New(), UserAgent: "curl"}},
+		Messages:   []db.Message{{ID: uuid.New(), ReceiverID: userId, Content: "hi"}},
+		Identities: []db.UserIdentity{{ID: identityId, UserID: uuid.NullUUID{UUID: userId, Valid: true}}},
+		Posts:      []db.Post{{ID: uuid.New(), UserIdentityID: identityId, Content: "my post"}},
+		Comments:   []db.Comment{},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	archive := randomArchive()
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, FormatJSON))
+	require.NotContains(t, buf.String(), "hashed secret")
+
+	var got Archive
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	require.Equal(t, archive.Profile.ID, got.Profile.ID)
+	require.Equal(t, archive.Posts[0].Content, got.Posts[0].Content)
+	require.Len(t, got.Sessions, 1)
+}
+
+func TestWriteZip(t *testing.T) {
+	archive := randomArchive()
+
+	var buf bytes.Buffer
+	require.NoError(t, archive.Write(&buf, FormatZip))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+
+	require.Len(t, files, 6)
+	require.NotContains(t, string(files["profile.json"]), "hashed secret")
+
+	var posts []db.Post
+	require.NoError(t, json.Unmarshal(files["posts.json"], &posts))
+	require.Equal(t, archive.Posts[0].ID, posts[0].ID)
+
+	var comments []db.Comment
+	require.NoError(t, json.Unmarshal(files["comments.json"], &comments))
+	require.Empty(t, comments)
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	require.Error(t, randomArchive().Write(io.Discard, "tar"))
+}
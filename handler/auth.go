@@ -40,7 +40,8 @@ type (
 		// The expiration date of refresh token
 		RefreshTokenExpiresAt time.Time `json:"refresh_token_expiry"`
 		// The user information needed for client
-		User         db.User         `json:"user"`
+		User db.User `json:"user"`
+		// The default identity of the user
 		UserIdentity db.UserIdentity `json:"user_identity"`
 	}
 )
@@ -93,6 +94,11 @@ func (s *Server) loginUser(c echo.Context) error {
 	}
 
 	user, err := s.store.GetUserByUsername(c.Request().Context(), data.Username)
+	if err == sql.ErrNoRows {
+		// an account waiting for its deletion can still log in until its grace period is over, which cancels
+		// the deletion
+		user, err = s.store.GetDeletedUserByUsername(c.Request().Context(), data.Username)
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(404, newError("user not found"))
@@ -104,6 +110,18 @@ func (s *Server) loginUser(c echo.Context) error {
 		return c.JSON(403, newError("password mismatch"))
 	}
 
+	if user.DeletedAt != nil {
+		if _, err := s.store.RestoreUser(c.Request().Context(), user.ID); err != nil {
+			// the grace period ended since the account was read
+			if err == sql.ErrNoRows {
+				return c.JSON(404, newError("user not found"))
+			}
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		user.DeletedAt = nil
+		user.DeleteAfter = nil
+	}
+
 	accessToken, accessTokenPayload, err := s.tokenMaker.CreateToken(user.ID, user.Username, s.cfg.AccessTokenDuration)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
@@ -144,7 +162,7 @@ func (s *Server) loginUser(c echo.Context) error {
 		return c.JSON(500, newError(err.Error()))
 	}
 
-	userIdentity, err := s.store.GetUserIdentityByUserId(c.Request().Context(), user.ID)
+	userIdentity, err := s.store.GetDefaultUserIdentity(c.Request().Context(), user.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusBadRequest, newError(err.Error()))
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -33,8 +34,9 @@ func TestLogin(t *testing.T) {
 			payload: fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RestoreUser(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Any()).Times(1)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -47,6 +49,58 @@ func TestLogin(t *testing.T) {
 				require.NotNil(t, resp.Data)
 			},
 		},
+		{
+			name:    "CANCELS DELETION",
+			payload: fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password),
+			buildStubs: func(store *mock.MockStore) {
+				deleted := user
+				deletedAt, deleteAfter := time.Now(), time.Now().Add(time.Hour)
+				deleted.DeletedAt, deleted.DeleteAfter = &deletedAt, &deleteAfter
+
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetDeletedUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(deleted, nil)
+				store.EXPECT().RestoreUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user.ID, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "GRACE PERIOD OVER",
+			payload: fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password),
+			buildStubs: func(store *mock.MockStore) {
+				deleted := user
+				deletedAt, deleteAfter := time.Now().Add(-time.Hour), time.Now()
+				deleted.DeletedAt, deleted.DeleteAfter = &deletedAt, &deleteAfter
+
+				// the grace period ends between reading the account and restoring it
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetDeletedUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(deleted, nil)
+				store.EXPECT().RestoreUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 404, rec.Code)
+			},
+		},
+		{
+			name:    "DELETED WITH WRONG PASSWORD",
+			payload: fmt.Sprintf(`{"username": %q, "password": "wrongpassword"}`, user.Username),
+			buildStubs: func(store *mock.MockStore) {
+				deleted := user
+				deletedAt := time.Now()
+				deleted.DeletedAt = &deletedAt
+
+				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetDeletedUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(deleted, nil)
+				store.EXPECT().RestoreUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 403, rec.Code)
+			},
+		},
 		{
 			name:    "Missing username field",
 			payload: fmt.Sprintf(`{"password": %q}`, password),
@@ -103,6 +157,7 @@ func TestLogin(t *testing.T) {
 			payload: fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().GetDeletedUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 404, rec.Code)
@@ -0,0 +1,463 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// the maximum number of keywords a user can mute
+const maxMutedKeywords = 100
+
+const (
+	blockKindBlock = "block"
+	blockKindMute  = "mute"
+)
+
+type (
+	// swagger:model
+	createBlockRequest struct {
+		// the user to block or mute, set either this or identity_id
+		UserId uuid.UUID `json:"user_id"`
+		// the identity to block or mute, set either this or user_id
+		IdentityId uuid.UUID `json:"identity_id"`
+		// block hides the target and stops it from commenting on your posts and messaging you,
+		// mute only hides it. Defaults to block.
+		Kind string `json:"kind" validate:"omitempty,oneof=block mute"`
+	}
+
+	// swagger:model
+	muteKeywordRequest struct {
+		// posts and comments containing the keyword are hidden, case insensitive
+		// required: true
+		Keyword string `json:"keyword" validate:"required,max=50"`
+	}
+)
+
+// blockedByIdentityOwner reports whether the user behind the identity has blocked userId.
+// Detached identities have no owner and block nobody.
+func (s *Server) blockedByIdentityOwner(ctx context.Context, identityId, userId uuid.UUID) (bool, error) {
+	identity, err := s.store.GetUserIdentityById(ctx, identityId)
+	if err != nil {
+		return false, err
+	}
+
+	if !identity.UserID.Valid || identity.UserID.UUID == userId {
+		return false, nil
+	}
+
+	return s.store.IsBlocked(ctx, db.IsBlockedParams{
+		UserID:  identity.UserID.UUID,
+		ActorID: userId,
+	})
+}
+
+// list blocked or muted users and identities
+func (s *Server) listBlocks(c echo.Context) error {
+	// swagger:operation GET /blocks blocks listBlocks
+	// ---
+	// summary: List blocks or mutes
+	// description: List the users and identities the current user has blocked or muted
+	// parameters:
+	// - name: kind
+	//   in: query
+	//   description: block or mute, defaults to block
+	//   required: false
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int64
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	kind := c.QueryParam("kind")
+	if kind == "" {
+		kind = blockKindBlock
+	}
+	if kind != blockKindBlock && kind != blockKindMute {
+		return c.JSON(http.StatusBadRequest, newError("kind must be block or mute"))
+	}
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	blocks, err := s.store.ListBlocks(c.Request().Context(), db.ListBlocksParams{
+		UserID: tokenPayload.UserId,
+		Kind:   kind,
+		Offset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(blocks))
+}
+
+// block or mute a user or identity
+func (s *Server) createBlock(c echo.Context) error {
+	// swagger:operation POST /blocks blocks createBlock
+	// ---
+	// summary: Block or mute
+	// description: Block or mute a user or identity. Blocking or muting a user only hides the content
+	//   they post under public identities. Blocking an already muted target turns the mute into a block
+	//   and the other way around.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the target
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/createBlockRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	var data createBlockRequest
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	if (data.UserId == uuid.Nil) == (data.IdentityId == uuid.Nil) {
+		return c.JSON(http.StatusBadRequest, newError("set either user_id or identity_id"))
+	}
+
+	if data.Kind == "" {
+		data.Kind = blockKindBlock
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	var block db.Block
+	var err error
+
+	if data.UserId != uuid.Nil {
+		if data.UserId == tokenPayload.UserId {
+			return c.JSON(http.StatusBadRequest, newError("you can't block yourself"))
+		}
+
+		if _, err := s.store.GetUserById(ctx, data.UserId); err != nil {
+			if err == sql.ErrNoRows {
+				return c.JSON(http.StatusNotFound, NOT_FOUND)
+			}
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+
+		block, err = s.store.BlockUser(ctx, db.BlockUserParams{
+			ID:            uuid.New(),
+			UserID:        tokenPayload.UserId,
+			BlockedUserID: data.UserId,
+			Kind:          data.Kind,
+		})
+	} else {
+		identity, err := s.store.GetUserIdentityById(ctx, data.IdentityId)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return c.JSON(http.StatusNotFound, NOT_FOUND)
+			}
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+
+		if identity.UserID.Valid && identity.UserID.UUID == tokenPayload.UserId {
+			return c.JSON(http.StatusBadRequest, newError("you can't block your own identity"))
+		}
+
+		block, err = s.store.BlockIdentity(ctx, db.BlockIdentityParams{
+			ID:                uuid.New(),
+			UserID:            tokenPayload.UserId,
+			BlockedIdentityID: data.IdentityId,
+			Kind:              data.Kind,
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(block))
+}
+
+// remove a block or mute
+func (s *Server) deleteBlock(c echo.Context) error {
+	// swagger:operation DELETE /blocks/{id} blocks deleteBlock
+	// ---
+	// summary: Unblock or unmute
+	// description: Remove a block or mute
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: block id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	blockId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	deleted, err := s.store.DeleteBlock(c.Request().Context(), db.DeleteBlockParams{
+		ID:     blockId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(deleted))
+}
+
+// list muted keywords
+func (s *Server) listMutedKeywords(c echo.Context) error {
+	// swagger:operation GET /blocks/keywords blocks listMutedKeywords
+	// ---
+	// summary: List muted keywords
+	// description: List the keywords the current user has muted
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	keywords, err := s.store.ListMutedKeywords(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(keywords))
+}
+
+// mute a keyword
+func (s *Server) muteKeyword(c echo.Context) error {
+	// swagger:operation POST /blocks/keywords blocks muteKeyword
+	// ---
+	// summary: Mute a keyword
+	// description: Hide posts and comments containing the keyword from the current user
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the keyword
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/muteKeywordRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '409':
+	//     description: The keyword is already muted
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	var data muteKeywordRequest
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	data.Keyword = strings.TrimSpace(data.Keyword)
+
+	if err := c.Validate(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	count, err := s.store.CountMutedKeywords(ctx, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if count >= maxMutedKeywords {
+		return c.JSON(http.StatusBadRequest, newError("you can't mute more than 100 keywords"))
+	}
+
+	keyword, err := s.store.CreateMutedKeyword(ctx, db.CreateMutedKeywordParams{
+		ID:      uuid.New(),
+		UserID:  tokenPayload.UserId,
+		Keyword: data.Keyword,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return c.JSON(http.StatusConflict, newError("keyword already muted"))
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(keyword))
+}
+
+// unmute a keyword
+func (s *Server) unmuteKeyword(c echo.Context) error {
+	// swagger:operation DELETE /blocks/keywords/{id} blocks unmuteKeyword
+	// ---
+	// summary: Unmute a keyword
+	// description: Remove a muted keyword
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: muted keyword id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	keywordId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	deleted, err := s.store.DeleteMutedKeyword(c.Request().Context(), db.DeleteMutedKeywordParams{
+		ID:     keywordId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(deleted))
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBlock(t *testing.T) {
+	_, user := RandomUser(t)
+	_, other := RandomUser(t)
+	identity := RandomUserIdentity(t, other.ID)
+	own := RandomUserIdentity(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "BLOCK USER",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"user_id": %q}`, other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().BlockUser(gomock.Any(), gomock.Any()).Times(1).
+					Do(func(_ interface{}, arg db.BlockUserParams) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, other.ID, arg.BlockedUserID)
+						require.Equal(t, blockKindBlock, arg.Kind)
+					}).
+					Return(db.Block{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "MUTE IDENTITY",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"identity_id": %q, "kind": "mute"}`, identity.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().BlockIdentity(gomock.Any(), gomock.Any()).Times(1).
+					Do(func(_ interface{}, arg db.BlockIdentityParams) {
+						require.Equal(t, identity.ID, arg.BlockedIdentityID)
+						require.Equal(t, blockKindMute, arg.Kind)
+					}).
+					Return(db.Block{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "BOTH TARGETS",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"user_id": %q, "identity_id": %q}`, other.ID, identity.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().BlockUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID KIND",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"user_id": %q, "kind": "hide"}`, other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().BlockUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "SELF",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"user_id": %q}`, user.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().BlockUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "OWN IDENTITY",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"identity_id": %q}`, own.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(own.ID)).Times(1).Return(own, nil)
+				store.EXPECT().BlockIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "USER NOT FOUND",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks",
+			payload: fmt.Sprintf(`{"user_id": %q}`, other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().BlockUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListBlocks(t *testing.T) {
+	_, user := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/blocks?kind=mute&page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListBlocks(gomock.Any(), gomock.Eq(db.ListBlocksParams{UserID: user.ID, Kind: blockKindMute, Offset: pageSize})).
+					Times(1).Return([]db.Block{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID KIND",
+			method: http.MethodGet,
+			url:    "/api/v1/blocks?kind=hide",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListBlocks(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestDeleteBlock(t *testing.T) {
+	_, user := RandomUser(t)
+	blockId := uuid.New()
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/blocks/%s", blockId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteBlock(gomock.Any(), gomock.Eq(db.DeleteBlockParams{ID: blockId, UserID: user.ID})).
+					Times(1).Return(blockId, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/blocks/%s", blockId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteBlock(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestMuteKeyword(t *testing.T) {
+	_, user := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks/keywords",
+			payload: `{"keyword": "  spoilers "}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountMutedKeywords(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreateMutedKeyword(gomock.Any(), gomock.Any()).Times(1).
+					Do(func(_ interface{}, arg db.CreateMutedKeywordParams) {
+						require.Equal(t, "spoilers", arg.Keyword)
+					}).
+					Return(db.MutedKeyword{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "EMPTY",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks/keywords",
+			payload: `{"keyword": "   "}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMutedKeyword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "LIMIT REACHED",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks/keywords",
+			payload: `{"keyword": "spoilers"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountMutedKeywords(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(maxMutedKeywords), nil)
+				store.EXPECT().CreateMutedKeyword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "ALREADY MUTED",
+			method:  http.MethodPost,
+			url:     "/api/v1/blocks/keywords",
+			payload: `{"keyword": "spoilers"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountMutedKeywords(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateMutedKeyword(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.MutedKeyword{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUnmuteKeyword(t *testing.T) {
+	_, user := RandomUser(t)
+	keywordId := uuid.New()
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/blocks/keywords/%s", keywordId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteMutedKeyword(gomock.Any(), gomock.Eq(db.DeleteMutedKeywordParams{ID: keywordId, UserID: user.ID})).
+					Times(1).Return(keywordId, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/blocks/keywords/%s", keywordId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteMutedKeyword(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestCreateMessageBlocked(t *testing.T) {
+	_, user := RandomUser(t)
+	_, receiver := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "BLOCKED",
+			method:  http.MethodPost,
+			url:     "/api/v1/messages",
+			payload: fmt.Sprintf(`{"receiver_id": %q, "content": "hello there"}`, receiver.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Eq(db.IsBlockedParams{UserID: receiver.ID, ActorID: user.ID})).
+					Times(1).Return(true, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+	})
+}
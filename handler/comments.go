@@ -3,7 +3,9 @@ package handler
 import (
 	db "cnfs/db/sqlc"
 	"cnfs/token"
+	"context"
 	"database/sql"
+	"log"
 	"net/http"
 	"time"
 
@@ -14,9 +16,10 @@ import (
 type (
 	// swagger:model
 	createCommentRequest struct {
-		PostId         uuid.UUID `json:"post_id" validate:"required"`
-		Content        string    `json:"content" validate:"required"`
-		UserIdentityId uuid.UUID `json:"user_identity_id" validate:"required"`
+		PostId  uuid.UUID `json:"post_id" validate:"required"`
+		Content string    `json:"content" validate:"required"`
+		// the identity to comment under, defaults to the user's default identity
+		UserIdentityId uuid.UUID `json:"user_identity_id"`
 		ParentId       uuid.UUID `json:"parent_id" validate:"required"`
 	}
 
@@ -33,7 +36,8 @@ func (s *Server) listAllComments(c echo.Context) error {
 	// swagger:operation GET /posts/{id}/comments comments listAllComments
 	// ---
 	// summary: List all comments of a post
-	// description: List all comments of a post
+	// description: List all comments of a post.
+	//   Signed in users don't see comments they blocked or muted.
 	// parameters:
 	// - name: id
 	//   in: path
@@ -62,7 +66,10 @@ func (s *Server) listAllComments(c echo.Context) error {
 		return c.JSON(400, newError(err.Error()))
 	}
 
-	comments, err := s.store.ListAllComments(c.Request().Context(), postId)
+	comments, err := s.store.ListAllComments(c.Request().Context(), db.ListAllCommentsParams{
+		PostID:   postId,
+		ViewerID: viewerId(c),
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusNotFound, NOT_FOUND)
@@ -140,6 +147,14 @@ func (s *Server) createComment(c echo.Context) error {
 	//     description: Bad Request
 	//     schema:
 	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '403':
+	//     description: The author of the post has blocked the user
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Post Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
 	//   '500':
 	//     description: Internal Server Error
 	//     schema:
@@ -159,11 +174,37 @@ func (s *Server) createComment(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, err)
 	}
 
-	comment, err := s.store.CreateComment(c.Request().Context(), db.CreateCommentParams{
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	userIdentity, err := s.postingIdentity(c.Request().Context(), tokenPayload.UserId, req.UserIdentityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	post, err := s.store.GetPostById(c.Request().Context(), req.PostId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	blocked, err := s.blockedByIdentityOwner(c.Request().Context(), post.UserIdentityID, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+	if blocked {
+		return c.JSON(http.StatusForbidden, newError("you can't comment on this post"))
+	}
+
+	comment, err := s.store.CreateCommentTx(c.Request().Context(), db.CreateCommentParams{
 		ID:             commentId,
 		PostID:         req.PostId,
 		Content:        req.Content,
-		UserIdentityID: req.UserIdentityId,
+		UserIdentityID: userIdentity.ID,
 		ParentID:       req.ParentId,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
@@ -176,14 +217,47 @@ func (s *Server) createComment(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
+	s.notifyComment(c.Request().Context(), post, comment, tokenPayload.UserId)
+
 	return c.JSON(http.StatusOK, newResponse(comment))
 }
 
+// notifyComment tells the author of the parent comment about a reply, or the author of the post about a comment,
+// and sends the comment to the webhooks of the post author.
+func (s *Server) notifyComment(ctx context.Context, post db.Post, comment db.Comment, userId uuid.UUID) {
+	postAuthor := s.identityOwner(ctx, post.UserIdentityID, userId)
+	// replies are comments on the post too, as far as the webhooks of its author go
+	if postAuthor != uuid.Nil {
+		s.emitWebhookEvent(ctx, postAuthor, webhookEventCommentCreated, comment)
+	}
+
+	kind, recipient, subjectId := notificationTypeComment, postAuthor, post.ID
+
+	if comment.ParentID != comment.ID {
+		parent, err := s.store.GetComment(ctx, comment.ParentID)
+		if err != nil {
+			// the parent is gone, nobody is left to reply to
+			if err != sql.ErrNoRows {
+				log.Printf("cannot notify of reply %s: %v", comment.ID, err)
+			}
+			return
+		}
+		kind, recipient, subjectId = notificationTypeReply, s.identityOwner(ctx, parent.UserIdentityID, userId), parent.ID
+	}
+
+	if recipient == uuid.Nil {
+		return
+	}
+
+	s.push(ctx, recipient, eventTypeComment, comment)
+	s.notify(ctx, recipient, kind, subjectId, userId)
+}
+
 func (s *Server) updateComment(c echo.Context) error {
 	// swagger:operation PUT /comments/{id} comments updateComment
 	// ---
 	// summary: Update a comment
-	// description: Update a comment
+	// description: Update a comment, the previous content stays available in the revisions of the comment
 	// parameters:
 	// - name: id
 	//   in: path
@@ -232,14 +306,6 @@ func (s *Server) updateComment(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
-	userIdentityId, err := s.store.GetUserIdentityByUserId(c.Request().Context(), tokenPayload.UserId)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, NOT_FOUND)
-		}
-		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-	}
-
 	hasComment, err := s.store.GetComment(c.Request().Context(), commentId)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -248,14 +314,16 @@ func (s *Server) updateComment(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	if userIdentityId.ID != hasComment.UserIdentityID {
-		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	editor, err := s.ownedIdentity(c.Request().Context(), tokenPayload.UserId, hasComment.UserIdentityID)
+	if err != nil {
+		return identityErrorResponse(c, err)
 	}
 
 	comment, err := s.store.UpdateComment(c.Request().Context(), db.UpdateCommentParams{
-		ID:        commentId,
-		Content:   req.Content,
-		UpdatedAt: time.Now(),
+		ID:               commentId,
+		Content:          req.Content,
+		UpdatedAt:        time.Now(),
+		EditorIdentityID: editor.ID,
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -271,7 +339,7 @@ func (s *Server) deleteComment(c echo.Context) error {
 	// swagger:operation DELETE /comments/{id} comments deleteComment
 	// ---
 	// summary: Delete a comment
-	// description: Delete a comment
+	// description: Move a comment and its replies to the trash, they can be restored until they are purged after the retention period
 	// parameters:
 	// - name: id
 	//   in: path
@@ -305,14 +373,6 @@ func (s *Server) deleteComment(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
-	userIdentityId, err := s.store.GetUserIdentityByUserId(c.Request().Context(), tokenPayload.UserId)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, NOT_FOUND)
-		}
-		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-	}
-
 	hasComment, err := s.store.GetComment(c.Request().Context(), commentId)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -321,8 +381,8 @@ func (s *Server) deleteComment(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	if userIdentityId.ID != hasComment.UserIdentityID {
-		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	if _, err := s.ownedIdentity(c.Request().Context(), tokenPayload.UserId, hasComment.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
 	}
 
 	comment, err := s.store.DeleteComment(c.Request().Context(), commentId)
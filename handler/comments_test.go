@@ -27,7 +27,7 @@ func TestListAllComments(t *testing.T) {
 			name:    "OK",
 			payload: "/api/v1/posts/" + post.ID.String() + "/comments",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(post.ID)).Return(comments, nil)
+				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(db.ListAllCommentsParams{PostID: post.ID})).Return(comments, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -37,7 +37,7 @@ func TestListAllComments(t *testing.T) {
 			name:    "404 post not found",
 			payload: "/api/v1/posts/" + post.ID.String() + "/comments",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(post.ID)).Return([]db.Comment{}, sql.ErrNoRows)
+				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(db.ListAllCommentsParams{PostID: post.ID})).Return([]db.Comment{}, sql.ErrNoRows)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 404, rec.Code)
@@ -47,7 +47,7 @@ func TestListAllComments(t *testing.T) {
 			name:    "500 internal server error",
 			payload: "/api/v1/posts/" + post.ID.String() + "/comments",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(post.ID)).Return([]db.Comment{}, sql.ErrConnDone)
+				store.EXPECT().ListAllComments(gomock.Any(), gomock.Eq(db.ListAllCommentsParams{PostID: post.ID})).Return([]db.Comment{}, sql.ErrConnDone)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 500, rec.Code)
@@ -168,6 +168,12 @@ func TestCreateComment(t *testing.T) {
 	_, user := RandomUser(t)
 	post := RandomPost(t, uuid.New())
 	comment := RandomComment(t, post.ID, uuid.Nil)
+	// a top level comment is its own parent
+	comment.ParentID = comment.ID
+	identity := RandomUserIdentity(t, user.ID)
+	identity.ID = comment.UserIdentityID
+	author := RandomUserIdentity(t, uuid.New())
+	author.ID = post.UserIdentityID
 	arg := db.CreateCommentParams{
 		ID:             comment.ID,
 		PostID:         post.ID,
@@ -183,7 +189,14 @@ func TestCreateComment(t *testing.T) {
 			name:    "OK",
 			payload: fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": %q}`, comment.UserIdentityID, post.ID, comment.ParentID, comment.Content),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateComment(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Eq(db.IsBlockedParams{UserID: author.UserID.UUID, ActorID: user.ID})).Times(1).Return(false, nil)
+				store.EXPECT().CreateCommentTx(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{UserID: author.UserID.UUID, Event: webhookEventCommentCreated})).Times(1).Return(nil, nil)
+				store.EXPECT().CreateNotification(gomock.Any(), EqNotification(author.UserID.UUID, notificationTypeComment, post.ID, user.ID)).Times(1).Return(db.CreateNotificationRow{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -194,7 +207,11 @@ func TestCreateComment(t *testing.T) {
 			payload: fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": %q}`, comment.UserIdentityID, post.ID, comment.ParentID, comment.Content),
 
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateComment(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(db.Comment{}, sql.ErrNoRows)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Eq(db.IsBlockedParams{UserID: author.UserID.UUID, ActorID: user.ID})).Times(1).Return(false, nil)
+				store.EXPECT().CreateCommentTx(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(db.Comment{}, sql.ErrNoRows)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 404, rec.Code)
@@ -205,12 +222,43 @@ func TestCreateComment(t *testing.T) {
 			payload: fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": %q}`, comment.UserIdentityID, post.ID, comment.ParentID, comment.Content),
 
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateComment(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(db.Comment{}, sql.ErrConnDone)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Eq(db.IsBlockedParams{UserID: author.UserID.UUID, ActorID: user.ID})).Times(1).Return(false, nil)
+				store.EXPECT().CreateCommentTx(gomock.Any(), EqCreateCommentParams(&arg, arg.ID)).Times(1).Return(db.Comment{}, sql.ErrConnDone)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 500, rec.Code)
 			},
 		},
+		{
+			name:    "403 blocked by the post author",
+			payload: fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": %q}`, comment.UserIdentityID, post.ID, comment.ParentID, comment.Content),
+
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Eq(db.IsBlockedParams{UserID: author.UserID.UUID, ActorID: user.ID})).Times(1).Return(true, nil)
+				store.EXPECT().CreateCommentTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 403, rec.Code)
+			},
+		},
+		{
+			name:    "401 identity of another user",
+			payload: fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": %q}`, comment.UserIdentityID, post.ID, comment.ParentID, comment.Content),
+
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(RandomUserIdentity(t, uuid.New()), nil)
+				store.EXPECT().CreateCommentTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -269,6 +317,8 @@ func TestUpdateComment(t *testing.T) {
 	_, user := RandomUser(t)
 	post := RandomPost(t, uuid.New())
 	comment := RandomComment(t, post.ID, uuid.Nil)
+	identity := RandomUserIdentity(t, user.ID)
+	identity.ID = comment.UserIdentityID
 	newContent := common.RandomString(36)
 
 	testCases := []testCase{
@@ -278,13 +328,14 @@ func TestUpdateComment(t *testing.T) {
 			buildStubs: func(store *mock.MockStore) {
 				updatedAt := time.Now()
 				arg := db.UpdateCommentParams{
-					UpdatedAt: updatedAt,
-					Content:   newContent,
-					ID:        comment.ID,
+					UpdatedAt:        updatedAt,
+					Content:          newContent,
+					ID:               comment.ID,
+					EditorIdentityID: comment.UserIdentityID,
 				}
 
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1)
-				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1)
+				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
 				comment.UpdatedAt = updatedAt
 				store.EXPECT().UpdateComment(gomock.Any(), EqUpdateCommentParams(arg, updatedAt)).Times(1).Return(comment.ID, nil)
 			},
@@ -296,7 +347,7 @@ func TestUpdateComment(t *testing.T) {
 			name:    "400 bad request - Missing payload",
 			payload: "",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetComment(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -306,12 +357,12 @@ func TestUpdateComment(t *testing.T) {
 			name:    "Unauthorized - Cannot update a comment that does not belong to the user",
 			payload: `{"content": "new content"}`,
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           uuid.New(),
-					UserID:       uuid.New(),
+				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           comment.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
 				store.EXPECT().UpdateComment(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -353,14 +404,16 @@ func TestDeleteComment(t *testing.T) {
 	_, user := RandomUser(t)
 	post := RandomPost(t, uuid.New())
 	comment := RandomComment(t, post.ID, uuid.Nil)
+	identity := RandomUserIdentity(t, user.ID)
+	identity.ID = comment.UserIdentityID
 
 	testCases := []testCase{
 		{
 			name:    "OK",
 			payload: comment.ID.String(),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1)
-				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1)
+				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(identity, nil)
 				store.EXPECT().DeleteComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment.ID, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -371,12 +424,12 @@ func TestDeleteComment(t *testing.T) {
 			name:    "Unauthorized - Cannot delete a comment that does not belong to the user",
 			payload: comment.ID.String(),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           uuid.New(),
-					UserID:       uuid.New(),
+				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(comment.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           comment.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-				store.EXPECT().GetComment(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
 				store.EXPECT().DeleteComment(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -0,0 +1,376 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"net/http"
+	"sort"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	contentActionHide = "hide"
+	contentActionBlur = "blur"
+	contentActionShow = "show"
+
+	// nsfw is a category of the preferences, posts carry it as a flag of its own
+	contentCategoryNsfw = "nsfw"
+)
+
+// flagged content is blurred until the viewer chooses otherwise, signed in or not
+const defaultContentAction = contentActionBlur
+
+// the content warnings authors and moderators can put on posts
+var contentWarnings = []string{
+	"violence",
+	"self_harm",
+	"suicide",
+	"abuse",
+	"substances",
+	"eating_disorders",
+	"grief",
+	"sexual_content",
+}
+
+// how strongly each action keeps a post out of sight, the strongest of the categories of a post wins
+var contentActionRanks = map[string]int{
+	contentActionShow: 0,
+	contentActionBlur: 1,
+	contentActionHide: 2,
+}
+
+type (
+	// swagger:model
+	updateContentFlagsRequest struct {
+		// the content warnings of the post, replacing the current ones
+		ContentWarnings []string `json:"content_warnings" validate:"max=8,unique,dive,content_warning"`
+		Nsfw            bool     `json:"nsfw"`
+	}
+
+	// swagger:model
+	contentPreferencesRequest struct {
+		// hide, blur or show for each category, categories left out are blurred
+		Preferences map[string]string `json:"preferences" validate:"dive,keys,content_category,endkeys,oneof=hide blur show"`
+	}
+
+	// swagger:model
+	contentPreferencesResponse struct {
+		// hide, blur or show for each category the user set
+		Preferences map[string]string `json:"preferences"`
+	}
+)
+
+// isContentWarning validates a content warning of a post.
+func isContentWarning(fl validator.FieldLevel) bool {
+	for _, warning := range contentWarnings {
+		if fl.Field().String() == warning {
+			return true
+		}
+	}
+	return false
+}
+
+// isContentCategory validates a category of the content preferences.
+func isContentCategory(fl validator.FieldLevel) bool {
+	return fl.Field().String() == contentCategoryNsfw || isContentWarning(fl)
+}
+
+// contentWarningsOf stores no warnings as an empty list rather than null.
+func contentWarningsOf(warnings []string) []string {
+	if warnings == nil {
+		return []string{}
+	}
+	return warnings
+}
+
+// contentPreferences reads what the viewer set for each category, nobody signed in has none.
+func (s *Server) contentPreferences(ctx context.Context, viewerId uuid.UUID) (map[string]string, error) {
+	if viewerId == uuid.Nil {
+		return nil, nil
+	}
+
+	preferences, err := s.store.ListContentPreferences(ctx, viewerId)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make(map[string]string, len(preferences))
+	for _, preference := range preferences {
+		actions[preference.Category] = preference.Action
+	}
+
+	return actions, nil
+}
+
+// contentAction is what the preferences ask for a post, show when the post is not flagged.
+func contentAction(preferences map[string]string, post db.Post) string {
+	categories := post.ContentWarnings
+	if post.Nsfw {
+		categories = append([]string{contentCategoryNsfw}, categories...)
+	}
+
+	action := contentActionShow
+	for _, category := range categories {
+		categoryAction, ok := preferences[category]
+		if !ok {
+			categoryAction = defaultContentAction
+		}
+		if contentActionRanks[categoryAction] > contentActionRanks[action] {
+			action = categoryAction
+		}
+	}
+
+	return action
+}
+
+// update the content warnings and nsfw flag of a post
+func (s *Server) updateContentFlags(c echo.Context) error {
+	// swagger:operation PUT /posts/{id}/content-flags posts updateContentFlags
+	// ---
+	// summary: Update the content flags of a post
+	// description: Set the content warnings and nsfw flag of a post. The author can set them until a moderator does,
+	//   after that only moderators can change them.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the flags
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/updateContentFlagsRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '403':
+	//     description: A moderator set the flags
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(updateContentFlagsRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	post, err := s.store.GetPostById(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	// moderators flag any post, the author only their own
+	user, err := s.store.GetUserById(ctx, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if !user.IsModerator {
+		if _, err := s.ownedIdentity(ctx, tokenPayload.UserId, post.UserIdentityID); err != nil {
+			return identityErrorResponse(c, err)
+		}
+		if post.FlagsModeratedAt != nil {
+			return c.JSON(http.StatusForbidden, newError("a moderator set the content flags of this post"))
+		}
+	}
+
+	updatedPost, err := s.store.SetPostContentFlags(ctx, db.SetPostContentFlagsParams{
+		ID:              postId,
+		ContentWarnings: contentWarningsOf(req.ContentWarnings),
+		Nsfw:            req.Nsfw,
+		Moderated:       user.IsModerator,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusForbidden, newError("a moderator set the content flags of this post"))
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(updatedPost))
+}
+
+// get the content preferences of the user
+func (s *Server) getContentPreferences(c echo.Context) error {
+	// swagger:operation GET /users/{id}/content-preferences users getContentPreferences
+	// ---
+	// summary: Get the content preferences of the user
+	// description: Get whether the user hides, blurs or shows posts of each content category.
+	//   Categories the user did not set are blurred.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/contentPreferencesResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok || tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	preferences, err := s.contentPreferences(c.Request().Context(), userId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(contentPreferencesResponse{Preferences: preferences}))
+}
+
+// replace the content preferences of the user
+func (s *Server) updateContentPreferences(c echo.Context) error {
+	// swagger:operation PUT /users/{id}/content-preferences users updateContentPreferences
+	// ---
+	// summary: Update the content preferences of the user
+	// description: Choose to hide, blur or show posts of each content category, replacing the previous choices.
+	//   Hidden posts are left out of the post lists and feeds, blurred posts come with blur set.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the preferences
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/contentPreferencesRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/contentPreferencesResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok || tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	req := new(contentPreferencesRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	categories := make([]string, 0, len(req.Preferences))
+	for category := range req.Preferences {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	actions := make([]string, 0, len(categories))
+	for _, category := range categories {
+		actions = append(actions, req.Preferences[category])
+	}
+
+	err = s.store.SetContentPreferences(c.Request().Context(), db.SetContentPreferencesParams{
+		UserID:     userId,
+		Categories: categories,
+		Actions:    actions,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	preferences := req.Preferences
+	if preferences == nil {
+		preferences = map[string]string{}
+	}
+
+	return c.JSON(http.StatusOK, newResponse(contentPreferencesResponse{Preferences: preferences}))
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentAction(t *testing.T) {
+	post := db.Post{ContentWarnings: []string{}}
+	require.Equal(t, contentActionShow, contentAction(nil, post))
+
+	post.Nsfw = true
+	require.Equal(t, contentActionBlur, contentAction(nil, post))
+	require.Equal(t, contentActionShow, contentAction(map[string]string{"nsfw": contentActionShow}, post))
+
+	post.ContentWarnings = []string{"grief", "violence"}
+	preferences := map[string]string{"nsfw": contentActionShow, "grief": contentActionShow, "violence": contentActionHide}
+	require.Equal(t, contentActionHide, contentAction(preferences, post))
+
+	delete(preferences, "violence")
+	require.Equal(t, contentActionBlur, contentAction(preferences, post))
+}
+
+func TestCreatePostWithContentFlags(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "a hard year", "content_warnings": ["grief", "self_harm"], "nsfw": false}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.Equal(t, []string{"grief", "self_harm"}, arg.ContentWarnings)
+						require.False(t, arg.Nsfw)
+						return db.Post{ID: arg.ID, Status: arg.Status, ContentWarnings: arg.ContentWarnings}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "NO WARNINGS",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "all good", "nsfw": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.NotNil(t, arg.ContentWarnings)
+						require.Empty(t, arg.ContentWarnings)
+						require.True(t, arg.Nsfw)
+						return db.Post{ID: arg.ID, Status: arg.Status, Nsfw: arg.Nsfw}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "UNKNOWN WARNING",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "hmm", "content_warnings": ["spoilers"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUpdateContentFlags(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	moderated := post
+	moderatedAt := time.Now()
+	moderated.FlagsModeratedAt = &moderatedAt
+	moderator := user
+	moderator.IsModerator = true
+	other := RandomUserIdentity(t, uuid.New())
+	url := fmt.Sprintf("/api/v1/posts/%s/content-flags", post.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "AUTHOR",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content_warnings": ["abuse"], "nsfw": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().SetPostContentFlags(gomock.Any(), gomock.Eq(db.SetPostContentFlagsParams{
+					ID:              post.ID,
+					ContentWarnings: []string{"abuse"},
+					Nsfw:            true,
+				})).Times(1).Return(post, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "AUTHOR AFTER A MODERATOR",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"nsfw": false}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(moderated, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().SetPostContentFlags(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, rec.Code)
+			},
+		},
+		{
+			name:    "MODERATOR",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content_warnings": ["violence"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(RandomPost(t, other.ID), nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(moderator, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SetPostContentFlags(gomock.Any(), gomock.Eq(db.SetPostContentFlagsParams{
+					ID:              post.ID,
+					ContentWarnings: []string{"violence"},
+					Moderated:       true,
+				})).Times(1).Return(moderated, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "SOMEONE ELSE",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"nsfw": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(RandomPost(t, other.ID), nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().SetPostContentFlags(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:    "UNKNOWN WARNING",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content_warnings": ["nsfw"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestContentPreferences(t *testing.T) {
+	_, user := RandomUser(t)
+	url := fmt.Sprintf("/api/v1/users/%s/content-preferences", user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "GET",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListContentPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).
+					Return([]db.ContentPreference{{UserID: user.ID, Category: "nsfw", Action: contentActionHide}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data contentPreferencesResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, map[string]string{"nsfw": contentActionHide}, resp.Data.Preferences)
+			},
+		},
+		{
+			name:    "UPDATE",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"preferences": {"violence": "hide", "nsfw": "show", "grief": "blur"}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetContentPreferences(gomock.Any(), gomock.Eq(db.SetContentPreferencesParams{
+					UserID:     user.ID,
+					Categories: []string{"grief", "nsfw", "violence"},
+					Actions:    []string{"blur", "show", "hide"},
+				})).Times(1).Return(nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "UNKNOWN CATEGORY",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"preferences": {"spoilers": "hide"}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetContentPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "UNKNOWN ACTION",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"preferences": {"nsfw": "delete"}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetContentPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "SOMEONE ELSE",
+			method:  http.MethodPut,
+			url:     fmt.Sprintf("/api/v1/users/%s/content-preferences", uuid.New()),
+			payload: `{"preferences": {}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetContentPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	})
+}
+
+func TestGetFlaggedPost(t *testing.T) {
+	_, user := RandomUser(t)
+	post := RandomPost(t, uuid.New())
+	post.Nsfw = true
+	post.ContentWarnings = []string{}
+
+	checkBlur := func(blur bool) func(rec *httptest.ResponseRecorder) {
+		return func(rec *httptest.ResponseRecorder) {
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var resp struct {
+				Data postResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.True(t, resp.Data.Nsfw)
+			require.Equal(t, blur, resp.Data.Blur)
+		}
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "SHOWN",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().ListContentPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).
+					Return([]db.ContentPreference{{Category: "nsfw", Action: contentActionShow}}, nil)
+			},
+			checkResponse: checkBlur(false),
+		},
+		{
+			name:   "BLURRED BY DEFAULT",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().ListContentPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.ContentPreference{}, nil)
+			},
+			checkResponse: checkBlur(true),
+		},
+		{
+			name:   "HIDDEN IS BLURRED WHEN ASKED FOR",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().ListContentPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).
+					Return([]db.ContentPreference{{Category: "nsfw", Action: contentActionHide}}, nil)
+			},
+			checkResponse: checkBlur(true),
+		},
+	})
+}
@@ -0,0 +1,378 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/digest"
+	"cnfs/mail"
+	"cnfs/token"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// the number of digests sent per run of the digest job
+	digestBatchSize = 100
+	// how long a claimed digest is left to its server before another one retries it
+	digestLease = time.Hour
+	// how long the mailer has to take one digest
+	digestSendTimeout = time.Minute
+	// the number of top comments in a digest
+	digestTopComments = 5
+)
+
+type (
+	// swagger:model
+	emailDigestRequest struct {
+		// where the digests are sent
+		Email string `json:"email" validate:"required,max=254,email"`
+		// daily or weekly
+		Frequency string `json:"frequency" validate:"required,oneof=daily weekly"`
+	}
+)
+
+// newUnsubscribeToken makes the secret of the unsubscribe links of a user's digests.
+func newUnsubscribeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// unsubscribeUrl is the unsubscribe link of the digests, it works without logging in.
+func (s *Server) unsubscribeUrl(unsubscribeToken string) string {
+	return strings.TrimRight(s.cfg.ApiUrl, "/") + "/api/v1/digest/unsubscribe?" + url.Values{"token": {unsubscribeToken}}.Encode()
+}
+
+// sendEmailDigests sends the digests that are due. A digest with nothing new in it is skipped, but still
+// counts as sent so the next one covers the period after it.
+func (s *Server) sendEmailDigests(ctx context.Context) error {
+	digests, err := s.store.ClaimDueEmailDigests(ctx, db.ClaimDueEmailDigestsParams{
+		LeaseSeconds: digestLease.Seconds(),
+		BatchSize:    digestBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range digests {
+		if err := s.sendEmailDigest(ctx, d); err != nil {
+			// the lease runs out and the digest is tried again
+			log.Printf("cannot send email digest to %s: %v", d.UserID, err)
+			continue
+		}
+
+		if err := s.store.MarkEmailDigestSent(ctx, d.UserID); err != nil {
+			log.Printf("cannot mark email digest of %s sent: %v", d.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendEmailDigest gathers what happened since the last digest of the user, or since they opted in, and mails it.
+func (s *Server) sendEmailDigest(ctx context.Context, d db.ClaimDueEmailDigestsRow) error {
+	since := d.CreatedAt
+	if d.LastSentAt != nil {
+		since = *d.LastSentAt
+	}
+
+	messages, err := s.store.CountDigestMessages(ctx, db.CountDigestMessagesParams{Since: since, UserID: d.UserID})
+	if err != nil {
+		return err
+	}
+
+	followers, err := s.store.CountDigestFollowers(ctx, db.CountDigestFollowersParams{Since: since, UserID: d.UserID})
+	if err != nil {
+		return err
+	}
+
+	comments, err := s.store.ListDigestTopComments(ctx, db.ListDigestTopCommentsParams{
+		UserID: d.UserID,
+		Since:  since,
+		Top:    digestTopComments,
+	})
+	if err != nil {
+		return err
+	}
+
+	clientUrl := strings.TrimRight(s.cfg.ClientUrl, "/")
+	content := digest.Digest{
+		Username:       d.Username,
+		Frequency:      d.Frequency,
+		UnreadMessages: messages.Unread,
+		NewMessages:    messages.New,
+		NewFollowers:   followers,
+		AppUrl:         clientUrl,
+		UnsubscribeUrl: s.unsubscribeUrl(d.UnsubscribeToken),
+	}
+	for _, comment := range comments {
+		content.TopComments = append(content.TopComments, digest.Comment{
+			Content:     comment.Content,
+			PostContent: comment.PostContent,
+			Url:         clientUrl + "/posts/" + comment.PostID.String(),
+			Replies:     comment.Replies,
+		})
+	}
+
+	if content.Empty() {
+		return nil
+	}
+
+	html, text, err := content.Render()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, digestSendTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      d.Email,
+		Subject: content.Subject(),
+		HTML:    html,
+		Text:    text,
+		Headers: map[string]string{
+			// mail clients show their own unsubscribe button, which posts to the link as RFC 8058 describes
+			"List-Unsubscribe":      "<" + content.UnsubscribeUrl + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+// get the email digest settings of the user
+func (s *Server) getEmailDigest(c echo.Context) error {
+	// swagger:operation GET /digest digest getEmailDigest
+	// ---
+	// summary: Get the email digest settings
+	// description: Get where and how often the user gets email digests, not found when they did not opt in.
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/EmailDigest"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	emailDigest, err := s.store.GetEmailDigest(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(emailDigest))
+}
+
+// opt in to email digests or change their settings
+func (s *Server) setEmailDigest(c echo.Context) error {
+	// swagger:operation PUT /digest digest setEmailDigest
+	// ---
+	// summary: Set the email digest settings
+	// description: Opt in to a daily or weekly email of unread confessions, top comments on the posts of the
+	//   user and new followers, or change where and how often it is sent. The first digest goes out one period
+	//   after opting in.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the email and frequency
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/emailDigestRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/EmailDigest"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(emailDigestRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	// only used when opting in, the links of the digests already sent keep working
+	unsubscribeToken, err := newUnsubscribeToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	emailDigest, err := s.store.SetEmailDigest(c.Request().Context(), db.SetEmailDigestParams{
+		UserID:           tokenPayload.UserId,
+		Email:            req.Email,
+		Frequency:        req.Frequency,
+		UnsubscribeToken: unsubscribeToken,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(emailDigest))
+}
+
+// opt out of email digests
+func (s *Server) deleteEmailDigest(c echo.Context) error {
+	// swagger:operation DELETE /digest digest deleteEmailDigest
+	// ---
+	// summary: Opt out of email digests
+	// description: Stop the email digests and forget the email they were sent to.
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	id, err := s.store.DeleteEmailDigest(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(id))
+}
+
+// show the page of the unsubscribe link of a digest
+func (s *Server) confirmUnsubscribeEmailDigest(c echo.Context) error {
+	// swagger:operation GET /digest/unsubscribe digest confirmUnsubscribeEmailDigest
+	// ---
+	// summary: Confirm unsubscribing from email digests
+	// description: The page the unsubscribe link of a digest opens, an HTML form that unsubscribes on submit.
+	//   Opening it does not unsubscribe, mail scanners open links too.
+	// produces:
+	// - text/html
+	// parameters:
+	// - name: token
+	//   in: query
+	//   description: the token of the unsubscribe link
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: OK
+	//   '400':
+	//     description: Bad Request
+
+	unsubscribeToken := c.QueryParam("token")
+	if unsubscribeToken == "" {
+		return c.String(http.StatusBadRequest, "missing token")
+	}
+
+	page, err := digest.UnsubscribePage(s.unsubscribeUrl(unsubscribeToken), s.cfg.ClientUrl, false)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "internal error")
+	}
+
+	return c.HTML(http.StatusOK, page)
+}
+
+// unsubscribe from email digests with the token of a digest
+func (s *Server) unsubscribeEmailDigest(c echo.Context) error {
+	// swagger:operation POST /digest/unsubscribe digest unsubscribeEmailDigest
+	// ---
+	// summary: Unsubscribe from email digests
+	// description: Unsubscribe with the token of the link in a digest, without logging in. Mail clients post
+	//   here for their own unsubscribe button as RFC 8058 describes. Unsubscribing twice, or with a token no
+	//   longer in use, is not an error.
+	// produces:
+	// - text/html
+	// parameters:
+	// - name: token
+	//   in: query
+	//   description: the token of the unsubscribe link
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: OK
+	//   '400':
+	//     description: Bad Request
+
+	unsubscribeToken := c.QueryParam("token")
+	if unsubscribeToken == "" {
+		return c.String(http.StatusBadRequest, "missing token")
+	}
+
+	if _, err := s.store.UnsubscribeEmailDigest(c.Request().Context(), unsubscribeToken); err != nil {
+		return c.String(http.StatusInternalServerError, "internal error")
+	}
+
+	page, err := digest.UnsubscribePage("", s.cfg.ClientUrl, true)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, "internal error")
+	}
+
+	return c.HTML(http.StatusOK, page)
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"cnfs/mail"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer keeps the messages it is given instead of sending them, failing for the addresses in fail.
+type recordingMailer struct {
+	sent []mail.Message
+	fail map[string]bool
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	if m.fail[msg.To] {
+		return errors.New("relay refused the message")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestGetEmailDigest(t *testing.T) {
+	_, user := RandomUser(t)
+	emailDigest := db.EmailDigest{UserID: user.ID, Email: "moth@cnfs.example", Frequency: "weekly", UnsubscribeToken: "secret-token"}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/digest",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetEmailDigest(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(emailDigest, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), emailDigest.Email)
+				require.NotContains(t, rec.Body.String(), emailDigest.UnsubscribeToken)
+			},
+		},
+		{
+			name:   "NOT OPTED IN",
+			method: http.MethodGet,
+			url:    "/api/v1/digest",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetEmailDigest(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.EmailDigest{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestSetEmailDigest(t *testing.T) {
+	_, user := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPut,
+			url:     "/api/v1/digest",
+			payload: `{"email": "moth@cnfs.example", "frequency": "daily"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetEmailDigest(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SetEmailDigestParams) (db.EmailDigest, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, "moth@cnfs.example", arg.Email)
+						require.Equal(t, "daily", arg.Frequency)
+						require.Len(t, arg.UnsubscribeToken, 64)
+						return db.EmailDigest{UserID: arg.UserID, Email: arg.Email, Frequency: arg.Frequency, UnsubscribeToken: arg.UnsubscribeToken}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), "unsubscribe_token")
+			},
+		},
+		{
+			name:    "INVALID EMAIL",
+			method:  http.MethodPut,
+			url:     "/api/v1/digest",
+			payload: `{"email": "moth", "frequency": "daily"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetEmailDigest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID FREQUENCY",
+			method:  http.MethodPut,
+			url:     "/api/v1/digest",
+			payload: `{"email": "moth@cnfs.example", "frequency": "hourly"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetEmailDigest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestDeleteEmailDigest(t *testing.T) {
+	_, user := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodDelete,
+			url:    "/api/v1/digest",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteEmailDigest(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user.ID, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "NOT OPTED IN",
+			method: http.MethodDelete,
+			url:    "/api/v1/digest",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteEmailDigest(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUnsubscribeEmailDigest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "CONFIRM PAGE",
+			method: http.MethodGet,
+			url:    "/api/v1/digest/unsubscribe?token=abc",
+			buildStubs: func(store *mock.MockStore) {
+				// following the link must not unsubscribe
+				store.EXPECT().UnsubscribeEmailDigest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), `action="`+cfg.ApiUrl+`/api/v1/digest/unsubscribe?token=abc"`)
+			},
+		},
+		{
+			name:   "ONE CLICK",
+			method: http.MethodPost,
+			url:    "/api/v1/digest/unsubscribe?token=abc",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnsubscribeEmailDigest(gomock.Any(), gomock.Eq("abc")).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), "You are unsubscribed")
+			},
+		},
+		{
+			name:   "ALREADY UNSUBSCRIBED",
+			method: http.MethodPost,
+			url:    "/api/v1/digest/unsubscribe?token=abc",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnsubscribeEmailDigest(gomock.Any(), gomock.Eq("abc")).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "MISSING TOKEN",
+			method: http.MethodPost,
+			url:    "/api/v1/digest/unsubscribe",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnsubscribeEmailDigest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			// mail clients post the form of RFC 8058 without any credentials
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader("List-Unsubscribe=One-Click"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
+
+func TestSendEmailDigests(t *testing.T) {
+	lastSent := time.Now().Add(-7 * 24 * time.Hour)
+	active := db.ClaimDueEmailDigestsRow{
+		UserID: uuid.New(), Email: "active@cnfs.example", Frequency: "weekly", UnsubscribeToken: "active-token",
+		LastSentAt: &lastSent, Username: "active",
+	}
+	quiet := db.ClaimDueEmailDigestsRow{
+		UserID: uuid.New(), Email: "quiet@cnfs.example", Frequency: "daily", UnsubscribeToken: "quiet-token",
+		CreatedAt: time.Now().Add(-24 * time.Hour), Username: "quiet",
+	}
+	failing := db.ClaimDueEmailDigestsRow{
+		UserID: uuid.New(), Email: "failing@cnfs.example", Frequency: "daily", UnsubscribeToken: "failing-token",
+		CreatedAt: time.Now().Add(-24 * time.Hour), Username: "failing",
+	}
+	postId := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().ClaimDueEmailDigests(gomock.Any(), gomock.Eq(db.ClaimDueEmailDigestsParams{
+		LeaseSeconds: digestLease.Seconds(),
+		BatchSize:    digestBatchSize,
+	})).Times(1).Return([]db.ClaimDueEmailDigestsRow{active, quiet, failing}, nil)
+
+	store.EXPECT().CountDigestMessages(gomock.Any(), gomock.Eq(db.CountDigestMessagesParams{Since: lastSent, UserID: active.UserID})).
+		Times(1).Return(db.CountDigestMessagesRow{Unread: 4, New: 2}, nil)
+	store.EXPECT().CountDigestFollowers(gomock.Any(), gomock.Eq(db.CountDigestFollowersParams{Since: lastSent, UserID: active.UserID})).
+		Times(1).Return(int64(1), nil)
+	store.EXPECT().ListDigestTopComments(gomock.Any(), gomock.Eq(db.ListDigestTopCommentsParams{UserID: active.UserID, Since: lastSent, Top: digestTopComments})).
+		Times(1).Return([]db.ListDigestTopCommentsRow{{ID: uuid.New(), Content: "<i>same</i>", PostID: postId, PostContent: "my confession", Replies: 3}}, nil)
+
+	// unread messages from before the last digest alone are not worth an email
+	for _, d := range []db.ClaimDueEmailDigestsRow{quiet, failing} {
+		messages := db.CountDigestMessagesRow{Unread: 2}
+		if d.UserID == failing.UserID {
+			messages.New = 1
+		}
+		store.EXPECT().CountDigestMessages(gomock.Any(), gomock.Eq(db.CountDigestMessagesParams{Since: d.CreatedAt, UserID: d.UserID})).
+			Times(1).Return(messages, nil)
+		store.EXPECT().CountDigestFollowers(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+		store.EXPECT().ListDigestTopComments(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+	}
+
+	store.EXPECT().MarkEmailDigestSent(gomock.Any(), gomock.Eq(active.UserID)).Times(1).Return(nil)
+	store.EXPECT().MarkEmailDigestSent(gomock.Any(), gomock.Eq(quiet.UserID)).Times(1).Return(nil)
+	// a digest that could not be sent is left to the lease to retry
+	store.EXPECT().MarkEmailDigestSent(gomock.Any(), gomock.Eq(failing.UserID)).Times(0)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+	mailer := &recordingMailer{fail: map[string]bool{failing.Email: true}}
+	server.mailer = mailer
+
+	require.NoError(t, server.sendEmailDigests(context.Background()))
+
+	require.Len(t, mailer.sent, 1)
+	msg := mailer.sent[0]
+	require.Equal(t, active.Email, msg.To)
+	require.Equal(t, "Your weekly digest: 4 unread confessions", msg.Subject)
+	require.Contains(t, msg.HTML, "&lt;i&gt;same&lt;/i&gt;")
+	require.Contains(t, msg.Text, fmt.Sprintf("%s/posts/%s", cfg.ClientUrl, postId))
+	require.Equal(t, fmt.Sprintf("<%s/api/v1/digest/unsubscribe?token=active-token>", cfg.ApiUrl), msg.Headers["List-Unsubscribe"])
+	require.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+}
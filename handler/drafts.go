@@ -0,0 +1,355 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	postStatusDraft     = "draft"
+	postStatusScheduled = "scheduled"
+	postStatusPublished = "published"
+)
+
+// the number of scheduled posts published per query of the scheduler
+const scheduledPostBatchSize = 100
+
+var errPublishAtInPast = errors.New("publish_at has to be in the future")
+
+type (
+	// swagger:model
+	updateDraftRequest struct {
+		Content string `json:"content" validate:"required,max=10000"`
+		// publish the post at this time, it has to be in the future. Without it the post stays a draft.
+		PublishAt *time.Time `json:"publish_at"`
+	}
+)
+
+// unpublishedStatus is the status of a post kept from being published, scheduled when it has a publish time.
+func unpublishedStatus(publishAt *time.Time) (string, error) {
+	if publishAt == nil {
+		return postStatusDraft, nil
+	}
+	if !publishAt.After(time.Now()) {
+		return "", errPublishAtInPast
+	}
+	return postStatusScheduled, nil
+}
+
+// draftPost gets an unpublished post of the user, the errors are those of ownedIdentity.
+func (s *Server) draftPost(ctx context.Context, userId, postId uuid.UUID) (db.Post, error) {
+	post, err := s.store.GetDraftPost(ctx, postId)
+	if err != nil {
+		return db.Post{}, err
+	}
+
+	if _, err := s.ownedIdentity(ctx, userId, post.UserIdentityID); err != nil {
+		return db.Post{}, err
+	}
+
+	return post, nil
+}
+
+// list the drafts and scheduled posts of the user
+func (s *Server) listDrafts(c echo.Context) error {
+	// swagger:operation GET /posts/drafts posts listDrafts
+	// ---
+	// summary: List the drafts of the user
+	// description: List the drafts and scheduled posts of the user under all of their identities,
+	//   the next to be published first. Only the user can see them until they are published.
+	// parameters:
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int32
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	posts, err := s.store.ListDraftPosts(c.Request().Context(), db.ListDraftPostsParams{
+		UserID:     tokenPayload.UserId,
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(posts))
+}
+
+// update a draft or scheduled post
+func (s *Server) updateDraft(c echo.Context) error {
+	// swagger:operation PUT /posts/drafts/{id} posts updateDraft
+	// ---
+	// summary: Update a draft
+	// description: Replace the content and publish time of a draft or scheduled post.
+	//   Setting a publish time schedules the post, leaving it out turns it back into a draft.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: draft
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/updateDraftRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(updateDraftRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	status, err := unpublishedStatus(req.PublishAt)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	if _, err := s.draftPost(c.Request().Context(), tokenPayload.UserId, id); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	// the scheduler may publish the post in between, then it is no longer a draft
+	post, err := s.store.UpdateDraftPostTx(c.Request().Context(), db.UpdateDraftPostTxParams{
+		UpdateDraftPostParams: db.UpdateDraftPostParams{
+			ID:        id,
+			Content:   req.Content,
+			Status:    status,
+			PublishAt: req.PublishAt,
+		},
+		Tags: parseHashtags(req.Content),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(post))
+}
+
+// publish a draft or scheduled post now
+func (s *Server) publishDraft(c echo.Context) error {
+	// swagger:operation POST /posts/drafts/{id}/publish posts publishDraft
+	// ---
+	// summary: Publish a draft
+	// description: Publish a draft or scheduled post now, it is dated from its publication.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	if _, err := s.draftPost(c.Request().Context(), tokenPayload.UserId, id); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	if _, err := s.store.PublishDraftPost(c.Request().Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	post, err := s.store.GetPostById(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(post))
+}
+
+// delete a draft or scheduled post
+func (s *Server) deleteDraft(c echo.Context) error {
+	// swagger:operation DELETE /posts/drafts/{id} posts deleteDraft
+	// ---
+	// summary: Delete a draft
+	// description: Move a draft or scheduled post to the trash, it is not published while it is there
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	if _, err := s.draftPost(c.Request().Context(), tokenPayload.UserId, id); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	deletedPost, err := s.store.DeletePost(c.Request().Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(deletedPost))
+}
+
+// publishScheduledPosts publishes the scheduled posts whose publish time has passed. The rows are claimed with
+// SKIP LOCKED, so each post is published by exactly one of the servers running the job.
+func (s *Server) publishScheduledPosts(ctx context.Context) error {
+	for {
+		published, err := s.store.PublishDuePosts(ctx, scheduledPostBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(published) < scheduledPostBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDraftPost(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "DRAFT",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "not yet #soon", "draft": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.Equal(t, postStatusDraft, arg.Status)
+						require.Nil(t, arg.PublishAt)
+						return db.Post{ID: arg.ID, Content: arg.Content, UserIdentityID: arg.UserIdentityID, Status: arg.Status}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "SCHEDULED",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": "later", "publish_at": %q}`, publishAt.Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.Equal(t, postStatusScheduled, arg.Status)
+						require.NotNil(t, arg.PublishAt)
+						require.True(t, publishAt.Equal(*arg.PublishAt))
+						return db.Post{ID: arg.ID, Content: arg.Content, Status: arg.Status, PublishAt: arg.PublishAt}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "PUBLISH AT IN THE PAST",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": "later", "publish_at": %q}`, time.Now().Add(-time.Hour).Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "DRAFT AND SCHEDULED",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": "later", "draft": true, "publish_at": %q}`, publishAt.Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListDrafts(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	draft := RandomPost(t, identity.ID)
+	draft.Status = postStatusDraft
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/posts/drafts?page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListDraftPosts(gomock.Any(), gomock.Eq(db.ListDraftPostsParams{UserID: user.ID, PageOffset: pageSize})).
+					Times(1).Return([]db.Post{draft}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID PAGE",
+			method: http.MethodGet,
+			url:    "/api/v1/posts/drafts?page=-1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListDraftPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUpdateDraft(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	other := RandomUserIdentity(t, uuid.New())
+	draft := RandomPost(t, identity.ID)
+	draft.Status = postStatusDraft
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	url := fmt.Sprintf("/api/v1/posts/drafts/%s", draft.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "SCHEDULE",
+			method:  http.MethodPut,
+			url:     url,
+			payload: fmt.Sprintf(`{"content": "ready #now", "publish_at": %q}`, publishAt.Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().UpdateDraftPostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateDraftPostTxParams) (db.Post, error) {
+						require.Equal(t, draft.ID, arg.ID)
+						require.Equal(t, postStatusScheduled, arg.Status)
+						require.True(t, publishAt.Equal(*arg.PublishAt))
+						require.Equal(t, []string{"now"}, arg.Tags)
+						return db.Post{ID: arg.ID, Content: arg.Content, Status: arg.Status, PublishAt: arg.PublishAt}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "BACK TO DRAFT",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content": "not yet"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().UpdateDraftPostTx(gomock.Any(), gomock.Eq(db.UpdateDraftPostTxParams{
+					UpdateDraftPostParams: db.UpdateDraftPostParams{
+						ID:      draft.ID,
+						Content: "not yet",
+						Status:  postStatusDraft,
+					},
+					Tags: []string{},
+				})).Times(1).Return(draft, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "PUBLISH AT IN THE PAST",
+			method:  http.MethodPut,
+			url:     url,
+			payload: fmt.Sprintf(`{"content": "late", "publish_at": %q}`, time.Now().Add(-time.Minute).Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateDraftPostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "SOMEONE ELSE",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content": "mine now"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(RandomPost(t, other.ID), nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().UpdateDraftPostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:    "ALREADY PUBLISHED",
+			method:  http.MethodPut,
+			url:     url,
+			payload: `{"content": "too late"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().UpdateDraftPostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestPublishDraft(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	draft := RandomPost(t, identity.ID)
+	draft.Status = postStatusDraft
+	published := draft
+	published.Status = postStatusPublished
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/posts/drafts/%s/publish", draft.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().PublishDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft.ID, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(published, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.Contains(t, rec.Body.String(), `"status":"published"`)
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/posts/drafts/%s/publish", draft.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().PublishDraftPost(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "DELETE",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/posts/drafts/%s", draft.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().DeletePost(gomock.Any(), gomock.Eq(draft.ID)).Times(1).Return(draft.ID, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID ID",
+			method: http.MethodPost,
+			url:    "/api/v1/posts/drafts/abc/publish",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDraftPost(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestPublishScheduledPosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	full := make([]uuid.UUID, scheduledPostBatchSize)
+	for i := range full {
+		full[i] = uuid.New()
+	}
+
+	store := mock.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().PublishDuePosts(gomock.Any(), gomock.Eq(int32(scheduledPostBatchSize))).Times(1).Return(full, nil),
+		store.EXPECT().PublishDuePosts(gomock.Any(), gomock.Any()).Times(1).Return([]uuid.UUID{uuid.New()}, nil),
+	)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, server.publishScheduledPosts(context.Background()))
+}
+
+func TestPublishScheduledPostsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().PublishDuePosts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.Error(t, server.publishScheduledPosts(context.Background()))
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"cnfs/realtime"
+	"cnfs/token"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// a message was sent to the user, data is the message
+	eventTypeMessage = "message"
+	// someone commented on a post or replied to a comment of the user, data is the comment
+	eventTypeComment = "comment"
+	// a notification of the user was created or folded into, data is the notification
+	eventTypeNotification = "notification"
+	// sent on idle streams so proxies keep them open
+	eventTypeHeartbeat = "heartbeat"
+	// the access token of the stream expired, the client reconnects with a fresh one
+	eventTypeExpired = "expired"
+)
+
+// swagger:model
+type streamEvent struct {
+	Type string `json:"type"`
+	// the message, comment or notification the event is about
+	Data interface{} `json:"data,omitempty"`
+}
+
+// push sends an event to the open streams of the user. Like notify, failing to do so is logged rather than
+// failing the request, the client still finds the data through the API.
+func (s *Server) push(ctx context.Context, userId uuid.UUID, kind string, data interface{}) {
+	if err := s.hub.Publish(ctx, userId, kind, data); err != nil {
+		log.Printf("cannot push %s to %s: %v", kind, userId, err)
+	}
+}
+
+// runHub delivers the events published by every instance to the streams of this one until ctx is cancelled.
+func (s *Server) runHub(ctx context.Context) {
+	if err := s.hub.Run(ctx); err != nil {
+		log.Printf("realtime hub stopped: %s", err.Error())
+	}
+}
+
+// eventData is the data of the event as JSON, null when it was left out.
+func eventData(event realtime.Event) []byte {
+	if event.Data == nil {
+		return []byte("null")
+	}
+	return event.Data
+}
+
+// stream events to the user as server-sent events
+func (s *Server) streamEvents(c echo.Context) error {
+	// swagger:operation GET /events events streamEvents
+	// ---
+	// summary: Stream events
+	// description: Push new messages, comments and notifications of the user as server-sent events, named after
+	//   the type of the event with its data as JSON. A comment line is sent when the stream is idle.
+	//   The stream ends with an expired event when the access token expires, and without one when the server
+	//   shuts down, a reconnect picks it up on another instance.
+	//   EventSource cannot set headers, so the access token can be passed in the access_token query parameter.
+	// produces:
+	// - text/event-stream
+	// parameters:
+	// - name: access_token
+	//   in: query
+	//   description: the access token, when it is not in the authorization header
+	//   required: false
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/streamEvent"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	sub := s.hub.Subscribe(tokenPayload.UserId)
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// keeps nginx from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(s.cfg.RealtimeHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	expired := time.NewTimer(time.Until(tokenPayload.ExpiredAt))
+	defer expired.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-s.closing:
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				return nil
+			}
+			_, err = fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, eventData(event))
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(res, ": heartbeat\n\n")
+		case <-expired.C:
+			fmt.Fprintf(res, "event: %s\ndata: null\n\n", eventTypeExpired)
+			res.Flush()
+			return nil
+		}
+
+		if err != nil {
+			return nil
+		}
+		res.Flush()
+	}
+}
+
+// stream events to the user over a WebSocket
+func (s *Server) streamEventsWebSocket(c echo.Context) error {
+	// swagger:operation GET /events/ws events streamEventsWebSocket
+	// ---
+	// summary: Stream events over a WebSocket
+	// description: Push new messages, comments and notifications of the user as JSON text messages holding the
+	//   type and data of the event. A heartbeat event is sent when the connection is idle.
+	//   The connection closes after an expired event when the access token expires, and without one when the
+	//   server shuts down, a reconnect picks it up on another instance.
+	//   Browsers cannot set headers on WebSockets, so the access token can be passed in the access_token query parameter.
+	// parameters:
+	// - name: access_token
+	//   in: query
+	//   description: the access token, when it is not in the authorization header
+	//   required: false
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '101':
+	//     description: Switching Protocols
+	//     schema:
+	//       "$ref": "#/definitions/streamEvent"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	server := websocket.Server{
+		// the stream is authorized by the access token rather than cookies, so any origin can open it
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+			s.serveWebSocket(ws, tokenPayload)
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// serveWebSocket writes the events of the user to the connection until either side closes it.
+func (s *Server) serveWebSocket(ws *websocket.Conn, tokenPayload *token.Payload) {
+	sub := s.hub.Subscribe(tokenPayload.UserId)
+	defer sub.Close()
+
+	// the client has nothing to say, reading only tells when it is gone
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	heartbeat := time.NewTicker(s.cfg.RealtimeHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	expired := time.NewTimer(time.Until(tokenPayload.ExpiredAt))
+	defer expired.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-closed:
+			return
+		case <-s.closing:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			err = websocket.JSON.Send(ws, streamEvent{Type: event.Type, Data: event.Data})
+		case <-heartbeat.C:
+			err = websocket.JSON.Send(ws, streamEvent{Type: eventTypeHeartbeat})
+		case <-expired.C:
+			websocket.JSON.Send(ws, streamEvent{Type: eventTypeExpired})
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
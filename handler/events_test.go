@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bufio"
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// newStreamServer serves the router over a real connection, event streams do not end on their own.
+func newStreamServer(t *testing.T, store *mock.MockStore, heartbeat time.Duration) (*Server, *httptest.Server) {
+	c := *cfg
+	c.RealtimeHeartbeatInterval = heartbeat
+
+	server, err := NewServer(store, &c)
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.router)
+	t.Cleanup(httpServer.Close)
+
+	return server, httpServer
+}
+
+func accessToken(t *testing.T, server *Server, user db.User) string {
+	token, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, cfg.AccessTokenDuration)
+	require.NoError(t, err)
+	return token
+}
+
+// waitForSubscription waits until the stream of the user is listening, events sent before then are not replayed.
+func waitForSubscription(t *testing.T, server *Server, userId uuid.UUID) {
+	require.Eventually(t, func() bool {
+		return server.hub.Connections(userId) > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamEvents(t *testing.T) {
+	_, user := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	server, httpServer := newStreamServer(t, mock.NewMockStore(ctrl), time.Hour)
+
+	req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/api/v1/events?access_token="+accessToken(t, server, user), nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get(echo.HeaderContentType))
+
+	waitForSubscription(t, server, user.ID)
+	server.push(context.Background(), user.ID, eventTypeMessage, db.Message{Content: "hello"})
+
+	reader := bufio.NewReader(res.Body)
+	readEvent := func() (string, string) {
+		var kind, data string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && kind == eventTypeMessage:
+				return kind, data
+			case strings.HasPrefix(line, "event: "):
+				kind = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	kind, data := readEvent()
+	require.Equal(t, eventTypeMessage, kind)
+
+	var message db.Message
+	require.NoError(t, json.Unmarshal([]byte(data), &message))
+	require.Equal(t, "hello", message.Content)
+}
+
+func TestStreamEventsHeartbeat(t *testing.T) {
+	_, user := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	server, httpServer := newStreamServer(t, mock.NewMockStore(ctrl), 10*time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/api/v1/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+accessToken(t, server, user))
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": heartbeat\n", line)
+}
+
+func TestStreamEventsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	_, httpServer := newStreamServer(t, mock.NewMockStore(ctrl), time.Hour)
+
+	for _, url := range []string{"/api/v1/events", "/api/v1/events?access_token=nope", "/api/v1/events/ws"} {
+		res, err := http.Get(httpServer.URL + url)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode, url)
+	}
+}
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	_, user := RandomUser(t)
+	_, other := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	server, httpServer := newStreamServer(t, mock.NewMockStore(ctrl), time.Hour)
+
+	url := strings.Replace(httpServer.URL, "http", "ws", 1) + "/api/v1/events/ws?access_token=" + accessToken(t, server, user)
+	ws, err := websocket.Dial(url, "", httpServer.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	waitForSubscription(t, server, user.ID)
+	server.push(context.Background(), other.ID, eventTypeMessage, db.Message{Content: "not yours"})
+	server.push(context.Background(), user.ID, eventTypeNotification, db.CreateNotificationRow{Type: notificationTypeReaction, Count: 12})
+
+	var event struct {
+		Type string                   `json:"type"`
+		Data db.CreateNotificationRow `json:"data"`
+	}
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, websocket.JSON.Receive(ws, &event))
+	require.Equal(t, eventTypeNotification, event.Type)
+	require.Equal(t, int64(12), event.Data.Count)
+}
+
+func TestPushMessage(t *testing.T) {
+	_, receiver := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+	store.EXPECT().HasBlocks(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(false, nil)
+	store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateMessageTxParams) (db.CreateMessageTxResult, error) {
+			return db.CreateMessageTxResult{Message: db.Message{ID: arg.ID, ReceiverID: arg.ReceiverID, Content: arg.Content}}, nil
+		})
+	store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateNotificationParams) (db.CreateNotificationRow, error) {
+			return db.CreateNotificationRow{ID: arg.ID, UserID: arg.UserID, Type: arg.Type, SubjectID: arg.SubjectID, Count: 3}, nil
+		})
+	store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	sub := server.hub.Subscribe(receiver.ID)
+	defer sub.Close()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/messages", strings.NewReader(fmt.Sprintf(`{"receiver_id": %q, "content": "psst"}`, receiver.ID)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	server.router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	message := <-sub.Events()
+	require.Equal(t, eventTypeMessage, message.Type)
+	require.Contains(t, string(message.Data), "psst")
+
+	notification := <-sub.Events()
+	require.Equal(t, eventTypeNotification, notification.Type)
+	require.Contains(t, string(notification.Data), `"count":3`)
+}
+
+func TestRedactedURI(t *testing.T) {
+	require.Equal(t, "/api/v1/events?access_token=REDACTED", redactedURI("/api/v1/events?access_token=v2.local.secret"))
+	require.Equal(t, "/api/v1/posts?page=2", redactedURI("/api/v1/posts?page=2"))
+}
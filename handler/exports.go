@@ -0,0 +1,395 @@
+package handler
+
+import (
+	"bytes"
+	db "cnfs/db/sqlc"
+	"cnfs/export"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	// the number of exports built per run of the export job
+	exportBatchSize = 5
+	// an export still running after this long is taken to have lost its worker and is built again
+	exportStaleAfter = time.Hour
+)
+
+// the status of an export whose archive can be downloaded
+const exportStatusDone = "done"
+
+type (
+	// swagger:model
+	createExportRequest struct {
+		// zip holds one JSON file per kind of data, json is a single document. Defaults to zip.
+		Format string `json:"format" validate:"omitempty,oneof=zip json"`
+	}
+
+	// swagger:model
+	exportResponse struct {
+		ID     uuid.UUID `json:"id"`
+		Format string    `json:"format"`
+		// pending, running, done or failed
+		Status string `json:"status"`
+		// why the export failed
+		Error string `json:"error,omitempty"`
+		// the size of the archive in bytes, once it is done
+		Size        int64      `json:"size,omitempty"`
+		CreatedAt   time.Time  `json:"created_at"`
+		CompletedAt *time.Time `json:"completed_at,omitempty"`
+		// the archive is removed after this time
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		// a signed url of the archive, set once it is done
+		DownloadUrl string `json:"download_url,omitempty"`
+	}
+)
+
+// newExportResponse adds a download url to exports that are done.
+func (s *Server) newExportResponse(e db.DataExport) (exportResponse, error) {
+	resp := exportResponse{
+		ID:          e.ID,
+		Format:      e.Format,
+		Status:      e.Status,
+		Error:       e.Error,
+		Size:        e.Size,
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+
+	if e.Status == exportStatusDone {
+		var err error
+		if resp.DownloadUrl, err = s.storage.SignedURL(e.StorageKey, s.cfg.SignedUrlDuration); err != nil {
+			return exportResponse{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+// request an export of all the data of the user
+func (s *Server) createExport(c echo.Context) error {
+	// swagger:operation POST /users/{id}/exports users createExport
+	// ---
+	// summary: Export the data of the user
+	// description: Queue an archive of the profile, sessions, messages, identities, posts and comments of the user.
+	//   It is built in the background, poll the export until it is done to get its download url.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the format of the archive
+	//   required: false
+	//   schema:
+	//     "$ref": "#/definitions/createExportRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '202':
+	//     description: Accepted
+	//     schema:
+	//       "$ref": "#/definitions/exportResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '409':
+	//     description: An export is already in progress
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok || tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	var data createExportRequest
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	if data.Format == "" {
+		data.Format = export.FormatZip
+	}
+
+	e, err := s.store.CreateDataExport(c.Request().Context(), db.CreateDataExportParams{
+		ID:     uuid.New(),
+		UserID: userId,
+		Format: data.Format,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			return c.JSON(http.StatusConflict, newError("an export is already in progress"))
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.newExportResponse(e)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusAccepted, newResponse(resp))
+}
+
+// list the exports of the user
+func (s *Server) listExports(c echo.Context) error {
+	// swagger:operation GET /users/{id}/exports users listExports
+	// ---
+	// summary: List the exports of the user
+	// description: List the recent exports of the user, newest first
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/exportResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok || tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	exports, err := s.store.ListDataExports(c.Request().Context(), userId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp := make([]exportResponse, 0, len(exports))
+	for _, e := range exports {
+		r, err := s.newExportResponse(e)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		resp = append(resp, r)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// get one export of the user
+func (s *Server) getExport(c echo.Context) error {
+	// swagger:operation GET /users/{id}/exports/{exportId} users getExport
+	// ---
+	// summary: Get an export of the user
+	// description: Get the status of an export, and its download url once it is done
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: exportId
+	//   in: path
+	//   description: export id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/exportResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok || tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	exportId, err := uuid.Parse(c.Param("exportId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	e, err := s.store.GetDataExport(c.Request().Context(), db.GetDataExportParams{
+		ID:     exportId,
+		UserID: userId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.newExportResponse(e)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// buildDataExports builds the queued exports and removes the expired ones.
+func (s *Server) buildDataExports(ctx context.Context) error {
+	for i := 0; i < exportBatchSize; i++ {
+		e, err := s.store.ClaimDataExport(ctx, exportStaleAfter.Seconds())
+		if err != nil {
+			if err == sql.ErrNoRows {
+				break
+			}
+			return err
+		}
+
+		if err := s.buildDataExport(ctx, e); err != nil {
+			log.Printf("cannot build export %s: %v", e.ID, err)
+			// the user gets a generic error, the details only go to the log
+			if err := s.store.FailDataExport(ctx, db.FailDataExportParams{ID: e.ID, Error: "the export could not be built"}); err != nil {
+				return err
+			}
+		}
+	}
+
+	expired, err := s.store.DeleteExpiredDataExports(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range expired {
+		if e.StorageKey != "" {
+			s.removeBlobs(ctx, e.StorageKey)
+		}
+	}
+
+	return nil
+}
+
+// buildDataExport collects the data of the user, writes the archive to storage and marks the export done.
+func (s *Server) buildDataExport(ctx context.Context, e db.DataExport) error {
+	archive, err := s.collectArchive(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf, e.Format); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%sexports/%s/%s.%s", privatePrefix, e.UserID, e.ID, e.Format)
+	size := int64(buf.Len())
+	if err := s.storage.Put(ctx, key, &buf, size, export.ContentType(e.Format)); err != nil {
+		return err
+	}
+
+	_, err = s.store.CompleteDataExport(ctx, db.CompleteDataExportParams{
+		ID:         e.ID,
+		StorageKey: key,
+		Size:       size,
+		TtlSeconds: s.cfg.DataExportTTL.Seconds(),
+	})
+	if err != nil {
+		s.removeBlobs(ctx, key)
+		return err
+	}
+
+	return nil
+}
+
+// collectArchive reads everything stored about the user.
+func (s *Server) collectArchive(ctx context.Context, userId uuid.UUID) (export.Archive, error) {
+	archive := export.Archive{ExportedAt: time.Now()}
+
+	var err error
+	if archive.Profile, err = s.store.GetUserById(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+	if archive.Sessions, err = s.store.ListUserSessions(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+	if archive.Messages, err = s.store.ExportUserMessages(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+	if archive.Identities, err = s.store.ListUserIdentities(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+	if archive.Posts, err = s.store.ExportUserPosts(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+	if archive.Comments, err = s.store.ExportUserComments(ctx, userId); err != nil {
+		return export.Archive{}, err
+	}
+
+	return archive, nil
+}
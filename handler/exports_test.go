@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExport(t *testing.T) {
+	_, user := RandomUser(t)
+	_, other := RandomUser(t)
+	url := fmt.Sprintf("/api/v1/users/%s/exports", user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     url,
+			payload: `{"format": "json"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateDataExport(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateDataExportParams) (db.DataExport, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, "json", arg.Format)
+						return db.DataExport{ID: arg.ID, UserID: arg.UserID, Format: arg.Format, Status: "pending"}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, rec.Code)
+
+				var resp struct {
+					Data exportResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, "pending", resp.Data.Status)
+				require.Empty(t, resp.Data.DownloadUrl)
+			},
+		},
+		{
+			name:   "DEFAULTS TO ZIP",
+			method: http.MethodPost,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateDataExport(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateDataExportParams) (db.DataExport, error) {
+						require.Equal(t, "zip", arg.Format)
+						return db.DataExport{ID: arg.ID, Format: arg.Format, Status: "pending"}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID FORMAT",
+			method:  http.MethodPost,
+			url:     url,
+			payload: `{"format": "tar"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateDataExport(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "IN PROGRESS",
+			method: http.MethodPost,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateDataExport(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.DataExport{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:   "SOMEONE ELSE",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/users/%s/exports", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateDataExport(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	})
+}
+
+func TestGetExport(t *testing.T) {
+	_, user := RandomUser(t)
+	completedAt := time.Now()
+	done := db.DataExport{
+		ID:          uuid.New(),
+		UserID:      user.ID,
+		Format:      "zip",
+		Status:      "done",
+		StorageKey:  fmt.Sprintf("private/exports/%s/export.zip", user.ID),
+		Size:        1024,
+		CompletedAt: &completedAt,
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "DONE",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/exports/%s", user.ID, done.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDataExport(gomock.Any(), gomock.Eq(db.GetDataExportParams{ID: done.ID, UserID: user.ID})).
+					Times(1).Return(done, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data exportResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Contains(t, resp.Data.DownloadUrl, done.StorageKey)
+				require.Contains(t, resp.Data.DownloadUrl, "signature=")
+				require.NotContains(t, rec.Body.String(), "storage_key")
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/exports/%s", user.ID, uuid.New()),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDataExport(gomock.Any(), gomock.Any()).Times(1).Return(db.DataExport{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "LIST",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/exports", user.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListDataExports(gomock.Any(), gomock.Eq(user.ID)).Times(1).
+					Return([]db.DataExport{done, {ID: uuid.New(), Status: "failed", Error: "the export could not be built"}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data []exportResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data, 2)
+				require.NotEmpty(t, resp.Data[0].DownloadUrl)
+				require.Empty(t, resp.Data[1].DownloadUrl)
+			},
+		},
+	})
+}
+
+func TestBuildDataExports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	queued := db.DataExport{ID: uuid.New(), UserID: user.ID, Format: "zip", Status: "running"}
+
+	var storedKey string
+
+	store := mock.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ClaimDataExport(gomock.Any(), gomock.Eq(exportStaleAfter.Seconds())).Times(1).Return(queued, nil),
+		store.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).Times(1).Return(db.DataExport{}, sql.ErrNoRows),
+	)
+	store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+	store.EXPECT().ListUserSessions(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.ListUserSessionsRow{}, nil)
+	store.EXPECT().ExportUserMessages(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.Message{}, nil)
+	store.EXPECT().ListUserIdentities(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.UserIdentity{identity}, nil)
+	store.EXPECT().ExportUserPosts(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.Post{post}, nil)
+	store.EXPECT().ExportUserComments(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.Comment{}, nil)
+	store.EXPECT().CompleteDataExport(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CompleteDataExportParams) (db.DataExport, error) {
+			require.Equal(t, queued.ID, arg.ID)
+			require.True(t, strings.HasPrefix(arg.StorageKey, privatePrefix))
+			require.Equal(t, cfg.DataExportTTL.Seconds(), arg.TtlSeconds)
+			storedKey = arg.StorageKey
+			return db.DataExport{}, nil
+		})
+	store.EXPECT().FailDataExport(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().DeleteExpiredDataExports(gomock.Any()).Times(1).Return([]db.DataExport{}, nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+	blobs := newTestStorage(t)
+	server.storage = blobs
+
+	require.NoError(t, server.buildDataExports(context.Background()))
+
+	blob, err := blobs.Get(context.Background(), storedKey)
+	require.NoError(t, err)
+	defer blob.Close()
+	data, err := io.ReadAll(blob)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 6)
+}
+
+func TestBuildDataExportsFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	queued := db.DataExport{ID: uuid.New(), UserID: uuid.New(), Format: "json", Status: "running"}
+
+	store := mock.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).Times(1).Return(queued, nil),
+		store.EXPECT().ClaimDataExport(gomock.Any(), gomock.Any()).Times(1).Return(db.DataExport{}, sql.ErrNoRows),
+	)
+	store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(queued.UserID)).Times(1).Return(db.User{}, sql.ErrNoRows)
+	store.EXPECT().CompleteDataExport(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().FailDataExport(gomock.Any(), gomock.Eq(db.FailDataExportParams{ID: queued.ID, Error: "the export could not be built"})).
+		Times(1).Return(nil)
+	store.EXPECT().DeleteExpiredDataExports(gomock.Any()).Times(1).
+		Return([]db.DataExport{{ID: uuid.New(), StorageKey: "private/exports/old.zip"}}, nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+	server.storage = newTestStorage(t)
+
+	require.NoError(t, server.buildDataExports(context.Background()))
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	feedSourceFollowing = "following"
+	feedSourceTrending  = "trending"
+)
+
+// swagger:model
+type feedResponse struct {
+	// either following, or trending when nothing followed has posted yet
+	Source string         `json:"source"`
+	Posts  []postResponse `json:"posts"`
+}
+
+// the personalised home feed
+func (s *Server) getFeed(c echo.Context) error {
+	// swagger:operation GET /feed posts getFeed
+	// ---
+	// summary: Home feed
+	// description: Posts from followed identities, and from followed users under their public identities,
+	//   newest first. Falls back to trending posts while there is nothing from followed accounts.
+	// parameters:
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int32
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/feedResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	page := c.QueryParam("page")
+	if page == "" {
+		page = "0"
+	}
+
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt < 0 {
+		return c.JSON(http.StatusBadRequest, newError("invalid page"))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	posts, err := s.store.ListFeedPosts(ctx, db.ListFeedPostsParams{
+		UserID:     tokenPayload.UserId,
+		PageOffset: int32(pageInt * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if len(posts) > 0 {
+		return s.feedResponse(c, feedSourceFollowing, posts)
+	}
+
+	// an empty page past the end of a non-empty feed is just the end of it
+	if pageInt > 0 {
+		hasPosts, err := s.store.HasFeedPosts(ctx, tokenPayload.UserId)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		if hasPosts {
+			return s.feedResponse(c, feedSourceFollowing, posts)
+		}
+	}
+
+	trending, err := s.store.ListTrendingPosts(ctx, db.ListTrendingPostsParams{
+		ViewerID:   tokenPayload.UserId,
+		PageOffset: int32(pageInt * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return s.feedResponse(c, feedSourceTrending, trending)
+}
+
+// feedResponse writes a page of the feed with the polls and content preferences applied.
+func (s *Server) feedResponse(c echo.Context, source string, posts []db.Post) error {
+	resp, err := s.postResponses(c.Request().Context(), viewerId(c), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(feedResponse{Source: source, Posts: resp}))
+}
@@ -0,0 +1,414 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// swagger:model
+type followingResponse struct {
+	// the users followed, posts under their public identities show up in the feed
+	Users []db.ListFollowedUsersRow `json:"users"`
+	// the identities followed, only listed for the user themselves
+	Identities []db.ListFollowedIdentitiesRow `json:"identities,omitempty"`
+}
+
+// follow a user
+func (s *Server) followUser(c echo.Context) error {
+	// swagger:operation POST /users/{id}/follow follows followUser
+	// ---
+	// summary: Follow a user
+	// description: Follow a user, the posts they make under their public identities show up in the feed.
+	//   Following a user twice has no effect.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	if tokenPayload.UserId == userId {
+		return c.JSON(http.StatusBadRequest, newError("you can't follow yourself"))
+	}
+
+	if _, err := s.store.GetUserById(c.Request().Context(), userId); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	err = s.store.FollowUser(c.Request().Context(), db.FollowUserParams{
+		ID:             uuid.New(),
+		FollowerID:     tokenPayload.UserId,
+		FolloweeUserID: userId,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(userId))
+}
+
+// unfollow a user
+func (s *Server) unfollowUser(c echo.Context) error {
+	// swagger:operation DELETE /users/{id}/follow follows unfollowUser
+	// ---
+	// summary: Unfollow a user
+	// description: Stop following a user
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not following the user
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	_, err = s.store.UnfollowUser(c.Request().Context(), db.UnfollowUserParams{
+		FollowerID:     tokenPayload.UserId,
+		FolloweeUserID: userId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(userId))
+}
+
+// follow an identity
+func (s *Server) followIdentity(c echo.Context) error {
+	// swagger:operation POST /identities/{id}/follow follows followIdentity
+	// ---
+	// summary: Follow an identity
+	// description: Follow an anonymous identity, its posts show up in the feed.
+	//   Nobody but the follower can see which identities they follow.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.store.GetUserIdentityById(c.Request().Context(), identityId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if identity.UserID.Valid && identity.UserID.UUID == tokenPayload.UserId {
+		return c.JSON(http.StatusBadRequest, newError("you can't follow your own identity"))
+	}
+
+	if identity.Retired {
+		return c.JSON(http.StatusBadRequest, newError(errRetiredIdentity.Error()))
+	}
+
+	err = s.store.FollowIdentity(c.Request().Context(), db.FollowIdentityParams{
+		ID:                 uuid.New(),
+		FollowerID:         tokenPayload.UserId,
+		FolloweeIdentityID: identityId,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identityId))
+}
+
+// unfollow an identity
+func (s *Server) unfollowIdentity(c echo.Context) error {
+	// swagger:operation DELETE /identities/{id}/follow follows unfollowIdentity
+	// ---
+	// summary: Unfollow an identity
+	// description: Stop following an anonymous identity
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not following the identity
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	_, err = s.store.UnfollowIdentity(c.Request().Context(), db.UnfollowIdentityParams{
+		FollowerID:         tokenPayload.UserId,
+		FolloweeIdentityID: identityId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identityId))
+}
+
+// list the followers of a user
+func (s *Server) listFollowers(c echo.Context) error {
+	// swagger:operation GET /users/{id}/followers follows listFollowers
+	// ---
+	// summary: List followers
+	// description: List the users following a user
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int64
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	followers, err := s.store.ListFollowers(c.Request().Context(), db.ListFollowersParams{
+		UserID:     userId,
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(followers))
+}
+
+// list the users and identities a user follows
+func (s *Server) listFollowing(c echo.Context) error {
+	// swagger:operation GET /users/{id}/following follows listFollowing
+	// ---
+	// summary: List followed users and identities
+	// description: List the users a user follows.
+	//   The followed identities are only included when users look at their own list.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int64
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/followingResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	var resp followingResponse
+
+	resp.Users, err = s.store.ListFollowedUsers(c.Request().Context(), db.ListFollowedUsersParams{
+		UserID:     userId,
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	// following an identity says something about the follower, keep it to themselves
+	if tokenPayload.UserId == userId {
+		resp.Identities, err = s.store.ListFollowedIdentities(c.Request().Context(), db.ListFollowedIdentitiesParams{
+			UserID:     userId,
+			PageOffset: int32(page * pageSize),
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
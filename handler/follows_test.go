@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowUser(t *testing.T) {
+	_, user := RandomUser(t)
+	_, other := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/users/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().FollowUser(gomock.Any(), gomock.Any()).Times(1).
+					Do(func(_ interface{}, arg db.FollowUserParams) {
+						require.Equal(t, user.ID, arg.FollowerID)
+						require.Equal(t, other.ID, arg.FolloweeUserID)
+					}).Return(nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "SELF",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/users/%s/follow", user.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().FollowUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "USER NOT FOUND",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/users/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().FollowUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "UNFOLLOW",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/users/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnfollowUser(gomock.Any(), gomock.Eq(db.UnfollowUserParams{
+					FollowerID:     user.ID,
+					FolloweeUserID: other.ID,
+				})).Times(1).Return(uuid.New(), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "UNFOLLOW NOT FOLLOWING",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/users/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnfollowUser(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestFollowIdentity(t *testing.T) {
+	_, user := RandomUser(t)
+	own := RandomUserIdentity(t, user.ID)
+	other := RandomUserIdentity(t, uuid.New())
+	retired := RandomUserIdentity(t, uuid.New())
+	retired.Retired = true
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/identities/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().FollowIdentity(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "OWN IDENTITY",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/identities/%s/follow", own.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(own.ID)).Times(1).Return(own, nil)
+				store.EXPECT().FollowIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "RETIRED IDENTITY",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/identities/%s/follow", retired.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(retired.ID)).Times(1).Return(retired, nil)
+				store.EXPECT().FollowIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "UNFOLLOW",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/identities/%s/follow", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnfollowIdentity(gomock.Any(), gomock.Eq(db.UnfollowIdentityParams{
+					FollowerID:         user.ID,
+					FolloweeIdentityID: other.ID,
+				})).Times(1).Return(uuid.New(), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListFollowing(t *testing.T) {
+	_, user := RandomUser(t)
+	_, other := RandomUser(t)
+	identities := []db.ListFollowedIdentitiesRow{{IdentityID: uuid.New()}}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OWN LIST",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/following", user.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFollowedUsers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListFollowedUsersRow{}, nil)
+				store.EXPECT().ListFollowedIdentities(gomock.Any(), gomock.Eq(db.ListFollowedIdentitiesParams{UserID: user.ID})).Times(1).Return(identities, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data followingResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, identities[0].IdentityID, resp.Data.Identities[0].IdentityID)
+			},
+		},
+		{
+			name:   "SOMEONE ELSE HIDES IDENTITIES",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/following?page=1", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFollowedUsers(gomock.Any(), gomock.Eq(db.ListFollowedUsersParams{UserID: other.ID, PageOffset: pageSize})).Times(1).Return([]db.ListFollowedUsersRow{}, nil)
+				store.EXPECT().ListFollowedIdentities(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), "identities")
+			},
+		},
+		{
+			name:   "FOLLOWERS",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/followers", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFollowers(gomock.Any(), gomock.Eq(db.ListFollowersParams{UserID: other.ID})).Times(1).
+					Return([]db.ListFollowersRow{{ID: user.ID, Username: user.Username}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID PAGE",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/users/%s/followers?page=-1", other.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFollowers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestGetFeed(t *testing.T) {
+	_, user := RandomUser(t)
+	posts := []db.Post{RandomPost(t, uuid.New()), RandomPost(t, uuid.New())}
+
+	checkSource := func(t *testing.T, rec *httptest.ResponseRecorder, source string) {
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Data feedResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, source, resp.Data.Source)
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "FOLLOWING",
+			method: http.MethodGet,
+			url:    "/api/v1/feed",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFeedPosts(gomock.Any(), gomock.Eq(db.ListFeedPostsParams{UserID: user.ID})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().ListTrendingPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				checkSource(t, rec, feedSourceFollowing)
+			},
+		},
+		{
+			name:   "FALLS BACK TO TRENDING",
+			method: http.MethodGet,
+			url:    "/api/v1/feed",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFeedPosts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Post{}, nil)
+				store.EXPECT().ListTrendingPosts(gomock.Any(), gomock.Eq(db.ListTrendingPostsParams{ViewerID: user.ID, PageOffset: 0})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				checkSource(t, rec, feedSourceTrending)
+			},
+		},
+		{
+			name:   "TRENDING NEXT PAGE",
+			method: http.MethodGet,
+			url:    "/api/v1/feed?page=2",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFeedPosts(gomock.Any(), gomock.Eq(db.ListFeedPostsParams{UserID: user.ID, PageOffset: 2 * pageSize})).Times(1).Return([]db.Post{}, nil)
+				store.EXPECT().HasFeedPosts(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(false, nil)
+				store.EXPECT().ListTrendingPosts(gomock.Any(), gomock.Eq(db.ListTrendingPostsParams{ViewerID: user.ID, PageOffset: 2 * pageSize})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				checkSource(t, rec, feedSourceTrending)
+			},
+		},
+		{
+			name:   "END OF FEED",
+			method: http.MethodGet,
+			url:    "/api/v1/feed?page=3",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFeedPosts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Post{}, nil)
+				store.EXPECT().HasFeedPosts(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(true, nil)
+				store.EXPECT().ListTrendingPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				checkSource(t, rec, feedSourceFollowing)
+			},
+		},
+		{
+			name:   "INTERNAL ERROR",
+			method: http.MethodGet,
+			url:    "/api/v1/feed",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListFeedPosts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	})
+}
@@ -23,12 +23,24 @@ package handler
 
 import (
 	"cnfs/common"
+	"cnfs/db/memory"
 	db "cnfs/db/sqlc"
+	"cnfs/mail"
+	"cnfs/realtime"
+	"cnfs/storage"
 	"cnfs/token"
 	"cnfs/web"
+	"cnfs/webhook"
+	"cnfs/webpush"
+	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"cnfs/config"
 
@@ -39,10 +51,24 @@ import (
 
 type (
 	Server struct {
-		cfg        *config.Config
-		router     *echo.Echo
-		store      db.Store
+		cfg      *config.Config
+		router   *echo.Echo
+		store    db.Store
+		storage  storage.Storage
+		hub      *realtime.Hub
+		webhooks *http.Client
+		// nil when no VAPID keys are configured
+		webPush    *webpush.Sender
+		mailer     mail.Mailer
 		tokenMaker token.Maker
+		// the connection pool of the store, nil when the data is kept in memory
+		db *sql.DB
+		// closed when the server shuts down, ends the event streams
+		closing chan struct{}
+		// the background jobs and the realtime hub
+		jobs sync.WaitGroup
+		// work that outlives the request that started it
+		background sync.WaitGroup
 	}
 
 	CustomValidator struct {
@@ -57,28 +83,124 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return nil
 }
 
+// newValidator adds the validations of the request fields of the handlers.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("content_warning", isContentWarning)
+	v.RegisterValidation("content_category", isContentCategory)
+	v.RegisterValidation("notification_type", isNotificationType)
+	v.RegisterValidation("webhook_event", isWebhookEvent)
+	v.RegisterValidation("webhook_url", isWebhookUrl)
+	return v
+}
+
+// a DATABASE_URL of this scheme keeps the data in memory, until the server stops
+const memoryDatabaseScheme = "memory://"
+
+// the LIMIT of the paginated list queries, page n starts n * pageSize rows in. The original users, posts and
+// messages lists keep stepping their pages by 10
+const pageSize = 20
+
+// Launch runs the API server until it gets SIGINT or SIGTERM, then shuts it down within SHUTDOWN_TIMEOUT.
 func Launch(cfg *config.Config) {
-	conn := common.SetupDb(cfg.DatabaseUrl)
-	store := db.NewStore(conn, cfg)
-	server, err := NewServer(store, cfg)
+	store, conn, err := openStore(cfg)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	server, err := newServer(store, conn, cfg)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	log.Fatal(server.router.Start(cfg.Host + ":" + cfg.Port))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := server.serve(ctx, cfg.Host+":"+cfg.Port); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// OpenStore opens the database of DATABASE_URL, postgres or memory for running without a database server.
+func OpenStore(cfg *config.Config) (db.Store, error) {
+	store, _, err := openStore(cfg)
+	return store, err
+}
+
+// openStore opens the store of DATABASE_URL along with its connection pool, nil when the data is kept in memory.
+func openStore(cfg *config.Config) (db.Store, *sql.DB, error) {
+	if !IsMemoryDatabase(cfg) {
+		conn, err := common.SetupDb(cfg.DatabaseUrl, cfg.MigrateOnStartup)
+		if err != nil {
+			return nil, nil, err
+		}
+		configurePool(conn, cfg)
+		return db.NewStore(conn, cfg), conn, nil
+	}
+
+	log.Println("-- Keeping the data in memory, it is lost when the server stops --")
+	if cfg.RealtimeBroker == "postgres" {
+		log.Println("no postgres to broker live events through, using the local broker")
+		cfg.RealtimeBroker = "local"
+	}
+	return memory.NewStore(), nil, nil
+}
+
+// configurePool applies the DB_* pool limits to the connections of the database.
+func configurePool(conn *sql.DB, cfg *config.Config) {
+	conn.SetMaxOpenConns(cfg.DbMaxOpenConns)
+	conn.SetMaxIdleConns(cfg.DbMaxIdleConns)
+	conn.SetConnMaxLifetime(cfg.DbConnMaxLifetime)
+	conn.SetConnMaxIdleTime(cfg.DbConnMaxIdleTime)
+}
+
+// IsMemoryDatabase reports whether DATABASE_URL keeps the data in memory.
+func IsMemoryDatabase(cfg *config.Config) bool {
+	return strings.HasPrefix(cfg.DatabaseUrl, memoryDatabaseScheme)
 }
 
 func NewServer(store db.Store, cfg *config.Config) (*Server, error) {
+	return newServer(store, nil, cfg)
+}
+
+// newServer builds the server on store, conn is its connection pool, which the realtime hub shares.
+func newServer(store db.Store, conn *sql.DB, cfg *config.Config) (*Server, error) {
 
 	tokenMaker, err := token.NewPasetoMaker(cfg.PasetoSymmetricKey)
 	if err != nil {
 		log.Fatalf("cannot make token maker: %s", err.Error())
 	}
 
+	blobs, err := storage.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hub, err := realtime.New(cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	webPush, err := newWebPushSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer, err := mail.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
 		cfg:        cfg,
 		store:      store,
+		storage:    blobs,
+		hub:        hub,
+		webhooks:   webhook.NewClient(cfg.WebhookTimeout, cfg.WebhookAllowPrivateAddresses),
+		webPush:    webPush,
+		mailer:     mailer,
 		tokenMaker: tokenMaker,
+		db:         conn,
+		closing:    make(chan struct{}),
 	}
 
 	server.setupRouter()
@@ -87,7 +209,7 @@ func NewServer(store db.Store, cfg *config.Config) (*Server, error) {
 
 func (s *Server) setupRouter() {
 	e := echo.New()
-	e.Validator = &CustomValidator{validator: validator.New()}
+	e.Validator = &CustomValidator{validator: newValidator()}
 
 	logger := zerolog.New(os.Stdout)
 
@@ -115,28 +237,119 @@ func (s *Server) setupRouter() {
 	users.GET("/:id", s.getUserById, s.authMiddleware)
 	users.GET("/:id/messages", s.listMessages, s.authMiddleware)
 	users.PATCH("/:id", s.updateUser, s.authMiddleware)
+	users.PATCH("/:id/profile", s.updateProfile, s.authMiddleware)
+	users.POST("/:id/avatar", s.uploadAvatar, s.authMiddleware, s.uploadLimitMiddleware())
 	users.DELETE("/:id", s.deleteUser, s.authMiddleware)
-	users.GET("/one/:username", s.getUserByUsername)
+	users.GET("/:id/exports", s.listExports, s.authMiddleware)
+	users.POST("/:id/exports", s.createExport, s.authMiddleware)
+	users.GET("/:id/exports/:exportId", s.getExport, s.authMiddleware)
+	users.GET("/:id/content-preferences", s.getContentPreferences, s.authMiddleware)
+	users.PUT("/:id/content-preferences", s.updateContentPreferences, s.authMiddleware)
+	users.GET("/one/:username", s.getUserByUsername, s.optionalAuthMiddleware)
+	users.POST("/:id/follow", s.followUser, s.authMiddleware)
+	users.DELETE("/:id/follow", s.unfollowUser, s.authMiddleware)
+	users.GET("/:id/followers", s.listFollowers, s.authMiddleware)
+	users.GET("/:id/following", s.listFollowing, s.authMiddleware)
 
 	messages := e.Group("/api/v1/messages")
 	messages.GET("/:id", s.getMessageById, s.authMiddleware)
-	messages.POST("", s.createMessage)
+	messages.POST("", s.createMessage, s.optionalAuthMiddleware, s.uploadLimitMiddleware())
 	messages.PUT("/:id", s.updateMessage, s.authMiddleware)
 	messages.DELETE("/:id", s.deleteMessage, s.authMiddleware)
+	messages.POST("/:id/restore", s.restoreMessage, s.authMiddleware)
 
 	posts := e.Group("/api/v1/posts")
-	posts.GET("", s.listAllPosts)
-	posts.GET("/:id", s.getPostById)
+	posts.GET("", s.listAllPosts, s.optionalAuthMiddleware)
+	posts.GET("/:id", s.getPostById, s.optionalAuthMiddleware)
 	posts.POST("", s.createNewPost, s.authMiddleware)
+	posts.GET("/drafts", s.listDrafts, s.authMiddleware)
+	posts.PUT("/drafts/:id", s.updateDraft, s.authMiddleware)
+	posts.DELETE("/drafts/:id", s.deleteDraft, s.authMiddleware)
+	posts.POST("/drafts/:id/publish", s.publishDraft, s.authMiddleware)
 	posts.PATCH("/:id", s.updatePost, s.authMiddleware)
 	posts.DELETE("/:id", s.deletePost, s.authMiddleware)
-	posts.GET("/:id/comments", s.listAllComments)
+	posts.POST("/:id/restore", s.restorePost, s.authMiddleware)
+	posts.GET("/:id/comments", s.listAllComments, s.optionalAuthMiddleware)
+	posts.GET("/:id/images", s.listPostImages)
+	posts.POST("/:id/images", s.uploadPostImage, s.authMiddleware, s.uploadLimitMiddleware())
+	posts.DELETE("/:id/images/:imageId", s.deletePostImage, s.authMiddleware)
+	posts.GET("/:id/reactions", s.getPostReactions, s.optionalAuthMiddleware)
+	posts.POST("/:id/poll/votes", s.votePoll, s.authMiddleware)
+	posts.PUT("/:id/content-flags", s.updateContentFlags, s.authMiddleware)
+	posts.PUT("/:id/reaction", s.reactToPost, s.authMiddleware)
+	posts.DELETE("/:id/reaction", s.deletePostReaction, s.authMiddleware)
+	posts.GET("/:id/revisions", s.listPostRevisions, s.authMiddleware)
+	posts.GET("/:id/revisions/diff", s.diffPostRevisions, s.authMiddleware)
+
+	identities := e.Group("/api/v1/identities", s.authMiddleware)
+	identities.GET("", s.listIdentities)
+	identities.POST("", s.createIdentity)
+	identities.PATCH("/:id", s.updateIdentity)
+	identities.PUT("/:id/default", s.switchIdentity)
+	identities.DELETE("/:id", s.retireIdentity)
+	identities.POST("/:id/rotate", s.rotateIdentityHash)
+	identities.PUT("/:id/rotation", s.updateIdentityRotation)
+	identities.POST("/:id/follow", s.followIdentity)
+	identities.DELETE("/:id/follow", s.unfollowIdentity)
+
+	notifications := e.Group("/api/v1/notifications", s.authMiddleware)
+	notifications.GET("", s.listNotifications)
+	notifications.POST("/read", s.markAllNotificationsRead)
+	notifications.POST("/:id/read", s.markNotificationRead)
+	notifications.GET("/preferences", s.getNotificationPreferences)
+	notifications.PUT("/preferences", s.updateNotificationPreferences)
+
+	e.GET("/api/v1/push/key", s.getPushKey)
+	push := e.Group("/api/v1/push", s.authMiddleware)
+	push.POST("/subscriptions", s.subscribePush)
+	push.DELETE("/subscriptions", s.unsubscribePush)
+
+	e.GET("/api/v1/digest/unsubscribe", s.confirmUnsubscribeEmailDigest)
+	e.POST("/api/v1/digest/unsubscribe", s.unsubscribeEmailDigest)
+	digests := e.Group("/api/v1/digest", s.authMiddleware)
+	digests.GET("", s.getEmailDigest)
+	digests.PUT("", s.setEmailDigest)
+	digests.DELETE("", s.deleteEmailDigest)
+
+	webhooks := e.Group("/api/v1/webhooks", s.authMiddleware)
+	webhooks.GET("", s.listWebhooks)
+	webhooks.POST("", s.createWebhook)
+	webhooks.PATCH("/:id", s.updateWebhook)
+	webhooks.DELETE("/:id", s.deleteWebhook)
+	webhooks.GET("/:id/deliveries", s.listWebhookDeliveries)
+	webhooks.POST("/:id/deliveries/:deliveryId/redeliver", s.redeliverWebhook)
+
+	events := e.Group("/api/v1/events", s.streamAuthMiddleware)
+	events.GET("", s.streamEvents)
+	events.GET("/ws", s.streamEventsWebSocket)
+
+	e.GET("/api/v1/feed", s.getFeed, s.authMiddleware)
+	e.GET("/api/v1/search", s.search, s.optionalAuthMiddleware)
+	e.GET("/api/v1/trash", s.listTrash, s.authMiddleware)
+
+	tags := e.Group("/api/v1/tags")
+	tags.GET("", s.searchTags)
+	tags.GET("/trending", s.listTrendingTags)
+	tags.GET("/:name/posts", s.listPostsByTag, s.optionalAuthMiddleware)
+
+	blocks := e.Group("/api/v1/blocks", s.authMiddleware)
+	blocks.GET("", s.listBlocks)
+	blocks.POST("", s.createBlock)
+	blocks.DELETE("/:id", s.deleteBlock)
+	blocks.GET("/keywords", s.listMutedKeywords)
+	blocks.POST("/keywords", s.muteKeyword)
+	blocks.DELETE("/keywords/:id", s.unmuteKeyword)
 
 	comments := e.Group("/api/v1/comments")
 	comments.GET("/:id", s.getCommentById)
 	comments.POST("", s.createComment, s.authMiddleware)
 	comments.PUT("/:id", s.updateComment, s.authMiddleware)
 	comments.DELETE("/:id", s.deleteComment, s.authMiddleware)
+	comments.POST("/:id/restore", s.restoreComment, s.authMiddleware)
+	comments.GET("/:id/revisions", s.listCommentRevisions, s.authMiddleware)
+	comments.GET("/:id/revisions/diff", s.diffCommentRevisions, s.authMiddleware)
+
+	e.GET("/api/v1/media/*", s.serveMedia)
 
 	s.router = e
 }
@@ -5,6 +5,7 @@ import (
 	"cnfs/config"
 	"cnfs/db/mock"
 	db "cnfs/db/sqlc"
+	"database/sql"
 	"log"
 	"net/http/httptest"
 	"os"
@@ -51,6 +52,18 @@ func RandomUser(t *testing.T) (string, db.User) {
 	return password, user
 }
 
+func RandomUserIdentity(t *testing.T, userId uuid.UUID) db.UserIdentity {
+	return db.UserIdentity{
+		ID:           uuid.New(),
+		UserID:       uuid.NullUUID{UUID: userId, Valid: true},
+		IdentityHash: uuid.New(),
+		Name:         common.RandomString(8),
+		IsDefault:    false,
+		Retired:      false,
+		CreatedAt:    time.Now(),
+	}
+}
+
 func RandomMessage(t *testing.T, userId uuid.UUID) db.Message {
 	return db.Message{
 		ID:         uuid.New(),
@@ -67,6 +80,7 @@ func RandomPost(t *testing.T, userIdentityId uuid.UUID) db.Post {
 		ID:             uuid.New(),
 		UserIdentityID: userIdentityId,
 		Content:        common.RandomString(48),
+		Status:         "published",
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
@@ -83,3 +97,33 @@ func RandomComment(t *testing.T, postId, parentId uuid.UUID) db.Comment {
 		UpdatedAt:      time.Now(),
 	}
 }
+
+func TestOpenMemoryStore(t *testing.T) {
+	c := *cfg
+	c.DatabaseUrl = "memory://"
+	c.RealtimeBroker = "postgres"
+
+	require.True(t, IsMemoryDatabase(&c))
+	store, err := OpenStore(&c)
+	require.NoError(t, err)
+	// there is no postgres to listen on
+	require.Equal(t, "local", c.RealtimeBroker)
+
+	_, err = NewServer(store, &c)
+	require.NoError(t, err)
+}
+
+func TestConfigurePool(t *testing.T) {
+	c := *cfg
+	c.DbMaxOpenConns = 7
+	c.DbMaxIdleConns = 3
+
+	// opening does not connect, the pool is there to configure either way
+	conn, err := sql.Open("postgres", c.DatabaseUrl)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	configurePool(conn, &c)
+	require.Equal(t, 7, conn.Stats().MaxOpenConnections)
+	require.Contains(t, poolStats(conn.Stats()), "database pool: 0 open, 0 in use, 0 idle")
+}
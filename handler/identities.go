@@ -0,0 +1,564 @@
+package handler
+
+import (
+	"cnfs/common"
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// the maximum number of non-retired identities a user can have at once
+const maxActiveIdentities = 10
+
+var errNotIdentityOwner = errors.New("identity does not belong to the user")
+var errRetiredIdentity = errors.New("identity has been retired")
+
+type (
+	// swagger:model
+	createIdentityRequest struct {
+		// the display name of the identity, only visible to the owner
+		// required: true
+		Name string `json:"name" validate:"required,max=32"`
+	}
+
+	// swagger:model
+	updateIdentityRequest struct {
+		// the new display name of the identity
+		Name *string `json:"name" validate:"omitempty,gt=0,max=32"`
+		// whether posts under this identity are listed on the public profile
+		IsPublic *bool `json:"is_public"`
+	}
+
+	// swagger:model
+	rotateIdentityRequest struct {
+		// when true the old identity and its content are detached from the user,
+		// otherwise the old identity is retired but its content stays editable
+		Detach bool `json:"detach"`
+	}
+
+	// swagger:model
+	identityRotationRequest struct {
+		// rotate the identity automatically every n days, 0 disables automatic rotation
+		IntervalDays int32 `json:"interval_days" validate:"gte=0,lte=365"`
+		// whether automatic rotations detach the old content
+		Detach bool `json:"detach"`
+	}
+)
+
+// ownedIdentity returns the identity if it belongs to the user.
+func (s *Server) ownedIdentity(ctx context.Context, userId, identityId uuid.UUID) (db.UserIdentity, error) {
+	identity, err := s.store.GetUserIdentityById(ctx, identityId)
+	if err != nil {
+		return db.UserIdentity{}, err
+	}
+
+	if !identity.UserID.Valid || identity.UserID.UUID != userId {
+		return db.UserIdentity{}, errNotIdentityOwner
+	}
+
+	return identity, nil
+}
+
+// postingIdentity resolves the identity a user wants to post under.
+// When no identity is given the user's default identity is used.
+func (s *Server) postingIdentity(ctx context.Context, userId, identityId uuid.UUID) (db.UserIdentity, error) {
+	if identityId == uuid.Nil {
+		return s.store.GetDefaultUserIdentity(ctx, userId)
+	}
+
+	identity, err := s.ownedIdentity(ctx, userId, identityId)
+	if err != nil {
+		return db.UserIdentity{}, err
+	}
+
+	if identity.Retired {
+		return db.UserIdentity{}, errRetiredIdentity
+	}
+
+	return identity, nil
+}
+
+// identityErrorResponse maps the errors of ownedIdentity and postingIdentity to a response.
+func identityErrorResponse(c echo.Context, err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return c.JSON(http.StatusNotFound, NOT_FOUND)
+	case errors.Is(err, errNotIdentityOwner):
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	case errors.Is(err, errRetiredIdentity):
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	default:
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+}
+
+// list all identities of the current user
+func (s *Server) listIdentities(c echo.Context) error {
+	// swagger:operation GET /identities identities listIdentities
+	// ---
+	// summary: List the identities of the current user
+	// description: List the identities of the current user, including retired ones
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identities, err := s.store.ListUserIdentities(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identities))
+}
+
+// create a new identity for the current user
+func (s *Server) createIdentity(c echo.Context) error {
+	// swagger:operation POST /identities identities createIdentity
+	// ---
+	// summary: Create a new identity
+	// description: Create a new anonymous identity that the current user can post under
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: identity
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/createIdentityRequest"
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(createIdentityRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	count, err := s.store.CountActiveUserIdentities(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if count >= maxActiveIdentities {
+		return c.JSON(http.StatusBadRequest, newError("you have reached the maximum number of identities, retire one first"))
+	}
+
+	identity, err := s.store.CreateUserIdentity(c.Request().Context(), db.CreateUserIdentityParams{
+		ID:           uuid.New(),
+		UserID:       tokenPayload.UserId,
+		IdentityHash: uuid.New(),
+		Name:         req.Name,
+		IsDefault:    false,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identity))
+}
+
+// update the name or visibility of an identity
+func (s *Server) updateIdentity(c echo.Context) error {
+	// swagger:operation PATCH /identities/{id} identities updateIdentity
+	// ---
+	// summary: Update an identity
+	// description: Rename an identity of the current user or change whether it is listed on the public profile
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: identity
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/updateIdentityRequest"
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(updateIdentityRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.store.UpdateUserIdentity(c.Request().Context(), db.UpdateUserIdentityParams{
+		Name:     common.NullString(req.Name),
+		IsPublic: common.NullBool(req.IsPublic),
+		ID:       identityId,
+		UserID:   tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identity))
+}
+
+// switch the default identity of the current user
+func (s *Server) switchIdentity(c echo.Context) error {
+	// swagger:operation PUT /identities/{id}/default identities switchIdentity
+	// ---
+	// summary: Switch the default identity
+	// description: Make the identity the one used when a post or comment doesn't name one
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.postingIdentity(c.Request().Context(), tokenPayload.UserId, identityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	err = s.store.SetDefaultUserIdentityTx(c.Request().Context(), db.SetDefaultUserIdentityParams{
+		ID:     identity.ID,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	identity.IsDefault = true
+	return c.JSON(http.StatusOK, newResponse(identity))
+}
+
+// retire an identity, it can no longer be posted under
+func (s *Server) retireIdentity(c echo.Context) error {
+	// swagger:operation DELETE /identities/{id} identities retireIdentity
+	// ---
+	// summary: Retire an identity
+	// description: Retire an identity, existing content stays but nothing new can be posted under it. The default identity cannot be retired.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.ownedIdentity(c.Request().Context(), tokenPayload.UserId, identityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	if identity.IsDefault {
+		return c.JSON(http.StatusBadRequest, newError("cannot retire the default identity, switch to another identity first"))
+	}
+
+	retired, err := s.store.RetireUserIdentity(c.Request().Context(), db.RetireUserIdentityParams{
+		ID:     identity.ID,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(retired))
+}
+
+// rotate an identity
+func (s *Server) rotateIdentityHash(c echo.Context) error {
+	// swagger:operation POST /identities/{id}/rotate identities rotateIdentityHash
+	// ---
+	// summary: Rotate an identity
+	// description: Replace the identity with a fresh one so new content can't be linked to the old content.
+	//   The old content either stays on the retired identity or is detached from the user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: rotation options
+	//   required: false
+	//   schema:
+	//     "$ref": "#/definitions/rotateIdentityRequest"
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(rotateIdentityRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.postingIdentity(c.Request().Context(), tokenPayload.UserId, identityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	fresh, err := s.store.RotateUserIdentityTx(c.Request().Context(), db.RotateUserIdentityTxParams{
+		Identity: identity,
+		Detach:   req.Detach,
+	})
+	if err != nil {
+		// rotated by another request in the meantime
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(fresh))
+}
+
+// configure the automatic rotation of an identity
+func (s *Server) updateIdentityRotation(c echo.Context) error {
+	// swagger:operation PUT /identities/{id}/rotation identities updateIdentityRotation
+	// ---
+	// summary: Configure automatic rotation
+	// description: Rotate the identity automatically every n days
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: identity id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: rotation schedule
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/identityRotationRequest"
+	// security:
+	// - key: []
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	identityId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(identityRotationRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	identity, err := s.store.UpdateUserIdentityRotation(c.Request().Context(), db.UpdateUserIdentityRotationParams{
+		RotationIntervalDays: sql.NullInt32{Int32: req.IntervalDays, Valid: req.IntervalDays > 0},
+		RotationDetach:       req.Detach,
+		ID:                   identityId,
+		UserID:               tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(identity))
+}
@@ -0,0 +1,413 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+type identityTestCase struct {
+	name          string
+	method        string
+	url           string
+	payload       string
+	buildStubs    func(store *mock.MockStore)
+	checkResponse func(rec *httptest.ResponseRecorder)
+}
+
+func runIdentityTestCases(t *testing.T, user db.User, testCases []identityTestCase) {
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.payload))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			token, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, cfg.AccessTokenDuration)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
+
+func TestListIdentities(t *testing.T) {
+	_, user := RandomUser(t)
+	identities := []db.UserIdentity{RandomUserIdentity(t, user.ID), RandomUserIdentity(t, user.ID)}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: "GET",
+			url:    "/api/v1/identities",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListUserIdentities(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identities, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:   "500 internal server error",
+			method: "GET",
+			url:    "/api/v1/identities",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListUserIdentities(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 500, rec.Code)
+			},
+		},
+	})
+}
+
+func TestCreateIdentity(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  "POST",
+			url:     "/api/v1/identities",
+			payload: fmt.Sprintf(`{"name": %q}`, identity.Name),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountActiveUserIdentities(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(identity, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "400 missing name",
+			method:  "POST",
+			url:     "/api/v1/identities",
+			payload: `{}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:    "400 too many identities",
+			method:  "POST",
+			url:     "/api/v1/identities",
+			payload: fmt.Sprintf(`{"name": %q}`, identity.Name),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountActiveUserIdentities(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(maxActiveIdentities), nil)
+				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUpdateIdentity(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  "PATCH",
+			url:     "/api/v1/identities/" + identity.ID.String(),
+			payload: `{"name": "night owl"}`,
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.UpdateUserIdentityParams{
+					Name:   sql.NullString{String: "night owl", Valid: true},
+					ID:     identity.ID,
+					UserID: user.ID,
+				}
+				store.EXPECT().UpdateUserIdentity(gomock.Any(), gomock.Eq(arg)).Times(1).Return(identity, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "OK - make public",
+			method:  "PATCH",
+			url:     "/api/v1/identities/" + identity.ID.String(),
+			payload: `{"is_public": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.UpdateUserIdentityParams{
+					IsPublic: sql.NullBool{Bool: true, Valid: true},
+					ID:       identity.ID,
+					UserID:   user.ID,
+				}
+				store.EXPECT().UpdateUserIdentity(gomock.Any(), gomock.Eq(arg)).Times(1).Return(identity, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "400 empty name",
+			method:  "PATCH",
+			url:     "/api/v1/identities/" + identity.ID.String(),
+			payload: `{"name": ""}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:    "404 not one of the user's identities",
+			method:  "PATCH",
+			url:     "/api/v1/identities/" + identity.ID.String(),
+			payload: `{"name": "night owl"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserIdentity(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 404, rec.Code)
+			},
+		},
+	})
+}
+
+func TestSwitchIdentity(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	retired := RandomUserIdentity(t, user.ID)
+	retired.Retired = true
+	other := RandomUserIdentity(t, uuid.New())
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: "PUT",
+			url:    "/api/v1/identities/" + identity.ID.String() + "/default",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().SetDefaultUserIdentityTx(gomock.Any(), gomock.Eq(db.SetDefaultUserIdentityParams{
+					ID:     identity.ID,
+					UserID: user.ID,
+				})).Times(1).Return(nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:   "400 retired identity",
+			method: "PUT",
+			url:    "/api/v1/identities/" + retired.ID.String() + "/default",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(retired.ID)).Times(1).Return(retired, nil)
+				store.EXPECT().SetDefaultUserIdentityTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:   "401 identity of another user",
+			method: "PUT",
+			url:    "/api/v1/identities/" + other.ID.String() + "/default",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().SetDefaultUserIdentityTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
+	})
+}
+
+func TestRetireIdentity(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	defaultIdentity := RandomUserIdentity(t, user.ID)
+	defaultIdentity.IsDefault = true
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: "DELETE",
+			url:    "/api/v1/identities/" + identity.ID.String(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().RetireUserIdentity(gomock.Any(), gomock.Eq(db.RetireUserIdentityParams{
+					ID:     identity.ID,
+					UserID: user.ID,
+				})).Times(1).Return(identity.ID, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:   "400 default identity",
+			method: "DELETE",
+			url:    "/api/v1/identities/" + defaultIdentity.ID.String(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(defaultIdentity.ID)).Times(1).Return(defaultIdentity, nil)
+				store.EXPECT().RetireUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:   "404 identity not found",
+			method: "DELETE",
+			url:    "/api/v1/identities/" + identity.ID.String(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().RetireUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 404, rec.Code)
+			},
+		},
+	})
+}
+
+func TestRotateIdentityHash(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	other := RandomUserIdentity(t, uuid.New())
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK - keep content",
+			method:  "POST",
+			url:     "/api/v1/identities/" + identity.ID.String() + "/rotate",
+			payload: `{"detach": false}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().RotateUserIdentityTx(gomock.Any(), gomock.Eq(db.RotateUserIdentityTxParams{
+					Identity: identity,
+					Detach:   false,
+				})).Times(1).Return(RandomUserIdentity(t, user.ID), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "OK - detach content",
+			method:  "POST",
+			url:     "/api/v1/identities/" + identity.ID.String() + "/rotate",
+			payload: `{"detach": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().RotateUserIdentityTx(gomock.Any(), gomock.Eq(db.RotateUserIdentityTxParams{
+					Identity: identity,
+					Detach:   true,
+				})).Times(1).Return(RandomUserIdentity(t, user.ID), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "401 identity of another user",
+			method:  "POST",
+			url:     "/api/v1/identities/" + other.ID.String() + "/rotate",
+			payload: `{}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(other.ID)).Times(1).Return(other, nil)
+				store.EXPECT().RotateUserIdentityTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
+		{
+			name:    "404 rotated in the meantime",
+			method:  "POST",
+			url:     "/api/v1/identities/" + identity.ID.String() + "/rotate",
+			payload: `{}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().RotateUserIdentityTx(gomock.Any(), gomock.Any()).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 404, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUpdateIdentityRotation(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  "PUT",
+			url:     "/api/v1/identities/" + identity.ID.String() + "/rotation",
+			payload: `{"interval_days": 30, "detach": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserIdentityRotation(gomock.Any(), gomock.Eq(db.UpdateUserIdentityRotationParams{
+					RotationIntervalDays: sql.NullInt32{Int32: 30, Valid: true},
+					RotationDetach:       true,
+					ID:                   identity.ID,
+					UserID:               user.ID,
+				})).Times(1).Return(identity, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "400 interval out of range",
+			method:  "PUT",
+			url:     "/api/v1/identities/" + identity.ID.String() + "/rotation",
+			payload: `{"interval_days": 1000}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserIdentityRotation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+	})
+}
+
+func TestRotateDueIdentities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().RotateDueUserIdentitiesTx(gomock.Any(), gomock.Eq(int32(rotationBatchSize))).Times(1).Return(1, nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, server.rotateDueIdentities(context.Background()))
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// the number of identities rotated per run of the rotation job
+	rotationBatchSize = 100
+	// how fast hot posts cool down with age, the higher the faster
+	hotGravity = 1.8
+	// the posts younger than this are rescored on every refresh, older ones once they get new reactions or
+	// comments, by then their hot score hardly changes with age
+	hotWindow = 7 * 24 * time.Hour
+)
+
+// startJobs runs the background jobs of the server and its realtime hub until ctx is cancelled, s.jobs
+// tells when they have all returned.
+func (s *Server) startJobs(ctx context.Context) {
+	s.startJob(func() { s.runHub(ctx) })
+	s.startEvery(ctx, "identity rotation", s.cfg.IdentityRotationInterval, s.rotateDueIdentities)
+	s.startEvery(ctx, "post score refresh", s.cfg.PostScoreRefreshInterval, s.refreshPostScores)
+	s.startEvery(ctx, "trash purge", s.cfg.TrashPurgeInterval, s.purgeTrash)
+	s.startEvery(ctx, "data export", s.cfg.DataExportInterval, s.buildDataExports)
+	s.startEvery(ctx, "scheduled post publishing", s.cfg.ScheduledPostInterval, s.publishScheduledPosts)
+	s.startEvery(ctx, "webhook delivery", s.cfg.WebhookDeliveryInterval, s.deliverWebhooks)
+	s.startEvery(ctx, "email digest", s.cfg.DigestInterval, s.sendEmailDigests)
+	if s.db != nil {
+		s.startEvery(ctx, "database pool stats", s.cfg.DbStatsInterval, s.logPoolStats)
+	}
+}
+
+// startJob runs job in its own goroutine, counted in s.jobs.
+func (s *Server) startJob(job func()) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		job()
+	}()
+}
+
+// startEvery starts a job calling job every interval, see runEvery. An interval of 0 or less disables the job.
+func (s *Server) startEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	if interval <= 0 {
+		log.Printf("job %s disabled", name)
+		return
+	}
+	s.startJob(func() { s.runEvery(ctx, name, interval, job) })
+}
+
+// runEvery calls job every interval until ctx is cancelled, errors are logged. A run in progress when ctx is
+// cancelled is given SHUTDOWN_TIMEOUT to finish, so it doesn't stop halfway through its writes.
+func (s *Server) runEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := s.outlive(ctx)
+			err := job(runCtx)
+			cancel()
+			if err != nil {
+				log.Printf("job %s failed: %s", name, err.Error())
+			}
+		}
+	}
+}
+
+// outlive returns a context cancelled SHUTDOWN_TIMEOUT after ctx is, or when the returned cancel is called.
+func (s *Server) outlive(ctx context.Context) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+
+		timer := time.NewTimer(s.cfg.ShutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return runCtx, func() {
+		close(done)
+		cancel()
+	}
+}
+
+// logPoolStats logs the use of the database connection pool.
+func (s *Server) logPoolStats(ctx context.Context) error {
+	log.Println(poolStats(s.db.Stats()))
+	return nil
+}
+
+// poolStats describes the state of a connection pool, and how often it made queries wait.
+func poolStats(stats sql.DBStats) string {
+	return fmt.Sprintf("database pool: %d open, %d in use, %d idle, %d waits for %s, %d closed idle, %d closed past their lifetime",
+		stats.OpenConnections, stats.InUse, stats.Idle, stats.WaitCount, stats.WaitDuration,
+		stats.MaxIdleClosed+stats.MaxIdleTimeClosed, stats.MaxLifetimeClosed)
+}
+
+// rotateDueIdentities rotates the identities whose rotation schedule has elapsed.
+func (s *Server) rotateDueIdentities(ctx context.Context) error {
+	_, err := s.store.RotateDueUserIdentitiesTx(ctx, rotationBatchSize)
+	return err
+}
+
+// refreshPostScores recomputes the scores the hot, top and controversial sorts order posts by.
+func (s *Server) refreshPostScores(ctx context.Context) error {
+	_, err := s.store.RefreshPostScores(ctx, db.RefreshPostScoresParams{
+		Gravity:       hotGravity,
+		WindowSeconds: hotWindow.Seconds(),
+	})
+	return err
+}
+
+// purgeTrash removes the posts, comments and messages deleted longer than the retention period ago, and the
+// accounts whose deletion grace period is over, for good along with the stored files of their media.
+func (s *Server) purgeTrash(ctx context.Context) error {
+	retention := s.cfg.TrashRetention.Seconds()
+
+	if _, err := s.store.PurgeComments(ctx, retention); err != nil {
+		return err
+	}
+
+	for _, purge := range []func(context.Context, float64) ([]db.Media, error){
+		s.store.PurgePosts,
+		s.store.PurgeMessages,
+	} {
+		media, err := purge(ctx, retention)
+		if err != nil {
+			return err
+		}
+		s.removeMedia(ctx, media...)
+	}
+
+	media, err := s.store.PurgeUsers(ctx)
+	if err != nil {
+		return err
+	}
+	s.removeMedia(ctx, media...)
+
+	return nil
+}
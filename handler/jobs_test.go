@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEveryLetsRunFinish(t *testing.T) {
+	c := *cfg
+	c.ShutdownTimeout = time.Second
+	server := &Server{cfg: &c}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	var once sync.Once
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	server.startEvery(ctx, "test", time.Millisecond, func(ctx context.Context) error {
+		once.Do(func() {
+			close(started)
+			// the jobs are stopped while the run is in progress
+			time.Sleep(50 * time.Millisecond)
+			finished <- ctx.Err()
+		})
+		return nil
+	})
+
+	<-started
+	stop()
+	server.jobs.Wait()
+	require.NoError(t, <-finished)
+}
+
+func TestRunEveryCancelsRunAfterTimeout(t *testing.T) {
+	c := *cfg
+	c.ShutdownTimeout = 10 * time.Millisecond
+	server := &Server{cfg: &c}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	var once sync.Once
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+	server.startEvery(ctx, "test", time.Millisecond, func(ctx context.Context) error {
+		once.Do(func() {
+			close(started)
+			<-ctx.Done()
+			finished <- ctx.Err()
+		})
+		return nil
+	})
+
+	<-started
+	stop()
+	server.jobs.Wait()
+	require.ErrorIs(t, <-finished, context.Canceled)
+}
+
+func TestStartEveryDisabled(t *testing.T) {
+	server := &Server{cfg: cfg}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		server.startEvery(context.Background(), "test", interval, func(ctx context.Context) error {
+			t.Fatal("a disabled job ran")
+			return nil
+		})
+	}
+	server.jobs.Wait()
+}
@@ -0,0 +1,596 @@
+package handler
+
+import (
+	"bytes"
+	db "cnfs/db/sqlc"
+	"cnfs/media"
+	"cnfs/storage"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// the maximum number of images attached to a single post
+const maxPostImages = 4
+
+// objects under this prefix are only reachable through signed urls
+const privatePrefix = "private/"
+
+// swagger:model
+type mediaResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Kind         string    `json:"kind"`
+	Url          string    `json:"url"`
+	ThumbnailUrl string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	// set for private media, the urls stop working after this time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// newMediaResponse builds the public urls of the media, or signed ones if it is private.
+func (s *Server) newMediaResponse(m db.Media) (mediaResponse, error) {
+	resp := mediaResponse{
+		ID:          m.ID,
+		Kind:        m.Kind,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		CreatedAt:   m.CreatedAt,
+	}
+
+	if !m.IsPrivate {
+		resp.Url = s.storage.URL(m.StorageKey)
+		resp.ThumbnailUrl = s.storage.URL(m.ThumbnailKey)
+		return resp, nil
+	}
+
+	var err error
+	if resp.Url, err = s.storage.SignedURL(m.StorageKey, s.cfg.SignedUrlDuration); err != nil {
+		return mediaResponse{}, err
+	}
+	if resp.ThumbnailUrl, err = s.storage.SignedURL(m.ThumbnailKey, s.cfg.SignedUrlDuration); err != nil {
+		return mediaResponse{}, err
+	}
+
+	expiresAt := time.Now().Add(s.cfg.SignedUrlDuration)
+	resp.ExpiresAt = &expiresAt
+
+	return resp, nil
+}
+
+func (s *Server) newMediaResponses(items []db.Media) ([]mediaResponse, error) {
+	resp := make([]mediaResponse, 0, len(items))
+	for _, m := range items {
+		r, err := s.newMediaResponse(m)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, r)
+	}
+	return resp, nil
+}
+
+// saveImage validates the uploaded image and writes it and its thumbnail to storage under prefix.
+// The returned params still need the kind and owner before the row is created.
+func (s *Server) saveImage(ctx context.Context, file *multipart.FileHeader, prefix string) (db.CreateMediaParams, error) {
+	if file.Size > s.cfg.MaxUploadSize {
+		return db.CreateMediaParams{}, media.ErrTooLarge
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return db.CreateMediaParams{}, err
+	}
+	defer f.Close()
+
+	img, err := media.Process(f, s.cfg.MaxUploadSize)
+	if err != nil {
+		return db.CreateMediaParams{}, err
+	}
+
+	id := uuid.New()
+	arg := db.CreateMediaParams{
+		ID:           id,
+		StorageKey:   prefix + "/" + id.String() + img.Ext,
+		ThumbnailKey: prefix + "/" + id.String() + "_thumb" + img.Ext,
+		ContentType:  img.ContentType,
+		Size:         int64(len(img.Data)),
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+		IsPrivate:    strings.HasPrefix(prefix, privatePrefix),
+	}
+
+	if err := s.storage.Put(ctx, arg.StorageKey, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType); err != nil {
+		return db.CreateMediaParams{}, err
+	}
+	if err := s.storage.Put(ctx, arg.ThumbnailKey, bytes.NewReader(img.Thumbnail), int64(len(img.Thumbnail)), img.ContentType); err != nil {
+		s.removeBlobs(ctx, arg.StorageKey)
+		return db.CreateMediaParams{}, err
+	}
+
+	return arg, nil
+}
+
+// createMedia records a saved image, the stored files are removed again if that fails.
+func (s *Server) createMedia(ctx context.Context, arg db.CreateMediaParams) (db.Media, error) {
+	m, err := s.store.CreateMedia(ctx, arg)
+	if err != nil {
+		s.removeBlobs(ctx, arg.StorageKey, arg.ThumbnailKey)
+		return db.Media{}, err
+	}
+	return m, nil
+}
+
+// removeMedia deletes the stored files of media rows that are already gone from the database.
+func (s *Server) removeMedia(ctx context.Context, items ...db.Media) {
+	for _, m := range items {
+		s.removeBlobs(ctx, m.StorageKey, m.ThumbnailKey)
+	}
+}
+
+// removeBlobs deletes stored files, failures are only logged since nothing references them anymore.
+func (s *Server) removeBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("cannot delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// uploadErrorResponse maps the errors of saveImage to a response.
+func uploadErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, newError(err.Error()))
+	case errors.Is(err, media.ErrUnsupportedType):
+		return c.JSON(http.StatusUnsupportedMediaType, newError(err.Error()))
+	case errors.Is(err, media.ErrInvalidImage):
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	default:
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+}
+
+// upload a new avatar
+func (s *Server) uploadAvatar(c echo.Context) error {
+	// swagger:operation POST /users/{id}/avatar users uploadAvatar
+	// ---
+	// summary: Upload an avatar
+	// description: Replace the avatar of the user with an uploaded jpeg or png image.
+	//   Metadata is stripped from the image and the previous uploaded avatar is removed.
+	// consumes:
+	// - multipart/form-data
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	// - name: file
+	//   in: formData
+	//   description: the image
+	//   required: true
+	//   type: file
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '413':
+	//     description: The file is too large
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '415':
+	//     description: The file is not a jpeg or png image
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, INVALID_TOKEN)
+	}
+
+	if tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, MISSING_FIELD)
+	}
+
+	ctx := c.Request().Context()
+
+	arg, err := s.saveImage(ctx, file, "avatars/"+userId.String())
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+	arg.Kind = "avatar"
+	arg.UserID = uuid.NullUUID{UUID: userId, Valid: true}
+
+	avatar, err := s.createMedia(ctx, arg)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	avatarUrl := s.storage.URL(avatar.StorageKey)
+	user, err := s.store.UpdateUserProfile(ctx, db.UpdateUserProfileParams{
+		AvatarUrl: sql.NullString{String: avatarUrl, Valid: true},
+		UpdatedAt: time.Now(),
+		ID:        userId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	old, err := s.store.DeleteOldAvatars(ctx, db.DeleteOldAvatarsParams{
+		UserID: userId,
+		KeepID: avatar.ID,
+	})
+	if err != nil {
+		// the new avatar is already in place, stale files are harmless
+		log.Printf("cannot delete old avatars of %s: %v", userId, err)
+	}
+	s.removeMedia(ctx, old...)
+
+	return c.JSON(http.StatusOK, newResponse(user))
+}
+
+// attach an image to a post
+func (s *Server) uploadPostImage(c echo.Context) error {
+	// swagger:operation POST /posts/{id}/images posts uploadPostImage
+	// ---
+	// summary: Attach an image to a post
+	// description: Upload a jpeg or png image to a post, a post can have up to 4 images.
+	// consumes:
+	// - multipart/form-data
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: file
+	//   in: formData
+	//   description: the image
+	//   required: true
+	//   type: file
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '201':
+	//     description: Created
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '413':
+	//     description: The file is too large
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '415':
+	//     description: The file is not a jpeg or png image
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, MISSING_FIELD)
+	}
+
+	ctx := c.Request().Context()
+
+	post, err := s.store.GetPostById(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if _, err := s.ownedIdentity(ctx, tokenPayload.UserId, post.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	count, err := s.store.CountMediaByPostId(ctx, uuid.NullUUID{UUID: postId, Valid: true})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if count >= maxPostImages {
+		return c.JSON(http.StatusBadRequest, newError("a post can't have more than 4 images"))
+	}
+
+	arg, err := s.saveImage(ctx, file, "posts/"+postId.String())
+	if err != nil {
+		return uploadErrorResponse(c, err)
+	}
+	arg.Kind = "post"
+	arg.PostID = uuid.NullUUID{UUID: postId, Valid: true}
+
+	image, err := s.createMedia(ctx, arg)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.newMediaResponse(image)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusCreated, newResponse(resp))
+}
+
+// list the images of a post
+func (s *Server) listPostImages(c echo.Context) error {
+	// swagger:operation GET /posts/{id}/images posts listPostImages
+	// ---
+	// summary: List the images of a post
+	// description: List the images attached to a post
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	images, err := s.store.ListMediaByPostId(c.Request().Context(), uuid.NullUUID{UUID: postId, Valid: true})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.newMediaResponses(images)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// remove an image from a post
+func (s *Server) deletePostImage(c echo.Context) error {
+	// swagger:operation DELETE /posts/{id}/images/{imageId} posts deletePostImage
+	// ---
+	// summary: Remove an image from a post
+	// description: Remove an image from a post and delete the stored files
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: imageId
+	//   in: path
+	//   description: image id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	imageId, err := uuid.Parse(c.Param("imageId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	image, err := s.store.GetMediaById(ctx, imageId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if !image.PostID.Valid || image.PostID.UUID != postId {
+		return c.JSON(http.StatusNotFound, NOT_FOUND)
+	}
+
+	post, err := s.store.GetPostById(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if _, err := s.ownedIdentity(ctx, tokenPayload.UserId, post.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	deleted, err := s.store.DeleteMedia(ctx, imageId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+	s.removeMedia(ctx, deleted)
+
+	return c.JSON(http.StatusOK, newResponse(deleted.ID))
+}
+
+// serve a stored file
+func (s *Server) serveMedia(c echo.Context) error {
+	// swagger:operation GET /media/{key} media serveMedia
+	// ---
+	// summary: Download a stored file
+	// description: Serve a file from the local storage backend.
+	//   Private files need the expires and signature parameters of a signed url.
+	// produces:
+	// - image/jpeg
+	// - image/png
+	// parameters:
+	// - name: key
+	//   in: path
+	//   description: the storage key
+	//   required: true
+	//   type: string
+	// - name: expires
+	//   in: query
+	//   description: expiry of a signed url
+	//   required: false
+	//   type: string
+	// - name: signature
+	//   in: query
+	//   description: signature of a signed url
+	//   required: false
+	//   type: string
+	// responses:
+	//   '200':
+	//     description: The file
+	//   '403':
+	//     description: Missing, invalid or expired signature
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+
+	key := c.Param("*")
+
+	maxAge := "public, max-age=31536000, immutable"
+	if strings.HasPrefix(key, privatePrefix) {
+		verifier, ok := s.storage.(storage.Verifier)
+		if !ok {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		if err := verifier.Verify(key, c.QueryParam("expires"), c.QueryParam("signature")); err != nil {
+			return c.JSON(http.StatusForbidden, newError(err.Error()))
+		}
+		maxAge = "private, no-store"
+	}
+
+	blob, err := s.storage.Get(c.Request().Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+	defer blob.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+
+	c.Response().Header().Set("Cache-Control", maxAge)
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return c.Stream(http.StatusOK, contentType, blob)
+}
@@ -0,0 +1,561 @@
+package handler
+
+import (
+	"bytes"
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"cnfs/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+type uploadTestCase struct {
+	name          string
+	url           string
+	file          []byte
+	fields        map[string]string
+	buildStubs    func(store *mock.MockStore)
+	checkResponse func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage)
+}
+
+func RandomPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 200, 255})
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, img))
+	return buf.Bytes()
+}
+
+func RandomMedia(t *testing.T, kind string, private bool) db.Media {
+	id := uuid.New()
+	prefix := kind + "s/"
+	if private {
+		prefix = privatePrefix + prefix
+	}
+
+	return db.Media{
+		ID:           id,
+		Kind:         kind,
+		StorageKey:   prefix + id.String() + ".png",
+		ThumbnailKey: prefix + id.String() + "_thumb.png",
+		ContentType:  "image/png",
+		Size:         100,
+		Width:        10,
+		Height:       10,
+		IsPrivate:    private,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// newTestStorage returns a local storage rooted in a temporary directory.
+func newTestStorage(t *testing.T) *storage.LocalStorage {
+	blobs, err := storage.NewLocalStorage(t.TempDir(), cfg.StoragePublicUrl, cfg.StorageSigningKey)
+	require.NoError(t, err)
+	return blobs
+}
+
+func newMultipartRequest(t *testing.T, target, field string, file []byte, fields map[string]string) *http.Request {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+
+	if file != nil {
+		part, err := w.CreateFormFile(field, "upload.png")
+		require.NoError(t, err)
+		_, err = part.Write(file)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func runUploadTestCases(t *testing.T, user db.User, field string, testCases []uploadTestCase) {
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+			blobs := newTestStorage(t)
+			server.storage = blobs
+
+			rec := httptest.NewRecorder()
+			req := newMultipartRequest(t, tc.url, field, tc.file, tc.fields)
+			token, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, cfg.AccessTokenDuration)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec, blobs)
+		})
+	}
+}
+
+// requireStored checks that the key exists in storage and holds a png without metadata chunks.
+func requireStored(t *testing.T, blobs storage.Storage, key string) {
+	r, err := blobs.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	_, err = png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(data, []byte("tEXt")))
+}
+
+func TestUploadAvatar(t *testing.T) {
+	_, user := RandomUser(t)
+	url := fmt.Sprintf("/api/v1/users/%s/avatar", user.ID)
+	oldAvatar := RandomMedia(t, "avatar", false)
+
+	runUploadTestCases(t, user, "file", []uploadTestCase{
+		{
+			name: "OK",
+			url:  url,
+			file: RandomPNG(t, 400, 200),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaParams) (db.Media, error) {
+						require.Equal(t, "avatar", arg.Kind)
+						require.Equal(t, uuid.NullUUID{UUID: user.ID, Valid: true}, arg.UserID)
+						require.False(t, arg.IsPrivate)
+						require.EqualValues(t, 400, arg.Width)
+						return db.Media{ID: arg.ID, StorageKey: arg.StorageKey, ThumbnailKey: arg.ThumbnailKey}, nil
+					})
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateUserProfileParams) (db.User, error) {
+						require.True(t, arg.AvatarUrl.Valid)
+						require.True(t, strings.HasPrefix(arg.AvatarUrl.String, cfg.StoragePublicUrl+"/avatars/"+user.ID.String()+"/"))
+						user.AvatarUrl = arg.AvatarUrl.String
+						return user, nil
+					})
+				store.EXPECT().DeleteOldAvatars(gomock.Any(), gomock.Any()).Times(1).Return([]db.Media{oldAvatar}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data db.User `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+				key := strings.TrimPrefix(resp.Data.AvatarUrl, cfg.StoragePublicUrl+"/")
+				requireStored(t, blobs, key)
+				requireStored(t, blobs, strings.TrimSuffix(key, ".png")+"_thumb.png")
+			},
+		},
+		{
+			name: "UNSUPPORTED TYPE",
+			url:  url,
+			file: []byte("GIF89a definitely not a png"),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			},
+		},
+		{
+			name: "TOO LARGE",
+			url:  url,
+			file: append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, cfg.MaxUploadSize)...),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+			},
+		},
+		{
+			name: "MISSING FILE",
+			url:  url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "UNAUTHORIZED",
+			url:  fmt.Sprintf("/api/v1/users/%s/avatar", uuid.New()),
+			file: RandomPNG(t, 10, 10),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUploadPostImage(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	url := fmt.Sprintf("/api/v1/posts/%s/images", post.ID)
+	postId := uuid.NullUUID{UUID: post.ID, Valid: true}
+
+	runUploadTestCases(t, user, "file", []uploadTestCase{
+		{
+			name: "OK",
+			url:  url,
+			file: RandomPNG(t, 20, 20),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CountMediaByPostId(gomock.Any(), gomock.Eq(postId)).Times(1).Return(int64(1), nil)
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMediaParams) (db.Media, error) {
+						require.Equal(t, "post", arg.Kind)
+						require.Equal(t, postId, arg.PostID)
+						return db.Media{ID: arg.ID, Kind: arg.Kind, StorageKey: arg.StorageKey, ThumbnailKey: arg.ThumbnailKey}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusCreated, rec.Code)
+
+				var resp struct {
+					Data mediaResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Nil(t, resp.Data.ExpiresAt)
+				requireStored(t, blobs, strings.TrimPrefix(resp.Data.Url, cfg.StoragePublicUrl+"/"))
+				requireStored(t, blobs, strings.TrimPrefix(resp.Data.ThumbnailUrl, cfg.StoragePublicUrl+"/"))
+			},
+		},
+		{
+			name: "TOO MANY IMAGES",
+			url:  url,
+			file: RandomPNG(t, 20, 20),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CountMediaByPostId(gomock.Any(), gomock.Eq(postId)).Times(1).Return(int64(maxPostImages), nil)
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name: "NOT OWNER",
+			url:  url,
+			file: RandomPNG(t, 20, 20),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(RandomUserIdentity(t, uuid.New()), nil)
+				store.EXPECT().CreateMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name: "POST NOT FOUND",
+			url:  url,
+			file: RandomPNG(t, 20, 20),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestCreateMessageWithImage(t *testing.T) {
+	_, receiver := RandomUser(t)
+	_, sender := RandomUser(t)
+
+	// the image the failing transaction was given, its files must be gone again
+	var failed db.CreateMediaParams
+
+	runUploadTestCases(t, sender, "image", []uploadTestCase{
+		{
+			name:   "OK",
+			url:    "/api/v1/messages",
+			file:   RandomPNG(t, 30, 30),
+			fields: map[string]string{"receiver_id": receiver.ID.String(), "content": "look at this"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMessageTxParams) (db.CreateMessageTxResult, error) {
+						require.Equal(t, "look at this", arg.Content)
+						require.NotNil(t, arg.Image)
+						require.Equal(t, "message", arg.Image.Kind)
+						require.True(t, arg.Image.IsPrivate)
+						require.True(t, strings.HasPrefix(arg.Image.StorageKey, privatePrefix))
+						return db.CreateMessageTxResult{
+							Message:     db.Message{ID: arg.ID, ReceiverID: arg.ReceiverID, Content: arg.Content},
+							Attachments: []db.Media{{ID: arg.Image.ID, StorageKey: arg.Image.StorageKey, ThumbnailKey: arg.Image.ThumbnailKey, IsPrivate: true}},
+						}, nil
+					})
+				store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateNotificationRow{}, nil)
+				store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data messageResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data.Attachments, 1)
+				require.NotNil(t, resp.Data.Attachments[0].ExpiresAt)
+				require.Contains(t, resp.Data.Attachments[0].Url, "signature=")
+			},
+		},
+		{
+			name:   "NOT STORED",
+			url:    "/api/v1/messages",
+			file:   RandomPNG(t, 30, 30),
+			fields: map[string]string{"receiver_id": receiver.ID.String(), "content": "look at this"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMessageTxParams) (db.CreateMessageTxResult, error) {
+						failed = *arg.Image
+						return db.CreateMessageTxResult{}, sql.ErrConnDone
+					})
+				store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+				require.NotEmpty(t, failed.StorageKey)
+				for _, key := range []string{failed.StorageKey, failed.ThumbnailKey} {
+					_, err := blobs.Get(context.Background(), key)
+					require.ErrorIs(t, err, storage.ErrNotFound)
+				}
+			},
+		},
+		{
+			name:   "UNSUPPORTED TYPE",
+			url:    "/api/v1/messages",
+			file:   []byte("not an image at all"),
+			fields: map[string]string{"receiver_id": receiver.ID.String(), "content": "look at this"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			},
+		},
+		{
+			name:   "WITHOUT IMAGE",
+			url:    "/api/v1/messages",
+			fields: map[string]string{"receiver_id": receiver.ID.String(), "content": "just text"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(receiver.ID)).Times(1).Return(receiver, nil)
+				store.EXPECT().IsBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMessageTxParams) (db.CreateMessageTxResult, error) {
+						require.Nil(t, arg.Image)
+						return db.CreateMessageTxResult{}, nil
+					})
+				store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateNotificationRow{}, nil)
+				store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder, blobs *storage.LocalStorage) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListPostImages(t *testing.T) {
+	post := RandomPost(t, uuid.New())
+	images := []db.Media{RandomMedia(t, "post", false), RandomMedia(t, "post", false)}
+
+	testCases := []testCase{
+		{
+			name:    "OK",
+			payload: fmt.Sprintf("/api/v1/posts/%s/images", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListMediaByPostId(gomock.Any(), gomock.Eq(uuid.NullUUID{UUID: post.ID, Valid: true})).Times(1).Return(images, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data []mediaResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data, 2)
+				require.Equal(t, cfg.StoragePublicUrl+"/"+images[0].StorageKey, resp.Data[0].Url)
+			},
+		},
+		{
+			name:    "INVALID ID",
+			payload: "/api/v1/posts/nope/images",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListMediaByPostId(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.payload, nil)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
+
+func TestDeletePostImage(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	image := RandomMedia(t, "post", false)
+	image.PostID = uuid.NullUUID{UUID: post.ID, Valid: true}
+	url := fmt.Sprintf("/api/v1/posts/%s/images/%s", post.ID, image.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodDelete,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetMediaById(gomock.Any(), gomock.Eq(image.ID)).Times(1).Return(image, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().DeleteMedia(gomock.Any(), gomock.Eq(image.ID)).Times(1).Return(image, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "IMAGE OF ANOTHER POST",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/posts/%s/images/%s", uuid.New(), image.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetMediaById(gomock.Any(), gomock.Eq(image.ID)).Times(1).Return(image, nil)
+				store.EXPECT().DeleteMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "NOT OWNER",
+			method: http.MethodDelete,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetMediaById(gomock.Any(), gomock.Eq(image.ID)).Times(1).Return(image, nil)
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(RandomUserIdentity(t, uuid.New()), nil)
+				store.EXPECT().DeleteMedia(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+	})
+}
+
+func TestServeMedia(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server, err := NewServer(mock.NewMockStore(ctrl), cfg)
+	require.NoError(t, err)
+	blobs := newTestStorage(t)
+	server.storage = blobs
+
+	ctx := context.Background()
+	content := RandomPNG(t, 5, 5)
+	require.NoError(t, blobs.Put(ctx, "posts/a.png", bytes.NewReader(content), int64(len(content)), "image/png"))
+	require.NoError(t, blobs.Put(ctx, "private/messages/b.png", bytes.NewReader(content), int64(len(content)), "image/png"))
+
+	signed, err := blobs.SignedURL("private/messages/b.png", time.Minute)
+	require.NoError(t, err)
+	signedUrl, err := url.Parse(signed)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"PUBLIC", "/api/v1/media/posts/a.png", http.StatusOK},
+		{"SIGNED", "/api/v1/media/private/messages/b.png?" + signedUrl.RawQuery, http.StatusOK},
+		{"UNSIGNED PRIVATE", "/api/v1/media/private/messages/b.png", http.StatusForbidden},
+		{"WRONG SIGNATURE", "/api/v1/media/private/messages/b.png?expires=9999999999&signature=00", http.StatusForbidden},
+		{"MISSING", "/api/v1/media/posts/missing.png", http.StatusNotFound},
+		{"TRAVERSAL", "/api/v1/media/posts/..%2F..%2Fapp.env", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+		require.Equal(t, tc.status, rec.Code, tc.name)
+		if tc.status == http.StatusOK {
+			require.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType), tc.name)
+			require.Equal(t, content, rec.Body.Bytes(), tc.name)
+		}
+	}
+}
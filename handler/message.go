@@ -18,12 +18,19 @@ type (
 		// the receiver_id
 		// type: uuid
 		// required: true
-		ReceiverId uuid.UUID `json:"receiver_id" validate:"required"`
+		ReceiverId uuid.UUID `json:"receiver_id" form:"receiver_id" validate:"required"`
 
 		// the actual message
 		// type: string
 		// required: true
-		Content string `json:"content" validate:"required,gte=1"`
+		Content string `json:"content" form:"content" validate:"required,gte=1"`
+	}
+
+	// swagger:model
+	messageResponse struct {
+		db.Message
+		// images attached by the sender, the urls are signed and expire
+		Attachments []mediaResponse `json:"attachments,omitempty"`
 	}
 )
 
@@ -37,14 +44,21 @@ func (s *Server) createMessage(c echo.Context) error {
 	// - application/json
 	// consumes:
 	// - application/json
+	// - multipart/form-data
 	//
 	// parameters:
 	// - name: body
 	//   in: body
-	//   description: this payload holds the receiver_id and the message
+	//   description: this payload holds the receiver_id and the message,
+	//     send it as multipart/form-data fields to attach an image
 	//   required: true
 	//   schema:
 	//     "$ref": "#/definitions/createMessageRequest"
+	// - name: image
+	//   in: formData
+	//   description: an optional jpeg or png image, only the receiver can see it
+	//   required: false
+	//   type: file
 	//
 	// responses:
 	//  200:
@@ -57,6 +71,27 @@ func (s *Server) createMessage(c echo.Context) error {
 	//	  schema:
 	//	     type: object
 	//		 	"$ref": "#/definitions/BadRequestResponse"
+	//  401:
+	//	  description: The sender is anonymous and the receiver blocks somebody, so only takes messages
+	//	    from signed in senders.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/UnauthorizedResponse"
+	//  403:
+	//	  description: The receiver has blocked the signed in sender.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/UnauthorizedResponse"
+	//  413:
+	//	  description: The image is too large.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/BadRequestResponse"
+	//  415:
+	//	  description: The image is not a jpeg or png.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/BadRequestResponse"
 	//  500:
 	//	  description: Internal error.
 	//	  schema:
@@ -65,7 +100,7 @@ func (s *Server) createMessage(c echo.Context) error {
 
 	data := new(createMessageRequest)
 
-	if err := c.Bind(&data); err != nil {
+	if err := c.Bind(data); err != nil {
 		return c.JSON(http.StatusBadRequest, MISSING_FIELD)
 	}
 
@@ -81,19 +116,76 @@ func (s *Server) createMessage(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	msgArg := db.CreateMessageParams{
-		ID:         uuid.New(),
-		ReceiverID: user.ID,
-		Content:    data.Content,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+	ctx := c.Request().Context()
+
+	// signed in senders are held to the receiver's blocks. Anonymous ones can't be told apart, any of them
+	// could be somebody blocked, so a receiver who blocks anybody only takes messages from signed in senders
+	if sender := viewerId(c); sender != uuid.Nil {
+		blocked, err := s.store.IsBlocked(ctx, db.IsBlockedParams{
+			UserID:  user.ID,
+			ActorID: sender,
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		if blocked {
+			return c.JSON(http.StatusForbidden, newError("you can't message this user"))
+		}
+	} else {
+		blocks, err := s.store.HasBlocks(ctx, user.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		if blocks {
+			return c.JSON(http.StatusUnauthorized, newError("sign in to message this user"))
+		}
+	}
+
+	arg := db.CreateMessageTxParams{
+		CreateMessageParams: db.CreateMessageParams{
+			ID:         uuid.New(),
+			ReceiverID: user.ID,
+			Content:    data.Content,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		},
+	}
+
+	// attachments are private, only the receiver gets signed urls for them
+	if file, err := c.FormFile("image"); err == nil {
+		image, err := s.saveImage(ctx, file, privatePrefix+"messages/"+arg.ID.String())
+		if err != nil {
+			return uploadErrorResponse(c, err)
+		}
+		image.Kind = "message"
+		arg.Image = &image
+	} else if err != http.ErrMissingFile && err != http.ErrNotMultipart {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
-	message, err := s.store.CreateMessage(c.Request().Context(), msgArg)
+
+	// the message and its attachment are stored together, the saved image is removed when they aren't
+	result, err := s.store.CreateMessageTx(ctx, arg)
 	if err != nil {
+		if arg.Image != nil {
+			s.removeBlobs(ctx, arg.Image.StorageKey, arg.Image.ThumbnailKey)
+		}
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
+	message := result.Message
 
-	return c.JSON(200, newResponse(message))
+	resp := messageResponse{Message: message}
+	if len(result.Attachments) > 0 {
+		if resp.Attachments, err = s.newMediaResponses(result.Attachments); err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+	}
+
+	s.push(ctx, user.ID, eventTypeMessage, resp)
+	// senders are anonymous, so every message counts towards the notification
+	s.notify(ctx, user.ID, notificationTypeMessage, user.ID, message.ID)
+	s.emitWebhookEvent(ctx, user.ID, webhookEventMessageCreated, resp)
+
+	return c.JSON(200, newResponse(resp))
 }
 
 // List all messages that has sent to the user
@@ -219,6 +311,11 @@ func (s *Server) getMessageById(c echo.Context) error {
 	//	  schema:
 	//	     type: object
 	//		 	"$ref": "#/definitions/BadRequestResponse"
+	//  401:
+	//	  description: Unauthorized.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/UnauthorizedResponse"
 	//  500:
 	//	  description: Internal error.
 	//	  schema:
@@ -231,6 +328,11 @@ func (s *Server) getMessageById(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
 
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
 	msg, err := s.store.GetMessageById(c.Request().Context(), msgId)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -239,7 +341,21 @@ func (s *Server) getMessageById(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	return c.JSON(200, newResponse(msg))
+	if msg.ReceiverID != tokenPayload.UserId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	attachments, err := s.store.ListMediaByMessageId(c.Request().Context(), uuid.NullUUID{UUID: msg.ID, Valid: true})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp := messageResponse{Message: msg}
+	if resp.Attachments, err = s.newMediaResponses(attachments); err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(200, newResponse(resp))
 }
 
 func (s *Server) updateMessage(c echo.Context) error {
@@ -319,7 +435,7 @@ func (s *Server) updateMessage(c echo.Context) error {
 }
 
 func (s *Server) deleteMessage(c echo.Context) error {
-	// Delete a message.
+	// Move a message to the trash, it can be restored until it is purged.
 	// swagger:operation DELETE /messages/{id} messages deleteMessage
 	//
 	// ---
@@ -379,6 +495,7 @@ func (s *Server) deleteMessage(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
+	// the message goes to the trash, its attachments are kept until it is purged
 	deleteMsgParam := db.DeleteOneMessageParams{
 		ReceiverID: tokenPayload.UserId,
 		ID:         msgId,
@@ -26,10 +26,11 @@ type eqCreateMessageParams struct {
 }
 
 func (e *eqCreateMessageParams) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateMessageParams)
-	if !ok {
+	tx, ok := x.(db.CreateMessageTxParams)
+	if !ok || tx.Image != nil {
 		return false
 	}
+	arg := tx.CreateMessageParams
 
 	e.arg.ID = arg.ID
 	e.arg.CreatedAt = arg.CreatedAt
@@ -63,7 +64,10 @@ func TestServer_createMessage(t *testing.T) {
 					UpdatedAt:  msg.UpdatedAt,
 				}
 				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
-				store.EXPECT().CreateMessage(gomock.Any(), EqCreateMessageParams(&arg, arg.ID)).Times(1).Return(msg, nil)
+				store.EXPECT().HasBlocks(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(false, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), EqCreateMessageParams(&arg, arg.ID)).Times(1).Return(db.CreateMessageTxResult{Message: msg}, nil)
+				store.EXPECT().CreateNotification(gomock.Any(), EqNotification(user.ID, notificationTypeMessage, user.ID, msg.ID)).Times(1).Return(db.CreateNotificationRow{}, nil)
+				store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{UserID: user.ID, Event: webhookEventMessageCreated})).Times(1).Return(nil, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -77,6 +81,18 @@ func TestServer_createMessage(t *testing.T) {
 				require.Empty(t, resp.Err)
 			},
 		},
+		{
+			name:    "ANONYMOUS TO A USER WHO BLOCKS",
+			payload: fmt.Sprintf(`{"receiver_id": %q, "content": %q}`, user.ID, msg.Content),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().HasBlocks(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(true, nil)
+				store.EXPECT().CreateMessageTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
 		{
 			name:    "USER NOT FOUND",
 			payload: fmt.Sprintf(`{"receiver_id": %q, "content": %q}`, user.ID, msg.Content),
@@ -365,6 +381,7 @@ func TestGetMessageById(t *testing.T) {
 			payload: fmt.Sprintf("/api/v1/messages/%s", msg.ID),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetMessageById(gomock.Any(), gomock.Eq(msg.ID)).Times(1).Return(msg, nil)
+				store.EXPECT().ListMediaByMessageId(gomock.Any(), gomock.Eq(uuid.NullUUID{UUID: msg.ID, Valid: true})).Times(1).Return([]db.Media{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -411,6 +428,17 @@ func TestGetMessageById(t *testing.T) {
 				require.Empty(t, resp.Data)
 			},
 		},
+		{
+			name:    "UNAUTHORIZED ACCESS",
+			payload: fmt.Sprintf("/api/v1/messages/%s", msg.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetMessageById(gomock.Any(), gomock.Eq(msg.ID)).Times(1).Return(RandomMessage(t, uuid.New()), nil)
+				store.EXPECT().ListMediaByMessageId(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
 		{
 			name:    "Invalid UUID",
 			payload: fmt.Sprintf("/api/v1/messages/%s", common.RandomString(12)),
@@ -446,7 +474,7 @@ func TestGetMessageById(t *testing.T) {
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodGet, tc.payload, nil)
-			token, _, err := server.tokenMaker.CreateToken(uuid.New(), common.RandomString(12), cfg.AccessTokenDuration)
+			token, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, cfg.AccessTokenDuration)
 			require.NoError(t, err)
 			req.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
 
@@ -593,6 +621,8 @@ func TestDeleteMessage(t *testing.T) {
 					ID:         msg.ID,
 				}
 				store.EXPECT().GetMessageById(gomock.Any(), gomock.Eq(msg.ID)).Times(1).Return(msg, nil)
+				// the attachments stay until the message is purged
+				store.EXPECT().ListMediaByMessageId(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().DeleteOneMessage(gomock.Any(), gomock.Eq(arg)).Times(1).Return(msg.ID, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
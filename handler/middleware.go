@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"cnfs/token"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
@@ -31,7 +36,7 @@ func (s *Server) loggerMiddleware(logger *zerolog.Logger) echo.MiddlewareFunc {
 			log_row.
 				Str("host", v.Host).
 				Time("time", v.StartTime.UTC()).
-				Str("URI", v.URI).
+				Str("URI", redactedURI(v.URI)).
 				Int("status", status_code).
 				Str("method", v.Method).
 				Str("latency", v.Latency.String()).
@@ -61,12 +66,36 @@ func (s *Server) rateLimitMiddleware(limit rate.Limit) echo.MiddlewareFunc {
 	return middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(limit))
 }
 
+// uploadLimitMiddleware rejects request bodies larger than the upload size,
+// leaving some room for the multipart framing and the other form fields.
+func (s *Server) uploadLimitMiddleware() echo.MiddlewareFunc {
+	return middleware.BodyLimit(strconv.FormatInt(s.cfg.MaxUploadSize+64<<10, 10))
+}
+
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationHeaderType = "bearer"
 	authorizationPayloadKey = "user"
+	accessTokenParam        = "access_token"
 )
 
+// parseAuthorization verifies the bearer token of an authorization header.
+func (s *Server) parseAuthorization(authorizationHeader string) (*token.Payload, error) {
+	fields := strings.Fields(authorizationHeader)
+
+	if len(fields) < 2 {
+		return nil, errors.New("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationHeaderType {
+		return nil, errors.New("unsupported authorization header type")
+	}
+
+	accessToken := fields[1]
+	return s.tokenMaker.VerifyToken(accessToken)
+}
+
 func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authorizationHeader := c.Request().Header.Get(authorizationHeaderKey)
@@ -74,19 +103,56 @@ func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusUnauthorized, newError("authorization header is missing"))
 		}
 
-		fields := strings.Fields(authorizationHeader)
+		payload, err := s.parseAuthorization(authorizationHeader)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, newError(err.Error()))
+		}
+
+		c.Set(authorizationPayloadKey, payload)
+		return next(c)
+	}
+}
 
-		if len(fields) < 2 {
-			return c.JSON(http.StatusUnauthorized, newError("invalid authorization header format"))
+// streamAuthMiddleware is authMiddleware for the event streams. Browsers cannot set headers on EventSource
+// and WebSocket connections, so the access token can come in the access_token query parameter instead.
+func (s *Server) streamAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	auth := s.authMiddleware(next)
+	return func(c echo.Context) error {
+		req := c.Request()
+		if accessToken := c.QueryParam(accessTokenParam); accessToken != "" && req.Header.Get(authorizationHeaderKey) == "" {
+			req.Header.Set(authorizationHeaderKey, authorizationHeaderType+" "+accessToken)
 		}
+		return auth(c)
+	}
+}
+
+// redactedURI keeps access tokens passed as query parameters out of the logs.
+func redactedURI(uri string) string {
+	parsed, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := parsed.Query()
+	if !query.Has(accessTokenParam) {
+		return uri
+	}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != authorizationHeaderType {
-			return c.JSON(http.StatusUnauthorized, newError("unsupported authorization header type"))
+	query.Set(accessTokenParam, "REDACTED")
+	parsed.RawQuery = query.Encode()
+	return parsed.RequestURI()
+}
+
+// optionalAuthMiddleware is authMiddleware for routes that also serve anonymous visitors.
+// Without an authorization header the request goes through with no user set.
+func (s *Server) optionalAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authorizationHeader := c.Request().Header.Get(authorizationHeaderKey)
+		if authorizationHeader == "" {
+			return next(c)
 		}
 
-		accessToken := fields[1]
-		payload, err := s.tokenMaker.VerifyToken(accessToken)
+		payload, err := s.parseAuthorization(authorizationHeader)
 		if err != nil {
 			return c.JSON(http.StatusUnauthorized, newError(err.Error()))
 		}
@@ -95,3 +161,11 @@ func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// viewerId returns the id of the user making the request, or uuid.Nil for anonymous visitors.
+func viewerId(c echo.Context) uuid.UUID {
+	if payload, ok := c.Get(authorizationPayloadKey).(*token.Payload); ok {
+		return payload.UserId
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,415 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// a confession was sent to the user, the subject is the user
+	notificationTypeMessage = "message"
+	// someone commented on a post of the user, the subject is the post
+	notificationTypeComment = "comment"
+	// someone replied to a comment of the user, the subject is the comment
+	notificationTypeReply = "reply"
+	// someone reacted to a post of the user, the subject is the post
+	notificationTypeReaction = "reaction"
+)
+
+var notificationTypes = []string{
+	notificationTypeMessage,
+	notificationTypeComment,
+	notificationTypeReply,
+	notificationTypeReaction,
+}
+
+type (
+	// swagger:model
+	notificationsResponse struct {
+		Notifications []db.ListNotificationsRow `json:"notifications"`
+		// the number of unread notifications, on any page
+		Unread int64 `json:"unread"`
+	}
+
+	// swagger:model
+	notificationPreferencesRequest struct {
+		// whether to be notified of each type, types left out keep their setting
+		Preferences map[string]bool `json:"preferences" validate:"dive,keys,notification_type,endkeys"`
+	}
+
+	// swagger:model
+	notificationPreferencesResponse struct {
+		// whether the user is notified of each type
+		Preferences map[string]bool `json:"preferences"`
+	}
+)
+
+// isNotificationType validates a type of notification.
+func isNotificationType(fl validator.FieldLevel) bool {
+	for _, kind := range notificationTypes {
+		if fl.Field().String() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// notify tells the user about an event, folding it into their unread notification of the same type and subject,
+// and pushes the notification to their open streams and subscribed browsers. The event already happened, so
+// failing to record it is logged rather than failing the request.
+func (s *Server) notify(ctx context.Context, userId uuid.UUID, kind string, subjectId, actorId uuid.UUID) {
+	notification, err := s.store.CreateNotification(ctx, db.CreateNotificationParams{
+		ID:        uuid.New(),
+		UserID:    userId,
+		Type:      kind,
+		SubjectID: subjectId,
+		ActorID:   actorId,
+	})
+	if err != nil {
+		// the user turned the type off
+		if err != sql.ErrNoRows {
+			log.Printf("cannot notify %s of %s %s: %v", userId, kind, subjectId, err)
+		}
+		return
+	}
+
+	s.push(ctx, userId, eventTypeNotification, notification)
+	s.sendWebPush(ctx, userId, notification)
+}
+
+// identityOwner is the user behind an identity, uuid.Nil when it has none or they are the one acting.
+func (s *Server) identityOwner(ctx context.Context, identityId, actorUserId uuid.UUID) uuid.UUID {
+	identity, err := s.store.GetUserIdentityById(ctx, identityId)
+	if err != nil {
+		log.Printf("cannot find the owner of identity %s: %v", identityId, err)
+		return uuid.Nil
+	}
+
+	if !identity.UserID.Valid || identity.UserID.UUID == actorUserId {
+		return uuid.Nil
+	}
+
+	return identity.UserID.UUID
+}
+
+// notificationPreferences fills in the types the user never set, which are on.
+func notificationPreferences(preferences []db.NotificationPreference) map[string]bool {
+	enabled := make(map[string]bool, len(notificationTypes))
+	for _, kind := range notificationTypes {
+		enabled[kind] = true
+	}
+	for _, preference := range preferences {
+		enabled[preference.Type] = preference.Enabled
+	}
+	return enabled
+}
+
+// list the notifications of the user
+func (s *Server) listNotifications(c echo.Context) error {
+	// swagger:operation GET /notifications notifications listNotifications
+	// ---
+	// summary: List the notifications of the user
+	// description: List the notifications of the user, latest activity first. Repeated events of the same type
+	//   and subject are folded into one notification until it is read, count is the number of people behind them.
+	// parameters:
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int32
+	// - name: unread
+	//   in: query
+	//   description: only list unread notifications
+	//   required: false
+	//   type: boolean
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/notificationsResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	unreadOnly := false
+	if unreadParam := c.QueryParam("unread"); unreadParam != "" {
+		if unreadOnly, err = strconv.ParseBool(unreadParam); err != nil {
+			return c.JSON(http.StatusBadRequest, newError(err.Error()))
+		}
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	notifications, err := s.store.ListNotifications(ctx, db.ListNotificationsParams{
+		UserID:     tokenPayload.UserId,
+		UnreadOnly: unreadOnly,
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	unread, err := s.store.CountUnreadNotifications(ctx, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(notificationsResponse{
+		Notifications: notifications,
+		Unread:        unread,
+	}))
+}
+
+// mark a notification as read
+func (s *Server) markNotificationRead(c echo.Context) error {
+	// swagger:operation POST /notifications/{id}/read notifications markNotificationRead
+	// ---
+	// summary: Mark a notification as read
+	// description: Mark a notification as read, later events of its type and subject start a new notification.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: notification id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	notificationId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	_, err = s.store.MarkNotificationRead(c.Request().Context(), db.MarkNotificationReadParams{
+		ID:     notificationId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(notificationId))
+}
+
+// mark all notifications as read
+func (s *Server) markAllNotificationsRead(c echo.Context) error {
+	// swagger:operation POST /notifications/read notifications markAllNotificationsRead
+	// ---
+	// summary: Mark all notifications as read
+	// description: Mark every unread notification of the user as read. The response holds how many were marked.
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	marked, err := s.store.MarkAllNotificationsRead(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(marked))
+}
+
+// get the notification preferences of the user
+func (s *Server) getNotificationPreferences(c echo.Context) error {
+	// swagger:operation GET /notifications/preferences notifications getNotificationPreferences
+	// ---
+	// summary: Get the notification preferences of the user
+	// description: Get whether the user is notified of messages, comments, replies and reactions.
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/notificationPreferencesResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	preferences, err := s.store.ListNotificationPreferences(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(notificationPreferencesResponse{
+		Preferences: notificationPreferences(preferences),
+	}))
+}
+
+// update the notification preferences of the user
+func (s *Server) updateNotificationPreferences(c echo.Context) error {
+	// swagger:operation PUT /notifications/preferences notifications updateNotificationPreferences
+	// ---
+	// summary: Update the notification preferences of the user
+	// description: Turn notifications of each type on or off. Turning a type off stops new notifications of it,
+	//   the ones already there stay.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the preferences
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/notificationPreferencesRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/notificationPreferencesResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(notificationPreferencesRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	kinds := make([]string, 0, len(req.Preferences))
+	for kind := range req.Preferences {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	enabled := make([]bool, 0, len(kinds))
+	for _, kind := range kinds {
+		enabled = append(enabled, req.Preferences[kind])
+	}
+
+	ctx := c.Request().Context()
+
+	err := s.store.SetNotificationPreferences(ctx, db.SetNotificationPreferencesParams{
+		UserID:  tokenPayload.UserId,
+		Types:   kinds,
+		Enabled: enabled,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	preferences, err := s.store.ListNotificationPreferences(ctx, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(notificationPreferencesResponse{
+		Preferences: notificationPreferences(preferences),
+	}))
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type eqNotification struct {
+	arg db.CreateNotificationParams
+}
+
+func (e *eqNotification) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateNotificationParams)
+	if !ok || arg.ID == uuid.Nil {
+		return false
+	}
+
+	arg.ID = uuid.Nil
+	return arg == e.arg
+}
+
+func (e *eqNotification) String() string {
+	return fmt.Sprintf("is a notification %v", e.arg)
+}
+
+// EqNotification matches a new notification whatever its generated id.
+func EqNotification(userId uuid.UUID, kind string, subjectId, actorId uuid.UUID) gomock.Matcher {
+	return &eqNotification{arg: db.CreateNotificationParams{
+		UserID:    userId,
+		Type:      kind,
+		SubjectID: subjectId,
+		ActorID:   actorId,
+	}}
+}
+
+func TestListNotifications(t *testing.T) {
+	_, user := RandomUser(t)
+	notifications := []db.ListNotificationsRow{
+		{ID: uuid.New(), UserID: user.ID, Type: notificationTypeReaction, SubjectID: uuid.New(), Count: 12},
+		{ID: uuid.New(), UserID: user.ID, Type: notificationTypeMessage, SubjectID: user.ID, Count: 1},
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/notifications?page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Eq(db.ListNotificationsParams{UserID: user.ID, PageOffset: pageSize})).
+					Times(1).Return(notifications, nil)
+				store.EXPECT().CountUnreadNotifications(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(2), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data notificationsResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data.Notifications, 2)
+				require.Equal(t, int64(12), resp.Data.Notifications[0].Count)
+				require.Equal(t, int64(2), resp.Data.Unread)
+			},
+		},
+		{
+			name:   "UNREAD ONLY",
+			method: http.MethodGet,
+			url:    "/api/v1/notifications?unread=true",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Eq(db.ListNotificationsParams{UserID: user.ID, UnreadOnly: true})).
+					Times(1).Return([]db.ListNotificationsRow{}, nil)
+				store.EXPECT().CountUnreadNotifications(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID UNREAD",
+			method: http.MethodGet,
+			url:    "/api/v1/notifications?unread=maybe",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "INTERNAL ERROR",
+			method: http.MethodGet,
+			url:    "/api/v1/notifications",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListNotifications(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	})
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	_, user := RandomUser(t)
+	notificationId := uuid.New()
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "ONE",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/notifications/%s/read", notificationId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Eq(db.MarkNotificationReadParams{ID: notificationId, UserID: user.ID})).
+					Times(1).Return(notificationId, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "SOMEONE ELSE'S",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/notifications/%s/read", notificationId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID ID",
+			method: http.MethodPost,
+			url:    "/api/v1/notifications/nope/read",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().MarkNotificationRead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:   "ALL",
+			method: http.MethodPost,
+			url:    "/api/v1/notifications/read",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().MarkAllNotificationsRead(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(int64(3), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data int64 `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, int64(3), resp.Data)
+			},
+		},
+	})
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	_, user := RandomUser(t)
+	stored := []db.NotificationPreference{{UserID: user.ID, Type: notificationTypeReaction, Enabled: false}}
+
+	checkPreferences := func(rec *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp struct {
+			Data notificationPreferencesResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, map[string]bool{
+			notificationTypeMessage:  true,
+			notificationTypeComment:  true,
+			notificationTypeReply:    true,
+			notificationTypeReaction: false,
+		}, resp.Data.Preferences)
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "GET",
+			method: http.MethodGet,
+			url:    "/api/v1/notifications/preferences",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListNotificationPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(stored, nil)
+			},
+			checkResponse: checkPreferences,
+		},
+		{
+			name:    "UPDATE",
+			method:  http.MethodPut,
+			url:     "/api/v1/notifications/preferences",
+			payload: `{"preferences": {"reaction": false, "comment": true}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetNotificationPreferences(gomock.Any(), gomock.Eq(db.SetNotificationPreferencesParams{
+					UserID:  user.ID,
+					Types:   []string{"comment", "reaction"},
+					Enabled: []bool{true, false},
+				})).Times(1).Return(nil)
+				store.EXPECT().ListNotificationPreferences(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(stored, nil)
+			},
+			checkResponse: checkPreferences,
+		},
+		{
+			name:    "UNKNOWN TYPE",
+			method:  http.MethodPut,
+			url:     "/api/v1/notifications/preferences",
+			payload: `{"preferences": {"mention": false}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestNotifyReply(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, uuid.New())
+	postAuthor := RandomUserIdentity(t, uuid.New())
+	postAuthor.ID = post.UserIdentityID
+	parent := RandomComment(t, post.ID, uuid.Nil)
+	parentAuthor := RandomUserIdentity(t, uuid.New())
+	parentAuthor.ID = parent.UserIdentityID
+	payload := fmt.Sprintf(`{"user_identity_id": %q, "post_id": %q, "parent_id": %q, "content": "same here"}`, identity.ID, post.ID, parent.ID)
+
+	createReply := func(store *mock.MockStore) {
+		store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+		store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+		store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(2).Return(postAuthor, nil)
+		store.EXPECT().IsBlocked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+		store.EXPECT().CreateCommentTx(gomock.Any(), gomock.Any()).Times(1).Return(RandomComment(t, post.ID, parent.ID), nil)
+		store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{UserID: postAuthor.UserID.UUID, Event: webhookEventCommentCreated})).
+			Times(1).Return(nil, nil)
+		store.EXPECT().GetComment(gomock.Any(), gomock.Eq(parent.ID)).Times(1).Return(parent, nil)
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "PARENT AUTHOR",
+			method:  http.MethodPost,
+			url:     "/api/v1/comments",
+			payload: payload,
+			buildStubs: func(store *mock.MockStore) {
+				createReply(store)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(parent.UserIdentityID)).Times(1).Return(parentAuthor, nil)
+				store.EXPECT().CreateNotification(gomock.Any(), EqNotification(parentAuthor.UserID.UUID, notificationTypeReply, parent.ID, user.ID)).
+					Times(1).Return(db.CreateNotificationRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "OWN COMMENT",
+			method:  http.MethodPost,
+			url:     "/api/v1/comments",
+			payload: payload,
+			buildStubs: func(store *mock.MockStore) {
+				createReply(store)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(parent.UserIdentityID)).Times(1).Return(RandomUserIdentity(t, user.ID), nil)
+				store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "FAILING TO NOTIFY",
+			method:  http.MethodPost,
+			url:     "/api/v1/comments",
+			payload: payload,
+			buildStubs: func(store *mock.MockStore) {
+				createReply(store)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(parent.UserIdentityID)).Times(1).Return(parentAuthor, nil)
+				store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateNotificationRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	})
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+var errClosesAtInPast = errors.New("closes_at has to be in the future")
+
+type (
+	// swagger:model
+	createPollRequest struct {
+		// between 2 and 10 distinct options, in the order they are shown
+		Options []string `json:"options" validate:"min=2,max=10,unique,dive,required,max=200"`
+		// let voters pick more than one option
+		Multiple bool `json:"multiple"`
+		// stop taking votes at this time, it has to be in the future. Without it the poll never closes.
+		ClosesAt *time.Time `json:"closes_at"`
+	}
+
+	// swagger:model
+	votePollRequest struct {
+		// one option, or several for multiple choice polls
+		OptionIds []uuid.UUID `json:"option_ids" validate:"required,min=1,max=10,unique"`
+		// the identity to vote under, defaults to the user's default identity
+		UserIdentityId uuid.UUID `json:"user_identity_id"`
+	}
+
+	// swagger:model
+	pollOptionResponse struct {
+		ID   uuid.UUID `json:"id"`
+		Text string    `json:"text"`
+		// the number of votes, left out until the viewer voted or the poll closed
+		Votes *int64 `json:"votes,omitempty"`
+		// whether the viewer picked the option
+		Voted bool `json:"voted"`
+	}
+
+	// swagger:model
+	pollResponse struct {
+		Multiple bool       `json:"multiple"`
+		ClosesAt *time.Time `json:"closes_at,omitempty"`
+		Closed   bool       `json:"closed"`
+		// whether the viewer voted, under any of their identities
+		Voted bool `json:"voted"`
+		// the number of identities that voted, left out until the viewer voted or the poll closed
+		Voters  *int64               `json:"voters,omitempty"`
+		Options []pollOptionResponse `json:"options"`
+	}
+
+	// swagger:model
+	postResponse struct {
+		db.Post
+		// the poll of the post, if it has one
+		Poll *pollResponse `json:"poll,omitempty"`
+		// whether the content preferences of the viewer ask to blur the post
+		Blur bool `json:"blur"`
+	}
+)
+
+// newPollResponse hides the results of the poll until the viewer voted or the poll closed.
+func newPollResponse(poll db.ListPollsRow, options []db.ListPollOptionsRow) *pollResponse {
+	resp := &pollResponse{
+		Multiple: poll.Multiple,
+		ClosesAt: poll.ClosesAt,
+		Closed:   poll.Closed,
+		Voted:    poll.Voted,
+		Options:  make([]pollOptionResponse, 0, len(options)),
+	}
+
+	showResults := poll.Voted || poll.Closed
+	if showResults {
+		resp.Voters = &poll.Voters
+	}
+
+	for i := range options {
+		option := pollOptionResponse{
+			ID:    options[i].ID,
+			Text:  options[i].Text,
+			Voted: options[i].Voted,
+		}
+		if showResults {
+			option.Votes = &options[i].Votes
+		}
+		resp.Options = append(resp.Options, option)
+	}
+
+	return resp
+}
+
+// loadPolls reads the polls of the posts as the viewer sees them, in two queries however many posts there are.
+func (s *Server) loadPolls(ctx context.Context, viewerId uuid.UUID, postIds []uuid.UUID) (map[uuid.UUID]*pollResponse, error) {
+	polls, err := s.store.ListPolls(ctx, db.ListPollsParams{
+		ViewerID: viewerId,
+		PostIds:  postIds,
+	})
+	if err != nil || len(polls) == 0 {
+		return nil, err
+	}
+
+	options, err := s.store.ListPollOptions(ctx, db.ListPollOptionsParams{
+		ViewerID: viewerId,
+		PostIds:  postIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	optionsByPost := make(map[uuid.UUID][]db.ListPollOptionsRow, len(polls))
+	for _, option := range options {
+		optionsByPost[option.PostID] = append(optionsByPost[option.PostID], option)
+	}
+
+	pollsByPost := make(map[uuid.UUID]*pollResponse, len(polls))
+	for _, poll := range polls {
+		pollsByPost[poll.PostID] = newPollResponse(poll, optionsByPost[poll.PostID])
+	}
+
+	return pollsByPost, nil
+}
+
+// postResponses embeds the polls of the posts and applies the content preferences of the viewer. A post the
+// viewer hides is blurred, the lists leave those out already so this only happens when it is asked for by id.
+func (s *Server) postResponses(ctx context.Context, viewerId uuid.UUID, posts []db.Post) ([]postResponse, error) {
+	resp := make([]postResponse, 0, len(posts))
+	if len(posts) == 0 {
+		return resp, nil
+	}
+
+	postIds := make([]uuid.UUID, 0, len(posts))
+	flagged := false
+	for _, post := range posts {
+		postIds = append(postIds, post.ID)
+		flagged = flagged || post.Nsfw || len(post.ContentWarnings) > 0
+	}
+
+	polls, err := s.loadPolls(ctx, viewerId, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// the preferences only matter for flagged posts
+	var preferences map[string]string
+	if flagged {
+		if preferences, err = s.contentPreferences(ctx, viewerId); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, post := range posts {
+		resp = append(resp, postResponse{
+			Post: post,
+			Poll: polls[post.ID],
+			Blur: contentAction(preferences, post) != contentActionShow,
+		})
+	}
+
+	return resp, nil
+}
+
+// vote in the poll of a post
+func (s *Server) votePoll(c echo.Context) error {
+	// swagger:operation POST /posts/{id}/poll/votes posts votePoll
+	// ---
+	// summary: Vote in a poll
+	// description: Vote in the poll of a post. Each user votes once, under any one of their identities, with one option or several for multiple choice polls.
+	//   The response holds the results of the poll.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the options voted for
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/votePollRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/pollResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '409':
+	//     description: The user already voted, under this identity or another
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(votePollRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err := s.store.GetPostById(ctx, postId); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	pollArg := db.ListPollsParams{
+		ViewerID: tokenPayload.UserId,
+		PostIds:  []uuid.UUID{postId},
+	}
+	polls, err := s.store.ListPolls(ctx, pollArg)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+	if len(polls) == 0 {
+		return c.JSON(http.StatusNotFound, NOT_FOUND)
+	}
+
+	poll := polls[0]
+	if poll.Closed {
+		return c.JSON(http.StatusBadRequest, newError("the poll is closed"))
+	}
+	// one vote per user, whichever identity they vote under
+	if poll.Voted {
+		return c.JSON(http.StatusConflict, newError("you already voted in this poll"))
+	}
+	if !poll.Multiple && len(req.OptionIds) > 1 {
+		return c.JSON(http.StatusBadRequest, newError("the poll takes a single option"))
+	}
+
+	identity, err := s.postingIdentity(ctx, tokenPayload.UserId, req.UserIdentityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	cast, err := s.store.CastPollBallotTx(ctx, db.CastPollBallotParams{
+		PostID:         postId,
+		UserIdentityID: identity.ID,
+		UserID:         tokenPayload.UserId,
+		OptionIds:      req.OptionIds,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return c.JSON(http.StatusConflict, newError("the identity already voted in this poll"))
+			case "foreign_key_violation":
+				return c.JSON(http.StatusBadRequest, newError("the option is not part of this poll"))
+			}
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+	// the poll closed or the user voted under another identity since it was read
+	if cast == 0 {
+		polls, err := s.store.ListPolls(ctx, pollArg)
+		if err != nil || len(polls) == 0 {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		if polls[0].Voted {
+			return c.JSON(http.StatusConflict, newError("you already voted in this poll"))
+		}
+		return c.JSON(http.StatusBadRequest, newError("the poll is closed"))
+	}
+
+	results, err := s.loadPolls(ctx, tokenPayload.UserId, []uuid.UUID{postId})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(results[postId]))
+}
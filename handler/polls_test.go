@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPoll(postId uuid.UUID) (db.ListPollsRow, []db.ListPollOptionsRow) {
+	poll := db.ListPollsRow{PostID: postId, Voters: 3}
+	options := []db.ListPollOptionsRow{
+		{ID: uuid.New(), PostID: postId, Position: 1, Text: "yes", Votes: 2},
+		{ID: uuid.New(), PostID: postId, Position: 2, Text: "no", Votes: 1},
+	}
+	return poll, options
+}
+
+func TestNewPollResponse(t *testing.T) {
+	poll, options := randomPoll(uuid.New())
+
+	hidden := newPollResponse(poll, options)
+	require.Nil(t, hidden.Voters)
+	require.Len(t, hidden.Options, 2)
+	for _, option := range hidden.Options {
+		require.Nil(t, option.Votes)
+	}
+
+	poll.Voted = true
+	options[0].Voted = true
+	voted := newPollResponse(poll, options)
+	require.Equal(t, int64(3), *voted.Voters)
+	require.Equal(t, int64(2), *voted.Options[0].Votes)
+	require.True(t, voted.Options[0].Voted)
+	require.False(t, voted.Options[1].Voted)
+
+	poll.Voted = false
+	poll.Closed = true
+	closed := newPollResponse(poll, options)
+	require.Equal(t, int64(1), *closed.Options[1].Votes)
+}
+
+func TestCreatePostWithPoll(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	closesAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": "confess or vote", "poll": {"options": ["yes", "no", "maybe"], "multiple": true, "closes_at": %q}}`, closesAt.Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.NotNil(t, arg.Poll)
+						require.True(t, arg.Poll.Multiple)
+						require.True(t, closesAt.Equal(*arg.Poll.ClosesAt))
+						require.Equal(t, []string{"yes", "no", "maybe"}, arg.PollOptions)
+						return db.Post{ID: arg.ID, Content: arg.Content, UserIdentityID: arg.UserIdentityID, Status: arg.Status}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "TOO FEW OPTIONS",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "vote", "poll": {"options": ["yes"]}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "TOO MANY OPTIONS",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "vote", "poll": {"options": ["1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"]}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "DUPLICATE OPTIONS",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: `{"content": "vote", "poll": {"options": ["yes", "yes"]}}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "CLOSES IN THE PAST",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": "vote", "poll": {"options": ["yes", "no"], "closes_at": %q}}`, time.Now().Add(-time.Hour).Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestGetPostWithPoll(t *testing.T) {
+	_, user := RandomUser(t)
+	post := RandomPost(t, uuid.New())
+	poll, options := randomPoll(post.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "RESULTS HIDDEN",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Eq(db.ListPollsParams{ViewerID: user.ID, PostIds: []uuid.UUID{post.ID}})).
+					Times(1).Return([]db.ListPollsRow{poll}, nil)
+				store.EXPECT().ListPollOptions(gomock.Any(), gomock.Eq(db.ListPollOptionsParams{ViewerID: user.ID, PostIds: []uuid.UUID{post.ID}})).
+					Times(1).Return(options, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data postResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, post.ID, resp.Data.ID)
+				require.NotNil(t, resp.Data.Poll)
+				require.Len(t, resp.Data.Poll.Options, 2)
+				require.Nil(t, resp.Data.Poll.Options[0].Votes)
+				require.NotContains(t, rec.Body.String(), `"votes"`)
+			},
+		},
+		{
+			name:   "NO POLL",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().ListPollOptions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), `"poll"`)
+			},
+		},
+	})
+}
+
+func TestVotePoll(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, uuid.New())
+	poll, options := randomPoll(post.ID)
+	url := fmt.Sprintf("/api/v1/posts/%s/poll/votes", post.ID)
+	single := fmt.Sprintf(`{"option_ids": [%q]}`, options[0].ID)
+	both := fmt.Sprintf(`{"option_ids": [%q, %q]}`, options[0].ID, options[1].ID)
+
+	multiple := poll
+	multiple.Multiple = true
+	closed := poll
+	closed.Closed = true
+	voted := poll
+	voted.Voted = true
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     url,
+			payload: both,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				gomock.InOrder(
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{multiple}, nil),
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{voted}, nil),
+				)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Eq(db.CastPollBallotParams{
+					PostID:         post.ID,
+					UserIdentityID: identity.ID,
+					UserID:         user.ID,
+					OptionIds:      []uuid.UUID{options[0].ID, options[1].ID},
+				})).Times(1).Return(int64(2), nil)
+				store.EXPECT().ListPollOptions(gomock.Any(), gomock.Any()).Times(1).Return(options, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data pollResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.True(t, resp.Data.Voted)
+				require.NotNil(t, resp.Data.Options[0].Votes)
+			},
+		},
+		{
+			name:    "SEVERAL OPTIONS IN A SINGLE CHOICE POLL",
+			method:  http.MethodPost,
+			url:     url,
+			payload: both,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{poll}, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "CLOSED",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{closed}, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "CLOSED WHILE VOTING",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				gomock.InOrder(
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{poll}, nil),
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{closed}, nil),
+				)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "VOTED UNDER ANOTHER IDENTITY",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{voted}, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:    "VOTED UNDER ANOTHER IDENTITY WHILE VOTING",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				gomock.InOrder(
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{poll}, nil),
+					store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{voted}, nil),
+				)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:    "ALREADY VOTED",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{poll}, nil)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, rec.Code)
+			},
+		},
+		{
+			name:    "OPTION OF ANOTHER POLL",
+			method:  http.MethodPost,
+			url:     url,
+			payload: fmt.Sprintf(`{"option_ids": [%q]}`, uuid.New()),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{poll}, nil)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), &pq.Error{Code: "23503"})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "NO POLL",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+				store.EXPECT().CastPollBallotTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:    "POST NOT FOUND",
+			method:  http.MethodPost,
+			url:     url,
+			payload: single,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:    "NO OPTIONS",
+			method:  http.MethodPost,
+			url:     url,
+			payload: `{"option_ids": []}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
@@ -6,16 +6,43 @@ import (
 	"database/sql"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	postSortNew           = "new"
+	postSortHot           = "hot"
+	postSortTop           = "top"
+	postSortControversial = "controversial"
+)
+
+// the number of days in each window of the top and controversial sorts, 0 is all time
+var postWindows = map[string]int32{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+	"year":  365,
+	"all":   0,
+}
+
 type (
 	// swagger:model
 	createPostRequest struct {
-		Content        string    `json:"content" validate:"required,max=10000"`
-		UserIdentityId uuid.UUID `json:"user_identity_id" validate:"required"`
+		Content string `json:"content" validate:"required,max=10000"`
+		// the identity to post under, defaults to the user's default identity
+		UserIdentityId uuid.UUID `json:"user_identity_id"`
+		// keep the post as a draft only the user can see
+		Draft bool `json:"draft"`
+		// publish the post at this time instead of now, it has to be in the future
+		PublishAt *time.Time `json:"publish_at"`
+		// attach a poll to the post
+		Poll *createPollRequest `json:"poll"`
+		// warn viewers of what the post is about, they choose to hide, blur or show each warning
+		ContentWarnings []string `json:"content_warnings" validate:"max=8,unique,dive,content_warning"`
+		Nsfw            bool     `json:"nsfw"`
 	}
 
 	// swagger:model
@@ -30,7 +57,13 @@ func (s *Server) listAllPosts(c echo.Context) error {
 	// swagger:operation GET /posts posts listAllPosts
 	// ---
 	// summary: List all posts
-	// description: List all posts
+	// description: List all posts, newest first by default.
+	//   hot favours recent posts with many likes and comments, top the most liked posts of the window
+	//   and controversial the posts of the window with the most evenly split likes and dislikes.
+	//   The hot, top and controversial scores are refreshed periodically, not on every reaction.
+	//   Signed in users don't see posts they blocked or muted.
+	//   The results of polls are left out until the viewer voted or the poll closed.
+	//   Posts of the content categories the viewer hides are left out, blur is set on those they blur.
 	// parameters:
 	// - name: page
 	//   in: query
@@ -38,6 +71,16 @@ func (s *Server) listAllPosts(c echo.Context) error {
 	//   required: false
 	//   type: integer
 	//   format: int32
+	// - name: sort
+	//   in: query
+	//   description: new, hot, top or controversial, defaults to new
+	//   required: false
+	//   type: string
+	// - name: window
+	//   in: query
+	//   description: day, week, month, year or all, only used by top and controversial, defaults to week
+	//   required: false
+	//   type: string
 	// responses:
 	//   '200':
 	//     description: OK
@@ -64,12 +107,57 @@ func (s *Server) listAllPosts(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
 
-	posts, err := s.store.ListAllPosts(c.Request().Context(), int32(pageInt*10))
+	window := c.QueryParam("window")
+	if window == "" {
+		window = "week"
+	}
+
+	days, ok := postWindows[window]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, newError("window must be day, week, month, year or all"))
+	}
+
+	ctx := c.Request().Context()
+	offset := int32(pageInt * 10)
+
+	var posts []db.Post
+
+	switch c.QueryParam("sort") {
+	case "", postSortNew:
+		posts, err = s.store.ListAllPosts(ctx, db.ListAllPostsParams{
+			ViewerID:   viewerId(c),
+			PageOffset: offset,
+		})
+	case postSortHot:
+		posts, err = s.store.ListHotPosts(ctx, db.ListHotPostsParams{
+			ViewerID:   viewerId(c),
+			PageOffset: offset,
+		})
+	case postSortTop:
+		posts, err = s.store.ListTopPosts(ctx, db.ListTopPostsParams{
+			Days:       days,
+			ViewerID:   viewerId(c),
+			PageOffset: offset,
+		})
+	case postSortControversial:
+		posts, err = s.store.ListControversialPosts(ctx, db.ListControversialPostsParams{
+			Days:       days,
+			ViewerID:   viewerId(c),
+			PageOffset: offset,
+		})
+	default:
+		return c.JSON(http.StatusBadRequest, newError("sort must be new, hot, top or controversial"))
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, newError(err.Error()))
 	}
 
-	return c.JSON(http.StatusOK, newResponse(posts))
+	resp, err := s.postResponses(ctx, viewerId(c), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
 }
 
 // get a post by id
@@ -78,7 +166,8 @@ func (s *Server) getPostById(c echo.Context) error {
 	// swagger:operation GET /posts/{id} posts getPostById
 	// ---
 	// summary: Get a post by id
-	// description: Get a post by id
+	// description: Get a post by id, with its poll. The results of the poll are left out until the viewer voted or the poll closed.
+	//   blur is set when the viewer hides or blurs one of the content categories of the post.
 	// parameters:
 	// - name: id
 	//   in: path
@@ -121,7 +210,12 @@ func (s *Server) getPostById(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, newError(err.Error()))
 	}
 
-	return c.JSON(http.StatusOK, newResponse(post))
+	resp, err := s.postResponses(c.Request().Context(), viewerId(c), []db.Post{post})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp[0]))
 }
 
 // create a new post
@@ -130,7 +224,9 @@ func (s *Server) createNewPost(c echo.Context) error {
 	// swagger:operation POST /posts posts createNewPost
 	// ---
 	// summary: Create a new post
-	// description: Create a new post
+	// description: Create a new post. The #hashtags in the content are indexed for the tag pages.
+	//   The post can also be kept as a draft, or scheduled to be published at a later time, and it can carry a poll.
+	//   Content warnings and the nsfw flag let viewers hide or blur the post.
 	// parameters:
 	// - name: body
 	//   in: body
@@ -164,11 +260,51 @@ func (s *Server) createNewPost(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, err)
 	}
 
-	post, err := s.store.CreatePost(c.Request().Context(), db.CreatePostParams{
-		ID:             uuid.New(),
-		Content:        req.Content,
-		UserIdentityID: req.UserIdentityId,
-	})
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	if req.Draft && req.PublishAt != nil {
+		return c.JSON(http.StatusBadRequest, newError("a post is either a draft or scheduled"))
+	}
+
+	status := postStatusPublished
+	if req.Draft || req.PublishAt != nil {
+		var err error
+		if status, err = unpublishedStatus(req.PublishAt); err != nil {
+			return c.JSON(http.StatusBadRequest, newError(err.Error()))
+		}
+	}
+
+	if req.Poll != nil && req.Poll.ClosesAt != nil && !req.Poll.ClosesAt.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, newError(errClosesAtInPast.Error()))
+	}
+
+	userIdentity, err := s.postingIdentity(c.Request().Context(), tokenPayload.UserId, req.UserIdentityId)
+	if err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	arg := db.CreatePostTxParams{
+		CreatePostParams: db.CreatePostParams{
+			ID:              uuid.New(),
+			Content:         req.Content,
+			UserIdentityID:  userIdentity.ID,
+			Status:          status,
+			PublishAt:       req.PublishAt,
+			ContentWarnings: contentWarningsOf(req.ContentWarnings),
+			Nsfw:            req.Nsfw,
+		},
+		Tags: parseHashtags(req.Content),
+	}
+	if req.Poll != nil {
+		arg.Poll = &db.CreatePollParams{Multiple: req.Poll.Multiple, ClosesAt: req.Poll.ClosesAt}
+		arg.PollOptions = req.Poll.Options
+	}
+
+	// the post is created with its tags and poll or not at all, so a failed request can be retried
+	post, err := s.store.CreatePostTx(c.Request().Context(), arg)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusNotFound, NOT_FOUND)
@@ -185,7 +321,8 @@ func (s *Server) updatePost(c echo.Context) error {
 	// swagger:operation PATCH /posts/{id} posts updatePost
 	// ---
 	// summary: Update a post
-	// description: Update a post
+	// description: Update a post, the tag index follows the #hashtags of the new content.
+	//   The previous content stays available in the revisions of the post.
 	// parameters:
 	// - name: id
 	//   in: path
@@ -238,14 +375,6 @@ func (s *Server) updatePost(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
-	userIdentity, err := s.store.GetUserIdentityByUserId(c.Request().Context(), tokenPayload.UserId)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, NOT_FOUND)
-		}
-		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-	}
-
 	// get the post
 	post, err := s.store.GetPostById(c.Request().Context(), id)
 	if err != nil {
@@ -255,14 +384,19 @@ func (s *Server) updatePost(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	// check if the user is the owner of the post
-	if post.UserIdentityID != userIdentity.ID {
-		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	// check if the user is the owner of the post, under any of their identities
+	editor, err := s.ownedIdentity(c.Request().Context(), tokenPayload.UserId, post.UserIdentityID)
+	if err != nil {
+		return identityErrorResponse(c, err)
 	}
 
-	updatedPost, err := s.store.UpdatePost(c.Request().Context(), db.UpdatePostParams{
-		ID:      id,
-		Content: req.Content,
+	updatedPost, err := s.store.UpdatePostTx(c.Request().Context(), db.UpdatePostTxParams{
+		UpdatePostParams: db.UpdatePostParams{
+			ID:               id,
+			Content:          req.Content,
+			EditorIdentityID: editor.ID,
+		},
+		Tags: parseHashtags(req.Content),
 	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, newError(err.Error()))
@@ -277,7 +411,7 @@ func (s *Server) deletePost(c echo.Context) error {
 	// swagger:operation DELETE /posts/{id} posts deletePost
 	// ---
 	// summary: Delete a post
-	// description: Delete a post
+	// description: Move a post to the trash, it can be restored until it is purged after the retention period
 	// parameters:
 	// - name: id
 	//   in: path
@@ -316,14 +450,6 @@ func (s *Server) deletePost(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
-	userIdentity, err := s.store.GetUserIdentityByUserId(c.Request().Context(), tokenPayload.UserId)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return c.JSON(http.StatusNotFound, NOT_FOUND)
-		}
-		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-	}
-
 	// get the post
 	post, err := s.store.GetPostById(c.Request().Context(), postId)
 	if err != nil {
@@ -333,14 +459,17 @@ func (s *Server) deletePost(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	// check if the post belongs to the user
-	if post.UserIdentityID != userIdentity.ID {
-		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	// check if the post belongs to the user, under any of their identities
+	if _, err := s.ownedIdentity(c.Request().Context(), tokenPayload.UserId, post.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
 	}
 
-	// delete the post
+	// move the post to the trash, its images are kept until the post is purged
 	deletedPost, err := s.store.DeletePost(c.Request().Context(), postId)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
@@ -25,7 +25,8 @@ func TestListAllPublicPost(t *testing.T) {
 			name:    "OK - with page 0",
 			payload: "0",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Eq(int32(0))).Times(1).Return(dummyPosts, nil)
+				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Eq(db.ListAllPostsParams{PageOffset: 0})).Times(1).Return(dummyPosts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -41,7 +42,8 @@ func TestListAllPublicPost(t *testing.T) {
 			name:    "OK - with page > 0",
 			payload: "1",
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Eq(int32(10))).Times(1).Return(dummyPosts, nil)
+				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Eq(db.ListAllPostsParams{PageOffset: 10})).Times(1).Return(dummyPosts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -102,10 +104,11 @@ type eqCreatePostParamsMatcher struct {
 }
 
 func (m *eqCreatePostParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreatePostParams)
+	tx, ok := x.(db.CreatePostTxParams)
 	if !ok {
 		return false
 	}
+	arg := tx.CreatePostParams
 
 	m.arg = arg
 	m.Id = arg.ID
@@ -124,6 +127,8 @@ func EqCreatePostParams(arg db.CreatePostParams) gomock.Matcher {
 func TestCreateNewPost(t *testing.T) {
 	_, user := RandomUser(t)
 	identityId := uuid.New()
+	identity := RandomUserIdentity(t, user.ID)
+	identity.ID = identityId
 	post := RandomPost(t, identityId)
 
 	testCases := []testCase{
@@ -136,7 +141,26 @@ func TestCreateNewPost(t *testing.T) {
 					Content:        post.Content,
 					UserIdentityID: post.UserIdentityID,
 				}
-				store.EXPECT().CreatePost(gomock.Any(), EqCreatePostParams(arg)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identityId)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), EqCreatePostParams(arg)).Times(1).Return(post, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+				resp := new(response)
+
+				body, err := io.ReadAll(rec.Body)
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal(body, &resp))
+				require.NotNil(t, resp.Data)
+			},
+		},
+		{
+			name:    "OK - defaults to the default identity",
+			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).Return(post, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -152,7 +176,7 @@ func TestCreateNewPost(t *testing.T) {
 			name:    "NEGATIVE - MISSING CONTENT",
 			payload: fmt.Sprintf(`{"user_identity_id": %q}`, post.UserIdentityID),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreatePost(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -168,12 +192,8 @@ func TestCreateNewPost(t *testing.T) {
 			name:    "NEGATIVE - USER IDENTITY ID NOT FOUND",
 			payload: fmt.Sprintf(`{"content": %q, "user_identity_id": %q}`, post.Content, post.UserIdentityID),
 			buildStubs: func(store *mock.MockStore) {
-				arg := db.CreatePostParams{
-					ID:             post.ID,
-					Content:        post.Content,
-					UserIdentityID: post.UserIdentityID,
-				}
-				store.EXPECT().CreatePost(gomock.Any(), EqCreatePostParams(arg)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identityId)).Times(1).Return(db.UserIdentity{}, sql.ErrNoRows)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 404, rec.Code)
@@ -185,6 +205,34 @@ func TestCreateNewPost(t *testing.T) {
 				require.Nil(t, resp.Data)
 			},
 		},
+		{
+			name:    "NEGATIVE - IDENTITY OF ANOTHER USER",
+			payload: fmt.Sprintf(`{"content": %q, "user_identity_id": %q}`, post.Content, post.UserIdentityID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identityId)).Times(1).Return(db.UserIdentity{
+					ID:           identityId,
+					UserID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
+					IdentityHash: uuid.New(),
+				}, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
+		{
+			name:    "NEGATIVE - RETIRED IDENTITY",
+			payload: fmt.Sprintf(`{"content": %q, "user_identity_id": %q}`, post.Content, post.UserIdentityID),
+			buildStubs: func(store *mock.MockStore) {
+				retired := identity
+				retired.Retired = true
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identityId)).Times(1).Return(retired, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -223,6 +271,7 @@ func TestGetPostById(t *testing.T) {
 			name: "OK",
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetPostById(gomock.Any(), post.ID).Times(1).Return(post, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -292,18 +341,18 @@ func TestUpdateOnePost(t *testing.T) {
 			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
 			buildStubs: func(store *mock.MockStore) {
 				arg := db.UpdatePostParams{
-					ID:      post.ID,
-					Content: post.Content,
+					ID:               post.ID,
+					Content:          post.Content,
+					EditorIdentityID: post.UserIdentityID,
 				}
 
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Return(db.UserIdentity{
-					ID:           userIdentityId,
-					UserID:       user.ID,
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           post.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: user.ID, Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-
-				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Return(post, nil)
-				store.EXPECT().UpdatePost(gomock.Any(), gomock.Eq(arg)).Return(post.ID, nil)
+				store.EXPECT().UpdatePostTx(gomock.Any(), gomock.Eq(db.UpdatePostTxParams{UpdatePostParams: arg, Tags: []string{}})).Return(post.ID, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -313,14 +362,9 @@ func TestUpdateOnePost(t *testing.T) {
 			name:    "NEGATIVE - POST NOT FOUND",
 			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           userIdentityId,
-					UserID:       user.ID,
-					IdentityHash: uuid.New(),
-				}, nil)
-
 				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
-				store.EXPECT().UpdatePost(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdatePostTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 404, rec.Code)
@@ -330,13 +374,13 @@ func TestUpdateOnePost(t *testing.T) {
 			name:    "Unautorized",
 			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           uuid.New(),
-					UserID:       user.ID,
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           post.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
-				store.EXPECT().UpdatePost(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdatePostTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 401, rec.Code)
@@ -382,13 +426,14 @@ func TestDeletePost(t *testing.T) {
 			name:    "OK",
 			payload: "/api/v1/posts/" + post.ID.String(),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           userIdentityId,
-					UserID:       user.ID,
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           post.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: user.ID, Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-
-				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				// the images stay until the post is purged
+				store.EXPECT().ListMediaByPostId(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().DeletePost(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post.ID, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -399,13 +444,8 @@ func TestDeletePost(t *testing.T) {
 			name:    "NEGATIVE - POST NOT FOUND",
 			payload: "/api/v1/posts/" + post.ID.String(),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           userIdentityId,
-					UserID:       user.ID,
-					IdentityHash: uuid.New(),
-				}, nil)
-
 				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().DeletePost(gomock.Any(), gomock.Eq(post.ID)).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -416,12 +456,12 @@ func TestDeletePost(t *testing.T) {
 			name:    "Unautorized",
 			payload: "/api/v1/posts/" + post.ID.String(),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().GetUserIdentityByUserId(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(db.UserIdentity{
-					ID:           uuid.New(),
-					UserID:       user.ID,
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(db.UserIdentity{
+					ID:           post.UserIdentityID,
+					UserID:       uuid.NullUUID{UUID: uuid.New(), Valid: true},
 					IdentityHash: uuid.New(),
 				}, nil)
-				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
 				store.EXPECT().DeletePost(gomock.Any(), gomock.Eq(post.ID)).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"cnfs/config"
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"cnfs/webhook"
+	"cnfs/webpush"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// swagger:model
+	pushKeyResponse struct {
+		// the applicationServerKey browsers subscribe with, base64url encoded
+		PublicKey string `json:"public_key"`
+	}
+
+	// swagger:model
+	pushSubscriptionRequest struct {
+		// the subscription as PushSubscription.toJSON() returns it
+		Endpoint string `json:"endpoint" validate:"required,max=2048,startswith=https://"`
+		Keys     struct {
+			P256dh string `json:"p256dh" validate:"required"`
+			Auth   string `json:"auth" validate:"required"`
+		} `json:"keys"`
+	}
+
+	// swagger:model
+	pushUnsubscribeRequest struct {
+		Endpoint string `json:"endpoint" validate:"required"`
+	}
+)
+
+// newWebPushSender builds the sender of web push notifications, nil when no VAPID keys are configured.
+func newWebPushSender(cfg *config.Config) (*webpush.Sender, error) {
+	if cfg.VapidPublicKey == "" && cfg.VapidPrivateKey == "" {
+		return nil, nil
+	}
+
+	subject := cfg.VapidSubject
+	if subject == "" {
+		subject = cfg.ClientUrl
+	}
+
+	vapid, err := webpush.NewVAPID(cfg.VapidPublicKey, cfg.VapidPrivateKey, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webpush.Sender{
+		VAPID: vapid,
+		// subscriptions come from users like webhooks do, push services are never on our own network
+		Client: webhook.NewClient(cfg.WebPushTimeout, false),
+		TTL:    cfg.WebPushTTL,
+	}, nil
+}
+
+// sendWebPush sends the notification to the browsers the user subscribed, so they hear about it with the app
+// closed. The push services are called in the background rather than holding up the request, and subscriptions
+// they no longer know are removed.
+func (s *Server) sendWebPush(ctx context.Context, userId uuid.UUID, notification db.CreateNotificationRow) {
+	if s.webPush == nil {
+		return
+	}
+
+	subscriptions, err := s.store.ListPushSubscriptions(ctx, userId)
+	if err != nil {
+		log.Printf("cannot list the push subscriptions of %s: %v", userId, err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	// the same event the streams get, the service worker can skip it when a window of the app has focus
+	payload, err := json.Marshal(streamEvent{Type: eventTypeNotification, Data: notification})
+	if err != nil {
+		log.Printf("cannot encode web push for %s: %v", userId, err)
+		return
+	}
+
+	for _, sub := range subscriptions {
+		s.background.Add(1)
+		go func(sub db.PushSubscription) {
+			defer s.background.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.WebPushTimeout)
+			defer cancel()
+
+			err := s.webPush.Send(ctx, webpush.Subscription{Endpoint: sub.Endpoint, P256dh: sub.P256dh, Auth: sub.Auth}, payload)
+			switch {
+			case errors.Is(err, webpush.ErrGone):
+				if err := s.store.DeleteGonePushSubscription(ctx, sub.ID); err != nil {
+					log.Printf("cannot remove push subscription %s: %v", sub.ID, err)
+				}
+			case err != nil:
+				log.Printf("cannot send web push to subscription %s: %v", sub.ID, err)
+			}
+		}(sub)
+	}
+}
+
+// get the key browsers subscribe to web push with
+func (s *Server) getPushKey(c echo.Context) error {
+	// swagger:operation GET /push/key push getPushKey
+	// ---
+	// summary: Get the web push key of the server
+	// description: Get the VAPID public key to pass as applicationServerKey when subscribing the browser with
+	//   PushManager.subscribe. Not found when web push is not set up on the server.
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/pushKeyResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+
+	if s.webPush == nil {
+		return c.JSON(http.StatusNotFound, NOT_FOUND)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(pushKeyResponse{PublicKey: s.webPush.VAPID.PublicKey}))
+}
+
+// subscribe a browser to web push notifications
+func (s *Server) subscribePush(c echo.Context) error {
+	// swagger:operation POST /push/subscriptions push subscribePush
+	// ---
+	// summary: Subscribe a browser to web push
+	// description: Register the push subscription of a browser, it then gets the notifications of the user while
+	//   the app is closed. Subscribing an endpoint again updates its keys, and moves it over when another user
+	//   had it.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the push subscription
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pushSubscriptionRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/PushSubscription"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(pushSubscriptionRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	sub := webpush.Subscription{Endpoint: req.Endpoint, P256dh: req.Keys.P256dh, Auth: req.Keys.Auth}
+	if err := sub.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	subscription, err := s.store.CreatePushSubscription(c.Request().Context(), db.CreatePushSubscriptionParams{
+		ID:       uuid.New(),
+		UserID:   tokenPayload.UserId,
+		Endpoint: sub.Endpoint,
+		P256dh:   sub.P256dh,
+		Auth:     sub.Auth,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(subscription))
+}
+
+// unsubscribe a browser from web push notifications
+func (s *Server) unsubscribePush(c echo.Context) error {
+	// swagger:operation DELETE /push/subscriptions push unsubscribePush
+	// ---
+	// summary: Unsubscribe a browser from web push
+	// description: Remove the push subscription with the endpoint, as on logging out or PushSubscription.unsubscribe.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the endpoint of the subscription
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pushUnsubscribeRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(pushUnsubscribeRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	id, err := s.store.DeletePushSubscription(c.Request().Context(), db.DeletePushSubscriptionParams{
+		Endpoint: req.Endpoint,
+		UserID:   tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(id))
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"cnfs/config"
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"cnfs/webpush"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// pushConfig is the test config with web push set up.
+func pushConfig(t *testing.T) *config.Config {
+	public, private, err := webpush.GenerateVAPIDKeys()
+	require.NoError(t, err)
+
+	c := *cfg
+	c.VapidPublicKey = public
+	c.VapidPrivateKey = private
+	return &c
+}
+
+// RandomPushSubscription makes the subscription a browser would send, with valid keys.
+func RandomPushSubscription(t *testing.T, userId uuid.UUID, endpoint string) db.PushSubscription {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+
+	return db.PushSubscription{
+		ID:       uuid.New(),
+		UserID:   userId,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)),
+		Auth:     base64.RawURLEncoding.EncodeToString(auth),
+	}
+}
+
+func TestGetPushKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	server, err := NewServer(mock.NewMockStore(ctrl), cfg)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/push/key", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	c := pushConfig(t)
+	server, err = NewServer(mock.NewMockStore(ctrl), c)
+	require.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/push/key", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data pushKeyResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, c.VapidPublicKey, resp.Data.PublicKey)
+
+	c.VapidPublicKey = "not a key"
+	_, err = NewServer(mock.NewMockStore(ctrl), c)
+	require.Error(t, err)
+}
+
+func TestSubscribePush(t *testing.T) {
+	_, user := RandomUser(t)
+	sub := RandomPushSubscription(t, user.ID, "https://fcm.googleapis.com/fcm/send/abc")
+	payload := func(endpoint, p256dh, auth string) string {
+		return fmt.Sprintf(`{"endpoint": %q, "expirationTime": null, "keys": {"p256dh": %q, "auth": %q}}`, endpoint, p256dh, auth)
+	}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     "/api/v1/push/subscriptions",
+			payload: payload(sub.Endpoint, sub.P256dh, sub.Auth),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePushSubscription(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePushSubscriptionParams) (db.PushSubscription, error) {
+						require.NotEqual(t, uuid.Nil, arg.ID)
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, sub.Endpoint, arg.Endpoint)
+						require.Equal(t, sub.P256dh, arg.P256dh)
+						require.Equal(t, sub.Auth, arg.Auth)
+						return sub, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), sub.Auth)
+			},
+		},
+		{
+			name:    "NOT HTTPS",
+			method:  http.MethodPost,
+			url:     "/api/v1/push/subscriptions",
+			payload: payload("http://push.example/send", sub.P256dh, sub.Auth),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePushSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID KEY",
+			method:  http.MethodPost,
+			url:     "/api/v1/push/subscriptions",
+			payload: payload(sub.Endpoint, base64.RawURLEncoding.EncodeToString(make([]byte, 65)), sub.Auth),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePushSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "MISSING KEYS",
+			method:  http.MethodPost,
+			url:     "/api/v1/push/subscriptions",
+			payload: fmt.Sprintf(`{"endpoint": %q}`, sub.Endpoint),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreatePushSubscription(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestUnsubscribePush(t *testing.T) {
+	_, user := RandomUser(t)
+	endpoint := "https://updates.push.services.mozilla.com/wpush/v2/abc"
+	arg := db.DeletePushSubscriptionParams{Endpoint: endpoint, UserID: user.ID}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodDelete,
+			url:     "/api/v1/push/subscriptions",
+			payload: fmt.Sprintf(`{"endpoint": %q}`, endpoint),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeletePushSubscription(gomock.Any(), gomock.Eq(arg)).Times(1).Return(uuid.New(), nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "NOT FOUND",
+			method:  http.MethodDelete,
+			url:     "/api/v1/push/subscriptions",
+			payload: fmt.Sprintf(`{"endpoint": %q}`, endpoint),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeletePushSubscription(gomock.Any(), gomock.Eq(arg)).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestNotifyWebPush(t *testing.T) {
+	_, user := RandomUser(t)
+
+	// stands in for the push service of the browsers, one of which unsubscribed
+	var mu sync.Mutex
+	var pushed []string
+	service := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "vapid t="))
+
+		mu.Lock()
+		pushed = append(pushed, r.URL.Path)
+		mu.Unlock()
+
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+
+	active := RandomPushSubscription(t, user.ID, service.URL+"/active")
+	gone := RandomPushSubscription(t, user.ID, service.URL+"/gone")
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateNotificationRow{UserID: user.ID, Count: 1}, nil)
+	store.EXPECT().ListPushSubscriptions(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return([]db.PushSubscription{active, gone}, nil)
+	store.EXPECT().DeleteGonePushSubscription(gomock.Any(), gomock.Eq(gone.ID)).Times(1).Return(nil)
+
+	server, err := NewServer(store, pushConfig(t))
+	require.NoError(t, err)
+	server.webPush.Client = service.Client()
+
+	server.notify(context.Background(), user.ID, notificationTypeMessage, user.ID, uuid.New())
+	server.background.Wait()
+
+	require.ElementsMatch(t, []string{"/active", "/gone"}, pushed)
+}
+
+func TestNotifyWebPushDisabled(t *testing.T) {
+	_, user := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().CreateNotification(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateNotificationRow{}, nil)
+	store.EXPECT().ListPushSubscriptions(gomock.Any(), gomock.Any()).Times(0)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	server.notify(context.Background(), user.ID, notificationTypeMessage, user.ID, uuid.New())
+}
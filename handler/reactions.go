@@ -0,0 +1,246 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// swagger:model
+	reactionRequest struct {
+		// LIKE or DISLIKE
+		// required: true
+		Reaction db.Satisfaction `json:"reaction" validate:"required,oneof=LIKE DISLIKE"`
+	}
+
+	// swagger:model
+	reactionsResponse struct {
+		Likes    int64 `json:"likes"`
+		Dislikes int64 `json:"dislikes"`
+		// the reaction of the signed in user, if any
+		Reaction db.Satisfaction `json:"reaction,omitempty"`
+	}
+)
+
+// reactionsOf counts the reactions to a post, along with the reaction of userId unless it is uuid.Nil
+func (s *Server) reactionsOf(ctx context.Context, postId, userId uuid.UUID) (reactionsResponse, error) {
+	counts, err := s.store.CountPostReactions(ctx, postId)
+	if err != nil {
+		return reactionsResponse{}, err
+	}
+
+	resp := reactionsResponse{Likes: counts.Likes, Dislikes: counts.Dislikes}
+	if userId == uuid.Nil {
+		return resp, nil
+	}
+
+	reaction, err := s.store.GetPostReaction(ctx, db.GetPostReactionParams{PostID: postId, UserID: userId})
+	if err != nil && err != sql.ErrNoRows {
+		return reactionsResponse{}, err
+	}
+	resp.Reaction = reaction.Reaction
+
+	return resp, nil
+}
+
+// get the reactions to a post
+func (s *Server) getPostReactions(c echo.Context) error {
+	// swagger:operation GET /posts/{id}/reactions reactions getPostReactions
+	// ---
+	// summary: Get the reactions to a post
+	// description: Count the likes and dislikes of a post. Signed in users also get their own reaction,
+	//   who else reacted is never shown.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/reactionsResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	resp, err := s.reactionsOf(c.Request().Context(), postId, viewerId(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// like or dislike a post
+func (s *Server) reactToPost(c echo.Context) error {
+	// swagger:operation PUT /posts/{id}/reaction reactions reactToPost
+	// ---
+	// summary: React to a post
+	// description: Like or dislike a post, replacing any earlier reaction of the user
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the reaction
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/reactionRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/reactionsResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	var data reactionRequest
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	post, err := s.store.GetPostById(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	_, err = s.store.SetPostReaction(ctx, db.SetPostReactionParams{
+		PostID:   postId,
+		UserID:   tokenPayload.UserId,
+		Reaction: data.Reaction,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if author := s.identityOwner(ctx, post.UserIdentityID, tokenPayload.UserId); author != uuid.Nil {
+		s.notify(ctx, author, notificationTypeReaction, post.ID, tokenPayload.UserId)
+		s.emitWebhookEvent(ctx, author, webhookEventPostReacted, postReactedData{PostID: post.ID, Reaction: data.Reaction})
+	}
+
+	resp, err := s.reactionsOf(ctx, postId, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// remove a reaction
+func (s *Server) deletePostReaction(c echo.Context) error {
+	// swagger:operation DELETE /posts/{id}/reaction reactions deletePostReaction
+	// ---
+	// summary: Remove a reaction
+	// description: Remove the reaction of the user to a post
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/reactionsResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '404':
+	//     description: No reaction to the post
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	_, err = s.store.DeletePostReaction(ctx, db.DeletePostReactionParams{
+		PostID: postId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.reactionsOf(ctx, postId, tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactToPost(t *testing.T) {
+	_, user := RandomUser(t)
+	post := RandomPost(t, uuid.New())
+	counts := db.CountPostReactionsRow{Likes: 3, Dislikes: 1}
+	author := RandomUserIdentity(t, uuid.New())
+	author.ID = post.UserIdentityID
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "LIKE",
+			method:  http.MethodPut,
+			url:     fmt.Sprintf("/api/v1/posts/%s/reaction", post.ID),
+			payload: `{"reaction": "LIKE"}`,
+			buildStubs: func(store *mock.MockStore) {
+				reaction := db.PostReaction{PostID: post.ID, UserID: user.ID, Reaction: db.SatisfactionLIKE}
+
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().SetPostReaction(gomock.Any(), gomock.Eq(db.SetPostReactionParams{
+					PostID:   post.ID,
+					UserID:   user.ID,
+					Reaction: db.SatisfactionLIKE,
+				})).Times(1).Return(reaction, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(post.UserIdentityID)).Times(1).Return(author, nil)
+				store.EXPECT().CreateNotification(gomock.Any(), EqNotification(author.UserID.UUID, notificationTypeReaction, post.ID, user.ID)).
+					Times(1).Return(db.CreateNotificationRow{}, nil)
+				store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{UserID: author.UserID.UUID, Event: webhookEventPostReacted})).
+					Times(1).Return(nil, nil)
+				store.EXPECT().CountPostReactions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(counts, nil)
+				store.EXPECT().GetPostReaction(gomock.Any(), gomock.Eq(db.GetPostReactionParams{PostID: post.ID, UserID: user.ID})).
+					Times(1).Return(reaction, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data reactionsResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, reactionsResponse{Likes: 3, Dislikes: 1, Reaction: db.SatisfactionLIKE}, resp.Data)
+			},
+		},
+		{
+			name:    "INVALID REACTION",
+			method:  http.MethodPut,
+			url:     fmt.Sprintf("/api/v1/posts/%s/reaction", post.ID),
+			payload: `{"reaction": "LOVE"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SetPostReaction(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "POST NOT FOUND",
+			method:  http.MethodPut,
+			url:     fmt.Sprintf("/api/v1/posts/%s/reaction", post.ID),
+			payload: `{"reaction": "DISLIKE"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+				store.EXPECT().SetPostReaction(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "REMOVE",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/posts/%s/reaction", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeletePostReaction(gomock.Any(), gomock.Eq(db.DeletePostReactionParams{PostID: post.ID, UserID: user.ID})).
+					Times(1).Return(post.ID, nil)
+				store.EXPECT().CountPostReactions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(counts, nil)
+				store.EXPECT().GetPostReaction(gomock.Any(), gomock.Any()).Times(1).Return(db.PostReaction{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data reactionsResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Empty(t, resp.Data.Reaction)
+			},
+		},
+		{
+			name:   "REMOVE WITHOUT REACTION",
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("/api/v1/posts/%s/reaction", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeletePostReaction(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListSortedPosts(t *testing.T) {
+	posts := []db.Post{RandomPost(t, uuid.New())}
+
+	testCases := []testCase{
+		{
+			name:    "HOT",
+			payload: "/api/v1/posts?sort=hot&page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListHotPosts(gomock.Any(), gomock.Eq(db.ListHotPostsParams{PageOffset: 10})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "TOP DEFAULTS TO A WEEK",
+			payload: "/api/v1/posts?sort=top",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTopPosts(gomock.Any(), gomock.Eq(db.ListTopPostsParams{Days: 7})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "CONTROVERSIAL OF ALL TIME",
+			payload: "/api/v1/posts?sort=controversial&window=all",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListControversialPosts(gomock.Any(), gomock.Eq(db.ListControversialPostsParams{Days: 0})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "NEW",
+			payload: "/api/v1/posts?sort=new",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Eq(db.ListAllPostsParams{})).Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID SORT",
+			payload: "/api/v1/posts?sort=best",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListAllPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID WINDOW",
+			payload: "/api/v1/posts?sort=top&window=decade",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTopPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "ANONYMOUS REACTIONS",
+			payload: fmt.Sprintf("/api/v1/posts/%s/reactions", posts[0].ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CountPostReactions(gomock.Any(), gomock.Eq(posts[0].ID)).Times(1).Return(db.CountPostReactionsRow{Likes: 1}, nil)
+				store.EXPECT().GetPostReaction(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.payload, nil)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
+
+func TestRefreshPostScores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().RefreshPostScores(gomock.Any(), gomock.Eq(db.RefreshPostScoresParams{
+		Gravity:       hotGravity,
+		WindowSeconds: hotWindow.Seconds(),
+	})).Times(1).Return(int64(12), nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, server.refreshPostScores(context.Background()))
+}
@@ -0,0 +1,398 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"cnfs/diff"
+	"cnfs/token"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// swagger:model
+type revisionDiffResponse struct {
+	From int32 `json:"from"`
+	To   int32 `json:"to"`
+	// the chunks that turn revision from into revision to
+	Chunks []diff.Chunk `json:"chunks"`
+}
+
+// canReadRevisions checks that the user owns the identity the content was written under, or is a moderator.
+// Revisions can hold what the author chose to take back, so nobody else gets to read them.
+func (s *Server) canReadRevisions(ctx context.Context, userId, identityId uuid.UUID) error {
+	_, err := s.ownedIdentity(ctx, userId, identityId)
+	if err == nil || !errors.Is(err, errNotIdentityOwner) {
+		return err
+	}
+
+	user, err := s.store.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsModerator {
+		return errNotIdentityOwner
+	}
+
+	return nil
+}
+
+// parseRevisionRange reads the from and to revisions to diff from the query
+func parseRevisionRange(c echo.Context) (int32, int32, error) {
+	from, err := strconv.ParseInt(c.QueryParam("from"), 10, 32)
+	if err != nil || from < 1 {
+		return 0, 0, errors.New("from must be a revision number")
+	}
+
+	to, err := strconv.ParseInt(c.QueryParam("to"), 10, 32)
+	if err != nil || to < 1 {
+		return 0, 0, errors.New("to must be a revision number")
+	}
+
+	return int32(from), int32(to), nil
+}
+
+// list the revisions of a post
+func (s *Server) listPostRevisions(c echo.Context) error {
+	// swagger:operation GET /posts/{id}/revisions posts listPostRevisions
+	// ---
+	// summary: List the revisions of a post
+	// description: List every version of a post, newest first. Only the author and moderators can see them, also once the post is deleted.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	post, err := s.store.GetPostByIdIncludingDeleted(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if err := s.canReadRevisions(ctx, tokenPayload.UserId, post.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	revisions, err := s.store.ListPostRevisions(ctx, postId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(revisions))
+}
+
+// diff two revisions of a post
+func (s *Server) diffPostRevisions(c echo.Context) error {
+	// swagger:operation GET /posts/{id}/revisions/diff posts diffPostRevisions
+	// ---
+	// summary: Diff two revisions of a post
+	// description: Compare two versions of a post word by word. Only the author and moderators can see them, also once the post is deleted.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: post id
+	//   required: true
+	//   type: string
+	// - name: from
+	//   in: query
+	//   description: the revision to compare from
+	//   required: true
+	//   type: integer
+	//   format: int32
+	// - name: to
+	//   in: query
+	//   description: the revision to compare to
+	//   required: true
+	//   type: integer
+	//   format: int32
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/revisionDiffResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	postId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	from, to, err := parseRevisionRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	post, err := s.store.GetPostByIdIncludingDeleted(ctx, postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if err := s.canReadRevisions(ctx, tokenPayload.UserId, post.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	fromRevision, err := s.store.GetPostRevision(ctx, db.GetPostRevisionParams{PostID: postId, Revision: from})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	toRevision, err := s.store.GetPostRevision(ctx, db.GetPostRevisionParams{PostID: postId, Revision: to})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(revisionDiffResponse{
+		From:   from,
+		To:     to,
+		Chunks: diff.Words(fromRevision.Content, toRevision.Content),
+	}))
+}
+
+// list the revisions of a comment
+func (s *Server) listCommentRevisions(c echo.Context) error {
+	// swagger:operation GET /comments/{id}/revisions comments listCommentRevisions
+	// ---
+	// summary: List the revisions of a comment
+	// description: List every version of a comment, newest first. Only the author and moderators can see them, also once the comment is deleted.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: comment id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	commentId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	comment, err := s.store.GetCommentIncludingDeleted(ctx, commentId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if err := s.canReadRevisions(ctx, tokenPayload.UserId, comment.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	revisions, err := s.store.ListCommentRevisions(ctx, commentId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(revisions))
+}
+
+// diff two revisions of a comment
+func (s *Server) diffCommentRevisions(c echo.Context) error {
+	// swagger:operation GET /comments/{id}/revisions/diff comments diffCommentRevisions
+	// ---
+	// summary: Diff two revisions of a comment
+	// description: Compare two versions of a comment word by word. Only the author and moderators can see them, also once the comment is deleted.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: comment id
+	//   required: true
+	//   type: string
+	// - name: from
+	//   in: query
+	//   description: the revision to compare from
+	//   required: true
+	//   type: integer
+	//   format: int32
+	// - name: to
+	//   in: query
+	//   description: the revision to compare to
+	//   required: true
+	//   type: integer
+	//   format: int32
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/revisionDiffResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	commentId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	from, to, err := parseRevisionRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	ctx := c.Request().Context()
+
+	comment, err := s.store.GetCommentIncludingDeleted(ctx, commentId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	if err := s.canReadRevisions(ctx, tokenPayload.UserId, comment.UserIdentityID); err != nil {
+		return identityErrorResponse(c, err)
+	}
+
+	fromRevision, err := s.store.GetCommentRevision(ctx, db.GetCommentRevisionParams{CommentID: commentId, Revision: from})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	toRevision, err := s.store.GetCommentRevision(ctx, db.GetCommentRevisionParams{CommentID: commentId, Revision: to})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(revisionDiffResponse{
+		From:   from,
+		To:     to,
+		Chunks: diff.Words(fromRevision.Content, toRevision.Content),
+	}))
+}
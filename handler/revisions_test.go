@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"cnfs/diff"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPostRevisions(t *testing.T) {
+	_, user := RandomUser(t)
+	_, author := RandomUser(t)
+	identity := RandomUserIdentity(t, author.ID)
+	post := RandomPost(t, identity.ID)
+	revisions := []db.PostRevision{
+		{PostID: post.ID, Revision: 2, UserIdentityID: identity.ID, Content: post.Content},
+		{PostID: post.ID, Revision: 1, UserIdentityID: identity.ID, Content: "before"},
+	}
+	url := fmt.Sprintf("/api/v1/posts/%s/revisions", post.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "MODERATOR",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				moderator := user
+				moderator.IsModerator = true
+
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(moderator, nil)
+				store.EXPECT().ListPostRevisions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(revisions, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "MODERATOR - DELETED POST",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				moderator := user
+				moderator.IsModerator = true
+				deleted := post
+				deleted.DeletedAt = &deleted.UpdatedAt
+
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(deleted, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(moderator, nil)
+				store.EXPECT().ListPostRevisions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(revisions, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "SOMEONE ELSE",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user, nil)
+				store.EXPECT().ListPostRevisions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, rec.Code)
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+
+	runIdentityTestCases(t, author, []identityTestCase{
+		{
+			name:   "AUTHOR",
+			method: http.MethodGet,
+			url:    url,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetUserById(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListPostRevisions(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(revisions, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	})
+}
+
+func TestDiffPostRevisions(t *testing.T) {
+	_, author := RandomUser(t)
+	identity := RandomUserIdentity(t, author.ID)
+	post := RandomPost(t, identity.ID)
+
+	runIdentityTestCases(t, author, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s/revisions/diff?from=1&to=2", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 1})).
+					Times(1).Return(db.PostRevision{Revision: 1, Content: "my boss is awful"}, nil)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 2})).
+					Times(1).Return(db.PostRevision{Revision: 2, Content: "my job is awful"}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data revisionDiffResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Equal(t, []diff.Chunk{
+					{Op: diff.Equal, Text: "my "},
+					{Op: diff.Delete, Text: "boss"},
+					{Op: diff.Insert, Text: "job"},
+					{Op: diff.Equal, Text: " is awful"},
+				}, resp.Data.Chunks)
+			},
+		},
+		{
+			name:   "MISSING REVISION",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s/revisions/diff?from=1&to=9", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 1})).
+					Times(1).Return(db.PostRevision{Revision: 1}, nil)
+				store.EXPECT().GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Revision: 9})).
+					Times(1).Return(db.PostRevision{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID RANGE",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/posts/%s/revisions/diff?from=0&to=2", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostByIdIncludingDeleted(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestCommentRevisions(t *testing.T) {
+	_, author := RandomUser(t)
+	identity := RandomUserIdentity(t, author.ID)
+	comment := RandomComment(t, uuid.New(), uuid.Nil)
+	comment.UserIdentityID = identity.ID
+
+	runIdentityTestCases(t, author, []identityTestCase{
+		{
+			name:   "LIST",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/comments/%s/revisions", comment.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetCommentIncludingDeleted(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().ListCommentRevisions(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return([]db.CommentRevision{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "DIFF",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/api/v1/comments/%s/revisions/diff?from=2&to=1", comment.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetCommentIncludingDeleted(gomock.Any(), gomock.Eq(comment.ID)).Times(1).Return(comment, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().GetCommentRevision(gomock.Any(), gomock.Eq(db.GetCommentRevisionParams{CommentID: comment.ID, Revision: 2})).
+					Times(1).Return(db.CommentRevision{Content: "after"}, nil)
+				store.EXPECT().GetCommentRevision(gomock.Any(), gomock.Eq(db.GetCommentRevisionParams{CommentID: comment.ID, Revision: 1})).
+					Times(1).Return(db.CommentRevision{Content: "before"}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+	})
+}
@@ -0,0 +1,377 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// matches the LIMIT of the search queries
+	searchPageSize = 20
+	// the longest search query accepted
+	maxSearchLength = 200
+
+	searchTypePosts    = "posts"
+	searchTypeComments = "comments"
+)
+
+var (
+	errEmptySearch   = errors.New("the query needs at least one word to search for")
+	errInvalidCursor = errors.New("invalid cursor")
+)
+
+// swagger:model
+type searchPostResponse struct {
+	postResponse
+	Rank float32 `json:"rank"`
+	// the matches marked with <mark></mark>
+	Snippet string `json:"snippet"`
+}
+
+// swagger:model
+type searchResponse struct {
+	// set when searching posts
+	Posts []searchPostResponse `json:"posts,omitempty"`
+	// set when searching comments
+	Comments []db.SearchCommentsRow `json:"comments,omitempty"`
+	// pass as cursor to get the next page, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// search posts or comments
+func (s *Server) search(c echo.Context) error {
+	// swagger:operation GET /search search search
+	// ---
+	// summary: Search posts and comments
+	// description: Full-text search over posts or comments, best matches first.
+	//   Words are stemmed, so "running" also finds "run". Wrap words in double quotes to search for a phrase,
+	//   end a word with * to match it as a prefix, start it with - to exclude it and put OR between words
+	//   to match either. Snippets mark the matches with <mark></mark>, the rest of the snippet is html escaped.
+	//   Signed in users don't see content they blocked or muted.
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: the search query
+	//   required: true
+	//   type: string
+	// - name: type
+	//   in: query
+	//   description: posts or comments, defaults to posts
+	//   required: false
+	//   type: string
+	// - name: identity_id
+	//   in: query
+	//   description: only return content of this identity
+	//   required: false
+	//   type: string
+	// - name: from
+	//   in: query
+	//   description: only return content created on or after this date, formatted as 2006-01-02
+	//   required: false
+	//   type: string
+	// - name: to
+	//   in: query
+	//   description: only return content created on or before this date, formatted as 2006-01-02
+	//   required: false
+	//   type: string
+	// - name: cursor
+	//   in: query
+	//   description: the next_cursor of the previous page
+	//   required: false
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/searchResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	q := c.QueryParam("q")
+	if len(q) > maxSearchLength {
+		return c.JSON(http.StatusBadRequest, newError("the query can't be longer than 200 characters"))
+	}
+
+	query, err := parseSearchQuery(q)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	searchType := c.QueryParam("type")
+	if searchType == "" {
+		searchType = searchTypePosts
+	}
+	if searchType != searchTypePosts && searchType != searchTypeComments {
+		return c.JSON(http.StatusBadRequest, newError("type must be posts or comments"))
+	}
+
+	var identityId uuid.NullUUID
+	if param := c.QueryParam("identity_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, newError(err.Error()))
+		}
+		identityId = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	from, err := parseDateParam(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError("from must be formatted as 2006-01-02"))
+	}
+
+	to, err := parseDateParam(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError("to must be formatted as 2006-01-02"))
+	}
+
+	cursorRank, cursorId, err := decodeSearchCursor(c.QueryParam("cursor"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	ctx := c.Request().Context()
+	var resp searchResponse
+
+	if searchType == searchTypePosts {
+		rows, err := s.store.SearchPosts(ctx, db.SearchPostsParams{
+			Query:       query,
+			IdentityID:  identityId,
+			CreatedFrom: from,
+			CreatedTo:   to,
+			ViewerID:    viewerId(c),
+			CursorID:    cursorId,
+			CursorRank:  cursorRank,
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+
+		resp.Posts, err = s.searchPostResponses(ctx, viewerId(c), rows)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+		if len(rows) == searchPageSize {
+			last := rows[len(rows)-1]
+			resp.NextCursor = encodeSearchCursor(last.Rank, last.ID)
+		}
+	} else {
+		resp.Comments, err = s.store.SearchComments(ctx, db.SearchCommentsParams{
+			Query:       query,
+			IdentityID:  identityId,
+			CreatedFrom: from,
+			CreatedTo:   to,
+			ViewerID:    viewerId(c),
+			CursorID:    cursorId,
+			CursorRank:  cursorRank,
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		}
+
+		for i := range resp.Comments {
+			resp.Comments[i].Snippet = escapeSnippet(resp.Comments[i].Snippet)
+		}
+		if len(resp.Comments) == searchPageSize {
+			last := resp.Comments[len(resp.Comments)-1]
+			resp.NextCursor = encodeSearchCursor(last.Rank, last.ID)
+		}
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// searchPostResponses goes through postResponses like any list of posts, so the found posts come with their
+// polls and are blurred as the viewer asks.
+func (s *Server) searchPostResponses(ctx context.Context, viewerId uuid.UUID, rows []db.SearchPostsRow) ([]searchPostResponse, error) {
+	posts := make([]db.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, db.Post{
+			ID:               row.ID,
+			Content:          row.Content,
+			UserIdentityID:   row.UserIdentityID,
+			CreatedAt:        row.CreatedAt,
+			UpdatedAt:        row.UpdatedAt,
+			Revision:         row.Revision,
+			Edited:           row.Edited,
+			DeletedAt:        row.DeletedAt,
+			Status:           row.Status,
+			PublishAt:        row.PublishAt,
+			ContentWarnings:  row.ContentWarnings,
+			Nsfw:             row.Nsfw,
+			FlagsModeratedAt: row.FlagsModeratedAt,
+		})
+	}
+
+	responses, err := s.postResponses(ctx, viewerId, posts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]searchPostResponse, 0, len(rows))
+	for i, row := range rows {
+		resp = append(resp, searchPostResponse{
+			postResponse: responses[i],
+			Rank:         row.Rank,
+			Snippet:      escapeSnippet(row.Snippet),
+		})
+	}
+	return resp, nil
+}
+
+// parseSearchQuery turns what users type into a tsquery.
+// Only letters and digits make it into the lexemes, so the result is always valid tsquery syntax.
+func parseSearchQuery(q string) (string, error) {
+	var (
+		out      strings.Builder
+		positive bool
+		or       bool
+	)
+
+	add := func(term string, negate bool) {
+		if out.Len() > 0 {
+			if or {
+				out.WriteString(" | ")
+			} else {
+				out.WriteString(" & ")
+			}
+		}
+		or = false
+
+		if negate {
+			out.WriteString("!(" + term + ")")
+			return
+		}
+		out.WriteString(term)
+		positive = true
+	}
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		negate := false
+		if q[0] == '-' {
+			negate = true
+			q = q[1:]
+		}
+
+		if q != "" && q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			var phrase string
+			if end < 0 {
+				phrase, q = q[1:], ""
+			} else {
+				phrase, q = q[1:end+1], q[end+2:]
+			}
+
+			if words := searchWords(phrase); len(words) > 0 {
+				add(strings.Join(words, " <-> "), negate)
+			}
+			continue
+		}
+
+		end := strings.IndexFunc(q, unicode.IsSpace)
+		if end < 0 {
+			end = len(q)
+		}
+		word := q[:end]
+		q = q[end:]
+
+		if word == "OR" && !negate {
+			or = out.Len() > 0
+			continue
+		}
+
+		words := searchWords(word)
+		if len(words) == 0 {
+			continue
+		}
+		if strings.HasSuffix(word, "*") {
+			words[len(words)-1] += ":*"
+		}
+		add(strings.Join(words, " <-> "), negate)
+	}
+
+	if !positive {
+		return "", errEmptySearch
+	}
+
+	return out.String(), nil
+}
+
+// searchWords splits s into its runs of letters and digits
+func searchWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// escapeSnippet html escapes a headline while keeping the <mark> tags postgres put around the matches
+func escapeSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(snippet, "&lt;/mark&gt;", "</mark>")
+}
+
+func parseDateParam(param string) (sql.NullTime, error) {
+	if param == "" {
+		return sql.NullTime{}, nil
+	}
+
+	date, err := time.Parse("2006-01-02", param)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+
+	return sql.NullTime{Time: date, Valid: true}, nil
+}
+
+// the cursor is the rank and id of the last result of a page
+func encodeSearchCursor(rank float32, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "," + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(cursor string) (sql.NullFloat64, uuid.NullUUID, error) {
+	if cursor == "" {
+		return sql.NullFloat64{}, uuid.NullUUID{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return sql.NullFloat64{}, uuid.NullUUID{}, errInvalidCursor
+	}
+
+	rankPart, idPart, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return sql.NullFloat64{}, uuid.NullUUID{}, errInvalidCursor
+	}
+
+	rank, err := strconv.ParseFloat(rankPart, 32)
+	if err != nil {
+		return sql.NullFloat64{}, uuid.NullUUID{}, errInvalidCursor
+	}
+
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return sql.NullFloat64{}, uuid.NullUUID{}, errInvalidCursor
+	}
+
+	return sql.NullFloat64{Float64: rank, Valid: true}, uuid.NullUUID{UUID: id, Valid: true}, nil
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	testCases := []struct {
+		query string
+		want  string
+		err   error
+	}{
+		{query: "cats", want: "cats"},
+		{query: "cats  dogs", want: "cats & dogs"},
+		{query: `"black cat" dogs`, want: "black <-> cat & dogs"},
+		{query: "prog*", want: "prog:*"},
+		{query: "cats -dogs", want: "cats & !(dogs)"},
+		{query: `cats -"hot dog"`, want: "cats & !(hot <-> dog)"},
+		{query: "cats OR dogs", want: "cats | dogs"},
+		{query: "e-mail", want: "e <-> mail"},
+		{query: `"unterminated phrase`, want: "unterminated <-> phrase"},
+		{query: "it's & (evil) | !", want: "it <-> s & evil"},
+		{query: "", err: errEmptySearch},
+		{query: "-cats", err: errEmptySearch},
+		{query: "&| !:*", err: errEmptySearch},
+	}
+
+	for _, tc := range testCases {
+		got, err := parseSearchQuery(tc.query)
+		require.Equal(t, tc.err, err, tc.query)
+		require.Equal(t, tc.want, got, tc.query)
+	}
+}
+
+func TestSearchCursor(t *testing.T) {
+	id := uuid.New()
+	rank := float32(0.1234567)
+
+	gotRank, gotId, err := decodeSearchCursor(encodeSearchCursor(rank, id))
+	require.NoError(t, err)
+	require.Equal(t, rank, float32(gotRank.Float64))
+	require.Equal(t, id, gotId.UUID)
+
+	_, _, err = decodeSearchCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+}
+
+func TestEscapeSnippet(t *testing.T) {
+	require.Equal(t,
+		"&lt;b&gt;big&lt;/b&gt; <mark>cats</mark> &amp; dogs",
+		escapeSnippet("<b>big</b> <mark>cats</mark> & dogs"))
+}
+
+func TestSearch(t *testing.T) {
+	identityId := uuid.New()
+
+	posts := make([]db.SearchPostsRow, searchPageSize)
+	for i := range posts {
+		posts[i] = db.SearchPostsRow{ID: uuid.New(), Rank: 0.5, Snippet: "<mark>cats</mark> <3"}
+	}
+	posts[1].Nsfw = true
+
+	testCases := []testCase{
+		{
+			name:    "OK",
+			payload: "/api/v1/search?q=cats",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Eq(db.SearchPostsParams{Query: "cats"})).
+					Times(1).Return(posts, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data searchResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data.Posts, searchPageSize)
+				require.Equal(t, "<mark>cats</mark> &lt;3", resp.Data.Posts[0].Snippet)
+				require.Equal(t, posts[0].ID, resp.Data.Posts[0].ID)
+				// flagged posts are blurred for anonymous viewers
+				require.False(t, resp.Data.Posts[0].Blur)
+				require.True(t, resp.Data.Posts[1].Blur)
+				require.Equal(t, encodeSearchCursor(0.5, posts[searchPageSize-1].ID), resp.Data.NextCursor)
+			},
+		},
+		{
+			name: "COMMENTS WITH FILTERS",
+			payload: "/api/v1/search?" + url.Values{
+				"q":           {`"black cat"`},
+				"type":        {"comments"},
+				"identity_id": {identityId.String()},
+				"from":        {"2023-01-02"},
+				"to":          {"2023-02-03"},
+				"cursor":      {encodeSearchCursor(0.25, identityId)},
+			}.Encode(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchComments(gomock.Any(), gomock.Eq(db.SearchCommentsParams{
+					Query:       "black <-> cat",
+					IdentityID:  uuid.NullUUID{UUID: identityId, Valid: true},
+					CreatedFrom: sql.NullTime{Time: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Valid: true},
+					CreatedTo:   sql.NullTime{Time: time.Date(2023, 2, 3, 0, 0, 0, 0, time.UTC), Valid: true},
+					CursorID:    uuid.NullUUID{UUID: identityId, Valid: true},
+					CursorRank:  sql.NullFloat64{Float64: 0.25, Valid: true},
+				})).Times(1).Return([]db.SearchCommentsRow{{ID: uuid.New()}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data searchResponse `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data.Comments, 1)
+				require.Empty(t, resp.Data.NextCursor)
+			},
+		},
+		{
+			name:    "EMPTY QUERY",
+			payload: "/api/v1/search?q=%26%7C",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID TYPE",
+			payload: "/api/v1/search?q=cats&type=users",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID DATE",
+			payload: "/api/v1/search?q=cats&from=yesterday",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "INVALID CURSOR",
+			payload: "/api/v1/search?q=cats&cursor=nope",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "500 internal server error",
+			payload: "/api/v1/search?q=cats",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchPosts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.payload, nil)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
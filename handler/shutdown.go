@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// serve runs the router on address along with the background jobs until ctx is cancelled or the router
+// fails, then shuts the server down within SHUTDOWN_TIMEOUT.
+func (s *Server) serve(ctx context.Context, address string) error {
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	s.startJobs(jobsCtx)
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- s.router.Start(address)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Println("-- Shutting down --")
+	case err = <-failed:
+		err = fmt.Errorf("cannot serve: %w", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := s.shutdown(shutdownCtx, stopJobs); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// shutdown stops the server in the order its parts depend on each other: the event streams are ended and
+// the requests in flight drained, then the jobs are stopped and the work they or the requests left in the
+// background waited for, and the database closed last. It gives up waiting when ctx is done, every step
+// is taken either way and the first failure returned.
+func (s *Server) shutdown(ctx context.Context, stopJobs context.CancelFunc) error {
+	var first error
+	failed := func(err error) {
+		log.Println(err.Error())
+		if first == nil {
+			first = err
+		}
+	}
+
+	// streams never end on their own, their clients reconnect to another instance
+	close(s.closing)
+
+	if err := s.router.Shutdown(ctx); err != nil {
+		failed(fmt.Errorf("cannot drain the requests: %w", err))
+	}
+
+	stopJobs()
+	if err := wait(ctx, &s.jobs); err != nil {
+		failed(fmt.Errorf("cannot stop the background jobs: %w", err))
+	}
+	if err := wait(ctx, &s.background); err != nil {
+		failed(fmt.Errorf("cannot finish the background work: %w", err))
+	}
+
+	if s.db != nil {
+		log.Println(poolStats(s.db.Stats()))
+		if err := s.db.Close(); err != nil {
+			failed(fmt.Errorf("cannot close the database: %w", err))
+		}
+	}
+
+	return first
+}
+
+// wait waits for wg until ctx is done.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"cnfs/db/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// startServing serves a server on the memory store on a free port, it shuts down when the returned function
+// is called and its error is sent on the channel.
+func startServing(t *testing.T, shutdownTimeout time.Duration) (*Server, string, func(), <-chan error) {
+	c := *cfg
+	c.DatabaseUrl = "memory://"
+	c.RealtimeBroker = "local"
+	c.ShutdownTimeout = shutdownTimeout
+
+	server, err := NewServer(memory.NewStore(), &c)
+	require.NoError(t, err)
+
+	ctx, stop := context.WithCancel(context.Background())
+	t.Cleanup(stop)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.serve(ctx, "127.0.0.1:0")
+	}()
+
+	require.Eventually(t, func() bool {
+		return server.router.ListenerAddr() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	return server, "http://" + server.router.ListenerAddr().String(), stop, done
+}
+
+func TestServeShutdown(t *testing.T) {
+	_, user := RandomUser(t)
+	server, url, stop, done := startServing(t, 5*time.Second)
+
+	res, err := http.Get(url + "/api/v1/events?access_token=" + accessToken(t, server, user))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	waitForSubscription(t, server, user.ID)
+
+	stop()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("the server did not shut down")
+	}
+
+	// the stream was ended rather than cut
+	_, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Zero(t, server.hub.Connections(user.ID))
+
+	_, err = http.Get(url + "/health")
+	require.Error(t, err)
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	server, _, stop, done := startServing(t, 50*time.Millisecond)
+
+	// work that never finishes
+	server.background.Add(1)
+	defer server.background.Done()
+
+	stop()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "background work")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the server waited past its timeout")
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	db "cnfs/db/sqlc"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// only the first tags of a post are indexed
+	maxPostTags = 10
+	// matches the length of tags.name
+	maxTagLength = 50
+	// the default and longest window for trending tags
+	defaultTrendingDays = 7
+	maxTrendingDays     = 30
+)
+
+// a # at the start or after anything that can't be part of a word, so urls#fragments and a#b are skipped
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_]+)`)
+
+// parseHashtags returns the distinct hashtags of content, lower case and without the #.
+// Tags made of digits only, like #1, are not tags.
+func parseHashtags(content string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(match[1])
+
+		if len([]rune(tag)) > maxTagLength || seen[tag] || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+		if len(tags) == maxPostTags {
+			break
+		}
+	}
+
+	return tags
+}
+
+// normalizeTag accepts tags with or without the leading #, in any case
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// list the posts with a tag
+func (s *Server) listPostsByTag(c echo.Context) error {
+	// swagger:operation GET /tags/{name}/posts tags listPostsByTag
+	// ---
+	// summary: List posts by tag
+	// description: List the posts with a hashtag, newest first.
+	//   Signed in users don't see posts they blocked or muted.
+	// parameters:
+	// - name: name
+	//   in: path
+	//   description: the tag, with or without the #
+	//   required: true
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int64
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tag := normalizeTag(c.Param("name"))
+	if tag == "" {
+		return c.JSON(http.StatusBadRequest, newError("invalid tag"))
+	}
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	posts, err := s.store.ListPostsByTag(c.Request().Context(), db.ListPostsByTagParams{
+		Tag:        tag,
+		ViewerID:   viewerId(c),
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp, err := s.postResponses(c.Request().Context(), viewerId(c), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(resp))
+}
+
+// autocomplete tags
+func (s *Server) searchTags(c echo.Context) error {
+	// swagger:operation GET /tags tags searchTags
+	// ---
+	// summary: Autocomplete tags
+	// description: List the most used tags starting with a prefix
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: the prefix, with or without the #
+	//   required: true
+	//   type: string
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	prefix := normalizeTag(c.QueryParam("q"))
+	if prefix == "" {
+		return c.JSON(http.StatusBadRequest, newError("q is required"))
+	}
+
+	if len([]rune(prefix)) > maxTagLength {
+		return c.JSON(http.StatusOK, newResponse([]db.SearchTagsRow{}))
+	}
+
+	// _ is a valid tag character but a LIKE wildcard
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+
+	tags, err := s.store.SearchTags(c.Request().Context(), escaped)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(tags))
+}
+
+// trending tags
+func (s *Server) listTrendingTags(c echo.Context) error {
+	// swagger:operation GET /tags/trending tags listTrendingTags
+	// ---
+	// summary: Trending tags
+	// description: List the tags used by the most posts over the last days
+	// parameters:
+	// - name: days
+	//   in: query
+	//   description: the number of days to look back, from 1 to 30, defaults to 7
+	//   required: false
+	//   type: integer
+	//   format: int32
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	days := defaultTrendingDays
+	if param := c.QueryParam("days"); param != "" {
+		var err error
+		days, err = strconv.Atoi(param)
+		if err != nil || days < 1 || days > maxTrendingDays {
+			return c.JSON(http.StatusBadRequest, newError("days must be between 1 and 30"))
+		}
+	}
+
+	tags, err := s.store.ListTrendingTags(c.Request().Context(), int32(days))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(tags))
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHashtags(t *testing.T) {
+	testCases := []struct {
+		content string
+		want    []string
+	}{
+		{content: "no tags here", want: []string{}},
+		{content: "#first post", want: []string{"first"}},
+		{content: "I hate #Mondays and #mondays, #work_life.", want: []string{"mondays", "work_life"}},
+		{content: "(#école) #日本", want: []string{"école", "日本"}},
+		{content: "number #1 and a#b and ##double", want: []string{}},
+		{content: "see example.com/#anchor or &#35;entity", want: []string{}},
+		{content: "#" + strings.Repeat("a", 51), want: []string{}},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, parseHashtags(tc.content), tc.content)
+	}
+
+	var many []string
+	for i := 0; i < maxPostTags+5; i++ {
+		many = append(many, fmt.Sprintf("#tag%d", i))
+	}
+	require.Len(t, parseHashtags(strings.Join(many, " ")), maxPostTags)
+}
+
+func TestCreatePostTags(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	post.Content = "new job #Work #life"
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "CREATE",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePostTxParams) (db.Post, error) {
+						require.Equal(t, []string{"work", "life"}, arg.Tags)
+						return post, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "UPDATE",
+			method:  http.MethodPatch,
+			url:     fmt.Sprintf("/api/v1/posts/%s", post.ID),
+			payload: `{"content": "quit #work"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetPostById(gomock.Any(), gomock.Eq(post.ID)).Times(1).Return(post, nil)
+				store.EXPECT().GetUserIdentityById(gomock.Any(), gomock.Eq(identity.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().UpdatePostTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdatePostTxParams) (uuid.UUID, error) {
+						require.Equal(t, []string{"work"}, arg.Tags)
+						return post.ID, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "TAGS FAIL",
+			method:  http.MethodPost,
+			url:     "/api/v1/posts",
+			payload: fmt.Sprintf(`{"content": %q}`, post.Content),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetDefaultUserIdentity(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(identity, nil)
+				store.EXPECT().CreatePostTx(gomock.Any(), gomock.Any()).Times(1).Return(db.Post{}, sql.ErrConnDone)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, rec.Code)
+			},
+		},
+	})
+}
+
+func TestTags(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:    "POSTS BY TAG",
+			payload: "/api/v1/tags/%23Work/posts?page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListPostsByTag(gomock.Any(), gomock.Eq(db.ListPostsByTagParams{Tag: "work", PageOffset: pageSize})).
+					Times(1).Return([]db.Post{RandomPost(t, uuid.New())}, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "AUTOCOMPLETE",
+			payload: "/api/v1/tags?q=%23Work_",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchTags(gomock.Any(), gomock.Eq(`work\_`)).
+					Times(1).Return([]db.SearchTagsRow{{Name: "work_life", PostCount: 3}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "AUTOCOMPLETE WITHOUT PREFIX",
+			payload: "/api/v1/tags",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchTags(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "TRENDING",
+			payload: "/api/v1/tags/trending",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTrendingTags(gomock.Any(), gomock.Eq(int32(defaultTrendingDays))).
+					Times(1).Return([]db.ListTrendingTagsRow{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "TRENDING INVALID WINDOW",
+			payload: "/api/v1/tags/trending?days=365",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTrendingTags(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.payload, nil)
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
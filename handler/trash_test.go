@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTrash(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	deletedAt := time.Now()
+	post := RandomPost(t, identity.ID)
+	post.DeletedAt = &deletedAt
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/trash?page=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTrashedPosts(gomock.Any(), gomock.Eq(db.ListTrashedPostsParams{UserID: user.ID, PageOffset: pageSize})).
+					Times(1).Return([]db.Post{post}, nil)
+				store.EXPECT().ListTrashedComments(gomock.Any(), gomock.Eq(db.ListTrashedCommentsParams{UserID: user.ID, PageOffset: pageSize})).
+					Times(1).Return([]db.Comment{}, nil)
+				store.EXPECT().ListTrashedMessages(gomock.Any(), gomock.Eq(db.ListTrashedMessagesParams{ReceiverID: user.ID, Offset: pageSize})).
+					Times(1).Return([]db.Message{}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID PAGE",
+			method: http.MethodGet,
+			url:    "/api/v1/trash?page=-1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListTrashedPosts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestRestore(t *testing.T) {
+	_, user := RandomUser(t)
+	identity := RandomUserIdentity(t, user.ID)
+	post := RandomPost(t, identity.ID)
+	comment := RandomComment(t, post.ID, uuid.Nil)
+	msg := RandomMessage(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "POST",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/posts/%s/restore", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RestorePost(gomock.Any(), gomock.Eq(db.RestorePostParams{ID: post.ID, UserID: user.ID})).
+					Times(1).Return(post, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "POST NOT IN TRASH",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/posts/%s/restore", post.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RestorePost(gomock.Any(), gomock.Any()).Times(1).Return(db.Post{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+		{
+			name:   "COMMENT",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/comments/%s/restore", comment.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RestoreComment(gomock.Any(), gomock.Eq(db.RestoreCommentParams{ID: comment.ID, UserID: user.ID})).
+					Times(1).Return(db.RestoreCommentRow{ID: comment.ID, Content: comment.Content, PostID: post.ID}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "MESSAGE",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/messages/%s/restore", msg.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RestoreMessage(gomock.Any(), gomock.Eq(db.RestoreMessageParams{ID: msg.ID, ReceiverID: user.ID})).
+					Times(1).Return(msg, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "INVALID ID",
+			method: http.MethodPost,
+			url:    "/api/v1/messages/abc/restore",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RestoreMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	retention := cfg.TrashRetention.Seconds()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().PurgeComments(gomock.Any(), gomock.Eq(retention)).Times(1).Return(int64(3), nil)
+	store.EXPECT().PurgePosts(gomock.Any(), gomock.Eq(retention)).Times(1).Return([]db.Media{}, nil)
+	store.EXPECT().PurgeMessages(gomock.Any(), gomock.Eq(retention)).Times(1).Return([]db.Media{}, nil)
+	store.EXPECT().PurgeUsers(gomock.Any()).Times(1).Return([]db.Media{}, nil)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, server.purgeTrash(context.Background()))
+}
+
+func TestPurgeTrashStopsOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().PurgeComments(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+	store.EXPECT().PurgePosts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().PurgeMessages(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().PurgeUsers(gomock.Any()).Times(0)
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	require.Error(t, server.purgeTrash(context.Background()))
+}
@@ -5,6 +5,7 @@ import (
 	db "cnfs/db/sqlc"
 	"cnfs/token"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 )
 
 type (
@@ -44,19 +46,58 @@ type (
 		// type: uuid
 		SessionId uuid.UUID `json:"session_id" validate:"required"`
 
-		// Field of the user in payload
+		// The new username, unchanged when omitted
 		//
-		// unique: true
 		// in: body
 		// type: string
-		Field string `json:"field" validate:"gte=8,required"`
+		Username *string `json:"username" validate:"omitempty,gte=8"`
 
-		// Payload of the user in payload
+		// The new password, unchanged when omitted
 		//
-		// unique: true
 		// in: body
 		// type: string
-		Payload string `json:"payload" validate:"gte=8,required"`
+		Password *string `json:"password" validate:"omitempty,gte=8"`
+	}
+
+	// swagger:model
+	updateProfileRequest struct {
+		// The name shown on the public profile
+		DisplayName *string `json:"display_name" validate:"omitempty,max=50"`
+		// A short bio
+		Bio *string `json:"bio" validate:"omitempty,max=500"`
+		// The url of the avatar image
+		AvatarUrl *string `json:"avatar_url" validate:"omitempty,url,max=2048"`
+		// The text shown to people sending a confession
+		ConfessionPrompt *string `json:"confession_prompt" validate:"omitempty,max=200"`
+		// Links shown on the public profile, an empty list clears them
+		Links *[]string `json:"links" validate:"omitempty,max=5,dive,url,max=2048"`
+	}
+
+	// swagger:model
+	userResponse struct {
+		ID               uuid.UUID `json:"id"`
+		Username         string    `json:"username"`
+		DisplayName      string    `json:"display_name"`
+		Bio              string    `json:"bio"`
+		AvatarUrl        string    `json:"avatar_url"`
+		ConfessionPrompt string    `json:"confession_prompt"`
+		Links            []string  `json:"links"`
+		CreatedAt        time.Time `json:"created_at"`
+		UpdatedAt        time.Time `json:"updated_at"`
+	}
+
+	// swagger:model
+	publicProfileResponse struct {
+		ID               uuid.UUID `json:"id"`
+		Username         string    `json:"username"`
+		DisplayName      string    `json:"display_name"`
+		Bio              string    `json:"bio"`
+		AvatarUrl        string    `json:"avatar_url"`
+		ConfessionPrompt string    `json:"confession_prompt"`
+		Links            []string  `json:"links"`
+		CreatedAt        time.Time `json:"created_at"`
+		// The posts made under the user's public identities
+		Posts []postResponse `json:"posts"`
 	}
 
 	// swagger:model
@@ -122,32 +163,30 @@ func (s *Server) createUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
 
-	createUserParam := db.CreateUserParams{
-		ID:        uuid.New(),
-		Username:  data.Username,
-		Password:  hashedPassword,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	user, err := s.store.CreateUser(c.Request().Context(), createUserParam)
+	result, err := s.store.CreateUserTx(c.Request().Context(), db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			ID:        uuid.New(),
+			Username:  data.Username,
+			Password:  hashedPassword,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		Identity: db.CreateUserIdentityParams{
+			ID:           uuid.New(),
+			IdentityHash: uuid.New(),
+			Name:         "default",
+			IsDefault:    true,
+		},
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "unique") {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
 			return c.JSON(http.StatusBadRequest, newError("user already exist"))
 		}
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	_, err = s.store.CreateUserIdentity(c.Request().Context(), db.CreateUserIdentityParams{
-		ID:           uuid.New(),
-		UserID:       user,
-		IdentityHash: uuid.New(),
-	})
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-	}
-
-	return c.JSON(http.StatusOK, newResponse(user))
+	return c.JSON(http.StatusOK, newResponse(result.UserID))
 }
 
 // List all users.
@@ -177,7 +216,7 @@ func (s *Server) listUsers(c echo.Context) error {
 	//	  schema:
 	//	     type: array
 	//		 	items:
-	//		 		"$ref": "#/definitions/User"
+	//		 		"$ref": "#/definitions/userResponse"
 	//  400:
 	//	  description: Bad request.
 	//	  schema:
@@ -203,7 +242,12 @@ func (s *Server) listUsers(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
 
-	return c.JSON(200, newResponse(users))
+	resp := make([]userResponse, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, newUserResponse(user))
+	}
+
+	return c.JSON(200, newResponse(resp))
 }
 
 // Get user by id.
@@ -232,7 +276,7 @@ func (s *Server) getUserById(c echo.Context) error {
 	//	  description: Success response with user information.
 	//	  schema:
 	//	     type: object
-	//		 	"$ref": "#/definitions/User"
+	//		 	"$ref": "#/definitions/userResponse"
 	//  400:
 	//	  description: Bad request.
 	//	  schema:
@@ -258,12 +302,77 @@ func (s *Server) getUserById(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	return c.JSON(200, newResponse(user))
+	return c.JSON(200, newResponse(newUserResponse(user)))
+}
+
+// newUserResponse leaves out the moderator status and the deletion state of the user, which aren't anybody
+// else's business.
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		ID:               user.ID,
+		Username:         user.Username,
+		DisplayName:      user.DisplayName,
+		Bio:              user.Bio,
+		AvatarUrl:        user.AvatarUrl,
+		ConfessionPrompt: user.ConfessionPrompt,
+		Links:            user.Links,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
 }
 
+// Get the public profile of a user by username.
 func (s *Server) getUserByUsername(c echo.Context) error {
+	// Get the public profile of a user.
+	// swagger:operation GET /users/one/{username} users getUserByUsername
+	//
+	// ---
+	//
+	// produces:
+	// - application/json
+	//
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: the username
+	//   required: true
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of the public posts
+	//   required: false
+	//   type: integer
+	//   format: int64
+	//
+	// responses:
+	//  200:
+	//	  description: The public profile and the posts made under public identities, without the posts the
+	//	    content preferences of a signed in viewer hide.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/publicProfileResponse"
+	//  400:
+	//	  description: Bad request.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/BadRequestResponse"
+	//  500:
+	//	  description: Internal error.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/InternalErrorResponse"
 	username := c.Param("username")
 
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
 	user, err := s.store.GetUserByUsername(c.Request().Context(), username)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -272,12 +381,38 @@ func (s *Server) getUserByUsername(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	return c.JSON(200, newResponse(user))
+	posts, err := s.store.ListPublicPostsByUserId(c.Request().Context(), db.ListPublicPostsByUserIdParams{
+		UserID:     user.ID,
+		ViewerID:   viewerId(c),
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	postResp, err := s.postResponses(c.Request().Context(), viewerId(c), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	resp := publicProfileResponse{
+		ID:               user.ID,
+		Username:         user.Username,
+		DisplayName:      user.DisplayName,
+		Bio:              user.Bio,
+		AvatarUrl:        user.AvatarUrl,
+		ConfessionPrompt: user.ConfessionPrompt,
+		Links:            user.Links,
+		CreatedAt:        user.CreatedAt,
+		Posts:            postResp,
+	}
+
+	return c.JSON(200, newResponse(resp))
 }
 
-// Update user by id.
+// Update the username and/or password of a user.
 func (s *Server) updateUser(c echo.Context) error {
-	// Update user by id.
+	// Update the username and/or password of a user, the session used is revoked.
 	// swagger:operation PATCH /users/{id} users updateUserById
 	//
 	// ---
@@ -343,6 +478,10 @@ func (s *Server) updateUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, err)
 	}
 
+	if data.Username == nil && data.Password == nil {
+		return c.JSON(http.StatusBadRequest, newError("i don't know what you want to update"))
+	}
+
 	tokenPayload, ok := c.Get("user").(*token.Payload)
 	if !ok {
 		return c.JSON(http.StatusUnauthorized, INVALID_TOKEN)
@@ -360,62 +499,145 @@ func (s *Server) updateUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	switch data.Field {
-	case "username":
-		updatedUserParam := db.UpdateUsernameParams{
-			Username:  data.Payload,
-			ID:        userId,
-			UpdatedAt: time.Now(),
-		}
-
-		user, err := s.store.UpdateUsername(c.Request().Context(), updatedUserParam)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-		}
+	arg := db.ChangePasswordTxParams{
+		UserID:    userId,
+		SessionID: data.SessionId,
+		Username:  common.NullString(data.Username),
+		UpdatedAt: time.Now(),
+	}
 
-		sessionRemoved, err := s.store.DeleteSession(c.Request().Context(), data.SessionId)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return c.JSON(http.StatusBadRequest, newError("session doesn't exist"))
-			}
-			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
-		}
+	var updated []string
+	if data.Username != nil {
+		updated = append(updated, "username")
+	}
 
-		return c.JSON(http.StatusOK, newResponse(fmt.Sprintf("user %s's username has been updated, session %s is deleted, please login again.", user, sessionRemoved)))
-	case "password":
-		hashedPassword, err := common.HashPassword(data.Payload)
+	if data.Password != nil {
+		hashedPassword, err := common.HashPassword(*data.Password)
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, newError(err.Error()))
 		}
+		arg.Password = sql.NullString{String: hashedPassword, Valid: true}
 
-		updateUserParam := db.UpdateUserPasswordParams{
-			ID:        userId,
-			Password:  hashedPassword,
-			UpdatedAt: time.Now(),
-		}
+		updated = append(updated, "password")
+	}
 
-		user, err := s.store.UpdateUserPassword(c.Request().Context(), updateUserParam)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	result, err := s.store.ChangePasswordTx(c.Request().Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusUnauthorized, newError("session expired, please login again"))
 		}
-
-		sessionRemoved, err := s.store.DeleteSession(c.Request().Context(), data.SessionId)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return c.JSON(http.StatusBadRequest, newError("session doesn't exist"))
-			}
-			return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return c.JSON(http.StatusBadRequest, newError("username already taken"))
 		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
 
-		return c.JSON(http.StatusOK, newResponse(fmt.Sprintf("user %s's password has been updated, session %s is deleted, please login again.", user, sessionRemoved)))
-	default:
-		return c.JSON(http.StatusBadRequest, newError("i don't know what you want to update"))
+	return c.JSON(http.StatusOK, newResponse(fmt.Sprintf("user %s's %s has been updated, session %s is deleted, please login again.", userId, strings.Join(updated, " and "), result.SessionID)))
+}
+
+// Update the profile of a user.
+func (s *Server) updateProfile(c echo.Context) error {
+	// Update the public profile of a user, omitted fields are left unchanged.
+	// swagger:operation PATCH /users/{id}/profile users updateProfile
+	//
+	// ---
+	// consumes:
+	// - application/json
+	//
+	// produces:
+	// - application/json
+	//
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: user id
+	//   required: true
+	//   type: string
+	//   format: uuid
+	// - name: body
+	//   in: body
+	//   description: profile fields
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/updateProfileRequest"
+	//
+	// security:
+	// - key: []
+	//
+	// responses:
+	//  200:
+	//	  description: The updated user.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/userResponse"
+	//  400:
+	//	  description: Bad request.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/BadRequestResponse"
+	//  401:
+	//	  description: Unauthorized.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/UnauthorizedResponse"
+	//  500:
+	//	  description: Internal error.
+	//	  schema:
+	//	     type: object
+	//		 	"$ref": "#/definitions/InternalErrorResponse"
+
+	userId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
 	}
+
+	var data updateProfileRequest
+
+	if err := c.Bind(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(&data); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, INVALID_TOKEN)
+	}
+
+	if tokenPayload.UserId != userId {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	arg := db.UpdateUserProfileParams{
+		DisplayName:      common.NullString(data.DisplayName),
+		Bio:              common.NullString(data.Bio),
+		AvatarUrl:        common.NullString(data.AvatarUrl),
+		ConfessionPrompt: common.NullString(data.ConfessionPrompt),
+		UpdatedAt:        time.Now(),
+		ID:               userId,
+	}
+	if data.Links != nil {
+		arg.Links = *data.Links
+	}
+
+	user, err := s.store.UpdateUserProfile(c.Request().Context(), arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(newUserResponse(user)))
 }
 
 // Delete user
 func (s *Server) deleteUser(c echo.Context) error {
-	// Delete one user by id
+	// Schedule the deletion of one user by id. The account is hidden and its sessions are revoked right away,
+	// it is purged once the grace period is over unless the user logs in again before then.
 	// swagger:operation DELETE /users/{id} users deleteUser
 	//
 	// ---
@@ -499,7 +721,10 @@ func (s *Server) deleteUser(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
 	}
 
-	user, err := s.store.DeleteOneUser(c.Request().Context(), userId)
+	deleteAfter, err := s.store.DeleteAccountTx(c.Request().Context(), db.DeleteOneUserParams{
+		ID:           userId,
+		GraceSeconds: s.cfg.AccountDeletionGracePeriod.Seconds(),
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
@@ -508,5 +733,8 @@ func (s *Server) deleteUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
 	}
 
-	return c.JSON(http.StatusOK, newResponse(fmt.Sprintf(`user %s has been deleted, all sessions has been revoked`, user)))
+	return c.JSON(http.StatusOK, newResponse(fmt.Sprintf(
+		`user %s will be deleted on %s, all sessions has been revoked, log in before then to cancel`,
+		userId, deleteAfter.Format(time.RFC3339),
+	)))
 }
@@ -6,7 +6,6 @@ import (
 	db "cnfs/db/sqlc"
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,10 +13,12 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,11 +28,18 @@ type eqCreateUserParamsMatcher struct {
 }
 
 func (e *eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+	txArg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
 
+	// the user is created with a default identity
+	if !txArg.Identity.IsDefault || txArg.Identity.ID == uuid.Nil || txArg.Identity.IdentityHash == uuid.Nil {
+		return false
+	}
+
+	arg := txArg.CreateUserParams
+
 	err := common.CheckPassword([]byte(arg.Password), []byte(e.password))
 	if err != nil {
 		return false
@@ -71,10 +79,9 @@ func TestCreateUser(t *testing.T) {
 
 				store.
 					EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
-					Return(user.ID, nil)
-				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(1)
+					Return(db.CreateUserTxResult{UserID: user.ID}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -91,7 +98,7 @@ func TestCreateUser(t *testing.T) {
 			name:    "Missing field",
 			payload: `{"password":"testpassword"}`,
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -110,7 +117,7 @@ func TestCreateUser(t *testing.T) {
 			name:    "No payload",
 			payload: ``,
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -137,8 +144,7 @@ func TestCreateUser(t *testing.T) {
 					UpdatedAt: user.UpdatedAt,
 				}
 
-				store.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).Times(1).Return(user.ID, errors.New("unique violation"))
-				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).Times(1).Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505", Constraint: "users_username_key"})
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -156,8 +162,7 @@ func TestCreateUser(t *testing.T) {
 			name:    "INTERNAL ERROR",
 			payload: fmt.Sprintf(`{"username": %q, "password": %q}`, user.Username, password),
 			buildStubs: func(store *mock.MockStore) {
-				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrConnDone)
-				store.EXPECT().CreateUserIdentity(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 500, rec.Code)
@@ -301,6 +306,7 @@ func TestListUsers(t *testing.T) {
 
 func TestGetUserById(t *testing.T) {
 	_, user := RandomUser(t)
+	user.IsModerator = true
 
 	testCases := []testCase{
 		{
@@ -319,6 +325,8 @@ func TestGetUserById(t *testing.T) {
 
 				require.NoError(t, json.Unmarshal(body, &resp))
 				require.NotNil(t, resp.Data)
+				require.NotContains(t, string(body), "is_moderator")
+				require.NotContains(t, string(body), "delete_after")
 			},
 		},
 		{
@@ -401,6 +409,8 @@ func TestGetUserById(t *testing.T) {
 
 func TestGetUserByUsername(t *testing.T) {
 	_, user := RandomUser(t)
+	nsfwPost := RandomPost(t, uuid.New())
+	nsfwPost.Nsfw = true
 
 	testCases := []testCase{
 		{
@@ -408,6 +418,11 @@ func TestGetUserByUsername(t *testing.T) {
 			payload: user.Username,
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ListPublicPostsByUserId(gomock.Any(), gomock.Eq(db.ListPublicPostsByUserIdParams{
+					UserID:     user.ID,
+					PageOffset: 0,
+				})).Times(1).Return([]db.Post{nsfwPost}, nil)
+				store.EXPECT().ListPolls(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListPollsRow{}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -419,6 +434,15 @@ func TestGetUserByUsername(t *testing.T) {
 
 				require.NoError(t, json.Unmarshal(body, &resp))
 				require.NotNil(t, resp.Data)
+
+				// only the safe fields of the user are exposed
+				profile, ok := resp.Data.(map[string]interface{})
+				require.True(t, ok)
+				require.NotContains(t, profile, "password")
+				require.NotContains(t, profile, "updated_at")
+				require.Len(t, profile["posts"], 1)
+				// flagged posts are blurred for anonymous viewers
+				require.Equal(t, true, profile["posts"].([]interface{})[0].(map[string]interface{})["blur"])
 			},
 		},
 		{
@@ -426,6 +450,7 @@ func TestGetUserByUsername(t *testing.T) {
 			payload: user.Username,
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().ListPublicPostsByUserId(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 400, rec.Code)
@@ -486,61 +511,38 @@ func TestGetUserByUsername(t *testing.T) {
 	}
 }
 
-type eqUpdateUserParamsMatcher struct {
-	arg      db.UpdateUserPasswordParams
+type eqChangePasswordTxParamsMatcher struct {
+	arg      db.ChangePasswordTxParams
 	password string
 }
 
-func (e *eqUpdateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.UpdateUserPasswordParams)
+func (e *eqChangePasswordTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.ChangePasswordTxParams)
 	if !ok {
 		return false
 	}
 
-	err := common.CheckPassword([]byte(arg.Password), []byte(e.password))
-	if err != nil {
+	// the password is hashed, and left unchanged when none is given
+	if e.password == "" {
+		if arg.Password.Valid {
+			return false
+		}
+	} else if !arg.Password.Valid || common.CheckPassword([]byte(arg.Password.String), []byte(e.password)) != nil {
 		return false
 	}
 
-	e.arg.ID = arg.ID
 	e.arg.Password = arg.Password
 	e.arg.UpdatedAt = arg.UpdatedAt
 
 	return reflect.DeepEqual(e.arg, arg)
 }
 
-func (e *eqUpdateUserParamsMatcher) String() string {
-	return fmt.Sprintf("matches arg %v and id %v", e.arg, e.password)
+func (e *eqChangePasswordTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
-func EqUpdateUserParams(arg *db.UpdateUserPasswordParams, password string) gomock.Matcher {
-	return &eqUpdateUserParamsMatcher{*arg, password}
-}
-
-type eqUpdateUsernameParamsMatcher struct {
-	arg      db.UpdateUsernameParams
-	password string
-}
-
-func (e *eqUpdateUsernameParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.UpdateUsernameParams)
-	if !ok {
-		return false
-	}
-
-	e.arg.ID = arg.ID
-	e.arg.Username = arg.Username
-	e.arg.UpdatedAt = arg.UpdatedAt
-
-	return reflect.DeepEqual(e.arg, arg)
-}
-
-func (e *eqUpdateUsernameParamsMatcher) String() string {
-	return fmt.Sprintf("matches arg %v and id %v", e.arg, e.password)
-}
-
-func EqUpdateUsernameParams(arg *db.UpdateUsernameParams, password string) gomock.Matcher {
-	return &eqUpdateUsernameParamsMatcher{*arg, password}
+func EqChangePasswordTxParams(arg db.ChangePasswordTxParams, password string) gomock.Matcher {
+	return &eqChangePasswordTxParamsMatcher{arg, password}
 }
 
 func TestUpdateUser(t *testing.T) {
@@ -552,17 +554,17 @@ func TestUpdateUser(t *testing.T) {
 	testCases := []testCase{
 		{
 			name:    "OK-Username",
-			payload: fmt.Sprintf(`{"field": "username", "payload": %q, "session_id": %q}`, newUsername, session_id),
+			payload: fmt.Sprintf(`{"username": %q, "session_id": %q}`, newUsername, session_id),
 			buildStubs: func(store *mock.MockStore) {
-				arg := db.UpdateUsernameParams{
-					ID:        user.ID,
-					Username:  newUsername,
-					UpdatedAt: user.UpdatedAt,
+				arg := db.ChangePasswordTxParams{
+					UserID:    user.ID,
+					SessionID: session_id,
+					Username:  sql.NullString{String: newUsername, Valid: true},
 				}
 
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Any()).Times(1)
-				store.EXPECT().UpdateUsername(gomock.Any(), EqUpdateUsernameParams(&arg, newPassword)).Times(1).Return(user.ID, nil)
-				store.EXPECT().DeleteSession(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), EqChangePasswordTxParams(arg, "")).Times(1).
+					Return(db.ChangePasswordTxResult{SessionID: session_id}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -579,19 +581,16 @@ func TestUpdateUser(t *testing.T) {
 		},
 		{
 			name:    "OK-Password",
-			payload: fmt.Sprintf(`{"field": "password", "payload": %q, "session_id": %q}`, newPassword, session_id),
+			payload: fmt.Sprintf(`{"password": %q, "session_id": %q}`, newPassword, session_id),
 			buildStubs: func(store *mock.MockStore) {
-				hashedPass, err := common.HashPassword(newPassword)
-				require.NoError(t, err)
-
-				arg := db.UpdateUserPasswordParams{
-					Password:  hashedPass,
-					ID:        user.ID,
-					UpdatedAt: user.UpdatedAt,
+				arg := db.ChangePasswordTxParams{
+					UserID:    user.ID,
+					SessionID: session_id,
 				}
+
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Any()).Times(1)
-				store.EXPECT().UpdateUserPassword(gomock.Any(), EqUpdateUserParams(&arg, newPassword)).Times(1).Return(user.ID, nil)
-				store.EXPECT().DeleteSession(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), EqChangePasswordTxParams(arg, newPassword)).Times(1).
+					Return(db.ChangePasswordTxResult{SessionID: session_id}, nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
@@ -606,9 +605,60 @@ func TestUpdateUser(t *testing.T) {
 				require.NotNil(t, resp.Data)
 			},
 		},
+		{
+			name:    "OK-Username and password",
+			payload: fmt.Sprintf(`{"username": %q, "password": %q, "session_id": %q}`, newUsername, newPassword, session_id),
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ChangePasswordTxParams{
+					UserID:    user.ID,
+					SessionID: session_id,
+					Username:  sql.NullString{String: newUsername, Valid: true},
+				}
+
+				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(session_id)).Times(1)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), EqChangePasswordTxParams(arg, newPassword)).Times(1).
+					Return(db.ChangePasswordTxResult{SessionID: session_id}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "Username taken",
+			payload: fmt.Sprintf(`{"username": %q, "session_id": %q}`, newUsername, session_id),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(session_id)).Times(1)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ChangePasswordTxResult{}, fmt.Errorf("tx err: %w", &pq.Error{Code: "23505", Constraint: "users_username_key"}))
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:    "Session gone during the change",
+			payload: fmt.Sprintf(`{"password": %q, "session_id": %q}`, newPassword, session_id),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(session_id)).Times(1)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ChangePasswordTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 401, rec.Code)
+			},
+		},
+		{
+			name:    "Username too short",
+			payload: fmt.Sprintf(`{"username": "short", "session_id": %q}`, session_id),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionById(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
 		{
 			name:    "Missing session ID",
-			payload: fmt.Sprintf(`{"field": "username", "payload": %q}`, newUsername),
+			payload: fmt.Sprintf(`{"username": %q}`, newUsername),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Any()).Times(0)
 			},
@@ -618,7 +668,7 @@ func TestUpdateUser(t *testing.T) {
 		},
 		{
 			name:    "Expired session",
-			payload: fmt.Sprintf(`{"field": "username", "payload": %q, "session_id": %q}`, newUsername, session_id),
+			payload: fmt.Sprintf(`{"username": %q, "session_id": %q}`, newUsername, session_id),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(session_id)).Times(1).Return(db.Session{}, sql.ErrNoRows)
 			},
@@ -627,8 +677,8 @@ func TestUpdateUser(t *testing.T) {
 			},
 		},
 		{
-			name:    "Unknown field",
-			payload: fmt.Sprintf(`{"field": "unknown", "payload": %q, "session_id": %q}`, newUsername, session_id),
+			name:    "Nothing to update",
+			payload: fmt.Sprintf(`{"session_id": %q}`, session_id),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(session_id)).Times(0)
 			},
@@ -670,6 +720,98 @@ func TestUpdateUser(t *testing.T) {
 	}
 }
 
+func TestUpdateProfile(t *testing.T) {
+	_, user := RandomUser(t)
+
+	testCases := []testCase{
+		{
+			name:    "OK",
+			payload: `{"display_name": "Jane", "bio": "tell me anything", "links": ["https://example.com"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ interface{}, arg db.UpdateUserProfileParams) (db.User, error) {
+					require.Equal(t, user.ID, arg.ID)
+					require.Equal(t, sql.NullString{String: "Jane", Valid: true}, arg.DisplayName)
+					require.Equal(t, sql.NullString{String: "tell me anything", Valid: true}, arg.Bio)
+					require.False(t, arg.AvatarUrl.Valid)
+					require.False(t, arg.ConfessionPrompt.Valid)
+					require.Equal(t, []string{"https://example.com"}, arg.Links)
+					return user, nil
+				})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+				require.NotContains(t, rec.Body.String(), "is_moderator")
+				require.NotContains(t, rec.Body.String(), "deleted_at")
+			},
+		},
+		{
+			name:    "OK - clear links",
+			payload: `{"links": []}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(_ interface{}, arg db.UpdateUserProfileParams) (db.User, error) {
+					require.NotNil(t, arg.Links)
+					require.Empty(t, arg.Links)
+					return user, nil
+				})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 200, rec.Code)
+			},
+		},
+		{
+			name:    "Invalid avatar url",
+			payload: `{"avatar_url": "not a url"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+		{
+			name:    "Too many links",
+			payload: `{"links": ["https://a.com", "https://b.com", "https://c.com", "https://d.com", "https://e.com", "https://f.com"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUserProfile(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, 400, rec.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, err := NewServer(store, cfg)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/v1/users/%s/profile", user.ID)
+
+			req := httptest.NewRequest(http.MethodPatch, url, strings.NewReader(tc.payload))
+
+			token, _, err := server.tokenMaker.CreateToken(user.ID, user.Username, cfg.AccessTokenDuration)
+			require.NoError(t, err)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, fmt.Sprintf("Bearer %s", token))
+
+			rec := httptest.NewRecorder()
+
+			server.router.ServeHTTP(rec, req)
+			tc.checkResponse(rec)
+		})
+	}
+}
+
 func TestDeleteUser(t *testing.T) {
 	_, user := RandomUser(t)
 	sessionId := uuid.New()
@@ -680,7 +822,10 @@ func TestDeleteUser(t *testing.T) {
 			payload: fmt.Sprintf(`{"session_id": %q}`, sessionId),
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetSessionById(gomock.Any(), gomock.Eq(sessionId)).Times(1)
-				store.EXPECT().DeleteOneUser(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(user.ID, nil)
+				store.EXPECT().DeleteAccountTx(gomock.Any(), gomock.Eq(db.DeleteOneUserParams{
+					ID:           user.ID,
+					GraceSeconds: cfg.AccountDeletionGracePeriod.Seconds(),
+				})).Times(1).Return(time.Now().Add(cfg.AccountDeletionGracePeriod), nil)
 			},
 			checkResponse: func(rec *httptest.ResponseRecorder) {
 				require.Equal(t, 200, rec.Code)
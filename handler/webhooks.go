@@ -0,0 +1,599 @@
+package handler
+
+import (
+	"cnfs/common"
+	db "cnfs/db/sqlc"
+	"cnfs/token"
+	"cnfs/webhook"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// a confession was sent to the user, the data is the message
+	webhookEventMessageCreated = "message.created"
+	// someone commented on a post of the user, the data is the comment
+	webhookEventCommentCreated = "comment.created"
+	// someone reacted to a post of the user, the data is the post and the reaction
+	webhookEventPostReacted = "post.reacted"
+)
+
+var webhookEvents = []string{
+	webhookEventMessageCreated,
+	webhookEventCommentCreated,
+	webhookEventPostReacted,
+}
+
+const (
+	// the number of deliveries sent per run of the delivery job
+	webhookBatchSize = 50
+	// how much of the error of a failed attempt is kept in the delivery log
+	maxWebhookError = 1024
+)
+
+type (
+	// swagger:model
+	createWebhookRequest struct {
+		// the http or https url the events are posted to
+		Url string `json:"url" validate:"required,max=2048,webhook_url"`
+		// the events to send, message.created, comment.created or post.reacted
+		Events []string `json:"events" validate:"required,min=1,unique,dive,webhook_event"`
+	}
+
+	// swagger:model
+	updateWebhookRequest struct {
+		Url *string `json:"url" validate:"omitempty,max=2048,webhook_url"`
+		// an empty list is rejected, a webhook without events would never be sent anything
+		Events []string `json:"events" validate:"omitempty,min=1,unique,dive,webhook_event"`
+		// inactive webhooks get no new events, their pending deliveries wait until they are active again
+		Active *bool `json:"active"`
+	}
+
+	// swagger:model
+	createWebhookResponse struct {
+		db.Webhook
+		// signs the deliveries, it is only shown once
+		Secret string `json:"secret"`
+	}
+
+	// swagger:model
+	webhookPayload struct {
+		// the same for every webhook the event went to, and kept on redelivery
+		ID        uuid.UUID   `json:"id"`
+		Event     string      `json:"event"`
+		CreatedAt time.Time   `json:"created_at"`
+		Data      interface{} `json:"data"`
+	}
+
+	// swagger:model
+	postReactedData struct {
+		PostID   uuid.UUID       `json:"post_id"`
+		Reaction db.Satisfaction `json:"reaction"`
+	}
+)
+
+// isWebhookEvent validates an event a webhook can subscribe to.
+func isWebhookEvent(fl validator.FieldLevel) bool {
+	for _, event := range webhookEvents {
+		if fl.Field().String() == event {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebhookUrl validates an absolute http or https url.
+func isWebhookUrl(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// emitWebhookEvent queues a delivery of the event to each webhook of the user subscribed to it. Like notify it
+// runs after the event happened, so failures are logged rather than failing the request.
+func (s *Server) emitWebhookEvent(ctx context.Context, userId uuid.UUID, event string, data interface{}) {
+	webhooks, err := s.store.ListWebhooksForEvent(ctx, db.ListWebhooksForEventParams{
+		UserID: userId,
+		Event:  event,
+	})
+	if err != nil {
+		log.Printf("cannot list the webhooks of %s for %s: %v", userId, event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:        uuid.New(),
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("cannot encode %s for the webhooks of %s: %v", event, userId, err)
+		return
+	}
+
+	for _, w := range webhooks {
+		_, err := s.store.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			ID:        uuid.New(),
+			WebhookID: w.ID,
+			Event:     event,
+			Payload:   payload,
+		})
+		if err != nil {
+			log.Printf("cannot queue %s for webhook %s: %v", event, w.ID, err)
+		}
+	}
+}
+
+// deliverWebhooks sends the due deliveries, each to its own endpoint at the same time.
+func (s *Server) deliverWebhooks(ctx context.Context) error {
+	deliveries, err := s.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+		// long enough for the attempt to finish before anyone else picks the delivery up
+		LeaseSeconds: (2 * s.cfg.WebhookTimeout).Seconds(),
+		BatchSize:    webhookBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		go func(d db.ClaimWebhookDeliveriesRow) {
+			defer wg.Done()
+			s.deliverWebhook(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliverWebhook makes one attempt at a delivery and records how it went, a failed attempt is retried after
+// a backoff that doubles with every attempt until the delivery runs out of them.
+func (s *Server) deliverWebhook(ctx context.Context, d db.ClaimWebhookDeliveriesRow) {
+	status, err := webhook.Send(ctx, s.webhooks, webhook.Delivery{
+		ID:      d.ID,
+		Event:   d.Event,
+		URL:     d.Url,
+		Secret:  d.Secret,
+		Payload: d.Payload,
+	})
+	if err == nil {
+		err := s.store.CompleteWebhookDelivery(ctx, db.CompleteWebhookDeliveryParams{
+			ID:             d.ID,
+			ResponseStatus: sql.NullInt32{Int32: int32(status), Valid: true},
+		})
+		if err != nil {
+			log.Printf("cannot complete webhook delivery %s: %v", d.ID, err)
+		}
+		return
+	}
+
+	message := err.Error()
+	if len(message) > maxWebhookError {
+		message = message[:maxWebhookError]
+	}
+
+	err = s.store.FailWebhookDelivery(ctx, db.FailWebhookDeliveryParams{
+		ID:             d.ID,
+		ResponseStatus: sql.NullInt32{Int32: int32(status), Valid: status != 0},
+		Error:          message,
+		MaxAttempts:    s.cfg.WebhookMaxAttempts,
+		RetrySeconds:   webhook.Backoff(int(d.Attempts) + 1).Seconds(),
+	})
+	if err != nil {
+		log.Printf("cannot record failed webhook delivery %s: %v", d.ID, err)
+	}
+}
+
+// list the webhooks of the user
+func (s *Server) listWebhooks(c echo.Context) error {
+	// swagger:operation GET /webhooks webhooks listWebhooks
+	// ---
+	// summary: List the webhooks of the user
+	// description: List the endpoints the user registered for events, oldest first. Their secrets are not shown.
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/Webhook"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	webhooks, err := s.store.ListWebhooks(c.Request().Context(), tokenPayload.UserId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(webhooks))
+}
+
+// register a webhook
+func (s *Server) createWebhook(c echo.Context) error {
+	// swagger:operation POST /webhooks webhooks createWebhook
+	// ---
+	// summary: Register a webhook
+	// description: Register an endpoint to post events to. Each delivery carries an X-Webhook-Signature header,
+	//   sha256= followed by the hex HMAC-SHA256 of the X-Webhook-Timestamp header, a dot and the body, keyed with
+	//   the secret. The secret is only in this response. Failed deliveries are retried with exponential backoff.
+	// parameters:
+	// - name: body
+	//   in: body
+	//   description: the endpoint and its events
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/createWebhookRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/createWebhookResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	req := new(createWebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	w, err := s.store.CreateWebhook(c.Request().Context(), db.CreateWebhookParams{
+		ID:     uuid.New(),
+		UserID: tokenPayload.UserId,
+		Url:    req.Url,
+		Secret: secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(createWebhookResponse{Webhook: w, Secret: w.Secret}))
+}
+
+// update a webhook
+func (s *Server) updateWebhook(c echo.Context) error {
+	// swagger:operation PATCH /webhooks/{id} webhooks updateWebhook
+	// ---
+	// summary: Update a webhook
+	// description: Change the url or events of a webhook, or pause it. Fields left out keep their value.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: webhook id
+	//   required: true
+	//   type: string
+	// - name: body
+	//   in: body
+	//   description: the fields to change
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/updateWebhookRequest"
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/Webhook"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	webhookId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	req := new(updateWebhookRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	w, err := s.store.UpdateWebhook(c.Request().Context(), db.UpdateWebhookParams{
+		Url:    common.NullString(req.Url),
+		Events: req.Events,
+		Active: common.NullBool(req.Active),
+		ID:     webhookId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(w))
+}
+
+// delete a webhook
+func (s *Server) deleteWebhook(c echo.Context) error {
+	// swagger:operation DELETE /webhooks/{id} webhooks deleteWebhook
+	// ---
+	// summary: Delete a webhook
+	// description: Delete a webhook along with its delivery log, pending deliveries are dropped.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: webhook id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       "$ref": "#/definitions/SuccessResponse"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	webhookId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	id, err := s.store.DeleteWebhook(c.Request().Context(), db.DeleteWebhookParams{
+		ID:     webhookId,
+		UserID: tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(id))
+}
+
+// list the deliveries of a webhook
+func (s *Server) listWebhookDeliveries(c echo.Context) error {
+	// swagger:operation GET /webhooks/{id}/deliveries webhooks listWebhookDeliveries
+	// ---
+	// summary: List the deliveries of a webhook
+	// description: List the events sent to a webhook, newest first, with their status, the number of attempts,
+	//   the status the endpoint last answered with and the error of the last failed attempt.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: webhook id
+	//   required: true
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   required: false
+	//   type: integer
+	//   format: int32
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '200':
+	//     description: OK
+	//     schema:
+	//       type: array
+	//       items:
+	//         "$ref": "#/definitions/WebhookDelivery"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	webhookId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	pageParam := c.QueryParam("page")
+	if pageParam == "" {
+		pageParam = "0"
+	}
+
+	page, err := strconv.ParseUint(pageParam, 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	deliveries, err := s.store.ListWebhookDeliveries(c.Request().Context(), db.ListWebhookDeliveriesParams{
+		WebhookID:  webhookId,
+		UserID:     tokenPayload.UserId,
+		PageOffset: int32(page * pageSize),
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusOK, newResponse(deliveries))
+}
+
+// send a delivery again
+func (s *Server) redeliverWebhook(c echo.Context) error {
+	// swagger:operation POST /webhooks/{id}/deliveries/{deliveryId}/redeliver webhooks redeliverWebhook
+	// ---
+	// summary: Send a delivery again
+	// description: Queue a new delivery with the payload of an earlier one, whatever became of it. The payload
+	//   keeps its id, so receivers can tell it is an event they may have seen.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: webhook id
+	//   required: true
+	//   type: string
+	// - name: deliveryId
+	//   in: path
+	//   description: delivery id
+	//   required: true
+	//   type: string
+	// security:
+	// - key: []
+	//
+	// responses:
+	//   '202':
+	//     description: Accepted
+	//     schema:
+	//       "$ref": "#/definitions/WebhookDelivery"
+	//   '400':
+	//     description: Bad Request
+	//     schema:
+	//       "$ref": "#/definitions/BadRequestResponse"
+	//   '401':
+	//     description: Unauthorized
+	//     schema:
+	//       "$ref": "#/definitions/UnauthorizedResponse"
+	//   '404':
+	//     description: Not Found
+	//     schema:
+	//       "$ref": "#/definitions/NotFoundResponse"
+	//   '500':
+	//     description: Internal Server Error
+	//     schema:
+	//       "$ref": "#/definitions/InternalErrorResponse"
+
+	webhookId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	deliveryId, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, newError(err.Error()))
+	}
+
+	tokenPayload, ok := c.Get("user").(*token.Payload)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, UNAUTHORIZED)
+	}
+
+	delivery, err := s.store.RedeliverWebhookDelivery(c.Request().Context(), db.RedeliverWebhookDeliveryParams{
+		NewID:     uuid.New(),
+		ID:        deliveryId,
+		WebhookID: webhookId,
+		UserID:    tokenPayload.UserId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, NOT_FOUND)
+		}
+		return c.JSON(http.StatusInternalServerError, INTERNAL_ERROR)
+	}
+
+	return c.JSON(http.StatusAccepted, newResponse(delivery))
+}
@@ -0,0 +1,414 @@
+package handler
+
+import (
+	"cnfs/db/mock"
+	db "cnfs/db/sqlc"
+	"cnfs/webhook"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func RandomWebhook(t *testing.T, userId uuid.UUID) db.Webhook {
+	return db.Webhook{
+		ID:        uuid.New(),
+		UserID:    userId,
+		Url:       "https://discord.example/api/webhooks/" + uuid.NewString(),
+		Secret:    "whsec_" + uuid.NewString(),
+		Events:    []string{webhookEventMessageCreated},
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	_, user := RandomUser(t)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPost,
+			url:     "/api/v1/webhooks",
+			payload: `{"url": "https://hooks.slack.example/services/T0", "events": ["message.created", "post.reacted"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateWebhookParams) (db.Webhook, error) {
+						require.NotEqual(t, uuid.Nil, arg.ID)
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, "https://hooks.slack.example/services/T0", arg.Url)
+						require.Equal(t, []string{webhookEventMessageCreated, webhookEventPostReacted}, arg.Events)
+						require.True(t, strings.HasPrefix(arg.Secret, "whsec_"))
+						return db.Webhook{ID: arg.ID, UserID: arg.UserID, Url: arg.Url, Secret: arg.Secret, Events: arg.Events, Active: true}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+
+				var resp struct {
+					Data map[string]interface{} `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.True(t, strings.HasPrefix(resp.Data["secret"].(string), "whsec_"))
+				require.Equal(t, true, resp.Data["active"])
+			},
+		},
+		{
+			name:    "UNKNOWN EVENT",
+			method:  http.MethodPost,
+			url:     "/api/v1/webhooks",
+			payload: `{"url": "https://hooks.slack.example/services/T0", "events": ["user.deleted"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "NO EVENTS",
+			method:  http.MethodPost,
+			url:     "/api/v1/webhooks",
+			payload: `{"url": "https://hooks.slack.example/services/T0", "events": []}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "NOT HTTP",
+			method:  http.MethodPost,
+			url:     "/api/v1/webhooks",
+			payload: `{"url": "ftp://hooks.example/in", "events": ["message.created"]}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+	})
+}
+
+func TestListWebhooks(t *testing.T) {
+	_, user := RandomUser(t)
+	webhooks := []db.Webhook{RandomWebhook(t, user.ID), RandomWebhook(t, user.ID)}
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodGet,
+			url:    "/api/v1/webhooks",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListWebhooks(gomock.Any(), gomock.Eq(user.ID)).Times(1).Return(webhooks, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+				require.NotContains(t, rec.Body.String(), "whsec_")
+
+				var resp struct {
+					Data []db.Webhook `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				require.Len(t, resp.Data, 2)
+			},
+		},
+	})
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	_, user := RandomUser(t)
+	w := RandomWebhook(t, user.ID)
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:    "OK",
+			method:  http.MethodPatch,
+			url:     "/api/v1/webhooks/" + w.ID.String(),
+			payload: `{"active": false}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateWebhook(gomock.Any(), gomock.Eq(db.UpdateWebhookParams{
+					Active: sql.NullBool{Bool: false, Valid: true},
+					ID:     w.ID,
+					UserID: user.ID,
+				})).Times(1).Return(w, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "EVENTS",
+			method:  http.MethodPatch,
+			url:     "/api/v1/webhooks/" + w.ID.String(),
+			payload: `{"events": ["comment.created"], "url": "https://bots.example/in"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateWebhook(gomock.Any(), gomock.Eq(db.UpdateWebhookParams{
+					Url:    sql.NullString{String: "https://bots.example/in", Valid: true},
+					Events: []string{webhookEventCommentCreated},
+					ID:     w.ID,
+					UserID: user.ID,
+				})).Times(1).Return(w, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:    "NO EVENTS",
+			method:  http.MethodPatch,
+			url:     "/api/v1/webhooks/" + w.ID.String(),
+			payload: `{"events": []}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateWebhook(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Code)
+			},
+		},
+		{
+			name:    "NOT FOUND",
+			method:  http.MethodPatch,
+			url:     "/api/v1/webhooks/" + w.ID.String(),
+			payload: `{"active": true}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateWebhook(gomock.Any(), gomock.Any()).Times(1).Return(db.Webhook{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	_, user := RandomUser(t)
+	webhookId := uuid.New()
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "OK",
+			method: http.MethodDelete,
+			url:    "/api/v1/webhooks/" + webhookId.String(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Eq(db.DeleteWebhookParams{ID: webhookId, UserID: user.ID})).
+					Times(1).Return(webhookId, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "NOT FOUND",
+			method: http.MethodDelete,
+			url:    "/api/v1/webhooks/" + webhookId.String(),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeleteWebhook(gomock.Any(), gomock.Any()).Times(1).Return(uuid.Nil, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	_, user := RandomUser(t)
+	webhookId, deliveryId := uuid.New(), uuid.New()
+
+	runIdentityTestCases(t, user, []identityTestCase{
+		{
+			name:   "LIST",
+			method: http.MethodGet,
+			url:    "/api/v1/webhooks/" + webhookId.String() + "/deliveries?page=2",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListWebhookDeliveries(gomock.Any(), gomock.Eq(db.ListWebhookDeliveriesParams{
+					WebhookID:  webhookId,
+					UserID:     user.ID,
+					PageOffset: 2 * pageSize,
+				})).Times(1).Return([]db.WebhookDelivery{{ID: deliveryId, WebhookID: webhookId, Status: "failed", Payload: []byte(`{}`)}}, nil)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Code)
+			},
+		},
+		{
+			name:   "REDELIVER",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/webhooks/%s/deliveries/%s/redeliver", webhookId, deliveryId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RedeliverWebhookDeliveryParams) (db.WebhookDelivery, error) {
+						require.NotEqual(t, deliveryId, arg.NewID)
+						require.Equal(t, deliveryId, arg.ID)
+						require.Equal(t, webhookId, arg.WebhookID)
+						require.Equal(t, user.ID, arg.UserID)
+						return db.WebhookDelivery{ID: arg.NewID, WebhookID: webhookId, Status: "pending", Payload: []byte(`{}`)}, nil
+					})
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, rec.Code)
+			},
+		},
+		{
+			name:   "REDELIVER NOT FOUND",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/api/v1/webhooks/%s/deliveries/%s/redeliver", webhookId, deliveryId),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().RedeliverWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(db.WebhookDelivery{}, sql.ErrNoRows)
+			},
+			checkResponse: func(rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Code)
+			},
+		},
+	})
+}
+
+func TestEmitWebhookEvent(t *testing.T) {
+	_, user := RandomUser(t)
+	webhooks := []db.Webhook{RandomWebhook(t, user.ID), RandomWebhook(t, user.ID)}
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+
+	var payloads [][]byte
+	store.EXPECT().ListWebhooksForEvent(gomock.Any(), gomock.Eq(db.ListWebhooksForEventParams{UserID: user.ID, Event: webhookEventMessageCreated})).
+		Times(1).Return(webhooks, nil)
+	for _, w := range webhooks {
+		w := w
+		store.EXPECT().CreateWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+				require.Equal(t, w.ID, arg.WebhookID)
+				require.Equal(t, webhookEventMessageCreated, arg.Event)
+				payloads = append(payloads, arg.Payload)
+				return db.WebhookDelivery{ID: arg.ID}, nil
+			})
+	}
+
+	server, err := NewServer(store, cfg)
+	require.NoError(t, err)
+
+	server.emitWebhookEvent(context.Background(), user.ID, webhookEventMessageCreated, db.Message{Content: "psst"})
+
+	// every webhook gets the same event
+	require.Len(t, payloads, 2)
+	require.JSONEq(t, string(payloads[0]), string(payloads[1]))
+
+	var payload struct {
+		ID    uuid.UUID  `json:"id"`
+		Event string     `json:"event"`
+		Data  db.Message `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	require.NotEqual(t, uuid.Nil, payload.ID)
+	require.Equal(t, webhookEventMessageCreated, payload.Event)
+	require.Equal(t, "psst", payload.Data.Content)
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	secret := "whsec_test"
+
+	// stands in for the bot, answering with the status in the path
+	received := make(chan *http.Request, 2)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		require.True(t, webhook.Verify(secret, timestamp, body, r.Header.Get(webhook.HeaderSignature)))
+
+		received <- r
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(status)
+	}))
+	defer endpoint.Close()
+
+	delivered := db.ClaimWebhookDeliveriesRow{
+		ID: uuid.New(), Event: webhookEventCommentCreated, Payload: []byte(`{"event":"comment.created"}`),
+		Url: endpoint.URL + "/204", Secret: secret,
+	}
+	failed := db.ClaimWebhookDeliveriesRow{
+		ID: uuid.New(), Event: webhookEventPostReacted, Payload: []byte(`{"event":"post.reacted"}`),
+		Url: endpoint.URL + "/500", Secret: secret, Attempts: 2,
+	}
+
+	c := *cfg
+	c.WebhookAllowPrivateAddresses = true
+	c.WebhookMaxAttempts = 8
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Eq(db.ClaimWebhookDeliveriesParams{
+		LeaseSeconds: (2 * c.WebhookTimeout).Seconds(),
+		BatchSize:    webhookBatchSize,
+	})).Times(1).Return([]db.ClaimWebhookDeliveriesRow{delivered, failed}, nil)
+	store.EXPECT().CompleteWebhookDelivery(gomock.Any(), gomock.Eq(db.CompleteWebhookDeliveryParams{
+		ID:             delivered.ID,
+		ResponseStatus: sql.NullInt32{Int32: http.StatusNoContent, Valid: true},
+	})).Times(1).Return(nil)
+	store.EXPECT().FailWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.FailWebhookDeliveryParams) error {
+			require.Equal(t, failed.ID, arg.ID)
+			require.Equal(t, sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true}, arg.ResponseStatus)
+			require.Contains(t, arg.Error, "500")
+			require.Equal(t, int32(8), arg.MaxAttempts)
+			// the third attempt failed
+			require.Equal(t, webhook.Backoff(3).Seconds(), arg.RetrySeconds)
+			return nil
+		})
+
+	server, err := NewServer(store, &c)
+	require.NoError(t, err)
+
+	require.NoError(t, server.deliverWebhooks(context.Background()))
+
+	events := map[string]string{}
+	for i := 0; i < 2; i++ {
+		r := <-received
+		events[r.Header.Get(webhook.HeaderEvent)] = r.Header.Get(webhook.HeaderID)
+	}
+	require.Equal(t, delivered.ID.String(), events[webhookEventCommentCreated])
+	require.Equal(t, failed.ID.String(), events[webhookEventPostReacted])
+}
+
+func TestDeliverWebhooksPrivateAddress(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a private address was reached")
+	}))
+	defer endpoint.Close()
+
+	d := db.ClaimWebhookDeliveriesRow{ID: uuid.New(), Url: endpoint.URL, Payload: []byte(`{}`)}
+
+	c := *cfg
+	c.WebhookAllowPrivateAddresses = false
+
+	ctrl := gomock.NewController(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).Times(1).Return([]db.ClaimWebhookDeliveriesRow{d}, nil)
+	store.EXPECT().FailWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.FailWebhookDeliveryParams) error {
+			require.False(t, arg.ResponseStatus.Valid)
+			require.Contains(t, arg.Error, webhook.ErrPrivateAddress.Error())
+			return nil
+		})
+
+	server, err := NewServer(store, &c)
+	require.NoError(t, err)
+
+	require.NoError(t, server.deliverWebhooks(context.Background()))
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"os"
+	"time"
+)
+
+// FileMailer writes every message as an .eml file to a directory instead of sending it, for development and
+// tests. The files open in any mail client.
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+	now  func() time.Time
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, errors.New("file mailer needs a directory")
+	}
+
+	addr, err := parseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{dir: dir, from: addr, now: time.Now}, nil
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := f.now()
+	data, err := compose(f.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	// named after the time so the directory lists in the order the messages were sent
+	file, err := os.CreateTemp(f.dir, now.UTC().Format("20060102T150405.000000000")+"-*.eml")
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,138 @@
+// Package mail sends email behind a small interface so the server can deliver
+// through an SMTP relay or, in development, drop the messages into a directory.
+package mail
+
+import (
+	"bytes"
+	"cnfs/config"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// Message is an email with both an HTML and a plain text body, mail clients show the one they can.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	// extra headers, such as List-Unsubscribe
+	Headers map[string]string
+}
+
+type Mailer interface {
+	// Send delivers the message, it is done once the message is handed over.
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the backend selected by MAILER_BACKEND.
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailerBackend {
+	case "", "file":
+		return NewFileMailer(cfg.MailFileDir, cfg.MailFrom)
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     cfg.SmtpHost,
+			Port:     cfg.SmtpPort,
+			Username: cfg.SmtpUsername,
+			Password: cfg.SmtpPassword,
+			From:     cfg.MailFrom,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mailer backend %q", cfg.MailerBackend)
+	}
+}
+
+// parseAddress reads a single address such as "cnfs <noreply@cnfs.example>".
+func parseAddress(address string) (*mail.Address, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	return addr, nil
+}
+
+// compose renders the message as RFC 5322 text, a multipart/alternative of the plain text and HTML bodies,
+// both quoted-printable so long lines and non-ASCII survive any relay.
+func compose(from *mail.Address, msg Message, now time.Time) ([]byte, error) {
+	to, err := parseAddress(msg.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, alternative := range []struct{ contentType, content string }{
+		// the last alternative is the preferred one
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(alternative.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	headers := map[string]string{
+		"From":         from.String(),
+		"To":           to.String(),
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         now.Format(time.RFC1123Z),
+		"Message-ID":   fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf("multipart/alternative; boundary=%q", parts.Boundary()),
+	}
+	for name, value := range msg.Headers {
+		// a header value must not start a header of its own
+		if strings.ContainsAny(name+value, "\r\n") {
+			return nil, fmt.Errorf("invalid header %q", name)
+		}
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s: %s\r\n", name, headers[name])
+	}
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,221 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = Message{
+	To:      "reader@cnfs.example",
+	Subject: "Your weekly digest ✉",
+	HTML:    `<p>You have <b>3</b> unread messages</p>`,
+	Text:    "You have 3 unread messages, and a line long enough that quoted-printable has to wrap it somewhere along the way",
+	Headers: map[string]string{"List-Unsubscribe": "<https://cnfs.example/unsubscribe?token=abc>"},
+}
+
+// readMessage parses what a mailer produced and returns its headers and the bodies by content type.
+func readMessage(t *testing.T, data []byte) (mail.Header, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	bodies := map[string]string{}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		// the reader undoes the quoted-printable encoding
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		bodies[contentType] = string(content)
+	}
+
+	return msg.Header, bodies
+}
+
+func TestCompose(t *testing.T) {
+	from, err := parseAddress("cnfs <noreply@cnfs.example>")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	data, err := compose(from, testMessage, now)
+	require.NoError(t, err)
+
+	for _, line := range strings.Split(string(data), "\r\n") {
+		require.LessOrEqual(t, len(line), 998)
+	}
+
+	header, bodies := readMessage(t, data)
+	require.Equal(t, `"cnfs" <noreply@cnfs.example>`, header.Get("From"))
+	require.Equal(t, "<reader@cnfs.example>", header.Get("To"))
+	require.Equal(t, testMessage.Headers["List-Unsubscribe"], header.Get("List-Unsubscribe"))
+	require.True(t, strings.HasSuffix(header.Get("Message-Id"), "@cnfs.example>"))
+
+	date, err := header.Date()
+	require.NoError(t, err)
+	require.True(t, now.Equal(date))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, testMessage.Subject, subject)
+
+	require.Equal(t, testMessage.Text, bodies["text/plain"])
+	require.Equal(t, testMessage.HTML, bodies["text/html"])
+}
+
+func TestComposeRejectsInjection(t *testing.T) {
+	from, err := parseAddress("noreply@cnfs.example")
+	require.NoError(t, err)
+
+	msg := testMessage
+	msg.To = "reader@cnfs.example\r\nBcc: everyone@cnfs.example"
+	_, err = compose(from, msg, time.Now())
+	require.ErrorIs(t, err, ErrInvalidAddress)
+
+	msg = testMessage
+	msg.Headers = map[string]string{"List-Unsubscribe": "<x>\r\nBcc: everyone@cnfs.example"}
+	_, err = compose(from, msg, time.Now())
+	require.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(dir, "noreply@cnfs.example")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(context.Background(), testMessage))
+	require.NoError(t, mailer.Send(context.Background(), testMessage))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	header, bodies := readMessage(t, data)
+	require.Equal(t, "<reader@cnfs.example>", header.Get("To"))
+	require.Equal(t, testMessage.Text, bodies["text/plain"])
+
+	_, err = NewFileMailer("", "noreply@cnfs.example")
+	require.Error(t, err)
+	_, err = NewFileMailer(dir, "not an address")
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
+
+// smtpServer is just enough of an SMTP server to accept one message, it hands back the envelope and data.
+func smtpServer(t *testing.T) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var transcript []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+			switch command {
+			case "EHLO", "HELO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				transcript = append(transcript, line)
+				reply("235 authenticated")
+			case "MAIL", "RCPT":
+				transcript = append(transcript, line)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				transcript = append(transcript, data.String())
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				received <- transcript
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	address, received := smtpServer(t)
+	host, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: host, Port: port, Username: "cnfs", Password: "secret", From: "noreply@cnfs.example"})
+	require.NoError(t, err)
+	// plain auth is only allowed to localhost without TLS
+	mailer.cfg.Host = "localhost"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, mailer.Send(ctx, testMessage))
+
+	transcript := <-received
+	require.Len(t, transcript, 4)
+	require.True(t, strings.HasPrefix(transcript[0], "AUTH PLAIN "))
+	require.Equal(t, "MAIL FROM:<noreply@cnfs.example>", strings.SplitN(transcript[1], " BODY", 2)[0])
+	require.Equal(t, "RCPT TO:<reader@cnfs.example>", transcript[2])
+
+	_, bodies := readMessage(t, []byte(transcript[3]))
+	require.Equal(t, testMessage.HTML, bodies["text/html"])
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{Port: "587", From: "noreply@cnfs.example"})
+	require.Error(t, err)
+
+	_, err = NewSMTPMailer(SMTPConfig{Host: "smtp.cnfs.example", Port: "587", From: "nope"})
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host string
+	// 587 for STARTTLS or 465 for implicit TLS, 25 when relaying on a trusted network
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends through an SMTP relay. The connection is upgraded with STARTTLS whenever the relay offers
+// it, and credentials are only sent over TLS or to localhost.
+type SMTPMailer struct {
+	cfg       SMTPConfig
+	from      *mail.Address
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Host == "" || cfg.Port == "" {
+		return nil, errors.New("smtp mailer needs a host and port")
+	}
+
+	addr, err := parseAddress(cfg.From)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SMTPMailer{
+		cfg:       cfg,
+		from:      addr,
+		tlsConfig: &tls.Config{ServerName: cfg.Host},
+		now:       time.Now,
+	}, nil
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := parseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	data, err := compose(s.from, msg, s.now())
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	var conn net.Conn
+	if s.cfg.Port == "465" {
+		conn, err = (&tls.Dialer{Config: s.tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// the smtp client knows nothing of contexts, the deadline bounds the whole conversation instead
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(s.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,183 @@
+// Package media validates uploaded images and prepares them for storage.
+//
+// Every image is decoded and encoded again, which drops EXIF and any other
+// metadata the original file carried. The EXIF orientation is applied to the
+// pixels beforehand so photos taken on phones keep displaying upright.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+const (
+	// ThumbnailSize is the longest side of a generated thumbnail in pixels.
+	ThumbnailSize = 320
+	// MaxPixels guards against decompression bombs, roughly a 50 megapixel photo.
+	MaxPixels = 50_000_000
+
+	jpegQuality = 85
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("unsupported file type, only jpeg and png images are accepted")
+	ErrInvalidImage    = errors.New("file is not a valid image")
+)
+
+// Image is a cleaned up upload together with its thumbnail.
+type Image struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Thumbnail   []byte
+	Width       int
+	Height      int
+}
+
+// Process reads at most maxSize bytes from r and returns the re-encoded image.
+func Process(r io.Reader, maxSize int64) (*Image, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(raw)
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	img := toNRGBA(src)
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(raw))
+	}
+
+	data, err := encode(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	thumb, err := encode(Thumbnail(img, ThumbnailSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := ".jpg"
+	if contentType == "image/png" {
+		ext = ".png"
+	}
+
+	bounds := img.Bounds()
+	return &Image{
+		ContentType: contentType,
+		Ext:         ext,
+		Data:        data,
+		Thumbnail:   thumb,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(buf, img)
+	} else {
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+
+	return buf.Bytes(), err
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok && img.Rect.Min == (image.Point{}) {
+		return img
+	}
+
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// Thumbnail scales img down so its longest side is at most size pixels,
+// averaging the source pixels each destination pixel covers.
+// Images that already fit are returned unchanged.
+func Thumbnail(img *image.NRGBA, size int) *image.NRGBA {
+	sw, sh := img.Rect.Dx(), img.Rect.Dy()
+	if sw <= size && sh <= size {
+		return img
+	}
+
+	dw, dh := size, sh*size/sw
+	if sh > sw {
+		dw, dh = sw*size/sh, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			// alpha-weight the colour channels so transparent pixels don't darken edges
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				row := img.Pix[y*img.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					b += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+
+			o := dst.Pix[dy*dst.Stride+dx*4:]
+			if a > 0 {
+				o[0], o[1], o[2] = uint8(r/a), uint8(g/a), uint8(b/a)
+			}
+			o[3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
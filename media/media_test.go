@@ -0,0 +1,107 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+// withExif inserts an APP1 segment holding a big endian orientation tag right after the SOI marker.
+func withExif(jpg []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, first IFD at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // orientation, SHORT, count 1
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessJpegStripsExifAndRotates(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, jpeg.Encode(buf, testImage(640, 400), nil))
+	raw := withExif(buf.Bytes(), 6)
+	require.Equal(t, 6, jpegOrientation(raw))
+
+	img, err := Process(bytes.NewReader(raw), int64(len(raw)))
+	require.NoError(t, err)
+
+	require.Equal(t, "image/jpeg", img.ContentType)
+	require.Equal(t, ".jpg", img.Ext)
+	require.Equal(t, 400, img.Width)
+	require.Equal(t, 640, img.Height)
+	require.False(t, bytes.Contains(img.Data, []byte("Exif")))
+	require.Equal(t, 1, jpegOrientation(img.Data))
+
+	thumb, err := jpeg.DecodeConfig(bytes.NewReader(img.Thumbnail))
+	require.NoError(t, err)
+	require.Equal(t, 200, thumb.Width)
+	require.Equal(t, ThumbnailSize, thumb.Height)
+}
+
+func TestProcessPng(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, testImage(100, 50)))
+
+	img, err := Process(buf, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", img.ContentType)
+	require.Equal(t, 100, img.Width)
+
+	// small images are not scaled up
+	thumb, err := png.DecodeConfig(bytes.NewReader(img.Thumbnail))
+	require.NoError(t, err)
+	require.Equal(t, 100, thumb.Width)
+	require.Equal(t, 50, thumb.Height)
+}
+
+func TestProcessRejects(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, testImage(10, 10)))
+
+	_, err := Process(bytes.NewReader(buf.Bytes()), int64(buf.Len()-1))
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Process(bytes.NewReader([]byte("GIF89a not really")), 1<<20)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Process(bytes.NewReader([]byte("\x89PNG\r\n\x1a\n broken")), 1<<20)
+	require.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestOrient(t *testing.T) {
+	img := testImage(3, 2)
+	for o := 1; o <= 8; o++ {
+		out := orient(img, o)
+		if o >= 5 {
+			require.Equal(t, image.Rect(0, 0, 2, 3), out.Rect, o)
+		} else {
+			require.Equal(t, image.Rect(0, 0, 3, 2), out.Rect, o)
+		}
+	}
+
+	// rotating 90 clockwise moves the top left pixel to the top right
+	out := orient(img, 6)
+	require.Equal(t, img.NRGBAAt(0, 0), out.NRGBAAt(1, 0))
+}
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "user_identities_user_id_idx";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "created_at";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "retired";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "is_default";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "name";
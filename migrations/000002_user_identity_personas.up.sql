@@ -0,0 +1,9 @@
+ALTER TABLE "user_identities" ADD COLUMN "name" varchar NOT NULL DEFAULT 'default';
+ALTER TABLE "user_identities" ADD COLUMN "is_default" boolean NOT NULL DEFAULT false;
+ALTER TABLE "user_identities" ADD COLUMN "retired" boolean NOT NULL DEFAULT false;
+ALTER TABLE "user_identities" ADD COLUMN "created_at" date NOT NULL DEFAULT (now());
+
+-- every existing user has exactly one identity, make it their default
+UPDATE "user_identities" SET is_default = true;
+
+CREATE INDEX ON "user_identities" ("user_id");
@@ -0,0 +1,6 @@
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "rotation_detach";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "rotation_interval_days";
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "rotated_at";
+
+DELETE FROM "user_identities" WHERE "user_id" IS NULL;
+ALTER TABLE "user_identities" ALTER COLUMN "user_id" SET NOT NULL;
@@ -0,0 +1,6 @@
+-- a detached identity keeps its content but no longer belongs to anyone
+ALTER TABLE "user_identities" ALTER COLUMN "user_id" DROP NOT NULL;
+
+ALTER TABLE "user_identities" ADD COLUMN "rotated_at" date NOT NULL DEFAULT (now());
+ALTER TABLE "user_identities" ADD COLUMN "rotation_interval_days" integer;
+ALTER TABLE "user_identities" ADD COLUMN "rotation_detach" boolean NOT NULL DEFAULT false;
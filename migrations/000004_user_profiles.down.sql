@@ -0,0 +1,7 @@
+ALTER TABLE "user_identities" DROP COLUMN IF EXISTS "is_public";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "links";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "confession_prompt";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "avatar_url";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "bio";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "display_name";
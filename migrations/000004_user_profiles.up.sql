@@ -0,0 +1,8 @@
+ALTER TABLE "users" ADD COLUMN "display_name" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "bio" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "avatar_url" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "confession_prompt" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "links" varchar[] NOT NULL DEFAULT '{}';
+
+-- posts under a public identity are listed on the owner's public profile
+ALTER TABLE "user_identities" ADD COLUMN "is_public" boolean NOT NULL DEFAULT false;
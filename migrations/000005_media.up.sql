@@ -0,0 +1,25 @@
+CREATE TABLE "media" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "kind" varchar NOT NULL CHECK ("kind" IN ('avatar', 'post', 'message')),
+  "user_id" uuid,
+  "post_id" uuid,
+  "message_id" uuid,
+  "storage_key" varchar NOT NULL,
+  "thumbnail_key" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "size" bigint NOT NULL,
+  "width" integer NOT NULL,
+  "height" integer NOT NULL,
+  "is_private" boolean NOT NULL DEFAULT false,
+  "created_at" date NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "media" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "media" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "media" ADD FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "media" ("user_id");
+CREATE INDEX ON "media" ("post_id");
+CREATE INDEX ON "media" ("message_id");
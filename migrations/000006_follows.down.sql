@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "comments_post_id_created_at_idx";
+DROP INDEX IF EXISTS "posts_user_identity_id_created_at_idx";
+
+DROP TABLE IF EXISTS "follows";
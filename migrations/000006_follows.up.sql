@@ -0,0 +1,23 @@
+-- a follow targets either a user or one of the anonymous identities, never both
+CREATE TABLE "follows" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "follower_id" uuid NOT NULL,
+  "followee_user_id" uuid,
+  "followee_identity_id" uuid,
+  "created_at" date NOT NULL DEFAULT (now()),
+  CHECK (("followee_user_id" IS NULL) <> ("followee_identity_id" IS NULL))
+);
+
+ALTER TABLE "follows" ADD FOREIGN KEY ("follower_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "follows" ADD FOREIGN KEY ("followee_user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "follows" ADD FOREIGN KEY ("followee_identity_id") REFERENCES "user_identities" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE UNIQUE INDEX ON "follows" ("follower_id", "followee_user_id") WHERE "followee_user_id" IS NOT NULL;
+CREATE UNIQUE INDEX ON "follows" ("follower_id", "followee_identity_id") WHERE "followee_identity_id" IS NOT NULL;
+CREATE INDEX ON "follows" ("followee_user_id");
+CREATE INDEX ON "follows" ("followee_identity_id");
+
+CREATE INDEX ON "posts" ("user_identity_id", "created_at");
+CREATE INDEX ON "comments" ("post_id", "created_at");
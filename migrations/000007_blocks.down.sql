@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS "hidden_from"(uuid, uuid, varchar);
+
+DROP TABLE IF EXISTS "muted_keywords";
+DROP TABLE IF EXISTS "blocks";
@@ -0,0 +1,52 @@
+-- blocks hide the target from the user and stop it from interacting with them,
+-- mutes only hide it. Either a user or one of the anonymous identities is targeted.
+CREATE TABLE "blocks" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "user_id" uuid NOT NULL,
+  "blocked_user_id" uuid,
+  "blocked_identity_id" uuid,
+  "kind" varchar NOT NULL CHECK ("kind" IN ('block', 'mute')),
+  "created_at" date NOT NULL DEFAULT (now()),
+  CHECK (("blocked_user_id" IS NULL) <> ("blocked_identity_id" IS NULL))
+);
+
+ALTER TABLE "blocks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "blocks" ADD FOREIGN KEY ("blocked_user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "blocks" ADD FOREIGN KEY ("blocked_identity_id") REFERENCES "user_identities" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE UNIQUE INDEX "blocks_user_id_blocked_user_id_key" ON "blocks" ("user_id", "blocked_user_id") WHERE "blocked_user_id" IS NOT NULL;
+CREATE UNIQUE INDEX "blocks_user_id_blocked_identity_id_key" ON "blocks" ("user_id", "blocked_identity_id") WHERE "blocked_identity_id" IS NOT NULL;
+
+CREATE TABLE "muted_keywords" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "user_id" uuid NOT NULL,
+  "keyword" varchar NOT NULL,
+  "created_at" date NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "muted_keywords" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE UNIQUE INDEX "muted_keywords_user_id_keyword_key" ON "muted_keywords" ("user_id", lower("keyword"));
+
+-- hidden_from reports whether content written under an identity is hidden from the viewer.
+-- Blocks and mutes of a user only cover their public identities, hiding their anonymous
+-- content as well would let anyone find out which identities belong to whom.
+CREATE FUNCTION "hidden_from"("viewer" uuid, "identity" uuid, "content" varchar) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM "blocks" b
+    WHERE b.user_id = viewer AND (
+      b.blocked_identity_id = identity
+      OR b.blocked_user_id = (
+        SELECT ui.user_id FROM "user_identities" ui WHERE ui.id = identity AND ui.is_public
+      )
+    )
+  ) OR EXISTS (
+    SELECT 1
+    FROM "muted_keywords" mk
+    WHERE mk.user_id = viewer AND position(lower(mk.keyword) IN lower(content)) > 0
+  )
+$$;
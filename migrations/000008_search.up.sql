@@ -0,0 +1,9 @@
+-- kept in sync by postgres, the english config stems words and drops stop words
+ALTER TABLE "posts" ADD COLUMN "search_vector" tsvector NOT NULL
+  GENERATED ALWAYS AS (to_tsvector('english', "content")) STORED;
+
+ALTER TABLE "comments" ADD COLUMN "search_vector" tsvector NOT NULL
+  GENERATED ALWAYS AS (to_tsvector('english', "content")) STORED;
+
+CREATE INDEX "posts_search_vector_idx" ON "posts" USING GIN ("search_vector");
+CREATE INDEX "comments_search_vector_idx" ON "comments" USING GIN ("search_vector");
@@ -0,0 +1,20 @@
+-- hashtags are stored lower case, without the leading #
+CREATE TABLE "tags" (
+  "name" varchar(50) PRIMARY KEY NOT NULL,
+  "created_at" date NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "post_tags" (
+  "post_id" uuid NOT NULL,
+  "tag" varchar(50) NOT NULL,
+  "created_at" date NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("post_id", "tag")
+);
+
+ALTER TABLE "post_tags" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "post_tags" ADD FOREIGN KEY ("tag") REFERENCES "tags" ("name") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+-- prefix lookups for autocomplete
+CREATE INDEX ON "tags" ("name" varchar_pattern_ops);
+CREATE INDEX ON "post_tags" ("tag", "created_at");
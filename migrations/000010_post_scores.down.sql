@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "post_scores";
+DROP TABLE IF EXISTS "post_reactions";
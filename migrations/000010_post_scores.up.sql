@@ -0,0 +1,28 @@
+-- one reaction per user and post, using the satisfaction type from the initial schema
+CREATE TABLE "post_reactions" (
+  "post_id" uuid NOT NULL,
+  "user_id" uuid NOT NULL,
+  "reaction" satisfaction NOT NULL,
+  "created_at" date NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("post_id", "user_id")
+);
+
+ALTER TABLE "post_reactions" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "post_reactions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+-- precomputed by the score refresher job, so sorting posts doesn't aggregate reactions and comments
+CREATE TABLE "post_scores" (
+  "post_id" uuid PRIMARY KEY NOT NULL,
+  "likes" integer NOT NULL DEFAULT 0,
+  "dislikes" integer NOT NULL DEFAULT 0,
+  "comments" integer NOT NULL DEFAULT 0,
+  "hot" double precision NOT NULL DEFAULT 0,
+  "controversy" double precision NOT NULL DEFAULT 0,
+  "refreshed_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "post_scores" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "post_scores" ("hot");
+CREATE INDEX ON "post_scores" ("controversy");
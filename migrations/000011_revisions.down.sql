@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "comment_revisions";
+DROP TABLE IF EXISTS "post_revisions";
+
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "edited";
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "revision";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "edited";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "revision";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_moderator";
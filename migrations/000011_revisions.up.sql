@@ -0,0 +1,42 @@
+-- moderators can read the revisions of any post or comment
+ALTER TABLE "users" ADD COLUMN "is_moderator" boolean NOT NULL DEFAULT false;
+
+-- revision counts the versions of the content, the first version is revision 1
+ALTER TABLE "posts" ADD COLUMN "revision" integer NOT NULL DEFAULT 1;
+ALTER TABLE "posts" ADD COLUMN "edited" boolean NOT NULL DEFAULT false;
+ALTER TABLE "comments" ADD COLUMN "revision" integer NOT NULL DEFAULT 1;
+ALTER TABLE "comments" ADD COLUMN "edited" boolean NOT NULL DEFAULT false;
+
+-- every version of the content, including the current one
+CREATE TABLE "post_revisions" (
+  "post_id" uuid NOT NULL,
+  "revision" integer NOT NULL,
+  "user_identity_id" uuid NOT NULL,
+  "content" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("post_id", "revision")
+);
+
+CREATE TABLE "comment_revisions" (
+  "comment_id" uuid NOT NULL,
+  "revision" integer NOT NULL,
+  "user_identity_id" uuid NOT NULL,
+  "content" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("comment_id", "revision")
+);
+
+ALTER TABLE "post_revisions" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "post_revisions" ADD FOREIGN KEY ("user_identity_id") REFERENCES "user_identities" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "comment_revisions" ADD FOREIGN KEY ("comment_id") REFERENCES "comments" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+ALTER TABLE "comment_revisions" ADD FOREIGN KEY ("user_identity_id") REFERENCES "user_identities" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+-- the existing content is the first revision
+INSERT INTO "post_revisions" ("post_id", "revision", "user_identity_id", "content", "created_at")
+SELECT "id", 1, "user_identity_id", "content", "created_at" FROM "posts";
+
+INSERT INTO "comment_revisions" ("comment_id", "revision", "user_identity_id", "content", "created_at")
+SELECT "id", 1, "user_identity_id", "content", "created_at" FROM "comments";
@@ -0,0 +1,31 @@
+CREATE OR REPLACE FUNCTION "hidden_from"("viewer" uuid, "identity" uuid, "content" varchar) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM "blocks" b
+    WHERE b.user_id = viewer AND (
+      b.blocked_identity_id = identity
+      OR b.blocked_user_id = (
+        SELECT ui.user_id FROM "user_identities" ui WHERE ui.id = identity AND ui.is_public
+      )
+    )
+  ) OR EXISTS (
+    SELECT 1
+    FROM "muted_keywords" mk
+    WHERE mk.user_id = viewer AND position(lower(mk.keyword) IN lower(content)) > 0
+  )
+$$;
+
+DROP FUNCTION IF EXISTS "comment_trashed"(uuid);
+DROP FUNCTION IF EXISTS "author_deleted"(uuid);
+
+-- the trash is emptied, nothing without a deleted_at column would hide it anymore
+DELETE FROM "comments" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "posts" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "messages" WHERE "deleted_at" IS NOT NULL;
+DELETE FROM "users" WHERE "deleted_at" IS NOT NULL;
+
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "messages" DROP COLUMN IF EXISTS "deleted_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deleted_at";
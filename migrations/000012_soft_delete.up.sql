@@ -0,0 +1,58 @@
+-- deleted rows stay in the trash, where they can be restored, until the purge job removes them for good
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "messages" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "posts" ADD COLUMN "deleted_at" timestamp;
+ALTER TABLE "comments" ADD COLUMN "deleted_at" timestamp;
+
+CREATE INDEX "users_deleted_at_idx" ON "users" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX "messages_deleted_at_idx" ON "messages" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX "posts_deleted_at_idx" ON "posts" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+CREATE INDEX "comments_deleted_at_idx" ON "comments" ("deleted_at") WHERE "deleted_at" IS NOT NULL;
+
+-- author_deleted reports whether the user behind an identity deleted their account.
+CREATE FUNCTION "author_deleted"("identity" uuid) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM "user_identities" ui
+    JOIN "users" u ON u.id = ui.user_id
+    WHERE ui.id = identity AND u.deleted_at IS NOT NULL
+  )
+$$;
+
+-- comment_trashed reports whether a comment is in the trash, either itself, through one of the
+-- comments it replies to or through its post. Deleting a comment takes its replies with it, as
+-- the hard delete did, and restoring it brings them back.
+CREATE FUNCTION "comment_trashed"("comment" uuid) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  WITH RECURSIVE "ancestors" AS (
+    SELECT c.id, c.parent_id, c.post_id, c.deleted_at FROM "comments" c WHERE c.id = comment
+    UNION
+    SELECT c.id, c.parent_id, c.post_id, c.deleted_at FROM "comments" c JOIN "ancestors" a ON c.id = a.parent_id
+  )
+  SELECT EXISTS (
+    SELECT 1
+    FROM "ancestors" a
+    JOIN "posts" p ON p.id = a.post_id
+    WHERE a.deleted_at IS NOT NULL OR p.deleted_at IS NOT NULL
+  )
+$$;
+
+-- content of deleted accounts is hidden from everyone, like blocked content
+CREATE OR REPLACE FUNCTION "hidden_from"("viewer" uuid, "identity" uuid, "content" varchar) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT author_deleted(identity) OR EXISTS (
+    SELECT 1
+    FROM "blocks" b
+    WHERE b.user_id = viewer AND (
+      b.blocked_identity_id = identity
+      OR b.blocked_user_id = (
+        SELECT ui.user_id FROM "user_identities" ui WHERE ui.id = identity AND ui.is_public
+      )
+    )
+  ) OR EXISTS (
+    SELECT 1
+    FROM "muted_keywords" mk
+    WHERE mk.user_id = viewer AND position(lower(mk.keyword) IN lower(content)) > 0
+  )
+$$;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "data_exports";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "delete_after";
@@ -0,0 +1,27 @@
+-- a deleted account is purged once delete_after passes, logging in before that cancels the deletion
+ALTER TABLE "users" ADD COLUMN "delete_after" timestamp;
+
+UPDATE "users" SET "delete_after" = "deleted_at" + interval '30 days' WHERE "deleted_at" IS NOT NULL;
+
+-- archives of everything a user has, built in the background and downloadable until they expire
+CREATE TABLE "data_exports" (
+  "id" uuid UNIQUE PRIMARY KEY NOT NULL,
+  "user_id" uuid NOT NULL,
+  "format" varchar NOT NULL CHECK ("format" IN ('zip', 'json')),
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'running', 'done', 'failed')),
+  "storage_key" varchar NOT NULL DEFAULT '',
+  "size" bigint NOT NULL DEFAULT 0,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "started_at" timestamp,
+  "completed_at" timestamp,
+  "expires_at" timestamp
+);
+
+ALTER TABLE "data_exports" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "data_exports" ("user_id", "created_at");
+CREATE INDEX ON "data_exports" ("created_at") WHERE "status" IN ('pending', 'running');
+
+-- a user waits for their export before asking for another one
+CREATE UNIQUE INDEX "data_exports_user_id_in_progress_key" ON "data_exports" ("user_id") WHERE "status" IN ('pending', 'running');
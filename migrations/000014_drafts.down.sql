@@ -0,0 +1,6 @@
+-- unpublished posts would become public without their status, they go to the trash instead
+UPDATE "posts" SET "deleted_at" = now() WHERE "status" <> 'published' AND "deleted_at" IS NULL;
+
+ALTER TABLE "posts" DROP CONSTRAINT IF EXISTS "posts_publish_at_check";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "publish_at";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,8 @@
+-- drafts and scheduled posts are only visible to their author until they are published,
+-- the scheduler publishes scheduled posts once publish_at passes
+ALTER TABLE "posts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'published'
+  CHECK ("status" IN ('draft', 'scheduled', 'published'));
+ALTER TABLE "posts" ADD COLUMN "publish_at" timestamp;
+ALTER TABLE "posts" ADD CONSTRAINT "posts_publish_at_check" CHECK (("status" = 'scheduled') = ("publish_at" IS NOT NULL));
+
+CREATE INDEX "posts_publish_at_idx" ON "posts" ("publish_at") WHERE "status" = 'scheduled';
@@ -0,0 +1,42 @@
+-- a post has at most one poll, it goes with the post
+CREATE TABLE "polls" (
+  "post_id" uuid PRIMARY KEY,
+  "multiple" boolean NOT NULL DEFAULT false,
+  "closes_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "poll_options" (
+  "id" uuid PRIMARY KEY,
+  "post_id" uuid NOT NULL,
+  "position" integer NOT NULL,
+  "text" varchar NOT NULL,
+  UNIQUE ("post_id", "position"),
+  UNIQUE ("post_id", "id")
+);
+
+-- each identity casts one ballot per poll, holding one option or several for multiple choice polls
+CREATE TABLE "poll_ballots" (
+  "post_id" uuid NOT NULL,
+  "user_identity_id" uuid NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("post_id", "user_identity_id")
+);
+
+CREATE TABLE "poll_votes" (
+  "post_id" uuid NOT NULL,
+  "user_identity_id" uuid NOT NULL,
+  "option_id" uuid NOT NULL,
+  PRIMARY KEY ("post_id", "user_identity_id", "option_id")
+);
+
+CREATE INDEX ON "poll_votes" ("option_id");
+CREATE INDEX ON "poll_ballots" ("user_identity_id");
+
+ALTER TABLE "polls" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+ALTER TABLE "poll_options" ADD FOREIGN KEY ("post_id") REFERENCES "polls" ("post_id") ON DELETE CASCADE ON UPDATE NO ACTION;
+ALTER TABLE "poll_ballots" ADD FOREIGN KEY ("post_id") REFERENCES "polls" ("post_id") ON DELETE CASCADE ON UPDATE NO ACTION;
+ALTER TABLE "poll_ballots" ADD FOREIGN KEY ("user_identity_id") REFERENCES "user_identities" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+ALTER TABLE "poll_votes" ADD FOREIGN KEY ("post_id", "user_identity_id") REFERENCES "poll_ballots" ("post_id", "user_identity_id") ON DELETE CASCADE ON UPDATE NO ACTION;
+-- the option has to belong to the poll voted in
+ALTER TABLE "poll_votes" ADD FOREIGN KEY ("post_id", "option_id") REFERENCES "poll_options" ("post_id", "id") ON DELETE CASCADE ON UPDATE NO ACTION;
@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS "hidden_by_preference"(uuid, varchar[], boolean);
+DROP TABLE IF EXISTS "content_preferences";
+
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "flags_moderated_at";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "nsfw";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "content_warnings";
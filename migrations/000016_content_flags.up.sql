@@ -0,0 +1,24 @@
+-- authors flag their posts, a moderator setting the flags takes them out of the author's hands
+ALTER TABLE "posts" ADD COLUMN "content_warnings" varchar[] NOT NULL DEFAULT '{}';
+ALTER TABLE "posts" ADD COLUMN "nsfw" boolean NOT NULL DEFAULT false;
+ALTER TABLE "posts" ADD COLUMN "flags_moderated_at" timestamp;
+
+-- what a user wants done with posts of a category, nsfw is a category too
+CREATE TABLE "content_preferences" (
+  "user_id" uuid NOT NULL,
+  "category" varchar NOT NULL,
+  "action" varchar NOT NULL CHECK ("action" IN ('hide', 'blur', 'show')),
+  PRIMARY KEY ("user_id", "category")
+);
+
+ALTER TABLE "content_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+-- hidden_by_preference reports whether the viewer chose to hide one of the categories of a post.
+CREATE FUNCTION "hidden_by_preference"("viewer" uuid, "warnings" varchar[], "nsfw" boolean) RETURNS boolean
+LANGUAGE sql STABLE AS $$
+  SELECT EXISTS (
+    SELECT 1
+    FROM "content_preferences" cp
+    WHERE cp.user_id = viewer AND cp.action = 'hide' AND (cp.category = ANY(warnings) OR (nsfw AND cp.category = 'nsfw'))
+  )
+$$;
@@ -0,0 +1,27 @@
+-- one row per unread event kind and subject, later events of the same kind fold into it until it is read.
+-- actors keeps who caused them so each one is counted once, it is never shown.
+CREATE TABLE "notifications" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "type" varchar NOT NULL CHECK ("type" IN ('message', 'comment', 'reply', 'reaction')),
+  "subject_id" uuid NOT NULL,
+  "actors" uuid[] NOT NULL,
+  "read_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE UNIQUE INDEX ON "notifications" ("user_id", "type", "subject_id") WHERE "read_at" IS NULL;
+CREATE INDEX ON "notifications" ("user_id", "updated_at");
+
+-- the types a user turned off, every type is on until then
+CREATE TABLE "notification_preferences" (
+  "user_id" uuid NOT NULL,
+  "type" varchar NOT NULL,
+  "enabled" boolean NOT NULL,
+  PRIMARY KEY ("user_id", "type")
+);
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
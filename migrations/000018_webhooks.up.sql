@@ -0,0 +1,36 @@
+-- endpoints a user registered to hear about events, deliveries are signed with the secret
+CREATE TABLE "webhooks" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "events" varchar[] NOT NULL,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "webhooks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "webhooks" ("user_id");
+
+-- every event sent to a webhook, kept as the delivery log. Pending deliveries are retried with backoff
+-- until they succeed or run out of attempts.
+CREATE TABLE "webhook_deliveries" (
+  "id" uuid PRIMARY KEY,
+  "webhook_id" uuid NOT NULL,
+  "event" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "attempts" int NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamp NOT NULL DEFAULT (now()),
+  "response_status" int,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "delivered_at" timestamp
+);
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("webhook_id") REFERENCES "webhooks" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "webhook_deliveries" ("webhook_id", "created_at");
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
@@ -0,0 +1,14 @@
+-- the browsers of a user that get web push notifications, an endpoint belongs to one browser
+CREATE TABLE "push_subscriptions" (
+  "id" uuid PRIMARY KEY,
+  "user_id" uuid NOT NULL,
+  "endpoint" varchar UNIQUE NOT NULL,
+  "p256dh" varchar NOT NULL,
+  "auth" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "push_subscriptions" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "push_subscriptions" ("user_id");
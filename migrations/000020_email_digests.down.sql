@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "email_digests";
+DROP FUNCTION IF EXISTS "digest_period";
@@ -0,0 +1,22 @@
+-- digest_period is the time between two digests of the frequency.
+CREATE FUNCTION "digest_period"("frequency" varchar) RETURNS interval
+LANGUAGE sql IMMUTABLE AS $$
+  SELECT CASE frequency WHEN 'daily' THEN interval '1 day' ELSE interval '7 days' END
+$$;
+
+-- users who opted in to an email digest of what they missed, and when they get the next one
+CREATE TABLE "email_digests" (
+  "user_id" uuid PRIMARY KEY,
+  "email" varchar NOT NULL,
+  "frequency" varchar NOT NULL CHECK ("frequency" IN ('daily', 'weekly')),
+  -- unsubscribes from the link in the emails, without logging in
+  "unsubscribe_token" varchar UNIQUE NOT NULL,
+  "last_sent_at" timestamp,
+  "next_send_at" timestamp NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "email_digests" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE NO ACTION;
+
+CREATE INDEX ON "email_digests" ("next_send_at");
@@ -0,0 +1,20 @@
+-- back to the day alone, the time of day is lost
+ALTER TABLE "users" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "users" ALTER COLUMN "updated_at" TYPE date USING ("updated_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "user_identities" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "user_identities" ALTER COLUMN "rotated_at" TYPE date USING ("rotated_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "messages" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "messages" ALTER COLUMN "updated_at" TYPE date USING ("updated_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "posts" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "posts" ALTER COLUMN "updated_at" TYPE date USING ("updated_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "comments" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "comments" ALTER COLUMN "updated_at" TYPE date USING ("updated_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "sessions" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "sessions" ALTER COLUMN "expires_at" TYPE date USING ("expires_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "media" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "follows" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "blocks" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "muted_keywords" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "tags" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "post_tags" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
+ALTER TABLE "post_reactions" ALTER COLUMN "created_at" TYPE date USING ("created_at" AT TIME ZONE 'UTC')::date;
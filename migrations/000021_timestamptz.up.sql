@@ -0,0 +1,21 @@
+-- the columns that only held the day become precise. Existing rows keep their day, at midnight UTC.
+
+ALTER TABLE "users" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "users" ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "user_identities" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "user_identities" ALTER COLUMN "rotated_at" TYPE timestamptz USING "rotated_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "messages" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "messages" ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "posts" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "posts" ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "comments" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "comments" ALTER COLUMN "updated_at" TYPE timestamptz USING "updated_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "sessions" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "sessions" ALTER COLUMN "expires_at" TYPE timestamptz USING "expires_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "media" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "follows" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "blocks" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "muted_keywords" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "tags" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "post_tags" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE "post_reactions" ALTER COLUMN "created_at" TYPE timestamptz USING "created_at"::timestamp AT TIME ZONE 'UTC';
@@ -0,0 +1,33 @@
+-- back to the local time of the session, without a time zone
+
+ALTER TABLE "users" ALTER COLUMN "deleted_at" TYPE timestamp;
+ALTER TABLE "users" ALTER COLUMN "delete_after" TYPE timestamp;
+ALTER TABLE "messages" ALTER COLUMN "deleted_at" TYPE timestamp;
+ALTER TABLE "posts" ALTER COLUMN "deleted_at" TYPE timestamp;
+ALTER TABLE "comments" ALTER COLUMN "deleted_at" TYPE timestamp;
+ALTER TABLE "posts" ALTER COLUMN "publish_at" TYPE timestamp;
+ALTER TABLE "posts" ALTER COLUMN "flags_moderated_at" TYPE timestamp;
+ALTER TABLE "post_scores" ALTER COLUMN "refreshed_at" TYPE timestamp;
+ALTER TABLE "post_revisions" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "comment_revisions" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "data_exports" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "data_exports" ALTER COLUMN "started_at" TYPE timestamp;
+ALTER TABLE "data_exports" ALTER COLUMN "completed_at" TYPE timestamp;
+ALTER TABLE "data_exports" ALTER COLUMN "expires_at" TYPE timestamp;
+ALTER TABLE "polls" ALTER COLUMN "closes_at" TYPE timestamp;
+ALTER TABLE "polls" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "poll_ballots" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "notifications" ALTER COLUMN "read_at" TYPE timestamp;
+ALTER TABLE "notifications" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "notifications" ALTER COLUMN "updated_at" TYPE timestamp;
+ALTER TABLE "webhooks" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "webhooks" ALTER COLUMN "updated_at" TYPE timestamp;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "next_attempt_at" TYPE timestamp;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "delivered_at" TYPE timestamp;
+ALTER TABLE "push_subscriptions" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "push_subscriptions" ALTER COLUMN "updated_at" TYPE timestamp;
+ALTER TABLE "email_digests" ALTER COLUMN "last_sent_at" TYPE timestamp;
+ALTER TABLE "email_digests" ALTER COLUMN "next_send_at" TYPE timestamp;
+ALTER TABLE "email_digests" ALTER COLUMN "created_at" TYPE timestamp;
+ALTER TABLE "email_digests" ALTER COLUMN "updated_at" TYPE timestamp;
@@ -0,0 +1,34 @@
+-- the timestamp columns left after 000021 get a time zone too. They were written by now() in the local time of
+-- the session, which is how the cast without USING reads them.
+
+ALTER TABLE "users" ALTER COLUMN "deleted_at" TYPE timestamptz;
+ALTER TABLE "users" ALTER COLUMN "delete_after" TYPE timestamptz;
+ALTER TABLE "messages" ALTER COLUMN "deleted_at" TYPE timestamptz;
+ALTER TABLE "posts" ALTER COLUMN "deleted_at" TYPE timestamptz;
+ALTER TABLE "comments" ALTER COLUMN "deleted_at" TYPE timestamptz;
+ALTER TABLE "posts" ALTER COLUMN "publish_at" TYPE timestamptz;
+ALTER TABLE "posts" ALTER COLUMN "flags_moderated_at" TYPE timestamptz;
+ALTER TABLE "post_scores" ALTER COLUMN "refreshed_at" TYPE timestamptz;
+ALTER TABLE "post_revisions" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "comment_revisions" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "data_exports" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "data_exports" ALTER COLUMN "started_at" TYPE timestamptz;
+ALTER TABLE "data_exports" ALTER COLUMN "completed_at" TYPE timestamptz;
+ALTER TABLE "data_exports" ALTER COLUMN "expires_at" TYPE timestamptz;
+ALTER TABLE "polls" ALTER COLUMN "closes_at" TYPE timestamptz;
+ALTER TABLE "polls" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "poll_ballots" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "notifications" ALTER COLUMN "read_at" TYPE timestamptz;
+ALTER TABLE "notifications" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "notifications" ALTER COLUMN "updated_at" TYPE timestamptz;
+ALTER TABLE "webhooks" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "webhooks" ALTER COLUMN "updated_at" TYPE timestamptz;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "next_attempt_at" TYPE timestamptz;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "delivered_at" TYPE timestamptz;
+ALTER TABLE "push_subscriptions" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "push_subscriptions" ALTER COLUMN "updated_at" TYPE timestamptz;
+ALTER TABLE "email_digests" ALTER COLUMN "last_sent_at" TYPE timestamptz;
+ALTER TABLE "email_digests" ALTER COLUMN "next_send_at" TYPE timestamptz;
+ALTER TABLE "email_digests" ALTER COLUMN "created_at" TYPE timestamptz;
+ALTER TABLE "email_digests" ALTER COLUMN "updated_at" TYPE timestamptz;
@@ -0,0 +1,10 @@
+-- a user has one default identity at most. Where a failed rotation left several, the newest stays the default.
+UPDATE "user_identities" SET "is_default" = false
+WHERE "is_default" = true AND "id" NOT IN (
+  SELECT DISTINCT ON ("user_id") "id"
+  FROM "user_identities"
+  WHERE "is_default" = true AND "user_id" IS NOT NULL
+  ORDER BY "user_id", "created_at" DESC, "id"
+);
+
+CREATE UNIQUE INDEX "user_identities_default_idx" ON "user_identities" ("user_id") WHERE "is_default";
@@ -0,0 +1,2 @@
+ALTER TABLE "post_scores" DROP COLUMN IF EXISTS "dirty";
+ALTER TABLE "post_reactions" DROP COLUMN IF EXISTS "updated_at";
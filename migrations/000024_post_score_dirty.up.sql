@@ -0,0 +1,7 @@
+-- a changed reaction keeps its created_at, so the score refresher compares updated_at instead
+ALTER TABLE "post_reactions" ADD COLUMN "updated_at" timestamptz NOT NULL DEFAULT (now());
+
+UPDATE "post_reactions" SET "updated_at" = "created_at";
+
+-- removed reactions and restored comments leave no timestamp behind, they mark the score of their post dirty
+ALTER TABLE "post_scores" ADD COLUMN "dirty" boolean NOT NULL DEFAULT false;
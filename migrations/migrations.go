@@ -0,0 +1,9 @@
+// Package migrations holds the database migrations, embedded so the binary runs them from anywhere.
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named as golang-migrate expects them.
+//
+//go:embed *.sql
+var FS embed.FS
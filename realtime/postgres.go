@@ -0,0 +1,95 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// the channel the instances notify each other on
+	postgresChannel = "realtime_events"
+	// notification payloads have to stay under 8000 bytes
+	maxPostgresPayload = 7900
+	// how long to wait before reconnecting a lost listener, doubling up to the maximum
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// how often an idle listener checks its connection is still up
+	listenerPingInterval = 90 * time.Second
+)
+
+// PostgresBroker carries events between the instances with LISTEN and NOTIFY on the database they share.
+// Events raised while a listener is reconnecting are lost to its instance.
+type PostgresBroker struct {
+	dsn string
+	db  *sql.DB
+}
+
+// NewPostgresBroker notifies through the connection pool of the store, db, so it shares its limits and is
+// closed with it. Only the listener holds a connection of its own to dsn.
+func NewPostgresBroker(dsn string, db *sql.DB) *PostgresBroker {
+	return &PostgresBroker{dsn: dsn, db: db}
+}
+
+func (b *PostgresBroker) Publish(ctx context.Context, event Event) error {
+	payload, err := notificationPayload(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", postgresChannel, string(payload))
+	return err
+}
+
+// notificationPayload encodes the event for NOTIFY. The data of an event too large to notify is left out,
+// its clients are told something happened and fetch it themselves.
+func notificationPayload(event Event) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil || len(payload) <= maxPostgresPayload {
+		return payload, err
+	}
+
+	event.Data = nil
+	return json.Marshal(event)
+}
+
+func (b *PostgresBroker) Listen(ctx context.Context, deliver func(Event)) error {
+	listener := pq.NewListener(b.dsn, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("realtime listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(postgresChannel); err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// nil after the connection was re-established
+			if notification == nil {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				log.Printf("realtime listener: cannot decode event: %v", err)
+				continue
+			}
+			deliver(event)
+		case <-ping.C:
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package realtime pushes events to the open connections of each user. A Hub
+// fans events out to the connections it holds, a Broker carries them between
+// the server instances so a user connected to one replica hears about events
+// raised on another.
+package realtime
+
+import (
+	"cnfs/config"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// the number of events a connection can fall behind by before it is dropped
+const subscriptionBuffer = 32
+
+type Event struct {
+	// the user the event is for
+	UserID uuid.UUID `json:"user_id"`
+	Type   string    `json:"type"`
+	// the event as the clients get it, null when it was too large to carry between instances
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker carries the events published on any instance to the hubs of every instance.
+type Broker interface {
+	// Publish sends the event to every listening instance, this one included.
+	Publish(ctx context.Context, event Event) error
+	// Listen calls deliver with every published event until ctx is cancelled.
+	Listen(ctx context.Context, deliver func(Event)) error
+}
+
+// Subscription is one open connection of a user.
+type Subscription struct {
+	hub    *Hub
+	userId uuid.UUID
+	events chan Event
+}
+
+// Events receives the events of the user, it is closed when the subscription is closed or falls behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the events and releases the subscription, it can be called more than once.
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+type Hub struct {
+	broker Broker
+
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// NewHub builds a hub publishing through broker, a nil broker keeps the events on this instance.
+func NewHub(broker Broker) *Hub {
+	return &Hub{
+		broker:        broker,
+		subscriptions: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// New builds the hub selected by REALTIME_BROKER, the postgres broker publishes through conn.
+func New(cfg *config.Config, conn *sql.DB) (*Hub, error) {
+	switch cfg.RealtimeBroker {
+	case "", "local":
+		return NewHub(nil), nil
+	case "postgres":
+		if conn == nil {
+			return nil, errors.New("the postgres realtime broker needs a postgres database")
+		}
+		return NewHub(NewPostgresBroker(cfg.DatabaseUrl, conn)), nil
+	default:
+		return nil, fmt.Errorf("unknown realtime broker %q", cfg.RealtimeBroker)
+	}
+}
+
+// Run delivers the events of the broker to the subscriptions of this instance until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) error {
+	if h.broker == nil {
+		<-ctx.Done()
+		return nil
+	}
+	return h.broker.Listen(ctx, h.deliver)
+}
+
+// Subscribe opens a subscription to the events of the user.
+func (h *Hub) Subscribe(userId uuid.UUID) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		userId: userId,
+		events: make(chan Event, subscriptionBuffer),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscriptions[userId] == nil {
+		h.subscriptions[userId] = make(map[*Subscription]struct{})
+	}
+	h.subscriptions[userId][sub] = struct{}{}
+
+	return sub
+}
+
+// Connections is the number of open subscriptions of the user on this instance.
+func (h *Hub) Connections(userId uuid.UUID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subscriptions[userId])
+}
+
+// Publish sends an event of the given type to every connection of the user, on any instance.
+func (h *Hub) Publish(ctx context.Context, userId uuid.UUID, kind string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := Event{UserID: userId, Type: kind, Data: payload}
+	if h.broker == nil {
+		h.deliver(event)
+		return nil
+	}
+	return h.broker.Publish(ctx, event)
+}
+
+// deliver hands the event to the subscriptions of its user. A subscription whose buffer is full is closed
+// rather than waited for, its client reconnects and catches up through the API.
+func (h *Hub) deliver(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscriptions[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeLocked(sub)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs, ok := h.subscriptions[sub.userId]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscriptions, sub.userId)
+	}
+	close(sub.events)
+}
@@ -0,0 +1,166 @@
+package realtime
+
+import (
+	"cnfs/config"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// loopbackBroker stands in for the database, what is published is delivered to every listener.
+type loopbackBroker struct {
+	mu        sync.Mutex
+	listeners []func(Event)
+	ready     chan struct{}
+}
+
+func (b *loopbackBroker) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, deliver := range b.listeners {
+		deliver(event)
+	}
+	return nil
+}
+
+func (b *loopbackBroker) Listen(ctx context.Context, deliver func(Event)) error {
+	b.mu.Lock()
+	b.listeners = append(b.listeners, deliver)
+	b.mu.Unlock()
+	close(b.ready)
+
+	<-ctx.Done()
+	return nil
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	select {
+	case event := <-sub.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := NewHub(nil)
+	userId := uuid.New()
+
+	first := hub.Subscribe(userId)
+	second := hub.Subscribe(userId)
+	other := hub.Subscribe(uuid.New())
+	require.Equal(t, 2, hub.Connections(userId))
+
+	require.NoError(t, hub.Publish(context.Background(), userId, "message", map[string]string{"content": "hi"}))
+
+	for _, sub := range []*Subscription{first, second} {
+		event := receive(t, sub)
+		require.Equal(t, userId, event.UserID)
+		require.Equal(t, "message", event.Type)
+		require.JSONEq(t, `{"content": "hi"}`, string(event.Data))
+	}
+	require.Empty(t, other.Events())
+
+	first.Close()
+	first.Close()
+	_, open := <-first.Events()
+	require.False(t, open)
+
+	require.NoError(t, hub.Publish(context.Background(), userId, "comment", nil))
+	require.Equal(t, "comment", receive(t, second).Type)
+	second.Close()
+	other.Close()
+	require.Empty(t, hub.subscriptions)
+}
+
+func TestHubDropsSlowSubscriptions(t *testing.T) {
+	hub := NewHub(nil)
+	userId := uuid.New()
+	sub := hub.Subscribe(userId)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		require.NoError(t, hub.Publish(context.Background(), userId, "message", i))
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	require.Equal(t, subscriptionBuffer, received)
+	require.Empty(t, hub.subscriptions)
+}
+
+func TestHubBroker(t *testing.T) {
+	broker := &loopbackBroker{ready: make(chan struct{})}
+	hub := NewHub(broker)
+	userId := uuid.New()
+	sub := hub.Subscribe(userId)
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- hub.Run(ctx) }()
+	<-broker.ready
+
+	require.NoError(t, hub.Publish(ctx, userId, "notification", map[string]int{"count": 12}))
+	require.JSONEq(t, `{"count": 12}`, string(receive(t, sub).Data))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestNew(t *testing.T) {
+	hub, err := New(&config.Config{RealtimeBroker: "local"}, nil)
+	require.NoError(t, err)
+	require.Nil(t, hub.broker)
+
+	dsn := "postgresql://localhost/cnfs"
+	conn, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	hub, err = New(&config.Config{RealtimeBroker: "postgres", DatabaseUrl: dsn}, conn)
+	require.NoError(t, err)
+	require.IsType(t, &PostgresBroker{}, hub.broker)
+	// the broker publishes through the pool of the store rather than one of its own
+	require.Same(t, conn, hub.broker.(*PostgresBroker).db)
+
+	_, err = New(&config.Config{RealtimeBroker: "postgres", DatabaseUrl: dsn}, nil)
+	require.Error(t, err)
+
+	_, err = New(&config.Config{RealtimeBroker: "redis"}, nil)
+	require.Error(t, err)
+}
+
+func TestNotificationPayload(t *testing.T) {
+	event := Event{UserID: uuid.New(), Type: "message", Data: json.RawMessage(`{"content": "hi"}`)}
+
+	payload, err := notificationPayload(event)
+	require.NoError(t, err)
+
+	var decoded Event
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, event.UserID, decoded.UserID)
+	require.JSONEq(t, string(event.Data), string(decoded.Data))
+
+	event.Data, err = json.Marshal(strings.Repeat("a", maxPostgresPayload))
+	require.NoError(t, err)
+
+	payload, err = notificationPayload(event)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(payload), maxPostgresPayload)
+
+	decoded = Event{}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	require.Equal(t, "message", decoded.Type)
+	require.Equal(t, "null", string(decoded.Data))
+}
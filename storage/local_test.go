@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "thisisaverysecretstoragesigningkey"
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost/media/", testSecret)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "posts/a/b.jpg", strings.NewReader("hello"), 5, "image/jpeg"))
+
+	r, err := store.Get(ctx, "posts/a/b.jpg")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "hello", string(data))
+
+	require.Equal(t, "http://localhost/media/posts/a/b.jpg", store.URL("posts/a/b.jpg"))
+
+	require.NoError(t, store.Delete(ctx, "posts/a/b.jpg"))
+	require.NoError(t, store.Delete(ctx, "posts/a/b.jpg"))
+
+	_, err = store.Get(ctx, "posts/a/b.jpg")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir(), "", testSecret)
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../b", "a//b", `a\b`} {
+		require.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x"), 1, ""), ErrInvalidKey, key)
+		_, err := store.Get(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "http://localhost/media", testSecret)
+	require.NoError(t, err)
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	signed, err := store.SignedURL("private/m.png", time.Minute)
+	require.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	require.Equal(t, "/media/private/m.png", u.Path)
+
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	require.NoError(t, store.Verify("private/m.png", expires, signature))
+
+	require.ErrorIs(t, store.Verify("private/other.png", expires, signature), ErrInvalidSignature)
+	require.ErrorIs(t, store.Verify("private/m.png", expires, "00"+signature[2:]), ErrInvalidSignature)
+	require.ErrorIs(t, store.Verify("private/m.png", "nope", signature), ErrInvalidSignature)
+
+	store.now = func() time.Time { return now.Add(2 * time.Minute) }
+	require.ErrorIs(t, store.Verify("private/m.png", expires, signature), ErrInvalidSignature)
+}
@@ -0,0 +1,80 @@
+// Package storage keeps uploaded blobs behind a small interface so the
+// server can run against the local filesystem or any S3-compatible service.
+package storage
+
+import (
+	"cnfs/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("object not found")
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+type Storage interface {
+	// Put stores the content under key, replacing any existing object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, the caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object, deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the permanent public address of the object.
+	URL(key string) string
+	// SignedURL returns an address that grants access to the object until ttl elapses.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// Verifier is implemented by backends whose signed URLs are served by this
+// application rather than by the storage service itself.
+type Verifier interface {
+	Verify(key, expires, signature string) error
+}
+
+// New builds the backend selected by STORAGE_BACKEND.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "", "local":
+		return NewLocalStorage(cfg.StorageLocalDir, cfg.StoragePublicUrl, cfg.StorageSigningKey)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicUrl: cfg.StoragePublicUrl,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func sign(secret []byte, key string, expires int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
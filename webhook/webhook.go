@@ -0,0 +1,157 @@
+// Package webhook sends events to the endpoints users registered for them. Each delivery is signed with the
+// secret of its endpoint so the receiver can tell it came from us and was not replayed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// the headers a delivery carries besides its content type
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	userAgent = "cnfs-webhooks/1"
+	// the first retry waits this long, each one after it twice as long as the last
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+	// how much of a failed response body is kept as the error of the attempt
+	maxErrorBody = 512
+)
+
+// ErrPrivateAddress is returned for endpoints resolving to an address inside our own network.
+var ErrPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+
+// Delivery is one event to send to an endpoint.
+type Delivery struct {
+	ID     uuid.UUID
+	Event  string
+	URL    string
+	Secret string
+	// the JSON body of the request
+	Payload []byte
+}
+
+// NewSecret makes the secret a new endpoint signs its deliveries with.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign is the signature of a payload sent at timestamp, the hex HMAC-SHA256 of "<timestamp>.<payload>"
+// prefixed with the scheme. Receivers compute it the same way and reject stale timestamps.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign.
+func Verify(secret string, timestamp int64, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
+
+// Backoff is how long to wait before retrying a delivery that failed attempts times.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+// NewClient builds the client deliveries are sent with. Redirects are not followed, and unless allowPrivate
+// is set, neither are connections to loopback, private or link local addresses, checked once the name is
+// resolved so an endpoint cannot point us at our own network.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// Send posts the delivery to its endpoint. The status is the one the endpoint answered with, 0 when it could
+// not be reached, and err is set unless it was a 2xx.
+func Send(ctx context.Context, client *http.Client, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, d.ID.String())
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, d.Payload))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+	// drain the rest so the connection can be reused
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("endpoint answered %s: %s", res.Status, bytes.TrimSpace(body))
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"message.created"}`)
+
+	signature := Sign("whsec_test", 1700000000, payload)
+	require.True(t, strings.HasPrefix(signature, "sha256="))
+	require.Len(t, signature, len("sha256=")+64)
+
+	require.True(t, Verify("whsec_test", 1700000000, payload, signature))
+	require.False(t, Verify("whsec_other", 1700000000, payload, signature))
+	require.False(t, Verify("whsec_test", 1700000001, payload, signature))
+	require.False(t, Verify("whsec_test", 1700000000, []byte(`{}`), signature))
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	require.NoError(t, err)
+	second, err := NewSecret()
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(first, "whsec_"))
+	require.NotEqual(t, first, second)
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 30*time.Second, Backoff(1))
+	require.Equal(t, time.Minute, Backoff(2))
+	require.Equal(t, 4*time.Minute, Backoff(4))
+	require.Equal(t, maxBackoff, Backoff(20))
+	require.Equal(t, maxBackoff, Backoff(1000))
+}
+
+func TestSend(t *testing.T) {
+	delivery := Delivery{
+		ID:      uuid.New(),
+		Event:   "comment.created",
+		Secret:  "whsec_test",
+		Payload: []byte(`{"event":"comment.created","data":{}}`),
+	}
+
+	received := make(chan *http.Request, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, delivery.Payload, body)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		require.True(t, Verify(delivery.Secret, timestamp, body, r.Header.Get(HeaderSignature)))
+
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+	delivery.URL = endpoint.URL
+
+	status, err := Send(context.Background(), NewClient(time.Second, true), delivery)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, status)
+
+	r := <-received
+	require.Equal(t, delivery.ID.String(), r.Header.Get(HeaderID))
+	require.Equal(t, "comment.created", r.Header.Get(HeaderEvent))
+	require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+}
+
+func TestSendFailure(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			http.Error(w, "bot is down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer endpoint.Close()
+
+	client := NewClient(time.Second, true)
+
+	status, err := Send(context.Background(), client, Delivery{URL: endpoint.URL})
+	require.Error(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Contains(t, err.Error(), "bot is down")
+
+	status, err = Send(context.Background(), client, Delivery{URL: endpoint.URL + "/redirect"})
+	require.Error(t, err)
+	require.Equal(t, http.StatusFound, status)
+}
+
+func TestSendPrivateAddress(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("a private address was reached")
+	}))
+	defer endpoint.Close()
+
+	status, err := Send(context.Background(), NewClient(time.Second, false), Delivery{URL: endpoint.URL})
+	require.True(t, errors.Is(err, ErrPrivateAddress), err)
+	require.Zero(t, status)
+}
@@ -0,0 +1,292 @@
+// Package webpush sends notifications to the push services of browsers. Payloads are encrypted for the
+// subscription as in RFC 8291 and requests are signed with the VAPID keys of the server as in RFC 8292, so
+// the push service can neither read them nor take them for someone else's.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// the record size of the encrypted content, a payload always fits in one record
+	recordSize = 4096
+	// what is left of the 4096 bytes push services accept once the header, padding delimiter and tag are in
+	MaxPayloadSize = recordSize - headerSize - 1 - tagSize
+	headerSize     = 16 + 4 + 1 + publicKeySize
+	tagSize        = 16
+	publicKeySize  = 65
+	authSecretSize = 16
+	// how long a VAPID token stays valid, push services refuse ones over 24 hours
+	tokenDuration = 12 * time.Hour
+	// how much of a failed response body is kept in the error
+	maxErrorBody = 512
+)
+
+var (
+	// ErrGone is returned when the push service no longer knows the subscription, it should be removed.
+	ErrGone = errors.New("push subscription is gone")
+	// ErrPayloadTooLarge is returned for payloads over MaxPayloadSize.
+	ErrPayloadTooLarge = errors.New("push payload is too large")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Subscription is where a browser gets its pushes, as the PushSubscription of the browser hands it out.
+type Subscription struct {
+	Endpoint string
+	// the public key of the browser, base64url encoded
+	P256dh string
+	// the authentication secret of the browser, base64url encoded
+	Auth string
+}
+
+// Validate checks the keys of the subscription decode to a P-256 point and a secret of the right size.
+func (s Subscription) Validate() error {
+	_, _, err := s.keys()
+	return err
+}
+
+func (s Subscription) keys() (*ecdsa.PublicKey, []byte, error) {
+	uaPublic, err := decodeKey(s.P256dh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), uaPublic)
+	if x == nil {
+		return nil, nil, errors.New("invalid p256dh key: not a P-256 point")
+	}
+
+	auth, err := decodeKey(s.Auth)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	if len(auth) != authSecretSize {
+		return nil, nil, errors.New("invalid auth secret: not 16 bytes")
+	}
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, auth, nil
+}
+
+// decodeKey reads a base64url key, browsers leave the padding out but some libraries keep it.
+func decodeKey(key string) ([]byte, error) {
+	if b, err := encoding.DecodeString(key); err == nil {
+		return b, nil
+	}
+	return base64.URLEncoding.DecodeString(key)
+}
+
+// Encrypt encrypts the payload for the subscription with the aes128gcm content coding of RFC 8188, keyed as
+// RFC 8291 describes from a fresh key pair and the keys of the browser.
+func Encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublic, authSecret, err := sub.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	asPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(elliptic.P256(), asPrivate.X, asPrivate.Y)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	sharedX, _ := elliptic.P256().ScalarMult(uaPublic.X, uaPublic.Y, asPrivate.D.Bytes())
+	ecdhSecret := sharedX.FillBytes(make([]byte, 32))
+
+	gcm, nonce, err := contentCipher(ecdhSecret, authSecret, elliptic.Marshal(elliptic.P256(), uaPublic.X, uaPublic.Y), asPublic, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, publicKeySize)
+	header = append(header, asPublic...)
+
+	// the single record is the last one, marked by the 0x02 padding delimiter
+	plaintext := append(append(make([]byte, 0, len(payload)+1), payload...), 2)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// contentCipher derives the content encryption key and nonce of a message, the same on both ends.
+func contentCipher(ecdhSecret, authSecret, uaPublic, asPublic, salt []byte) (cipher.AEAD, []byte, error) {
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := expand(hkdf.New(sha256.New, ecdhSecret, authSecret, keyInfo), 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cek, err := expand(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: aes128gcm\x00")), 16)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := expand(hkdf.New(sha256.New, ikm, salt, []byte("Content-Encoding: nonce\x00")), 12)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gcm, nonce, nil
+}
+
+func expand(r io.Reader, size int) ([]byte, error) {
+	b := make([]byte, size)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+// VAPID identifies the server to push services.
+type VAPID struct {
+	privateKey *ecdsa.PrivateKey
+	// PublicKey is the uncompressed public key, base64url encoded, browsers subscribe with it as the
+	// applicationServerKey.
+	PublicKey string
+	// Subject is a mailto: or https: url push services can reach the operator of the server at.
+	Subject string
+}
+
+// NewVAPID reads a key pair in the base64url form web-push libraries generate, a raw 32 byte private key
+// and an uncompressed public key.
+func NewVAPID(publicKey, privateKey, subject string) (*VAPID, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("invalid VAPID private key")
+	}
+
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: elliptic.P256()}, D: new(big.Int).SetBytes(d)}
+	key.X, key.Y = elliptic.P256().ScalarBaseMult(d)
+
+	public, err := decodeKey(publicKey)
+	if err != nil || !bytes.Equal(public, elliptic.Marshal(elliptic.P256(), key.X, key.Y)) {
+		return nil, errors.New("the VAPID public key does not belong to the private key")
+	}
+
+	return &VAPID{privateKey: key, PublicKey: encoding.EncodeToString(public), Subject: subject}, nil
+}
+
+// GenerateVAPIDKeys makes a new key pair for NewVAPID.
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return encoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)),
+		encoding.EncodeToString(key.D.FillBytes(make([]byte, 32))), nil
+}
+
+// Authorization is the header value signing a request to the push service at endpoint, a JWT for the
+// origin of the endpoint signed with ES256 followed by the public key.
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header := encoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(tokenDuration).Unix(),
+		"sub": v.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, v.privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS wants r and s as fixed size big endian halves, not ASN.1
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, encoding.EncodeToString(signature), v.PublicKey), nil
+}
+
+// Sender delivers pushes with one set of VAPID keys.
+type Sender struct {
+	VAPID  *VAPID
+	Client *http.Client
+	// how long the push service keeps a push for a browser that is offline
+	TTL time.Duration
+}
+
+// Send encrypts the payload and hands it to the push service of the subscription. It returns ErrGone when
+// the push service answers 404 or 410, the subscription expired or was unsubscribed.
+func (s *Sender) Send(ctx context.Context, sub Subscription, payload []byte) error {
+	body, err := Encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.VAPID.Authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(s.TTL.Seconds())))
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrGone
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return fmt.Errorf("push service answered %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
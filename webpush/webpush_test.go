@@ -0,0 +1,226 @@
+package webpush
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// browser holds the keys a browser subscribes with, it decrypts what is pushed to it.
+type browser struct {
+	key  *ecdsa.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	auth := make([]byte, authSecretSize)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) Subscription {
+	return Subscription{
+		Endpoint: endpoint,
+		P256dh:   encoding.EncodeToString(elliptic.Marshal(elliptic.P256(), b.key.X, b.key.Y)),
+		Auth:     encoding.EncodeToString(b.auth),
+	}
+}
+
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	require.Greater(t, len(body), headerSize)
+
+	salt := body[:16]
+	require.Equal(t, uint32(recordSize), binary.BigEndian.Uint32(body[16:20]))
+	require.Equal(t, byte(publicKeySize), body[20])
+	asPublic := body[21:headerSize]
+
+	x, y := elliptic.Unmarshal(elliptic.P256(), asPublic)
+	require.NotNil(t, x)
+	sharedX, _ := elliptic.P256().ScalarMult(x, y, b.key.D.Bytes())
+
+	gcm, nonce, err := contentCipher(sharedX.FillBytes(make([]byte, 32)), b.auth, elliptic.Marshal(elliptic.P256(), b.key.X, b.key.Y), asPublic, salt)
+	require.NoError(t, err)
+
+	plaintext, err := gcm.Open(nil, nonce, body[headerSize:], nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(2), plaintext[len(plaintext)-1])
+
+	return plaintext[:len(plaintext)-1]
+}
+
+func newVAPID(t *testing.T) *VAPID {
+	public, private, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+
+	vapid, err := NewVAPID(public, private, "mailto:ops@cnfs.example")
+	require.NoError(t, err)
+	return vapid
+}
+
+func TestEncrypt(t *testing.T) {
+	b := newBrowser(t)
+	sub := b.subscription("https://push.example/send/1")
+
+	first, err := Encrypt(sub, []byte(`{"type":"message"}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"message"}`, string(b.decrypt(t, first)))
+
+	// every push is keyed afresh
+	second, err := Encrypt(sub, []byte(`{"type":"message"}`))
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	full, err := Encrypt(sub, make([]byte, MaxPayloadSize))
+	require.NoError(t, err)
+	require.Len(t, full, recordSize)
+
+	_, err = Encrypt(sub, make([]byte, MaxPayloadSize+1))
+	require.ErrorIs(t, err, ErrPayloadTooLarge)
+}
+
+// the example of RFC 8291 appendix A, decrypted with the keys of the browser
+func TestEncryptionVector(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := encoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+
+	d := decode("q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94")
+	key := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: elliptic.P256()}, D: new(big.Int).SetBytes(d)}
+	key.X, key.Y = elliptic.P256().ScalarBaseMult(d)
+	b := &browser{key: key, auth: decode("BTBZMqHH6r4Tts7J_aSIgg")}
+	require.Equal(t, "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4", b.subscription("").P256dh)
+
+	body := decode("DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN")
+	require.Equal(t, "When I grow up, I want to be a watermelon", string(b.decrypt(t, body)))
+}
+
+func TestSubscriptionValidate(t *testing.T) {
+	sub := newBrowser(t).subscription("https://push.example/send/1")
+	require.NoError(t, sub.Validate())
+
+	badKey := sub
+	badKey.P256dh = encoding.EncodeToString(make([]byte, publicKeySize))
+	require.Error(t, badKey.Validate())
+
+	badAuth := sub
+	badAuth.Auth = encoding.EncodeToString([]byte("short"))
+	require.Error(t, badAuth.Validate())
+
+	notBase64 := sub
+	notBase64.Auth = "not base64!"
+	require.Error(t, notBase64.Validate())
+}
+
+func TestNewVAPID(t *testing.T) {
+	public, private, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+
+	vapid, err := NewVAPID(public, private, "mailto:ops@cnfs.example")
+	require.NoError(t, err)
+	require.Equal(t, public, vapid.PublicKey)
+
+	otherPublic, _, err := GenerateVAPIDKeys()
+	require.NoError(t, err)
+	_, err = NewVAPID(otherPublic, private, "mailto:ops@cnfs.example")
+	require.Error(t, err)
+
+	_, err = NewVAPID(public, "short", "mailto:ops@cnfs.example")
+	require.Error(t, err)
+}
+
+func TestAuthorization(t *testing.T) {
+	vapid := newVAPID(t)
+	now := time.Now()
+
+	header, err := vapid.Authorization("https://fcm.googleapis.com/fcm/send/abc", now)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(header, "vapid t="))
+
+	parts := strings.SplitN(strings.TrimPrefix(header, "vapid t="), ", k=", 2)
+	require.Len(t, parts, 2)
+	require.Equal(t, vapid.PublicKey, parts[1])
+
+	jwt := strings.Split(parts[0], ".")
+	require.Len(t, jwt, 3)
+
+	claims, err := encoding.DecodeString(jwt[1])
+	require.NoError(t, err)
+	var decoded struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	require.NoError(t, json.Unmarshal(claims, &decoded))
+	require.Equal(t, "https://fcm.googleapis.com", decoded.Aud)
+	require.Equal(t, now.Add(tokenDuration).Unix(), decoded.Exp)
+	require.Equal(t, "mailto:ops@cnfs.example", decoded.Sub)
+
+	signature, err := encoding.DecodeString(jwt[2])
+	require.NoError(t, err)
+	require.Len(t, signature, 64)
+
+	digest := sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	require.True(t, ecdsa.Verify(&vapid.privateKey.PublicKey, digest[:], r, s))
+}
+
+func TestSend(t *testing.T) {
+	b := newBrowser(t)
+
+	// stands in for the push service, answering with the status in the path
+	received := make(chan []byte, 1)
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "86400", r.Header.Get("TTL"))
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "vapid t="))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		switch r.URL.Path {
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/unknown":
+			w.WriteHeader(http.StatusNotFound)
+		case "/busy":
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			received <- body
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer service.Close()
+
+	sender := &Sender{VAPID: newVAPID(t), Client: service.Client(), TTL: 24 * time.Hour}
+
+	require.NoError(t, sender.Send(context.Background(), b.subscription(service.URL+"/send"), []byte(`{"count":3}`)))
+	require.JSONEq(t, `{"count":3}`, string(b.decrypt(t, <-received)))
+
+	require.ErrorIs(t, sender.Send(context.Background(), b.subscription(service.URL+"/gone"), []byte(`{}`)), ErrGone)
+	require.ErrorIs(t, sender.Send(context.Background(), b.subscription(service.URL+"/unknown"), []byte(`{}`)), ErrGone)
+
+	err := sender.Send(context.Background(), b.subscription(service.URL+"/busy"), []byte(`{}`))
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrGone)
+	require.Contains(t, err.Error(), "slow down")
+}